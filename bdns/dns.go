@@ -1,9 +1,15 @@
 package bdns
 
 import (
+	"bytes"
+	"crypto/tls"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"math/rand"
 	"net"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"sync"
@@ -154,6 +160,8 @@ type DNSClient interface {
 // DNSClientImpl represents a client that talks to an external resolver
 type DNSClientImpl struct {
 	dnsClient                exchanger
+	dotClient                exchanger
+	dohClient                exchanger
 	servers                  []string
 	allowRestrictedAddresses bool
 	maxTries                 int
@@ -170,14 +178,87 @@ type exchanger interface {
 	Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error)
 }
 
+const (
+	// dotPrefix marks a server address as a DNS-over-TLS (RFC 7858) resolver,
+	// e.g. "tls://10.0.0.1:853".
+	dotPrefix = "tls://"
+	// dohPrefix marks a server address as a DNS-over-HTTPS (RFC 8484) resolver.
+	// The whole address is used as the URL queries are POSTed to, e.g.
+	// "https://resolver.example.com/dns-query".
+	dohPrefix = "https://"
+
+	dohMediaType = "application/dns-message"
+)
+
+// dohExchanger sends DNS queries to a DNS-over-HTTPS resolver using the POST
+// method described in RFC 8484 Section 4.1.
+type dohExchanger struct {
+	client *http.Client
+}
+
+func (d *dohExchanger) Exchange(m *dns.Msg, a string) (*dns.Msg, time.Duration, error) {
+	packed, err := m.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+	req, err := http.NewRequest("POST", a, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", dohMediaType)
+	req.Header.Set("Accept", dohMediaType)
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		// Unwrap network errors so that exchangeOne can retry temporary
+		// failures in the same way as it does for classic DNS.
+		if urlErr, ok := err.(*url.Error); ok {
+			if opErr, ok := urlErr.Err.(*net.OpError); ok {
+				return nil, 0, opErr
+			}
+		}
+		return nil, 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("DoH resolver returned HTTP status %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != dohMediaType {
+		return nil, 0, fmt.Errorf("DoH resolver returned unexpected Content-Type %q", ct)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, dns.MaxMsgSize))
+	if err != nil {
+		return nil, 0, err
+	}
+	rtt := time.Since(start)
+
+	r := new(dns.Msg)
+	err = r.Unpack(body)
+	if err != nil {
+		return nil, rtt, err
+	}
+	if r.Id != m.Id {
+		return nil, rtt, dns.ErrId
+	}
+	return r, rtt, nil
+}
+
 // NewDNSClientImpl constructs a new DNS resolver object that utilizes the
-// provided list of DNS servers for resolution.
+// provided list of DNS servers for resolution. Servers given as plain
+// "host:port" addresses are queried over UDP. Servers prefixed with "tls://"
+// are queried using DNS-over-TLS and servers given as "https://" URLs using
+// DNS-over-HTTPS, both verified against tlsConfig, which may be nil to use
+// the system roots.
 func NewDNSClientImpl(
 	readTimeout time.Duration,
 	servers []string,
 	stats metrics.Scope,
 	clk clock.Clock,
 	maxTries int,
+	tlsConfig *tls.Config,
 ) *DNSClientImpl {
 	stats = stats.NewScope("DNS")
 	// TODO(jmhodges): make constructor use an Option func pattern
@@ -187,6 +268,21 @@ func NewDNSClientImpl(
 	dnsClient.ReadTimeout = readTimeout
 	dnsClient.Net = "udp"
 
+	dotClient := &dns.Client{
+		Net:         "tcp-tls",
+		ReadTimeout: readTimeout,
+		TLSConfig:   tlsConfig,
+	}
+	dohClient := &dohExchanger{
+		client: &http.Client{
+			Timeout: readTimeout,
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: tlsConfig,
+			},
+		},
+	}
+
 	queryTime := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "dns_query_time",
@@ -214,6 +310,8 @@ func NewDNSClientImpl(
 
 	return &DNSClientImpl{
 		dnsClient:                dnsClient,
+		dotClient:                dotClient,
+		dohClient:                dohClient,
 		servers:                  servers,
 		allowRestrictedAddresses: false,
 		maxTries:                 maxTries,
@@ -227,12 +325,25 @@ func NewDNSClientImpl(
 // NewTestDNSClientImpl constructs a new DNS resolver object that utilizes the
 // provided list of DNS servers for resolution and will allow loopback addresses.
 // This constructor should *only* be called from tests (unit or integration).
-func NewTestDNSClientImpl(readTimeout time.Duration, servers []string, stats metrics.Scope, clk clock.Clock, maxTries int) *DNSClientImpl {
-	resolver := NewDNSClientImpl(readTimeout, servers, stats, clk, maxTries)
+func NewTestDNSClientImpl(readTimeout time.Duration, servers []string, stats metrics.Scope, clk clock.Clock, maxTries int, tlsConfig *tls.Config) *DNSClientImpl {
+	resolver := NewDNSClientImpl(readTimeout, servers, stats, clk, maxTries, tlsConfig)
 	resolver.allowRestrictedAddresses = true
 	return resolver
 }
 
+// exchangerFor returns the exchanger used to query the given server, chosen
+// by its scheme, along with the address to pass to it.
+func (dnsClient *DNSClientImpl) exchangerFor(server string) (exchanger, string) {
+	switch {
+	case strings.HasPrefix(server, dotPrefix):
+		return dnsClient.dotClient, strings.TrimPrefix(server, dotPrefix)
+	case strings.HasPrefix(server, dohPrefix):
+		return dnsClient.dohClient, server
+	default:
+		return dnsClient.dnsClient, server
+	}
+}
+
 // exchangeOne performs a single DNS exchange with a randomly chosen server
 // out of the server list, returning the response, time, and error (if any).
 // We assume that the upstream resolver requests and validates DNSSEC records
@@ -262,7 +373,6 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 	chosenServer := dnsClient.servers[chosenServerIndex]
 
 	start := dnsClient.clk.Now()
-	client := dnsClient.dnsClient
 	qtypeStr := dns.TypeToString[qtype]
 	tries := 1
 	defer func() {
@@ -281,9 +391,11 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 	}()
 	for {
 		ch := make(chan dnsResp, 1)
+		client, addr := dnsClient.exchangerFor(chosenServer)
+		resolver := chosenServer
 
 		go func() {
-			rsp, rtt, err := client.Exchange(m, chosenServer)
+			rsp, rtt, err := client.Exchange(m, addr)
 			result, authenticated := "failed", ""
 			if rsp != nil {
 				result = dns.RcodeToString[rsp.Rcode]
@@ -293,7 +405,7 @@ func (dnsClient *DNSClientImpl) exchangeOne(ctx context.Context, hostname string
 				"qtype":              qtypeStr,
 				"result":             result,
 				"authenticated_data": authenticated,
-				"resolver":           chosenServer,
+				"resolver":           resolver,
 			}).Observe(rtt.Seconds())
 			ch <- dnsResp{m: rsp, err: err}
 		}()
@@ -1,10 +1,20 @@
 package bdns
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"math/big"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"sync"
@@ -247,7 +257,7 @@ func newTestStats() metrics.Scope {
 var testStats = newTestStats()
 
 func TestDNSNoServers(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Hour, []string{}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Hour, []string{}, testStats, clock.NewFake(), 1, nil)
 
 	_, err := obj.LookupHost(context.Background(), "letsencrypt.org")
 
@@ -255,7 +265,7 @@ func TestDNSNoServers(t *testing.T) {
 }
 
 func TestDNSOneServer(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	_, err := obj.LookupHost(context.Background(), "letsencrypt.org")
 
@@ -263,7 +273,7 @@ func TestDNSOneServer(t *testing.T) {
 }
 
 func TestDNSDuplicateServers(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr, dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr, dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	_, err := obj.LookupHost(context.Background(), "letsencrypt.org")
 
@@ -271,7 +281,7 @@ func TestDNSDuplicateServers(t *testing.T) {
 }
 
 func TestDNSLookupsNoServer(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{}, testStats, clock.NewFake(), 1, nil)
 
 	_, _, err := obj.LookupTXT(context.Background(), "letsencrypt.org")
 	test.AssertError(t, err, "No servers")
@@ -284,7 +294,7 @@ func TestDNSLookupsNoServer(t *testing.T) {
 }
 
 func TestDNSServFail(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 	bad := "servfail.com"
 
 	_, _, err := obj.LookupTXT(context.Background(), bad)
@@ -299,7 +309,7 @@ func TestDNSServFail(t *testing.T) {
 }
 
 func TestDNSLookupTXT(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	a, _, err := obj.LookupTXT(context.Background(), "letsencrypt.org")
 	t.Logf("A: %v", a)
@@ -313,7 +323,7 @@ func TestDNSLookupTXT(t *testing.T) {
 }
 
 func TestDNSLookupHost(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	ip, err := obj.LookupHost(context.Background(), "servfail.com")
 	t.Logf("servfail.com - IP: %s, Err: %s", ip, err)
@@ -380,7 +390,7 @@ func TestDNSLookupHost(t *testing.T) {
 }
 
 func TestDNSNXDOMAIN(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	hostname := "nxdomain.letsencrypt.org"
 	_, err := obj.LookupHost(context.Background(), hostname)
@@ -397,7 +407,7 @@ func TestDNSNXDOMAIN(t *testing.T) {
 }
 
 func TestDNSLookupCAA(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	caas, err := obj.LookupCAA(context.Background(), "bracewel.net")
 	test.AssertNotError(t, err, "CAA lookup failed")
@@ -413,7 +423,7 @@ func TestDNSLookupCAA(t *testing.T) {
 }
 
 func TestDNSTXTAuthorities(t *testing.T) {
-	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1)
+	obj := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 1, nil)
 
 	_, auths, err := obj.LookupTXT(context.Background(), "letsencrypt.org")
 
@@ -594,7 +604,7 @@ func TestRetry(t *testing.T) {
 	}
 
 	for i, tc := range tests {
-		dr := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), tc.maxTries)
+		dr := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), tc.maxTries, nil)
 		dr.dnsClient = tc.te
 		_, _, err := dr.LookupTXT(context.Background(), "example.com")
 		if err == errTooManyRequests {
@@ -621,7 +631,7 @@ func TestRetry(t *testing.T) {
 		}
 	}
 
-	dr := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 3)
+	dr := NewTestDNSClientImpl(time.Second*10, []string{dnsLoopbackAddr}, testStats, clock.NewFake(), 3, nil)
 	dr.dnsClient = &testExchanger{errs: []error{isTempErr, isTempErr, nil}}
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel()
@@ -713,7 +723,7 @@ func TestRotateServerOnErr(t *testing.T) {
 	// number of dnsServers to ensure we always get around to trying the one
 	// working server
 	maxTries := 5
-	client := NewTestDNSClientImpl(time.Second*10, dnsServers, testStats, clock.NewFake(), maxTries)
+	client := NewTestDNSClientImpl(time.Second*10, dnsServers, testStats, clock.NewFake(), maxTries, nil)
 
 	// Configure a mock exchanger that will always return a retryable error for
 	// the A and B servers. This will force the C server to do all the work once
@@ -741,3 +751,136 @@ func TestRotateServerOnErr(t *testing.T) {
 	// We expect that the C server eventually served all of the lookups attempted
 	test.AssertEquals(t, mock.lookups["c"], maxTries*2)
 }
+
+// selfSignedTLSConfigs returns a server TLS config with a certificate for
+// 127.0.0.1 and a client TLS config that trusts it.
+func selfSignedTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stub resolver"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing certificate")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	}
+	return serverConfig, &tls.Config{RootCAs: roots}
+}
+
+func TestDNSOverTLS(t *testing.T) {
+	serverConfig, clientConfig := selfSignedTLSConfigs(t)
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	test.AssertNotError(t, err, "listening for DoT")
+	started := make(chan struct{})
+	server := &dns.Server{
+		Listener:          l,
+		Net:               "tcp-tls",
+		ReadTimeout:       time.Second,
+		WriteTimeout:      time.Second,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	<-started
+	defer func() {
+		_ = server.Shutdown()
+	}()
+	resolver := "tls://" + l.Addr().String()
+
+	obj := NewTestDNSClientImpl(time.Second*10, []string{resolver}, testStats, clock.NewFake(), 1, clientConfig)
+	ips, err := obj.LookupHost(context.Background(), "cps.letsencrypt.org")
+	test.AssertNotError(t, err, "LookupHost over DoT failed")
+	test.AssertEquals(t, len(ips), 1)
+	test.AssertEquals(t, ips[0].String(), "127.0.0.1")
+
+	// A resolver whose certificate isn't trusted mustn't be used.
+	obj = NewTestDNSClientImpl(time.Second*10, []string{resolver}, testStats, clock.NewFake(), 1, nil)
+	_, err = obj.LookupHost(context.Background(), "cps.letsencrypt.org")
+	test.AssertError(t, err, "LookupHost succeeded against an untrusted DoT resolver")
+}
+
+// dohStub answers DNS-over-HTTPS queries by forwarding them to the loopback
+// test resolver.
+func dohStub(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != dohMediaType {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, err := ioutil.ReadAll(r.Body)
+		test.AssertNotError(t, err, "reading DoH request")
+		query := new(dns.Msg)
+		err = query.Unpack(body)
+		test.AssertNotError(t, err, "unpacking DoH request")
+		if query.Question[0].Name == "http-error.letsencrypt.org." {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		resp, err := dns.Exchange(query, dnsLoopbackAddr)
+		test.AssertNotError(t, err, "forwarding DoH request")
+		packed, err := resp.Pack()
+		test.AssertNotError(t, err, "packing DoH response")
+		w.Header().Set("Content-Type", dohMediaType)
+		_, _ = w.Write(packed)
+	})
+}
+
+func TestDNSOverHTTPS(t *testing.T) {
+	serverConfig, clientConfig := selfSignedTLSConfigs(t)
+	server := httptest.NewUnstartedServer(dohStub(t))
+	server.TLS = serverConfig
+	server.StartTLS()
+	defer server.Close()
+	resolver := server.URL + "/dns-query"
+
+	obj := NewTestDNSClientImpl(time.Second*10, []string{resolver}, testStats, clock.NewFake(), 1, clientConfig)
+	ips, err := obj.LookupHost(context.Background(), "cps.letsencrypt.org")
+	test.AssertNotError(t, err, "LookupHost over DoH failed")
+	test.AssertEquals(t, len(ips), 1)
+	test.AssertEquals(t, ips[0].String(), "127.0.0.1")
+
+	txts, _, err := obj.LookupTXT(context.Background(), "split-txt.letsencrypt.org")
+	test.AssertNotError(t, err, "LookupTXT over DoH failed")
+	test.AssertDeepEquals(t, txts, []string{"abc"})
+
+	_, err = obj.LookupHost(context.Background(), "http-error.letsencrypt.org")
+	test.AssertError(t, err, "LookupHost succeeded despite a DoH HTTP error")
+
+	// A resolver whose certificate isn't trusted mustn't be used.
+	obj = NewTestDNSClientImpl(time.Second*10, []string{resolver}, testStats, clock.NewFake(), 1, nil)
+	_, err = obj.LookupHost(context.Background(), "cps.letsencrypt.org")
+	test.AssertError(t, err, "LookupHost succeeded against an untrusted DoH resolver")
+}
+
+func TestExchangerFor(t *testing.T) {
+	obj := NewTestDNSClientImpl(time.Second, nil, testStats, clock.NewFake(), 1, nil)
+	testCases := []struct {
+		server   string
+		expected exchanger
+		addr     string
+	}{
+		{"127.0.0.1:53", obj.dnsClient, "127.0.0.1:53"},
+		{"tls://127.0.0.1:853", obj.dotClient, "127.0.0.1:853"},
+		{"https://127.0.0.1/dns-query", obj.dohClient, "https://127.0.0.1/dns-query"},
+	}
+	for _, tc := range testCases {
+		client, addr := obj.exchangerFor(tc.server)
+		test.Assert(t, client == tc.expected, fmt.Sprintf("wrong exchanger for %q", tc.server))
+		test.AssertEquals(t, addr, tc.addr)
+	}
+}
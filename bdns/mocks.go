@@ -41,6 +41,14 @@ func (mock *MockDNSClient) LookupTXT(_ context.Context, hostname string) ([]stri
 		// expected token + test account jwk thumbprint
 		return []string{"LPsIwTo7o8BoG0-vjCyGQGBWSVIPxI-i_X336eUOQZo"}, nil, nil
 	}
+	if hostname == "_o7v76rusep3qjnvt._acme-challenge.good-dns-account01.com" {
+		// The dns-account-01 label for the account URL
+		// "http://boulder:4000/acme/reg/1" and
+		// base64(sha256("LoqXcYV8q5ONbJQxbmR7SCTNo3tiAXDfowyjxAjEuX0"
+		//               + "." + "9jg46WB3rR_AHD-EBXdN7cBkH1WOu0tA3M9fm21mqTI"))
+		// expected token + test account jwk thumbprint
+		return []string{"LPsIwTo7o8BoG0-vjCyGQGBWSVIPxI-i_X336eUOQZo"}, []string{"respect my authority!"}, nil
+	}
 	// empty-txts.com always returns zero TXT records
 	if hostname == "_acme-challenge.empty-txts.com" {
 		return []string{}, nil, nil
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto"
 	"crypto/ecdsa"
+	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -34,9 +35,13 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	csrlib "github.com/letsencrypt/boulder/csr"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/issuance"
+	"github.com/letsencrypt/boulder/linter"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -53,6 +58,7 @@ var (
 	oidSubjectAltName         = asn1.ObjectIdentifier{2, 5, 29, 17}
 	oidSubjectKeyIdentifier   = asn1.ObjectIdentifier{2, 5, 29, 14}
 	oidTLSFeature             = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+	oidOCSPNoCheck            = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
 
 	// CSR attribute requesting extensions
 	oidExtensionRequest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 14}
@@ -99,6 +105,7 @@ const (
 
 type certificateStorage interface {
 	AddCertificate(context.Context, []byte, int64, []byte, *time.Time) (string, error)
+	AddKeyHash(context.Context, *sapb.AddKeyHashRequest) error
 }
 
 type certificateType string
@@ -111,12 +118,12 @@ const (
 // CertificateAuthorityImpl represents a CA that signs certificates, CRLs, and
 // OCSP responses.
 type CertificateAuthorityImpl struct {
-	rsaProfile   string
-	ecdsaProfile string
+	// A map from name to the certificate profiles requests can select
+	certProfiles map[string]*certProfile
+	// The profile used for requests that don't select one
+	defaultProfile *certProfile
 	// A map from issuer cert common name to an internalIssuer struct
-	issuers map[string]*internalIssuer
-	// The common name of the default issuer cert
-	defaultIssuer     *internalIssuer
+	issuers           map[string]*internalIssuer
 	sa                certificateStorage
 	pa                core.PolicyAuthority
 	keyPolicy         goodkey.KeyPolicy
@@ -124,11 +131,10 @@ type CertificateAuthorityImpl struct {
 	log               blog.Logger
 	stats             metrics.Scope
 	prefix            int // Prepended to the serial number
-	validityPeriod    time.Duration
+	lifespanCRL       time.Duration
 	backdate          time.Duration
 	maxNames          int
 	forceCNFromSAN    bool
-	enableMustStaple  bool
 	signatureCount    *prometheus.CounterVec
 	csrExtensionCount *prometheus.CounterVec
 	orphanQueue       *goque.Queue
@@ -138,19 +144,89 @@ type CertificateAuthorityImpl struct {
 type Issuer struct {
 	Signer crypto.Signer
 	Cert   *x509.Certificate
+	// OCSPSigner and OCSPCert are the key and certificate of an optional
+	// delegated OCSP responder for this issuer. If they are nil OCSP responses
+	// are signed directly by Signer.
+	OCSPSigner crypto.Signer
+	OCSPCert   *x509.Certificate
+	// IssuerURL, OCSPURL and CRLURL, if set, replace those of the issuance
+	// profile in certificates signed natively by this issuer, so that each
+	// issuer's certificates point at its own certificate and revocation
+	// services.
+	IssuerURL string
+	OCSPURL   string
+	CRLURL    string
 }
 
 // internalIssuer represents the fully initialized internal state for a single
-// issuer, including the cfssl signer and OCSP signer objects.
+// issuer, including the cfssl signer and OCSP signer objects, and the raw
+// signer used for CRLs. When the NonCFSSLSigner feature is enabled, native
+// issues certificates in place of eeSigner. Otherwise each certificate is
+// first signed by lintSigner, which holds the linter's throwaway key, and
+// linted.
 type internalIssuer struct {
 	cert       *x509.Certificate
+	signer     crypto.Signer
 	eeSigner   *local.Signer
+	native     *issuance.Issuer
+	linter     *linter.Linter
+	lintSigner *local.Signer
 	ocspSigner ocsp.Signer
 }
 
+// lint signs a certificate with the issuer's lint signer, using sign, and
+// lints it. It returns the to-be-signed certificate that was linted.
+func (i *internalIssuer) lint(sign func(*local.Signer) ([]byte, error)) ([]byte, error) {
+	lintPEM, err := sign(i.lintSigner)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(lintPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("invalid certificate value returned by lint signer")
+	}
+	return i.linter.CheckSigned(block.Bytes)
+}
+
+// checkLinted returns an error unless certDER is the certificate whose
+// to-be-signed certificate, lintTBS, was linted.
+func checkLinted(certDER, lintTBS []byte) error {
+	cert, err := x509.ParseCertificate(certDER)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(cert.RawTBSCertificate, lintTBS) {
+		return errors.New("signed certificate differs from linted certificate")
+	}
+	return nil
+}
+
+// signatureAlgorithm picks the algorithm the CFSSL signers use to sign
+// certificates with an issuer key of the given type, matching the choice of
+// the native issuance engine.
+func signatureAlgorithm(pub crypto.PublicKey) (x509.SignatureAlgorithm, error) {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return x509.SHA256WithRSA, nil
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			return x509.ECDSAWithSHA256, nil
+		case elliptic.P384():
+			return x509.ECDSAWithSHA384, nil
+		}
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported issuer ECDSA curve %s", k.Curve.Params().Name)
+	default:
+		return x509.UnknownSignatureAlgorithm, fmt.Errorf("unsupported issuer key type %T", pub)
+	}
+}
+
 func makeInternalIssuers(
 	issuers []Issuer,
 	policy *cfsslConfig.Signing,
+	profileConfig *issuance.ProfileConfig,
+	registry *linter.Registry,
+	clk clock.Clock,
 	lifespanOCSP time.Duration,
 ) (map[string]*internalIssuer, error) {
 	if len(issuers) == 0 {
@@ -161,14 +237,28 @@ func makeInternalIssuers(
 		if iss.Cert == nil || iss.Signer == nil {
 			return nil, errors.New("Issuer with nil cert or signer specified.")
 		}
-		eeSigner, err := local.NewSigner(iss.Signer, iss.Cert, x509.SHA256WithRSA, policy)
+		sigAlg, err := signatureAlgorithm(iss.Cert.PublicKey)
+		if err != nil {
+			return nil, err
+		}
+		eeSigner, err := local.NewSigner(iss.Signer, iss.Cert, sigAlg, policy)
 		if err != nil {
 			return nil, err
 		}
 
 		// Set up our OCSP signer. Note this calls for both the issuer cert and the
-		// OCSP signing cert, which are the same in our case.
-		ocspSigner, err := ocsp.NewSigner(iss.Cert, iss.Cert, iss.Signer, lifespanOCSP)
+		// OCSP signing cert, which are the same unless a delegated responder is
+		// configured. When they differ the responder cert is included in each
+		// response.
+		responderCert, responderSigner := iss.Cert, iss.Signer
+		if iss.OCSPCert != nil || iss.OCSPSigner != nil {
+			err = checkOCSPResponder(iss.Cert, iss.OCSPCert, iss.OCSPSigner)
+			if err != nil {
+				return nil, err
+			}
+			responderCert, responderSigner = iss.OCSPCert, iss.OCSPSigner
+		}
+		ocspSigner, err := ocsp.NewSigner(iss.Cert, responderCert, responderSigner, lifespanOCSP)
 		if err != nil {
 			return nil, err
 		}
@@ -176,18 +266,93 @@ func makeInternalIssuers(
 		if internalIssuers[cn] != nil {
 			return nil, errors.New("Multiple issuer certs with the same CommonName are not supported")
 		}
+		var native *issuance.Issuer
+		if profileConfig != nil {
+			pc := *profileConfig
+			if iss.IssuerURL != "" {
+				pc.IssuerURL = iss.IssuerURL
+			}
+			if iss.OCSPURL != "" {
+				pc.OCSPURL = iss.OCSPURL
+			}
+			if iss.CRLURL != "" {
+				pc.CRLURL = iss.CRLURL
+			}
+			profile, err := issuance.NewProfile(pc)
+			if err != nil {
+				return nil, err
+			}
+			native, err = issuance.NewIssuer(iss.Cert, iss.Signer, profile, registry, clk)
+			if err != nil {
+				return nil, err
+			}
+		}
+		l, err := linter.New(iss.Cert, registry)
+		if err != nil {
+			return nil, err
+		}
+		lintSigner, err := local.NewSigner(l.Signer(), l.Issuer(), sigAlg, policy)
+		if err != nil {
+			return nil, err
+		}
 		internalIssuers[cn] = &internalIssuer{
 			cert:       iss.Cert,
+			signer:     iss.Signer,
 			eeSigner:   eeSigner,
+			native:     native,
+			linter:     l,
+			lintSigner: lintSigner,
 			ocspSigner: ocspSigner,
 		}
 	}
 	return internalIssuers, nil
 }
 
-// NewCertificateAuthorityImpl creates a CA instance that can sign certificates
-// from a single issuer (the first first in the issuers slice), and can sign OCSP
-// for any of the issuer certificates provided.
+// checkOCSPResponder checks that cert is suitable for use as a delegated OCSP
+// responder for issuer, as described in RFC 6960 section 4.2.2.2, and that it
+// matches signer.
+func checkOCSPResponder(issuer, cert *x509.Certificate, signer crypto.Signer) error {
+	if cert == nil || signer == nil {
+		return errors.New("OCSP responder with nil cert or signer specified")
+	}
+	cn := issuer.Subject.CommonName
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return fmt.Errorf("OCSP responder cert for %q has a different issuer name", cn)
+	}
+	if err := cert.CheckSignatureFrom(issuer); err != nil {
+		return fmt.Errorf("OCSP responder cert for %q is not signed by its issuer: %s", cn, err)
+	}
+	if cert.IsCA {
+		return fmt.Errorf("OCSP responder cert for %q is a CA certificate", cn)
+	}
+	var ocspSigning bool
+	for _, eku := range cert.ExtKeyUsage {
+		if eku == x509.ExtKeyUsageOCSPSigning {
+			ocspSigning = true
+		}
+	}
+	if !ocspSigning {
+		return fmt.Errorf("OCSP responder cert for %q lacks the id-kp-OCSPSigning extended key usage", cn)
+	}
+	var noCheck bool
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oidOCSPNoCheck) {
+			noCheck = true
+		}
+	}
+	if !noCheck {
+		return fmt.Errorf("OCSP responder cert for %q lacks the id-pkix-ocsp-nocheck extension", cn)
+	}
+	if !core.KeyDigestEquals(signer.Public(), cert.PublicKey) {
+		return fmt.Errorf("OCSP responder key for %q does not match its cert", cn)
+	}
+	return nil
+}
+
+// NewCertificateAuthorityImpl creates a CA instance that can sign certificates,
+// OCSP and CRLs from any of the issuers provided. Each certificate profile
+// chooses the issuer of its certificates by the type of the subscriber key,
+// defaulting to the first in the issuers slice.
 func NewCertificateAuthorityImpl(
 	config ca_config.CAConfig,
 	sa certificateStorage,
@@ -228,20 +393,34 @@ func NewCertificateAuthorityImpl(
 		}
 	}
 
+	var issuanceConfig *issuance.ProfileConfig
+	if features.Enabled(features.NonCFSSLSigner) {
+		if config.Issuance == nil {
+			return nil, errors.New("the NonCFSSLSigner feature requires an issuance profile")
+		}
+		issuanceConfig = config.Issuance
+	}
+
+	registry, err := linter.NewRegistry(config.IgnoredLints)
+	if err != nil {
+		return nil, err
+	}
+
 	internalIssuers, err := makeInternalIssuers(
 		issuers,
 		cfsslConfigObj.Signing,
+		issuanceConfig,
+		registry,
+		clk,
 		config.LifespanOCSP.Duration)
 	if err != nil {
 		return nil, err
 	}
 	defaultIssuer := internalIssuers[issuers[0].Cert.Subject.CommonName]
 
-	rsaProfile := config.RSAProfile
-	ecdsaProfile := config.ECDSAProfile
-
-	if rsaProfile == "" || ecdsaProfile == "" {
-		return nil, errors.New("must specify rsaProfile and ecdsaProfile")
+	certProfiles, defaultProfile, err := loadCertProfiles(config, cfsslConfigObj.Signing, internalIssuers, defaultIssuer)
+	if err != nil {
+		return nil, err
 	}
 
 	csrExtensionCount := prometheus.NewCounterVec(
@@ -264,27 +443,18 @@ func NewCertificateAuthorityImpl(
 		sa:                sa,
 		pa:                pa,
 		issuers:           internalIssuers,
-		defaultIssuer:     defaultIssuer,
-		rsaProfile:        rsaProfile,
-		ecdsaProfile:      ecdsaProfile,
+		certProfiles:      certProfiles,
+		defaultProfile:    defaultProfile,
 		prefix:            config.SerialPrefix,
 		clk:               clk,
 		log:               logger,
 		stats:             stats,
 		keyPolicy:         keyPolicy,
 		forceCNFromSAN:    !config.DoNotForceCN, // Note the inversion here
-		enableMustStaple:  config.EnableMustStaple,
 		signatureCount:    signatureCount,
 		csrExtensionCount: csrExtensionCount,
 		orphanQueue:       orphanQueue,
-	}
-
-	if config.Expiry == "" {
-		return nil, errors.New("Config must specify an expiry period.")
-	}
-	ca.validityPeriod, err = time.ParseDuration(config.Expiry)
-	if err != nil {
-		return nil, err
+		lifespanCRL:       config.LifespanCRL.Duration,
 	}
 
 	// TODO(briansmith): Make the backdate setting mandatory after the
@@ -297,6 +467,22 @@ func NewCertificateAuthorityImpl(
 
 	ca.maxNames = config.MaxNames
 
+	// The issuance profile refuses certificates valid for longer, or backdated
+	// further, than it allows, so catch a mismatch here rather than at
+	// issuance time. A final certificate shares its precertificate's
+	// NotBefore, so the profile must allow for some time to pass between the
+	// two.
+	if issuanceConfig != nil {
+		if ca.backdate >= config.Issuance.MaxValidityBackdate.Duration {
+			return nil, errors.New("issuance profile's maxValidityBackdate must be longer than backdate")
+		}
+		for name, profile := range certProfiles {
+			if profile.validity > config.Issuance.MaxValidityPeriod.Duration {
+				return nil, fmt.Errorf("certificate profile %q is valid for longer than the issuance profile's maxValidityPeriod", name)
+			}
+		}
+	}
+
 	return ca, nil
 }
 
@@ -317,15 +503,18 @@ func (ca *CertificateAuthorityImpl) noteSignError(err error) {
 // currently supported:
 //
 // * 1.3.6.1.5.5.7.1.24 - TLS Feature [RFC7633], with the "must staple" value.
-//                        Any other value will result in an error.
+//                        Any other value will result in an error. Whether it
+//                        is included depends on the profile's Must Staple
+//                        policy, which can also add it unrequested.
 //
 // Other requested extensions are silently ignored.
-func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateRequest) ([]signer.Extension, error) {
+func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateRequest, profile *certProfile) ([]signer.Extension, error) {
 	extensions := []signer.Extension{}
 
 	extensionSeen := map[string]bool{}
 	hasBasic := false
 	hasOther := false
+	hasMustStaple := false
 
 	for _, attr := range csr.Attributes {
 		if !attr.Type.Equal(oidExtensionRequest) {
@@ -351,8 +540,9 @@ func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateReque
 						return nil, berrors.MalformedError("unsupported value for extension with OID %v", ext.Type)
 					}
 
-					if ca.enableMustStaple {
+					if profile.mustStaple == mustStapleAllow || profile.mustStaple == mustStapleRequire {
 						extensions = append(extensions, mustStapleExtension)
+						hasMustStaple = true
 					}
 				case ext.Type.Equal(oidAuthorityInfoAccess),
 					ext.Type.Equal(oidAuthorityKeyIdentifier),
@@ -379,6 +569,10 @@ func (ca *CertificateAuthorityImpl) extensionsFromCSR(csr *x509.CertificateReque
 		ca.csrExtensionCount.With(prometheus.Labels{csrExtensionCategory: csrExtensionOther}).Inc()
 	}
 
+	if profile.mustStaple == mustStapleRequire && !hasMustStaple {
+		extensions = append(extensions, mustStapleExtension)
+	}
+
 	return extensions, nil
 }
 
@@ -420,8 +614,8 @@ func (ca *CertificateAuthorityImpl) GenerateOCSP(ctx context.Context, xferObj co
 
 // IssueCertificate attempts to convert a CSR into a signed Certificate, while
 // enforcing all policies. Names (domains) in the CertificateRequest will be
-// lowercased before storage.
-// Currently it will always sign with the defaultIssuer.
+// lowercased before storage. The certificate is signed by the issuer its
+// profile selects for the subscriber's key type.
 func (ca *CertificateAuthorityImpl) IssueCertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest) (core.Certificate, error) {
 	emptyCert := core.Certificate{}
 
@@ -437,12 +631,17 @@ func (ca *CertificateAuthorityImpl) IssueCertificate(ctx context.Context, issueR
 		orderID = *issueReq.OrderID
 	}
 
-	serialBigInt, validity, err := ca.generateSerialNumberAndValidity()
+	profile, err := ca.certProfileFor(issueReq.GetCertificateProfileName())
 	if err != nil {
 		return emptyCert, err
 	}
 
-	certDER, err := ca.issueCertificateOrPrecertificate(ctx, issueReq, serialBigInt, validity, certType)
+	serialBigInt, validity, err := ca.generateSerialNumberAndValidity(profile)
+	if err != nil {
+		return emptyCert, err
+	}
+
+	certDER, err := ca.issueCertificateOrPrecertificate(ctx, issueReq, profile, serialBigInt, validity, certType)
 	if err != nil {
 		return emptyCert, err
 	}
@@ -451,12 +650,17 @@ func (ca *CertificateAuthorityImpl) IssueCertificate(ctx context.Context, issueR
 }
 
 func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest) (*caPB.IssuePrecertificateResponse, error) {
-	serialBigInt, validity, err := ca.generateSerialNumberAndValidity()
+	profile, err := ca.certProfileFor(issueReq.GetCertificateProfileName())
+	if err != nil {
+		return nil, err
+	}
+
+	serialBigInt, validity, err := ca.generateSerialNumberAndValidity(profile)
 	if err != nil {
 		return nil, err
 	}
 
-	precertDER, err := ca.issueCertificateOrPrecertificate(ctx, issueReq, serialBigInt, validity, precertType)
+	precertDER, err := ca.issueCertificateOrPrecertificate(ctx, issueReq, profile, serialBigInt, validity, precertType)
 	if err != nil {
 		return nil, err
 	}
@@ -468,8 +672,9 @@ func (ca *CertificateAuthorityImpl) IssuePrecertificate(ctx context.Context, iss
 // IssueCertificateForPrecertificate takes a precertificate and a set of SCTs for that precertificate
 // and uses the signer to create and sign a certificate from them. The poison extension is removed
 // and a SCT list extension is inserted in its place. Except for this and the signature the certificate
-// exactly matches the precertificate. After the certificate is signed a OCSP response is generated
-// and the response and certificate are stored in the database.
+// must exactly match the precertificate, and nothing is signed if the linted certificate doesn't.
+// After the certificate is signed a OCSP response is generated and the response and certificate are
+// stored in the database.
 func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx context.Context, req *caPB.IssueCertificateForPrecertificateRequest) (core.Certificate, error) {
 	emptyCert := core.Certificate{}
 	precert, err := x509.ParseCertificate(req.DER)
@@ -485,20 +690,76 @@ func (ca *CertificateAuthorityImpl) IssueCertificateForPrecertificate(ctx contex
 		}
 		scts = append(scts, sct)
 	}
-	certPEM, err := ca.defaultIssuer.eeSigner.SignFromPrecert(precert, scts)
+	serialHex := core.SerialToString(precert.SerialNumber)
+	// The certificate must be signed by the same issuer as its precertificate,
+	// whichever issuer that was.
+	issuer := ca.issuers[precert.Issuer.CommonName]
+	if issuer == nil {
+		return emptyCert, berrors.InternalServerError("This CA doesn't have an issuer cert with CommonName %q", precert.Issuer.CommonName)
+	}
+	err = precert.CheckSignatureFrom(issuer.cert)
 	if err != nil {
-		return emptyCert, err
+		return emptyCert, berrors.InternalServerError("precertificate %s was not signed by %q: %s", serialHex, precert.Issuer.CommonName, err)
 	}
-	serialHex := core.SerialToString(precert.SerialNumber)
-	block, _ := pem.Decode(certPEM)
-	if block == nil || block.Type != "CERTIFICATE" {
-		err = berrors.InternalServerError("invalid certificate value returned")
-		ca.log.AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
-		return emptyCert, err
+	var certDER []byte
+	if native := issuer.native; native != nil {
+		issueReq, err := native.RequestFromPrecert(precert, scts)
+		if err != nil {
+			return emptyCert, err
+		}
+		certDER, err = native.Issue(issueReq)
+		ca.noteSignError(err)
+		if err != nil {
+			err = berrors.InternalServerError("failed to sign certificate: %s", err)
+			ca.log.AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
+			return emptyCert, err
+		}
+	} else {
+		// CFSSL only signs a certificate for a precertificate it signed
+		// itself, so the lint signer is given the precertificate re-signed
+		// with the linter's key.
+		lintTBS, err := issuer.lint(func(s *local.Signer) ([]byte, error) {
+			lintPrecertDER, err := issuer.linter.Resign(req.DER)
+			if err != nil {
+				return nil, err
+			}
+			lintPrecert, err := x509.ParseCertificate(lintPrecertDER)
+			if err != nil {
+				return nil, err
+			}
+			return s.SignFromPrecert(lintPrecert, scts)
+		})
+		if err != nil {
+			err = berrors.InternalServerError("failed to lint certificate: %s", err)
+			ca.log.AuditErrf("Linting failed: serial=[%s] err=[%v]", serialHex, err)
+			return emptyCert, err
+		}
+		err = issuance.CheckPrecertificateMatch(precert.RawTBSCertificate, lintTBS)
+		if err != nil {
+			err = berrors.InternalServerError(err.Error())
+			ca.log.AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
+			return emptyCert, err
+		}
+		certPEM, err := issuer.eeSigner.SignFromPrecert(precert, scts)
+		if err != nil {
+			return emptyCert, err
+		}
+		block, _ := pem.Decode(certPEM)
+		if block == nil || block.Type != "CERTIFICATE" {
+			err = berrors.InternalServerError("invalid certificate value returned")
+			ca.log.AuditErrf("PEM decode error, aborting: serial=[%s] pem=[%s] err=[%v]", serialHex, certPEM, err)
+			return emptyCert, err
+		}
+		certDER = block.Bytes
+		err = checkLinted(certDER, lintTBS)
+		if err != nil {
+			err = berrors.InternalServerError(err.Error())
+			ca.log.AuditErrf("Signing failed: serial=[%s] cert=[%s] err=[%v]", serialHex, hex.EncodeToString(certDER), err)
+			return emptyCert, err
+		}
 	}
-	certDER := block.Bytes
 	ca.log.AuditInfof("Signing success: serial=[%s] names=[%s] precertificate=[%s] certificate=[%s]",
-		serialHex, strings.Join(precert.DNSNames, ", "), hex.EncodeToString(req.DER),
+		serialHex, strings.Join(core.IdentifierNames(precert.DNSNames, precert.IPAddresses), ", "), hex.EncodeToString(req.DER),
 		hex.EncodeToString(certDER))
 	return ca.generateOCSPAndStoreCertificate(ctx, *req.RegistrationID, *req.OrderID, precert.SerialNumber, certDER)
 }
@@ -508,7 +769,7 @@ type validity struct {
 	NotAfter  time.Time
 }
 
-func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int, validity, error) {
+func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity(profile *certProfile) (*big.Int, validity, error) {
 	// We want 136 bits of random number, plus an 8-bit instance id prefix.
 	const randBits = 136
 	serialBytes := make([]byte, randBits/8+1)
@@ -525,19 +786,42 @@ func (ca *CertificateAuthorityImpl) generateSerialNumberAndValidity() (*big.Int,
 	notBefore := ca.clk.Now().Add(-1 * ca.backdate)
 	validity := validity{
 		NotBefore: notBefore,
-		NotAfter:  notBefore.Add(ca.validityPeriod),
+		NotAfter:  notBefore.Add(profile.validity),
 	}
 
 	return serialBigInt, validity, nil
 }
 
-func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest, serialBigInt *big.Int, validity validity, certType certificateType) ([]byte, error) {
+// storeKeyHash records the hash of the public key of the certificate that will
+// be issued with the given serial.
+func (ca *CertificateAuthorityImpl) storeKeyHash(ctx context.Context, pub crypto.PublicKey, serial *big.Int, notAfter time.Time) error {
+	keyHash, err := core.KeySPKIHash(pub)
+	if err != nil {
+		return err
+	}
+	serialHex := core.SerialToString(serial)
+	notAfterNS := notAfter.UnixNano()
+	err = ca.sa.AddKeyHash(ctx, &sapb.AddKeyHashRequest{
+		KeyHash:      keyHash,
+		Serial:       &serialHex,
+		CertNotAfter: &notAfterNS,
+	})
+	if err != nil {
+		err = berrors.InternalServerError("failed to store key hash: %s", err)
+		ca.log.AuditErrf("Storing key hash failed: serial=[%s] err=[%v]", serialHex, err)
+		return err
+	}
+	return nil
+}
+
+func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest, profile *certProfile, serialBigInt *big.Int, validity validity, certType certificateType) ([]byte, error) {
 	csr, err := x509.ParseCertificateRequest(issueReq.Csr)
 	if err != nil {
 		return nil, err
 	}
 
 	if err := csrlib.VerifyCSR(
+		ctx,
 		csr,
 		ca.maxNames,
 		&ca.keyPolicy,
@@ -549,12 +833,16 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		return nil, berrors.MalformedError(err.Error())
 	}
 
-	extensions, err := ca.extensionsFromCSR(csr)
+	extensions, err := ca.extensionsFromCSR(csr, profile)
 	if err != nil {
 		return nil, err
 	}
 
-	issuer := ca.defaultIssuer
+	issuer, err := profile.issuerFor(csr.PublicKey)
+	if err != nil {
+		ca.log.AuditErr(err.Error())
+		return nil, err
+	}
 
 	if issuer.cert.NotAfter.Before(validity.NotAfter) {
 		err = berrors.InternalServerError("cannot issue a certificate that expires after the issuer certificate")
@@ -562,29 +850,39 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		return nil, err
 	}
 
+	// The hash of the key is stored before the precertificate is signed, so
+	// that the precertificate can be found if the key is blocked even if the
+	// final certificate is never stored.
+	if certType == precertType && features.Enabled(features.StoreKeyHashes) {
+		err = ca.storeKeyHash(ctx, csr.PublicKey, serialBigInt, validity.NotAfter)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if issuer.native != nil {
+		return ca.issueNative(issuer, csr, profile, extensions, serialBigInt, validity, certType)
+	}
+
 	// Convert the CSR to PEM
 	csrPEM := string(pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE REQUEST",
 		Bytes: csr.Raw,
 	}))
 
-	var profile string
-	switch csr.PublicKey.(type) {
-	case *rsa.PublicKey:
-		profile = ca.rsaProfile
-	case *ecdsa.PublicKey:
-		profile = ca.ecdsaProfile
-	default:
-		err = berrors.InternalServerError("unsupported key type %T", csr.PublicKey)
+	signingProfile, err := profile.signingProfile(csr.PublicKey)
+	if err != nil {
 		ca.log.AuditErr(err.Error())
 		return nil, err
 	}
 
-	// Send the cert off for signing
+	// Send the cert off for signing. The signer puts each host that parses as an
+	// IP address into the certificate's iPAddress SANs.
+	names := core.IdentifierNames(csr.DNSNames, csr.IPAddresses)
 	req := signer.SignRequest{
 		Request: csrPEM,
-		Profile: profile,
-		Hosts:   csr.DNSNames,
+		Profile: signingProfile,
+		Hosts:   names,
 		Subject: &signer.Subject{
 			CN: csr.Subject.CommonName,
 		},
@@ -604,8 +902,16 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		req.Subject.SerialNumber = serialHex
 	}
 
-	ca.log.AuditInfof("Signing: serial=[%s] names=[%s] csr=[%s]",
-		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw))
+	ca.log.AuditInfof("Signing: serial=[%s] names=[%s] profile=[%s] csr=[%s]",
+		serialHex, strings.Join(names, ", "), profile.name, hex.EncodeToString(csr.Raw))
+
+	// Refuse to sign a certificate with the issuer's key if it fails linting.
+	lintTBS, err := issuer.lint(func(s *local.Signer) ([]byte, error) { return s.Sign(req) })
+	if err != nil {
+		err = berrors.InternalServerError("failed to lint certificate: %s", err)
+		ca.log.AuditErrf("Linting failed: serial=[%s] err=[%v]", serialHex, err)
+		return nil, err
+	}
 
 	certPEM, err := issuer.eeSigner.Sign(req)
 	ca.noteSignError(err)
@@ -629,9 +935,58 @@ func (ca *CertificateAuthorityImpl) issueCertificateOrPrecertificate(ctx context
 		return nil, err
 	}
 	certDER := block.Bytes
+	err = checkLinted(certDER, lintTBS)
+	if err != nil {
+		err = berrors.InternalServerError(err.Error())
+		ca.log.AuditErrf("Signing failed: serial=[%s] %s=[%s] err=[%v]", serialHex, certType, hex.EncodeToString(certDER), err)
+		return nil, err
+	}
+
+	ca.log.AuditInfof("Signing success: serial=[%s] names=[%s] csr=[%s] %s=[%s]",
+		serialHex, strings.Join(names, ", "), hex.EncodeToString(csr.Raw), certType,
+		hex.EncodeToString(certDER))
+
+	return certDER, nil
+}
+
+// issueNative signs a certificate or precertificate for csr with the issuer's
+// native issuance engine, which refuses to sign it if it fails linting.
+func (ca *CertificateAuthorityImpl) issueNative(issuer *internalIssuer, csr *x509.CertificateRequest, profile *certProfile, extensions []signer.Extension, serialBigInt *big.Int, validity validity, certType certificateType) ([]byte, error) {
+	serialHex := core.SerialToString(serialBigInt)
+	req := &issuance.IssuanceRequest{
+		PublicKey:       csr.PublicKey,
+		Serial:          serialBigInt,
+		NotBefore:       validity.NotBefore,
+		NotAfter:        validity.NotAfter,
+		CommonName:      csr.Subject.CommonName,
+		DNSNames:        csr.DNSNames,
+		IPAddresses:     csr.IPAddresses,
+		IncludeCTPoison: certType == precertType,
+	}
+	if !ca.forceCNFromSAN {
+		req.SubjectSerialNumber = serialHex
+	}
+	for _, ext := range extensions {
+		if asn1.ObjectIdentifier(ext.ID).Equal(oidTLSFeature) {
+			req.IncludeMustStaple = true
+		}
+	}
+
+	names := core.IdentifierNames(csr.DNSNames, csr.IPAddresses)
+	ca.log.AuditInfof("Signing: serial=[%s] names=[%s] profile=[%s] csr=[%s]",
+		serialHex, strings.Join(names, ", "), profile.name, hex.EncodeToString(csr.Raw))
+
+	certDER, err := issuer.native.Issue(req)
+	ca.noteSignError(err)
+	if err != nil {
+		err = berrors.InternalServerError("failed to sign certificate: %s", err)
+		ca.log.AuditErrf("Signing failed: serial=[%s] err=[%v]", serialHex, err)
+		return nil, err
+	}
+	ca.signatureCount.With(prometheus.Labels{"purpose": string(certType)}).Inc()
 
 	ca.log.AuditInfof("Signing success: serial=[%s] names=[%s] csr=[%s] %s=[%s]",
-		serialHex, strings.Join(csr.DNSNames, ", "), hex.EncodeToString(csr.Raw), certType,
+		serialHex, strings.Join(names, ", "), hex.EncodeToString(csr.Raw), certType,
 		hex.EncodeToString(certDER))
 
 	return certDER, nil
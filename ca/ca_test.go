@@ -13,8 +13,10 @@ import (
 	"fmt"
 	"io/ioutil"
 	"math/big"
+	"net"
 	"os"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -34,10 +36,14 @@ import (
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/issuance"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
+	"github.com/letsencrypt/boulder/revocation"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -167,6 +173,8 @@ type testCtx struct {
 
 type mockSA struct {
 	certificate core.Certificate
+	keyHashes   []*sapb.AddKeyHashRequest
+	failKeyHash bool
 }
 
 func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []byte, _ *time.Time) (string, error) {
@@ -174,6 +182,14 @@ func (m *mockSA) AddCertificate(ctx context.Context, der []byte, _ int64, _ []by
 	return "", nil
 }
 
+func (m *mockSA) AddKeyHash(_ context.Context, req *sapb.AddKeyHashRequest) error {
+	if m.failKeyHash {
+		return errors.New("database unavailable")
+	}
+	m.keyHashes = append(m.keyHashes, req)
+	return nil
+}
+
 var caKey crypto.Signer
 var caCert *x509.Certificate
 var ctx = context.Background()
@@ -196,7 +212,7 @@ func setup(t *testing.T) *testCtx {
 
 	pa, err := policy.New(nil)
 	test.AssertNotError(t, err, "Couldn't create PA")
-	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json")
+	err = pa.SetHostnamePolicyFile("../test/hostname-policy.json", 0)
 	test.AssertNotError(t, err, "Couldn't set hostname policy")
 
 	allowedExtensions := []cfsslConfig.OID{
@@ -269,7 +285,7 @@ func setup(t *testing.T) *testCtx {
 		},
 	}
 
-	issuers := []Issuer{{caKey, caCert}}
+	issuers := []Issuer{{Signer: caKey, Cert: caCert}}
 
 	keyPolicy := goodkey.KeyPolicy{
 		AllowRSA:           true,
@@ -440,7 +456,7 @@ func issueCertificateSubTestIssueCertificate(t *testing.T, i *TestCertificateIss
 
 func issueCertificateSubTestValidityUsesCAClock(t *testing.T, i *TestCertificateIssuance) {
 	test.AssertEquals(t, i.cert.NotBefore, i.ca.clk.Now().Add(-1*i.ca.backdate))
-	test.AssertEquals(t, i.cert.NotAfter, i.cert.NotBefore.Add(i.ca.validityPeriod))
+	test.AssertEquals(t, i.cert.NotAfter, i.cert.NotBefore.Add(i.ca.defaultProfile.validity))
 }
 
 // Test issuing when multiple issuers are present.
@@ -580,6 +596,217 @@ func TestOCSP(t *testing.T) {
 	test.AssertEquals(t, parsedNewCertOcspResp.SerialNumber.Cmp(parsedNewCert.SerialNumber), 0)
 }
 
+// makeOCSPResponder returns a key and a delegated OCSP responder certificate
+// for it, issued by caCert. mutate, if non-nil, is applied to the template
+// before it is signed.
+func makeOCSPResponder(t *testing.T, mutate func(*x509.Certificate)) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate responder key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1337),
+		Subject:      pkix.Name{CommonName: "happy hacker fake OCSP responder"},
+		NotBefore:    caCert.NotBefore,
+		NotAfter:     caCert.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidOCSPNoCheck, Value: asn1.NullBytes},
+		},
+	}
+	if mutate != nil {
+		mutate(template)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	test.AssertNotError(t, err, "Failed to create responder cert")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse responder cert")
+	return key, cert
+}
+
+func TestOCSPDelegatedResponder(t *testing.T) {
+	testCtx := setup(t)
+	sa := &mockSA{}
+	responderKey, responderCert := makeOCSPResponder(t, nil)
+	issuers := []Issuer{{
+		Signer:     caKey,
+		Cert:       caCert,
+		OCSPSigner: responderKey,
+		OCSPCert:   responderCert,
+	}}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issueReq := caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID}
+	cert, err := ca.IssueCertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue")
+	ocspResp, err := ca.GenerateOCSP(ctx, core.OCSPSigningRequest{
+		CertDER: cert.DER,
+		Status:  string(core.OCSPStatusGood),
+	})
+	test.AssertNotError(t, err, "Failed to generate OCSP")
+
+	// ParseResponse checks that the included responder cert was issued by
+	// caCert and that the response is signed by the responder key.
+	parsed, err := ocsp.ParseResponse(ocspResp, caCert)
+	test.AssertNotError(t, err, "Failed to parse / validate OCSP response")
+	test.AssertEquals(t, parsed.Status, ocsp.Good)
+	test.Assert(t, parsed.Certificate != nil, "OCSP response didn't include the responder cert")
+	test.Assert(t, bytes.Equal(parsed.Certificate.Raw, responderCert.Raw),
+		"OCSP response included the wrong responder cert")
+	err = caCert.CheckSignature(parsed.SignatureAlgorithm, parsed.TBSResponseData, parsed.Signature)
+	test.AssertError(t, err, "OCSP response was signed by the issuer key")
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	_, caResponder := makeOCSPResponder(t, func(tmpl *x509.Certificate) {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	})
+	_, noEKU := makeOCSPResponder(t, func(tmpl *x509.Certificate) {
+		tmpl.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+	})
+	_, noCheck := makeOCSPResponder(t, func(tmpl *x509.Certificate) {
+		tmpl.ExtraExtensions = nil
+	})
+	selfSignedTemplate := *responderCert
+	selfSignedTemplate.SignatureAlgorithm = x509.UnknownSignatureAlgorithm
+	selfSignedDER, err := x509.CreateCertificate(rand.Reader, &selfSignedTemplate, &selfSignedTemplate, responderKey.Public(), responderKey)
+	test.AssertNotError(t, err, "Failed to create self-signed responder cert")
+	selfSigned, err := x509.ParseCertificate(selfSignedDER)
+	test.AssertNotError(t, err, "Failed to parse self-signed responder cert")
+
+	testCases := []struct {
+		name   string
+		signer crypto.Signer
+		cert   *x509.Certificate
+	}{
+		{"missing cert", responderKey, nil},
+		{"missing signer", nil, responderCert},
+		{"mismatched key", otherKey, responderCert},
+		{"CA cert", responderKey, caResponder},
+		{"missing OCSPSigning EKU", responderKey, noEKU},
+		{"missing nocheck extension", responderKey, noCheck},
+		{"not issued by issuer", responderKey, selfSigned},
+	}
+	for _, tc := range testCases {
+		_, err := NewCertificateAuthorityImpl(
+			testCtx.caConfig,
+			sa,
+			testCtx.pa,
+			testCtx.fc,
+			testCtx.stats,
+			[]Issuer{{Signer: caKey, Cert: caCert, OCSPSigner: tc.signer, OCSPCert: tc.cert}},
+			testCtx.keyPolicy,
+			testCtx.logger,
+			nil)
+		test.AssertError(t, err, fmt.Sprintf("CA accepted an OCSP responder with %s", tc.name))
+	}
+}
+
+func TestGenerateCRL(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.LifespanCRL = cmd.ConfigDuration{Duration: 7 * 24 * time.Hour}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		&mockSA{},
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	revokedAt := testCtx.fc.Now().Add(-time.Hour)
+	crlDER, err := ca.GenerateCRL(ctx, core.CRLSigningRequest{
+		IssuerCommonName:  caCert.Subject.CommonName,
+		Shard:             3,
+		DistributionPoint: "http://crl.example.com/3.crl",
+		Entries: []core.CRLEntry{
+			{Serial: "000000000000000000000000000000000001", Reason: revocation.KeyCompromise, RevokedAt: revokedAt},
+			{Serial: "000000000000000000000000000000000002", Reason: revocation.Unspecified, RevokedAt: revokedAt},
+		},
+	})
+	test.AssertNotError(t, err, "Failed to generate CRL")
+
+	crl, err := x509.ParseCRL(crlDER)
+	test.AssertNotError(t, err, "Failed to parse CRL")
+	test.AssertNotError(t, caCert.CheckCRLSignature(crl), "CRL signature didn't verify")
+	tbs := crl.TBSCertList
+	test.AssertEquals(t, tbs.Version, 1)
+	test.Assert(t, tbs.ThisUpdate.Equal(testCtx.fc.Now()), "Wrong thisUpdate")
+	test.Assert(t, tbs.NextUpdate.Equal(testCtx.fc.Now().Add(7*24*time.Hour)), "Wrong nextUpdate")
+
+	test.AssertEquals(t, len(tbs.RevokedCertificates), 2)
+	test.AssertEquals(t, tbs.RevokedCertificates[0].SerialNumber.Int64(), int64(1))
+	test.Assert(t, tbs.RevokedCertificates[0].RevocationTime.Equal(revokedAt), "Wrong revocation time")
+	test.AssertEquals(t, len(tbs.RevokedCertificates[0].Extensions), 1)
+	test.AssertDeepEquals(t, tbs.RevokedCertificates[0].Extensions[0].Id, oidCRLReasonCode)
+	test.AssertDeepEquals(t, tbs.RevokedCertificates[0].Extensions[0].Value, []byte{0x0a, 0x01, 0x01})
+	// An unspecified reason is represented by omitting the reasonCode
+	// extension.
+	test.AssertEquals(t, len(tbs.RevokedCertificates[1].Extensions), 0)
+
+	extensions := make(map[string]pkix.Extension)
+	for _, ext := range tbs.Extensions {
+		extensions[ext.Id.String()] = ext
+	}
+	test.AssertEquals(t, len(extensions), 3)
+	var aki authKeyID
+	_, err = asn1.Unmarshal(extensions[oidAuthorityKeyIdentifier.String()].Value, &aki)
+	test.AssertNotError(t, err, "Failed to parse AKI")
+	test.AssertByteEquals(t, aki.ID, caCert.SubjectKeyId)
+	var number *big.Int
+	_, err = asn1.Unmarshal(extensions[oidCRLNumber.String()].Value, &number)
+	test.AssertNotError(t, err, "Failed to parse CRL number")
+	test.AssertEquals(t, number.Int64(), testCtx.fc.Now().UnixNano())
+	idpExt := extensions[oidIssuingDistributionPoint.String()]
+	test.Assert(t, idpExt.Critical, "IDP extension should be critical")
+	var idp issuingDistributionPoint
+	_, err = asn1.Unmarshal(idpExt.Value, &idp)
+	test.AssertNotError(t, err, "Failed to parse IDP")
+	test.Assert(t, idp.OnlyContainsUserCerts, "IDP should set onlyContainsUserCerts")
+	test.AssertEquals(t, len(idp.DistributionPoint.FullName), 1)
+	test.AssertEquals(t, string(idp.DistributionPoint.FullName[0].Bytes), "http://crl.example.com/3.crl")
+
+	// Without a distribution point there is no IDP extension, and an empty
+	// shard is still a valid CRL.
+	crlDER, err = ca.GenerateCRL(ctx, core.CRLSigningRequest{
+		IssuerCommonName: caCert.Subject.CommonName,
+	})
+	test.AssertNotError(t, err, "Failed to generate empty CRL")
+	crl, err = x509.ParseCRL(crlDER)
+	test.AssertNotError(t, err, "Failed to parse empty CRL")
+	test.AssertEquals(t, len(crl.TBSCertList.RevokedCertificates), 0)
+	test.AssertEquals(t, len(crl.TBSCertList.Extensions), 2)
+
+	_, err = ca.GenerateCRL(ctx, core.CRLSigningRequest{IssuerCommonName: "not an issuer"})
+	test.AssertError(t, err, "Generated CRL for an unknown issuer")
+
+	_, err = ca.GenerateCRL(ctx, core.CRLSigningRequest{
+		IssuerCommonName: caCert.Subject.CommonName,
+		Entries:          []core.CRLEntry{{Serial: "nope"}},
+	})
+	test.AssertError(t, err, "Generated CRL with an invalid serial")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Wrong error type for invalid serial")
+
+	// A CA without a CRL lifespan refuses to sign CRLs.
+	ca.lifespanCRL = 0
+	_, err = ca.GenerateCRL(ctx, core.CRLSigningRequest{IssuerCommonName: caCert.Subject.CommonName})
+	test.AssertError(t, err, "Generated CRL without a CRL lifespan")
+}
+
 func TestInvalidCSRs(t *testing.T) {
 	testCases := []struct {
 		name         string
@@ -665,6 +892,316 @@ func TestInvalidCSRs(t *testing.T) {
 	}
 }
 
+func TestIssueCertificateForIP(t *testing.T) {
+	testCtx := setup(t)
+	policyFile, err := ioutil.TempFile("", "ca-ip-policy.json")
+	test.AssertNotError(t, err, "Failed to create policy file")
+	defer os.Remove(policyFile.Name())
+	_, err = policyFile.Write([]byte(`{"Blacklist": ["example.org"], "AllowedIPRanges": ["10.0.0.0/8"]}`))
+	test.AssertNotError(t, err, "Failed to write policy file")
+	pa, err := policy.New(nil)
+	test.AssertNotError(t, err, "Couldn't create PA")
+	err = pa.SetHostnamePolicyFile(policyFile.Name(), 0)
+	test.AssertNotError(t, err, "Failed to load policy file")
+
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate key")
+	issue := func(ips ...net.IP) (*x509.Certificate, error) {
+		csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+			// An IP address CN is moved into the SANs rather than copied into
+			// the certificate's CN.
+			Subject:     pkix.Name{CommonName: ips[0].String()},
+			IPAddresses: ips,
+		}, key)
+		test.AssertNotError(t, err, "Failed to create CSR")
+		coreCert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{Csr: csrDER, RegistrationID: &arbitraryRegID})
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParseCertificate(coreCert.DER)
+	}
+
+	cert, err := issue(net.ParseIP("10.0.0.1"), net.ParseIP("10.0.0.2"))
+	test.AssertNotError(t, err, "Failed to issue certificate for IP addresses")
+	test.AssertEquals(t, cert.Subject.CommonName, "")
+	test.AssertEquals(t, len(cert.DNSNames), 0)
+	test.AssertDeepEquals(t, core.IdentifierNames(nil, cert.IPAddresses), []string{"10.0.0.1", "10.0.0.2"})
+
+	_, err = issue(net.ParseIP("192.168.0.1"))
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Issued a certificate for an IP address outside the allowed ranges")
+}
+
+func TestCertProfiles(t *testing.T) {
+	testCtx := setup(t)
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"classic": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Validity:     cmd.ConfigDuration{Duration: 90 * 24 * time.Hour},
+			MustStaple:   "allow",
+		},
+		"shortlived": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Validity:     cmd.ConfigDuration{Duration: 7 * 24 * time.Hour},
+			MustStaple:   "require",
+		},
+	}
+	testCtx.caConfig.DefaultCertProfile = "classic"
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issue := func(profile string) (*x509.Certificate, error) {
+		coreCert, err := ca.IssueCertificate(ctx, &caPB.IssueCertificateRequest{
+			Csr:                    CNandSANCSR,
+			RegistrationID:         &arbitraryRegID,
+			CertificateProfileName: &profile,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return x509.ParseCertificate(coreCert.DER)
+	}
+
+	// A request that doesn't name a profile gets the default one.
+	cert, err := issue("")
+	test.AssertNotError(t, err, "Failed to issue with the default profile")
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 90*24*time.Hour)
+	test.AssertEquals(t, countMustStaple(t, cert), 0)
+
+	cert, err = issue("shortlived")
+	test.AssertNotError(t, err, "Failed to issue with the shortlived profile")
+	test.AssertEquals(t, cert.NotAfter.Sub(cert.NotBefore), 7*24*time.Hour)
+	test.AssertEquals(t, countMustStaple(t, cert), 1)
+
+	_, err = issue("unknown")
+	test.AssertError(t, err, "Issued with an unknown profile")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Wrong error type for an unknown profile")
+}
+
+func TestLoadCertProfiles(t *testing.T) {
+	testCtx := setup(t)
+	signing := testCtx.caConfig.CFSSL.Signing
+	issuer := &internalIssuer{cert: caCert}
+	issuers := map[string]*internalIssuer{caCert.Subject.CommonName: issuer}
+	good := ca_config.CertProfileConfig{
+		RSAProfile:   rsaProfileName,
+		ECDSAProfile: ecdsaProfileName,
+		Validity:     cmd.ConfigDuration{Duration: time.Hour},
+	}
+
+	// Without CertProfiles the legacy fields make up the default profile.
+	profiles, defaultProfile, err := loadCertProfiles(testCtx.caConfig, signing, issuers, issuer)
+	test.AssertNotError(t, err, "Failed to load legacy profile")
+	test.AssertEquals(t, len(profiles), 1)
+	test.AssertEquals(t, defaultProfile.validity, 8760*time.Hour)
+	test.AssertEquals(t, defaultProfile.mustStaple, mustStapleIgnore)
+	test.AssertEquals(t, defaultProfile.rsaIssuer, issuer)
+	test.AssertEquals(t, defaultProfile.ecdsaIssuer, issuer)
+
+	testCases := []struct {
+		name    string
+		profile func(ca_config.CertProfileConfig) ca_config.CertProfileConfig
+	}{
+		{"unknown CFSSL profile", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.RSAProfile = "nope"
+			return pc
+		}},
+		{"missing ECDSA profile", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.ECDSAProfile = ""
+			return pc
+		}},
+		{"no validity", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.Validity = cmd.ConfigDuration{}
+			return pc
+		}},
+		{"bad must staple policy", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.MustStaple = "sometimes"
+			return pc
+		}},
+		{"unknown RSA issuer", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.RSAIssuer = "nope"
+			return pc
+		}},
+		{"unknown ECDSA issuer", func(pc ca_config.CertProfileConfig) ca_config.CertProfileConfig {
+			pc.ECDSAIssuer = "nope"
+			return pc
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			config := testCtx.caConfig
+			config.DefaultCertProfile = "default"
+			config.CertProfiles = map[string]ca_config.CertProfileConfig{"default": tc.profile(good)}
+			_, _, err := loadCertProfiles(config, signing, issuers, issuer)
+			test.AssertError(t, err, "loadCertProfiles accepted a bad profile")
+		})
+	}
+
+	config := testCtx.caConfig
+	config.DefaultCertProfile = "missing"
+	config.CertProfiles = map[string]ca_config.CertProfileConfig{"default": good}
+	_, _, err = loadCertProfiles(config, signing, issuers, issuer)
+	test.AssertError(t, err, "loadCertProfiles accepted an unconfigured default profile")
+}
+
+// makeECDSAIssuer returns a P-256 key and an intermediate certificate for it,
+// issued by caCert.
+func makeECDSAIssuer(t *testing.T) (*ecdsa.PrivateKey, *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate ECDSA issuer key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(7331),
+		Subject:               pkix.Name{CommonName: "happy hacker fake ECDSA CA"},
+		NotBefore:             caCert.NotBefore,
+		NotAfter:              caCert.NotAfter,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          []byte{7, 3, 3, 1},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, key.Public(), caKey)
+	test.AssertNotError(t, err, "Failed to create ECDSA issuer cert")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Failed to parse ECDSA issuer cert")
+	return key, cert
+}
+
+func TestIssuerSelection(t *testing.T) {
+	for _, native := range []bool{false, true} {
+		t.Run(fmt.Sprintf("native=%t", native), func(t *testing.T) {
+			testIssuerSelection(t, native)
+		})
+	}
+}
+
+func testIssuerSelection(t *testing.T, native bool) {
+	if native {
+		_ = features.Set(map[string]bool{"NonCFSSLSigner": true})
+		defer features.Reset()
+	}
+	testCtx := setup(t)
+	testCtx.caConfig.Issuance = &issuance.ProfileConfig{
+		IssuerURL:           "http://not-example.com/issuer-url",
+		OCSPURL:             "http://not-example.com/ocsp",
+		Policies:            []issuance.PolicyInformation{{OID: "2.23.140.1.2.1"}},
+		MaxValidityPeriod:   cmd.ConfigDuration{Duration: 8760 * time.Hour},
+		MaxValidityBackdate: cmd.ConfigDuration{Duration: time.Hour + time.Minute},
+	}
+	ecdsaKey, ecdsaCert := makeECDSAIssuer(t)
+	ecdsaName := ecdsaCert.Subject.CommonName
+	testCtx.caConfig.DefaultCertProfile = "default"
+	testCtx.caConfig.CertProfiles = map[string]ca_config.CertProfileConfig{
+		"default": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			ECDSAIssuer:  ecdsaName,
+			Validity:     cmd.ConfigDuration{Duration: 8760 * time.Hour},
+		},
+		"rsaonly": {
+			RSAProfile:   rsaProfileName,
+			ECDSAProfile: ecdsaProfileName,
+			Validity:     cmd.ConfigDuration{Duration: 8760 * time.Hour},
+		},
+	}
+	issuers := []Issuer{
+		{Signer: caKey, Cert: caCert},
+		{
+			Signer:    ecdsaKey,
+			Cert:      ecdsaCert,
+			IssuerURL: "http://not-example.com/ecdsa-issuer-url",
+		},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	issue := func(csr []byte, profile string) *x509.Certificate {
+		t.Helper()
+		orderID := int64(0)
+		precert, err := ca.IssuePrecertificate(ctx, &caPB.IssueCertificateRequest{
+			Csr:                    csr,
+			RegistrationID:         &arbitraryRegID,
+			OrderID:                &orderID,
+			CertificateProfileName: &profile,
+		})
+		test.AssertNotError(t, err, "Failed to issue precert")
+		sctBytes, err := cttls.Marshal(ct.SignedCertificateTimestamp{SCTVersion: 0, Timestamp: 2020})
+		test.AssertNotError(t, err, "Failed to marshal SCT")
+		cert, err := ca.IssueCertificateForPrecertificate(ctx, &caPB.IssueCertificateForPrecertificateRequest{
+			DER:            precert.DER,
+			SCTs:           [][]byte{sctBytes},
+			RegistrationID: &arbitraryRegID,
+			OrderID:        &orderID,
+		})
+		test.AssertNotError(t, err, "Failed to issue cert from precert")
+		parsed, err := x509.ParseCertificate(cert.DER)
+		test.AssertNotError(t, err, "Failed to parse cert")
+		return parsed
+	}
+
+	// ECDSA subscriber keys go to the ECDSA issuer, and the final certificate
+	// is signed by its precertificate's issuer.
+	cert := issue(ECDSACSR, "")
+	test.AssertNotError(t, cert.CheckSignatureFrom(ecdsaCert), "ECDSA cert not signed by the ECDSA issuer")
+	test.AssertEquals(t, cert.SignatureAlgorithm, x509.ECDSAWithSHA256)
+	if native {
+		test.AssertDeepEquals(t, cert.IssuingCertificateURL, []string{"http://not-example.com/ecdsa-issuer-url"})
+		test.AssertDeepEquals(t, cert.OCSPServer, []string{"http://not-example.com/ocsp"})
+	}
+	ocspResp, err := ca.GenerateOCSP(ctx, core.OCSPSigningRequest{
+		CertDER: cert.Raw,
+		Status:  string(core.OCSPStatusGood),
+	})
+	test.AssertNotError(t, err, "Failed to generate OCSP")
+	_, err = ocsp.ParseResponse(ocspResp, ecdsaCert)
+	test.AssertNotError(t, err, "OCSP response not signed by the ECDSA issuer")
+
+	// RSA subscriber keys still go to the default issuer.
+	cert = issue(CNandSANCSR, "")
+	test.AssertNotError(t, cert.CheckSignatureFrom(caCert), "RSA cert not signed by the default issuer")
+	if native {
+		test.AssertDeepEquals(t, cert.IssuingCertificateURL, []string{"http://not-example.com/issuer-url"})
+	}
+
+	// A profile without an ECDSA issuer uses the default issuer.
+	cert = issue(ECDSACSR, "rsaonly")
+	test.AssertNotError(t, cert.CheckSignatureFrom(caCert), "ECDSA cert not signed by the default issuer")
+}
+
 func TestRejectValidityTooLong(t *testing.T) {
 	testCtx := setup(t)
 	sa := &mockSA{}
@@ -778,13 +1315,13 @@ func issueCertificateSubTestProfileSelectionECDSA(t *testing.T, i *TestCertifica
 
 func issueCertificateSubTestMustStapleDisabledSetup(t *testing.T) (*CertificateAuthorityImpl, *mockSA) {
 	ca, sa := issueCertificateSubTestDefaultSetup(t)
-	ca.enableMustStaple = false
+	ca.defaultProfile.mustStaple = mustStapleIgnore
 	return ca, sa
 }
 
 func issueCertificateSubTestMustStapleEnabledSetup(t *testing.T) (*CertificateAuthorityImpl, *mockSA) {
 	ca, sa := issueCertificateSubTestDefaultSetup(t)
-	ca.enableMustStaple = true
+	ca.defaultProfile.mustStaple = mustStapleAllow
 	return ca, sa
 }
 
@@ -801,7 +1338,7 @@ func countMustStaple(t *testing.T, cert *x509.Certificate) (count int) {
 }
 
 func issueCertificateSubTestMustStapleWhenDisabled(t *testing.T, i *TestCertificateIssuance) {
-	// With ca.enableMustStaple = false, should issue successfully and not add
+	// With the "ignore" Must Staple policy, should issue successfully and not add
 	// Must Staple.
 	certType := "certificate"
 	if i.mode.issuePrecertificate {
@@ -814,7 +1351,7 @@ func issueCertificateSubTestMustStapleWhenDisabled(t *testing.T, i *TestCertific
 }
 
 func issueCertificateSubTestMustStapleWhenEnabled(t *testing.T, i *TestCertificateIssuance) {
-	// With ca.enableMustStaple = true, a TLS feature extension should put a must-staple
+	// With the "allow" Must Staple policy, a TLS feature extension should put a must-staple
 	// extension into the cert. Even if there are multiple TLS Feature extensions, only
 	// one extension should be included.
 	certType := "certificate"
@@ -876,6 +1413,50 @@ func signatureCountByPurpose(signatureType string, signatureCount *prometheus.Co
 	return test.CountCounterVec("purpose", signatureType, signatureCount)
 }
 
+func TestIssuePrecertificateStoresKeyHash(t *testing.T) {
+	testCtx := setup(t)
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+	orderID := int64(0)
+	issueReq := caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, OrderID: &orderID}
+
+	// Without the StoreKeyHashes feature no hash is stored.
+	_, err = ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue precert")
+	test.AssertEquals(t, len(sa.keyHashes), 0)
+
+	err = features.Set(map[string]bool{"StoreKeyHashes": true})
+	test.AssertNotError(t, err, "Failed to set feature flags")
+	defer features.Reset()
+
+	precert, err := ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue precert")
+	parsedPrecert, err := x509.ParseCertificate(precert.DER)
+	test.AssertNotError(t, err, "Failed to parse precert")
+	keyHash, err := core.KeySPKIHash(parsedPrecert.PublicKey)
+	test.AssertNotError(t, err, "Failed to hash key")
+	test.AssertEquals(t, len(sa.keyHashes), 1)
+	test.AssertByteEquals(t, sa.keyHashes[0].KeyHash, keyHash)
+	test.AssertEquals(t, *sa.keyHashes[0].Serial, core.SerialToString(parsedPrecert.SerialNumber))
+	test.AssertEquals(t, *sa.keyHashes[0].CertNotAfter, parsedPrecert.NotAfter.UnixNano())
+
+	// A precertificate isn't signed if its key hash can't be stored.
+	sa.failKeyHash = true
+	_, err = ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertError(t, err, "IssuePrecertificate succeeded without storing the key hash")
+	test.Assert(t, berrors.Is(err, berrors.InternalServer), fmt.Sprintf("unexpected error type: %s", err))
+}
+
 func TestIssueCertificateForPrecertificate(t *testing.T) {
 	testCtx := setup(t)
 	sa := &mockSA{}
@@ -942,6 +1523,118 @@ func TestIssueCertificateForPrecertificate(t *testing.T) {
 	test.Assert(t, list, "returned cert doesn't contain SCT list")
 }
 
+func TestNativeIssuance(t *testing.T) {
+	_ = features.Set(map[string]bool{"NonCFSSLSigner": true})
+	defer features.Reset()
+
+	testCtx := setup(t)
+	testCtx.caConfig.Issuance = &issuance.ProfileConfig{
+		IssuerURL:           "http://not-example.com/issuer-url",
+		OCSPURL:             "http://not-example.com/ocsp",
+		CRLURL:              "http://not-example.com/crl",
+		Policies:            []issuance.PolicyInformation{{OID: "2.23.140.1.2.1"}},
+		MaxValidityPeriod:   cmd.ConfigDuration{Duration: 8760 * time.Hour},
+		MaxValidityBackdate: cmd.ConfigDuration{Duration: time.Hour + time.Minute},
+	}
+	sa := &mockSA{}
+	ca, err := NewCertificateAuthorityImpl(
+		testCtx.caConfig,
+		sa,
+		testCtx.pa,
+		testCtx.fc,
+		testCtx.stats,
+		testCtx.issuers,
+		testCtx.keyPolicy,
+		testCtx.logger,
+		nil)
+	test.AssertNotError(t, err, "Failed to create CA")
+
+	orderID := int64(0)
+	issueReq := caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, OrderID: &orderID}
+	precert, err := ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue precert")
+	parsedPrecert, err := x509.ParseCertificate(precert.DER)
+	test.AssertNotError(t, err, "Failed to parse precert")
+	test.AssertNotError(t, parsedPrecert.CheckSignatureFrom(caCert), "Precert signature doesn't verify")
+	test.AssertDeepEquals(t, parsedPrecert.OCSPServer, []string{"http://not-example.com/ocsp"})
+	test.AssertEquals(t, parsedPrecert.NotAfter.Sub(parsedPrecert.NotBefore), 8760*time.Hour)
+	test.Assert(t, findExtension(parsedPrecert.Extensions, OIDExtensionCTPoison) != nil, "returned precert not poisoned")
+	test.AssertEquals(t, signatureCountByPurpose("precertificate", ca.signatureCount), 1)
+
+	sctBytes, err := cttls.Marshal(ct.SignedCertificateTimestamp{SCTVersion: 0, Timestamp: 2020})
+	test.AssertNotError(t, err, "Failed to marshal SCT")
+	cert, err := ca.IssueCertificateForPrecertificate(ctx, &caPB.IssueCertificateForPrecertificateRequest{
+		DER:            precert.DER,
+		SCTs:           [][]byte{sctBytes},
+		RegistrationID: &arbitraryRegID,
+		OrderID:        new(int64),
+	})
+	test.AssertNotError(t, err, "Failed to issue cert from precert")
+	parsedCert, err := x509.ParseCertificate(cert.DER)
+	test.AssertNotError(t, err, "Failed to parse cert")
+	test.AssertNotError(t, parsedCert.CheckSignatureFrom(caCert), "Cert signature doesn't verify")
+	test.AssertDeepEquals(t, parsedCert.DNSNames, parsedPrecert.DNSNames)
+	test.Assert(t, findExtension(parsedCert.Extensions, OIDExtensionCTPoison) == nil, "returned cert is poisoned")
+	test.Assert(t, findExtension(parsedCert.Extensions, signer.SCTListOID) != nil, "returned cert doesn't contain SCT list")
+
+	// The issuance profile must allow for the CA's backdate and validity.
+	testCtx.caConfig.Issuance.MaxValidityBackdate.Duration = time.Hour
+	_, err = NewCertificateAuthorityImpl(testCtx.caConfig, sa, testCtx.pa, testCtx.fc, testCtx.stats,
+		testCtx.issuers, testCtx.keyPolicy, testCtx.logger, nil)
+	test.AssertError(t, err, "CA accepted an issuance profile not allowing for its backdate")
+	testCtx.caConfig.Issuance.MaxValidityBackdate.Duration = 2 * time.Hour
+	testCtx.caConfig.Issuance.MaxValidityPeriod.Duration = 90 * 24 * time.Hour
+	_, err = NewCertificateAuthorityImpl(testCtx.caConfig, sa, testCtx.pa, testCtx.fc, testCtx.stats,
+		testCtx.issuers, testCtx.keyPolicy, testCtx.logger, nil)
+	test.AssertError(t, err, "CA accepted an issuance profile shorter than its certificate profiles")
+	testCtx.caConfig.Issuance = nil
+	_, err = NewCertificateAuthorityImpl(testCtx.caConfig, sa, testCtx.pa, testCtx.fc, testCtx.stats,
+		testCtx.issuers, testCtx.keyPolicy, testCtx.logger, nil)
+	test.AssertError(t, err, "CA accepted NonCFSSLSigner without an issuance profile")
+}
+
+func TestLintFailure(t *testing.T) {
+	testCtx := setup(t)
+	// Lints only apply to certificates issued after their effective dates.
+	testCtx.fc.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	testCtx.caConfig.Expiry = "2160h"
+	// Without an OCSP URL the certificate fails
+	// e_sub_cert_aia_does_not_contain_ocsp_url.
+	testCtx.caConfig.CFSSL.Signing.Profiles[rsaProfileName].OCSP = ""
+	sa := &mockSA{}
+	newCA := func() *CertificateAuthorityImpl {
+		ca, err := NewCertificateAuthorityImpl(
+			testCtx.caConfig,
+			sa,
+			testCtx.pa,
+			testCtx.fc,
+			testCtx.stats,
+			testCtx.issuers,
+			testCtx.keyPolicy,
+			testCtx.logger,
+			nil)
+		test.AssertNotError(t, err, "Failed to create CA")
+		return ca
+	}
+
+	ca := newCA()
+	issueReq := caPB.IssueCertificateRequest{Csr: CNandSANCSR, RegistrationID: &arbitraryRegID, OrderID: new(int64)}
+	_, err := ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertError(t, err, "CA signed a precertificate that fails linting")
+	test.Assert(t, strings.Contains(err.Error(), "e_sub_cert_aia_does_not_contain_ocsp_url"), "Error doesn't name the failed lint")
+	test.AssertEquals(t, signatureCountByPurpose("precertificate", ca.signatureCount), 0)
+
+	testCtx.caConfig.IgnoredLints = []string{"e_sub_cert_aia_does_not_contain_ocsp_url"}
+	ca = newCA()
+	_, err = ca.IssuePrecertificate(ctx, &issueReq)
+	test.AssertNotError(t, err, "Failed to issue precertificate failing only ignored lints")
+
+	testCtx.caConfig.IgnoredLints = []string{"e_no_such_lint"}
+	_, err = NewCertificateAuthorityImpl(testCtx.caConfig, sa, testCtx.pa, testCtx.fc, testCtx.stats,
+		testCtx.issuers, testCtx.keyPolicy, testCtx.logger, nil)
+	test.AssertError(t, err, "CA accepted an unknown lint name")
+}
+
 type queueSA struct {
 	fail      bool
 	duplicate bool
@@ -949,6 +1642,10 @@ type queueSA struct {
 	issued *time.Time
 }
 
+func (qsa *queueSA) AddKeyHash(_ context.Context, _ *sapb.AddKeyHashRequest) error {
+	return nil
+}
+
 func (qsa *queueSA) AddCertificate(_ context.Context, _ []byte, _ int64, _ []byte, issued *time.Time) (string, error) {
 	if qsa.fail {
 		return "", errors.New("bad")
@@ -5,6 +5,7 @@ import (
 	"github.com/letsencrypt/pkcs11key"
 
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/issuance"
 )
 
 // CAConfig structs have configuration information for the certificate
@@ -17,6 +18,7 @@ type CAConfig struct {
 
 	GRPCCA            *cmd.GRPCServerConfig
 	GRPCOCSPGenerator *cmd.GRPCServerConfig
+	GRPCCRLGenerator  *cmd.GRPCServerConfig
 
 	RSAProfile   string
 	ECDSAProfile string
@@ -25,11 +27,17 @@ type CAConfig struct {
 	// TODO(jsha): Remove Key field once we've migrated to Issuers
 	Key *IssuerConfig
 	// Issuers contains configuration information for each issuer cert and key
-	// this CA knows about. The first in the list is used as the default.
+	// this CA knows about. All of them sign OCSP and CRLs for the certificates
+	// they issued. The first in the list is the default issuer of certificates,
+	// for certificate profiles that don't select another by key type.
 	Issuers []IssuerConfig
 	// LifespanOCSP is how long OCSP responses are valid for; It should be longer
 	// than the minTimeToExpiry field for the OCSP Updater.
 	LifespanOCSP cmd.ConfigDuration
+	// LifespanCRL is how long CRLs are valid for, i.e. the distance between a
+	// CRL's thisUpdate and nextUpdate. It should be longer than the
+	// updatePeriod of the CRL Updater. If unset the CA refuses to sign CRLs.
+	LifespanCRL cmd.ConfigDuration
 	// How long issued certificates are valid for, should match expiry field
 	// in cfssl config.
 	Expiry string
@@ -49,6 +57,25 @@ type CAConfig struct {
 	// triggers issuance of certificates with Must Staple.
 	EnableMustStaple bool
 
+	// CertProfiles maps names to the certificate profiles that issuance
+	// requests can select. If it is empty a single profile, named by
+	// DefaultCertProfile, is built from RSAProfile, ECDSAProfile, Expiry and
+	// EnableMustStaple.
+	CertProfiles map[string]CertProfileConfig
+
+	// DefaultCertProfile names the certificate profile used for requests that
+	// don't select one.
+	DefaultCertProfile string
+
+	// IgnoredLints names the lints whose errors don't prevent the CA from
+	// signing a certificate.
+	IgnoredLints []string
+
+	// Issuance is the profile of the certificates issued when the
+	// NonCFSSLSigner feature is enabled, in which case the certificate
+	// profiles don't need to name CFSSL signing profiles.
+	Issuance *issuance.ProfileConfig
+
 	// EnablePrecertificateFlow governs whether precertificate-based issuance
 	// is enabled.
 	EnablePrecertificateFlow bool
@@ -66,6 +93,30 @@ type CAConfig struct {
 	Features map[string]bool
 }
 
+// CertProfileConfig describes a named certificate profile. The key usages and
+// extensions of the certificates issued under it come from the CFSSL signing
+// profiles it names, or from the Issuance profile when the NonCFSSLSigner
+// feature is enabled.
+type CertProfileConfig struct {
+	// RSAProfile and ECDSAProfile name the CFSSL signing profiles used for
+	// RSA and ECDSA subscriber keys respectively.
+	RSAProfile   string
+	ECDSAProfile string
+	// RSAIssuer and ECDSAIssuer are the common names of the issuers that sign
+	// certificates for RSA and ECDSA subscriber keys respectively. Each
+	// defaults to the first of the CA's Issuers. The CFSSL signing profiles
+	// should carry the URLs of the issuer they are used with.
+	RSAIssuer   string
+	ECDSAIssuer string
+	// Validity is how long certificates issued under this profile are valid
+	// for.
+	Validity cmd.ConfigDuration
+	// MustStaple is one of "ignore", which never includes the Must Staple
+	// extension, "allow", which includes it when the CSR requests it, and
+	// "require", which always includes it. Defaults to "ignore".
+	MustStaple string
+}
+
 // IssuerConfig contains info about an issuer: private key and issuer cert.
 // It should contain either a File path to a PEM-format private key,
 // or a PKCS11Config defining how to load a module for an HSM.
@@ -78,4 +129,15 @@ type IssuerConfig struct {
 	// Number of sessions to open with the HSM. For maximum performance,
 	// this should be equal to the number of cores in the HSM. Defaults to 1.
 	NumSessions int
+	// IssuerURL, OCSPURL and CRLURL, if set, replace those of the Issuance
+	// profile in certificates signed by this issuer when the NonCFSSLSigner
+	// feature is enabled.
+	IssuerURL string
+	OCSPURL   string
+	CRLURL    string
+	// OCSPResponder optionally configures the private key and certificate of a
+	// delegated OCSP responder for this issuer, loaded in the same way as the
+	// issuer's own. When set, OCSP responses are signed by the responder key
+	// instead of the issuer key. Its own OCSPResponder field must be unset.
+	OCSPResponder *IssuerConfig
 }
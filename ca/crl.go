@@ -0,0 +1,203 @@
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	_ "crypto/sha256" // for crypto.SHA256.New
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/revocation"
+)
+
+// CRL related OIDs, from RFC 5280 Section 5.
+var (
+	oidCRLNumber                = asn1.ObjectIdentifier{2, 5, 29, 20}
+	oidCRLReasonCode            = asn1.ObjectIdentifier{2, 5, 29, 21}
+	oidIssuingDistributionPoint = asn1.ObjectIdentifier{2, 5, 29, 28}
+	oidSignatureSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSignatureECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+const (
+	// crlVersion2 is the encoded value of the v2 CRL version field.
+	crlVersion2 = 1
+	// uriNameTag is the GeneralName tag for a uniformResourceIdentifier.
+	uriNameTag = 6
+)
+
+// tbsCertList mirrors pkix.TBSCertificateList, except that the issuer is
+// carried as a raw value so that the issuer's subject is copied byte for byte
+// from the issuer certificate rather than being re-encoded.
+type tbsCertList struct {
+	Version             int `asn1:"optional,default:0"`
+	Signature           pkix.AlgorithmIdentifier
+	Issuer              asn1.RawValue
+	ThisUpdate          time.Time
+	NextUpdate          time.Time                 `asn1:"optional"`
+	RevokedCertificates []pkix.RevokedCertificate `asn1:"optional"`
+	Extensions          []pkix.Extension          `asn1:"tag:0,optional,explicit"`
+}
+
+type certificateList struct {
+	TBSCertList        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	SignatureValue     asn1.BitString
+}
+
+// distributionPointName and issuingDistributionPoint are the subset of the
+// IssuingDistributionPoint extension (RFC 5280 Section 5.2.5) that we emit: a
+// single URI and the onlyContainsUserCerts flag.
+type distributionPointName struct {
+	FullName []asn1.RawValue `asn1:"optional,tag:0"`
+}
+
+type issuingDistributionPoint struct {
+	DistributionPoint     distributionPointName `asn1:"optional,tag:0"`
+	OnlyContainsUserCerts bool                  `asn1:"optional,tag:1"`
+}
+
+type authKeyID struct {
+	ID []byte `asn1:"optional,tag:0"`
+}
+
+// GenerateCRL produces a new CRL containing the provided entries, signed by
+// the issuer named in the request, and returns its DER encoding. Each shard of
+// an issuer's CRL is signed separately; the caller is responsible for deciding
+// which entries belong to which shard.
+func (ca *CertificateAuthorityImpl) GenerateCRL(ctx context.Context, crlReq core.CRLSigningRequest) ([]byte, error) {
+	if ca.lifespanCRL == 0 {
+		return nil, berrors.InternalServerError("this CA is not configured to sign CRLs")
+	}
+	issuer := ca.issuers[crlReq.IssuerCommonName]
+	if issuer == nil {
+		return nil, fmt.Errorf("This CA doesn't have an issuer cert with CommonName %q", crlReq.IssuerCommonName)
+	}
+
+	sigAlg, hashFunc, err := crlSignatureAlgorithm(issuer.signer)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked := make([]pkix.RevokedCertificate, 0, len(crlReq.Entries))
+	for _, entry := range crlReq.Entries {
+		serial, err := core.StringToSerial(entry.Serial)
+		if err != nil {
+			return nil, berrors.MalformedError("invalid serial %q in CRL entry", entry.Serial)
+		}
+		rc := pkix.RevokedCertificate{
+			SerialNumber:   serial,
+			RevocationTime: entry.RevokedAt.UTC(),
+		}
+		// RFC 5280 Section 5.3.1 says the reasonCode extension should be absent
+		// instead of using the unspecified (0) reason code.
+		if entry.Reason != revocation.Unspecified {
+			reason, err := asn1.Marshal(asn1.Enumerated(entry.Reason))
+			if err != nil {
+				return nil, err
+			}
+			rc.Extensions = []pkix.Extension{{Id: oidCRLReasonCode, Value: reason}}
+		}
+		revoked = append(revoked, rc)
+	}
+
+	thisUpdate := ca.clk.Now().UTC()
+	extensions, err := ca.crlExtensions(issuer, thisUpdate, crlReq.DistributionPoint)
+	if err != nil {
+		return nil, err
+	}
+
+	tbs := tbsCertList{
+		Version:             crlVersion2,
+		Signature:           sigAlg,
+		Issuer:              asn1.RawValue{FullBytes: issuer.cert.RawSubject},
+		ThisUpdate:          thisUpdate,
+		NextUpdate:          thisUpdate.Add(ca.lifespanCRL),
+		RevokedCertificates: revoked,
+		Extensions:          extensions,
+	}
+	tbsDER, err := asn1.Marshal(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	h := hashFunc.New()
+	h.Write(tbsDER)
+	signature, err := issuer.signer.Sign(rand.Reader, h.Sum(nil), hashFunc)
+	ca.noteSignError(err)
+	if err != nil {
+		return nil, err
+	}
+	ca.signatureCount.With(prometheus.Labels{"purpose": "crl"}).Inc()
+
+	ca.log.AuditInfof("Signed CRL: issuer=[%s] shard=[%d] entries=[%d] thisUpdate=[%s]",
+		crlReq.IssuerCommonName, crlReq.Shard, len(revoked), thisUpdate)
+
+	return asn1.Marshal(certificateList{
+		TBSCertList:        asn1.RawValue{FullBytes: tbsDER},
+		SignatureAlgorithm: sigAlg,
+		SignatureValue:     asn1.BitString{Bytes: signature, BitLength: len(signature) * 8},
+	})
+}
+
+// crlExtensions returns the CRL extensions for a CRL signed by issuer at
+// thisUpdate. The CRL number is derived from thisUpdate so that it increases
+// monotonically without the CA having to keep any state.
+func (ca *CertificateAuthorityImpl) crlExtensions(issuer *internalIssuer, thisUpdate time.Time, distributionPoint string) ([]pkix.Extension, error) {
+	aki, err := asn1.Marshal(authKeyID{ID: issuer.cert.SubjectKeyId})
+	if err != nil {
+		return nil, err
+	}
+	number, err := asn1.Marshal(big.NewInt(thisUpdate.UnixNano()))
+	if err != nil {
+		return nil, err
+	}
+	extensions := []pkix.Extension{
+		{Id: oidAuthorityKeyIdentifier, Value: aki},
+		{Id: oidCRLNumber, Value: number},
+	}
+	if distributionPoint != "" {
+		idp, err := asn1.Marshal(issuingDistributionPoint{
+			DistributionPoint: distributionPointName{
+				FullName: []asn1.RawValue{
+					{Tag: uriNameTag, Class: asn1.ClassContextSpecific, Bytes: []byte(distributionPoint)},
+				},
+			},
+			OnlyContainsUserCerts: true,
+		})
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, pkix.Extension{Id: oidIssuingDistributionPoint, Critical: true, Value: idp})
+	}
+	return extensions, nil
+}
+
+// crlSignatureAlgorithm picks the signature algorithm used for CRLs based on
+// the type of the issuer's key. CRLs are always signed using SHA-256.
+func crlSignatureAlgorithm(signer crypto.Signer) (pkix.AlgorithmIdentifier, crypto.Hash, error) {
+	switch signer.Public().(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{
+			Algorithm:  oidSignatureSHA256WithRSA,
+			Parameters: asn1.NullRawValue,
+		}, crypto.SHA256, nil
+	case *ecdsa.PublicKey:
+		return pkix.AlgorithmIdentifier{
+			Algorithm: oidSignatureECDSAWithSHA256,
+		}, crypto.SHA256, nil
+	default:
+		return pkix.AlgorithmIdentifier{}, 0, errors.New("unsupported issuer key type for CRL signing")
+	}
+}
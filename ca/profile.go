@@ -0,0 +1,179 @@
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"time"
+
+	cfsslConfig "github.com/cloudflare/cfssl/config"
+
+	"github.com/letsencrypt/boulder/ca/config"
+	"github.com/letsencrypt/boulder/cmd"
+	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
+)
+
+// mustStaplePolicy controls whether certificates issued under a profile carry
+// the TLS Feature extension with the "must staple" value.
+type mustStaplePolicy string
+
+const (
+	// mustStapleIgnore never includes Must Staple, even if the CSR requests it.
+	mustStapleIgnore = mustStaplePolicy("ignore")
+	// mustStapleAllow includes Must Staple when the CSR requests it.
+	mustStapleAllow = mustStaplePolicy("allow")
+	// mustStapleRequire includes Must Staple whether or not the CSR requests
+	// it.
+	mustStapleRequire = mustStaplePolicy("require")
+)
+
+// certProfile is a named set of issuance parameters that a request can select.
+// The key usages and extensions are provided by the CFSSL signing profiles
+// named by rsaProfile and ecdsaProfile, while the validity period and Must
+// Staple policy are enforced by the CA itself. Certificates for RSA and ECDSA
+// subscriber keys are signed by rsaIssuer and ecdsaIssuer respectively.
+type certProfile struct {
+	name         string
+	rsaProfile   string
+	ecdsaProfile string
+	rsaIssuer    *internalIssuer
+	ecdsaIssuer  *internalIssuer
+	validity     time.Duration
+	mustStaple   mustStaplePolicy
+}
+
+// signingProfile returns the name of the CFSSL signing profile to use for the
+// given subscriber public key.
+func (p *certProfile) signingProfile(pub interface{}) (string, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return p.rsaProfile, nil
+	case *ecdsa.PublicKey:
+		return p.ecdsaProfile, nil
+	default:
+		return "", berrors.InternalServerError("unsupported key type %T", pub)
+	}
+}
+
+// issuerFor returns the issuer that signs certificates for the given
+// subscriber public key.
+func (p *certProfile) issuerFor(pub interface{}) (*internalIssuer, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		return p.rsaIssuer, nil
+	case *ecdsa.PublicKey:
+		return p.ecdsaIssuer, nil
+	default:
+		return nil, berrors.InternalServerError("unsupported key type %T", pub)
+	}
+}
+
+// loadCertProfiles builds the CA's certificate profiles from its config,
+// checking that each names signing profiles that CFSSL knows about, unless
+// the NonCFSSLSigner feature is enabled and they aren't used, and issuers
+// that are among the CA's issuers, which are keyed by common name. Profiles
+// that don't name an issuer for a key type use defaultIssuer. It returns the
+// profiles by name along with the default profile. A config without
+// CertProfiles gets a single profile built from the legacy RSAProfile,
+// ECDSAProfile, Expiry and EnableMustStaple fields.
+func loadCertProfiles(
+	config ca_config.CAConfig,
+	signing *cfsslConfig.Signing,
+	issuers map[string]*internalIssuer,
+	defaultIssuer *internalIssuer,
+) (map[string]*certProfile, *certProfile, error) {
+	useCFSSL := !features.Enabled(features.NonCFSSLSigner)
+	profileConfigs := config.CertProfiles
+	if len(profileConfigs) == 0 {
+		if useCFSSL && (config.RSAProfile == "" || config.ECDSAProfile == "") {
+			return nil, nil, errors.New("must specify rsaProfile and ecdsaProfile")
+		}
+		if config.Expiry == "" {
+			return nil, nil, errors.New("Config must specify an expiry period.")
+		}
+		expiry, err := time.ParseDuration(config.Expiry)
+		if err != nil {
+			return nil, nil, err
+		}
+		mustStaple := mustStapleIgnore
+		if config.EnableMustStaple {
+			mustStaple = mustStapleAllow
+		}
+		profileConfigs = map[string]ca_config.CertProfileConfig{
+			config.DefaultCertProfile: {
+				RSAProfile:   config.RSAProfile,
+				ECDSAProfile: config.ECDSAProfile,
+				Validity:     cmd.ConfigDuration{Duration: expiry},
+				MustStaple:   string(mustStaple),
+			},
+		}
+	}
+
+	profiles := make(map[string]*certProfile, len(profileConfigs))
+	for name, pc := range profileConfigs {
+		if useCFSSL {
+			if pc.RSAProfile == "" || pc.ECDSAProfile == "" {
+				return nil, nil, fmt.Errorf("certificate profile %q must specify rsaProfile and ecdsaProfile", name)
+			}
+			for _, signingProfile := range []string{pc.RSAProfile, pc.ECDSAProfile} {
+				if signing == nil || signing.Profiles[signingProfile] == nil {
+					return nil, nil, fmt.Errorf("certificate profile %q names unknown CFSSL profile %q", name, signingProfile)
+				}
+			}
+		}
+		if pc.Validity.Duration <= 0 {
+			return nil, nil, fmt.Errorf("certificate profile %q must specify a positive validity period", name)
+		}
+		rsaIssuer, ecdsaIssuer := defaultIssuer, defaultIssuer
+		if pc.RSAIssuer != "" {
+			rsaIssuer = issuers[pc.RSAIssuer]
+			if rsaIssuer == nil {
+				return nil, nil, fmt.Errorf("certificate profile %q names unknown RSA issuer %q", name, pc.RSAIssuer)
+			}
+		}
+		if pc.ECDSAIssuer != "" {
+			ecdsaIssuer = issuers[pc.ECDSAIssuer]
+			if ecdsaIssuer == nil {
+				return nil, nil, fmt.Errorf("certificate profile %q names unknown ECDSA issuer %q", name, pc.ECDSAIssuer)
+			}
+		}
+		mustStaple := mustStaplePolicy(pc.MustStaple)
+		switch mustStaple {
+		case "":
+			mustStaple = mustStapleIgnore
+		case mustStapleIgnore, mustStapleAllow, mustStapleRequire:
+		default:
+			return nil, nil, fmt.Errorf("certificate profile %q has unknown mustStaple policy %q", name, pc.MustStaple)
+		}
+		profiles[name] = &certProfile{
+			name:         name,
+			rsaProfile:   pc.RSAProfile,
+			ecdsaProfile: pc.ECDSAProfile,
+			rsaIssuer:    rsaIssuer,
+			ecdsaIssuer:  ecdsaIssuer,
+			validity:     pc.Validity.Duration,
+			mustStaple:   mustStaple,
+		}
+	}
+
+	defaultProfile := profiles[config.DefaultCertProfile]
+	if defaultProfile == nil {
+		return nil, nil, fmt.Errorf("default certificate profile %q is not configured", config.DefaultCertProfile)
+	}
+	return profiles, defaultProfile, nil
+}
+
+// certProfileFor returns the certificate profile selected by name, or the
+// default profile if name is empty.
+func (ca *CertificateAuthorityImpl) certProfileFor(name string) (*certProfile, error) {
+	if name == "" {
+		return ca.defaultProfile, nil
+	}
+	profile, ok := ca.certProfiles[name]
+	if !ok {
+		return nil, berrors.MalformedError("unknown certificate profile %q", name)
+	}
+	return profile, nil
+}
@@ -26,12 +26,13 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
 type IssueCertificateRequest struct {
-	Csr                  []byte   `protobuf:"bytes,1,opt,name=csr" json:"csr,omitempty"`
-	RegistrationID       *int64   `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
-	OrderID              *int64   `protobuf:"varint,3,opt,name=orderID" json:"orderID,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Csr                    []byte   `protobuf:"bytes,1,opt,name=csr" json:"csr,omitempty"`
+	RegistrationID         *int64   `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	OrderID                *int64   `protobuf:"varint,3,opt,name=orderID" json:"orderID,omitempty"`
+	CertificateProfileName *string  `protobuf:"bytes,4,opt,name=certificateProfileName" json:"certificateProfileName,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{} `json:"-"`
+	XXX_unrecognized       []byte   `json:"-"`
+	XXX_sizecache          int32    `json:"-"`
 }
 
 func (m *IssueCertificateRequest) Reset()         { *m = IssueCertificateRequest{} }
@@ -80,6 +81,13 @@ func (m *IssueCertificateRequest) GetOrderID() int64 {
 	return 0
 }
 
+func (m *IssueCertificateRequest) GetCertificateProfileName() string {
+	if m != nil && m.CertificateProfileName != nil {
+		return *m.CertificateProfileName
+	}
+	return ""
+}
+
 type IssuePrecertificateResponse struct {
 	DER                  []byte   `protobuf:"bytes,1,opt,name=DER" json:"DER,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -286,44 +294,214 @@ func (m *OCSPResponse) GetResponse() []byte {
 	return nil
 }
 
+type CRLEntry struct {
+	Serial               *string  `protobuf:"bytes,1,opt,name=serial" json:"serial,omitempty"`
+	Reason               *int32   `protobuf:"varint,2,opt,name=reason" json:"reason,omitempty"`
+	RevokedAt            *int64   `protobuf:"varint,3,opt,name=revokedAt" json:"revokedAt,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CRLEntry) Reset()         { *m = CRLEntry{} }
+func (m *CRLEntry) String() string { return proto.CompactTextString(m) }
+func (*CRLEntry) ProtoMessage()    {}
+func (*CRLEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f9fdc2529716820, []int{5}
+}
+
+func (m *CRLEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CRLEntry.Unmarshal(m, b)
+}
+func (m *CRLEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CRLEntry.Marshal(b, m, deterministic)
+}
+func (m *CRLEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CRLEntry.Merge(m, src)
+}
+func (m *CRLEntry) XXX_Size() int {
+	return xxx_messageInfo_CRLEntry.Size(m)
+}
+func (m *CRLEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_CRLEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CRLEntry proto.InternalMessageInfo
+
+func (m *CRLEntry) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *CRLEntry) GetReason() int32 {
+	if m != nil && m.Reason != nil {
+		return *m.Reason
+	}
+	return 0
+}
+
+func (m *CRLEntry) GetRevokedAt() int64 {
+	if m != nil && m.RevokedAt != nil {
+		return *m.RevokedAt
+	}
+	return 0
+}
+
+type GenerateCRLRequest struct {
+	IssuerCommonName     *string     `protobuf:"bytes,1,opt,name=issuerCommonName" json:"issuerCommonName,omitempty"`
+	Shard                *int64      `protobuf:"varint,2,opt,name=shard" json:"shard,omitempty"`
+	DistributionPoint    *string     `protobuf:"bytes,3,opt,name=distributionPoint" json:"distributionPoint,omitempty"`
+	Entries              []*CRLEntry `protobuf:"bytes,4,rep,name=entries" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *GenerateCRLRequest) Reset()         { *m = GenerateCRLRequest{} }
+func (m *GenerateCRLRequest) String() string { return proto.CompactTextString(m) }
+func (*GenerateCRLRequest) ProtoMessage()    {}
+func (*GenerateCRLRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f9fdc2529716820, []int{6}
+}
+
+func (m *GenerateCRLRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GenerateCRLRequest.Unmarshal(m, b)
+}
+func (m *GenerateCRLRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GenerateCRLRequest.Marshal(b, m, deterministic)
+}
+func (m *GenerateCRLRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GenerateCRLRequest.Merge(m, src)
+}
+func (m *GenerateCRLRequest) XXX_Size() int {
+	return xxx_messageInfo_GenerateCRLRequest.Size(m)
+}
+func (m *GenerateCRLRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GenerateCRLRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GenerateCRLRequest proto.InternalMessageInfo
+
+func (m *GenerateCRLRequest) GetIssuerCommonName() string {
+	if m != nil && m.IssuerCommonName != nil {
+		return *m.IssuerCommonName
+	}
+	return ""
+}
+
+func (m *GenerateCRLRequest) GetShard() int64 {
+	if m != nil && m.Shard != nil {
+		return *m.Shard
+	}
+	return 0
+}
+
+func (m *GenerateCRLRequest) GetDistributionPoint() string {
+	if m != nil && m.DistributionPoint != nil {
+		return *m.DistributionPoint
+	}
+	return ""
+}
+
+func (m *GenerateCRLRequest) GetEntries() []*CRLEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type CRLResponse struct {
+	Crl                  []byte   `protobuf:"bytes,1,opt,name=crl" json:"crl,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CRLResponse) Reset()         { *m = CRLResponse{} }
+func (m *CRLResponse) String() string { return proto.CompactTextString(m) }
+func (*CRLResponse) ProtoMessage()    {}
+func (*CRLResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_8f9fdc2529716820, []int{7}
+}
+
+func (m *CRLResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CRLResponse.Unmarshal(m, b)
+}
+func (m *CRLResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CRLResponse.Marshal(b, m, deterministic)
+}
+func (m *CRLResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CRLResponse.Merge(m, src)
+}
+func (m *CRLResponse) XXX_Size() int {
+	return xxx_messageInfo_CRLResponse.Size(m)
+}
+func (m *CRLResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CRLResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CRLResponse proto.InternalMessageInfo
+
+func (m *CRLResponse) GetCrl() []byte {
+	if m != nil {
+		return m.Crl
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*IssueCertificateRequest)(nil), "ca.IssueCertificateRequest")
 	proto.RegisterType((*IssuePrecertificateResponse)(nil), "ca.IssuePrecertificateResponse")
 	proto.RegisterType((*IssueCertificateForPrecertificateRequest)(nil), "ca.IssueCertificateForPrecertificateRequest")
 	proto.RegisterType((*GenerateOCSPRequest)(nil), "ca.GenerateOCSPRequest")
 	proto.RegisterType((*OCSPResponse)(nil), "ca.OCSPResponse")
+	proto.RegisterType((*CRLEntry)(nil), "ca.CRLEntry")
+	proto.RegisterType((*GenerateCRLRequest)(nil), "ca.GenerateCRLRequest")
+	proto.RegisterType((*CRLResponse)(nil), "ca.CRLResponse")
 }
 
 func init() { proto.RegisterFile("ca/proto/ca.proto", fileDescriptor_8f9fdc2529716820) }
 
 var fileDescriptor_8f9fdc2529716820 = []byte{
-	// 401 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x53, 0x41, 0xab, 0xd3, 0x40,
-	0x10, 0x6e, 0x92, 0xf7, 0xac, 0x6f, 0x88, 0xd2, 0xb7, 0x4f, 0x7d, 0x21, 0x15, 0xac, 0x39, 0x48,
-	0x10, 0x49, 0xa1, 0x57, 0x4f, 0xb5, 0xa9, 0x52, 0x10, 0x2c, 0x5b, 0xbd, 0x78, 0x5b, 0xb6, 0x53,
-	0x0d, 0x62, 0xb6, 0xce, 0x6e, 0x04, 0x0f, 0xfe, 0x09, 0x7f, 0x9d, 0x3f, 0x47, 0xb2, 0x4d, 0xda,
-	0x34, 0xa4, 0xf6, 0xf0, 0x6e, 0x33, 0xf3, 0x65, 0xe7, 0xfb, 0xe6, 0x9b, 0x09, 0x5c, 0x4b, 0x31,
-	0xde, 0x92, 0x32, 0x6a, 0x2c, 0x45, 0x62, 0x03, 0xe6, 0x4a, 0x11, 0x3e, 0x96, 0x8a, 0xb0, 0x06,
-	0x14, 0xe1, 0x0e, 0x8a, 0xbe, 0xc3, 0xed, 0x42, 0xeb, 0x02, 0x67, 0x48, 0x26, 0xdb, 0x64, 0x52,
-	0x18, 0xe4, 0xf8, 0xa3, 0x40, 0x6d, 0xd8, 0x00, 0x3c, 0xa9, 0x29, 0x70, 0x46, 0x4e, 0xec, 0xf3,
-	0x32, 0x64, 0x2f, 0xe0, 0x21, 0xe1, 0x97, 0x4c, 0x1b, 0x12, 0x26, 0x53, 0xf9, 0x22, 0x0d, 0xdc,
-	0x91, 0x13, 0x7b, 0xbc, 0x55, 0x65, 0x01, 0xf4, 0x15, 0xad, 0x91, 0x16, 0x69, 0xe0, 0xd9, 0x0f,
-	0xea, 0x34, 0x1a, 0xc3, 0xd0, 0xd2, 0x2d, 0x09, 0x65, 0x93, 0x51, 0x6f, 0x55, 0xae, 0xb1, 0xa4,
-	0x4c, 0xe7, 0xbc, 0xa6, 0x4c, 0xe7, 0x3c, 0xfa, 0xe3, 0x40, 0xdc, 0x16, 0xf8, 0x56, 0x51, 0xfb,
-	0xfd, 0x5e, 0xf1, 0xf1, 0x73, 0xc6, 0xe0, 0x62, 0x35, 0xfb, 0xa8, 0x03, 0x77, 0xe4, 0xc5, 0x3e,
-	0xb7, 0x71, 0xc7, 0x14, 0xde, 0xb9, 0x29, 0x2e, 0x8e, 0xa7, 0xf8, 0x0d, 0x37, 0xef, 0x30, 0x47,
-	0x12, 0x06, 0x3f, 0xcc, 0x56, 0xcb, 0x9a, 0x3e, 0x80, 0x7e, 0x29, 0xea, 0x20, 0xa1, 0x4e, 0xd9,
-	0x13, 0xb8, 0xa7, 0x8d, 0x30, 0x85, 0xb6, 0x86, 0x5d, 0xf1, 0x2a, 0x2b, 0xeb, 0x84, 0x42, 0xab,
-	0xdc, 0x4a, 0xb8, 0xe4, 0x55, 0xc6, 0x9e, 0xc2, 0x15, 0xe1, 0x4f, 0xf5, 0x0d, 0xd7, 0x53, 0x53,
-	0x91, 0x1f, 0x0a, 0xd1, 0x4b, 0xf0, 0x77, 0xb4, 0x95, 0x6b, 0x21, 0xdc, 0xa7, 0x2a, 0xae, 0x88,
-	0xf7, 0xf9, 0xe4, 0xaf, 0x0b, 0x8f, 0x1a, 0xd6, 0x4d, 0x0b, 0xf3, 0x55, 0x51, 0x66, 0x7e, 0xb1,
-	0x14, 0x06, 0x6d, 0x5f, 0xd9, 0x30, 0x91, 0x22, 0x39, 0x71, 0x0e, 0xe1, 0x75, 0x62, 0xcf, 0xa6,
-	0x81, 0x44, 0x3d, 0xf6, 0x09, 0x6e, 0x3a, 0xf6, 0xf9, 0xff, 0x46, 0xcf, 0xf6, 0x60, 0xf7, 0x15,
-	0x44, 0x3d, 0xb6, 0x81, 0xe7, 0x67, 0x97, 0xce, 0x5e, 0x75, 0x91, 0x9c, 0xba, 0x8d, 0x6e, 0xf9,
-	0xaf, 0xc1, 0x6f, 0x2e, 0x92, 0xdd, 0x96, 0x2d, 0x3b, 0x56, 0x1b, 0x0e, 0x4a, 0xa0, 0x69, 0x7a,
-	0xd4, 0x9b, 0xbc, 0x87, 0x07, 0x65, 0xa5, 0xfa, 0x5c, 0xd1, 0x9d, 0xba, 0xbd, 0xe9, 0x7f, 0xbe,
-	0xb4, 0x7f, 0xe4, 0xbf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x16, 0x23, 0x09, 0x70, 0xc0, 0x03, 0x00,
-	0x00,
+	// 576 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xa4, 0x54, 0xc1, 0x6e, 0xd3, 0x40,
+	0x10, 0x8d, 0xe3, 0x84, 0x34, 0x13, 0x03, 0xc9, 0xb6, 0xa4, 0x56, 0x8a, 0xd4, 0xe0, 0x43, 0x65,
+	0x55, 0x55, 0x22, 0xe5, 0x80, 0x90, 0x38, 0x15, 0x27, 0x40, 0xa4, 0x08, 0xa2, 0x2d, 0x48, 0x88,
+	0xdb, 0xe2, 0x6c, 0xa8, 0x45, 0xe2, 0x2d, 0xb3, 0x1b, 0xa4, 0x1e, 0xf8, 0x09, 0xfe, 0x81, 0x1b,
+	0x1f, 0xc4, 0xe7, 0xa0, 0xdd, 0xd8, 0xb1, 0x9b, 0x3a, 0xf4, 0xd0, 0xdb, 0xce, 0x3c, 0xef, 0xbc,
+	0x37, 0xb3, 0x6f, 0x0c, 0xad, 0x90, 0xf5, 0xaf, 0x50, 0x28, 0xd1, 0x0f, 0x59, 0xcf, 0x1c, 0x48,
+	0x39, 0x64, 0x9d, 0x27, 0xa1, 0x40, 0x9e, 0x02, 0x02, 0xf9, 0x1a, 0xf2, 0x7e, 0x5b, 0x70, 0x38,
+	0x96, 0x72, 0xc5, 0x03, 0x8e, 0x2a, 0x9a, 0x47, 0x21, 0x53, 0x9c, 0xf2, 0xef, 0x2b, 0x2e, 0x15,
+	0x69, 0x82, 0x1d, 0x4a, 0x74, 0xad, 0xae, 0xe5, 0x3b, 0x54, 0x1f, 0xc9, 0x09, 0x3c, 0x42, 0xfe,
+	0x35, 0x92, 0x0a, 0x99, 0x8a, 0x44, 0x3c, 0x1e, 0xba, 0xe5, 0xae, 0xe5, 0xdb, 0x74, 0x2b, 0x4b,
+	0x5c, 0xa8, 0x09, 0x9c, 0x71, 0x1c, 0x0f, 0x5d, 0xdb, 0x7c, 0x90, 0x86, 0xe4, 0x39, 0xb4, 0xc3,
+	0x8c, 0x69, 0x8a, 0x62, 0x1e, 0x2d, 0xf8, 0x3b, 0xb6, 0xe4, 0x6e, 0xa5, 0x6b, 0xf9, 0x75, 0xba,
+	0x03, 0xf5, 0xfa, 0x70, 0x64, 0x64, 0x4e, 0x91, 0x87, 0x79, 0xa5, 0xf2, 0x4a, 0xc4, 0x92, 0x6b,
+	0xa9, 0xc3, 0x11, 0x4d, 0xa5, 0x0e, 0x47, 0xd4, 0xfb, 0x65, 0x81, 0xbf, 0xdd, 0xd8, 0x6b, 0x81,
+	0xdb, 0xf7, 0x37, 0x9d, 0xde, 0xbc, 0x4e, 0x08, 0x54, 0x2e, 0x82, 0x0f, 0xd2, 0x2d, 0x77, 0x6d,
+	0xdf, 0xa1, 0xe6, 0x5c, 0xd0, 0xbd, 0x7d, 0x57, 0xf7, 0x95, 0x1b, 0xdd, 0x7b, 0x3f, 0x61, 0xff,
+	0x0d, 0x8f, 0x39, 0x32, 0xc5, 0xdf, 0x07, 0x17, 0xd3, 0x94, 0xde, 0x85, 0x9a, 0x16, 0x95, 0x49,
+	0x48, 0x43, 0xd2, 0x86, 0x07, 0x52, 0x31, 0xb5, 0x92, 0x66, 0xd0, 0x75, 0x9a, 0x44, 0x3a, 0x8f,
+	0x9c, 0x49, 0x11, 0x1b, 0x09, 0x55, 0x9a, 0x44, 0xe4, 0x29, 0xd4, 0x91, 0xff, 0x10, 0xdf, 0xf8,
+	0xec, 0x5c, 0x25, 0xe4, 0x59, 0xc2, 0x3b, 0x05, 0x67, 0x4d, 0x9b, 0x4c, 0xad, 0x03, 0x7b, 0x98,
+	0x9c, 0x13, 0xe2, 0x4d, 0xec, 0x7d, 0x82, 0xbd, 0x80, 0x4e, 0x46, 0xb1, 0xc2, 0x6b, 0xa3, 0x82,
+	0x63, 0xc4, 0x16, 0xae, 0x95, 0xa8, 0x30, 0x51, 0x4e, 0x45, 0x79, 0xb7, 0x0a, 0x7b, 0x5b, 0xc5,
+	0x1f, 0x0b, 0x48, 0x3a, 0x85, 0x80, 0x4e, 0xd2, 0x21, 0x9c, 0x42, 0x33, 0xd2, 0xef, 0x85, 0x81,
+	0x58, 0x2e, 0x45, 0x6c, 0x3c, 0xb1, 0xa6, 0xbb, 0x95, 0x27, 0x07, 0x50, 0x95, 0x97, 0x0c, 0x67,
+	0x89, 0xfd, 0xd6, 0x01, 0x39, 0x83, 0xd6, 0x4c, 0xbf, 0x43, 0xf4, 0x65, 0xa5, 0x5f, 0x62, 0x2a,
+	0xa2, 0x78, 0x4d, 0x5f, 0xa7, 0xb7, 0x01, 0x72, 0x02, 0x35, 0x1e, 0x2b, 0x8c, 0xb8, 0x74, 0x2b,
+	0x5d, 0xdb, 0x6f, 0x0c, 0x9c, 0x5e, 0xc8, 0x7a, 0x69, 0xcf, 0x34, 0x05, 0xbd, 0x63, 0x68, 0x18,
+	0x95, 0x99, 0xd3, 0x42, 0x5c, 0x6c, 0x96, 0x02, 0x17, 0x83, 0xbf, 0x65, 0x38, 0xc8, 0x99, 0xec,
+	0x7c, 0xa5, 0x2e, 0x05, 0x46, 0xea, 0x9a, 0x0c, 0xa1, 0xb9, 0xed, 0x40, 0x72, 0xa4, 0x49, 0x76,
+	0x2c, 0x5c, 0xa7, 0xd5, 0x33, 0x9b, 0x99, 0x43, 0xbc, 0x12, 0xf9, 0x08, 0xfb, 0x05, 0xce, 0xff,
+	0x7f, 0xa1, 0xe3, 0x0d, 0x58, 0xbc, 0x2f, 0x5e, 0x89, 0xcc, 0xe1, 0xd9, 0x9d, 0xeb, 0x41, 0xce,
+	0x8a, 0x48, 0x76, 0x6d, 0x51, 0xb1, 0xfc, 0x97, 0xe0, 0xe4, 0x2d, 0x4f, 0x0e, 0x75, 0xc9, 0x82,
+	0x25, 0xe8, 0x34, 0x35, 0x90, 0xb7, 0xa7, 0x57, 0x1a, 0x4c, 0xe0, 0xa1, 0xce, 0x24, 0x9f, 0x0b,
+	0xbc, 0x5f, 0xb5, 0xb7, 0xe0, 0x04, 0x74, 0x92, 0x15, 0x7b, 0x01, 0x8d, 0x9c, 0x0f, 0x49, 0x3b,
+	0x5f, 0x2b, 0x33, 0x66, 0xe7, 0x71, 0xe2, 0x8b, 0xac, 0xd2, 0xab, 0xda, 0xe7, 0xaa, 0xf9, 0x7d,
+	0xfe, 0x03, 0x00, 0x00, 0xff, 0xff, 0x03, 0x00, 0x47, 0x62, 0xb5, 0xe3, 0x6d, 0x05, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -585,3 +763,75 @@ var _OCSPGenerator_serviceDesc = grpc.ServiceDesc{
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "ca/proto/ca.proto",
 }
+
+// CRLGeneratorClient is the client API for CRLGenerator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type CRLGeneratorClient interface {
+	GenerateCRL(ctx context.Context, in *GenerateCRLRequest, opts ...grpc.CallOption) (*CRLResponse, error)
+}
+
+type cRLGeneratorClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewCRLGeneratorClient(cc *grpc.ClientConn) CRLGeneratorClient {
+	return &cRLGeneratorClient{cc}
+}
+
+func (c *cRLGeneratorClient) GenerateCRL(ctx context.Context, in *GenerateCRLRequest, opts ...grpc.CallOption) (*CRLResponse, error) {
+	out := new(CRLResponse)
+	err := c.cc.Invoke(ctx, "/ca.CRLGenerator/GenerateCRL", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CRLGeneratorServer is the server API for CRLGenerator service.
+type CRLGeneratorServer interface {
+	GenerateCRL(context.Context, *GenerateCRLRequest) (*CRLResponse, error)
+}
+
+// UnimplementedCRLGeneratorServer can be embedded to have forward compatible implementations.
+type UnimplementedCRLGeneratorServer struct {
+}
+
+func (*UnimplementedCRLGeneratorServer) GenerateCRL(ctx context.Context, req *GenerateCRLRequest) (*CRLResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GenerateCRL not implemented")
+}
+
+func RegisterCRLGeneratorServer(s *grpc.Server, srv CRLGeneratorServer) {
+	s.RegisterService(&_CRLGenerator_serviceDesc, srv)
+}
+
+func _CRLGenerator_GenerateCRL_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GenerateCRLRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CRLGeneratorServer).GenerateCRL(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ca.CRLGenerator/GenerateCRL",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CRLGeneratorServer).GenerateCRL(ctx, req.(*GenerateCRLRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _CRLGenerator_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "ca.CRLGenerator",
+	HandlerType: (*CRLGeneratorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GenerateCRL",
+			Handler:    _CRLGenerator_GenerateCRL_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "ca/proto/ca.proto",
+}
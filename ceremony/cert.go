@@ -0,0 +1,323 @@
+package ceremony
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+)
+
+// AllowedSigAlgs contains the allowed signature algorithms
+var AllowedSigAlgs = map[string]x509.SignatureAlgorithm{
+	"SHA256WithRSA":   x509.SHA256WithRSA,
+	"SHA384WithRSA":   x509.SHA384WithRSA,
+	"SHA512WithRSA":   x509.SHA512WithRSA,
+	"ECDSAWithSHA256": x509.ECDSAWithSHA256,
+	"ECDSAWithSHA384": x509.ECDSAWithSHA384,
+	"ECDSAWithSHA512": x509.ECDSAWithSHA512,
+}
+
+// dateLayout is the format of the NotBefore and NotAfter dates in a
+// CertProfile
+const dateLayout = "2006-01-02 15:04:05"
+
+// CertProfile contains the information required to generate a certificate
+// for signing
+type CertProfile struct {
+	// SignatureAlgorithm should contain one of the allowed signature algorithms
+	// in AllowedSigAlgs
+	SignatureAlgorithm string `yaml:"signatureAlgorithm"`
+
+	// CommonName should contain the requested subject common name
+	CommonName string `yaml:"commonName"`
+	// Organization should contain the requested subject organization
+	Organization string `yaml:"organization"`
+	// Country should contain the requested subject country code
+	Country string `yaml:"country"`
+
+	// NotBefore should contain the requested NotBefore date for the
+	// certificate in the format "2006-01-02 15:04:05". Dates will
+	// always be UTC.
+	NotBefore string `yaml:"notBefore"`
+	// NotAfter should contain the requested NotAfter date for the
+	// certificate in the format "2006-01-02 15:04:05". Dates will
+	// always be UTC.
+	NotAfter string `yaml:"notAfter"`
+
+	// OCSPURL should contain the URL at which a OCSP responder that
+	// can respond to OCSP requests for this certificate operates
+	OCSPURL string `yaml:"ocspURL"`
+	// CRLURL should contain the URL at which CRLs for this certificate
+	// can be found
+	CRLURL string `yaml:"crlURL"`
+	// IssuerURL should contain the URL at which the issuing certificate
+	// can be found, this is only required if generating an intermediate
+	// certificate
+	IssuerURL string `yaml:"issuerURL"`
+
+	// PolicyOIDs should contain any OIDs to be inserted in a certificate
+	// policies extension. These should be formatted in the standard OID
+	// string format (i.e. "1.2.3")
+	PolicyOIDs []string `yaml:"policyOIDs"`
+}
+
+// CertType is the kind of certificate a CertProfile is used to generate
+type CertType int
+
+const (
+	// RootCert is a self-signed root certificate
+	RootCert CertType = iota
+	// IntermediateCert is an intermediate certificate signed by a root
+	IntermediateCert
+	// OCSPCert is a delegated OCSP signing certificate
+	OCSPCert
+	// CrossCert is a certificate with the same subject and public key as an
+	// existing CA certificate, signed by a different issuer
+	CrossCert
+)
+
+func parseOID(oidStr string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, a := range strings.Split(oidStr, ".") {
+		i, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, err
+		}
+		oid = append(oid, i)
+	}
+	return oid, nil
+}
+
+// VerifyProfile checks that profile contains everything required to generate
+// a certificate of type ct, and nothing that type of certificate shouldn't
+// contain. The subject of a cross-signed certificate is taken from the
+// certificate being cross-signed, so it must not be set in the profile.
+// Delegated OCSP signing certificates include the OCSP no-check extension,
+// so they must not contain OCSP or CRL URLs.
+func VerifyProfile(profile CertProfile, ct CertType) error {
+	if profile.NotBefore == "" {
+		return errors.New("NotBefore in profile is required")
+	}
+	if profile.NotAfter == "" {
+		return errors.New("NotAfter in profile is required")
+	}
+	if profile.SignatureAlgorithm == "" {
+		return errors.New("SignatureAlgorithm in profile is required")
+	}
+	if ct == CrossCert {
+		if profile.CommonName != "" || profile.Organization != "" || profile.Country != "" {
+			return errors.New("CommonName, Organization and Country in profile must not be set for cross-signed certificates")
+		}
+	} else {
+		if profile.CommonName == "" {
+			return errors.New("CommonName in profile is required")
+		}
+		if profile.Organization == "" {
+			return errors.New("Organization in profile is required")
+		}
+		if profile.Country == "" {
+			return errors.New("Country in profile is required")
+		}
+	}
+	switch ct {
+	case IntermediateCert, CrossCert:
+		if profile.OCSPURL == "" {
+			return errors.New("OCSPURL in profile is required for intermediates")
+		}
+		if profile.CRLURL == "" {
+			return errors.New("CRLURL in profile is required for intermediates")
+		}
+		if profile.IssuerURL == "" {
+			return errors.New("IssuerURL in profile is required for intermediates")
+		}
+	case OCSPCert:
+		if profile.OCSPURL != "" {
+			return errors.New("OCSPURL in profile must not be set for OCSP signing certificates")
+		}
+		if profile.CRLURL != "" {
+			return errors.New("CRLURL in profile must not be set for OCSP signing certificates")
+		}
+	}
+
+	notBefore, err := time.Parse(dateLayout, profile.NotBefore)
+	if err != nil {
+		return fmt.Errorf("NotBefore in profile is malformed: %s", err)
+	}
+	notAfter, err := time.Parse(dateLayout, profile.NotAfter)
+	if err != nil {
+		return fmt.Errorf("NotAfter in profile is malformed: %s", err)
+	}
+	if !notAfter.After(notBefore) {
+		return errors.New("NotAfter in profile must be after NotBefore")
+	}
+	if _, ok := AllowedSigAlgs[profile.SignatureAlgorithm]; !ok {
+		return fmt.Errorf("SignatureAlgorithm in profile is unsupported: %q", profile.SignatureAlgorithm)
+	}
+	for _, oidStr := range profile.PolicyOIDs {
+		if _, err := parseOID(oidStr); err != nil {
+			return fmt.Errorf("PolicyOIDs in profile contains malformed OID %q", oidStr)
+		}
+	}
+	return nil
+}
+
+// oidOCSPNoCheck is the OID of the id-pkix-ocsp-nocheck extension defined
+// in RFC 6960 Section 4.2.2.2.1
+var oidOCSPNoCheck = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 5}
+
+// makeTemplate generates the certificate template for use in x509.CreateCertificate.
+// Root, intermediate and cross-signed certificates are CA certificates which may
+// only sign certificates and CRLs. OCSP signing certificates are end-entity
+// certificates which may only sign OCSP responses.
+func makeTemplate(ctx pkcs11helpers.PKCtx, profile *CertProfile, pubKey []byte, ct CertType, session pkcs11.SessionHandle) (*x509.Certificate, error) {
+	notBefore, err := time.Parse(dateLayout, profile.NotBefore)
+	if err != nil {
+		return nil, err
+	}
+	notAfter, err := time.Parse(dateLayout, profile.NotAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	var ocspServer []string
+	if profile.OCSPURL != "" {
+		ocspServer = []string{profile.OCSPURL}
+	}
+	var crlDistributionPoints []string
+	if profile.CRLURL != "" {
+		crlDistributionPoints = []string{profile.CRLURL}
+	}
+	var issuingCertificateURL []string
+	if profile.IssuerURL != "" {
+		issuingCertificateURL = []string{profile.IssuerURL}
+	}
+
+	var policyOIDs []asn1.ObjectIdentifier
+	for _, oidStr := range profile.PolicyOIDs {
+		oid, err := parseOID(oidStr)
+		if err != nil {
+			return nil, err
+		}
+		policyOIDs = append(policyOIDs, oid)
+	}
+
+	sigAlg, ok := AllowedSigAlgs[profile.SignatureAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", profile.SignatureAlgorithm)
+	}
+
+	subjectKeyID := sha256.Sum256(pubKey)
+
+	serial, err := ctx.GenerateRandom(session, 16)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number: %s", err)
+	}
+
+	cert := &x509.Certificate{
+		SignatureAlgorithm:    sigAlg,
+		SerialNumber:          big.NewInt(0).SetBytes(serial),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		Subject: pkix.Name{
+			CommonName:   profile.CommonName,
+			Organization: []string{profile.Organization},
+			Country:      []string{profile.Country},
+		},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		OCSPServer:            ocspServer,
+		CRLDistributionPoints: crlDistributionPoints,
+		IssuingCertificateURL: issuingCertificateURL,
+		PolicyIdentifiers:     policyOIDs,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		SubjectKeyId:          subjectKeyID[:],
+	}
+
+	if ct == OCSPCert {
+		cert.IsCA = false
+		cert.KeyUsage = x509.KeyUsageDigitalSignature
+		cert.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageOCSPSigning}
+		// The value of the no-check extension is an ASN.1 NULL
+		cert.ExtraExtensions = []pkix.Extension{{Id: oidOCSPNoCheck, Value: asn1.NullBytes}}
+	}
+
+	return cert, nil
+}
+
+type failReader struct{}
+
+func (fr *failReader) Read([]byte) (int, error) {
+	return 0, errors.New("Empty reader used by x509.CreateCertificate")
+}
+
+// SignCertificate signs the certificate described by profile for pub, whose
+// DER encoded SubjectPublicKeyInfo is pubDER, with signer and checks the
+// signature. If issuer is nil the certificate is self-signed. If toCross is
+// non-nil the certificate takes its subject from toCross.
+func SignCertificate(
+	ctx pkcs11helpers.PKCtx,
+	session pkcs11.SessionHandle,
+	profile *CertProfile,
+	ct CertType,
+	pubDER []byte,
+	pub crypto.PublicKey,
+	issuer *x509.Certificate,
+	toCross *x509.Certificate,
+	signer crypto.Signer,
+) (*x509.Certificate, error) {
+	template, err := makeTemplate(ctx, profile, pubDER, ct, session)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct certificate template from profile: %s", err)
+	}
+	if toCross != nil {
+		// Use the exact encoding of the subject being cross-signed, and its
+		// key identifier, so that the cross-signed certificate can be used
+		// interchangeably with it when building chains
+		template.RawSubject = toCross.RawSubject
+		template.SubjectKeyId = toCross.SubjectKeyId
+	}
+	parent := template
+	if issuer != nil {
+		template.AuthorityKeyId = issuer.SubjectKeyId
+		parent = issuer
+	}
+	log.Println("Generated certificate template from profile")
+
+	// x509.CreateCertificate uses a io.Reader here for signing methods that require
+	// a source of randomness. Since PKCS#11 based signing generates needed randomness
+	// at the HSM we don't need to pass a real reader. Instead of passing a nil reader
+	// we use one that always returns errors in case the internal usage of this reader
+	// changes.
+	certBytes, err := x509.CreateCertificate(&failReader{}, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate: %s", err)
+	}
+	log.Printf("Signed certificate: %x\n", certBytes)
+	cert, err := x509.ParseCertificate(certBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signed certificate: %s", err)
+	}
+
+	// If generating a root then the signing key is the public key
+	// in the cert itself, so set the parent to itself
+	if issuer == nil {
+		parent = cert
+	}
+	if err := cert.CheckSignatureFrom(parent); err != nil {
+		return nil, fmt.Errorf("failed to verify certificate signature: %s", err)
+	}
+	log.Println("Verified certificate signature")
+	return cert, nil
+}
@@ -0,0 +1,332 @@
+package ceremony
+
+import (
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/asn1"
+	"errors"
+	"testing"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/miekg/pkcs11"
+)
+
+func TestParseOID(t *testing.T) {
+	_, err := parseOID("")
+	test.AssertError(t, err, "parseOID accepted an empty OID")
+	_, err = parseOID("a.b.c")
+	test.AssertError(t, err, "parseOID accepted an OID containing non-ints")
+	oid, err := parseOID("1.2.3")
+	test.AssertNotError(t, err, "parseOID failed with a valid OID")
+	test.Assert(t, oid.Equal(asn1.ObjectIdentifier{1, 2, 3}), "parseOID returned incorrect OID")
+}
+
+func TestMakeTemplate(t *testing.T) {
+	ctx := pkcs11helpers.MockCtx{}
+	profile := &CertProfile{}
+
+	profile.NotBefore = "1234"
+	_, err := makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertError(t, err, "makeTemplate didn't fail with invalid not before")
+
+	profile.NotBefore = "2018-05-18 11:31:00"
+	profile.NotAfter = "1234"
+	_, err = makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertError(t, err, "makeTemplate didn't fail with invalid not after")
+
+	profile.NotAfter = "2018-05-18 11:31:00"
+	profile.PolicyOIDs = []string{""}
+	_, err = makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertError(t, err, "makeTemplate didn't fail with invalid policy OID")
+
+	profile.PolicyOIDs = []string{"1.2.3"}
+	profile.SignatureAlgorithm = "nope"
+	_, err = makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertError(t, err, "makeTemplate didn't fail with invalid signature algorithm")
+
+	profile.SignatureAlgorithm = "SHA256WithRSA"
+	ctx.GenerateRandomFunc = func(pkcs11.SessionHandle, int) ([]byte, error) {
+		return nil, errors.New("bad")
+	}
+	_, err = makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertError(t, err, "makeTemplate didn't fail when GenerateRandom failed")
+
+	ctx.GenerateRandomFunc = func(_ pkcs11.SessionHandle, length int) ([]byte, error) {
+		r := make([]byte, length)
+		_, err := rand.Read(r)
+		return r, err
+	}
+	profile.CommonName = "common name"
+	profile.Organization = "organization"
+	profile.Country = "country"
+	profile.OCSPURL = "ocsp"
+	profile.CRLURL = "crl"
+	profile.IssuerURL = "issuer"
+	cert, err := makeTemplate(ctx, profile, nil, IntermediateCert, 0)
+	test.AssertNotError(t, err, "makeTemplate failed when everything worked as expected")
+	test.AssertEquals(t, cert.Subject.CommonName, profile.CommonName)
+	test.AssertEquals(t, len(cert.Subject.Organization), 1)
+	test.AssertEquals(t, cert.Subject.Organization[0], profile.Organization)
+	test.AssertEquals(t, len(cert.Subject.Country), 1)
+	test.AssertEquals(t, cert.Subject.Country[0], profile.Country)
+	test.AssertEquals(t, len(cert.OCSPServer), 1)
+	test.AssertEquals(t, cert.OCSPServer[0], profile.OCSPURL)
+	test.AssertEquals(t, len(cert.CRLDistributionPoints), 1)
+	test.AssertEquals(t, cert.CRLDistributionPoints[0], profile.CRLURL)
+	test.AssertEquals(t, len(cert.IssuingCertificateURL), 1)
+	test.AssertEquals(t, cert.IssuingCertificateURL[0], profile.IssuerURL)
+	test.Assert(t, cert.IsCA, "intermediate template isn't a CA")
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageCertSign|x509.KeyUsageCRLSign)
+
+	profile.OCSPURL = ""
+	profile.CRLURL = ""
+	cert, err = makeTemplate(ctx, profile, nil, OCSPCert, 0)
+	test.AssertNotError(t, err, "makeTemplate failed for an OCSP signing certificate")
+	test.Assert(t, !cert.IsCA, "OCSP signing template is a CA")
+	test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+	test.AssertEquals(t, len(cert.ExtKeyUsage), 1)
+	test.AssertEquals(t, cert.ExtKeyUsage[0], x509.ExtKeyUsageOCSPSigning)
+	test.AssertEquals(t, len(cert.ExtraExtensions), 1)
+	test.Assert(t, cert.ExtraExtensions[0].Id.Equal(oidOCSPNoCheck), "OCSP signing template is missing the no-check extension")
+}
+
+func TestVerifyProfile(t *testing.T) {
+	for _, tc := range []struct {
+		profile     CertProfile
+		ct          CertType
+		expectedErr string
+	}{
+		{
+			profile:     CertProfile{},
+			ct:          IntermediateCert,
+			expectedErr: "NotBefore in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore: "a",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "NotAfter in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore: "a",
+				NotAfter:  "b",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "SignatureAlgorithm in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "CommonName in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "Organization in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "Country in profile is required",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "OCSPURL in profile is required for intermediates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				OCSPURL:            "g",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "CRLURL in profile is required for intermediates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				OCSPURL:            "g",
+				CRLURL:             "h",
+			},
+			ct:          IntermediateCert,
+			expectedErr: "IssuerURL in profile is required for intermediates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "a",
+				NotAfter:           "b",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+			},
+			ct:          RootCert,
+			expectedErr: "NotBefore in profile is malformed: parsing time \"a\" as \"2006-01-02 15:04:05\": cannot parse \"a\" as \"2006\"",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2018-05-18 11:31:00",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+			},
+			ct:          RootCert,
+			expectedErr: "NotAfter in profile must be after NotBefore",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "c",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+			},
+			ct:          RootCert,
+			expectedErr: "SignatureAlgorithm in profile is unsupported: \"c\"",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				PolicyOIDs:         []string{"1.2.a"},
+			},
+			ct:          RootCert,
+			expectedErr: "PolicyOIDs in profile contains malformed OID \"1.2.a\"",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+			},
+			ct: RootCert,
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				OCSPURL:            "g",
+			},
+			ct:          OCSPCert,
+			expectedErr: "OCSPURL in profile must not be set for OCSP signing certificates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				CRLURL:             "h",
+			},
+			ct:          OCSPCert,
+			expectedErr: "CRLURL in profile must not be set for OCSP signing certificates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				Organization:       "e",
+				Country:            "f",
+				IssuerURL:          "i",
+			},
+			ct: OCSPCert,
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CommonName:         "d",
+				OCSPURL:            "g",
+				CRLURL:             "h",
+				IssuerURL:          "i",
+			},
+			ct:          CrossCert,
+			expectedErr: "CommonName, Organization and Country in profile must not be set for cross-signed certificates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				CRLURL:             "h",
+				IssuerURL:          "i",
+			},
+			ct:          CrossCert,
+			expectedErr: "OCSPURL in profile is required for intermediates",
+		},
+		{
+			profile: CertProfile{
+				NotBefore:          "2018-05-18 11:31:00",
+				NotAfter:           "2038-05-18 11:31:00",
+				SignatureAlgorithm: "SHA256WithRSA",
+				OCSPURL:            "g",
+				CRLURL:             "h",
+				IssuerURL:          "i",
+			},
+			ct: CrossCert,
+		},
+	} {
+		err := VerifyProfile(tc.profile, tc.ct)
+		if err != nil {
+			if tc.expectedErr != err.Error() {
+				t.Fatalf("Expected %q, got %q", tc.expectedErr, err.Error())
+			}
+		} else if tc.expectedErr != "" {
+			t.Fatalf("VerifyProfile didn't fail, expected %q", tc.expectedErr)
+		}
+	}
+}
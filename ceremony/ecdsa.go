@@ -1,10 +1,9 @@
-package main
+package ceremony
 
 import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
-	"crypto/rand"
 	"errors"
 	"fmt"
 	"log"
@@ -95,7 +94,7 @@ func ecPub(
 	if err != nil {
 		return nil, err
 	}
-	if pubKey.Curve != expectedCurve {
+	if pubKey.Curve.Params() != expectedCurve {
 		return nil, errors.New("Returned EC parameters doesn't match expected curve")
 	}
 	log.Printf("\tX: %X\n", pubKey.X.Bytes())
@@ -132,18 +131,13 @@ func ecVerify(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, object pkcs
 }
 
 // ecGenerate is used to generate and verify a ECDSA key pair of the type
-// specified by curveStr and with the provided label. It returns the public
-// part of the generated key pair as a ecdsa.PublicKey.
-func ecGenerate(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, label, curveStr string) (*ecdsa.PublicKey, error) {
+// specified by curveStr and with the provided label and ID. It returns the
+// public part of the generated key pair as a ecdsa.PublicKey.
+func ecGenerate(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, label, curveStr string, keyID []byte) (*ecdsa.PublicKey, error) {
 	curve, present := stringToCurve[curveStr]
 	if !present {
 		return nil, fmt.Errorf("curve %q not supported", curveStr)
 	}
-	keyID := make([]byte, 4)
-	_, err := rand.Read(keyID)
-	if err != nil {
-		return nil, err
-	}
 	log.Printf("Generating ECDSA key with curve %s and ID %x\n", curveStr, keyID)
 	args := ecArgs(label, curve, keyID)
 	pub, priv, err := ctx.GenerateKeyPair(session, args.mechanism, args.publicAttrs, args.privateAttrs)
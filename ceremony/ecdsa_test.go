@@ -1,4 +1,4 @@
-package main
+package ceremony
 
 import (
 	"crypto/ecdsa"
@@ -168,14 +168,14 @@ func TestECGenerate(t *testing.T) {
 	ctx := pkcs11helpers.MockCtx{}
 
 	// Test ecGenerate fails with unknown curve
-	_, err := ecGenerate(ctx, 0, "", "bad-curve")
+	_, err := ecGenerate(ctx, 0, "", "bad-curve", []byte{1, 2, 3, 4})
 	test.AssertError(t, err, "ecGenerate accepted unknown curve")
 
 	// Test ecGenerate fails when GenerateKeyPair fails
 	ctx.GenerateKeyPairFunc = func(pkcs11.SessionHandle, []*pkcs11.Mechanism, []*pkcs11.Attribute, []*pkcs11.Attribute) (pkcs11.ObjectHandle, pkcs11.ObjectHandle, error) {
 		return 0, 0, errors.New("bad")
 	}
-	_, err = ecGenerate(ctx, 0, "", "P-256")
+	_, err = ecGenerate(ctx, 0, "", "P-256", []byte{1, 2, 3, 4})
 	test.AssertError(t, err, "ecGenerate didn't fail on GenerateKeyPair error")
 
 	// Test ecGenerate fails when ecPub fails
@@ -185,7 +185,7 @@ func TestECGenerate(t *testing.T) {
 	ctx.GetAttributeValueFunc = func(pkcs11.SessionHandle, pkcs11.ObjectHandle, []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
 		return nil, errors.New("bad")
 	}
-	_, err = ecGenerate(ctx, 0, "", "P-256")
+	_, err = ecGenerate(ctx, 0, "", "P-256", []byte{1, 2, 3, 4})
 	test.AssertError(t, err, "ecGenerate didn't fail on ecPub error")
 
 	// Test ecGenerate fails when ecVerify fails
@@ -198,7 +198,7 @@ func TestECGenerate(t *testing.T) {
 	ctx.GenerateRandomFunc = func(pkcs11.SessionHandle, int) ([]byte, error) {
 		return nil, errors.New("yup")
 	}
-	_, err = ecGenerate(ctx, 0, "", "P-256")
+	_, err = ecGenerate(ctx, 0, "", "P-256", []byte{1, 2, 3, 4})
 	test.AssertError(t, err, "ecGenerate didn't fail on ecVerify error")
 
 	// Test ecGenerate doesn't fail when everything works
@@ -211,6 +211,6 @@ func TestECGenerate(t *testing.T) {
 	ctx.SignFunc = func(pkcs11.SessionHandle, []byte) ([]byte, error) {
 		return []byte{82, 33, 179, 118, 118, 141, 38, 154, 5, 20, 207, 140, 127, 221, 237, 139, 222, 74, 189, 107, 84, 133, 127, 80, 226, 169, 25, 110, 141, 226, 196, 69, 202, 51, 204, 77, 22, 198, 104, 91, 74, 120, 221, 156, 122, 11, 43, 54, 106, 10, 165, 202, 229, 71, 44, 18, 113, 236, 213, 47, 208, 239, 198, 33}, nil
 	}
-	_, err = ecGenerate(ctx, 0, "", "P-256")
+	_, err = ecGenerate(ctx, 0, "", "P-256", []byte{1, 2, 3, 4})
 	test.AssertNotError(t, err, "ecGenerate didn't succeed when everything worked as expected")
 }
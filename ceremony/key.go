@@ -0,0 +1,114 @@
+// Package ceremony contains the key generation and certificate signing used
+// by the gen-key, gen-ca and ceremony tools to perform key ceremonies on a HSM
+// using PKCS#11.
+package ceremony
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/miekg/pkcs11"
+)
+
+type generateArgs struct {
+	mechanism    []*pkcs11.Mechanism
+	privateAttrs []*pkcs11.Attribute
+	publicAttrs  []*pkcs11.Attribute
+}
+
+func getRandomBytes(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle) ([]byte, error) {
+	r, err := ctx.GenerateRandom(session, 4)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// KeySpec describes a key pair to generate
+type KeySpec struct {
+	// Type is either "rsa" or "ecdsa"
+	Type string
+	// RSAModLength is the size of the RSA modulus in bits. Only used if Type
+	// is "rsa".
+	RSAModLength uint
+	// RSAPublicExponent is the public RSA exponent, which defaults to 65537.
+	// Only used if Type is "rsa".
+	RSAPublicExponent uint
+	// ECDSACurve is the curve to use, one of P-224, P-256, P-384 or P-521.
+	// Only used if Type is "ecdsa".
+	ECDSACurve string
+}
+
+// SupportedCurve returns whether ECDSA keys can be generated on the named curve
+func SupportedCurve(name string) bool {
+	_, ok := stringToCurve[name]
+	return ok
+}
+
+// KeyInfo describes a key pair generated on the device
+type KeyInfo struct {
+	// ID is the CKA_ID of the private and public key objects
+	ID []byte
+	// Key is the public part of the key pair
+	Key crypto.PublicKey
+	// DER is the DER encoded SubjectPublicKeyInfo of Key
+	DER []byte
+}
+
+// GenerateKey generates a RSA or ECDSA key pair on the device, as specified
+// by spec, and stores it with the provided label and a random ID.
+//
+// When generating a key the following steps are taken:
+//   1. Constructs templates for the private and public keys consisting
+//      of the appropriate PKCS#11 attributes.
+//   2. Executes a PKCS#11 GenerateKeyPair operation with the constructed
+//      templates and either CKM_RSA_PKCS_KEY_PAIR_GEN or CKM_EC_KEY_PAIR_GEN.
+//   3. Extracts the public key components from the returned public key object
+//      handle and construct a Golang public key object from them.
+//   4. Generates 4 bytes of random data from the HSM using a PKCS#11 GenerateRandom
+//      operation.
+//   5. Signs the random data with the private key object handle using a PKCS#11
+//      SignInit/Sign operation.
+//   6. Verifies the returned signature of the random data with the constructed
+//      public key.
+//   7. Marshals the public key into a DER SubjectPublicKeyInfo.
+//
+func GenerateKey(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, label string, spec KeySpec) (*KeyInfo, error) {
+	keyID := make([]byte, 4)
+	_, err := rand.Read(keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var pubKey crypto.PublicKey
+	switch spec.Type {
+	case "rsa":
+		exponent := spec.RSAPublicExponent
+		if exponent == 0 {
+			exponent = 65537
+		}
+		pubKey, err = rsaGenerate(ctx, session, label, spec.RSAModLength, exponent, keyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key pair: %s", err)
+		}
+	case "ecdsa":
+		pubKey, err = ecGenerate(ctx, session, label, spec.ECDSACurve, keyID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate ECDSA key pair: %s", err)
+		}
+	default:
+		return nil, errors.New("key type may only be rsa or ecdsa")
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal public key: %s", err)
+	}
+	log.Printf("Generated key pair with label %q and ID %x\n", label, keyID)
+	return &KeyInfo{ID: keyID, Key: pubKey, DER: der}, nil
+}
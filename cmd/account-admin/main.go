@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+const usageString = `
+usage:
+account-admin list-certs --config <path> <account-id>
+account-admin rate-limits --config <path> [--account <account-id>] [--domain <domain>]
+
+command descriptions:
+  list-certs   List every certificate issued to an account, newest first, with
+               its expiry, OCSP status and names
+  rate-limits  Show the current usage of each rate limit that applies to an
+               account, a domain, or both
+
+args:
+  config    File path to the configuration file for this service
+  account   Account ID to show rate limit usage for
+  domain    Domain name to show rate limit usage for
+`
+
+// pageSize is the number of certificates fetched from the SA per request.
+const pageSize = 100
+
+type config struct {
+	AccountAdmin struct {
+		// The account admin tool needs a TLSConfig to set up its gRPC client
+		// certs, but doesn't get the TLS field from ServiceConfig, so declares
+		// its own.
+		TLS cmd.TLSConfig
+
+		SAService *cmd.GRPCClientConfig
+		RAService *cmd.GRPCClientConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func setupContext(c config) (core.StorageAuthority, blog.Logger) {
+	logger := cmd.NewLogger(c.Syslog)
+
+	tlsConfig, err := c.AccountAdmin.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	saConn, err := bgrpc.ClientSetup(c.AccountAdmin.SAService, tlsConfig, clientMetrics, cmd.Clock())
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
+
+	return sac, logger
+}
+
+func setupRA(c config) core.RegistrationAuthority {
+	tlsConfig, err := c.AccountAdmin.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	raConn, err := bgrpc.ClientSetup(c.AccountAdmin.RAService, tlsConfig, clientMetrics, cmd.Clock())
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	return bgrpc.NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(raConn))
+}
+
+// listCerts writes a table of the certificates issued to the account with the
+// given ID to w, fetching them from the SA perPage at a time. It returns the
+// number of certificates listed.
+func listCerts(ctx context.Context, sac core.StorageGetter, regID int64, perPage int64, w io.Writer) (int, error) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERIAL\tISSUED\tEXPIRES\tSTATUS\tNAMES")
+	var count int
+	var before int64
+	for {
+		page, err := sac.GetCertificatesForAccount(ctx, &sapb.GetCertificatesForAccountRequest{
+			RegistrationID: &regID,
+			Before:         &before,
+			Limit:          &perPage,
+		})
+		if err != nil {
+			return count, err
+		}
+		for _, ac := range page.Certificates {
+			names := "unparseable"
+			cert, err := x509.ParseCertificate(ac.Certificate.Der)
+			if err == nil {
+				names = strings.Join(core.IdentifierNames(cert.DNSNames, cert.IPAddresses), ",")
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+				*ac.Certificate.Serial,
+				time.Unix(0, ac.Certificate.GetIssued()).UTC().Format(time.RFC3339),
+				time.Unix(0, ac.Certificate.GetExpires()).UTC().Format(time.RFC3339),
+				*ac.Status,
+				names)
+			count++
+		}
+		if int64(len(page.Certificates)) < perPage {
+			break
+		}
+		before = *page.Certificates[len(page.Certificates)-1].Id
+	}
+	return count, tw.Flush()
+}
+
+// showRateLimits writes a table of the usage of each rate limit that applies to
+// the account with the given ID and/or the domain to w. Either may be left
+// unset.
+func showRateLimits(ctx context.Context, rac core.RegistrationAuthority, regID int64, domain string, w io.Writer) error {
+	req := &rapb.RateLimitUsageRequest{}
+	if regID != 0 {
+		req.RegistrationID = &regID
+	}
+	if domain != "" {
+		req.Domain = &domain
+	}
+	resp, err := rac.GetRateLimitUsage(ctx, req)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "LIMIT\tKEY\tUSED\tTHRESHOLD\tWINDOW")
+	for _, u := range resp.Usages {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n",
+			u.GetName(),
+			u.GetKey(),
+			u.GetUsed(),
+			u.GetThreshold(),
+			time.Duration(u.GetWindow()))
+	}
+	return tw.Flush()
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	account := flagSet.Int64("account", 0, "Account ID to show rate limit usage for")
+	domain := flagSet.String("domain", "", "Domain name to show rate limit usage for")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" {
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.AccountAdmin.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	ctx := context.Background()
+	args := flagSet.Args()
+	switch {
+	case command == "list-certs" && len(args) == 1:
+		regID, err := strconv.ParseInt(args[0], 10, 64)
+		cmd.FailOnError(err, "Account ID must be an integer")
+		sac, logger := setupContext(c)
+		count, err := listCerts(ctx, sac, regID, pageSize, os.Stdout)
+		cmd.FailOnError(err, fmt.Sprintf("Failed to list certificates for account %d", regID))
+		logger.Infof("Listed %d certificates for account %d", count, regID)
+
+	case command == "rate-limits" && len(args) == 0 && (*account != 0 || *domain != ""):
+		rac := setupRA(c)
+		err := showRateLimits(ctx, rac, *account, *domain, os.Stdout)
+		cmd.FailOnError(err, "Failed to show rate limit usage")
+
+	default:
+		usage()
+	}
+}
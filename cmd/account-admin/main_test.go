@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	"github.com/letsencrypt/boulder/mocks"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var ctx = context.Background()
+
+// pagingSA serves its certificates, which are ordered newest first, honouring
+// the paging parameters of GetCertificatesForAccount.
+type pagingSA struct {
+	mocks.StorageAuthority
+	certs    []*sapb.AccountCertificate
+	requests int
+}
+
+func (sa *pagingSA) GetCertificatesForAccount(_ context.Context, req *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error) {
+	sa.requests++
+	if *req.RegistrationID != 1 {
+		return nil, errors.New("unexpected account")
+	}
+	resp := &sapb.AccountCertificates{}
+	for _, ac := range sa.certs {
+		if *req.Before != 0 && *ac.Id >= *req.Before {
+			continue
+		}
+		if int64(len(resp.Certificates)) == *req.Limit {
+			break
+		}
+		resp.Certificates = append(resp.Certificates, ac)
+	}
+	return resp, nil
+}
+
+func makeAccountCert(t *testing.T, id int64, name string) *sapb.AccountCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	issued := time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(id),
+		DNSNames:     []string{name},
+		NotBefore:    issued,
+		NotAfter:     issued.Add(90 * 24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating certificate")
+	serial := core.SerialToString(template.SerialNumber)
+	issuedNS := template.NotBefore.UnixNano()
+	expiresNS := template.NotAfter.UnixNano()
+	status := string(core.OCSPStatusGood)
+	return &sapb.AccountCertificate{
+		Id: &id,
+		Certificate: &corepb.Certificate{
+			Serial:  &serial,
+			Der:     der,
+			Issued:  &issuedNS,
+			Expires: &expiresNS,
+		},
+		Status: &status,
+	}
+}
+
+func TestListCerts(t *testing.T) {
+	sa := &pagingSA{}
+	for id := int64(5); id > 0; id-- {
+		sa.certs = append(sa.certs, makeAccountCert(t, id, "example.com"))
+	}
+
+	var out bytes.Buffer
+	count, err := listCerts(ctx, sa, 1, 2, &out)
+	test.AssertNotError(t, err, "listCerts failed")
+	test.AssertEquals(t, count, 5)
+	// Two full pages and a short one.
+	test.AssertEquals(t, sa.requests, 3)
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.AssertEquals(t, len(lines), 6)
+	test.Assert(t, strings.HasPrefix(lines[0], "SERIAL"), "missing header")
+	test.Assert(t, strings.HasPrefix(lines[1], *sa.certs[0].Certificate.Serial), "certificates not listed newest first")
+	test.AssertContains(t, lines[1], "2019-05-01T00:00:00Z")
+	test.AssertContains(t, lines[1], "2019-07-30T00:00:00Z")
+	test.AssertContains(t, lines[1], "good")
+	test.AssertContains(t, lines[1], "example.com")
+
+	_, err = listCerts(ctx, sa, 2, 2, &out)
+	test.AssertError(t, err, "listCerts didn't return the SA's error")
+}
+
+// usageRA reports a fixed usage for any account or domain it is asked about.
+type usageRA struct {
+	core.RegistrationAuthority
+	req *rapb.RateLimitUsageRequest
+}
+
+func (ra *usageRA) GetRateLimitUsage(_ context.Context, req *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	ra.req = req
+	name, key := "certificatesPerName", req.GetDomain()
+	used, threshold, window := int64(3), int64(50), int64(168*time.Hour)
+	return &rapb.RateLimitUsages{Usages: []*rapb.RateLimitUsage{
+		{Name: &name, Key: &key, Used: &used, Threshold: &threshold, Window: &window},
+	}}, nil
+}
+
+func TestShowRateLimits(t *testing.T) {
+	ra := &usageRA{}
+	var out bytes.Buffer
+	err := showRateLimits(ctx, ra, 0, "example.com", &out)
+	test.AssertNotError(t, err, "showRateLimits failed")
+	test.Assert(t, ra.req.RegistrationID == nil, "unset account ID was sent")
+	test.AssertEquals(t, ra.req.GetDomain(), "example.com")
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	test.AssertEquals(t, len(lines), 2)
+	test.Assert(t, strings.HasPrefix(lines[0], "LIMIT"), "missing header")
+	test.AssertEquals(t, strings.Join(strings.Fields(lines[1]), " "), "certificatesPerName example.com 3 50 168h0m0s")
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+// revokerName is recorded as the administrator responsible for revocations
+// made by the Bad Key Revoker.
+const revokerName = "bad-key-revoker"
+
+/*
+ * revokerDB is an interface collecting the gorp.DbMap functions that the Bad
+ * Key Revoker relies on. Using this adapter shim allows tests to swap out the
+ * dbMap implementation.
+ */
+type revokerDB interface {
+	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
+	SelectOne(holder interface{}, query string, args ...interface{}) error
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// uncheckedKey is a blocked key whose extant certificates haven't been
+// revoked yet. CheckAttempts counts the earlier attempts that failed.
+type uncheckedKey struct {
+	ID            int64  `db:"id"`
+	KeyHash       []byte `db:"keyHash"`
+	CheckAttempts int    `db:"checkAttempts"`
+}
+
+// unrevokedCert is an unexpired, unrevoked certificate using a blocked key.
+type unrevokedCert struct {
+	Serial string `db:"serial"`
+	DER    []byte `db:"der"`
+}
+
+// badKeyRevoker revokes the certificates that use keys which have been
+// blocked, one key at a time.
+type badKeyRevoker struct {
+	stats metrics.Scope
+	log   blog.Logger
+	clk   clock.Clock
+
+	dbMap      revokerDB
+	rac        core.RegistrationAuthority
+	batchSize  int
+	interval   time.Duration
+	maxBackoff time.Duration
+}
+
+func newRevoker(
+	stats metrics.Scope,
+	clk clock.Clock,
+	dbMap revokerDB,
+	rac core.RegistrationAuthority,
+	config cmd.BadKeyRevokerConfig,
+	log blog.Logger,
+) (*badKeyRevoker, error) {
+	if config.Interval.Duration == 0 {
+		return nil, errors.New("interval must be non-zero")
+	}
+	if config.BatchSize == 0 {
+		// Default to 1000
+		config.BatchSize = 1000
+	}
+	if config.MaxBackoff.Duration == 0 {
+		config.MaxBackoff.Duration = 24 * time.Hour
+	}
+	return &badKeyRevoker{
+		stats:      stats,
+		log:        log,
+		clk:        clk,
+		dbMap:      dbMap,
+		rac:        rac,
+		batchSize:  config.BatchSize,
+		interval:   config.Interval.Duration,
+		maxBackoff: config.MaxBackoff.Duration,
+	}, nil
+}
+
+// findUncheckedKey returns the oldest blocked key whose extant certificates
+// haven't been checked and which isn't waiting to be retried after a failed
+// attempt, or sql.ErrNoRows if there isn't one.
+func (bkr *badKeyRevoker) findUncheckedKey() (uncheckedKey, error) {
+	var key uncheckedKey
+	err := bkr.dbMap.SelectOne(
+		&key,
+		`SELECT id, keyHash, checkAttempts
+		FROM blockedKeys
+		WHERE extantCertificatesChecked = false
+		AND (nextCheck IS NULL OR nextCheck <= ?)
+		ORDER BY id ASC
+		LIMIT 1`,
+		bkr.clk.Now(),
+	)
+	return key, err
+}
+
+// findUnrevokedCertificates returns up to batchSize unexpired, unrevoked
+// certificates using the key with the given hash whose serial sorts after
+// lastSerial.
+func (bkr *badKeyRevoker) findUnrevokedCertificates(keyHash []byte, lastSerial string) ([]unrevokedCert, error) {
+	var certs []unrevokedCert
+	_, err := bkr.dbMap.Select(
+		&certs,
+		`SELECT
+			c.serial,
+			c.der
+			FROM keyHashToSerial AS kh
+			JOIN certificateStatus AS cs ON cs.serial = kh.certSerial
+			JOIN certificates AS c ON c.serial = kh.certSerial
+			WHERE kh.keyHash = :keyHash
+			AND kh.certNotAfter > :now
+			AND cs.status != :revoked
+			AND kh.certSerial > :lastSerial
+			ORDER BY kh.certSerial ASC
+			LIMIT :limit`,
+		map[string]interface{}{
+			"keyHash":    keyHash,
+			"now":        bkr.clk.Now(),
+			"revoked":    string(core.OCSPStatusRevoked),
+			"lastSerial": lastSerial,
+			"limit":      bkr.batchSize,
+		},
+	)
+	if err == sql.ErrNoRows {
+		return certs, nil
+	}
+	return certs, err
+}
+
+// revokeCertificates revokes every unexpired certificate using the key with
+// the given hash. A failure to revoke one certificate doesn't prevent the
+// others from being attempted, but the key is left unchecked so that the
+// failures are retried.
+func (bkr *badKeyRevoker) revokeCertificates(ctx context.Context, key uncheckedKey) error {
+	var failures int
+	lastSerial := ""
+	for {
+		certs, err := bkr.findUnrevokedCertificates(key.KeyHash, lastSerial)
+		if err != nil {
+			return err
+		}
+		for _, uc := range certs {
+			err := bkr.revokeCertificate(ctx, uc)
+			if err != nil {
+				bkr.log.AuditErrf("Failed to revoke certificate %s using blocked key %d: %s", uc.Serial, key.ID, err)
+				bkr.stats.Inc("RevocationFailures", 1)
+				failures++
+				continue
+			}
+			bkr.log.AuditInfof("Revoked certificate %s using blocked key %d", uc.Serial, key.ID)
+			bkr.stats.Inc("CertificatesRevoked", 1)
+		}
+		if len(certs) < bkr.batchSize {
+			break
+		}
+		lastSerial = certs[len(certs)-1].Serial
+	}
+	if failures > 0 {
+		return fmt.Errorf("failed to revoke %d certificates using blocked key %d", failures, key.ID)
+	}
+	return nil
+}
+
+func (bkr *badKeyRevoker) revokeCertificate(ctx context.Context, uc unrevokedCert) error {
+	cert, err := x509.ParseCertificate(uc.DER)
+	if err != nil {
+		return err
+	}
+	return bkr.rac.AdministrativelyRevokeCertificate(ctx, *cert, revocation.KeyCompromise, revokerName)
+}
+
+// markChecked records that every extant certificate using a blocked key has
+// been revoked.
+func (bkr *badKeyRevoker) markChecked(key uncheckedKey) error {
+	_, err := bkr.dbMap.Exec(
+		"UPDATE blockedKeys SET extantCertificatesChecked = true WHERE id = ?",
+		key.ID,
+	)
+	return err
+}
+
+// deferKey records a failed attempt to revoke the certificates using a key
+// and schedules the next attempt with exponential backoff, so that a key with
+// a certificate that can't be revoked doesn't hold up the keys blocked after
+// it.
+func (bkr *badKeyRevoker) deferKey(key uncheckedKey) error {
+	attempts := key.CheckAttempts + 1
+	nextCheck := bkr.clk.Now().Add(core.RetryBackoff(attempts, bkr.interval, bkr.maxBackoff, 2))
+	_, err := bkr.dbMap.Exec(
+		"UPDATE blockedKeys SET checkAttempts = ?, nextCheck = ? WHERE id = ?",
+		attempts,
+		nextCheck,
+		key.ID,
+	)
+	return err
+}
+
+// checkOneKey revokes the certificates using the oldest unchecked blocked key.
+// It returns false if there were no unchecked keys left.
+func (bkr *badKeyRevoker) checkOneKey(ctx context.Context) (bool, error) {
+	key, err := bkr.findUncheckedKey()
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = bkr.revokeCertificates(ctx, key)
+	if err != nil {
+		bkr.stats.Inc("KeysDeferred", 1)
+		deferErr := bkr.deferKey(key)
+		if deferErr != nil {
+			bkr.log.AuditErrf("Failed to defer blocked key %d: %s", key.ID, deferErr)
+		}
+		return true, err
+	}
+	err = bkr.markChecked(key)
+	if err != nil {
+		return true, err
+	}
+	bkr.stats.Inc("KeysChecked", 1)
+	return true, nil
+}
+
+// tick checks one key and, if there were none left to check or checking
+// failed, sleeps for the configured interval.
+func (bkr *badKeyRevoker) tick() {
+	tickStart := bkr.clk.Now()
+	found, err := bkr.checkOneKey(context.TODO())
+	bkr.stats.TimingDuration("TickDuration", bkr.clk.Now().Sub(tickStart))
+	bkr.stats.Inc("Ticks", 1)
+	if err != nil {
+		bkr.log.AuditErrf("Failed to check blocked key: %s", err)
+		bkr.stats.Inc("FailedTicks", 1)
+	}
+	if err != nil || !found {
+		bkr.clk.Sleep(bkr.interval)
+	}
+}
+
+type config struct {
+	BadKeyRevoker cmd.BadKeyRevokerConfig
+
+	Syslog cmd.SyslogConfig
+}
+
+func setupClients(c cmd.BadKeyRevokerConfig, stats metrics.Scope, clk clock.Clock) core.RegistrationAuthority {
+	tls, err := c.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+	clientMetrics := bgrpc.NewClientMetrics(stats)
+	raConn, err := bgrpc.ClientSetup(c.RAService, tls, clientMetrics, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to RA")
+	return bgrpc.NewRegistrationAuthorityClient(rapb.NewRegistrationAuthorityClient(raConn))
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	conf := c.BadKeyRevoker
+	err = features.Set(conf.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, conf.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	// Configure DB
+	dbURL, err := conf.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbMap, err := sa.NewDbMap(dbURL, conf.DBConfig.MaxDBConns)
+	cmd.FailOnError(err, "Could not connect to database")
+
+	// Collect and periodically report DB metrics using the DBMap and prometheus scope.
+	sa.InitDBMetrics(dbMap, scope)
+
+	clk := cmd.Clock()
+	rac := setupClients(conf, scope, clk)
+
+	revoker, err := newRevoker(scope, clk, dbMap, rac, conf, logger)
+	cmd.FailOnError(err, "Failed to create revoker")
+
+	go func() {
+		for {
+			revoker.tick()
+		}
+	}()
+
+	go cmd.CatchSignals(logger, nil)
+
+	// Sleep forever (until signaled)
+	select {}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var ctx = context.Background()
+
+// mockRA records the serials it is asked to revoke, failing for those in
+// fail.
+type mockRA struct {
+	core.RegistrationAuthority
+	revoked []string
+	fail    map[string]bool
+}
+
+func (ra *mockRA) AdministrativelyRevokeCertificate(_ context.Context, cert x509.Certificate, code revocation.Reason, user string) error {
+	serial := core.SerialToString(cert.SerialNumber)
+	if ra.fail[serial] {
+		return errors.New("nope")
+	}
+	if code != revocation.KeyCompromise || user != revokerName {
+		return errors.New("unexpected revocation reason or user")
+	}
+	ra.revoked = append(ra.revoked, serial)
+	return nil
+}
+
+// mockDB holds blocked keys and the certificates using them in memory,
+// honouring the paging parameters the revoker passes. Revocations made
+// through a mockRA are reflected by the revoked callback.
+type mockDB struct {
+	keys      []uncheckedKey
+	checked   map[int64]bool
+	attempts  map[int64]int
+	nextCheck map[int64]time.Time
+	certs     map[string][]unrevokedCert
+	revoked   func(serial string) bool
+}
+
+func (db *mockDB) Select(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	params := args[0].(map[string]interface{})
+	out := i.(*[]unrevokedCert)
+	for _, uc := range db.certs[string(params["keyHash"].([]byte))] {
+		if uc.Serial <= params["lastSerial"].(string) || db.revoked(uc.Serial) {
+			continue
+		}
+		if len(*out) == params["limit"].(int) {
+			break
+		}
+		*out = append(*out, uc)
+	}
+	return nil, nil
+}
+
+func (db *mockDB) SelectOne(holder interface{}, query string, args ...interface{}) error {
+	now := args[0].(time.Time)
+	for _, key := range db.keys {
+		if db.checked[key.ID] || db.nextCheck[key.ID].After(now) {
+			continue
+		}
+		key.CheckAttempts = db.attempts[key.ID]
+		*holder.(*uncheckedKey) = key
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
+func (db *mockDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	if strings.Contains(query, "extantCertificatesChecked = true") {
+		db.checked[args[0].(int64)] = true
+		return nil, nil
+	}
+	id := args[2].(int64)
+	db.attempts[id] = args[0].(int)
+	db.nextCheck[id] = args[1].(time.Time)
+	return nil, nil
+}
+
+func makeCert(t *testing.T, serial int64) unrevokedCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating certificate")
+	return unrevokedCert{Serial: core.SerialToString(template.SerialNumber), DER: der}
+}
+
+func setup(t *testing.T, batchSize int) (*badKeyRevoker, *mockDB, *mockRA, clock.FakeClock) {
+	ra := &mockRA{fail: make(map[string]bool)}
+	db := &mockDB{
+		checked:   make(map[int64]bool),
+		attempts:  make(map[int64]int),
+		nextCheck: make(map[int64]time.Time),
+		certs:     make(map[string][]unrevokedCert),
+	}
+	db.revoked = func(serial string) bool {
+		for _, s := range ra.revoked {
+			if s == serial {
+				return true
+			}
+		}
+		return false
+	}
+	clk := clock.NewFake()
+	bkr, err := newRevoker(
+		metrics.NewNoopScope(),
+		clk,
+		db,
+		ra,
+		cmd.BadKeyRevokerConfig{
+			Interval:  cmd.ConfigDuration{Duration: time.Minute},
+			BatchSize: batchSize,
+		},
+		blog.NewMock(),
+	)
+	test.AssertNotError(t, err, "newRevoker failed")
+	return bkr, db, ra, clk
+}
+
+func TestNewRevoker(t *testing.T) {
+	_, err := newRevoker(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockRA{},
+		cmd.BadKeyRevokerConfig{}, blog.NewMock())
+	test.AssertError(t, err, "newRevoker accepted a zero interval")
+
+	bkr, err := newRevoker(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockRA{},
+		cmd.BadKeyRevokerConfig{Interval: cmd.ConfigDuration{Duration: time.Second}}, blog.NewMock())
+	test.AssertNotError(t, err, "newRevoker failed")
+	test.AssertEquals(t, bkr.batchSize, 1000)
+	test.AssertEquals(t, bkr.maxBackoff, 24*time.Hour)
+}
+
+func TestCheckOneKey(t *testing.T) {
+	bkr, db, ra, _ := setup(t, 2)
+
+	found, err := bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed with no keys")
+	test.Assert(t, !found, "checkOneKey found a key when there were none")
+
+	db.keys = []uncheckedKey{{ID: 1, KeyHash: []byte("a")}, {ID: 2, KeyHash: []byte("b")}}
+	// More certificates than fit in one batch.
+	db.certs["a"] = []unrevokedCert{makeCert(t, 1), makeCert(t, 2), makeCert(t, 3)}
+
+	found, err = bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.Assert(t, found, "checkOneKey didn't find a key")
+	sort.Strings(ra.revoked)
+	test.AssertDeepEquals(t, ra.revoked, []string{
+		db.certs["a"][0].Serial,
+		db.certs["a"][1].Serial,
+		db.certs["a"][2].Serial,
+	})
+	test.Assert(t, db.checked[1], "key 1 wasn't marked checked")
+
+	// A key with no certificates is still marked checked.
+	found, err = bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.Assert(t, found, "checkOneKey didn't find a key")
+	test.Assert(t, db.checked[2], "key 2 wasn't marked checked")
+	test.AssertEquals(t, len(ra.revoked), 3)
+
+	found, err = bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.Assert(t, !found, "checkOneKey found a key after all were checked")
+}
+
+func TestCheckOneKeyRevocationFailure(t *testing.T) {
+	bkr, db, ra, clk := setup(t, 10)
+
+	db.keys = []uncheckedKey{{ID: 1, KeyHash: []byte("a")}, {ID: 2, KeyHash: []byte("b")}}
+	db.certs["a"] = []unrevokedCert{makeCert(t, 1), makeCert(t, 2)}
+	db.certs["b"] = []unrevokedCert{makeCert(t, 3)}
+	ra.fail[db.certs["a"][0].Serial] = true
+
+	// The failure shouldn't stop the other certificate being revoked, but the
+	// key should be left unchecked so that it is retried.
+	_, err := bkr.checkOneKey(ctx)
+	test.AssertError(t, err, "checkOneKey didn't fail")
+	test.AssertDeepEquals(t, ra.revoked, []string{db.certs["a"][1].Serial})
+	test.Assert(t, !db.checked[1], "key was marked checked despite a failure")
+	test.AssertEquals(t, db.attempts[1], 1)
+
+	// The failing key is backed off, so the next key is checked rather than
+	// the failing key being retried straight away.
+	found, err := bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.Assert(t, found, "checkOneKey didn't find a key")
+	test.Assert(t, db.checked[2], "key blocked after a failing key wasn't checked")
+	found, err = bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.Assert(t, !found, "checkOneKey retried a key before its backoff expired")
+
+	// Each failure doubles the backoff.
+	clk.Add(2 * bkr.interval)
+	_, err = bkr.checkOneKey(ctx)
+	test.AssertError(t, err, "checkOneKey didn't fail")
+	test.AssertEquals(t, db.attempts[1], 2)
+	backoff := db.nextCheck[1].Sub(clk.Now())
+	test.Assert(t, backoff > bkr.interval && backoff <= 3*bkr.interval,
+		fmt.Sprintf("unexpected backoff %s after two failures", backoff))
+
+	delete(ra.fail, db.certs["a"][0].Serial)
+	clk.Add(3 * bkr.interval)
+	_, err = bkr.checkOneKey(ctx)
+	test.AssertNotError(t, err, "checkOneKey failed")
+	test.AssertEquals(t, len(ra.revoked), 3)
+	test.Assert(t, db.checked[1], "key wasn't marked checked")
+}
@@ -4,12 +4,15 @@ import (
 	"crypto"
 	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"os"
 
 	"github.com/beeker1121/goque"
+	"google.golang.org/grpc"
 
 	"github.com/cloudflare/cfssl/helpers"
 	"github.com/letsencrypt/pkcs11key"
@@ -39,14 +42,29 @@ func loadIssuers(c config) ([]ca.Issuer, error) {
 	for _, issuerConfig := range c.CA.Issuers {
 		priv, cert, err := loadIssuer(issuerConfig)
 		cmd.FailOnError(err, "Couldn't load private key")
-		issuers = append(issuers, ca.Issuer{
-			Signer: priv,
-			Cert:   cert,
-		})
+		issuer := ca.Issuer{
+			Signer:    priv,
+			Cert:      cert,
+			IssuerURL: issuerConfig.IssuerURL,
+			OCSPURL:   issuerConfig.OCSPURL,
+			CRLURL:    issuerConfig.CRLURL,
+		}
+		if issuerConfig.OCSPResponder != nil {
+			issuer.OCSPSigner, issuer.OCSPCert, err = loadOCSPResponder(*issuerConfig.OCSPResponder)
+			cmd.FailOnError(err, "Couldn't load OCSP responder")
+		}
+		issuers = append(issuers, issuer)
 	}
 	return issuers, nil
 }
 
+func loadOCSPResponder(responderConfig ca_config.IssuerConfig) (crypto.Signer, *x509.Certificate, error) {
+	if responderConfig.OCSPResponder != nil {
+		return nil, nil, errors.New("OCSP responder config must not itself contain an OCSPResponder")
+	}
+	return loadIssuer(responderConfig)
+}
+
 func loadIssuer(issuerConfig ca_config.IssuerConfig) (crypto.Signer, *x509.Certificate, error) {
 	cert, err := core.LoadCert(issuerConfig.CertFile)
 	if err != nil {
@@ -109,6 +127,7 @@ func loadSigner(issuerConfig ca_config.IssuerConfig) (crypto.Signer, error) {
 func main() {
 	caAddr := flag.String("ca-addr", "", "CA gRPC listen address override")
 	ocspAddr := flag.String("ocsp-addr", "", "OCSP gRPC listen address override")
+	crlAddr := flag.String("crl-addr", "", "CRL gRPC listen address override")
 	debugAddr := flag.String("debug-addr", "", "Debug server address override")
 	configFile := flag.String("config", "", "File path to the configuration file for this service")
 	flag.Parse()
@@ -130,6 +149,9 @@ func main() {
 	if *ocspAddr != "" {
 		c.CA.GRPCOCSPGenerator.Address = *ocspAddr
 	}
+	if *crlAddr != "" && c.CA.GRPCCRLGenerator != nil {
+		c.CA.GRPCCRLGenerator.Address = *crlAddr
+	}
 	if *debugAddr != "" {
 		c.CA.DebugAddr = *debugAddr
 	}
@@ -150,13 +172,13 @@ func main() {
 	if c.CA.HostnamePolicyFile == "" {
 		cmd.FailOnError(fmt.Errorf("HostnamePolicyFile was empty."), "")
 	}
-	err = pa.SetHostnamePolicyFile(c.CA.HostnamePolicyFile)
+	err = pa.SetHostnamePolicyFile(c.CA.HostnamePolicyFile, c.CA.HostnamePolicyMaxRemoved)
 	cmd.FailOnError(err, "Couldn't load hostname policy file")
 
 	issuers, err := loadIssuers(c)
 	cmd.FailOnError(err, "Couldn't load issuers")
 
-	kp, err := goodkey.NewKeyPolicy(c.CA.WeakKeyFile)
+	kp, err := goodkey.NewKeyPolicy(c.CA.WeakKeyFile, nil)
 	cmd.FailOnError(err, "Unable to create key policy")
 
 	tlsConfig, err := c.CA.TLS.Load()
@@ -210,9 +232,27 @@ func main() {
 			"OCSPGenerator gRPC service failed")
 	}()
 
+	// The CRLGenerator service is optional so that CAs which don't sign CRLs
+	// don't need to be configured with a listener for it.
+	var crlSrv *grpc.Server
+	if c.CA.GRPCCRLGenerator != nil {
+		var crlListener net.Listener
+		crlSrv, crlListener, err = bgrpc.NewServer(c.CA.GRPCCRLGenerator, tlsConfig, serverMetrics, clk)
+		cmd.FailOnError(err, "Unable to setup CA gRPC server")
+		crlWrapper := bgrpc.NewCertificateAuthorityServer(cai)
+		caPB.RegisterCRLGeneratorServer(crlSrv, crlWrapper)
+		go func() {
+			cmd.FailOnError(cmd.FilterShutdownErrors(crlSrv.Serve(crlListener)),
+				"CRLGenerator gRPC service failed")
+		}()
+	}
+
 	go cmd.CatchSignals(logger, func() {
 		caSrv.GracefulStop()
 		ocspSrv.GracefulStop()
+		if crlSrv != nil {
+			crlSrv.GracefulStop()
+		}
 	})
 
 	select {}
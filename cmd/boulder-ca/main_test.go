@@ -41,3 +41,17 @@ func TestLoadIssuerBadCert(t *testing.T) {
 		t.Fatal("loadIssuer succeeded when loading key from /dev/null")
 	}
 }
+
+func TestLoadOCSPResponderNested(t *testing.T) {
+	_, _, err := loadOCSPResponder(ca_config.IssuerConfig{
+		File:     "../../test/test-ca.key",
+		CertFile: "../../test/test-ca2.pem",
+		OCSPResponder: &ca_config.IssuerConfig{
+			File:     "../../test/test-ca.key",
+			CertFile: "../../test/test-ca2.pem",
+		},
+	})
+	if err == nil {
+		t.Fatal("loadOCSPResponder succeeded with a nested OCSPResponder")
+	}
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/policy"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/reloader"
+)
+
+const usageString = `
+usage:
+boulder-policy check --type <rate-limits|hostname> --live <path> --proposed <path> [--max-removed <fraction>]
+
+command descriptions:
+  check  Validate a proposed policy file the way the RA and CA would when
+         reloading it, and print how it differs from the live file. Exits
+         non-zero if the proposed file is invalid or would be refused for
+         removing too many entries
+
+args:
+  type         Kind of policy file: "rate-limits" (YAML) or "hostname" (JSON)
+  live         File path to the policy file currently in use
+  proposed     File path to the policy file that would replace it
+  max-removed  Largest fraction of the live file's entries the proposed file
+               may remove, as configured for the RA or CA. Zero means no limit
+`
+
+// policyEntries returns a function that parses and validates the given kind
+// of policy file, returning its entries.
+func policyEntries(kind string) (func([]byte) (map[string]string, error), error) {
+	switch kind {
+	case "rate-limits":
+		return ratelimit.PolicyEntries, nil
+	case "hostname":
+		return policy.HostnamePolicyEntries, nil
+	default:
+		return nil, fmt.Errorf("unknown policy type %q", kind)
+	}
+}
+
+// checkPolicy validates the proposed contents of a policy file of the given
+// kind, writes the entries changed, added or removed relative to the live
+// contents to w, and returns an error if the proposed contents are invalid or
+// remove more than maxRemoved of the live entries.
+func checkPolicy(kind string, live, proposed []byte, maxRemoved float64, w io.Writer) error {
+	parse, err := policyEntries(kind)
+	if err != nil {
+		return err
+	}
+	liveEntries, err := parse(live)
+	if err != nil {
+		return fmt.Errorf("live policy is invalid: %s", err)
+	}
+	proposedEntries, err := parse(proposed)
+	if err != nil {
+		return fmt.Errorf("proposed policy is invalid: %s", err)
+	}
+
+	var keys []string
+	for k := range liveEntries {
+		keys = append(keys, k)
+	}
+	for k := range proposedEntries {
+		if _, ok := liveEntries[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changed, added, removed int
+	for _, k := range keys {
+		oldValue, inLive := liveEntries[k]
+		newValue, inProposed := proposedEntries[k]
+		switch {
+		case !inLive:
+			added++
+			fmt.Fprintf(w, "+ %s\n", entryString(k, newValue))
+		case !inProposed:
+			removed++
+			fmt.Fprintf(w, "- %s\n", entryString(k, oldValue))
+		case oldValue != newValue:
+			changed++
+			fmt.Fprintf(w, "  %s: %s -> %s\n", k, oldValue, newValue)
+		}
+	}
+	fmt.Fprintf(w, "%d changed, %d added, %d removed of %d live entries\n",
+		changed, added, removed, len(liveEntries))
+
+	return reloader.CheckRemovals(liveEntries, proposedEntries, maxRemoved)
+}
+
+// entryString formats an entry for display, leaving out empty values such as
+// those of hostname policy entries.
+func entryString(key, value string) string {
+	if value == "" {
+		return key
+	}
+	return key + ": " + value
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	kind := flagSet.String("type", "", "Kind of policy file: rate-limits or hostname")
+	livePath := flagSet.String("live", "", "File path to the policy file currently in use")
+	proposedPath := flagSet.String("proposed", "", "File path to the policy file that would replace it")
+	maxRemoved := flagSet.Float64("max-removed", 0, "Largest fraction of live entries that may be removed")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if command != "check" || *kind == "" || *livePath == "" || *proposedPath == "" {
+		usage()
+	}
+
+	live, err := ioutil.ReadFile(*livePath)
+	cmd.FailOnError(err, "Failed to read live policy file")
+	proposed, err := ioutil.ReadFile(*proposedPath)
+	cmd.FailOnError(err, "Failed to read proposed policy file")
+	err = checkPolicy(*kind, live, proposed, *maxRemoved, os.Stdout)
+	cmd.FailOnError(err, "Proposed policy would be refused")
+}
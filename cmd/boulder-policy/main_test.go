@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestCheckRateLimits(t *testing.T) {
+	live := []byte(`
+certificatesPerName:
+  window: 2160h
+  threshold: 2
+  overrides:
+    ratelimit.me: 1
+    lim.it: 0
+newOrdersPerAccount:
+  window: 3h
+  threshold: 1500
+`)
+	proposed := []byte(`
+certificatesPerName:
+  window: 2160h
+  threshold: 5
+  overrides:
+    ratelimit.me: 1
+    le.wtf: 10000
+newOrdersPerAccount:
+  window: 3h
+  threshold: 1500
+`)
+	var out bytes.Buffer
+	err := checkPolicy("rate-limits", live, proposed, 0.5, &out)
+	test.AssertNotError(t, err, "checkPolicy failed")
+	test.AssertEquals(t, out.String(), `+ certificatesPerName.overrides[le.wtf]: 10000
+- certificatesPerName.overrides[lim.it]: 0
+  certificatesPerName.threshold: 2 -> 5
+1 changed, 1 added, 1 removed of 6 live entries
+`)
+
+	// Also dropping the newOrdersPerAccount limit removes three of six entries.
+	err = checkPolicy("rate-limits", live, proposed[:bytes.Index(proposed, []byte("newOrders"))], 0.4, &out)
+	test.AssertError(t, err, "checkPolicy allowed too many removals")
+
+	err = checkPolicy("rate-limits", live, []byte("certificatesPerNames:\n  threshold: 2\n"), 0, &out)
+	test.AssertError(t, err, "checkPolicy accepted an unknown limit")
+}
+
+func TestCheckHostnamePolicy(t *testing.T) {
+	live := []byte(`{"Blacklist": ["example.com", "example.net"]}`)
+	proposed := []byte(`{"Blacklist": ["example.com"], "ExactBlacklist": ["www.example.org"]}`)
+	var out bytes.Buffer
+	err := checkPolicy("hostname", live, proposed, 0, &out)
+	test.AssertNotError(t, err, "checkPolicy failed")
+	test.AssertEquals(t, out.String(), `- blacklist[example.net]
++ exactBlacklist[www.example.org]
+0 changed, 1 added, 1 removed of 2 live entries
+`)
+
+	err = checkPolicy("hostname", live, []byte(`{"Blacklist": []}`), 0, &out)
+	test.AssertError(t, err, "checkPolicy accepted an empty blacklist")
+
+	err = checkPolicy("hostname", []byte("{"), proposed, 0, &out)
+	test.AssertError(t, err, "checkPolicy accepted an invalid live policy")
+
+	err = checkPolicy("bogus", live, proposed, 0, &out)
+	test.AssertError(t, err, "checkPolicy accepted an unknown policy type")
+}
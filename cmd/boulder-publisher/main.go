@@ -24,6 +24,11 @@ type config struct {
 		// sampled every N nanoseconds.
 		// https://golang.org/pkg/runtime/#SetBlockProfileRate
 		BlockProfileRate int
+
+		// LogListFile, if set, is the path to a CT log list in the v3
+		// log_list.json schema. Submissions to logs it marks retired or
+		// rejected are refused. It is reloaded whenever it changes.
+		LogListFile string
 	}
 
 	Syslog cmd.SyslogConfig
@@ -84,6 +89,10 @@ func main() {
 		bundle,
 		logger,
 		scope)
+	if c.Publisher.LogListFile != "" {
+		err = pubi.SetLogListFile(c.Publisher.LogListFile)
+		cmd.FailOnError(err, "Couldn't load CT log list")
+	}
 
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, l, err := bgrpc.NewServer(c.Publisher.GRPC, tlsConfig, serverMetrics, clk)
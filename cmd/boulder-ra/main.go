@@ -4,31 +4,128 @@ import (
 	"crypto/x509"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	netmail "net/mail"
 	"os"
 	"time"
 
+	"github.com/jmhodges/clock"
+
 	akamaipb "github.com/letsencrypt/boulder/akamai/proto"
 	caPB "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/ctpolicy"
 	"github.com/letsencrypt/boulder/features"
-	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/goodkey/sagoodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	bmail "github.com/letsencrypt/boulder/mail"
+	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
 	pubPB "github.com/letsencrypt/boulder/publisher/proto"
 	"github.com/letsencrypt/boulder/ra"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/ratelimit"
+	"github.com/letsencrypt/boulder/rocsp"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	vaPB "github.com/letsencrypt/boulder/va/proto"
 )
 
+// newIodefReporter returns a reporter sending CAA refusal reports by email
+// through the configured SMTP server and by HTTP.
+func newIodefReporter(
+	c cmd.SMTPConfig,
+	from string,
+	smtpTrustedRootFile string,
+	timeout time.Duration,
+	interval time.Duration,
+	queueSize int,
+	workers int,
+	clk clock.Clock,
+	logger blog.Logger,
+	scope metrics.Scope) *bmail.IodefReporter {
+	var smtpRoots *x509.CertPool
+	if smtpTrustedRootFile != "" {
+		pem, err := ioutil.ReadFile(smtpTrustedRootFile)
+		cmd.FailOnError(err, "Loading trusted roots file")
+		smtpRoots = x509.NewCertPool()
+		if !smtpRoots.AppendCertsFromPEM(pem) {
+			cmd.FailOnError(nil, "Failed to parse root certs PEM")
+		}
+	}
+	fromAddress, err := netmail.ParseAddress(from)
+	cmd.FailOnError(err, fmt.Sprintf("Could not parse from address: %s", from))
+	smtpPassword, err := c.Pass()
+	cmd.FailOnError(err, "Failed to load SMTP password")
+	mailer := bmail.New(
+		c.Server,
+		c.Port,
+		c.Username,
+		smtpPassword,
+		smtpRoots,
+		*fromAddress,
+		logger,
+		scope,
+		time.Second,
+		time.Minute)
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	if interval == 0 {
+		interval = 24 * time.Hour
+	}
+	if queueSize == 0 {
+		queueSize = 1000
+	}
+	if workers == 0 {
+		workers = 4
+	}
+	return bmail.NewIodefReporter(mailer, timeout, interval, queueSize, workers, clk, logger)
+}
+
 type config struct {
 	RA struct {
 		cmd.ServiceConfig
 		cmd.HostnamePolicyConfig
 
 		RateLimitPoliciesFilename string
+		// RateLimitPoliciesMaxRemoved is the largest fraction of the loaded rate
+		// limit policies' entries that a changed file may remove before it is
+		// refused. Zero means no limit.
+		RateLimitPoliciesMaxRemoved float64
+
+		// RateLimitRedis, if set, is where the token buckets enforcing the
+		// RegistrationsPerIP, RegistrationsPerIPRange, CertificatesPerName,
+		// CertificatesPerFQDNSet and NewOrdersPerAccount limits are kept,
+		// instead of counting rows in the database on every request.
+		RateLimitRedis *cmd.RedisConfig
+
+		// OCSPRedis, if set, is the Redis server ocsp-updater stores OCSP
+		// responses in for ocsp-responder. With the RevokeAtRA feature it must
+		// be set whenever ocsp-responder serves from Redis, so that revocations
+		// replace the good responses stored there.
+		OCSPRedis *cmd.RedisConfig
+
+		// CAAIodefReports, if set, configures how the RA reports refusals to
+		// issue because of CAA to the targets of the domain's iodef records.
+		CAAIodefReports *struct {
+			cmd.SMTPConfig
+			From string
+			// Path to a file containing a list of trusted root certificates for
+			// use during the SMTP connection.
+			SMTPTrustedRootFile string
+			// Timeout bounds each HTTP report. Defaults to 10 seconds.
+			Timeout cmd.ConfigDuration
+			// Interval is the minimum time between reports for each registered
+			// domain. Defaults to 24 hours.
+			Interval cmd.ConfigDuration
+			// QueueSize is the most reports waiting to be sent before further
+			// reports are dropped. Defaults to 1000.
+			QueueSize int
+			// Workers is the number of reports sent at once. Defaults to 4.
+			Workers int
+		}
 
 		MaxContactsPerRegistration int
 
@@ -76,11 +173,26 @@ type config struct {
 		// test them or because they are not yet approved by a browser/root
 		// program but we still want our certs to end up there.
 		InformationalCTLogs []cmd.LogDescription
+		// CTLogList, if set, is used to build the CT log groups instead of
+		// CTLogGroups2. InformationalCTLogs are still submitted to.
+		CTLogList *cmd.CTLogListConfig
 
 		// IssuerCertPath is the path to the intermediate used to issue certificates.
 		// It is required if the RevokeAtRA feature is enabled and is used to
 		// generate OCSP URLs to purge at revocation time.
+		// TODO: Remove once IssuerCerts is deployed.
 		IssuerCertPath string
+		// IssuerCerts is a list of paths to the certificates of every issuer
+		// whose certificates may be revoked. The issuer of each revoked
+		// certificate is used to generate its OCSP URLs to purge. If empty,
+		// IssuerCertPath is used.
+		IssuerCerts []string
+
+		// CertProfiles lists the certificate profiles, by the names the CA
+		// knows them by, that orders may request, along with the accounts
+		// allowed to request each. Orders that don't request a profile get
+		// the CA's default profile.
+		CertProfiles map[string]ra.CertProfilePolicy
 
 		Features map[string]bool
 	}
@@ -127,11 +239,15 @@ func main() {
 	if c.RA.HostnamePolicyFile == "" {
 		cmd.Fail("HostnamePolicyFile must be provided.")
 	}
-	err = pa.SetHostnamePolicyFile(c.RA.HostnamePolicyFile)
+	err = pa.SetHostnamePolicyFile(c.RA.HostnamePolicyFile, c.RA.HostnamePolicyMaxRemoved)
 	cmd.FailOnError(err, "Couldn't load hostname policy file")
 
-	if features.Enabled(features.RevokeAtRA) && (c.RA.AkamaiPurgerService == nil || c.RA.IssuerCertPath == "") {
-		cmd.Fail("If the RevokeAtRA feature is enabled the AkamaiPurgerService and IssuerCertPath config fields must be populated")
+	issuerPaths := c.RA.IssuerCerts
+	if len(issuerPaths) == 0 && c.RA.IssuerCertPath != "" {
+		issuerPaths = []string{c.RA.IssuerCertPath}
+	}
+	if features.Enabled(features.RevokeAtRA) && (c.RA.AkamaiPurgerService == nil || len(issuerPaths) == 0) {
+		cmd.Fail("If the RevokeAtRA feature is enabled the AkamaiPurgerService and IssuerCerts config fields must be populated")
 	}
 
 	tlsConfig, err := c.RA.TLS.Load()
@@ -148,7 +264,7 @@ func main() {
 
 	caConn, err := bgrpc.ClientSetup(c.RA.CAService, tlsConfig, clientMetrics, clk)
 	cmd.FailOnError(err, "Unable to create CA client")
-	cac := bgrpc.NewCertificateAuthorityClient(caPB.NewCertificateAuthorityClient(caConn), nil)
+	cac := bgrpc.NewCertificateAuthorityClient(caPB.NewCertificateAuthorityClient(caConn), nil, nil)
 
 	var ctp *ctpolicy.CTPolicy
 	conn, err := bgrpc.ClientSetup(c.RA.PublisherService, tlsConfig, clientMetrics, clk)
@@ -156,21 +272,24 @@ func main() {
 	pubc := bgrpc.NewPublisherClientWrapper(pubPB.NewPublisherClient(conn))
 
 	var apc akamaipb.AkamaiPurgerClient
-	var issuerCert *x509.Certificate
+	var issuerCerts []*x509.Certificate
 	if features.Enabled(features.RevokeAtRA) {
 		apConn, err := bgrpc.ClientSetup(c.RA.AkamaiPurgerService, tlsConfig, clientMetrics, clk)
 		cmd.FailOnError(err, "Unable to create a Akamai Purger client")
 		apc = akamaipb.NewAkamaiPurgerClient(apConn)
 
-		issuerCert, err = core.LoadCert(c.RA.IssuerCertPath)
-		cmd.FailOnError(err, "Failed to load issuer certificate")
+		for _, path := range issuerPaths {
+			issuerCert, err := core.LoadCert(path)
+			cmd.FailOnError(err, fmt.Sprintf("Failed to load issuer certificate %q", path))
+			issuerCerts = append(issuerCerts, issuerCert)
+		}
 	}
 
 	// Boulder's components assume that there will always be CT logs configured.
 	// Issuing a certificate without SCTs embedded is a miss-issuance event in the
 	// enviromnent Boulder is built for. Exit early if there is no CTLogGroups2
-	// configured.
-	if len(c.RA.CTLogGroups2) == 0 {
+	// or CTLogList configured.
+	if len(c.RA.CTLogGroups2) == 0 && c.RA.CTLogList == nil {
 		cmd.Fail("CTLogGroups2 must not be empty")
 	}
 
@@ -181,6 +300,11 @@ func main() {
 				fmt.Sprintf("CTLogGroups2 index %d specifies no logs", i))
 		}
 		for _, l := range g.Logs {
+			// Without an operator for each log, the SCTs can't be checked
+			// for operator diversity.
+			if features.Enabled(features.EnforceSCTPolicy) && l.Operator == "" {
+				cmd.Fail(fmt.Sprintf("CTLogGroups2 index %d has a log with no operator", i))
+			}
 			if l.TemporalSet != nil {
 				err := l.Setup()
 				cmd.FailOnError(err, "Failed to setup a temporal log set")
@@ -188,6 +312,11 @@ func main() {
 		}
 	}
 	ctp = ctpolicy.New(pubc, c.RA.CTLogGroups2, c.RA.InformationalCTLogs, logger, scope)
+	if c.RA.CTLogList != nil {
+		err = ctp.SetLogListFile(c.RA.CTLogList.File, c.RA.CTLogList.Stagger.Duration,
+			c.RA.CTLogList.SubmitFinalCert, c.RA.InformationalCTLogs)
+		cmd.FailOnError(err, "Couldn't load CT log list")
+	}
 
 	saConn, err := bgrpc.ClientSetup(c.RA.SAService, tlsConfig, clientMetrics, clk)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
@@ -205,8 +334,9 @@ func main() {
 		pendingAuthorizationLifetime = time.Duration(c.RA.PendingAuthorizationLifetimeDays) * 24 * time.Hour
 	}
 
-	kp, err := goodkey.NewKeyPolicy(c.RA.WeakKeyFile)
+	kp, err := sagoodkey.NewKeyPolicy(c.RA.WeakKeyFile, sac)
 	cmd.FailOnError(err, "Unable to create key policy")
+	kp.AllowEd25519 = features.Enabled(features.EdDSAAccountKeys)
 
 	if c.RA.MaxNames == 0 {
 		cmd.Fail(fmt.Sprintf("Error in RA config: MaxNames must not be 0"))
@@ -228,10 +358,11 @@ func main() {
 		c.RA.OrderLifetime.Duration,
 		ctp,
 		apc,
-		issuerCert,
+		issuerCerts,
+		c.RA.CertProfiles,
 	)
 
-	policyErr := rai.SetRateLimitPoliciesFile(c.RA.RateLimitPoliciesFilename)
+	policyErr := rai.SetRateLimitPoliciesFile(c.RA.RateLimitPoliciesFilename, c.RA.RateLimitPoliciesMaxRemoved)
 	cmd.FailOnError(policyErr, "Couldn't load rate limit policies file")
 	rai.PA = pa
 
@@ -239,6 +370,27 @@ func main() {
 	rai.CA = cac
 	rai.SA = sac
 
+	if c.RA.RateLimitRedis != nil {
+		source, err := ratelimit.NewRedisSource(*c.RA.RateLimitRedis)
+		cmd.FailOnError(err, "Failed to create rate limit Redis client")
+		logger.Infof("Enforcing rate limits with buckets in Redis at %s", c.RA.RateLimitRedis.Addr)
+		rai.Limiter = ratelimit.NewLimiter(clk, source)
+	}
+
+	if c.RA.OCSPRedis != nil {
+		rc, err := rocsp.NewClientFromConfig(*c.RA.OCSPRedis)
+		cmd.FailOnError(err, "Failed to create OCSP Redis client")
+		rai.OCSPStore = rc
+	}
+
+	if c.RA.CAAIodefReports != nil {
+		rai.CAAReporter = newIodefReporter(c.RA.CAAIodefReports.SMTPConfig,
+			c.RA.CAAIodefReports.From, c.RA.CAAIodefReports.SMTPTrustedRootFile,
+			c.RA.CAAIodefReports.Timeout.Duration, c.RA.CAAIodefReports.Interval.Duration,
+			c.RA.CAAIodefReports.QueueSize, c.RA.CAAIodefReports.Workers,
+			clk, logger, scope)
+	}
+
 	serverMetrics := bgrpc.NewServerMetrics(scope)
 	grpcSrv, listener, err := bgrpc.NewServer(c.RA.GRPC, tlsConfig, serverMetrics, clk)
 	cmd.FailOnError(err, "Unable to setup RA gRPC server")
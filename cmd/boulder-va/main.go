@@ -1,7 +1,10 @@
 package main
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"flag"
+	"io/ioutil"
 	"os"
 	"time"
 
@@ -28,8 +31,15 @@ type config struct {
 		// The number of times to try a DNS query (that has a temporary error)
 		// before giving up. May be short-circuited by deadlines. A zero value
 		// will be turned into 1.
-		DNSTries     int
+		DNSTries int
+		// DNSResolvers are the resolvers validation lookups are sent to. Plain
+		// "host:port" addresses are queried over UDP, "tls://host:port"
+		// addresses over DNS-over-TLS and "https://" URLs over DNS-over-HTTPS.
 		DNSResolvers []string
+		// DNSResolverCACertFile is a PEM file of the CA certificates trusted to
+		// issue the certificates of DNS-over-TLS and DNS-over-HTTPS resolvers.
+		// If empty, the system roots are used.
+		DNSResolverCACertFile string
 
 		RemoteVAs                   []cmd.GRPCClientConfig
 		MaxRemoteValidationFailures int
@@ -97,6 +107,16 @@ func main() {
 	if dnsTries < 1 {
 		dnsTries = 1
 	}
+	var dnsTLSConfig *tls.Config
+	if c.VA.DNSResolverCACertFile != "" {
+		caCertBytes, err := ioutil.ReadFile(c.VA.DNSResolverCACertFile)
+		cmd.FailOnError(err, "Couldn't read DNS resolver CA certificates")
+		rootCAs := x509.NewCertPool()
+		if ok := rootCAs.AppendCertsFromPEM(caCertBytes); !ok {
+			cmd.Fail("Couldn't parse DNS resolver CA certificates")
+		}
+		dnsTLSConfig = &tls.Config{RootCAs: rootCAs}
+	}
 	clk := cmd.Clock()
 	var resolver bdns.DNSClient
 	if len(c.Common.DNSResolver) != 0 {
@@ -108,10 +128,11 @@ func main() {
 			c.VA.DNSResolvers,
 			scope,
 			clk,
-			dnsTries)
+			dnsTries,
+			dnsTLSConfig)
 		resolver = r
 	} else {
-		r := bdns.NewTestDNSClientImpl(dnsTimeout, c.VA.DNSResolvers, scope, clk, dnsTries)
+		r := bdns.NewTestDNSClientImpl(dnsTimeout, c.VA.DNSResolvers, scope, clk, dnsTries, dnsTLSConfig)
 		resolver = r
 	}
 
@@ -127,8 +148,8 @@ func main() {
 			remotes = append(
 				remotes,
 				va.RemoteVA{
-					ValidationAuthority: bgrpc.NewValidationAuthorityGRPCClient(vaConn),
-					Addresses:           rva.ServerAddress,
+					RemoteClients: bgrpc.NewValidationAuthorityGRPCClient(vaConn),
+					Addresses:     rva.ServerAddress,
 				},
 			)
 		}
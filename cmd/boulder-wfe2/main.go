@@ -15,10 +15,11 @@ import (
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
-	"github.com/letsencrypt/boulder/goodkey"
+	"github.com/letsencrypt/boulder/goodkey/sagoodkey"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/wfe2"
@@ -42,14 +43,31 @@ type config struct {
 		AcceptRevocationReason bool
 		AllowAuthzDeactivation bool
 
+		// OrdersPerPage is the number of order URLs returned per page of an
+		// account's orders list when the ListAccountOrders feature is enabled.
+		OrdersPerPage int
+
 		TLS cmd.TLSConfig
 
 		RAService *cmd.GRPCClientConfig
 		SAService *cmd.GRPCClientConfig
 
+		// GetNonceService, if set, is the nonce-service used to issue anti-replay
+		// nonces in place of the WFE's own in-process nonce service. It may be
+		// balanced across every nonce-service instance.
+		GetNonceService *cmd.GRPCClientConfig
+		// RedeemNonceServices maps the nonce prefix of each nonce-service
+		// instance to the config used to reach that instance directly, so that
+		// nonces are redeemed by the instance that issued them. It is required
+		// when GetNonceService is set.
+		RedeemNonceServices map[string]cmd.GRPCClientConfig
+
 		// CertificateChains maps AIA issuer URLs to certificate filenames.
 		// Certificates are read into the chain in the order they are defined in the
-		// slice of filenames.
+		// slice of filenames. The first certificate of each chain should be the
+		// issuer of the certificates it is served with: with several issuers
+		// active, each certificate is served with a chain starting with its own
+		// issuer, preferring the one for its AIA issuer URL.
 		CertificateChains map[string][]string
 
 		Features map[string]bool
@@ -61,6 +79,16 @@ type config struct {
 		// DirectoryWebsite is used for the /directory response's "meta" element's
 		// "website" field.
 		DirectoryWebsite string
+		// ExternalAccountRequired is used for the /directory response's "meta"
+		// element's "externalAccountRequired" field. When true, new accounts can
+		// only be created with an external account binding made using a key
+		// provisioned with the eab-admin tool.
+		ExternalAccountRequired bool
+		// CertProfiles maps the names of the certificate profiles that orders
+		// may request to a human readable description. It is used for the
+		// /directory response's "meta" element's "profiles" field. The RA
+		// decides which accounts may use each profile.
+		CertProfiles map[string]string
 
 		// ACMEv2 requests (outside some registration/revocation messages) use a JWS with
 		// a KeyID header containing the full account URL. For new accounts this
@@ -70,6 +98,12 @@ type config struct {
 		// header of the WFE1 instance and the legacy 'reg' path component. This
 		// will differ in configuration for production and staging.
 		LegacyKeyIDPrefix string
+
+		// MassRevocationFile is the path to a JSON file of the form
+		// {"serials": [...]} listing certificates which have been flagged for
+		// mass revocation. The renewalInfo endpoint tells subscribers to renew
+		// those certificates immediately. The file is reloaded when it changes.
+		MassRevocationFile string
 	}
 
 	Syslog cmd.SyslogConfig
@@ -180,7 +214,7 @@ func loadCertificateChains(chainConfig map[string][]string) (map[string][]byte,
 	return results, nil
 }
 
-func setupWFE(c config, logger blog.Logger, stats metrics.Scope, clk clock.Clock) (core.RegistrationAuthority, core.StorageAuthority) {
+func setupWFE(c config, logger blog.Logger, stats metrics.Scope, clk clock.Clock) (core.RegistrationAuthority, core.StorageAuthority, noncepb.NonceServiceClient, map[string]noncepb.NonceServiceClient) {
 	tlsConfig, err := c.WFE.TLS.Load()
 	cmd.FailOnError(err, "TLS config")
 	clientMetrics := bgrpc.NewClientMetrics(stats)
@@ -192,7 +226,21 @@ func setupWFE(c config, logger blog.Logger, stats metrics.Scope, clk clock.Clock
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
 	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
 
-	return rac, sac
+	var rns noncepb.NonceServiceClient
+	npm := map[string]noncepb.NonceServiceClient{}
+	if c.WFE.GetNonceService != nil {
+		rnsConn, err := bgrpc.ClientSetup(c.WFE.GetNonceService, tlsConfig, clientMetrics, clk)
+		cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to get nonce service")
+		rns = noncepb.NewNonceServiceClient(rnsConn)
+		for prefix, serviceConfig := range c.WFE.RedeemNonceServices {
+			serviceConfig := serviceConfig
+			conn, err := bgrpc.ClientSetup(&serviceConfig, tlsConfig, clientMetrics, clk)
+			cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to redeem nonce service")
+			npm[prefix] = noncepb.NewNonceServiceClient(conn)
+		}
+	}
+
+	return rac, sac, rns, npm
 }
 
 func main() {
@@ -219,11 +267,12 @@ func main() {
 
 	clk := cmd.Clock()
 
-	kp, err := goodkey.NewKeyPolicy("") // don't load any weak keys
+	rac, sac, rns, npm := setupWFE(c, logger, scope, clk)
+	kp, err := sagoodkey.NewKeyPolicy("", sac) // don't load any weak keys
 	cmd.FailOnError(err, "Unable to create key policy")
-	wfe, err := wfe2.NewWebFrontEndImpl(scope, clk, kp, certChains, logger)
+	kp.AllowEd25519 = features.Enabled(features.EdDSAAccountKeys)
+	wfe, err := wfe2.NewWebFrontEndImpl(scope, clk, kp, certChains, rns, npm, logger)
 	cmd.FailOnError(err, "Unable to create WFE")
-	rac, sac := setupWFE(c, logger, scope, clk)
 	wfe.RA = rac
 	wfe.SA = sac
 
@@ -231,10 +280,18 @@ func main() {
 	wfe.AllowOrigins = c.WFE.AllowOrigins
 	wfe.AcceptRevocationReason = c.WFE.AcceptRevocationReason
 	wfe.AllowAuthzDeactivation = c.WFE.AllowAuthzDeactivation
+	wfe.OrdersPerPage = c.WFE.OrdersPerPage
 	wfe.DirectoryCAAIdentity = c.WFE.DirectoryCAAIdentity
 	wfe.DirectoryWebsite = c.WFE.DirectoryWebsite
+	wfe.ExternalAccountRequired = c.WFE.ExternalAccountRequired
+	wfe.CertProfiles = c.WFE.CertProfiles
 	wfe.LegacyKeyIDPrefix = c.WFE.LegacyKeyIDPrefix
 
+	if c.WFE.MassRevocationFile != "" {
+		err = wfe.SetMassRevocationFile(c.WFE.MassRevocationFile)
+		cmd.FailOnError(err, "Couldn't load mass revocation file")
+	}
+
 	wfe.IssuerCert, err = cmd.LoadCert(c.Common.IssuerCert)
 	cmd.FailOnError(err, fmt.Sprintf("Couldn't read issuer cert [%s]", c.Common.IssuerCert))
 
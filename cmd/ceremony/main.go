@@ -0,0 +1,617 @@
+// ceremony is a tool for performing key ceremonies on a HSM using PKCS#11. A
+// ceremony is described by a YAML configuration file which specifies the type
+// of ceremony, the HSM to use, the keys and certificates to read and write, and
+// the contents of any certificate to be signed. Every parameter is validated,
+// and every input is loaded, before the HSM is touched.
+//
+// The supported ceremony types are:
+//   root:              generates a key pair and self-signs a root certificate
+//                      with it
+//   key:               generates a key pair, e.g. for an intermediate or an
+//                      OCSP signing certificate, and writes out its public key
+//   intermediate:      signs an intermediate certificate for a public key
+//   ocsp-signer:       signs a delegated OCSP signing certificate for a public
+//                      key
+//   cross-certificate: signs a certificate with the same subject and public
+//                      key as an existing CA certificate
+//
+// Each step of the ceremony is recorded in a transcript, signed with a key
+// held by the ceremony operators, which can later be checked with the
+// --verify-transcript flag.
+//
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/pkcs11"
+	"gopkg.in/yaml.v2"
+
+	"github.com/letsencrypt/boulder/ceremony"
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+)
+
+const (
+	rootCeremony             = "root"
+	keyCeremony              = "key"
+	intermediateCeremony     = "intermediate"
+	ocspSignerCeremony       = "ocsp-signer"
+	crossCertificateCeremony = "cross-certificate"
+)
+
+// pkcs11Config specifies the token used in a ceremony and the key, on that
+// token, which is either generated or used for signing
+type pkcs11Config struct {
+	// Module is the path to the PKCS#11 module to use
+	Module string `yaml:"module"`
+	// Slot is the ID of the slot containing the token
+	Slot *uint `yaml:"slot"`
+	// PIN is the token PIN. If empty, a PED based login is assumed.
+	PIN string `yaml:"pin"`
+	// KeyLabel is the label to store a generated key pair with, or the label
+	// of the key pair to sign with
+	KeyLabel string `yaml:"keyLabel"`
+	// KeyID is the hex encoded ID of the key pair to sign with. If empty the
+	// key pair is found by KeyLabel alone. It can't be set when generating a
+	// key pair, as generated key pairs are given a random ID.
+	KeyID string `yaml:"keyID"`
+}
+
+// keyConfig specifies the key pair to generate
+type keyConfig struct {
+	// Type is either "rsa" or "ecdsa"
+	Type string `yaml:"type"`
+	// RSAModLength is the size of the RSA modulus in bits. Only used if Type
+	// is "rsa".
+	RSAModLength uint `yaml:"rsaModLength"`
+	// RSAPublicExponent is the public RSA exponent, which defaults to 65537.
+	// Only used if Type is "rsa".
+	RSAPublicExponent uint `yaml:"rsaPublicExponent"`
+	// ECDSACurve is the curve to use, one of P-224, P-256, P-384 or P-521.
+	// Only used if Type is "ecdsa".
+	ECDSACurve string `yaml:"ecdsaCurve"`
+}
+
+// inputsConfig contains the paths of the files a ceremony reads
+type inputsConfig struct {
+	// PublicKeyPath is the PEM public key to sign a certificate for
+	PublicKeyPath string `yaml:"publicKeyPath"`
+	// IssuerCertificatePath is the PEM certificate of the signing key
+	IssuerCertificatePath string `yaml:"issuerCertificatePath"`
+	// CertificateToCrossSignPath is the PEM certificate whose subject and
+	// public key are cross-signed
+	CertificateToCrossSignPath string `yaml:"certificateToCrossSignPath"`
+}
+
+// outputsConfig contains the paths of the files a ceremony writes. None of
+// them may exist before the ceremony.
+type outputsConfig struct {
+	// PublicKeyPath is where the PEM public key of a generated key pair is
+	// written
+	PublicKeyPath string `yaml:"publicKeyPath"`
+	// CertificatePath is where the PEM signed certificate is written
+	CertificatePath string `yaml:"certificatePath"`
+}
+
+// transcriptConfig specifies where the transcript of a ceremony is written
+// and the key it's signed with
+type transcriptConfig struct {
+	// Path is where the transcript is written
+	Path string `yaml:"path"`
+	// SigningKeyPath is the PEM private key used to sign the transcript
+	SigningKeyPath string `yaml:"signingKeyPath"`
+}
+
+// ceremonyConfig is the YAML configuration of a ceremony. Which fields are
+// required, and which must be left unset, depends on the ceremony type.
+type ceremonyConfig struct {
+	CeremonyType string                `yaml:"ceremonyType"`
+	PKCS11       pkcs11Config          `yaml:"pkcs11"`
+	Key          keyConfig             `yaml:"key"`
+	Inputs       inputsConfig          `yaml:"inputs"`
+	Outputs      outputsConfig         `yaml:"outputs"`
+	CertProfile  *ceremony.CertProfile `yaml:"certificateProfile"`
+	Transcript   transcriptConfig      `yaml:"transcript"`
+}
+
+// certTypes maps the ceremony types which sign a certificate issued by an
+// existing key to the type of that certificate
+var certTypes = map[string]ceremony.CertType{
+	intermediateCeremony:     ceremony.IntermediateCert,
+	ocspSignerCeremony:       ceremony.OCSPCert,
+	crossCertificateCeremony: ceremony.CrossCert,
+}
+
+// validate checks that every parameter required by the ceremony type is
+// present and valid, and that nothing else is set
+func (c ceremonyConfig) validate() error {
+	if c.PKCS11.Module == "" {
+		return errors.New("pkcs11.module is required")
+	}
+	if c.PKCS11.Slot == nil {
+		return errors.New("pkcs11.slot is required")
+	}
+	if c.PKCS11.KeyLabel == "" {
+		return errors.New("pkcs11.keyLabel is required")
+	}
+	if _, err := hex.DecodeString(c.PKCS11.KeyID); err != nil {
+		return fmt.Errorf("pkcs11.keyID is malformed: %s", err)
+	}
+	if c.Transcript.Path == "" {
+		return errors.New("transcript.path is required")
+	}
+	if c.Transcript.SigningKeyPath == "" {
+		return errors.New("transcript.signingKeyPath is required")
+	}
+
+	switch c.CeremonyType {
+	case rootCeremony, keyCeremony:
+		if c.PKCS11.KeyID != "" {
+			return errors.New("pkcs11.keyID can't be set when generating a key pair")
+		}
+		if err := c.Key.validate(); err != nil {
+			return err
+		}
+		if c.Inputs != (inputsConfig{}) {
+			return fmt.Errorf("inputs can't be set for %s ceremonies", c.CeremonyType)
+		}
+		if c.Outputs.PublicKeyPath == "" {
+			return errors.New("outputs.publicKeyPath is required")
+		}
+		if c.CeremonyType == keyCeremony {
+			if c.Outputs.CertificatePath != "" {
+				return errors.New("outputs.certificatePath can't be set for key ceremonies")
+			}
+			if c.CertProfile != nil {
+				return errors.New("certificateProfile can't be set for key ceremonies")
+			}
+			break
+		}
+		if c.Outputs.CertificatePath == "" {
+			return errors.New("outputs.certificatePath is required")
+		}
+		if c.CertProfile == nil {
+			return errors.New("certificateProfile is required")
+		}
+		if err := ceremony.VerifyProfile(*c.CertProfile, ceremony.RootCert); err != nil {
+			return fmt.Errorf("invalid certificateProfile: %s", err)
+		}
+		if !sigAlgMatchesKeyType(ceremony.AllowedSigAlgs[c.CertProfile.SignatureAlgorithm], c.Key.Type) {
+			return fmt.Errorf("certificateProfile.signatureAlgorithm %q can't be used with a %s key", c.CertProfile.SignatureAlgorithm, c.Key.Type)
+		}
+	case intermediateCeremony, ocspSignerCeremony, crossCertificateCeremony:
+		if c.Key != (keyConfig{}) {
+			return fmt.Errorf("key can't be set for %s ceremonies", c.CeremonyType)
+		}
+		if c.CeremonyType == crossCertificateCeremony {
+			if c.Inputs.CertificateToCrossSignPath == "" {
+				return errors.New("inputs.certificateToCrossSignPath is required")
+			}
+			if c.Inputs.PublicKeyPath != "" {
+				return errors.New("inputs.publicKeyPath can't be set for cross-certificate ceremonies")
+			}
+		} else {
+			if c.Inputs.PublicKeyPath == "" {
+				return errors.New("inputs.publicKeyPath is required")
+			}
+			if c.Inputs.CertificateToCrossSignPath != "" {
+				return fmt.Errorf("inputs.certificateToCrossSignPath can't be set for %s ceremonies", c.CeremonyType)
+			}
+		}
+		if c.Inputs.IssuerCertificatePath == "" {
+			return errors.New("inputs.issuerCertificatePath is required")
+		}
+		if c.Outputs.PublicKeyPath != "" {
+			return fmt.Errorf("outputs.publicKeyPath can't be set for %s ceremonies", c.CeremonyType)
+		}
+		if c.Outputs.CertificatePath == "" {
+			return errors.New("outputs.certificatePath is required")
+		}
+		if c.CertProfile == nil {
+			return errors.New("certificateProfile is required")
+		}
+		if err := ceremony.VerifyProfile(*c.CertProfile, certTypes[c.CeremonyType]); err != nil {
+			return fmt.Errorf("invalid certificateProfile: %s", err)
+		}
+	default:
+		return fmt.Errorf("unknown ceremonyType %q", c.CeremonyType)
+	}
+
+	for _, path := range []string{c.Outputs.PublicKeyPath, c.Outputs.CertificatePath, c.Transcript.Path} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			return fmt.Errorf("output %q already exists", path)
+		}
+	}
+	return nil
+}
+
+// validate checks the parameters of the key pair to generate
+func (kc keyConfig) validate() error {
+	switch kc.Type {
+	case "rsa":
+		switch kc.RSAModLength {
+		case 2048, 3072, 4096:
+		default:
+			return errors.New("key.rsaModLength must be 2048, 3072 or 4096")
+		}
+		if kc.RSAPublicExponent != 0 && kc.RSAPublicExponent != 65537 {
+			return errors.New("key.rsaPublicExponent may only be 65537")
+		}
+		if kc.ECDSACurve != "" {
+			return errors.New("key.ecdsaCurve can't be set for RSA keys")
+		}
+	case "ecdsa":
+		if !ceremony.SupportedCurve(kc.ECDSACurve) {
+			return fmt.Errorf("key.ecdsaCurve %q is unsupported", kc.ECDSACurve)
+		}
+		if kc.RSAModLength != 0 || kc.RSAPublicExponent != 0 {
+			return errors.New("key.rsaModLength and key.rsaPublicExponent can't be set for ECDSA keys")
+		}
+	default:
+		return errors.New("key.type may only be rsa or ecdsa")
+	}
+	return nil
+}
+
+// sigAlgMatchesKeyType checks that alg can be used with a key of keyType,
+// which is either "rsa" or "ecdsa"
+func sigAlgMatchesKeyType(alg x509.SignatureAlgorithm, keyType string) bool {
+	switch alg {
+	case x509.SHA256WithRSA, x509.SHA384WithRSA, x509.SHA512WithRSA:
+		return keyType == "rsa"
+	case x509.ECDSAWithSHA256, x509.ECDSAWithSHA384, x509.ECDSAWithSHA512:
+		return keyType == "ecdsa"
+	}
+	return false
+}
+
+// publicKeyType returns the name of the key type alg, as used in keyConfig
+func publicKeyType(alg x509.PublicKeyAlgorithm) string {
+	switch alg {
+	case x509.RSA:
+		return "rsa"
+	case x509.ECDSA:
+		return "ecdsa"
+	}
+	return alg.String()
+}
+
+// ceremonyInputs contains the files read by a ceremony, loaded and checked
+// before the ceremony begins
+type ceremonyInputs struct {
+	transcriptSigner crypto.Signer
+	// pubDER is the DER SubjectPublicKeyInfo to sign a certificate for
+	pubDER []byte
+	pub    crypto.PublicKey
+	// issuer is the certificate of the signing key
+	issuer *x509.Certificate
+	// toCross is the certificate being cross-signed
+	toCross *x509.Certificate
+	// hashes contains the hex encoded SHA-256 hashes of each input file,
+	// for the transcript
+	hashes map[string]string
+}
+
+// loadInputs reads and checks the files required by the ceremony described by
+// c, which must already be valid
+func (c ceremonyConfig) loadInputs() (*ceremonyInputs, error) {
+	inputs := &ceremonyInputs{hashes: map[string]string{}}
+	readPEM := func(name, path, blockType string) ([]byte, error) {
+		pemBytes, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %s", name, err)
+		}
+		block, _ := pem.Decode(pemBytes)
+		if block == nil || block.Type != blockType {
+			return nil, fmt.Errorf("failed to parse %s: no %s PEM block found", name, blockType)
+		}
+		hash := sha256.Sum256(pemBytes)
+		inputs.hashes[name] = hex.EncodeToString(hash[:])
+		return block.Bytes, nil
+	}
+	readCert := func(name, path string) (*x509.Certificate, error) {
+		der, err := readPEM(name, path, "CERTIFICATE")
+		if err != nil {
+			return nil, err
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %s", name, err)
+		}
+		if !cert.BasicConstraintsValid || !cert.IsCA {
+			return nil, fmt.Errorf("%s isn't a CA certificate", name)
+		}
+		return cert, nil
+	}
+
+	signer, err := loadTranscriptSigner(c.Transcript.SigningKeyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load transcript signing key: %s", err)
+	}
+	inputs.transcriptSigner = signer
+
+	if c.Inputs.PublicKeyPath != "" {
+		inputs.pubDER, err = readPEM("public-key", c.Inputs.PublicKeyPath, "PUBLIC KEY")
+		if err != nil {
+			return nil, err
+		}
+		inputs.pub, err = x509.ParsePKIXPublicKey(inputs.pubDER)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public-key: %s", err)
+		}
+	}
+	if c.Inputs.IssuerCertificatePath != "" {
+		inputs.issuer, err = readCert("issuer-certificate", c.Inputs.IssuerCertificatePath)
+		if err != nil {
+			return nil, err
+		}
+		keyType := publicKeyType(inputs.issuer.PublicKeyAlgorithm)
+		if !sigAlgMatchesKeyType(ceremony.AllowedSigAlgs[c.CertProfile.SignatureAlgorithm], keyType) {
+			return nil, fmt.Errorf("certificateProfile.signatureAlgorithm %q can't be used with the %s key of issuer-certificate", c.CertProfile.SignatureAlgorithm, keyType)
+		}
+	}
+	if c.Inputs.CertificateToCrossSignPath != "" {
+		inputs.toCross, err = readCert("certificate-to-cross-sign", c.Inputs.CertificateToCrossSignPath)
+		if err != nil {
+			return nil, err
+		}
+		inputs.pubDER = inputs.toCross.RawSubjectPublicKeyInfo
+		inputs.pub = inputs.toCross.PublicKey
+	}
+	if inputs.issuer != nil && bytes.Equal(inputs.pubDER, inputs.issuer.RawSubjectPublicKeyInfo) {
+		return nil, errors.New("the public key to sign a certificate for is the issuer's public key")
+	}
+	return inputs, nil
+}
+
+// writeFile writes data to path, which must not already exist
+func writeFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func hashHex(data []byte) string {
+	hash := sha256.Sum256(data)
+	return hex.EncodeToString(hash[:])
+}
+
+// signCert signs the certificate described by profile for pub with signer,
+// checks the signature and writes the certificate to path. If issuer is nil
+// the certificate is self-signed.
+func signCert(
+	ctx pkcs11helpers.PKCtx,
+	session pkcs11.SessionHandle,
+	profile *ceremony.CertProfile,
+	ct ceremony.CertType,
+	pubDER []byte,
+	pub crypto.PublicKey,
+	issuer *x509.Certificate,
+	toCross *x509.Certificate,
+	signer crypto.Signer,
+	path string,
+	t *transcript,
+) error {
+	cert, err := ceremony.SignCertificate(ctx, session, profile, ct, pubDER, pub, issuer, toCross, signer)
+	if err != nil {
+		return err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	log.Printf("Certificate PEM:\n%s", pemBytes)
+	if err := writeFile(path, pemBytes); err != nil {
+		return fmt.Errorf("failed to write certificate to %q: %s", path, err)
+	}
+	log.Printf("Certificate written to %q\n", path)
+	return t.record("certificate signed", map[string]string{
+		"serial":             fmt.Sprintf("%x", cert.SerialNumber),
+		"subject":            cert.Subject.String(),
+		"issuer":             cert.Issuer.String(),
+		"not-before":         cert.NotBefore.String(),
+		"not-after":          cert.NotAfter.String(),
+		"sha256-fingerprint": hashHex(cert.Raw),
+		"path":               path,
+	})
+}
+
+// generateKeyPair generates the key pair specified by c, writes its public key
+// out and returns it
+func generateKeyPair(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, c *ceremonyConfig, t *transcript) (*ceremony.KeyInfo, error) {
+	key, err := ceremony.GenerateKey(ctx, session, c.PKCS11.KeyLabel, ceremony.KeySpec{
+		Type:              c.Key.Type,
+		RSAModLength:      c.Key.RSAModLength,
+		RSAPublicExponent: c.Key.RSAPublicExponent,
+		ECDSACurve:        c.Key.ECDSACurve,
+	})
+	if err != nil {
+		return nil, err
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: key.DER})
+	log.Printf("Public key PEM:\n%s\n", pemBytes)
+	if err := writeFile(c.Outputs.PublicKeyPath, pemBytes); err != nil {
+		return nil, fmt.Errorf("failed to write public key to %q: %s", c.Outputs.PublicKeyPath, err)
+	}
+	log.Printf("Public key written to %q\n", c.Outputs.PublicKeyPath)
+	err = t.record("key generated", map[string]string{
+		"label":             c.PKCS11.KeyLabel,
+		"id":                hex.EncodeToString(key.ID),
+		"public-key-sha256": hashHex(key.DER),
+		"path":              c.Outputs.PublicKeyPath,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// runCeremony performs the ceremony described by c, which must already be
+// valid, using an open session on its token
+func runCeremony(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle, c *ceremonyConfig, inputs *ceremonyInputs, configHash string, t *transcript) error {
+	details := map[string]string{"ceremony-type": c.CeremonyType, "config-sha256": configHash}
+	for name, hash := range inputs.hashes {
+		details[name+"-sha256"] = hash
+	}
+	if err := t.record("ceremony started", details); err != nil {
+		return err
+	}
+
+	switch c.CeremonyType {
+	case keyCeremony:
+		if _, err := generateKeyPair(ctx, session, c, t); err != nil {
+			return err
+		}
+	case rootCeremony:
+		key, err := generateKeyPair(ctx, session, c, t)
+		if err != nil {
+			return err
+		}
+		signer, err := pkcs11helpers.GetSigner(ctx, session, c.PKCS11.KeyLabel, hex.EncodeToString(key.ID))
+		if err != nil {
+			return fmt.Errorf("failed to retrieve private key handle: %s", err)
+		}
+		log.Println("Retrieved private key handle")
+		err = signCert(ctx, session, c.CertProfile, ceremony.RootCert, key.DER, key.Key, nil, nil, signer, c.Outputs.CertificatePath, t)
+		if err != nil {
+			return err
+		}
+	default:
+		signer, err := pkcs11helpers.GetSigner(ctx, session, c.PKCS11.KeyLabel, c.PKCS11.KeyID)
+		if err != nil {
+			return fmt.Errorf("failed to retrieve private key handle: %s", err)
+		}
+		signerDER, err := x509.MarshalPKIXPublicKey(signer.Public())
+		if err != nil {
+			return fmt.Errorf("failed to marshal signing public key: %s", err)
+		}
+		if !bytes.Equal(signerDER, inputs.issuer.RawSubjectPublicKeyInfo) {
+			return errors.New("signing key doesn't match the public key of issuer-certificate")
+		}
+		log.Println("Retrieved private key handle")
+		err = t.record("signing key loaded", map[string]string{
+			"label":             c.PKCS11.KeyLabel,
+			"id":                c.PKCS11.KeyID,
+			"public-key-sha256": hashHex(signerDER),
+		})
+		if err != nil {
+			return err
+		}
+		err = signCert(ctx, session, c.CertProfile, certTypes[c.CeremonyType], inputs.pubDER, inputs.pub, inputs.issuer, inputs.toCross, signer, c.Outputs.CertificatePath, t)
+		if err != nil {
+			return err
+		}
+	}
+	return t.record("ceremony completed", nil)
+}
+
+// loadConfig reads and validates the ceremony configuration at path, and loads
+// its inputs. It returns the hex encoded SHA-256 hash of the configuration
+// file for the transcript.
+func loadConfig(path string) (*ceremonyConfig, *ceremonyInputs, string, error) {
+	configBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to read config file %q: %s", path, err)
+	}
+	var config ceremonyConfig
+	if err := yaml.UnmarshalStrict(configBytes, &config); err != nil {
+		return nil, nil, "", fmt.Errorf("failed to parse config file: %s", err)
+	}
+	if err := config.validate(); err != nil {
+		return nil, nil, "", fmt.Errorf("invalid config: %s", err)
+	}
+	inputs, err := config.loadInputs()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &config, inputs, hashHex(configBytes), nil
+}
+
+func main() {
+	configPath := flag.String("config", "", "Path to ceremony configuration file in YAML format. See https://godoc.org/github.com/letsencrypt/boulder/cmd/ceremony for details.")
+	verifyPath := flag.String("verify-transcript", "", "Path to a ceremony transcript to verify, instead of performing a ceremony")
+	transcriptKeyPath := flag.String("transcript-public-key", "", "Path to the PEM public key the transcript being verified was signed with")
+	flag.Parse()
+
+	if *verifyPath != "" {
+		if *transcriptKeyPath == "" {
+			log.Fatal("--transcript-public-key is required")
+		}
+		pemBytes, err := ioutil.ReadFile(*transcriptKeyPath)
+		if err != nil {
+			log.Fatalf("Failed to read public key %q: %s", *transcriptKeyPath, err)
+		}
+		block, _ := pem.Decode(pemBytes)
+		if block == nil {
+			log.Fatal("Failed to parse public key")
+		}
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			log.Fatalf("Failed to parse public key: %s", err)
+		}
+		f, err := os.Open(*verifyPath)
+		if err != nil {
+			log.Fatalf("Failed to open transcript %q: %s", *verifyPath, err)
+		}
+		defer f.Close()
+		steps, err := verifyTranscript(f, pub)
+		if err != nil {
+			log.Fatalf("Failed to verify transcript: %s", err)
+		}
+		log.Printf("Verified transcript containing %d steps\n", steps)
+		return
+	}
+
+	if *configPath == "" {
+		log.Fatal("--config is required")
+	}
+	config, inputs, configHash, err := loadConfig(*configPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Loaded %s ceremony config\n", config.CeremonyType)
+
+	ctx, session, err := pkcs11helpers.Initialize(config.PKCS11.Module, *config.PKCS11.Slot, config.PKCS11.PIN)
+	if err != nil {
+		log.Fatalf("Failed to setup session and PKCS#11 context: %s", err)
+	}
+	log.Println("Opened PKCS#11 session")
+
+	t, err := newTranscript(config.Transcript.Path, inputs.transcriptSigner, clock.Default())
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = runCeremony(ctx, session, config, inputs, configHash, t)
+	if err != nil {
+		// Record the failure, so that the transcript shows why it ends where
+		// it does
+		if recordErr := t.record("ceremony failed", map[string]string{"error": err.Error()}); recordErr != nil {
+			log.Printf("Failed to record ceremony failure in transcript: %s\n", recordErr)
+		}
+	}
+	if closeErr := t.Close(); closeErr != nil && err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		log.Fatalf("Ceremony failed: %s", err)
+	}
+	log.Printf("Ceremony completed, transcript written to %q\n", config.Transcript.Path)
+}
@@ -0,0 +1,851 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/ceremony"
+	"github.com/letsencrypt/boulder/test"
+)
+
+func validRootConfig() ceremonyConfig {
+	slot := uint(0)
+	return ceremonyConfig{
+		CeremonyType: rootCeremony,
+		PKCS11: pkcs11Config{
+			Module:   "module",
+			Slot:     &slot,
+			KeyLabel: "root",
+		},
+		Key: keyConfig{
+			Type:       "ecdsa",
+			ECDSACurve: "P-384",
+		},
+		Outputs: outputsConfig{
+			PublicKeyPath:   "/nonexistent/root.pub",
+			CertificatePath: "/nonexistent/root.pem",
+		},
+		CertProfile: &ceremony.CertProfile{
+			SignatureAlgorithm: "ECDSAWithSHA384",
+			CommonName:         "root",
+			Organization:       "org",
+			Country:            "US",
+			NotBefore:          "2019-01-01 00:00:00",
+			NotAfter:           "2039-01-01 00:00:00",
+		},
+		Transcript: transcriptConfig{
+			Path:           "/nonexistent/transcript",
+			SigningKeyPath: "/nonexistent/transcript.key",
+		},
+	}
+}
+
+func validIntermediateConfig() ceremonyConfig {
+	slot := uint(0)
+	return ceremonyConfig{
+		CeremonyType: intermediateCeremony,
+		PKCS11: pkcs11Config{
+			Module:   "module",
+			Slot:     &slot,
+			KeyLabel: "root",
+			KeyID:    "ffff",
+		},
+		Inputs: inputsConfig{
+			PublicKeyPath:         "/nonexistent/intermediate.pub",
+			IssuerCertificatePath: "/nonexistent/root.pem",
+		},
+		Outputs: outputsConfig{
+			CertificatePath: "/nonexistent/intermediate.pem",
+		},
+		CertProfile: &ceremony.CertProfile{
+			SignatureAlgorithm: "ECDSAWithSHA384",
+			CommonName:         "intermediate",
+			Organization:       "org",
+			Country:            "US",
+			NotBefore:          "2019-01-01 00:00:00",
+			NotAfter:           "2029-01-01 00:00:00",
+			OCSPURL:            "http://ocsp",
+			CRLURL:             "http://crl",
+			IssuerURL:          "http://issuer",
+		},
+		Transcript: transcriptConfig{
+			Path:           "/nonexistent/transcript",
+			SigningKeyPath: "/nonexistent/transcript.key",
+		},
+	}
+}
+
+func TestValidate(t *testing.T) {
+	existing, err := ioutil.TempFile("", "ceremony-output")
+	test.AssertNotError(t, err, "failed to create temporary file")
+	existing.Close()
+	defer os.Remove(existing.Name())
+
+	for _, tc := range []struct {
+		name        string
+		config      func() ceremonyConfig
+		expectedErr string
+	}{
+		{
+			name:   "valid root",
+			config: validRootConfig,
+		},
+		{
+			name: "valid key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CeremonyType = keyCeremony
+				c.Key = keyConfig{Type: "rsa", RSAModLength: 2048}
+				c.Outputs.CertificatePath = ""
+				c.CertProfile = nil
+				return c
+			},
+		},
+		{
+			name:   "valid intermediate",
+			config: validIntermediateConfig,
+		},
+		{
+			name: "valid ocsp-signer",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CeremonyType = ocspSignerCeremony
+				c.CertProfile.OCSPURL = ""
+				c.CertProfile.CRLURL = ""
+				return c
+			},
+		},
+		{
+			name: "valid cross-certificate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CeremonyType = crossCertificateCeremony
+				c.Inputs.PublicKeyPath = ""
+				c.Inputs.CertificateToCrossSignPath = "/nonexistent/other-root.pem"
+				c.CertProfile.CommonName = ""
+				c.CertProfile.Organization = ""
+				c.CertProfile.Country = ""
+				return c
+			},
+		},
+		{
+			name: "unknown ceremony type",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CeremonyType = "party"
+				return c
+			},
+			expectedErr: "unknown ceremonyType \"party\"",
+		},
+		{
+			name: "missing module",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.PKCS11.Module = ""
+				return c
+			},
+			expectedErr: "pkcs11.module is required",
+		},
+		{
+			name: "missing slot",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.PKCS11.Slot = nil
+				return c
+			},
+			expectedErr: "pkcs11.slot is required",
+		},
+		{
+			name: "missing key label",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.PKCS11.KeyLabel = ""
+				return c
+			},
+			expectedErr: "pkcs11.keyLabel is required",
+		},
+		{
+			name: "malformed key ID",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.PKCS11.KeyID = "not hex"
+				return c
+			},
+			expectedErr: "pkcs11.keyID is malformed: encoding/hex: invalid byte: U+006E 'n'",
+		},
+		{
+			name: "missing transcript path",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Transcript.Path = ""
+				return c
+			},
+			expectedErr: "transcript.path is required",
+		},
+		{
+			name: "missing transcript signing key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Transcript.SigningKeyPath = ""
+				return c
+			},
+			expectedErr: "transcript.signingKeyPath is required",
+		},
+		{
+			name: "key ID when generating a key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.PKCS11.KeyID = "ffff"
+				return c
+			},
+			expectedErr: "pkcs11.keyID can't be set when generating a key pair",
+		},
+		{
+			name: "unknown key type",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key.Type = "dsa"
+				return c
+			},
+			expectedErr: "key.type may only be rsa or ecdsa",
+		},
+		{
+			name: "short RSA modulus",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key = keyConfig{Type: "rsa", RSAModLength: 1024}
+				return c
+			},
+			expectedErr: "key.rsaModLength must be 2048, 3072 or 4096",
+		},
+		{
+			name: "bad RSA exponent",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key = keyConfig{Type: "rsa", RSAModLength: 2048, RSAPublicExponent: 3}
+				return c
+			},
+			expectedErr: "key.rsaPublicExponent may only be 65537",
+		},
+		{
+			name: "curve for RSA key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key = keyConfig{Type: "rsa", RSAModLength: 2048, ECDSACurve: "P-256"}
+				return c
+			},
+			expectedErr: "key.ecdsaCurve can't be set for RSA keys",
+		},
+		{
+			name: "unknown curve",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key.ECDSACurve = "P-255"
+				return c
+			},
+			expectedErr: "key.ecdsaCurve \"P-255\" is unsupported",
+		},
+		{
+			name: "modulus for ECDSA key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Key.RSAModLength = 2048
+				return c
+			},
+			expectedErr: "key.rsaModLength and key.rsaPublicExponent can't be set for ECDSA keys",
+		},
+		{
+			name: "inputs for root",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Inputs.PublicKeyPath = "/nonexistent/root.pub"
+				return c
+			},
+			expectedErr: "inputs can't be set for root ceremonies",
+		},
+		{
+			name: "missing public key output",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Outputs.PublicKeyPath = ""
+				return c
+			},
+			expectedErr: "outputs.publicKeyPath is required",
+		},
+		{
+			name: "certificate output for key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CeremonyType = keyCeremony
+				c.CertProfile = nil
+				return c
+			},
+			expectedErr: "outputs.certificatePath can't be set for key ceremonies",
+		},
+		{
+			name: "profile for key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CeremonyType = keyCeremony
+				c.Outputs.CertificatePath = ""
+				return c
+			},
+			expectedErr: "certificateProfile can't be set for key ceremonies",
+		},
+		{
+			name: "missing certificate output for root",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Outputs.CertificatePath = ""
+				return c
+			},
+			expectedErr: "outputs.certificatePath is required",
+		},
+		{
+			name: "missing profile for root",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CertProfile = nil
+				return c
+			},
+			expectedErr: "certificateProfile is required",
+		},
+		{
+			name: "invalid root profile",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CertProfile.CommonName = ""
+				return c
+			},
+			expectedErr: "invalid certificateProfile: CommonName in profile is required",
+		},
+		{
+			name: "root signature algorithm doesn't match key",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.CertProfile.SignatureAlgorithm = "SHA256WithRSA"
+				return c
+			},
+			expectedErr: "certificateProfile.signatureAlgorithm \"SHA256WithRSA\" can't be used with a ecdsa key",
+		},
+		{
+			name: "key for intermediate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Key.Type = "rsa"
+				return c
+			},
+			expectedErr: "key can't be set for intermediate ceremonies",
+		},
+		{
+			name: "missing public key input",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Inputs.PublicKeyPath = ""
+				return c
+			},
+			expectedErr: "inputs.publicKeyPath is required",
+		},
+		{
+			name: "certificate to cross-sign for intermediate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Inputs.CertificateToCrossSignPath = "/nonexistent/other-root.pem"
+				return c
+			},
+			expectedErr: "inputs.certificateToCrossSignPath can't be set for intermediate ceremonies",
+		},
+		{
+			name: "missing certificate to cross-sign",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CeremonyType = crossCertificateCeremony
+				return c
+			},
+			expectedErr: "inputs.certificateToCrossSignPath is required",
+		},
+		{
+			name: "public key for cross-certificate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CeremonyType = crossCertificateCeremony
+				c.Inputs.CertificateToCrossSignPath = "/nonexistent/other-root.pem"
+				return c
+			},
+			expectedErr: "inputs.publicKeyPath can't be set for cross-certificate ceremonies",
+		},
+		{
+			name: "missing issuer certificate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Inputs.IssuerCertificatePath = ""
+				return c
+			},
+			expectedErr: "inputs.issuerCertificatePath is required",
+		},
+		{
+			name: "public key output for intermediate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Outputs.PublicKeyPath = "/nonexistent/intermediate.pub"
+				return c
+			},
+			expectedErr: "outputs.publicKeyPath can't be set for intermediate ceremonies",
+		},
+		{
+			name: "missing profile for intermediate",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CertProfile = nil
+				return c
+			},
+			expectedErr: "certificateProfile is required",
+		},
+		{
+			name: "invalid ocsp-signer profile",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.CeremonyType = ocspSignerCeremony
+				return c
+			},
+			expectedErr: "invalid certificateProfile: OCSPURL in profile must not be set for OCSP signing certificates",
+		},
+		{
+			name: "existing output",
+			config: func() ceremonyConfig {
+				c := validIntermediateConfig()
+				c.Outputs.CertificatePath = existing.Name()
+				return c
+			},
+			expectedErr: fmt.Sprintf("output %q already exists", existing.Name()),
+		},
+		{
+			name: "existing transcript",
+			config: func() ceremonyConfig {
+				c := validRootConfig()
+				c.Transcript.Path = existing.Name()
+				return c
+			},
+			expectedErr: fmt.Sprintf("output %q already exists", existing.Name()),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.config().validate()
+			if tc.expectedErr == "" {
+				test.AssertNotError(t, err, "validate failed for a valid config")
+			} else {
+				test.AssertError(t, err, "validate didn't fail")
+				test.AssertEquals(t, err.Error(), tc.expectedErr)
+			}
+		})
+	}
+}
+
+// testCA is a self-signed CA certificate and its key, for use as an input to
+// ceremonies
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T, name string) testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		SubjectKeyId:          []byte(name),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "failed to create certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "failed to parse certificate")
+	return testCA{cert: cert, key: key}
+}
+
+func writePEM(t *testing.T, path, blockType string, der []byte) {
+	t.Helper()
+	err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600)
+	test.AssertNotError(t, err, "failed to write PEM file")
+}
+
+func TestLoadInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ceremony-inputs")
+	test.AssertNotError(t, err, "failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	transcriptKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	transcriptKeyDER, err := x509.MarshalECPrivateKey(transcriptKey)
+	test.AssertNotError(t, err, "failed to marshal key")
+	writePEM(t, path("transcript.key"), "EC PRIVATE KEY", transcriptKeyDER)
+
+	root := newTestCA(t, "root")
+	writePEM(t, path("root.pem"), "CERTIFICATE", root.cert.Raw)
+	otherRoot := newTestCA(t, "other root")
+	writePEM(t, path("other-root.pem"), "CERTIFICATE", otherRoot.cert.Raw)
+	writePEM(t, path("root.pub"), "PUBLIC KEY", root.cert.RawSubjectPublicKeyInfo)
+	writePEM(t, path("root-as-key.pem"), "PUBLIC KEY", root.cert.Raw)
+
+	eeKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	eeDER, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "end entity"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}, root.cert, eeKey.Public(), root.key)
+	test.AssertNotError(t, err, "failed to create certificate")
+	writePEM(t, path("ee.pem"), "CERTIFICATE", eeDER)
+	eePubDER, err := x509.MarshalPKIXPublicKey(eeKey.Public())
+	test.AssertNotError(t, err, "failed to marshal public key")
+	writePEM(t, path("ee.pub"), "PUBLIC KEY", eePubDER)
+
+	config := func() ceremonyConfig {
+		c := validIntermediateConfig()
+		c.CertProfile.SignatureAlgorithm = "ECDSAWithSHA256"
+		c.Inputs.PublicKeyPath = path("ee.pub")
+		c.Inputs.IssuerCertificatePath = path("root.pem")
+		c.Transcript.SigningKeyPath = path("transcript.key")
+		return c
+	}
+
+	inputs, err := config().loadInputs()
+	test.AssertNotError(t, err, "loadInputs failed with valid inputs")
+	test.AssertByteEquals(t, inputs.pubDER, eePubDER)
+	test.AssertByteEquals(t, inputs.issuer.Raw, root.cert.Raw)
+	test.AssertEquals(t, len(inputs.hashes), 2)
+
+	c := config()
+	c.CeremonyType = crossCertificateCeremony
+	c.Inputs.PublicKeyPath = ""
+	c.Inputs.CertificateToCrossSignPath = path("other-root.pem")
+	inputs, err = c.loadInputs()
+	test.AssertNotError(t, err, "loadInputs failed with valid cross-certificate inputs")
+	test.AssertByteEquals(t, inputs.pubDER, otherRoot.cert.RawSubjectPublicKeyInfo)
+	test.AssertByteEquals(t, inputs.toCross.Raw, otherRoot.cert.Raw)
+
+	for _, tc := range []struct {
+		name        string
+		modify      func(*ceremonyConfig)
+		expectedErr string
+	}{
+		{
+			name:        "missing transcript signing key",
+			modify:      func(c *ceremonyConfig) { c.Transcript.SigningKeyPath = path("missing") },
+			expectedErr: "failed to load transcript signing key",
+		},
+		{
+			name:        "missing public key",
+			modify:      func(c *ceremonyConfig) { c.Inputs.PublicKeyPath = path("missing") },
+			expectedErr: "failed to read public-key",
+		},
+		{
+			name:        "public key isn't a public key",
+			modify:      func(c *ceremonyConfig) { c.Inputs.PublicKeyPath = path("root.pem") },
+			expectedErr: "failed to parse public-key: no PUBLIC KEY PEM block found",
+		},
+		{
+			name:        "malformed public key",
+			modify:      func(c *ceremonyConfig) { c.Inputs.PublicKeyPath = path("root-as-key.pem") },
+			expectedErr: "failed to parse public-key",
+		},
+		{
+			name:        "issuer isn't a CA",
+			modify:      func(c *ceremonyConfig) { c.Inputs.IssuerCertificatePath = path("ee.pem") },
+			expectedErr: "issuer-certificate isn't a CA certificate",
+		},
+		{
+			name:        "signature algorithm doesn't match issuer",
+			modify:      func(c *ceremonyConfig) { c.CertProfile.SignatureAlgorithm = "SHA256WithRSA" },
+			expectedErr: "certificateProfile.signatureAlgorithm \"SHA256WithRSA\" can't be used with the ecdsa key of issuer-certificate",
+		},
+		{
+			name:        "public key is the issuer's",
+			modify:      func(c *ceremonyConfig) { c.Inputs.PublicKeyPath = path("root.pub") },
+			expectedErr: "the public key to sign a certificate for is the issuer's public key",
+		},
+		{
+			name: "cross-signing a certificate with itself",
+			modify: func(c *ceremonyConfig) {
+				c.CeremonyType = crossCertificateCeremony
+				c.Inputs.PublicKeyPath = ""
+				c.Inputs.CertificateToCrossSignPath = path("root.pem")
+			},
+			expectedErr: "the public key to sign a certificate for is the issuer's public key",
+		},
+		{
+			name: "cross-signing an end-entity certificate",
+			modify: func(c *ceremonyConfig) {
+				c.CeremonyType = crossCertificateCeremony
+				c.Inputs.PublicKeyPath = ""
+				c.Inputs.CertificateToCrossSignPath = path("ee.pem")
+			},
+			expectedErr: "certificate-to-cross-sign isn't a CA certificate",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			c := config()
+			tc.modify(&c)
+			_, err := c.loadInputs()
+			test.AssertError(t, err, "loadInputs didn't fail")
+			test.Assert(t, strings.HasPrefix(err.Error(), tc.expectedErr), fmt.Sprintf("expected error starting with %q, got %q", tc.expectedErr, err))
+		})
+	}
+}
+
+// softHSMModule is the default location of the SoftHSM PKCS#11 module, which
+// can be overridden with the SOFTHSM2_MODULE environment variable
+const softHSMModule = "/usr/lib/softhsm/libsofthsm2.so"
+
+// TestCeremoniesSoftHSM performs each type of ceremony against a token
+// created in a temporary SoftHSM token directory, checking the certificates
+// and transcripts they produce. It's skipped if SoftHSM isn't installed.
+func TestCeremoniesSoftHSM(t *testing.T) {
+	module := os.Getenv("SOFTHSM2_MODULE")
+	if module == "" {
+		module = softHSMModule
+	}
+	if _, err := os.Stat(module); err != nil {
+		t.Skipf("SoftHSM module %q not found", module)
+	}
+	if _, err := exec.LookPath("softhsm2-util"); err != nil {
+		t.Skip("softhsm2-util not found")
+	}
+
+	dir, err := ioutil.TempDir("", "ceremony-softhsm")
+	test.AssertNotError(t, err, "failed to create temporary directory")
+	defer os.RemoveAll(dir)
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	test.AssertNotError(t, os.Mkdir(path("tokens"), 0700), "failed to create token directory")
+	conf := fmt.Sprintf("directories.tokendir = %s\n", path("tokens"))
+	test.AssertNotError(t, ioutil.WriteFile(path("softhsm2.conf"), []byte(conf), 0600), "failed to write SoftHSM config")
+	defer os.Setenv("SOFTHSM2_CONF", os.Getenv("SOFTHSM2_CONF"))
+	os.Setenv("SOFTHSM2_CONF", path("softhsm2.conf"))
+	out, err := exec.Command("softhsm2-util", "--init-token", "--free", "--label", "ceremony", "--pin", "1234", "--so-pin", "5678").CombinedOutput()
+	test.AssertNotError(t, err, fmt.Sprintf("failed to initialize token: %s", out))
+
+	// The PKCS#11 module can only be initialized once per process, so a
+	// single session is shared by every ceremony rather than each opening
+	// its own as the ceremony command does
+	ctx := pkcs11.New(module)
+	test.Assert(t, ctx != nil, "failed to load PKCS#11 module")
+	test.AssertNotError(t, ctx.Initialize(), "failed to initialize PKCS#11 module")
+	defer ctx.Destroy()
+	defer ctx.Finalize()
+	slots, err := ctx.GetSlotList(true)
+	test.AssertNotError(t, err, "failed to list slots")
+	var slot *uint
+	for _, s := range slots {
+		info, err := ctx.GetTokenInfo(s)
+		test.AssertNotError(t, err, "failed to get token info")
+		if info.Label == "ceremony" {
+			s := s
+			slot = &s
+		}
+	}
+	test.Assert(t, slot != nil, "failed to find initialized token")
+	session, err := ctx.OpenSession(*slot, pkcs11.CKF_SERIAL_SESSION|pkcs11.CKF_RW_SESSION)
+	test.AssertNotError(t, err, "failed to open session")
+	defer ctx.CloseSession(session)
+	test.AssertNotError(t, ctx.Login(session, pkcs11.CKU_USER, "1234"), "failed to login")
+
+	transcriptKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	transcriptKeyDER, err := x509.MarshalECPrivateKey(transcriptKey)
+	test.AssertNotError(t, err, "failed to marshal key")
+	writePEM(t, path("transcript.key"), "EC PRIVATE KEY", transcriptKeyDER)
+
+	// run performs the ceremony described by the YAML config body, which is
+	// prefixed with the pkcs11 and transcript sections, and checks that its
+	// transcript records the expected number of steps
+	run := func(name, keyLabel, body string, steps int) {
+		t.Helper()
+		config := fmt.Sprintf(`pkcs11:
+  module: %s
+  slot: %d
+  pin: "1234"
+  keyLabel: %s
+transcript:
+  path: %s
+  signingKeyPath: %s
+%s`, module, *slot, keyLabel, path(name+".transcript"), path("transcript.key"), body)
+		test.AssertNotError(t, ioutil.WriteFile(path(name+".yaml"), []byte(config), 0600), "failed to write config")
+		c, inputs, configHash, err := loadConfig(path(name + ".yaml"))
+		test.AssertNotError(t, err, fmt.Sprintf("failed to load %s config", name))
+		tr, err := newTranscript(c.Transcript.Path, inputs.transcriptSigner, clock.Default())
+		test.AssertNotError(t, err, "failed to create transcript")
+		err = runCeremony(ctx, session, c, inputs, configHash, tr)
+		test.AssertNotError(t, err, fmt.Sprintf("%s ceremony failed", name))
+		test.AssertNotError(t, tr.Close(), "failed to close transcript")
+
+		f, err := os.Open(c.Transcript.Path)
+		test.AssertNotError(t, err, "failed to open transcript")
+		defer f.Close()
+		recorded, err := verifyTranscript(f, transcriptKey.Public())
+		test.AssertNotError(t, err, fmt.Sprintf("failed to verify %s transcript", name))
+		test.AssertEquals(t, recorded, steps)
+	}
+	loadCert := func(name string) *x509.Certificate {
+		t.Helper()
+		pemBytes, err := ioutil.ReadFile(path(name))
+		test.AssertNotError(t, err, "failed to read certificate")
+		block, _ := pem.Decode(pemBytes)
+		test.Assert(t, block != nil, "failed to decode certificate")
+		cert, err := x509.ParseCertificate(block.Bytes)
+		test.AssertNotError(t, err, "failed to parse certificate")
+		return cert
+	}
+
+	run("root", "root", fmt.Sprintf(`ceremonyType: root
+key:
+  type: ecdsa
+  ecdsaCurve: P-384
+outputs:
+  publicKeyPath: %s
+  certificatePath: %s
+certificateProfile:
+  signatureAlgorithm: ECDSAWithSHA384
+  commonName: ceremony root
+  organization: boulder
+  country: US
+  notBefore: 2019-01-01 00:00:00
+  notAfter: 2039-01-01 00:00:00
+`, path("root.pub"), path("root.pem")), 4)
+
+	run("intermediate-key", "intermediate", fmt.Sprintf(`ceremonyType: key
+key:
+  type: rsa
+  rsaModLength: 2048
+outputs:
+  publicKeyPath: %s
+`, path("intermediate.pub")), 3)
+
+	run("intermediate", "root", fmt.Sprintf(`ceremonyType: intermediate
+inputs:
+  publicKeyPath: %s
+  issuerCertificatePath: %s
+outputs:
+  certificatePath: %s
+certificateProfile:
+  signatureAlgorithm: ECDSAWithSHA384
+  commonName: ceremony intermediate
+  organization: boulder
+  country: US
+  notBefore: 2019-01-01 00:00:00
+  notAfter: 2029-01-01 00:00:00
+  ocspURL: http://ocsp.example.com
+  crlURL: http://crl.example.com/root.crl
+  issuerURL: http://example.com/root.der
+  policyOIDs:
+    - 2.23.140.1.2.1
+`, path("intermediate.pub"), path("root.pem"), path("intermediate.pem")), 4)
+
+	run("ocsp-key", "ocsp", fmt.Sprintf(`ceremonyType: key
+key:
+  type: ecdsa
+  ecdsaCurve: P-256
+outputs:
+  publicKeyPath: %s
+`, path("ocsp.pub")), 3)
+
+	run("ocsp-signer", "root", fmt.Sprintf(`ceremonyType: ocsp-signer
+inputs:
+  publicKeyPath: %s
+  issuerCertificatePath: %s
+outputs:
+  certificatePath: %s
+certificateProfile:
+  signatureAlgorithm: ECDSAWithSHA384
+  commonName: ceremony root OCSP
+  organization: boulder
+  country: US
+  notBefore: 2019-01-01 00:00:00
+  notAfter: 2020-01-01 00:00:00
+  issuerURL: http://example.com/root.der
+`, path("ocsp.pub"), path("root.pem"), path("ocsp.pem")), 4)
+
+	run("other-root", "other-root", fmt.Sprintf(`ceremonyType: root
+key:
+  type: rsa
+  rsaModLength: 2048
+outputs:
+  publicKeyPath: %s
+  certificatePath: %s
+certificateProfile:
+  signatureAlgorithm: SHA256WithRSA
+  commonName: ceremony other root
+  organization: boulder
+  country: US
+  notBefore: 2019-01-01 00:00:00
+  notAfter: 2039-01-01 00:00:00
+`, path("other-root.pub"), path("other-root.pem")), 4)
+
+	run("cross-certificate", "other-root", fmt.Sprintf(`ceremonyType: cross-certificate
+inputs:
+  certificateToCrossSignPath: %s
+  issuerCertificatePath: %s
+outputs:
+  certificatePath: %s
+certificateProfile:
+  signatureAlgorithm: SHA256WithRSA
+  notBefore: 2019-01-01 00:00:00
+  notAfter: 2029-01-01 00:00:00
+  ocspURL: http://ocsp.example.com
+  crlURL: http://crl.example.com/other-root.crl
+  issuerURL: http://example.com/other-root.der
+`, path("root.pem"), path("other-root.pem"), path("cross.pem")), 4)
+
+	root := loadCert("root.pem")
+	test.AssertNotError(t, root.CheckSignatureFrom(root), "root isn't self-signed")
+
+	intermediate := loadCert("intermediate.pem")
+	test.AssertNotError(t, intermediate.CheckSignatureFrom(root), "intermediate isn't signed by root")
+	test.Assert(t, intermediate.IsCA, "intermediate isn't a CA")
+	test.AssertByteEquals(t, intermediate.AuthorityKeyId, root.SubjectKeyId)
+
+	ocsp := loadCert("ocsp.pem")
+	test.AssertNotError(t, ocsp.CheckSignatureFrom(root), "OCSP signing certificate isn't signed by root")
+	test.Assert(t, !ocsp.IsCA, "OCSP signing certificate is a CA")
+	test.AssertEquals(t, len(ocsp.ExtKeyUsage), 1)
+	test.AssertEquals(t, ocsp.ExtKeyUsage[0], x509.ExtKeyUsageOCSPSigning)
+
+	// The cross-signed root has the subject and key of the root, but is
+	// signed by the other root
+	otherRoot := loadCert("other-root.pem")
+	cross := loadCert("cross.pem")
+	test.AssertNotError(t, cross.CheckSignatureFrom(otherRoot), "cross-certificate isn't signed by the other root")
+	test.Assert(t, bytes.Equal(cross.RawSubject, root.RawSubject), "cross-certificate subject doesn't match root")
+	test.AssertByteEquals(t, cross.RawSubjectPublicKeyInfo, root.RawSubjectPublicKeyInfo)
+	test.AssertByteEquals(t, cross.SubjectKeyId, root.SubjectKeyId)
+	test.AssertNotError(t, intermediate.CheckSignatureFrom(cross), "intermediate doesn't chain to the cross-certificate")
+
+	// A ceremony that would overwrite its outputs is refused before it starts
+	_, _, _, err = loadConfig(path("root.yaml"))
+	test.AssertError(t, err, "loadConfig accepted a config whose outputs already exist")
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/jmhodges/clock"
+)
+
+// transcriptEntry records a single step of a ceremony. Entries are chained
+// together by including the hash of the previous signed entry, so that steps
+// can't be removed from or reordered within a transcript without breaking the
+// chain.
+type transcriptEntry struct {
+	Step    int               `json:"step"`
+	Time    time.Time         `json:"time"`
+	Action  string            `json:"action"`
+	Details map[string]string `json:"details,omitempty"`
+	// Previous is the hex encoded SHA-256 hash of the previous line of the
+	// transcript, or empty for the first entry
+	Previous string `json:"previous,omitempty"`
+}
+
+// signedTranscriptEntry is a single line of a transcript, containing the
+// JSON encoded transcriptEntry and a signature over its SHA-256 hash.
+type signedTranscriptEntry struct {
+	Entry     json.RawMessage `json:"entry"`
+	Signature []byte          `json:"signature"`
+}
+
+// transcript writes a signed record of each step of a ceremony, one JSON
+// encoded signedTranscriptEntry per line. Each line is flushed to disk as
+// soon as it is written so that an interrupted ceremony still leaves a
+// record of the steps that were completed.
+type transcript struct {
+	file     *os.File
+	signer   crypto.Signer
+	clk      clock.Clock
+	step     int
+	previous string
+}
+
+// newTranscript creates a transcript at path, signed with signer. The file
+// must not already exist.
+func newTranscript(path string, signer crypto.Signer, clk clock.Clock) (*transcript, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript: %s", err)
+	}
+	return &transcript{file: f, signer: signer, clk: clk}, nil
+}
+
+// record signs and appends an entry describing a completed step
+func (t *transcript) record(action string, details map[string]string) error {
+	t.step++
+	entry, err := json.Marshal(transcriptEntry{
+		Step:     t.step,
+		Time:     t.clk.Now().UTC(),
+		Action:   action,
+		Details:  details,
+		Previous: t.previous,
+	})
+	if err != nil {
+		return err
+	}
+	digest := sha256.Sum256(entry)
+	signature, err := t.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign transcript entry: %s", err)
+	}
+	line, err := json.Marshal(signedTranscriptEntry{Entry: entry, Signature: signature})
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+	if _, err := t.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write transcript entry: %s", err)
+	}
+	if err := t.file.Sync(); err != nil {
+		return fmt.Errorf("failed to write transcript entry: %s", err)
+	}
+	lineHash := sha256.Sum256(line)
+	t.previous = hex.EncodeToString(lineHash[:])
+	log.Printf("Recorded step %d in transcript: %s\n", t.step, action)
+	return nil
+}
+
+// Close closes the underlying transcript file
+func (t *transcript) Close() error {
+	return t.file.Close()
+}
+
+// loadTranscriptSigner loads the PEM encoded RSA or ECDSA private key used to
+// sign transcripts from path. PKCS#8, PKCS#1 and SEC 1 encodings are accepted.
+func loadTranscriptSigner(path string) (crypto.Signer, error) {
+	pemBytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			return k, nil
+		case *ecdsa.PrivateKey:
+			return k, nil
+		default:
+			return nil, fmt.Errorf("unsupported key type %T", key)
+		}
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("failed to parse private key")
+}
+
+// verifyTranscript checks that every line of the transcript read from r is
+// signed by pub and that the lines are correctly chained together. It returns
+// the number of steps in the transcript.
+func verifyTranscript(r io.Reader, pub crypto.PublicKey) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	var previous string
+	var steps int
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		var signed signedTranscriptEntry
+		if err := json.Unmarshal(line, &signed); err != nil {
+			return 0, fmt.Errorf("line %d is malformed: %s", steps+1, err)
+		}
+		digest := sha256.Sum256(signed.Entry)
+		if err := verifySignature(pub, digest[:], signed.Signature); err != nil {
+			return 0, fmt.Errorf("line %d has an invalid signature: %s", steps+1, err)
+		}
+		var entry transcriptEntry
+		if err := json.Unmarshal(signed.Entry, &entry); err != nil {
+			return 0, fmt.Errorf("line %d is malformed: %s", steps+1, err)
+		}
+		steps++
+		if entry.Step != steps {
+			return 0, fmt.Errorf("line %d records step %d", steps, entry.Step)
+		}
+		if entry.Previous != previous {
+			return 0, fmt.Errorf("line %d doesn't follow the previous line", steps)
+		}
+		lineHash := sha256.Sum256(append(append([]byte{}, line...), '\n'))
+		previous = hex.EncodeToString(lineHash[:])
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return steps, nil
+}
+
+func verifySignature(pub crypto.PublicKey, digest, signature []byte) error {
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature)
+	case *ecdsa.PublicKey:
+		var sig struct {
+			R, S *big.Int
+		}
+		rest, err := asn1.Unmarshal(signature, &sig)
+		if err != nil {
+			return err
+		}
+		if len(rest) != 0 {
+			return errors.New("trailing data after ECDSA signature")
+		}
+		if !ecdsa.Verify(k, digest, sig.R, sig.S) {
+			return errors.New("ECDSA signature doesn't verify")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported key type %T", pub)
+	}
+}
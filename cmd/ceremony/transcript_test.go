@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func writeTranscript(t *testing.T, dir string, signer *ecdsa.PrivateKey) []byte {
+	t.Helper()
+	path := filepath.Join(dir, "transcript")
+	tr, err := newTranscript(path, signer, clock.NewFake())
+	test.AssertNotError(t, err, "newTranscript failed")
+	test.AssertNotError(t, tr.record("one", map[string]string{"a": "b"}), "record failed")
+	test.AssertNotError(t, tr.record("two", nil), "record failed")
+	test.AssertNotError(t, tr.record("three", map[string]string{"c": "d"}), "record failed")
+	test.AssertNotError(t, tr.Close(), "Close failed")
+
+	_, err = newTranscript(path, signer, clock.NewFake())
+	test.AssertError(t, err, "newTranscript overwrote an existing transcript")
+
+	contents, err := ioutil.ReadFile(path)
+	test.AssertNotError(t, err, "failed to read transcript")
+	return contents
+}
+
+func TestTranscript(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ceremony-transcript")
+	test.AssertNotError(t, err, "failed to create temporary directory")
+	defer os.RemoveAll(dir)
+
+	signer, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	contents := writeTranscript(t, dir, signer)
+
+	steps, err := verifyTranscript(bytes.NewReader(contents), signer.Public())
+	test.AssertNotError(t, err, "verifyTranscript failed for a valid transcript")
+	test.AssertEquals(t, steps, 3)
+
+	lines := bytes.SplitAfter(contents, []byte("\n"))
+	var signed signedTranscriptEntry
+	test.AssertNotError(t, json.Unmarshal(lines[1], &signed), "failed to parse transcript line")
+	var entry transcriptEntry
+	test.AssertNotError(t, json.Unmarshal(signed.Entry, &entry), "failed to parse transcript entry")
+	test.AssertEquals(t, entry.Step, 2)
+	test.AssertEquals(t, entry.Action, "two")
+	test.AssertEquals(t, entry.Previous, hashHex(lines[0]))
+
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	_, err = verifyTranscript(bytes.NewReader(contents), other.Public())
+	test.AssertError(t, err, "verifyTranscript accepted a transcript signed by another key")
+
+	// Removing a step breaks the chain
+	removed := bytes.Join([][]byte{lines[0], lines[2]}, nil)
+	_, err = verifyTranscript(bytes.NewReader(removed), signer.Public())
+	test.AssertError(t, err, "verifyTranscript accepted a transcript with a missing step")
+
+	// Modifying a step breaks its signature
+	modified := bytes.Replace(contents, []byte(`"action":"two"`), []byte(`"action":"owt"`), 1)
+	_, err = verifyTranscript(bytes.NewReader(modified), signer.Public())
+	test.AssertError(t, err, "verifyTranscript accepted a modified transcript")
+
+	// Steps from another transcript signed by the same key don't chain
+	otherDir := filepath.Join(dir, "other")
+	test.AssertNotError(t, os.Mkdir(otherDir, 0700), "failed to create directory")
+	otherLines := bytes.SplitAfter(writeTranscript(t, otherDir, signer), []byte("\n"))
+	spliced := bytes.Join([][]byte{lines[0], lines[1], otherLines[2]}, nil)
+	_, err = verifyTranscript(bytes.NewReader(spliced), signer.Public())
+	test.AssertError(t, err, "verifyTranscript accepted a spliced transcript")
+}
+
+func TestLoadTranscriptSigner(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ceremony-transcript")
+	test.AssertNotError(t, err, "failed to create temporary directory")
+	defer os.RemoveAll(dir)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "failed to generate key")
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "failed to generate key")
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	test.AssertNotError(t, err, "failed to marshal key")
+	pkcs8DER, err := x509.MarshalPKCS8PrivateKey(rsaKey)
+	test.AssertNotError(t, err, "failed to marshal key")
+
+	for _, tc := range []struct {
+		name  string
+		block *pem.Block
+		valid bool
+	}{
+		{"sec1", &pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}, true},
+		{"pkcs1", &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}, true},
+		{"pkcs8", &pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8DER}, true},
+		{"garbage", &pem.Block{Type: "PRIVATE KEY", Bytes: []byte{1, 2, 3}}, false},
+	} {
+		path := filepath.Join(dir, tc.name)
+		test.AssertNotError(t, ioutil.WriteFile(path, pem.EncodeToMemory(tc.block), 0600), "failed to write key")
+		_, err := loadTranscriptSigner(path)
+		if tc.valid {
+			test.AssertNotError(t, err, "loadTranscriptSigner failed for "+tc.name)
+		} else {
+			test.AssertError(t, err, "loadTranscriptSigner accepted "+tc.name)
+		}
+	}
+	_, err = loadTranscriptSigner(filepath.Join(dir, "missing"))
+	test.AssertError(t, err, "loadTranscriptSigner accepted a missing file")
+}
@@ -17,12 +17,11 @@ import (
 	"github.com/jmhodges/clock"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/zmap/zcrypto/x509"
-	"github.com/zmap/zlint"
-	"github.com/zmap/zlint/lints"
 
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/linter"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
@@ -93,6 +92,7 @@ type certDB interface {
 
 type certChecker struct {
 	pa           core.PolicyAuthority
+	lints        *linter.Registry
 	dbMap        certDB
 	certs        chan core.Certificate
 	clock        clock.Clock
@@ -102,9 +102,10 @@ type certChecker struct {
 	stats        metrics.Scope
 }
 
-func newChecker(saDbMap certDB, clk clock.Clock, pa core.PolicyAuthority, period time.Duration) certChecker {
+func newChecker(saDbMap certDB, clk clock.Clock, pa core.PolicyAuthority, lints *linter.Registry, period time.Duration) certChecker {
 	c := certChecker{
 		pa:          pa,
+		lints:       lints,
 		dbMap:       saDbMap,
 		certs:       make(chan core.Certificate, batchSize),
 		rMu:         new(sync.Mutex),
@@ -216,17 +217,9 @@ func (c *certChecker) checkCert(cert core.Certificate) (problems []string) {
 	if err != nil {
 		problems = append(problems, fmt.Sprintf("Couldn't parse stored certificate: %s", err))
 	} else {
-		// Run zlint checks
-		results := zlint.LintCertificate(parsedCert)
-		for name, res := range results.Results {
-			// ignore notices and warnings
-			if res.Status >= lints.Error {
-				prob := fmt.Sprintf("zlint %s: %s", res.Status, name)
-				if res.Details != "" {
-					prob = fmt.Sprintf("%s %s", prob, res.Details)
-				}
-				problems = append(problems, prob)
-			}
+		// Run the lints the CA runs before signing, less any ignored
+		for _, failure := range c.lints.Check(parsedCert) {
+			problems = append(problems, failure.String())
 		}
 		// Check stored serial is correct
 		storedSerial, err := core.StringToSerial(cert.Serial)
@@ -284,6 +277,13 @@ func (c *certChecker) checkCert(cert core.Certificate) (problems []string) {
 				}
 			}
 		}
+		// Check that the PA is still willing to issue for each IP address
+		for _, ip := range parsedCert.IPAddresses {
+			id := core.AcmeIdentifier{Type: core.IdentifierIP, Value: ip.String()}
+			if err = c.pa.WillingToIssue(id); err != nil {
+				problems = append(problems, fmt.Sprintf("Policy Authority isn't willing to issue for '%s': %s", ip, err))
+			}
+		}
 		// Check the cert has the correct key usage extensions
 		if !reflect.DeepEqual(parsedCert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}) {
 			problems = append(problems, "Certificate has incorrect key usage extensions")
@@ -313,6 +313,9 @@ type config struct {
 		UnexpiredOnly       bool
 		BadResultsOnly      bool
 		CheckPeriod         cmd.ConfigDuration
+		// IgnoredLints names the lints whose errors aren't reported as
+		// problems.
+		IgnoredLints []string
 
 		Features map[string]bool
 	}
@@ -376,13 +379,17 @@ func main() {
 
 	pa, err := policy.New(config.PA.Challenges)
 	cmd.FailOnError(err, "Failed to create PA")
-	err = pa.SetHostnamePolicyFile(config.CertChecker.HostnamePolicyFile)
+	err = pa.SetHostnamePolicyFile(config.CertChecker.HostnamePolicyFile, config.CertChecker.HostnamePolicyMaxRemoved)
 	cmd.FailOnError(err, "Failed to load HostnamePolicyFile")
 
+	lints, err := linter.NewRegistry(config.CertChecker.IgnoredLints)
+	cmd.FailOnError(err, "Failed to load lints")
+
 	checker := newChecker(
 		saDbMap,
 		cmd.Clock(),
 		pa,
+		lints,
 		config.CertChecker.CheckPeriod.Duration,
 	)
 	fmt.Fprintf(os.Stderr, "# Getting certificates issued in the last %s\n", config.CertChecker.CheckPeriod)
@@ -18,6 +18,7 @@ import (
 	"golang.org/x/net/context"
 
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/linter"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/policy"
@@ -28,6 +29,7 @@ import (
 )
 
 var pa *policy.AuthorityImpl
+var lints *linter.Registry
 
 func init() {
 	var err error
@@ -35,7 +37,11 @@ func init() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	err = pa.SetHostnamePolicyFile("../../test/hostname-policy.json")
+	lints, err = linter.NewRegistry(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	err = pa.SetHostnamePolicyFile("../../test/hostname-policy.json", 0)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -51,7 +57,7 @@ func BenchmarkCheckCert(b *testing.B) {
 		test.ResetSATestDatabase(b)()
 	}()
 
-	checker := newChecker(saDbMap, clock.Default(), pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, clock.Default(), pa, lints, expectedValidityPeriod)
 	testKey, _ := rsa.GenerateKey(rand.Reader, 1024)
 	expiry := time.Now().AddDate(0, 0, 1)
 	serial := big.NewInt(1337)
@@ -88,7 +94,7 @@ func TestCheckWildcardCert(t *testing.T) {
 	testKey, _ := rsa.GenerateKey(rand.Reader, 2048)
 	fc := clock.NewFake()
 	fc.Add(time.Hour * 24 * 90)
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, lints, expectedValidityPeriod)
 	issued := checker.clock.Now().Add(-time.Hour * 24 * 45)
 	goodExpiry := issued.Add(expectedValidityPeriod)
 	serial := big.NewInt(1337)
@@ -136,7 +142,7 @@ func TestCheckCert(t *testing.T) {
 	fc := clock.NewFake()
 	fc.Add(time.Hour * 24 * 90)
 
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, lints, expectedValidityPeriod)
 
 	// Create a RFC 7633 OCSP Must Staple Extension.
 	// OID 1.3.6.1.5.5.7.1.24
@@ -249,12 +255,61 @@ func TestCheckCert(t *testing.T) {
 	test.AssertEquals(t, len(problems), 0)
 }
 
+func TestCheckCertIgnoredLints(t *testing.T) {
+	testKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	fc := clock.NewFake()
+	fc.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+
+	// Without an OCSP URL the certificate fails
+	// e_sub_cert_aia_does_not_contain_ocsp_url.
+	issued := fc.Now().Add(-time.Hour)
+	serial := big.NewInt(1337)
+	rawCert := x509.Certificate{
+		Subject:               pkix.Name{CommonName: "example-a.com"},
+		NotBefore:             issued,
+		NotAfter:              issued.Add(expectedValidityPeriod),
+		DNSNames:              []string{"example-a.com"},
+		SerialNumber:          serial,
+		BasicConstraintsValid: true,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		IssuingCertificateURL: []string{"http://example.com/cert"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &rawCert, &rawCert, &testKey.PublicKey, testKey)
+	test.AssertNotError(t, err, "Couldn't create certificate")
+	parsed, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "Couldn't parse created certificate")
+	cert := core.Certificate{
+		Serial:  core.SerialToString(serial),
+		Digest:  core.Fingerprint256(der),
+		Expires: parsed.NotAfter,
+		Issued:  parsed.NotBefore,
+		DER:     der,
+	}
+
+	checker := newChecker(nil, fc, pa, lints, expectedValidityPeriod)
+	found := false
+	for _, p := range checker.checkCert(cert) {
+		if p == "zlint error: e_sub_cert_aia_does_not_contain_ocsp_url" {
+			found = true
+		}
+	}
+	test.Assert(t, found, "Lint failure wasn't reported")
+
+	ignoring, err := linter.NewRegistry([]string{"e_sub_cert_aia_does_not_contain_ocsp_url"})
+	test.AssertNotError(t, err, "Couldn't create lint registry")
+	checker = newChecker(nil, fc, pa, ignoring, expectedValidityPeriod)
+	for _, p := range checker.checkCert(cert) {
+		test.AssertNotEquals(t, p, "zlint error: e_sub_cert_aia_does_not_contain_ocsp_url")
+	}
+}
+
 func TestGetAndProcessCerts(t *testing.T) {
 	saDbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
 	test.AssertNotError(t, err, "Couldn't connect to database")
 	fc := clock.NewFake()
 
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, lints, expectedValidityPeriod)
 	sa, err := sa.NewSQLStorageAuthority(saDbMap, fc, blog.NewMock(), metrics.NewNoopScope(), 1)
 	test.AssertNotError(t, err, "Couldn't create SA to insert certificates")
 	saCleanUp := test.ResetSATestDatabase(t)
@@ -340,7 +395,7 @@ func TestGetCertsEmptyResults(t *testing.T) {
 	saDbMap, err := sa.NewDbMap(vars.DBConnSA, 0)
 	test.AssertNotError(t, err, "Couldn't connect to database")
 	fc := clock.NewFake()
-	checker := newChecker(saDbMap, fc, pa, expectedValidityPeriod)
+	checker := newChecker(saDbMap, fc, pa, lints, expectedValidityPeriod)
 	checker.dbMap = mismatchedCountDB{}
 
 	batchSize = 3
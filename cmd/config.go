@@ -70,6 +70,21 @@ type SMTPConfig struct {
 	Username string
 }
 
+// RedisConfig defines how to connect to a Redis server.
+type RedisConfig struct {
+	PasswordConfig
+	// Addr is the host:port of the Redis server.
+	Addr string
+	// PoolSize is the number of connections to keep open, even when idle.
+	PoolSize int
+	// MaxActive is the most connections that may be in use at once. Commands
+	// wait, within Timeout, for a connection once it is reached. It defaults
+	// to PoolSize and is raised to PoolSize if it is smaller.
+	MaxActive int
+	// Timeout bounds each command sent to Redis, including dialing.
+	Timeout ConfigDuration
+}
+
 // PAConfig specifies how a policy authority should connect to its
 // database, what policies it should enforce, and what challenges
 // it should offer.
@@ -82,6 +97,10 @@ type PAConfig struct {
 // what hostnames to issue for.
 type HostnamePolicyConfig struct {
 	HostnamePolicyFile string
+	// HostnamePolicyMaxRemoved is the largest fraction of the loaded policy's
+	// entries that a changed file may remove before it is refused, to guard
+	// against reloading a truncated file. Zero means no limit.
+	HostnamePolicyMaxRemoved float64
 }
 
 // CheckChallenges checks whether the list of challenges in the PA config
@@ -179,6 +198,78 @@ type OCSPUpdaterConfig struct {
 	OCSPGeneratorService *GRPCClientConfig
 	AkamaiPurgerService  *GRPCClientConfig
 
+	// IssuerCerts is a list of paths to the certificates of every issuer whose
+	// OCSP responses are purged from the Akamai cache when a certificate is
+	// revoked. If empty, the single Common.IssuerCert is used.
+	IssuerCerts []string
+
+	// Redis, if set, is where OCSP responses are written in addition to the
+	// certificateStatus table, so that ocsp-responder can serve them without
+	// querying the database.
+	Redis *RedisConfig
+
+	Features map[string]bool
+}
+
+// CRLUpdaterConfig contains the configuration for the CRL Updater, which
+// periodically signs and publishes a sharded CRL for each configured issuer.
+type CRLUpdaterConfig struct {
+	ServiceConfig
+	DBConfig
+
+	// IssuerCerts is a list of paths to the issuer certificates to produce CRLs
+	// for. Revoked certificates which weren't issued by one of these are
+	// skipped.
+	IssuerCerts []string
+	// NumShards is the number of shards each issuer's CRL is split into. A
+	// certificate is placed in the shard given by its serial modulo NumShards.
+	NumShards int64
+	// UpdatePeriod is how often a new set of CRLs is produced. It should be
+	// shorter than the CA's LifespanCRL.
+	UpdatePeriod ConfigDuration
+	// BatchSize is the number of revoked certificates fetched from the database
+	// per query.
+	BatchSize int
+
+	// CRLURLBase, if set, is the URL under which shards are served. Each shard
+	// is published at CRLURLBase/<issuer>/<shard>.crl and that URL is included
+	// in the shard's Issuing Distribution Point extension.
+	CRLURLBase string
+	// OutputDir, if set, is a local directory shards are written to, using the
+	// same <issuer>/<shard>.crl layout.
+	OutputDir string
+	// UploadURLBase, if set, is a base URL shards are uploaded to with HTTP
+	// PUT, using the same <issuer>/<shard>.crl layout.
+	UploadURLBase string
+
+	SignFailureBackoffFactor float64
+	SignFailureBackoffMax    ConfigDuration
+
+	CRLGeneratorService *GRPCClientConfig
+
+	Features map[string]bool
+}
+
+// BadKeyRevokerConfig contains the configuration for the Bad Key Revoker,
+// which revokes the unexpired certificates that use a key after it has been
+// added to the blockedKeys table.
+type BadKeyRevokerConfig struct {
+	ServiceConfig
+	DBConfig
+
+	// Interval is how long to wait before looking for newly blocked keys
+	// after finding none left to check.
+	Interval ConfigDuration
+	// BatchSize is the number of certificates fetched from the database per
+	// query when looking for those that use a blocked key.
+	BatchSize int
+	// MaxBackoff caps how long a key with certificates that couldn't be
+	// revoked is skipped before it is tried again. The wait starts at Interval
+	// and doubles with each failed attempt. Defaults to 24 hours.
+	MaxBackoff ConfigDuration
+
+	RAService *GRPCClientConfig
+
 	Features map[string]bool
 }
 
@@ -343,6 +434,9 @@ type LogDescription struct {
 	URI             string
 	Key             string
 	SubmitFinalCert bool
+	// Operator is the name of the organization operating the log. SCTs must
+	// come from logs of at least two distinct operators.
+	Operator string
 
 	*TemporalSet
 }
@@ -367,3 +461,17 @@ type CTGroup struct {
 	// the next.
 	Stagger ConfigDuration
 }
+
+// CTLogListConfig configures loading CT logs from a log list in the v3
+// log_list.json schema rather than from CTGroups. A group is built from the
+// usable logs of each operator in the list, with temporal shards picked by
+// the certificate's expiration, and SCTs are required from any two of them.
+// The file is reloaded whenever it changes.
+type CTLogListConfig struct {
+	File string
+	// How long to wait for one log in a group to accept a certificate before
+	// moving on to the next.
+	Stagger ConfigDuration
+	// Whether final certificates are submitted to the logs in the list.
+	SubmitFinalCert bool
+}
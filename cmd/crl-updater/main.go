@@ -0,0 +1,390 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	capb "github.com/letsencrypt/boulder/ca/proto"
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+/*
+ * crlDB is an interface collecting the gorp.DbMap functions that the CRL
+ * Updater relies on. Using this adapter shim allows tests to swap out the
+ * dbMap implementation.
+ */
+type crlDB interface {
+	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
+}
+
+// crlIssuer is an issuer certificate the CRL Updater produces CRLs for. The id
+// is used to name the issuer's shards when they are published.
+type crlIssuer struct {
+	cert *x509.Certificate
+	id   string
+}
+
+// revokedCert is a revoked, unexpired certificate as read from the database.
+// The DER is used to work out which issuer the certificate belongs to.
+type revokedCert struct {
+	Serial        string            `db:"serial"`
+	RevokedDate   time.Time         `db:"revokedDate"`
+	RevokedReason revocation.Reason `db:"revokedReason"`
+	DER           []byte            `db:"der"`
+}
+
+// CRLUpdater periodically asks the CA to sign a CRL for each shard of each
+// configured issuer and publishes the results.
+type CRLUpdater struct {
+	stats metrics.Scope
+	log   blog.Logger
+	clk   clock.Clock
+
+	dbMap crlDB
+	cac   core.CertificateAuthority
+
+	issuers    []crlIssuer
+	numShards  int64
+	batchSize  int
+	crlURLBase string
+	publishers []crlPublisher
+
+	updatePeriod         time.Duration
+	failureBackoffFactor float64
+	failureBackoffMax    time.Duration
+	failures             int
+}
+
+func newUpdater(
+	stats metrics.Scope,
+	clk clock.Clock,
+	dbMap crlDB,
+	ca core.CertificateAuthority,
+	issuers []*x509.Certificate,
+	publishers []crlPublisher,
+	config cmd.CRLUpdaterConfig,
+	log blog.Logger,
+) (*CRLUpdater, error) {
+	if len(issuers) == 0 {
+		return nil, errors.New("at least one issuer certificate is required")
+	}
+	if len(publishers) == 0 {
+		return nil, errors.New("at least one of outputDir or uploadURLBase is required")
+	}
+	if config.NumShards <= 0 {
+		return nil, errors.New("numShards must be positive")
+	}
+	if config.UpdatePeriod.Duration == 0 {
+		return nil, errors.New("updatePeriod must be non-zero")
+	}
+	if config.BatchSize == 0 {
+		// Default to 1000
+		config.BatchSize = 1000
+	}
+
+	updater := &CRLUpdater{
+		stats:                stats,
+		log:                  log,
+		clk:                  clk,
+		dbMap:                dbMap,
+		cac:                  ca,
+		numShards:            config.NumShards,
+		batchSize:            config.BatchSize,
+		crlURLBase:           strings.TrimSuffix(config.CRLURLBase, "/"),
+		publishers:           publishers,
+		updatePeriod:         config.UpdatePeriod.Duration,
+		failureBackoffFactor: config.SignFailureBackoffFactor,
+		failureBackoffMax:    config.SignFailureBackoffMax.Duration,
+	}
+	for _, issuer := range issuers {
+		if len(issuer.SubjectKeyId) == 0 {
+			return nil, fmt.Errorf("issuer %q has no subject key identifier", issuer.Subject.CommonName)
+		}
+		updater.issuers = append(updater.issuers, crlIssuer{
+			cert: issuer,
+			id:   hex.EncodeToString(issuer.SubjectKeyId),
+		})
+	}
+	return updater, nil
+}
+
+// findRevokedCertificates returns up to batchSize revoked certificates which
+// haven't expired as of now and whose serial sorts after lastSerial.
+func (updater *CRLUpdater) findRevokedCertificates(now time.Time, lastSerial string, batchSize int) ([]revokedCert, error) {
+	var certs []revokedCert
+	_, err := updater.dbMap.Select(
+		&certs,
+		`SELECT
+				cs.serial,
+				cs.revokedDate,
+				cs.revokedReason,
+				c.der
+				FROM certificateStatus AS cs
+				JOIN certificates AS c ON cs.serial = c.serial
+				WHERE cs.status = :status
+				AND cs.notAfter > :now
+				AND cs.serial > :lastSerial
+				ORDER BY cs.serial ASC
+				LIMIT :limit`,
+		map[string]interface{}{
+			"status":     string(core.OCSPStatusRevoked),
+			"now":        now,
+			"lastSerial": lastSerial,
+			"limit":      batchSize,
+		},
+	)
+	if err == sql.ErrNoRows {
+		return certs, nil
+	}
+	return certs, err
+}
+
+// collectEntries reads every revoked, unexpired certificate from the database
+// and sorts it into the right shard of the right issuer's CRL. The result is
+// indexed first by issuer (in the same order as updater.issuers) and then by
+// shard.
+func (updater *CRLUpdater) collectEntries(batchSize int) ([][][]core.CRLEntry, error) {
+	entries := make([][][]core.CRLEntry, len(updater.issuers))
+	for i := range entries {
+		entries[i] = make([][]core.CRLEntry, updater.numShards)
+	}
+
+	now := updater.clk.Now()
+	lastSerial := ""
+	for {
+		certs, err := updater.findRevokedCertificates(now, lastSerial, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, rc := range certs {
+			issuerIdx, shard, err := updater.placeCertificate(rc)
+			if err != nil {
+				updater.log.AuditErrf("Skipping revoked certificate %s: %s", rc.Serial, err)
+				updater.stats.Inc("SkippedCertificates", 1)
+				continue
+			}
+			entries[issuerIdx][shard] = append(entries[issuerIdx][shard], core.CRLEntry{
+				Serial:    rc.Serial,
+				Reason:    rc.RevokedReason,
+				RevokedAt: rc.RevokedDate,
+			})
+		}
+		if len(certs) < batchSize {
+			return entries, nil
+		}
+		lastSerial = certs[len(certs)-1].Serial
+	}
+}
+
+// placeCertificate returns the index of the issuer of a revoked certificate
+// and the shard of that issuer's CRL the certificate belongs in.
+func (updater *CRLUpdater) placeCertificate(rc revokedCert) (int, int64, error) {
+	cert, err := x509.ParseCertificate(rc.DER)
+	if err != nil {
+		return 0, 0, err
+	}
+	for i, issuer := range updater.issuers {
+		if string(cert.RawIssuer) != string(issuer.cert.RawSubject) {
+			continue
+		}
+		shard := new(big.Int).Mod(cert.SerialNumber, big.NewInt(updater.numShards))
+		return i, shard.Int64(), nil
+	}
+	return 0, 0, fmt.Errorf("issuer %q is not configured", cert.Issuer.CommonName)
+}
+
+// shardPath is the path, relative to a publisher's base, at which a shard is
+// published.
+func shardPath(issuerID string, shard int64) string {
+	return fmt.Sprintf("%s/%d.crl", issuerID, shard)
+}
+
+// updateCRLs signs and publishes every shard of every issuer's CRL. Shards
+// with no entries are still published so that relying parties always see a
+// fresh CRL. A failure to produce one shard doesn't prevent the others from
+// being attempted.
+func (updater *CRLUpdater) updateCRLs(ctx context.Context, batchSize int) error {
+	entries, err := updater.collectEntries(batchSize)
+	if err != nil {
+		updater.log.AuditErrf("Failed to find revoked certificates: %s", err)
+		return err
+	}
+
+	var failures int
+	for i, issuer := range updater.issuers {
+		for shard := int64(0); shard < updater.numShards; shard++ {
+			err := updater.updateShard(ctx, issuer, shard, entries[i][shard])
+			if err != nil {
+				updater.log.AuditErrf("Failed to update CRL shard %d for issuer %q: %s",
+					shard, issuer.cert.Subject.CommonName, err)
+				updater.stats.Inc("ShardFailures", 1)
+				failures++
+				continue
+			}
+			updater.stats.Inc("ShardsPublished", 1)
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("failed to update %d CRL shards", failures)
+	}
+	return nil
+}
+
+func (updater *CRLUpdater) updateShard(ctx context.Context, issuer crlIssuer, shard int64, entries []core.CRLEntry) error {
+	var distributionPoint string
+	if updater.crlURLBase != "" {
+		distributionPoint = updater.crlURLBase + "/" + shardPath(issuer.id, shard)
+	}
+	crl, err := updater.cac.GenerateCRL(ctx, core.CRLSigningRequest{
+		IssuerCommonName:  issuer.cert.Subject.CommonName,
+		Shard:             shard,
+		DistributionPoint: distributionPoint,
+		Entries:           entries,
+	})
+	if err != nil {
+		return err
+	}
+	for _, p := range updater.publishers {
+		err = p.publish(ctx, issuer.id, shard, crl)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tick runs one update and then sleeps until the next update is due, or for
+// a backoff period if the update failed.
+func (updater *CRLUpdater) tick() {
+	tickStart := updater.clk.Now()
+	err := updater.updateCRLs(context.TODO(), updater.batchSize)
+	tickDuration := updater.clk.Now().Sub(tickStart)
+	updater.stats.TimingDuration("TickDuration", tickDuration)
+	updater.stats.Inc("Ticks", 1)
+	if tickDuration > updater.updatePeriod {
+		updater.stats.Inc("LongTicks", 1)
+	}
+
+	sleepDur := updater.updatePeriod - tickDuration
+	if err != nil {
+		updater.stats.Inc("FailedTicks", 1)
+		updater.failures++
+		sleepDur = core.RetryBackoff(updater.failures, updater.updatePeriod, updater.failureBackoffMax, updater.failureBackoffFactor)
+	} else {
+		updater.failures = 0
+	}
+	updater.clk.Sleep(sleepDur)
+}
+
+type config struct {
+	CRLUpdater cmd.CRLUpdaterConfig
+
+	Syslog cmd.SyslogConfig
+}
+
+func setupClients(c cmd.CRLUpdaterConfig, stats metrics.Scope, clk clock.Clock) core.CertificateAuthority {
+	var tls *tls.Config
+	var err error
+	if c.TLS.CertFile != nil {
+		tls, err = c.TLS.Load()
+		cmd.FailOnError(err, "TLS config")
+	}
+	clientMetrics := bgrpc.NewClientMetrics(stats)
+	caConn, err := bgrpc.ClientSetup(c.CRLGeneratorService, tls, clientMetrics, clk)
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to CA")
+	// Make a CA client that is only capable of signing CRLs.
+	return bgrpc.NewCertificateAuthorityClient(nil, nil, capb.NewCRLGeneratorClient(caConn))
+}
+
+func setupPublishers(c cmd.CRLUpdaterConfig) []crlPublisher {
+	var publishers []crlPublisher
+	if c.OutputDir != "" {
+		publishers = append(publishers, dirPublisher{dir: c.OutputDir})
+	}
+	if c.UploadURLBase != "" {
+		publishers = append(publishers, newHTTPPublisher(c.UploadURLBase))
+	}
+	return publishers
+}
+
+func main() {
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	conf := c.CRLUpdater
+	err = features.Set(conf.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, conf.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	var issuers []*x509.Certificate
+	for _, path := range conf.IssuerCerts {
+		issuer, err := core.LoadCert(path)
+		cmd.FailOnError(err, fmt.Sprintf("Failed to load issuer certificate %q", path))
+		issuers = append(issuers, issuer)
+	}
+
+	// Configure DB
+	dbURL, err := conf.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbMap, err := sa.NewDbMap(dbURL, conf.DBConfig.MaxDBConns)
+	cmd.FailOnError(err, "Could not connect to database")
+
+	// Collect and periodically report DB metrics using the DBMap and prometheus scope.
+	sa.InitDBMetrics(dbMap, scope)
+
+	clk := cmd.Clock()
+	cac := setupClients(conf, scope, clk)
+
+	updater, err := newUpdater(
+		scope,
+		clk,
+		dbMap,
+		cac,
+		issuers,
+		setupPublishers(conf),
+		conf,
+		logger,
+	)
+	cmd.FailOnError(err, "Failed to create updater")
+
+	go func() {
+		for {
+			updater.tick()
+		}
+	}()
+
+	go cmd.CatchSignals(logger, nil)
+
+	// Sleep forever (until signaled)
+	select {}
+}
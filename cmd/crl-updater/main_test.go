@@ -0,0 +1,260 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/revocation"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var ctx = context.Background()
+
+// mockCA records the CRL signing requests it receives and returns the issuer's
+// CommonName as the "CRL".
+type mockCA struct {
+	mocks.MockCA
+	requests []core.CRLSigningRequest
+	fail     bool
+}
+
+func (ca *mockCA) GenerateCRL(_ context.Context, req core.CRLSigningRequest) ([]byte, error) {
+	if ca.fail {
+		return nil, errors.New("nope")
+	}
+	ca.requests = append(ca.requests, req)
+	return []byte(req.IssuerCommonName), nil
+}
+
+// mockDB serves revoked certificates from memory, honouring the paging
+// parameters the updater passes.
+type mockDB struct {
+	certs   []revokedCert
+	queries int
+}
+
+func (db *mockDB) Select(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	db.queries++
+	params := args[0].(map[string]interface{})
+	out := i.(*[]revokedCert)
+	for _, rc := range db.certs {
+		if rc.Serial <= params["lastSerial"].(string) {
+			continue
+		}
+		if len(*out) == params["limit"].(int) {
+			break
+		}
+		*out = append(*out, rc)
+	}
+	return nil, nil
+}
+
+type recordingPublisher struct {
+	published map[string][]byte
+}
+
+func (p *recordingPublisher) publish(_ context.Context, issuerID string, shard int64, crl []byte) error {
+	p.published[shardPath(issuerID, shard)] = crl
+	return nil
+}
+
+func makeIssuer(t *testing.T, cn string, skid []byte) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		SubjectKeyId:          skid,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating issuer")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issuer")
+	return cert, key
+}
+
+func makeRevoked(t *testing.T, issuer *x509.Certificate, key *ecdsa.PrivateKey, serial int64) revokedCert {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, issuer, key.Public(), key)
+	test.AssertNotError(t, err, "creating certificate")
+	return revokedCert{
+		Serial:        core.SerialToString(big.NewInt(serial)),
+		RevokedDate:   time.Now(),
+		RevokedReason: revocation.KeyCompromise,
+		DER:           der,
+	}
+}
+
+func setup(t *testing.T, db crlDB, ca core.CertificateAuthority, issuers []*x509.Certificate, p crlPublisher) *CRLUpdater {
+	updater, err := newUpdater(
+		metrics.NewNoopScope(),
+		clock.NewFake(),
+		db,
+		ca,
+		issuers,
+		[]crlPublisher{p},
+		cmd.CRLUpdaterConfig{
+			NumShards:    2,
+			UpdatePeriod: cmd.ConfigDuration{Duration: time.Hour},
+			CRLURLBase:   "http://crl.example.com/",
+		},
+		blog.NewMock(),
+	)
+	test.AssertNotError(t, err, "Failed to create updater")
+	return updater
+}
+
+func TestNewUpdaterValidation(t *testing.T) {
+	issuer, _ := makeIssuer(t, "issuer", []byte{1})
+	p := &recordingPublisher{}
+	good := cmd.CRLUpdaterConfig{NumShards: 1, UpdatePeriod: cmd.ConfigDuration{Duration: time.Hour}}
+
+	_, err := newUpdater(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockCA{}, nil, []crlPublisher{p}, good, blog.NewMock())
+	test.AssertError(t, err, "newUpdater accepted no issuers")
+	_, err = newUpdater(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockCA{}, []*x509.Certificate{issuer}, nil, good, blog.NewMock())
+	test.AssertError(t, err, "newUpdater accepted no publishers")
+	noSKID := &x509.Certificate{Subject: pkix.Name{CommonName: "no skid"}}
+	_, err = newUpdater(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockCA{}, []*x509.Certificate{noSKID}, []crlPublisher{p}, good, blog.NewMock())
+	test.AssertError(t, err, "newUpdater accepted an issuer without a subject key identifier")
+
+	bad := good
+	bad.NumShards = 0
+	_, err = newUpdater(metrics.NewNoopScope(), clock.NewFake(), &mockDB{}, &mockCA{}, []*x509.Certificate{issuer}, []crlPublisher{p}, bad, blog.NewMock())
+	test.AssertError(t, err, "newUpdater accepted zero shards")
+}
+
+func TestUpdateCRLs(t *testing.T) {
+	issuerA, keyA := makeIssuer(t, "issuer A", []byte{0xaa})
+	issuerB, keyB := makeIssuer(t, "issuer B", []byte{0xbb})
+	unknown, unknownKey := makeIssuer(t, "unknown", []byte{0xcc})
+
+	db := &mockDB{certs: []revokedCert{
+		makeRevoked(t, issuerA, keyA, 1),
+		makeRevoked(t, issuerA, keyA, 2),
+		makeRevoked(t, issuerA, keyA, 3),
+		makeRevoked(t, issuerB, keyB, 4),
+		makeRevoked(t, unknown, unknownKey, 5),
+	}}
+	ca := &mockCA{}
+	p := &recordingPublisher{published: make(map[string][]byte)}
+	updater := setup(t, db, ca, []*x509.Certificate{issuerA, issuerB}, p)
+
+	// Use a batch size of 2 so that paging through the results is exercised.
+	err := updater.updateCRLs(ctx, 2)
+	test.AssertNotError(t, err, "updateCRLs failed")
+	test.AssertEquals(t, db.queries, 3)
+
+	// Every shard of every issuer is signed, even empty ones.
+	test.AssertEquals(t, len(ca.requests), 4)
+	var published []string
+	for path := range p.published {
+		published = append(published, path)
+	}
+	sort.Strings(published)
+	test.AssertDeepEquals(t, published, []string{"aa/0.crl", "aa/1.crl", "bb/0.crl", "bb/1.crl"})
+	test.AssertEquals(t, string(p.published["bb/1.crl"]), "issuer B")
+
+	entries := make(map[string][]string)
+	for _, req := range ca.requests {
+		var serials []string
+		for _, entry := range req.Entries {
+			test.AssertEquals(t, entry.Reason, revocation.Reason(revocation.KeyCompromise))
+			serials = append(serials, entry.Serial)
+		}
+		entries[shardPath(req.IssuerCommonName, req.Shard)] = serials
+		test.AssertEquals(t, req.DistributionPoint,
+			"http://crl.example.com/"+shardPath(map[string]string{"issuer A": "aa", "issuer B": "bb"}[req.IssuerCommonName], req.Shard))
+	}
+	test.AssertDeepEquals(t, entries["issuer A/0.crl"], []string{db.certs[1].Serial})
+	test.AssertDeepEquals(t, entries["issuer A/1.crl"], []string{db.certs[0].Serial, db.certs[2].Serial})
+	test.AssertEquals(t, len(entries["issuer B/0.crl"]), 1)
+	test.AssertEquals(t, len(entries["issuer B/1.crl"]), 0)
+}
+
+func TestUpdateCRLsSignFailure(t *testing.T) {
+	issuer, _ := makeIssuer(t, "issuer", []byte{1})
+	p := &recordingPublisher{published: make(map[string][]byte)}
+	updater := setup(t, &mockDB{}, &mockCA{fail: true}, []*x509.Certificate{issuer}, p)
+
+	err := updater.updateCRLs(ctx, 10)
+	test.AssertError(t, err, "updateCRLs didn't fail when the CA did")
+	test.AssertEquals(t, len(p.published), 0)
+}
+
+func TestDirPublisher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crl-updater")
+	test.AssertNotError(t, err, "creating temp dir")
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	p := dirPublisher{dir: dir}
+	err = p.publish(ctx, "aa", 3, []byte("first"))
+	test.AssertNotError(t, err, "publish failed")
+	err = p.publish(ctx, "aa", 3, []byte("second"))
+	test.AssertNotError(t, err, "publish failed")
+
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "aa", "3.crl"))
+	test.AssertNotError(t, err, "reading published CRL")
+	test.AssertEquals(t, string(contents), "second")
+
+	files, err := ioutil.ReadDir(filepath.Join(dir, "aa"))
+	test.AssertNotError(t, err, "listing published CRLs")
+	test.AssertEquals(t, len(files), 1)
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	var gotPath, gotType, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		gotPath, gotType, gotBody = r.URL.Path, r.Header.Get("Content-Type"), string(body)
+		if gotPath == "/crls/aa/1.crl" {
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	p := newHTTPPublisher(srv.URL + "/crls/")
+	err := p.publish(ctx, "aa", 1, []byte("crl"))
+	test.AssertNotError(t, err, "publish failed")
+	test.AssertEquals(t, gotPath, "/crls/aa/1.crl")
+	test.AssertEquals(t, gotType, "application/pkix-crl")
+	test.AssertEquals(t, gotBody, "crl")
+
+	err = p.publish(ctx, "aa", 2, []byte("crl"))
+	test.AssertError(t, err, "publish didn't fail on a 403")
+}
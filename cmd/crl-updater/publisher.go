@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/net/context"
+)
+
+// crlPublisher makes a signed CRL shard available to relying parties.
+type crlPublisher interface {
+	publish(ctx context.Context, issuerID string, shard int64, crl []byte) error
+}
+
+// dirPublisher writes shards to a local directory, for instance one that is
+// served by a web server or synced to a CDN.
+type dirPublisher struct {
+	dir string
+}
+
+// publish writes the shard to a temporary file and then renames it into
+// place, so that a reader never sees a partially written CRL.
+func (p dirPublisher) publish(_ context.Context, issuerID string, shard int64, crl []byte) error {
+	path := filepath.Join(p.dir, filepath.FromSlash(shardPath(issuerID, shard)))
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(path), ".crl-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	_, err = tmp.Write(crl)
+	if err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(tmp.Name(), 0644)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// httpPublisher uploads shards to a web server with HTTP PUT.
+type httpPublisher struct {
+	base   string
+	client *http.Client
+}
+
+func newHTTPPublisher(base string) httpPublisher {
+	return httpPublisher{
+		base:   strings.TrimSuffix(base, "/"),
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (p httpPublisher) publish(ctx context.Context, issuerID string, shard int64, crl []byte) error {
+	url := p.base + "/" + shardPath(issuerID, shard)
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(crl))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/pkix-crl")
+	resp, err := p.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("uploading CRL to %s: unexpected status %d", url, resp.StatusCode)
+	}
+	return nil
+}
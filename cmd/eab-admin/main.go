@@ -0,0 +1,160 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+const usageString = `
+usage:
+eab-admin add-key --config <path> <key-id>
+eab-admin retire-key --config <path> <key-id>
+
+command descriptions:
+  add-key     Create an external account key with the given ID and print its
+              base64url encoded HMAC key, to be handed to the subscriber
+  retire-key  Retire an external account key so that it can no longer be used
+              to create new accounts
+
+args:
+  config    File path to the configuration file for this service
+`
+
+// hmacKeyLength is the length in bytes of generated HMAC keys. 256 bits
+// matches the output size of HS256, the MAC algorithm clients most commonly
+// use for external account bindings.
+const hmacKeyLength = 32
+
+// maxKeyIDLength matches the width of the externalAccountKeys.keyID column.
+const maxKeyIDLength = 255
+
+type config struct {
+	EABAdmin struct {
+		// The EAB admin tool needs a TLSConfig to set up its gRPC client certs,
+		// but doesn't get the TLS field from ServiceConfig, so declares its own.
+		TLS cmd.TLSConfig
+
+		SAService *cmd.GRPCClientConfig
+
+		Features map[string]bool
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func setupContext(c config) (core.StorageAuthority, blog.Logger) {
+	logger := cmd.NewLogger(c.Syslog)
+
+	tlsConfig, err := c.EABAdmin.TLS.Load()
+	cmd.FailOnError(err, "TLS config")
+
+	clientMetrics := bgrpc.NewClientMetrics(metrics.NewNoopScope())
+	saConn, err := bgrpc.ClientSetup(c.EABAdmin.SAService, tlsConfig, clientMetrics, cmd.Clock())
+	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
+	sac := bgrpc.NewStorageAuthorityClient(sapb.NewStorageAuthorityClient(saConn))
+
+	return sac, logger
+}
+
+func validKeyID(keyID string) error {
+	if keyID == "" {
+		return errors.New("key ID must not be empty")
+	}
+	if len(keyID) > maxKeyIDLength {
+		return fmt.Errorf("key ID must be at most %d characters long", maxKeyIDLength)
+	}
+	return nil
+}
+
+// addKey generates a new HMAC key using randReader and stores it in the SA
+// under keyID. It returns the base64url encoding of the HMAC key, which is the
+// form ACME clients expect it in.
+func addKey(ctx context.Context, sac core.StorageAdder, keyID string, randReader io.Reader) (string, error) {
+	if err := validKeyID(keyID); err != nil {
+		return "", err
+	}
+	hmacKey := make([]byte, hmacKeyLength)
+	_, err := io.ReadFull(randReader, hmacKey)
+	if err != nil {
+		return "", err
+	}
+	err = sac.AddExternalAccountKey(ctx, &sapb.ExternalAccountKey{
+		KeyID:   &keyID,
+		HmacKey: hmacKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(hmacKey), nil
+}
+
+// retireKey retires the external account key stored in the SA under keyID.
+func retireKey(ctx context.Context, sac core.StorageAdder, keyID string) error {
+	if err := validKeyID(keyID); err != nil {
+		return err
+	}
+	return sac.RetireExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+}
+
+func main() {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, usageString)
+		os.Exit(1)
+	}
+	if len(os.Args) <= 2 {
+		usage()
+	}
+
+	command := os.Args[1]
+	flagSet := flag.NewFlagSet(command, flag.ContinueOnError)
+	configFile := flagSet.String("config", "", "File path to the configuration file for this service")
+	err := flagSet.Parse(os.Args[2:])
+	cmd.FailOnError(err, "Error parsing flagset")
+
+	if *configFile == "" {
+		usage()
+	}
+
+	var c config
+	err = cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+	err = features.Set(c.EABAdmin.Features)
+	cmd.FailOnError(err, "Failed to set feature flags")
+
+	ctx := context.Background()
+	args := flagSet.Args()
+	switch {
+	case command == "add-key" && len(args) == 1:
+		keyID := args[0]
+		sac, logger := setupContext(c)
+		hmacKey, err := addKey(ctx, sac, keyID, rand.Reader)
+		cmd.FailOnError(err, fmt.Sprintf("Failed to add external account key %q", keyID))
+		logger.AuditInfof("Added external account key %q", keyID)
+		fmt.Printf("key ID:   %s\nHMAC key: %s\n", keyID, hmacKey)
+
+	case command == "retire-key" && len(args) == 1:
+		keyID := args[0]
+		sac, logger := setupContext(c)
+		err := retireKey(ctx, sac, keyID)
+		cmd.FailOnError(err, fmt.Sprintf("Failed to retire external account key %q", keyID))
+		logger.AuditInfof("Retired external account key %q", keyID)
+
+	default:
+		usage()
+	}
+}
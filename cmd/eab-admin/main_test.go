@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/mocks"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var ctx = context.Background()
+
+// recordingSA remembers the external account key operations it is asked to
+// perform.
+type recordingSA struct {
+	mocks.StorageAuthority
+	added   []*sapb.ExternalAccountKey
+	retired []string
+}
+
+func (sa *recordingSA) AddExternalAccountKey(_ context.Context, req *sapb.ExternalAccountKey) error {
+	sa.added = append(sa.added, req)
+	return nil
+}
+
+func (sa *recordingSA) RetireExternalAccountKey(_ context.Context, req *sapb.ExternalAccountKeyID) error {
+	sa.retired = append(sa.retired, *req.KeyID)
+	return nil
+}
+
+func TestAddKey(t *testing.T) {
+	sa := &recordingSA{}
+	random := bytes.Repeat([]byte{0xff}, hmacKeyLength)
+
+	hmacKey, err := addKey(ctx, sa, "some-key", bytes.NewReader(random))
+	test.AssertNotError(t, err, "addKey failed")
+	test.AssertEquals(t, hmacKey, base64.RawURLEncoding.EncodeToString(random))
+	test.AssertEquals(t, len(sa.added), 1)
+	test.AssertEquals(t, *sa.added[0].KeyID, "some-key")
+	test.AssertByteEquals(t, sa.added[0].HmacKey, random)
+
+	_, err = addKey(ctx, sa, "short-read", bytes.NewReader(random[1:]))
+	test.AssertError(t, err, "addKey didn't fail when it couldn't read enough randomness")
+	_, err = addKey(ctx, sa, "", bytes.NewReader(random))
+	test.AssertError(t, err, "addKey accepted an empty key ID")
+	_, err = addKey(ctx, sa, strings.Repeat("a", maxKeyIDLength+1), bytes.NewReader(random))
+	test.AssertError(t, err, "addKey accepted an overlong key ID")
+	test.AssertEquals(t, len(sa.added), 1)
+}
+
+func TestRetireKey(t *testing.T) {
+	sa := &recordingSA{}
+
+	err := retireKey(ctx, sa, "some-key")
+	test.AssertNotError(t, err, "retireKey failed")
+	test.AssertDeepEquals(t, sa.retired, []string{"some-key"})
+
+	err = retireKey(ctx, sa, "")
+	test.AssertError(t, err, "retireKey accepted an empty key ID")
+	test.AssertEquals(t, len(sa.retired), 1)
+}
@@ -3,49 +3,24 @@
 // key and verifies a test message that was signed using the generated private
 // key. Any action it takes should be thoroughly logged and documented.
 //
-// When generating a key this tool follows the following steps:
-//   1. Constructs templates for the private and public keys consisting
-//      of the appropriate PKCS#11 attributes.
-//   2. Executes a PKCS#11 GenerateKeyPair operation with the constructed
-//      templates and either CKM_RSA_PKCS_KEY_PAIR_GEN or CKM_EC_KEY_PAIR_GEN.
-//   3. Extracts the public key components from the returned public key object
-//      handle and construct a Golang public key object from them.
-//   4. Generates 4 bytes of random data from the HSM using a PKCS#11 GenerateRandom
-//      operation.
-//   5. Signs the random data with the private key object handle using a PKCS#11
-//      SignInit/Sign operation.
-//   6. Verifies the returned signature of the random data with the constructed
-//      public key.
-//   7. Marshals the public key into a PEM public key object and print it to STDOUT.
+// When generating a key this tool follows the steps described in
+// ceremony.GenerateKey, and then marshals the public key into a PEM public key
+// object and prints it to STDOUT.
 //
 package main
 
 import (
-	"crypto/x509"
 	"encoding/pem"
 	"flag"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 
+	"github.com/letsencrypt/boulder/ceremony"
 	"github.com/letsencrypt/boulder/pkcs11helpers"
-	"github.com/miekg/pkcs11"
 )
 
-type generateArgs struct {
-	mechanism    []*pkcs11.Mechanism
-	privateAttrs []*pkcs11.Attribute
-	publicAttrs  []*pkcs11.Attribute
-}
-
-func getRandomBytes(ctx pkcs11helpers.PKCtx, session pkcs11.SessionHandle) ([]byte, error) {
-	r, err := ctx.GenerateRandom(session, 4)
-	if err != nil {
-		return nil, err
-	}
-	return r, nil
-}
-
 func main() {
 	module := flag.String("module", "", "PKCS#11 module to use")
 	keyType := flag.String("type", "", "Type of key to generate (RSA or ECDSA)")
@@ -67,6 +42,12 @@ func main() {
 	if *keyType != "RSA" && *keyType != "ECDSA" {
 		log.Fatal("--type may only be RSA or ECDSA")
 	}
+	if *keyType == "RSA" && *rsaModLen == 0 {
+		log.Fatal("--modulus-bits is required")
+	}
+	if *keyType == "ECDSA" && *ecdsaCurve == "" {
+		log.Fatal("--curve is required")
+	}
 	if *label == "" {
 		log.Fatal("--label is required")
 	}
@@ -80,32 +61,17 @@ func main() {
 	}
 	log.Println("Opened PKCS#11 session")
 
-	var pubKey interface{}
-	switch *keyType {
-	case "RSA":
-		if *rsaModLen == 0 {
-			log.Fatal("--modulus-bits is required")
-		}
-		pubKey, err = rsaGenerate(ctx, session, *label, *rsaModLen, *rsaExp)
-		if err != nil {
-			log.Fatalf("Failed to generate RSA key pair: %s", err)
-		}
-	case "ECDSA":
-		if *ecdsaCurve == "" {
-			log.Fatal("--ecdsaCurve is required")
-		}
-		pubKey, err = ecGenerate(ctx, session, *label, *ecdsaCurve)
-		if err != nil {
-			log.Fatalf("Failed to generate ECDSA key pair: %s", err)
-		}
-	}
-
-	der, err := x509.MarshalPKIXPublicKey(pubKey)
+	key, err := ceremony.GenerateKey(ctx, session, *label, ceremony.KeySpec{
+		Type:              strings.ToLower(*keyType),
+		RSAModLength:      *rsaModLen,
+		RSAPublicExponent: *rsaExp,
+		ECDSACurve:        *ecdsaCurve,
+	})
 	if err != nil {
-		log.Fatalf("Failed to marshal public key: %s", err)
+		log.Fatal(err)
 	}
 
-	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: key.DER})
 	log.Printf("Public key PEM:\n%s\n", pemBytes)
 	if err := ioutil.WriteFile(*outputPath, pemBytes, os.ModePerm); err != nil {
 		log.Fatalf("Failed to write public key to %q: %s", *outputPath, err)
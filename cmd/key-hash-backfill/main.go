@@ -0,0 +1,155 @@
+// Command key-hash-backfill records the public key hashes of unexpired
+// certificates issued before the StoreKeyHashes feature was enabled in the
+// keyHashToSerial table. Until it has run, RevokeCertByKey and
+// bad-key-revoker don't find those certificates. It can be run again safely:
+// hashes that are already recorded are left untouched.
+package main
+
+import (
+	"crypto/x509"
+	"database/sql"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/sa"
+)
+
+type backfillDB interface {
+	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+type certInfo struct {
+	ID      int64     `db:"id"`
+	Serial  string    `db:"serial"`
+	DER     []byte    `db:"der"`
+	Expires time.Time `db:"expires"`
+}
+
+type backfiller struct {
+	dbMap     backfillDB
+	clk       clock.Clock
+	log       blog.Logger
+	batchSize int
+}
+
+// findCerts returns up to batchSize unexpired certificates whose IDs are
+// greater than lastID, in ID order.
+func (b *backfiller) findCerts(lastID int64) ([]certInfo, error) {
+	var certs []certInfo
+	_, err := b.dbMap.Select(
+		&certs,
+		`SELECT id, serial, der, expires
+		FROM certificates
+		WHERE id > ? AND expires > ?
+		ORDER BY id ASC
+		LIMIT ?`,
+		lastID,
+		b.clk.Now(),
+		b.batchSize,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return certs, err
+}
+
+// recordKeyHash records the hash of the certificate's public key, unless it
+// is already recorded.
+func (b *backfiller) recordKeyHash(c certInfo) error {
+	cert, err := x509.ParseCertificate(c.DER)
+	if err != nil {
+		return err
+	}
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	_, err = b.dbMap.Exec(
+		`INSERT IGNORE INTO keyHashToSerial (keyHash, certNotAfter, certSerial)
+		VALUES (?, ?, ?)`,
+		keyHash,
+		c.Expires,
+		c.Serial,
+	)
+	return err
+}
+
+// backfill records the key hashes of every unexpired certificate, and returns
+// the number of certificates it has seen. A certificate whose key hash can't
+// be recorded is logged and skipped.
+func (b *backfiller) backfill() (int, error) {
+	var lastID int64
+	var count, failures int
+	for {
+		certs, err := b.findCerts(lastID)
+		if err != nil {
+			return count, err
+		}
+		if len(certs) == 0 {
+			break
+		}
+		for _, c := range certs {
+			err := b.recordKeyHash(c)
+			if err != nil {
+				b.log.AuditErrf("failed to record key hash: serial=[%s] err=[%s]", c.Serial, err)
+				failures++
+			}
+			lastID = c.ID
+			count++
+		}
+		b.log.Infof("recorded key hashes of %d certificates, up to ID %d", count, lastID)
+	}
+	if failures > 0 {
+		return count, fmt.Errorf("failed to record the key hashes of %d certificates", failures)
+	}
+	return count, nil
+}
+
+type config struct {
+	KeyHashBackfill struct {
+		cmd.DBConfig
+		// BatchSize is the number of certificates read from the database at
+		// a time.
+		BatchSize int
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	configFile := flag.String("config", "", "Path to configuration file")
+	flag.Parse()
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, fmt.Sprintf("Failed to read and parse configuration file %q", *configFile))
+
+	conf := c.KeyHashBackfill
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = 1000
+	}
+
+	logger := cmd.NewLogger(c.Syslog)
+
+	dbURL, err := conf.DBConfig.URL()
+	cmd.FailOnError(err, "Couldn't load DB URL")
+	dbMap, err := sa.NewDbMap(dbURL, conf.DBConfig.MaxDBConns)
+	cmd.FailOnError(err, "Could not connect to database")
+
+	b := &backfiller{
+		dbMap:     dbMap,
+		clk:       cmd.Clock(),
+		log:       logger,
+		batchSize: conf.BatchSize,
+	}
+	count, err := b.backfill()
+	cmd.FailOnError(err, "Backfilling key hashes")
+	logger.Infof("recorded key hashes of %d unexpired certificates", count)
+}
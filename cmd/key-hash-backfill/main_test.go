@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"database/sql"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/core"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+type keyHashRow struct {
+	keyHash []byte
+	serial  string
+}
+
+// mockDB holds certificates in memory, honouring the paging parameters the
+// backfiller passes, and records the key hashes inserted. Inserting a key
+// hash that is already recorded is ignored.
+type mockDB struct {
+	certs     []certInfo
+	keyHashes []keyHashRow
+}
+
+func (db *mockDB) Select(i interface{}, query string, args ...interface{}) ([]interface{}, error) {
+	lastID := args[0].(int64)
+	now := args[1].(time.Time)
+	limit := args[2].(int)
+	out := i.(*[]certInfo)
+	for _, c := range db.certs {
+		if c.ID <= lastID || !c.Expires.After(now) {
+			continue
+		}
+		if len(*out) == limit {
+			break
+		}
+		*out = append(*out, c)
+	}
+	return nil, nil
+}
+
+func (db *mockDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	row := keyHashRow{keyHash: args[0].([]byte), serial: args[2].(string)}
+	for _, existing := range db.keyHashes {
+		if bytes.Equal(existing.keyHash, row.keyHash) && existing.serial == row.serial {
+			return nil, nil
+		}
+	}
+	db.keyHashes = append(db.keyHashes, row)
+	return nil, nil
+}
+
+func makeCert(t *testing.T, id int64, key *ecdsa.PrivateKey, expires time.Time) certInfo {
+	t.Helper()
+	template := &x509.Certificate{SerialNumber: big.NewInt(id), NotAfter: expires}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating certificate")
+	return certInfo{
+		ID:      id,
+		Serial:  core.SerialToString(template.SerialNumber),
+		DER:     der,
+		Expires: expires,
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	fc := clock.NewFake()
+	fc.Set(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC))
+	k1, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	k2, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating key")
+	unexpired := fc.Now().Add(30 * 24 * time.Hour)
+
+	db := &mockDB{certs: []certInfo{
+		makeCert(t, 1, k1, unexpired),
+		makeCert(t, 2, k2, fc.Now().Add(-time.Hour)),
+		makeCert(t, 3, k2, unexpired),
+		{ID: 4, Serial: "malformed", DER: []byte("not a cert"), Expires: unexpired},
+		makeCert(t, 5, k1, unexpired),
+	}}
+	b := &backfiller{dbMap: db, clk: fc, log: blog.NewMock(), batchSize: 2}
+
+	// The malformed certificate is reported, but doesn't stop the others
+	// from being backfilled.
+	count, err := b.backfill()
+	test.AssertError(t, err, "backfill succeeded with a malformed certificate")
+	test.AssertEquals(t, count, 4)
+	test.AssertEquals(t, len(db.keyHashes), 3)
+	k1Hash, err := core.KeySPKIHash(k1.Public())
+	test.AssertNotError(t, err, "hashing key")
+	k2Hash, err := core.KeySPKIHash(k2.Public())
+	test.AssertNotError(t, err, "hashing key")
+	test.AssertDeepEquals(t, db.keyHashes, []keyHashRow{
+		{keyHash: k1Hash, serial: db.certs[0].Serial},
+		{keyHash: k2Hash, serial: db.certs[2].Serial},
+		{keyHash: k1Hash, serial: db.certs[4].Serial},
+	})
+
+	// Running again records nothing new.
+	db.certs = append(db.certs[:3], db.certs[4])
+	_, err = b.backfill()
+	test.AssertNotError(t, err, "backfill failed")
+	test.AssertEquals(t, len(db.keyHashes), 3)
+}
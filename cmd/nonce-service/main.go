@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"github.com/letsencrypt/boulder/cmd"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
+	"github.com/letsencrypt/boulder/nonce"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
+)
+
+type config struct {
+	NonceService struct {
+		cmd.ServiceConfig
+
+		// MaxUsed is the number of redeemed nonces remembered. If zero,
+		// nonce.MaxUsed is used.
+		MaxUsed int
+		// NoncePrefix is added to every nonce this instance issues so that WFEs
+		// can send them back to it to be redeemed. It is required, and must be
+		// unique among the instances the WFEs are configured with.
+		NoncePrefix string
+	}
+
+	Syslog cmd.SyslogConfig
+}
+
+func main() {
+	grpcAddr := flag.String("addr", "", "gRPC listen address override")
+	debugAddr := flag.String("debug-addr", "", "Debug server address override")
+	prefixOverride := flag.String("prefix", "", "Override the configured nonce prefix")
+	configFile := flag.String("config", "", "File path to the configuration file for this service")
+	flag.Parse()
+	if *configFile == "" {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var c config
+	err := cmd.ReadConfigFile(*configFile, &c)
+	cmd.FailOnError(err, "Reading JSON config file into config structure")
+
+	if *grpcAddr != "" {
+		c.NonceService.GRPC.Address = *grpcAddr
+	}
+	if *debugAddr != "" {
+		c.NonceService.DebugAddr = *debugAddr
+	}
+	if *prefixOverride != "" {
+		c.NonceService.NoncePrefix = *prefixOverride
+	}
+	// Without a prefix the WFEs can't tell which instance issued a nonce, so
+	// none of the nonces this instance issues could be redeemed.
+	if c.NonceService.NoncePrefix == "" {
+		cmd.Fail("NoncePrefix must not be empty")
+	}
+
+	scope, logger := cmd.StatsAndLogging(c.Syslog, c.NonceService.DebugAddr)
+	defer logger.AuditPanic()
+	logger.Info(cmd.VersionString())
+
+	ns, err := nonce.NewNonceService(scope, c.NonceService.MaxUsed, c.NonceService.NoncePrefix)
+	cmd.FailOnError(err, "Failed to initialize nonce service")
+
+	tlsConfig, err := c.NonceService.TLS.Load()
+	cmd.FailOnError(err, "tlsConfig config")
+
+	serverMetrics := bgrpc.NewServerMetrics(scope)
+	grpcSrv, l, err := bgrpc.NewServer(c.NonceService.GRPC, tlsConfig, serverMetrics, cmd.Clock())
+	cmd.FailOnError(err, "Unable to setup nonce service gRPC server")
+	noncepb.RegisterNonceServiceServer(grpcSrv, nonce.NewServer(ns))
+
+	go cmd.CatchSignals(logger, grpcSrv.GracefulStop)
+
+	err = cmd.FilterShutdownErrors(grpcSrv.Serve(l))
+	cmd.FailOnError(err, "Nonce service gRPC server failed")
+}
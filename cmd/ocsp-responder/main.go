@@ -26,6 +26,7 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/metrics/measured_http"
+	"github.com/letsencrypt/boulder/rocsp"
 	"github.com/letsencrypt/boulder/sa"
 )
 
@@ -69,7 +70,7 @@ serialNumber field, since we will always query on it.
 */
 type DBSource struct {
 	dbMap             dbSelector
-	caKeyHash         []byte
+	caKeyHashes       [][]byte
 	reqSerialPrefixes []string
 	timeout           time.Duration
 	log               blog.Logger
@@ -83,17 +84,17 @@ type dbSelector interface {
 }
 
 // NewSourceFromDatabase produces a DBSource representing the binding of a
-// given DB schema to a CA key.
+// given DB schema to one or more CA keys.
 func NewSourceFromDatabase(
 	dbMap dbSelector,
-	caKeyHash []byte,
+	caKeyHashes [][]byte,
 	reqSerialPrefixes []string,
 	timeout time.Duration,
 	log blog.Logger,
 ) (src *DBSource, err error) {
 	src = &DBSource{
 		dbMap:             dbMap,
-		caKeyHash:         caKeyHash,
+		caKeyHashes:       caKeyHashes,
 		reqSerialPrefixes: reqSerialPrefixes,
 		timeout:           timeout,
 		log:               log,
@@ -106,12 +107,20 @@ type dbResponse struct {
 	OCSPLastUpdated time.Time
 }
 
-// Response is called by the HTTP server to handle a new OCSP request.
-func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
-	// Check that this request is for the proper CA
-	if bytes.Compare(req.IssuerKeyHash, src.caKeyHash) != 0 {
+// serialFor checks that req is for a certificate this source could have a
+// response for and returns its serial, or cfocsp.ErrNotFound if it isn't.
+func (src *DBSource) serialFor(req *ocsp.Request) (string, error) {
+	// Check that this request is for one of our CAs
+	var knownCA bool
+	for _, caKeyHash := range src.caKeyHashes {
+		if bytes.Equal(req.IssuerKeyHash, caKeyHash) {
+			knownCA = true
+			break
+		}
+	}
+	if !knownCA {
 		src.log.Debugf("Request intended for CA Cert ID: %s", hex.EncodeToString(req.IssuerKeyHash))
-		return nil, nil, cfocsp.ErrNotFound
+		return "", cfocsp.ErrNotFound
 	}
 
 	serialString := core.SerialToString(req.SerialNumber)
@@ -123,16 +132,30 @@ func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
 			}
 		}
 		if !match {
-			return nil, nil, cfocsp.ErrNotFound
+			return "", cfocsp.ErrNotFound
 		}
 	}
+	return serialString, nil
+}
 
+// Response is called by the HTTP server to handle a new OCSP request.
+func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	serialString, err := src.serialFor(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src.responseForSerial(serialString)
+}
+
+// responseForSerial looks up the response for a serial that has already been
+// checked by serialFor.
+func (src *DBSource) responseForSerial(serialString string) ([]byte, http.Header, error) {
 	src.log.Debugf("Searching for OCSP issued by us for serial %s", serialString)
 
 	var response dbResponse
 	defer func() {
 		if len(response.OCSPResponse) != 0 {
-			src.log.Debugf("OCSP Response sent for CA=%s, Serial=%s", hex.EncodeToString(src.caKeyHash), serialString)
+			src.log.Debugf("OCSP Response sent for Serial=%s", serialString)
 		}
 	}()
 	ctx := context.Background()
@@ -154,29 +177,36 @@ func (src *DBSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
 		return nil, nil, err
 	}
 	if response.OCSPLastUpdated.IsZero() {
-		src.log.Debugf("OCSP Response not sent (ocspLastUpdated is zero) for CA=%s, Serial=%s", hex.EncodeToString(src.caKeyHash), serialString)
+		src.log.Debugf("OCSP Response not sent (ocspLastUpdated is zero) for Serial=%s", serialString)
 		return nil, nil, cfocsp.ErrNotFound
 	}
 
 	return response.OCSPResponse, nil, nil
 }
 
-func makeDBSource(dbMap dbSelector, issuerCert string, reqSerialPrefixes []string, timeout time.Duration, log blog.Logger) (*DBSource, error) {
-	// Load the CA's key so we can store its SubjectKey in the DB
-	caCertDER, err := cmd.LoadCert(issuerCert)
-	if err != nil {
-		return nil, fmt.Errorf("Could not read issuer cert %s: %s", issuerCert, err)
-	}
-	caCert, err := x509.ParseCertificate(caCertDER)
-	if err != nil {
-		return nil, fmt.Errorf("Could not parse issuer cert %s: %s", issuerCert, err)
+func makeDBSource(dbMap dbSelector, issuerCerts []string, reqSerialPrefixes []string, timeout time.Duration, log blog.Logger) (*DBSource, error) {
+	if len(issuerCerts) == 0 {
+		return nil, fmt.Errorf("No issuer certs")
 	}
-	if len(caCert.SubjectKeyId) == 0 {
-		return nil, fmt.Errorf("Empty subjectKeyID")
+	// Load each CA's key so we can recognize requests for its certificates
+	var caKeyHashes [][]byte
+	for _, issuerCert := range issuerCerts {
+		caCertDER, err := cmd.LoadCert(issuerCert)
+		if err != nil {
+			return nil, fmt.Errorf("Could not read issuer cert %s: %s", issuerCert, err)
+		}
+		caCert, err := x509.ParseCertificate(caCertDER)
+		if err != nil {
+			return nil, fmt.Errorf("Could not parse issuer cert %s: %s", issuerCert, err)
+		}
+		if len(caCert.SubjectKeyId) == 0 {
+			return nil, fmt.Errorf("Empty subjectKeyID in issuer cert %s", issuerCert)
+		}
+		caKeyHashes = append(caKeyHashes, caCert.SubjectKeyId)
 	}
 
 	// Construct source from DB
-	return NewSourceFromDatabase(dbMap, caCert.SubjectKeyId, reqSerialPrefixes, timeout, log)
+	return NewSourceFromDatabase(dbMap, caKeyHashes, reqSerialPrefixes, timeout, log)
 }
 
 type config struct {
@@ -204,6 +234,16 @@ type config struct {
 
 		RequiredSerialPrefixes []string
 
+		// IssuerCerts is a list of paths to the certificates of every issuer
+		// whose OCSP responses are served from the database. If empty, the
+		// single Common.IssuerCert is used.
+		IssuerCerts []string
+
+		// Redis, if set, is checked for a response written by ocsp-updater
+		// before the database is queried. It is only used when responses are
+		// served from a database.
+		Redis *cmd.RedisConfig
+
 		Features map[string]bool
 	}
 
@@ -258,20 +298,31 @@ as generated by Boulder's single-ocsp command.
 		if dbConnect == "" {
 			dbConnect = config.Source
 		}
-		logger.Infof("Loading OCSP Database for CA Cert: %s", c.Common.IssuerCert)
+		issuerCerts := config.IssuerCerts
+		if len(issuerCerts) == 0 {
+			issuerCerts = []string{c.Common.IssuerCert}
+		}
+		logger.Infof("Loading OCSP Database for CA Certs: %s", strings.Join(issuerCerts, ", "))
 		dbMap, err := sa.NewDbMap(dbConnect, config.DBConfig.MaxDBConns)
 		cmd.FailOnError(err, "Could not connect to database")
 		sa.SetSQLDebug(dbMap, logger)
 		// Collect and periodically report DB metrics using the DBMap and prometheus scope.
 		sa.InitDBMetrics(dbMap, scope)
 
-		source, err = makeDBSource(
+		dbSource, err := makeDBSource(
 			dbMap,
-			c.Common.IssuerCert,
+			issuerCerts,
 			c.OCSPResponder.RequiredSerialPrefixes,
 			c.OCSPResponder.Timeout.Duration,
 			logger)
 		cmd.FailOnError(err, "Couldn't load OCSP DB")
+		source = dbSource
+		if config.Redis != nil {
+			rc, err := rocsp.NewClientFromConfig(*config.Redis)
+			cmd.FailOnError(err, "Failed to create Redis client")
+			logger.Infof("Serving OCSP responses from Redis at %s", config.Redis.Addr)
+			source = newRedisSource(rc, dbSource, scope, logger)
+		}
 		// Export the MaxDBConns
 		dbConnStat := prometheus.NewGauge(prometheus.GaugeOpts{
 			Name: "max_db_connections",
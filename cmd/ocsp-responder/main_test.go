@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -21,6 +22,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/rocsp"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/prometheus/client_golang/prometheus"
 )
@@ -87,7 +89,7 @@ func TestMux(t *testing.T) {
 }
 
 func TestDBHandler(t *testing.T) {
-	src, err := makeDBSource(mockSelector{}, "./testdata/test-ca.der.pem", nil, time.Second, blog.NewMock())
+	src, err := makeDBSource(mockSelector{}, []string{"./testdata/test-ca.der.pem"}, nil, time.Second, blog.NewMock())
 	if err != nil {
 		t.Fatalf("makeDBSource: %s", err)
 	}
@@ -210,7 +212,7 @@ func (bs brokenSelector) WithContext(context.Context) gorp.SqlExecutor {
 
 func TestErrorLog(t *testing.T) {
 	mockLog := blog.NewMock()
-	src, err := makeDBSource(brokenSelector{}, "./testdata/test-ca.der.pem", nil, time.Second, mockLog)
+	src, err := makeDBSource(brokenSelector{}, []string{"./testdata/test-ca.der.pem"}, nil, time.Second, mockLog)
 	test.AssertNotError(t, err, "Failed to create broken dbMap")
 
 	ocspReq, err := ocsp.ParseRequest(req)
@@ -236,7 +238,7 @@ func mustRead(path string) []byte {
 
 func TestRequiredSerialPrefix(t *testing.T) {
 	mockLog := blog.NewMock()
-	src, err := makeDBSource(mockSelector{}, "./testdata/test-ca.der.pem", []string{"nope"}, time.Second, mockLog)
+	src, err := makeDBSource(mockSelector{}, []string{"./testdata/test-ca.der.pem"}, []string{"nope"}, time.Second, mockLog)
 	test.AssertNotError(t, err, "failed to create DBSource")
 
 	ocspReq, err := ocsp.ParseRequest(req)
@@ -247,8 +249,86 @@ func TestRequiredSerialPrefix(t *testing.T) {
 
 	fmt.Println(core.SerialToString(ocspReq.SerialNumber))
 
-	src, err = makeDBSource(mockSelector{}, "./testdata/test-ca.der.pem", []string{"00", "nope"}, time.Second, mockLog)
+	src, err = makeDBSource(mockSelector{}, []string{"./testdata/test-ca.der.pem"}, []string{"00", "nope"}, time.Second, mockLog)
 	test.AssertNotError(t, err, "failed to create DBSource")
 	_, _, err = src.Response(ocspReq)
 	test.AssertNotError(t, err, "src.Response failed with acceptable prefix")
 }
+
+func TestMultipleIssuers(t *testing.T) {
+	mockLog := blog.NewMock()
+	ocspReq, err := ocsp.ParseRequest(req)
+	test.AssertNotError(t, err, "Failed to parse OCSP request")
+
+	src, err := makeDBSource(mockSelector{}, []string{"../../test/test-root.pem"}, nil, time.Second, mockLog)
+	test.AssertNotError(t, err, "failed to create DBSource")
+	_, _, err = src.Response(ocspReq)
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+
+	src, err = makeDBSource(mockSelector{}, []string{"../../test/test-root.pem", "./testdata/test-ca.der.pem"}, nil, time.Second, mockLog)
+	test.AssertNotError(t, err, "failed to create DBSource")
+	_, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "src.Response failed for a request for the second issuer")
+
+	_, err = makeDBSource(mockSelector{}, nil, nil, time.Second, mockLog)
+	test.AssertError(t, err, "makeDBSource accepted no issuer certs")
+}
+
+// mockResponseGetter serves responses from a map, or fails every lookup if
+// err is set.
+type mockResponseGetter struct {
+	responses map[string][]byte
+	err       error
+	lookups   int
+}
+
+func (m *mockResponseGetter) GetResponse(_ context.Context, serial string) ([]byte, error) {
+	m.lookups++
+	if m.err != nil {
+		return nil, m.err
+	}
+	resp, ok := m.responses[serial]
+	if !ok {
+		return nil, rocsp.ErrNotFound
+	}
+	return resp, nil
+}
+
+func TestRedisSource(t *testing.T) {
+	ocspReq, err := ocsp.ParseRequest(req)
+	test.AssertNotError(t, err, "Failed to parse OCSP request")
+	serial := core.SerialToString(ocspReq.SerialNumber)
+
+	dbSource, err := makeDBSource(mockSelector{}, []string{"./testdata/test-ca.der.pem"}, nil, time.Second, blog.NewMock())
+	test.AssertNotError(t, err, "makeDBSource failed")
+	getter := &mockResponseGetter{responses: map[string][]byte{serial: []byte("from redis")}}
+	src := newRedisSource(getter, dbSource, stats, blog.NewMock())
+
+	// A hit is served from Redis.
+	body, _, err := src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertEquals(t, string(body), "from redis")
+	test.AssertEquals(t, test.CountCounterVec("result", "hit", src.lookups), 1)
+
+	// A miss falls back to the database.
+	delete(getter.responses, serial)
+	body, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertByteEquals(t, body, resp.OCSPResponse)
+	test.AssertEquals(t, test.CountCounterVec("result", "miss", src.lookups), 1)
+
+	// As does any other failure to look the response up in Redis.
+	getter.err = errors.New("connection refused")
+	body, _, err = src.Response(ocspReq)
+	test.AssertNotError(t, err, "Response failed")
+	test.AssertByteEquals(t, body, resp.OCSPResponse)
+	test.AssertEquals(t, test.CountCounterVec("result", "error", src.lookups), 1)
+
+	// Requests the database source would reject don't reach Redis.
+	getter.err = nil
+	getter.lookups = 0
+	dbSource.reqSerialPrefixes = []string{"nope"}
+	_, _, err = src.Response(ocspReq)
+	test.AssertEquals(t, err, cfocsp.ErrNotFound)
+	test.AssertEquals(t, getter.lookups, 0)
+}
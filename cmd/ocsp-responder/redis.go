@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ocsp"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/rocsp"
+)
+
+// responseGetter is the part of *rocsp.Client that RedisSource uses.
+type responseGetter interface {
+	GetResponse(ctx context.Context, serial string) ([]byte, error)
+}
+
+// RedisSource serves the pre-signed OCSP responses that ocsp-updater writes to
+// Redis, falling back to a DBSource for responses that aren't there, either
+// because they haven't been written yet or because Redis is unavailable.
+type RedisSource struct {
+	client  responseGetter
+	db      *DBSource
+	log     blog.Logger
+	lookups *prometheus.CounterVec
+}
+
+// newRedisSource returns a RedisSource that looks responses up with client
+// before trying db. Requests that db would reject, because they are for
+// another issuer or don't have a required serial prefix, are rejected without
+// querying Redis.
+func newRedisSource(client responseGetter, db *DBSource, scope metrics.Scope, log blog.Logger) *RedisSource {
+	lookups := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "redisLookups",
+			Help: "Number of OCSP response lookups in Redis by result (hit, miss or error)",
+		},
+		[]string{"result"},
+	)
+	scope.MustRegister(lookups)
+	return &RedisSource{
+		client:  client,
+		db:      db,
+		log:     log,
+		lookups: lookups,
+	}
+}
+
+// Response is called by the HTTP server to handle a new OCSP request.
+func (src *RedisSource) Response(req *ocsp.Request) ([]byte, http.Header, error) {
+	serialString, err := src.db.serialFor(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.Background()
+	if src.db.timeout != 0 {
+		var cancel func()
+		ctx, cancel = context.WithTimeout(ctx, src.db.timeout)
+		defer cancel()
+	}
+	resp, err := src.client.GetResponse(ctx, serialString)
+	switch {
+	case err == nil:
+		src.lookups.With(prometheus.Labels{"result": "hit"}).Inc()
+		return resp, nil, nil
+	case err == rocsp.ErrNotFound:
+		src.lookups.With(prometheus.Labels{"result": "miss"}).Inc()
+	default:
+		src.lookups.With(prometheus.Labels{"result": "error"}).Inc()
+		src.log.Warningf("Looking up OCSP response in Redis: %s", err)
+	}
+	return src.db.responseForSerial(serialString)
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"crypto/tls"
 	"crypto/x509"
 	"database/sql"
@@ -19,6 +20,7 @@ import (
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
+	"github.com/letsencrypt/boulder/rocsp"
 	"github.com/letsencrypt/boulder/sa"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"golang.org/x/crypto/ocsp"
@@ -36,6 +38,13 @@ type ocspDB interface {
 	Exec(query string, args ...interface{}) (sql.Result, error)
 }
 
+// ocspResponseStore is somewhere other than the database that OCSP responses
+// are written to, so that they can be served without a database query. It is
+// satisfied by *rocsp.Client.
+type ocspResponseStore interface {
+	StoreResponse(ctx context.Context, serial string, resp []byte, ttl time.Duration) error
+}
+
 // OCSPUpdater contains the useful objects for the Updater
 type OCSPUpdater struct {
 	stats metrics.Scope
@@ -59,8 +68,13 @@ type OCSPUpdater struct {
 
 	ccu           *akamai.CachePurgeClient
 	purgerService akamaipb.AkamaiPurgerClient
-	// issuer is used to generate OCSP request URLs to purge
-	issuer *x509.Certificate
+	// issuers are used to generate OCSP request URLs to purge, each for the
+	// certificates it issued
+	issuers []*x509.Certificate
+
+	// responseStore, if non-nil, receives a copy of every OCSP response that
+	// is stored in the database.
+	responseStore ocspResponseStore
 }
 
 func newUpdater(
@@ -70,8 +84,9 @@ func newUpdater(
 	ca core.CertificateAuthority,
 	sac core.StorageAuthority,
 	apc akamaipb.AkamaiPurgerClient,
+	responseStore ocspResponseStore,
 	config cmd.OCSPUpdaterConfig,
-	issuerPath string,
+	issuerPaths []string,
 	log blog.Logger,
 ) (*OCSPUpdater, error) {
 	if config.OldOCSPBatchSize == 0 ||
@@ -101,6 +116,7 @@ func newUpdater(
 		ocspMinTimeToExpiry:          config.OCSPMinTimeToExpiry.Duration,
 		ocspStaleMaxAge:              config.OCSPStaleMaxAge.Duration,
 		parallelGenerateOCSPRequests: config.ParallelGenerateOCSPRequests,
+		responseStore:                responseStore,
 	}
 
 	// Setup loops
@@ -131,11 +147,17 @@ func newUpdater(
 			})
 	}
 
-	if config.AkamaiBaseURL != "" {
-		issuer, err := core.LoadCert(issuerPath)
-		if err != nil {
-			return nil, err
+	if config.AkamaiBaseURL != "" || apc != nil {
+		for _, issuerPath := range issuerPaths {
+			issuer, err := core.LoadCert(issuerPath)
+			if err != nil {
+				return nil, err
+			}
+			updater.issuers = append(updater.issuers, issuer)
 		}
+	}
+
+	if config.AkamaiBaseURL != "" {
 		ccu, err := akamai.NewCachePurgeClient(
 			config.AkamaiBaseURL,
 			config.AkamaiClientToken,
@@ -151,13 +173,7 @@ func newUpdater(
 			return nil, err
 		}
 		updater.ccu = ccu
-		updater.issuer = issuer
 	} else if apc != nil {
-		issuer, err := core.LoadCert(issuerPath)
-		if err != nil {
-			return nil, err
-		}
-		updater.issuer = issuer
 		updater.purgerService = apc
 	}
 
@@ -257,7 +273,11 @@ func (updater *OCSPUpdater) generateRevokedResponse(ctx context.Context, status
 	// If cache client is populated generate purge URLs
 	var purgeURLs []string
 	if updater.ccu != nil || updater.purgerService != nil {
-		purgeURLs, err = akamai.GeneratePurgeURLs(cert.DER, updater.issuer)
+		issuer, err := updater.issuerFor(cert.DER)
+		if err != nil {
+			return nil, nil, err
+		}
+		purgeURLs, err = akamai.GeneratePurgeURLs(cert.DER, issuer)
 		if err != nil {
 			return nil, nil, err
 		}
@@ -266,12 +286,27 @@ func (updater *OCSPUpdater) generateRevokedResponse(ctx context.Context, status
 	return &status, purgeURLs, nil
 }
 
-func (updater *OCSPUpdater) storeResponse(status *core.CertificateStatus) error {
+// issuerFor returns the configured issuer of the certificate in der.
+func (updater *OCSPUpdater) issuerFor(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	for _, issuer := range updater.issuers {
+		if bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+			return issuer, nil
+		}
+	}
+	return nil, fmt.Errorf("issuer %q of certificate %s is not configured",
+		cert.Issuer.CommonName, core.SerialToString(cert.SerialNumber))
+}
+
+func (updater *OCSPUpdater) storeResponse(ctx context.Context, status *core.CertificateStatus) error {
 	// Update the certificateStatus table with the new OCSP response, the status
 	// WHERE is used make sure we don't overwrite a revoked response with a one
 	// containing a 'good' status and that we don't do the inverse when the OCSP
 	// status should be 'good'.
-	_, err := updater.dbMap.Exec(
+	result, err := updater.dbMap.Exec(
 		`UPDATE certificateStatus
 		 SET ocspResponse=?,ocspLastUpdated=?
 		 WHERE serial=?
@@ -281,7 +316,51 @@ func (updater *OCSPUpdater) storeResponse(status *core.CertificateStatus) error
 		status.Serial,
 		string(status.Status),
 	)
-	return err
+	if err != nil {
+		return err
+	}
+	if updater.responseStore == nil {
+		return nil
+	}
+	// The dbMap is configured with clientFoundRows, so no affected rows means
+	// the status guard above didn't match and the response must not be stored
+	// anywhere else either.
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return nil
+	}
+	updater.storeResponseElsewhere(ctx, status)
+	return nil
+}
+
+// storeResponseElsewhere writes an OCSP response that has already been stored
+// in the database to the updater's responseStore, with a TTL that expires it
+// at the response's nextUpdate. The database remains the source of truth, so
+// failures are logged and counted rather than returned.
+func (updater *OCSPUpdater) storeResponseElsewhere(ctx context.Context, status *core.CertificateStatus) {
+	resp, err := ocsp.ParseResponse(status.OCSPResponse, nil)
+	if err != nil {
+		updater.log.AuditErrf("Failed to parse OCSP response for serial %s: %s", status.Serial, err)
+		updater.stats.Inc("Errors.StoreResponseRedis", 1)
+		return
+	}
+	ttl := resp.NextUpdate.Sub(updater.clk.Now())
+	if ttl <= 0 {
+		updater.log.Warningf("Not storing OCSP response for serial %s in Redis: nextUpdate %s has passed",
+			status.Serial, resp.NextUpdate)
+		updater.stats.Inc("Errors.StoreResponseRedis", 1)
+		return
+	}
+	err = updater.responseStore.StoreResponse(ctx, status.Serial, status.OCSPResponse, ttl)
+	if err != nil {
+		updater.log.AuditErrf("Failed to store OCSP response for serial %s in Redis: %s", status.Serial, err)
+		updater.stats.Inc("Errors.StoreResponseRedis", 1)
+		return
+	}
+	updater.stats.Inc("StoredResponsesRedis", 1)
 }
 
 // markExpired updates a given CertificateStatus to have `isExpired` set.
@@ -342,7 +421,7 @@ func (updater *OCSPUpdater) revokedCertificatesTick(ctx context.Context, batchSi
 			return err
 		}
 		allPurgeURLs = append(allPurgeURLs, purgeURLs...)
-		err = updater.storeResponse(meta)
+		err = updater.storeResponse(ctx, meta)
 		if err != nil {
 			updater.stats.Inc("Errors.StoreRevokedResponse", 1)
 			updater.log.AuditErrf("Failed to store OCSP response: %s", err)
@@ -396,7 +475,7 @@ func (updater *OCSPUpdater) generateOCSPResponses(ctx context.Context, statuses
 			return
 		}
 		stats.Inc("GeneratedResponses", 1)
-		err = updater.storeResponse(meta)
+		err = updater.storeResponse(ctx, meta)
 		if err != nil {
 			updater.log.AuditErrf("Failed to store OCSP response: %s", err)
 			stats.Inc("Errors.StoreResponse", 1)
@@ -523,7 +602,7 @@ func setupClients(c cmd.OCSPUpdaterConfig, stats metrics.Scope, clk clock.Clock)
 	// Make a CA client that is only capable of signing OCSP.
 	// TODO(jsha): Once we've fully moved to gRPC, replace this
 	// with a plain caPB.NewOCSPGeneratorClient.
-	cac := bgrpc.NewCertificateAuthorityClient(nil, capb.NewOCSPGeneratorClient(caConn))
+	cac := bgrpc.NewCertificateAuthorityClient(nil, capb.NewOCSPGeneratorClient(caConn), nil)
 
 	conn, err := bgrpc.ClientSetup(c.SAService, tls, clientMetrics, clk)
 	cmd.FailOnError(err, "Failed to load credentials and create gRPC connection to SA")
@@ -571,6 +650,18 @@ func main() {
 	clk := cmd.Clock()
 	cac, sac, apc := setupClients(conf, scope, clk)
 
+	var responseStore ocspResponseStore
+	if conf.Redis != nil {
+		rc, err := rocsp.NewClientFromConfig(*conf.Redis)
+		cmd.FailOnError(err, "Failed to create Redis client")
+		responseStore = rc
+	}
+
+	issuerPaths := conf.IssuerCerts
+	if len(issuerPaths) == 0 {
+		issuerPaths = []string{c.Common.IssuerCert}
+	}
+
 	updater, err := newUpdater(
 		scope,
 		clk,
@@ -578,9 +669,10 @@ func main() {
 		cac,
 		sac,
 		apc,
+		responseStore,
 		// Necessary evil for now
 		conf,
-		c.Common.IssuerCert,
+		issuerPaths,
 		logger,
 	)
 	cmd.FailOnError(err, "Failed to create updater")
@@ -1,8 +1,16 @@
 package main
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"database/sql"
+	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math/big"
 	"testing"
 	"time"
 
@@ -18,6 +26,7 @@ import (
 	"github.com/letsencrypt/boulder/sa/satest"
 	"github.com/letsencrypt/boulder/test"
 	"github.com/letsencrypt/boulder/test/vars"
+	"golang.org/x/crypto/ocsp"
 	"golang.org/x/net/context"
 	"gopkg.in/go-gorp/gorp.v2"
 )
@@ -46,6 +55,10 @@ func (ca *mockCA) GenerateOCSP(_ context.Context, xferObj core.OCSPSigningReques
 	return
 }
 
+func (ca *mockCA) GenerateCRL(_ context.Context, _ core.CRLSigningRequest) ([]byte, error) {
+	return nil, nil
+}
+
 var log = blog.UseMock()
 
 const (
@@ -79,13 +92,14 @@ func setup(t *testing.T) (*OCSPUpdater, core.StorageAuthority, *gorp.DbMap, cloc
 		&mockCA{},
 		sa,
 		nil,
+		nil,
 		cmd.OCSPUpdaterConfig{
 			OldOCSPBatchSize:            1,
 			RevokedCertificateBatchSize: 1,
 			OldOCSPWindow:               cmd.ConfigDuration{Duration: time.Second},
 			RevokedCertificateWindow:    cmd.ConfigDuration{Duration: time.Second},
 		},
-		"",
+		nil,
 		blog.NewMock(),
 	)
 	test.AssertNotError(t, err, "Failed to create newUpdater")
@@ -99,7 +113,7 @@ func TestGenerateAndStoreOCSPResponse(t *testing.T) {
 	updater.ccu = &akamai.CachePurgeClient{}
 	issuer, err := core.LoadCert("../../test/test-ca2.pem")
 	test.AssertNotError(t, err, "Couldn't read test issuer certificate")
-	updater.issuer = issuer
+	updater.issuers = []*x509.Certificate{issuer}
 
 	reg := satest.CreateWorkingRegistration(t, sa)
 	parsedCert, err := core.LoadCert("test-cert.pem")
@@ -113,12 +127,12 @@ func TestGenerateAndStoreOCSPResponse(t *testing.T) {
 
 	meta, err := updater.generateResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate OCSP response")
-	err = updater.storeResponse(meta)
+	err = updater.storeResponse(ctx, meta)
 	test.AssertNotError(t, err, "Couldn't store certificate status")
 
 	secondMeta, purgeURLs, err := updater.generateRevokedResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate revoked OCSP response")
-	err = updater.storeResponse(secondMeta)
+	err = updater.storeResponse(ctx, secondMeta)
 	test.AssertNotError(t, err, "Couldn't store certificate status")
 	test.AssertDeepEquals(t, purgeURLs, []string{
 		// akamai magic POST format
@@ -134,6 +148,24 @@ func TestGenerateAndStoreOCSPResponse(t *testing.T) {
 	test.AssertByteEquals(t, meta.OCSPResponse, newStatus.OCSPResponse)
 }
 
+func TestIssuerFor(t *testing.T) {
+	ca, err := core.LoadCert("../../test/test-ca.pem")
+	test.AssertNotError(t, err, "Couldn't read test issuer certificate")
+	ca2, err := core.LoadCert("../../test/test-ca2.pem")
+	test.AssertNotError(t, err, "Couldn't read test issuer certificate")
+	cert, err := core.LoadCert("test-cert.pem")
+	test.AssertNotError(t, err, "Couldn't read test certificate")
+
+	updater := &OCSPUpdater{issuers: []*x509.Certificate{ca, ca2}}
+	issuer, err := updater.issuerFor(cert.Raw)
+	test.AssertNotError(t, err, "Couldn't find issuer of test-cert.pem")
+	test.AssertEquals(t, issuer, ca2)
+
+	updater.issuers = []*x509.Certificate{ca}
+	_, err = updater.issuerFor(cert.Raw)
+	test.AssertError(t, err, "Found an issuer for test-cert.pem that isn't configured")
+}
+
 func TestGenerateOCSPResponses(t *testing.T) {
 	updater, sa, dbMap, fc, cleanUp := setup(t)
 	defer cleanUp()
@@ -214,7 +246,7 @@ func TestFindStaleOCSPResponses(t *testing.T) {
 
 	meta, err := updater.generateResponse(ctx, status)
 	test.AssertNotError(t, err, "Couldn't generate OCSP response")
-	err = updater.storeResponse(meta)
+	err = updater.storeResponse(ctx, meta)
 	test.AssertNotError(t, err, "Couldn't store OCSP response")
 
 	certs, err = updater.findStaleOCSPResponses(earliest, 10)
@@ -403,7 +435,7 @@ func TestStoreResponseGuard(t *testing.T) {
 	// Attempt to update OCSP response where status.Status is good but stored status
 	// is revoked, this should fail silently
 	status.OCSPResponse = []byte{0, 1, 1}
-	err = updater.storeResponse(&status)
+	err = updater.storeResponse(ctx, &status)
 	test.AssertNotError(t, err, "Failed to update certificate status")
 
 	// Make sure the OCSP response hasn't actually changed
@@ -413,7 +445,7 @@ func TestStoreResponseGuard(t *testing.T) {
 
 	// Changing the status to the stored status should allow the update to occur
 	status.Status = core.OCSPStatusRevoked
-	err = updater.storeResponse(&status)
+	err = updater.storeResponse(ctx, &status)
 	test.AssertNotError(t, err, "Failed to updated certificate status")
 
 	// Make sure the OCSP response has been updated
@@ -464,3 +496,105 @@ func TestLoopTickBackoff(t *testing.T) {
 	test.AssertEquals(t, l.failures, 0)
 	test.AssertEquals(t, l.clk.Now(), start)
 }
+
+// updateDB is an ocspDB whose updates affect a fixed number of rows.
+type updateDB struct {
+	ocspDB
+	rowsAffected int64
+}
+
+func (db updateDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return driver.RowsAffected(db.rowsAffected), nil
+}
+
+// recordingStore remembers the OCSP responses, and their TTLs, that it is asked
+// to store.
+type recordingStore struct {
+	responses map[string][]byte
+	ttls      map[string]time.Duration
+	fail      bool
+}
+
+func (s *recordingStore) StoreResponse(_ context.Context, serial string, resp []byte, ttl time.Duration) error {
+	if s.fail {
+		return errors.New("nope")
+	}
+	s.responses[serial] = resp
+	s.ttls[serial] = ttl
+	return nil
+}
+
+func TestStoreResponseElsewhere(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	issuerDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	test.AssertNotError(t, err, "creating issuer")
+	issuer, err := x509.ParseCertificate(issuerDER)
+	test.AssertNotError(t, err, "parsing issuer")
+
+	fc := clock.NewFake()
+	fc.Set(time.Date(2019, 5, 1, 0, 0, 0, 0, time.UTC))
+	ocspResp, err := ocsp.CreateResponse(issuer, issuer, ocsp.Response{
+		Status:       ocsp.Good,
+		SerialNumber: big.NewInt(1337),
+		ThisUpdate:   fc.Now(),
+		NextUpdate:   fc.Now().Add(72 * time.Hour),
+	}, key)
+	test.AssertNotError(t, err, "creating OCSP response")
+
+	store := &recordingStore{responses: make(map[string][]byte), ttls: make(map[string]time.Duration)}
+	updater := &OCSPUpdater{
+		stats:         metrics.NewNoopScope(),
+		log:           blog.NewMock(),
+		clk:           fc,
+		dbMap:         updateDB{rowsAffected: 1},
+		responseStore: store,
+	}
+
+	status := &core.CertificateStatus{Serial: "good", Status: core.OCSPStatusGood, OCSPResponse: ocspResp}
+	err = updater.storeResponse(ctx, status)
+	test.AssertNotError(t, err, "storeResponse failed")
+	test.AssertByteEquals(t, store.responses["good"], ocspResp)
+	test.AssertEquals(t, store.ttls["good"], 72*time.Hour)
+
+	// A response that the database's status guard rejected mustn't be stored.
+	updater.dbMap = updateDB{rowsAffected: 0}
+	status.Serial = "guarded"
+	err = updater.storeResponse(ctx, status)
+	test.AssertNotError(t, err, "storeResponse failed")
+	_, present := store.responses["guarded"]
+	test.Assert(t, !present, "response rejected by the database was stored in Redis")
+
+	// Neither is one whose nextUpdate has passed, nor one that can't be parsed,
+	// and neither that nor a failure to store is an error as far as the caller
+	// is concerned.
+	updater.dbMap = updateDB{rowsAffected: 1}
+	fc.Add(73 * time.Hour)
+	status.Serial = "stale"
+	err = updater.storeResponse(ctx, status)
+	test.AssertNotError(t, err, "storeResponse failed")
+	_, present = store.responses["stale"]
+	test.Assert(t, !present, "stale response was stored in Redis")
+
+	status.Serial = "garbage"
+	status.OCSPResponse = []byte{1, 2, 3}
+	err = updater.storeResponse(ctx, status)
+	test.AssertNotError(t, err, "storeResponse failed")
+	_, present = store.responses["garbage"]
+	test.Assert(t, !present, "unparseable response was stored in Redis")
+
+	fc.Add(-73 * time.Hour)
+	store.fail = true
+	status.Serial = "failed"
+	status.OCSPResponse = ocspResp
+	err = updater.storeResponse(ctx, status)
+	test.AssertNotError(t, err, "storeResponse failed when Redis did")
+}
@@ -28,3 +28,10 @@ func DNSChallenge01(token string) Challenge {
 func TLSALPNChallenge01(token string) Challenge {
 	return newChallenge(ChallengeTypeTLSALPN01, token)
 }
+
+// DNSAccountChallenge01 constructs a random dns-account-01 challenge. If token
+// is empty a random token will be generated, otherwise the provided token is
+// used.
+func DNSAccountChallenge01(token string) Challenge {
+	return newChallenge(ChallengeTypeDNSAccount01, token)
+}
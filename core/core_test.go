@@ -33,9 +33,13 @@ func TestChallenges(t *testing.T) {
 	tlsalpn01 := TLSALPNChallenge01("")
 	test.AssertNotError(t, tlsalpn01.CheckConsistencyForClientOffer(), "CheckConsistencyForClientOffer returned an error")
 
+	dnsAccount01 := DNSAccountChallenge01("")
+	test.AssertNotError(t, dnsAccount01.CheckConsistencyForClientOffer(), "CheckConsistencyForClientOffer returned an error")
+
 	test.Assert(t, ValidChallenge(ChallengeTypeHTTP01), "Refused valid challenge")
 	test.Assert(t, ValidChallenge(ChallengeTypeDNS01), "Refused valid challenge")
 	test.Assert(t, ValidChallenge(ChallengeTypeTLSALPN01), "Refused valid challenge")
+	test.Assert(t, ValidChallenge(ChallengeTypeDNSAccount01), "Refused valid challenge")
 	test.Assert(t, !ValidChallenge("nonsense-71"), "Accepted invalid challenge")
 }
 
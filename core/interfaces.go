@@ -86,8 +86,14 @@ type RegistrationAuthority interface {
 	// [WebFrontEnd]
 	FinalizeOrder(ctx context.Context, req *rapb.FinalizeOrderRequest) (*corepb.Order, error)
 
+	// [WebFrontEnd]
+	RevokeCertByKey(ctx context.Context, req *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error)
+
 	// [AdminRevoker]
 	AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason, adminName string) error
+
+	// [AccountAdmin]
+	GetRateLimitUsage(ctx context.Context, req *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error)
 }
 
 // CertificateAuthority defines the public interface for the Boulder CA
@@ -102,6 +108,9 @@ type CertificateAuthority interface {
 	IssueCertificateForPrecertificate(ctx context.Context, req *caPB.IssueCertificateForPrecertificateRequest) (Certificate, error)
 
 	GenerateOCSP(ctx context.Context, ocspReq OCSPSigningRequest) ([]byte, error)
+
+	// [CRLUpdater]
+	GenerateCRL(ctx context.Context, crlReq CRLSigningRequest) ([]byte, error)
 }
 
 // PolicyAuthority defines the public interface for the Boulder PA
@@ -136,6 +145,11 @@ type StorageGetter interface {
 	CountInvalidAuthorizations(ctx context.Context, req *sapb.CountInvalidAuthorizationsRequest) (count *sapb.Count, err error)
 	GetAuthorizations(ctx context.Context, req *sapb.GetAuthorizationsRequest) (*sapb.Authorizations, error)
 	GetAuthz2(ctx context.Context, req *sapb.AuthorizationID2) (*corepb.Authorization, error)
+	GetExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) (*sapb.ExternalAccountKey, error)
+	KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error)
+	GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error)
+	GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error)
+	GetCertificatesForAccount(ctx context.Context, req *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error)
 }
 
 // StorageAdder are the Boulder SA's write/update methods
@@ -155,6 +169,10 @@ type StorageAdder interface {
 	AddPendingAuthorizations(ctx context.Context, req *sapb.AddPendingAuthorizationsRequest) (*sapb.AuthorizationIDs, error)
 	SetOrderError(ctx context.Context, order *corepb.Order) error
 	RevokeCertificate(ctx context.Context, req *sapb.RevokeCertificateRequest) error
+	AddExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKey) error
+	RetireExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) error
+	AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) error
+	AddKeyHash(ctx context.Context, req *sapb.AddKeyHashRequest) error
 }
 
 // StorageAuthority interface represents a simple key/value
@@ -2,7 +2,9 @@ package core
 
 import (
 	"crypto"
+	"crypto/sha256"
 	"crypto/x509"
+	"encoding/base32"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
@@ -47,6 +49,7 @@ const (
 // These types are the available identification mechanisms
 const (
 	IdentifierDNS = IdentifierType("dns")
+	IdentifierIP  = IdentifierType("ip") // RFC 8738
 )
 
 // The types of ACME resources
@@ -69,9 +72,10 @@ const (
 
 // These types are the available challenges
 const (
-	ChallengeTypeHTTP01    = "http-01"
-	ChallengeTypeDNS01     = "dns-01"
-	ChallengeTypeTLSALPN01 = "tls-alpn-01"
+	ChallengeTypeHTTP01       = "http-01"
+	ChallengeTypeDNS01        = "dns-01"
+	ChallengeTypeTLSALPN01    = "tls-alpn-01"
+	ChallengeTypeDNSAccount01 = "dns-account-01"
 )
 
 // ValidChallenge tests whether the provided string names a known challenge
@@ -79,7 +83,8 @@ func ValidChallenge(name string) bool {
 	switch name {
 	case ChallengeTypeHTTP01,
 		ChallengeTypeDNS01,
-		ChallengeTypeTLSALPN01:
+		ChallengeTypeTLSALPN01,
+		ChallengeTypeDNSAccount01:
 		return true
 	default:
 		return false
@@ -89,16 +94,38 @@ func ValidChallenge(name string) bool {
 // DNSPrefix is attached to DNS names in DNS challenges
 const DNSPrefix = "_acme-challenge"
 
+// DNSAccountLabel returns the account-scoped label that is attached before
+// DNSPrefix in dns-account-01 challenges for the account with the given URL.
+// It is an underscore followed by the lowercase base32 encoding of the first
+// 10 bytes of the SHA-256 digest of the account URL, so that several accounts
+// can validate the same name at once without their TXT records colliding.
+func DNSAccountLabel(accountURL string) string {
+	digest := sha256.Sum256([]byte(accountURL))
+	return "_" + strings.ToLower(base32.StdEncoding.EncodeToString(digest[:10]))
+}
+
 // An AcmeIdentifier encodes an identifier that can
 // be validated by ACME.  The protocol allows for different
 // types of identifier to be supported (DNS names, IP
-// addresses, etc.), but currently we only support
-// domain names.
+// addresses, etc.), and we support domain names and IP
+// addresses.
 type AcmeIdentifier struct {
 	Type  IdentifierType `json:"type"`  // The type of identifier being encoded
 	Value string         `json:"value"` // The identifier itself
 }
 
+// IdentifierForName returns the identifier for a name as it is stored in an
+// order or a certificate: an IP identifier if the name is the textual form of
+// an IP address, and a DNS identifier otherwise. The policy authority never
+// accepts a DNS identifier that looks like an IP address, so the two can't be
+// confused.
+func IdentifierForName(name string) AcmeIdentifier {
+	if net.ParseIP(name) != nil {
+		return AcmeIdentifier{Type: IdentifierIP, Value: name}
+	}
+	return AcmeIdentifier{Type: IdentifierDNS, Value: name}
+}
+
 // CertificateRequest is just a CSR
 //
 // This data is unmarshalled from JSON by way of RawCertificateRequest, which
@@ -158,6 +185,12 @@ type Registration struct {
 	CreatedAt time.Time `json:"createdAt"`
 
 	Status AcmeStatus `json:"status"`
+
+	// ExternalAccountKeyID is the ID of the pre-provisioned external account
+	// key that a new registration is to be bound to (RFC 8555 Section 7.3.4).
+	// It is only used when creating a registration and is never returned to
+	// clients.
+	ExternalAccountKeyID string `json:"-"`
 }
 
 // ValidationRecord represents a validation attempt against a specific URL/hostname
@@ -290,7 +323,7 @@ func (ch Challenge) RecordsSane() bool {
 			ch.ValidationRecord[0].AddressUsed == nil || len(ch.ValidationRecord[0].AddressesResolved) == 0 {
 			return false
 		}
-	case ChallengeTypeDNS01:
+	case ChallengeTypeDNS01, ChallengeTypeDNSAccount01:
 		if len(ch.ValidationRecord) > 1 {
 			return false
 		}
@@ -587,6 +620,27 @@ type OCSPSigningRequest struct {
 	RevokedAt time.Time
 }
 
+// CRLEntry is a transfer object representing a single revoked certificate
+// within a CRL Signing Request
+type CRLEntry struct {
+	Serial    string
+	Reason    revocation.Reason
+	RevokedAt time.Time
+}
+
+// CRLSigningRequest is a transfer object representing a request to sign one
+// shard of the CRL for a single issuer. The issuer is identified by its
+// CommonName, in the same way the CA identifies issuers for OCSP.
+type CRLSigningRequest struct {
+	IssuerCommonName string
+	Shard            int64
+	// DistributionPoint, if not empty, is the URL this shard will be
+	// published at. It is included in the CRL's Issuing Distribution Point
+	// extension.
+	DistributionPoint string
+	Entries           []CRLEntry
+}
+
 // SignedCertificateTimestamp is the internal representation of ct.SignedCertificateTimestamp
 // that is used to maintain backwards compatibility with our old CT implementation.
 type SignedCertificateTimestamp struct {
@@ -637,3 +691,46 @@ type SCTDERs [][]byte
 // CertDER is a convenience type that helps differentiate what the
 // underlying byte slice contains
 type CertDER []byte
+
+// SuggestedWindow is a type exposed inside the RenewalInfo resource.
+type SuggestedWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// RenewalInfo is a type which is exposed to clients which query the renewalInfo
+// endpoint specified in draft-ietf-acme-ari.
+type RenewalInfo struct {
+	SuggestedWindow SuggestedWindow `json:"suggestedWindow"`
+}
+
+// RenewalInfoSimple constructs a `RenewalInfo` object and suggested window
+// using a very simple renewal calculation: calculate a point 2/3rds of the way
+// through the validity period, then give a 2-day window around that. Both the
+// `issued` and `expires` timestamps are expected to be UTC.
+func RenewalInfoSimple(issued time.Time, expires time.Time) RenewalInfo {
+	validity := expires.Add(time.Second).Sub(issued)
+	renewalOffset := validity / time.Duration(3)
+	idealRenewal := expires.Add(-renewalOffset)
+	return RenewalInfo{
+		SuggestedWindow: SuggestedWindow{
+			Start: idealRenewal.Add(-24 * time.Hour),
+			End:   idealRenewal.Add(24 * time.Hour),
+		},
+	}
+}
+
+// RenewalInfoImmediate constructs a `RenewalInfo` object with a suggested
+// window in the past. Per draft-ietf-acme-ari, clients should
+// attempt to renew immediately if the suggested window is in the past. The
+// passed `now` is assumed to be a timestamp representing the current moment in
+// time.
+func RenewalInfoImmediate(now time.Time) RenewalInfo {
+	oneHourAgo := now.Add(-1 * time.Hour)
+	return RenewalInfo{
+		SuggestedWindow: SuggestedWindow{
+			Start: oneHourAgo,
+			End:   oneHourAgo.Add(time.Minute * 30),
+		},
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"math/big"
 	"net"
 	"testing"
+	"time"
 
 	"gopkg.in/square/go-jose.v2"
 
@@ -47,6 +48,11 @@ func TestRecordSanityCheckOnUnsupportChallengeType(t *testing.T) {
 	test.Assert(t, !chall.RecordsSane(), "Record with unsupported challenge type should not be sane")
 }
 
+func TestDNSAccountLabel(t *testing.T) {
+	// The example from draft-ietf-acme-scoped-dns-challenges
+	test.AssertEquals(t, DNSAccountLabel("https://example.com/acme/acct/ExampleAccount"), "_ujmmovf2vn55tgye")
+}
+
 func TestChallengeSanityCheck(t *testing.T) {
 	// Make a temporary account key
 	var accountKey *jose.JSONWebKey
@@ -57,7 +63,7 @@ func TestChallengeSanityCheck(t *testing.T) {
   }`), &accountKey)
 	test.AssertNotError(t, err, "Error unmarshaling JWK")
 
-	types := []string{ChallengeTypeHTTP01, ChallengeTypeDNS01, ChallengeTypeTLSALPN01}
+	types := []string{ChallengeTypeHTTP01, ChallengeTypeDNS01, ChallengeTypeTLSALPN01, ChallengeTypeDNSAccount01}
 	for _, challengeType := range types {
 		chall := Challenge{
 			Type:   challengeType,
@@ -166,3 +172,27 @@ func TestFindChallengeByType(t *testing.T) {
 	test.AssertEquals(t, 1, authz.FindChallengeByStringID(authz.Challenges[1].StringID()))
 	test.AssertEquals(t, -1, authz.FindChallengeByStringID("hello"))
 }
+
+func TestRenewalInfoSimple(t *testing.T) {
+	issued := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	expires := issued.Add(90*24*time.Hour - time.Second)
+	ri := RenewalInfoSimple(issued, expires)
+	// Two thirds of the way through a 90 day certificate is just before the
+	// start of day 60, and the window is a day either side of that.
+	test.AssertEquals(t, ri.SuggestedWindow.Start, issued.Add(59*24*time.Hour-time.Second))
+	test.AssertEquals(t, ri.SuggestedWindow.End, issued.Add(61*24*time.Hour-time.Second))
+}
+
+func TestRenewalInfoImmediate(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ri := RenewalInfoImmediate(now)
+	test.Assert(t, ri.SuggestedWindow.End.Before(now), "window should end in the past")
+	test.Assert(t, ri.SuggestedWindow.Start.Before(ri.SuggestedWindow.End), "window should start before it ends")
+}
+
+func TestIdentifierForName(t *testing.T) {
+	test.AssertEquals(t, IdentifierForName("example.com"), AcmeIdentifier{Type: IdentifierDNS, Value: "example.com"})
+	test.AssertEquals(t, IdentifierForName("*.example.com"), AcmeIdentifier{Type: IdentifierDNS, Value: "*.example.com"})
+	test.AssertEquals(t, IdentifierForName("10.0.0.1"), AcmeIdentifier{Type: IdentifierIP, Value: "10.0.0.1"})
+	test.AssertEquals(t, IdentifierForName("2001:db8::1"), AcmeIdentifier{Type: IdentifierIP, Value: "2001:db8::1"})
+}
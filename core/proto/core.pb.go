@@ -340,14 +340,17 @@ func (m *Certificate) GetExpires() int64 {
 }
 
 type Registration struct {
-	Id                   *int64   `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	Key                  []byte   `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
-	Contact              []string `protobuf:"bytes,3,rep,name=contact" json:"contact,omitempty"`
-	ContactsPresent      *bool    `protobuf:"varint,4,opt,name=contactsPresent" json:"contactsPresent,omitempty"`
-	Agreement            *string  `protobuf:"bytes,5,opt,name=agreement" json:"agreement,omitempty"`
-	InitialIP            []byte   `protobuf:"bytes,6,opt,name=initialIP" json:"initialIP,omitempty"`
-	CreatedAt            *int64   `protobuf:"varint,7,opt,name=createdAt" json:"createdAt,omitempty"`
-	Status               *string  `protobuf:"bytes,8,opt,name=status" json:"status,omitempty"`
+	Id              *int64   `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Key             []byte   `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	Contact         []string `protobuf:"bytes,3,rep,name=contact" json:"contact,omitempty"`
+	ContactsPresent *bool    `protobuf:"varint,4,opt,name=contactsPresent" json:"contactsPresent,omitempty"`
+	Agreement       *string  `protobuf:"bytes,5,opt,name=agreement" json:"agreement,omitempty"`
+	InitialIP       []byte   `protobuf:"bytes,6,opt,name=initialIP" json:"initialIP,omitempty"`
+	CreatedAt       *int64   `protobuf:"varint,7,opt,name=createdAt" json:"createdAt,omitempty"`
+	Status          *string  `protobuf:"bytes,8,opt,name=status" json:"status,omitempty"`
+	// The ID of an external account key to bind the registration to. Only
+	// used when creating a registration.
+	ExternalAccountKeyID *string  `protobuf:"bytes,9,opt,name=externalAccountKeyID" json:"externalAccountKeyID,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -434,6 +437,13 @@ func (m *Registration) GetStatus() string {
 	return ""
 }
 
+func (m *Registration) GetExternalAccountKeyID() string {
+	if m != nil && m.ExternalAccountKeyID != nil {
+		return *m.ExternalAccountKeyID
+	}
+	return ""
+}
+
 type Authorization struct {
 	Id                   *string      `protobuf:"bytes,1,opt,name=id" json:"id,omitempty"`
 	Identifier           *string      `protobuf:"bytes,2,opt,name=identifier" json:"identifier,omitempty"`
@@ -530,19 +540,20 @@ func (m *Authorization) GetV2() bool {
 }
 
 type Order struct {
-	Id                   *int64          `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
-	RegistrationID       *int64          `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
-	Expires              *int64          `protobuf:"varint,3,opt,name=expires" json:"expires,omitempty"`
-	Error                *ProblemDetails `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
-	CertificateSerial    *string         `protobuf:"bytes,5,opt,name=certificateSerial" json:"certificateSerial,omitempty"`
-	Authorizations       []string        `protobuf:"bytes,6,rep,name=authorizations" json:"authorizations,omitempty"`
-	Status               *string         `protobuf:"bytes,7,opt,name=status" json:"status,omitempty"`
-	Names                []string        `protobuf:"bytes,8,rep,name=names" json:"names,omitempty"`
-	BeganProcessing      *bool           `protobuf:"varint,9,opt,name=beganProcessing" json:"beganProcessing,omitempty"`
-	Created              *int64          `protobuf:"varint,10,opt,name=created" json:"created,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
-	XXX_unrecognized     []byte          `json:"-"`
-	XXX_sizecache        int32           `json:"-"`
+	Id                     *int64          `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	RegistrationID         *int64          `protobuf:"varint,2,opt,name=registrationID" json:"registrationID,omitempty"`
+	Expires                *int64          `protobuf:"varint,3,opt,name=expires" json:"expires,omitempty"`
+	Error                  *ProblemDetails `protobuf:"bytes,4,opt,name=error" json:"error,omitempty"`
+	CertificateSerial      *string         `protobuf:"bytes,5,opt,name=certificateSerial" json:"certificateSerial,omitempty"`
+	Authorizations         []string        `protobuf:"bytes,6,rep,name=authorizations" json:"authorizations,omitempty"`
+	Status                 *string         `protobuf:"bytes,7,opt,name=status" json:"status,omitempty"`
+	Names                  []string        `protobuf:"bytes,8,rep,name=names" json:"names,omitempty"`
+	BeganProcessing        *bool           `protobuf:"varint,9,opt,name=beganProcessing" json:"beganProcessing,omitempty"`
+	Created                *int64          `protobuf:"varint,10,opt,name=created" json:"created,omitempty"`
+	CertificateProfileName *string         `protobuf:"bytes,11,opt,name=certificateProfileName" json:"certificateProfileName,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{}        `json:"-"`
+	XXX_unrecognized       []byte          `json:"-"`
+	XXX_sizecache          int32           `json:"-"`
 }
 
 func (m *Order) Reset()         { *m = Order{} }
@@ -640,6 +651,13 @@ func (m *Order) GetCreated() int64 {
 	return 0
 }
 
+func (m *Order) GetCertificateProfileName() string {
+	if m != nil && m.CertificateProfileName != nil {
+		return *m.CertificateProfileName
+	}
+	return ""
+}
+
 type Empty struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
@@ -685,51 +703,54 @@ func init() {
 func init() { proto.RegisterFile("core/proto/core.proto", fileDescriptor_80ea9561f1d738ba) }
 
 var fileDescriptor_80ea9561f1d738ba = []byte{
-	// 736 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0x41, 0x6e, 0xdb, 0x3a,
-	0x10, 0x85, 0x2c, 0x2b, 0xb6, 0xc6, 0xfe, 0x89, 0x43, 0xe4, 0x07, 0xc2, 0xc7, 0x47, 0x20, 0x68,
-	0xf1, 0x21, 0x04, 0x1f, 0x09, 0xe0, 0x1b, 0xa4, 0x49, 0x17, 0x59, 0xd5, 0x60, 0xd2, 0x2e, 0xba,
-	0x53, 0xa4, 0xa9, 0xcd, 0x46, 0x16, 0x0d, 0x92, 0x36, 0xea, 0xde, 0xa1, 0x07, 0xe9, 0x61, 0x7a,
-	0x8d, 0x2e, 0x7b, 0x84, 0xa2, 0xe0, 0x50, 0xb6, 0x65, 0x39, 0x45, 0x77, 0x33, 0x8f, 0x94, 0x39,
-	0xf3, 0xde, 0x9b, 0x31, 0xfc, 0x9d, 0x4b, 0x85, 0xd7, 0x0b, 0x25, 0x8d, 0xbc, 0xb6, 0xe1, 0x15,
-	0x85, 0xac, 0x6b, 0xe3, 0xe4, 0x4b, 0x07, 0xc2, 0xdb, 0x59, 0x56, 0x96, 0x58, 0x4d, 0x91, 0x1d,
-	0x43, 0x47, 0x14, 0x91, 0x17, 0x7b, 0xa9, 0xcf, 0x3b, 0xa2, 0x60, 0x0c, 0xba, 0x66, 0xbd, 0xc0,
-	0xa8, 0x13, 0x7b, 0x69, 0xc8, 0x29, 0x66, 0xe7, 0x70, 0xa4, 0x4d, 0x66, 0x96, 0x3a, 0x3a, 0x22,
-	0xb4, 0xce, 0xd8, 0x08, 0xfc, 0xa5, 0x12, 0x51, 0x48, 0xa0, 0x0d, 0xd9, 0x19, 0x04, 0x46, 0x3e,
-	0x63, 0x15, 0xf9, 0x84, 0xb9, 0x84, 0x5d, 0xc2, 0xe8, 0x19, 0xd7, 0x37, 0x4b, 0x33, 0x93, 0x4a,
-	0x7c, 0xce, 0x8c, 0x90, 0x55, 0x14, 0xd0, 0x85, 0x03, 0x9c, 0xdd, 0xc1, 0xe9, 0x2a, 0x2b, 0x45,
-	0x41, 0x99, 0xc2, 0x5c, 0xaa, 0x42, 0x47, 0x10, 0xfb, 0xe9, 0x60, 0x7c, 0x7e, 0x45, 0xbd, 0xbc,
-	0xdb, 0x1e, 0x73, 0x3a, 0xe6, 0x87, 0x1f, 0xb0, 0x4b, 0x08, 0x50, 0x29, 0xa9, 0xa2, 0x5e, 0xec,
-	0xa5, 0x83, 0xf1, 0x99, 0xfb, 0x72, 0xa2, 0xe4, 0x53, 0x89, 0xf3, 0x3b, 0x34, 0x99, 0x28, 0x35,
-	0x77, 0x57, 0x92, 0x1f, 0x1e, 0x8c, 0xda, 0xbf, 0xc9, 0xfe, 0x81, 0xfe, 0x4c, 0x6a, 0x53, 0x65,
-	0x73, 0x24, 0x72, 0x42, 0xbe, 0xcd, 0x2d, 0x45, 0x0b, 0xa9, 0xcc, 0x86, 0x22, 0x1b, 0xb3, 0xff,
-	0xe1, 0x34, 0x2b, 0x0a, 0x85, 0x5a, 0xa3, 0xe6, 0xa8, 0x65, 0xb9, 0xc2, 0x22, 0xf2, 0x63, 0x3f,
-	0x1d, 0xf2, 0xc3, 0x03, 0x16, 0xc3, 0xa0, 0x06, 0xdf, 0x6a, 0x2c, 0xa2, 0x6e, 0xec, 0xa5, 0x43,
-	0xde, 0x84, 0xe8, 0x86, 0xe3, 0xc5, 0x08, 0xd4, 0x51, 0x10, 0xfb, 0x69, 0xc8, 0x9b, 0x90, 0x23,
-	0xbf, 0xac, 0x15, 0xb1, 0x21, 0xfb, 0x0f, 0x8e, 0xb7, 0x4f, 0x3d, 0x2a, 0x81, 0x45, 0xd4, 0xa3,
-	0x02, 0x5a, 0x68, 0xf2, 0x11, 0x8e, 0xf7, 0x99, 0xb0, 0xaf, 0x2d, 0x1c, 0xf2, 0x68, 0xb5, 0x77,
-	0x0d, 0x37, 0x21, 0x6b, 0x81, 0x82, 0x2e, 0xd7, 0x5d, 0xd7, 0x19, 0xbb, 0x00, 0x98, 0x19, 0xb3,
-	0x78, 0x70, 0xf6, 0xb0, 0xaa, 0x07, 0xbc, 0x81, 0x24, 0x5f, 0x3d, 0x18, 0xdc, 0xa2, 0x32, 0xe2,
-	0x83, 0xc8, 0x33, 0x83, 0xb6, 0x46, 0x85, 0x53, 0xa1, 0x8d, 0x22, 0xb6, 0xef, 0xef, 0x6a, 0xeb,
-	0xb5, 0x50, 0xb2, 0x1c, 0x2a, 0x91, 0x6d, 0xdf, 0x73, 0x19, 0xd5, 0x21, 0xa6, 0xa8, 0x4d, 0xed,
-	0xb0, 0x3a, 0xb3, 0x6c, 0x14, 0xa8, 0x6a, 0x26, 0x6d, 0x68, 0x6f, 0x0a, 0xad, 0x97, 0x58, 0x90,
-	0xd5, 0x7c, 0x5e, 0x67, 0x2c, 0x82, 0x1e, 0x7e, 0x5a, 0x08, 0x85, 0xce, 0xcd, 0x3e, 0xdf, 0xa4,
-	0xc9, 0x77, 0x0f, 0x86, 0xbc, 0x51, 0xc6, 0xc1, 0x6c, 0x8c, 0xc0, 0x7f, 0xc6, 0x35, 0x55, 0x34,
-	0xe4, 0x36, 0xb4, 0x3f, 0x96, 0xcb, 0xca, 0x64, 0xb9, 0x21, 0xb1, 0x43, 0xbe, 0x49, 0x59, 0x0a,
-	0x27, 0x75, 0xa8, 0x27, 0x0a, 0x35, 0x56, 0x86, 0x8a, 0xeb, 0xf3, 0x36, 0xcc, 0xfe, 0x85, 0x30,
-	0x9b, 0x2a, 0xc4, 0xb9, 0xbd, 0xe3, 0xc6, 0x62, 0x07, 0xd8, 0x53, 0x51, 0x09, 0x23, 0xb2, 0xf2,
-	0x7e, 0x42, 0x05, 0x0f, 0xf9, 0x0e, 0xb0, 0xa7, 0xb9, 0xc2, 0xcc, 0x60, 0x71, 0x63, 0xc8, 0xeb,
-	0x3e, 0xdf, 0x01, 0x8d, 0xb9, 0xed, 0x37, 0xe7, 0x36, 0xf9, 0xe9, 0xc1, 0x5f, 0xfb, 0x53, 0xb7,
-	0xeb, 0x34, 0xa4, 0x4e, 0x2f, 0x00, 0x44, 0x81, 0x95, 0x95, 0x0d, 0x55, 0x2d, 0x41, 0x03, 0x79,
-	0x41, 0x46, 0xff, 0xb7, 0x32, 0xba, 0x0a, 0xba, 0x7b, 0x9b, 0xa3, 0x21, 0x42, 0xb0, 0x27, 0x02,
-	0xbb, 0x06, 0xc8, 0x37, 0xcb, 0xc9, 0x2a, 0x64, 0x07, 0xff, 0xc4, 0x8d, 0xef, 0x76, 0x69, 0xf1,
-	0xc6, 0x15, 0x96, 0xc0, 0x30, 0x97, 0xf3, 0x27, 0x51, 0xd1, 0x9b, 0x9a, 0x58, 0x18, 0xf2, 0x3d,
-	0xcc, 0xb6, 0xb7, 0x1a, 0x13, 0x09, 0x7d, 0xde, 0x59, 0x8d, 0x93, 0x6f, 0x1d, 0x08, 0xde, 0x28,
-	0xeb, 0x92, 0xb6, 0xc4, 0x87, 0x8d, 0x75, 0x5e, 0x6c, 0xac, 0xd1, 0x80, 0xbf, 0xdf, 0xc0, 0x76,
-	0xf5, 0x74, 0xff, 0xb8, 0x7a, 0xec, 0xd6, 0xc8, 0x77, 0xc3, 0xf1, 0xe0, 0x0c, 0xef, 0x2c, 0x70,
-	0x78, 0x40, 0xf3, 0xdd, 0x54, 0xcd, 0xd1, 0x13, 0xf2, 0x16, 0xda, 0x20, 0xbd, 0xb7, 0x47, 0xfa,
-	0x19, 0x04, 0x76, 0x7f, 0x59, 0x37, 0xd8, 0xcf, 0x5c, 0x62, 0x8d, 0xfa, 0x84, 0xd3, 0xac, 0x9a,
-	0x28, 0x99, 0xa3, 0xd6, 0xa2, 0x9a, 0xd2, 0x42, 0xef, 0xf3, 0x36, 0x4c, 0x66, 0x77, 0xde, 0x8a,
-	0xc0, 0xf5, 0x5c, 0xa7, 0x49, 0x0f, 0x82, 0xd7, 0xf3, 0x85, 0x59, 0xbf, 0xea, 0xbd, 0x0f, 0xe8,
-	0xaf, 0xe6, 0x57, 0x00, 0x00, 0x00, 0xff, 0xff, 0xbe, 0x8c, 0x5a, 0x8e, 0x82, 0x06, 0x00, 0x00,
+	// 779 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x55, 0xc1, 0x6e, 0xda, 0x4c,
+	0x10, 0x96, 0x31, 0x0e, 0x78, 0xe0, 0x4f, 0xc8, 0x8a, 0x3f, 0xb2, 0xaa, 0x2a, 0xb2, 0x7c, 0xa8,
+	0xac, 0xa8, 0x4a, 0x24, 0x0e, 0xbd, 0xa7, 0xa1, 0x87, 0xa8, 0x52, 0x8b, 0x36, 0x69, 0x0f, 0xbd,
+	0x39, 0xf6, 0x04, 0xb6, 0x31, 0x5e, 0x6b, 0x77, 0x41, 0xa1, 0xf7, 0x1e, 0xfb, 0x02, 0x7d, 0x83,
+	0xbe, 0x5c, 0x1f, 0xa1, 0xaa, 0x76, 0xd7, 0x80, 0x0d, 0x89, 0x7a, 0x9b, 0xf9, 0x66, 0xcc, 0xce,
+	0x7c, 0xf3, 0xcd, 0x00, 0xff, 0xa7, 0x5c, 0xe0, 0x45, 0x29, 0xb8, 0xe2, 0x17, 0xda, 0x3c, 0x37,
+	0x26, 0x69, 0x6b, 0x3b, 0xfa, 0xd1, 0x02, 0xff, 0x6a, 0x96, 0xe4, 0x39, 0x16, 0x53, 0x24, 0x87,
+	0xd0, 0x62, 0x59, 0xe0, 0x84, 0x4e, 0xec, 0xd2, 0x16, 0xcb, 0x08, 0x81, 0xb6, 0x5a, 0x95, 0x18,
+	0xb4, 0x42, 0x27, 0xf6, 0xa9, 0xb1, 0xc9, 0x09, 0x1c, 0x48, 0x95, 0xa8, 0x85, 0x0c, 0x0e, 0x0c,
+	0x5a, 0x79, 0x64, 0x00, 0xee, 0x42, 0xb0, 0xc0, 0x37, 0xa0, 0x36, 0xc9, 0x10, 0x3c, 0xc5, 0x1f,
+	0xb0, 0x08, 0x5c, 0x83, 0x59, 0x87, 0x9c, 0xc1, 0xe0, 0x01, 0x57, 0x97, 0x0b, 0x35, 0xe3, 0x82,
+	0x7d, 0x4b, 0x14, 0xe3, 0x45, 0xe0, 0x99, 0x84, 0x3d, 0x9c, 0x8c, 0xe1, 0x78, 0x99, 0xe4, 0x2c,
+	0x33, 0x9e, 0xc0, 0x94, 0x8b, 0x4c, 0x06, 0x10, 0xba, 0x71, 0x6f, 0x74, 0x72, 0x6e, 0x7a, 0xf9,
+	0xbc, 0x09, 0x53, 0x13, 0xa6, 0xfb, 0x1f, 0x90, 0x33, 0xf0, 0x50, 0x08, 0x2e, 0x82, 0x4e, 0xe8,
+	0xc4, 0xbd, 0xd1, 0xd0, 0x7e, 0x39, 0x11, 0xfc, 0x2e, 0xc7, 0xf9, 0x18, 0x55, 0xc2, 0x72, 0x49,
+	0x6d, 0x4a, 0xf4, 0xdb, 0x81, 0xc1, 0xee, 0x6f, 0x92, 0x17, 0xd0, 0x9d, 0x71, 0xa9, 0x8a, 0x64,
+	0x8e, 0x86, 0x1c, 0x9f, 0x6e, 0x7c, 0x4d, 0x51, 0xc9, 0x85, 0x5a, 0x53, 0xa4, 0x6d, 0xf2, 0x1a,
+	0x8e, 0x93, 0x2c, 0x13, 0x28, 0x25, 0x4a, 0x8a, 0x92, 0xe7, 0x4b, 0xcc, 0x02, 0x37, 0x74, 0xe3,
+	0x3e, 0xdd, 0x0f, 0x90, 0x10, 0x7a, 0x15, 0xf8, 0x49, 0x62, 0x16, 0xb4, 0x43, 0x27, 0xee, 0xd3,
+	0x3a, 0x64, 0x32, 0x2c, 0x2f, 0x8a, 0xa1, 0x0c, 0xbc, 0xd0, 0x8d, 0x7d, 0x5a, 0x87, 0x2c, 0xf9,
+	0x79, 0x35, 0x11, 0x6d, 0x92, 0x57, 0x70, 0xb8, 0x79, 0xea, 0x56, 0x30, 0xcc, 0x82, 0x8e, 0x29,
+	0x60, 0x07, 0x8d, 0xbe, 0xc2, 0x61, 0x93, 0x09, 0xfd, 0x5a, 0x69, 0x91, 0xdb, 0x55, 0xb9, 0x6e,
+	0xb8, 0x0e, 0x69, 0x09, 0x64, 0x26, 0xb9, 0xea, 0xba, 0xf2, 0xc8, 0x29, 0xc0, 0x4c, 0xa9, 0xf2,
+	0xc6, 0xca, 0x43, 0x4f, 0xdd, 0xa3, 0x35, 0x24, 0xfa, 0xe5, 0x40, 0xef, 0x0a, 0x85, 0x62, 0xf7,
+	0x2c, 0x4d, 0x14, 0xea, 0x1a, 0x05, 0x4e, 0x99, 0x54, 0xc2, 0xb0, 0x7d, 0x3d, 0xae, 0xa4, 0xb7,
+	0x83, 0x1a, 0xc9, 0xa1, 0x60, 0xc9, 0xe6, 0x3d, 0xeb, 0x99, 0x3a, 0xd8, 0x14, 0xa5, 0xaa, 0x14,
+	0x56, 0x79, 0x9a, 0x8d, 0x0c, 0x45, 0xc5, 0xa4, 0x36, 0x75, 0x26, 0x93, 0x72, 0x81, 0x99, 0x91,
+	0x9a, 0x4b, 0x2b, 0x8f, 0x04, 0xd0, 0xc1, 0xc7, 0x92, 0x09, 0xb4, 0x6a, 0x76, 0xe9, 0xda, 0x8d,
+	0x7e, 0xb6, 0xa0, 0x4f, 0x6b, 0x65, 0xec, 0xed, 0xc6, 0x00, 0xdc, 0x07, 0x5c, 0x99, 0x8a, 0xfa,
+	0x54, 0x9b, 0xfa, 0xc7, 0x52, 0x5e, 0xa8, 0x24, 0x55, 0x66, 0xd8, 0x3e, 0x5d, 0xbb, 0x24, 0x86,
+	0xa3, 0xca, 0x94, 0x13, 0x81, 0x12, 0x0b, 0x65, 0x8a, 0xeb, 0xd2, 0x5d, 0x98, 0xbc, 0x04, 0x3f,
+	0x99, 0x0a, 0xc4, 0xb9, 0xce, 0xb1, 0x6b, 0xb1, 0x05, 0x74, 0x94, 0x15, 0x4c, 0xb1, 0x24, 0xbf,
+	0x9e, 0x98, 0x82, 0xfb, 0x74, 0x0b, 0xe8, 0x68, 0x2a, 0x30, 0x51, 0x98, 0x5d, 0x2a, 0xa3, 0x75,
+	0x97, 0x6e, 0x81, 0xda, 0xde, 0x76, 0x1b, 0x7b, 0x3b, 0x82, 0x21, 0x3e, 0x2a, 0x14, 0x45, 0x92,
+	0x5f, 0xa6, 0x29, 0x5f, 0x14, 0xea, 0x3d, 0xae, 0xae, 0xc7, 0xd5, 0x22, 0x3f, 0x19, 0x8b, 0xfe,
+	0x38, 0xf0, 0x5f, 0x73, 0x53, 0xb7, 0xec, 0xf8, 0x86, 0x9d, 0x53, 0x00, 0x96, 0x61, 0xa1, 0x47,
+	0x8d, 0xa2, 0x1a, 0x5b, 0x0d, 0x79, 0x62, 0xf4, 0xee, 0xb3, 0xa3, 0xb7, 0x55, 0xb7, 0x1b, 0x55,
+	0xd7, 0x06, 0xe7, 0x35, 0x06, 0x47, 0x2e, 0x00, 0xd2, 0xf5, 0x41, 0xd3, 0x53, 0xd5, 0xc7, 0xe2,
+	0xc8, 0xae, 0xfc, 0xe6, 0xd0, 0xd1, 0x5a, 0x0a, 0x89, 0xa0, 0x9f, 0xf2, 0xf9, 0x1d, 0x2b, 0xcc,
+	0x9b, 0xd2, 0x30, 0xd7, 0xa7, 0x0d, 0x4c, 0xb7, 0xb7, 0x1c, 0x19, 0xe2, 0xba, 0xb4, 0xb5, 0x1c,
+	0x45, 0xdf, 0x5d, 0xf0, 0x3e, 0x0a, 0xad, 0xac, 0x5d, 0x59, 0xec, 0x37, 0xd6, 0x7a, 0xb2, 0xb1,
+	0x5a, 0x03, 0x6e, 0xb3, 0x81, 0xcd, 0xb9, 0x6a, 0xff, 0xf3, 0x5c, 0xe9, 0x4b, 0x93, 0x6e, 0x17,
+	0xea, 0xc6, 0x2e, 0x89, 0x95, 0xcd, 0x7e, 0xc0, 0xdc, 0x84, 0xfa, 0xd4, 0x2c, 0x3d, 0x3e, 0xdd,
+	0x41, 0x6b, 0xa4, 0x77, 0x1a, 0xa4, 0x0f, 0xc1, 0xd3, 0x37, 0x4f, 0x2b, 0x48, 0x7f, 0x66, 0x1d,
+	0x2d, 0xee, 0x3b, 0x9c, 0x26, 0xc5, 0x44, 0xf0, 0x14, 0xa5, 0x64, 0xc5, 0xd4, 0x68, 0xa7, 0x4b,
+	0x77, 0x61, 0xb3, 0x20, 0x56, 0x8f, 0x01, 0xd8, 0x9e, 0x2b, 0x97, 0xbc, 0x81, 0x93, 0x5a, 0xb9,
+	0x13, 0xc1, 0xef, 0x59, 0x8e, 0x1f, 0xf4, 0xbd, 0xed, 0x99, 0x0a, 0x9e, 0x89, 0x46, 0x1d, 0xf0,
+	0xde, 0xcd, 0x4b, 0xb5, 0x7a, 0xdb, 0xf9, 0xe2, 0x99, 0xbf, 0xb5, 0xbf, 0x00, 0x00, 0x00, 0xff,
+	0xff, 0x03, 0x00, 0x85, 0x9c, 0xe1, 0x7f, 0xee, 0x06, 0x00, 0x00,
 }
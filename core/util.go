@@ -16,12 +16,14 @@ import (
 	"io/ioutil"
 	"math/big"
 	mrand "math/rand"
+	"net"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 	"unicode"
 
+	"golang.org/x/crypto/ed25519"
 	jose "gopkg.in/square/go-jose.v2"
 
 	blog "github.com/letsencrypt/boulder/log"
@@ -87,6 +89,23 @@ func Fingerprint256(data []byte) string {
 	return base64.RawURLEncoding.EncodeToString(d.Sum(nil))
 }
 
+// ed25519SPKIPrefix is the DER encoding of an Ed25519 SubjectPublicKeyInfo
+// (RFC 8410 Section 4) up to the start of the 32 byte public key.
+var ed25519SPKIPrefix = []byte{0x30, 0x2a, 0x30, 0x05, 0x06, 0x03, 0x2b, 0x65, 0x70, 0x03, 0x21, 0x00}
+
+// marshalPKIXPublicKey is x509.MarshalPKIXPublicKey, extended to support the
+// Ed25519 keys used by EdDSA account keys, which the x509 package can't
+// marshal.
+func marshalPKIXPublicKey(key crypto.PublicKey) ([]byte, error) {
+	if edKey, ok := key.(ed25519.PublicKey); ok {
+		if len(edKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid Ed25519 public key length %d", len(edKey))
+		}
+		return append(append([]byte{}, ed25519SPKIPrefix...), edKey...), nil
+	}
+	return x509.MarshalPKIXPublicKey(key)
+}
+
 // KeyDigest produces a padded, standard Base64-encoded SHA256 digest of a
 // provided public key.
 func KeyDigest(key crypto.PublicKey) (string, error) {
@@ -99,7 +118,7 @@ func KeyDigest(key crypto.PublicKey) (string, error) {
 	case jose.JSONWebKey:
 		return KeyDigest(t.Key)
 	default:
-		keyDER, err := x509.MarshalPKIXPublicKey(key)
+		keyDER, err := marshalPKIXPublicKey(key)
 		if err != nil {
 			logger := blog.Get()
 			logger.Debugf("Problem marshaling public key: %s", err)
@@ -110,6 +129,28 @@ func KeyDigest(key crypto.PublicKey) (string, error) {
 	}
 }
 
+// KeySPKIHash returns the SHA256 digest of the DER encoded
+// SubjectPublicKeyInfo of a public key. This is the form in which keys are
+// recorded in the blockedKeys and keyHashToSerial tables.
+func KeySPKIHash(key crypto.PublicKey) ([]byte, error) {
+	switch t := key.(type) {
+	case *jose.JSONWebKey:
+		if t == nil {
+			return nil, fmt.Errorf("Cannot compute hash of nil key")
+		}
+		return KeySPKIHash(t.Key)
+	case jose.JSONWebKey:
+		return KeySPKIHash(t.Key)
+	default:
+		keyDER, err := marshalPKIXPublicKey(key)
+		if err != nil {
+			return nil, err
+		}
+		spkiDigest := sha256.Sum256(keyDER)
+		return spkiDigest[:], nil
+	}
+}
+
 // KeyDigestEquals determines whether two public keys have the same digest.
 func KeyDigestEquals(j, k crypto.PublicKey) bool {
 	digestJ, errJ := KeyDigest(j)
@@ -128,11 +169,11 @@ func PublicKeysEqual(a, b interface{}) (bool, error) {
 	if a == nil || b == nil {
 		return false, errors.New("One or more nil arguments to PublicKeysEqual")
 	}
-	aBytes, err := x509.MarshalPKIXPublicKey(a)
+	aBytes, err := marshalPKIXPublicKey(a)
 	if err != nil {
 		return false, err
 	}
-	bBytes, err := x509.MarshalPKIXPublicKey(b)
+	bBytes, err := marshalPKIXPublicKey(b)
 	if err != nil {
 		return false, err
 	}
@@ -217,6 +258,18 @@ func UniqueLowerNames(names []string) (unique []string) {
 	return
 }
 
+// IdentifierNames returns the identifier values named by a certificate's or
+// CSR's subject alternative names: the DNS names followed by the textual form
+// of each IP address, which is how IP identifiers are written in orders.
+func IdentifierNames(dnsNames []string, ips []net.IP) []string {
+	names := make([]string, 0, len(dnsNames)+len(ips))
+	names = append(names, dnsNames...)
+	for _, ip := range ips {
+		names = append(names, ip.String())
+	}
+	return names
+}
+
 // LoadCertBundle loads a PEM bundle of certificates from disk
 func LoadCertBundle(filename string) ([]*x509.Certificate, error) {
 	bundleBytes, err := ioutil.ReadFile(filename)
@@ -1,14 +1,19 @@
 package core
 
 import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"math"
 	"math/big"
+	"net"
 	"sort"
 	"strings"
 	"testing"
 
+	"golang.org/x/crypto/ed25519"
 	"gopkg.in/square/go-jose.v2"
 
 	"github.com/letsencrypt/boulder/test"
@@ -91,6 +96,31 @@ func TestKeyDigest(t *testing.T) {
 	test.Assert(t, err != nil, "Should have rejected unknown key type")
 }
 
+func TestKeyDigestEd25519(t *testing.T) {
+	// The example key from RFC 8410 Section 10.1.
+	key, err := hex.DecodeString("19bf44096984cdfe8541bac167dc3b96c85086aa30b6b6cb0c5c38ad703166e1")
+	test.AssertNotError(t, err, "decoding key")
+	der, err := marshalPKIXPublicKey(ed25519.PublicKey(key))
+	test.AssertNotError(t, err, "marshalPKIXPublicKey failed for an Ed25519 key")
+	test.AssertEquals(t, base64.StdEncoding.EncodeToString(der), "MCowBQYDK2VwAyEAGb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=")
+
+	digest, err := KeyDigest(&jose.JSONWebKey{Key: ed25519.PublicKey(key)})
+	test.AssertNotError(t, err, "KeyDigest failed for an Ed25519 key")
+	spkiDigest := sha256.Sum256(der)
+	test.AssertEquals(t, digest, base64.StdEncoding.EncodeToString(spkiDigest[:]))
+
+	hash, err := KeySPKIHash(ed25519.PublicKey(key))
+	test.AssertNotError(t, err, "KeySPKIHash failed for an Ed25519 key")
+	test.AssertByteEquals(t, hash, spkiDigest[:])
+
+	equal, err := PublicKeysEqual(ed25519.PublicKey(key), ed25519.PublicKey(key))
+	test.AssertNotError(t, err, "PublicKeysEqual failed for Ed25519 keys")
+	test.Assert(t, equal, "Identical Ed25519 keys weren't equal")
+
+	_, err = KeySPKIHash(ed25519.PublicKey(key[1:]))
+	test.AssertError(t, err, "KeySPKIHash accepted a short Ed25519 key")
+}
+
 func TestKeyDigestEquals(t *testing.T) {
 	var jwk1, jwk2 jose.JSONWebKey
 	err := json.Unmarshal([]byte(JWK1JSON), &jwk1)
@@ -114,6 +144,14 @@ func TestUniqueLowerNames(t *testing.T) {
 	test.AssertDeepEquals(t, []string{"a.com", "bar.com", "baz.com", "foobar.com"}, u)
 }
 
+func TestIdentifierNames(t *testing.T) {
+	names := IdentifierNames(
+		[]string{"example.com", "www.example.com"},
+		[]net.IP{net.ParseIP("10.0.0.1"), net.IPv4(10, 0, 0, 2).To4(), net.ParseIP("2001:db8::1")})
+	test.AssertDeepEquals(t, names, []string{"example.com", "www.example.com", "10.0.0.1", "10.0.0.2", "2001:db8::1"})
+	test.AssertEquals(t, len(IdentifierNames(nil, nil)), 0)
+}
+
 func TestValidSerial(t *testing.T) {
 	notLength32Or36 := "A"
 	length32 := strings.Repeat("A", 32)
@@ -8,8 +8,10 @@ type ValidationAuthority interface {
 	// given Authorization and returns the updated ValidationRecords.
 	//
 	// A failure to validate the Challenge will result in a error of type
-	// *probs.ProblemDetails.
+	// *probs.ProblemDetails. If the failure is because the domain's CAA
+	// records prevent issuance, the targets of any iodef records, to which
+	// the refusal may be reported, are returned too.
 	//
 	// TODO(#1626): remove authz parameter
-	PerformValidation(ctx context.Context, domain string, challenge Challenge, authz Authorization) ([]ValidationRecord, error)
+	PerformValidation(ctx context.Context, domain string, challenge Challenge, authz Authorization) (records []ValidationRecord, iodef []string, err error)
 }
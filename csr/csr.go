@@ -5,9 +5,14 @@ import (
 	"crypto/x509"
 	"errors"
 	"fmt"
+	"net"
+	"sort"
 	"strings"
 
+	"golang.org/x/net/context"
+
 	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/goodkey"
 )
 
@@ -36,20 +41,24 @@ var (
 	unsupportedSigAlg   = errors.New("signature algorithm not supported")
 	invalidSig          = errors.New("invalid signature on CSR")
 	invalidEmailPresent = errors.New("CSR contains one or more email address fields")
-	invalidIPPresent    = errors.New("CSR contains one or more IP address fields")
-	invalidNoDNS        = errors.New("at least one DNS name is required")
+	invalidNoNames      = errors.New("at least one DNS name or IP address is required")
 )
 
 // VerifyCSR checks the validity of a x509.CertificateRequest. Before doing checks it normalizes
-// the CSR which lowers the case of DNS names and subject CN, and if forceCNFromSAN is true it
-// will hoist a DNS name into the CN if it is empty.
-func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority, forceCNFromSAN bool, regID int64) error {
+// the CSR which lowers the case of DNS names and subject CN, moves an IP address CN into the IP
+// addresses, and if forceCNFromSAN is true it will hoist a DNS name into the CN if it is empty.
+// Problems with the CSR are returned as plain errors, while a failure to check whether its
+// public key is blocked is returned as a berrors.InternalServer error.
+func VerifyCSR(ctx context.Context, csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.KeyPolicy, pa core.PolicyAuthority, forceCNFromSAN bool, regID int64) error {
 	normalizeCSR(csr, forceCNFromSAN)
 	key, ok := csr.PublicKey.(crypto.PublicKey)
 	if !ok {
 		return invalidPubKey
 	}
-	if err := keyPolicy.GoodKey(key); err != nil {
+	if err := keyPolicy.GoodKey(ctx, key); err != nil {
+		if !berrors.Is(err, berrors.Malformed) {
+			return berrors.InternalServerError("unable to check public key in CSR: %s", err)
+		}
 		return fmt.Errorf("invalid public key in CSR: %s", err)
 	}
 	if !goodSignatureAlgorithms[csr.SignatureAlgorithm] {
@@ -61,17 +70,14 @@ func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.Ke
 	if len(csr.EmailAddresses) > 0 {
 		return invalidEmailPresent
 	}
-	if len(csr.IPAddresses) > 0 {
-		return invalidIPPresent
-	}
-	if len(csr.DNSNames) == 0 && csr.Subject.CommonName == "" {
-		return invalidNoDNS
+	if len(csr.DNSNames) == 0 && len(csr.IPAddresses) == 0 && csr.Subject.CommonName == "" {
+		return invalidNoNames
 	}
 	if len(csr.Subject.CommonName) > maxCNLength {
 		return fmt.Errorf("CN was longer than %d bytes", maxCNLength)
 	}
-	if len(csr.DNSNames) > maxNames {
-		return fmt.Errorf("CSR contains more than %d DNS names", maxNames)
+	if len(csr.DNSNames)+len(csr.IPAddresses) > maxNames {
+		return fmt.Errorf("CSR contains more than %d identifiers", maxNames)
 	}
 	badNames := []string{}
 	for _, name := range csr.DNSNames {
@@ -84,15 +90,31 @@ func VerifyCSR(csr *x509.CertificateRequest, maxNames int, keyPolicy *goodkey.Ke
 			badNames = append(badNames, fmt.Sprintf("%q", name))
 		}
 	}
+	for _, ip := range csr.IPAddresses {
+		ident := core.AcmeIdentifier{
+			Type:  core.IdentifierIP,
+			Value: ip.String(),
+		}
+		if err := pa.WillingToIssueWildcard(ident); err != nil {
+			badNames = append(badNames, fmt.Sprintf("%q", ident.Value))
+		}
+	}
 	if len(badNames) > 0 {
 		return fmt.Errorf("policy forbids issuing for: %s", strings.Join(badNames, ", "))
 	}
 	return nil
 }
 
-// normalizeCSR deduplicates and lowers the case of dNSNames and the subject CN.
-// If forceCNFromSAN is true it will also hoist a dNSName into the CN if it is empty.
+// normalizeCSR deduplicates and lowers the case of dNSNames and the subject CN,
+// and deduplicates and sorts iPAddresses. A subject CN that is an IP address is
+// moved into the iPAddresses, since we never put IP addresses in the CN. If
+// forceCNFromSAN is true it will also hoist a dNSName into the CN if it is empty.
 func normalizeCSR(csr *x509.CertificateRequest, forceCNFromSAN bool) {
+	if ip := net.ParseIP(csr.Subject.CommonName); ip != nil {
+		csr.IPAddresses = append(csr.IPAddresses, ip)
+		csr.Subject.CommonName = ""
+	}
+	csr.IPAddresses = uniqueIPs(csr.IPAddresses)
 	if forceCNFromSAN && csr.Subject.CommonName == "" {
 		if len(csr.DNSNames) > 0 {
 			csr.Subject.CommonName = csr.DNSNames[0]
@@ -103,3 +125,19 @@ func normalizeCSR(csr *x509.CertificateRequest, forceCNFromSAN bool) {
 	csr.Subject.CommonName = strings.ToLower(csr.Subject.CommonName)
 	csr.DNSNames = core.UniqueLowerNames(csr.DNSNames)
 }
+
+// uniqueIPs returns the unique addresses in ips, sorted by their textual form.
+func uniqueIPs(ips []net.IP) []net.IP {
+	seen := make(map[string]bool, len(ips))
+	var unique []net.IP
+	for _, ip := range ips {
+		if !seen[ip.String()] {
+			seen[ip.String()] = true
+			unique = append(unique, ip)
+		}
+	}
+	sort.Slice(unique, func(i, j int) bool {
+		return unique[i].String() < unique[j].String()
+	})
+	return unique
+}
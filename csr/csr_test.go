@@ -10,6 +10,8 @@ import (
 	"strings"
 	"testing"
 
+	"golang.org/x/net/context"
+
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/goodkey"
 	"github.com/letsencrypt/boulder/test"
@@ -32,7 +34,7 @@ func (pa *mockPA) WillingToIssue(id core.AcmeIdentifier) error {
 }
 
 func (pa *mockPA) WillingToIssueWildcard(id core.AcmeIdentifier) error {
-	if id.Value == "bad-name.com" || id.Value == "other-bad-name.com" {
+	if id.Value == "bad-name.com" || id.Value == "other-bad-name.com" || id.Value == "192.0.2.1" {
 		return errors.New("")
 	}
 	return nil
@@ -67,6 +69,13 @@ func TestVerifyCSR(t *testing.T) {
 	signedReqWithIPAddress := new(x509.CertificateRequest)
 	*signedReqWithIPAddress = *signedReq
 	signedReqWithIPAddress.IPAddresses = []net.IP{net.IPv4(1, 2, 3, 4)}
+	signedReqWithBadIPAddress := new(x509.CertificateRequest)
+	*signedReqWithBadIPAddress = *signedReq
+	signedReqWithBadIPAddress.IPAddresses = []net.IP{net.IPv4(1, 2, 3, 4), net.IPv4(192, 0, 2, 1)}
+	signedReqWithTooManyNames := new(x509.CertificateRequest)
+	*signedReqWithTooManyNames = *signedReq
+	signedReqWithTooManyNames.DNSNames = []string{"a.com"}
+	signedReqWithTooManyNames.IPAddresses = []net.IP{net.IPv4(1, 2, 3, 4)}
 
 	cases := []struct {
 		csr           *x509.CertificateRequest
@@ -106,7 +115,7 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			invalidNoDNS,
+			invalidNoNames,
 		},
 		{
 			signedReqWithLongCN,
@@ -122,7 +131,7 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			errors.New("CSR contains more than 1 DNS names"),
+			errors.New("CSR contains more than 1 identifiers"),
 		},
 		{
 			signedReqWithBadNames,
@@ -146,12 +155,28 @@ func TestVerifyCSR(t *testing.T) {
 			testingPolicy,
 			&mockPA{},
 			0,
-			invalidIPPresent,
+			nil,
+		},
+		{
+			signedReqWithBadIPAddress,
+			100,
+			testingPolicy,
+			&mockPA{},
+			0,
+			errors.New("policy forbids issuing for: \"192.0.2.1\""),
+		},
+		{
+			signedReqWithTooManyNames,
+			1,
+			testingPolicy,
+			&mockPA{},
+			0,
+			errors.New("CSR contains more than 1 identifiers"),
 		},
 	}
 
 	for _, c := range cases {
-		err := VerifyCSR(c.csr, c.maxNames, c.keyPolicy, c.pa, false, c.regID)
+		err := VerifyCSR(context.Background(), c.csr, c.maxNames, c.keyPolicy, c.pa, false, c.regID)
 		test.AssertDeepEquals(t, c.expectedError, err)
 	}
 }
@@ -200,3 +225,22 @@ func TestNormalizeCSR(t *testing.T) {
 		test.AssertDeepEquals(t, c.expectedNames, c.csr.DNSNames)
 	}
 }
+
+func TestNormalizeCSRIPAddresses(t *testing.T) {
+	csr := &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: "10.0.0.2"},
+		DNSNames:    []string{"a.com"},
+		IPAddresses: []net.IP{net.ParseIP("10.0.0.3"), net.ParseIP("10.0.0.2"), net.ParseIP("10.0.0.3")},
+	}
+	normalizeCSR(csr, true)
+	test.AssertEquals(t, csr.Subject.CommonName, "a.com")
+	test.AssertDeepEquals(t, csr.DNSNames, []string{"a.com"})
+	test.AssertDeepEquals(t, core.IdentifierNames(nil, csr.IPAddresses), []string{"10.0.0.2", "10.0.0.3"})
+
+	// An IP address CN isn't hoisted back into the CN.
+	csr = &x509.CertificateRequest{Subject: pkix.Name{CommonName: "10.0.0.2"}}
+	normalizeCSR(csr, true)
+	test.AssertEquals(t, csr.Subject.CommonName, "")
+	test.AssertEquals(t, len(csr.DNSNames), 0)
+	test.AssertDeepEquals(t, core.IdentifierNames(nil, csr.IPAddresses), []string{"10.0.0.2"})
+}
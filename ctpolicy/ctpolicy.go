@@ -2,25 +2,39 @@ package ctpolicy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
 	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/ctpolicy/loglist"
 	berrors "github.com/letsencrypt/boulder/errors"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
+	"github.com/letsencrypt/boulder/reloader"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
 // CTPolicy is used to hold information about SCTs required from various
 // groupings
 type CTPolicy struct {
-	pub           core.Publisher
-	groups        []cmd.CTGroup
+	pub core.Publisher
+
+	// logsMu protects groups, required, informational and finalLogs, which
+	// are replaced when a log list is reloaded.
+	logsMu sync.RWMutex
+	groups []cmd.CTGroup
+	// required is the number of groups an SCT is required from.
+	required      int
 	informational []cmd.LogDescription
 	finalLogs     []cmd.LogDescription
 	log           blog.Logger
@@ -35,6 +49,35 @@ func New(pub core.Publisher,
 	log blog.Logger,
 	stats metrics.Scope,
 ) *CTPolicy {
+	winnerCounter := prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "sct_race_winner",
+			Help: "Counter of logs that win SCT submission races.",
+		},
+		[]string{"log", "group"},
+	)
+	stats.MustRegister(winnerCounter)
+
+	ctp := &CTPolicy{
+		pub:           pub,
+		log:           log,
+		winnerCounter: winnerCounter,
+	}
+	ctp.SetLogs(groups, informational)
+	return ctp
+}
+
+// SetLogs replaces the groups of logs SCTs are required from and the
+// informational logs that are submitted to. An SCT is required from every
+// group.
+func (ctp *CTPolicy) SetLogs(groups []cmd.CTGroup, informational []cmd.LogDescription) {
+	ctp.setLogs(groups, len(groups), informational)
+}
+
+// setLogs replaces the groups of logs SCTs are obtained from, the number of
+// them an SCT is required from, and the informational logs that are
+// submitted to.
+func (ctp *CTPolicy) setLogs(groups []cmd.CTGroup, required int, informational []cmd.LogDescription) {
 	var finalLogs []cmd.LogDescription
 	for _, group := range groups {
 		for _, log := range group.Logs {
@@ -49,42 +92,93 @@ func New(pub core.Publisher,
 		}
 	}
 
-	winnerCounter := prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "sct_race_winner",
-			Help: "Counter of logs that win SCT submission races.",
-		},
-		[]string{"log", "group"},
-	)
-	stats.MustRegister(winnerCounter)
+	ctp.logsMu.Lock()
+	defer ctp.logsMu.Unlock()
+	ctp.groups = groups
+	ctp.required = required
+	ctp.informational = informational
+	ctp.finalLogs = finalLogs
+}
 
-	return &CTPolicy{
-		pub:           pub,
-		groups:        groups,
-		informational: informational,
-		finalLogs:     finalLogs,
-		log:           log,
-		winnerCounter: winnerCounter,
-	}
+// logs returns the current groups, the number of them an SCT is required
+// from, the informational logs and the logs final certificates are submitted
+// to.
+func (ctp *CTPolicy) logs() ([]cmd.CTGroup, int, []cmd.LogDescription, []cmd.LogDescription) {
+	ctp.logsMu.RLock()
+	defer ctp.logsMu.RUnlock()
+	return ctp.groups, ctp.required, ctp.informational, ctp.finalLogs
+}
+
+// SetLogListFile loads the CT log list in the v3 log_list.json schema from
+// filename, and reloads it whenever it changes. A group is built from the
+// usable logs of each operator in the list. SCTs are required from any
+// minSCTOperators of those groups, so that an outage of one operator doesn't
+// block issuance while others are available. The qualified logs in the list
+// are submitted to as informational logs, in addition to the given ones.
+//
+// A log list that fails to load, or doesn't have enough operators with usable
+// logs, is logged and ignored. The previous logs stay in use.
+func (ctp *CTPolicy) SetLogListFile(
+	filename string,
+	stagger time.Duration,
+	submitFinalCert bool,
+	informational []cmd.LogDescription,
+) error {
+	_, err := reloader.New(filename, func(b []byte) error {
+		ll, err := loglist.Parse(b)
+		if err != nil {
+			return err
+		}
+		groups, err := ll.Groups(stagger, submitFinalCert)
+		if err != nil {
+			return err
+		}
+		if len(groups) < minSCTOperators {
+			return fmt.Errorf("log list has usable logs from %d operators, %d required",
+				len(groups), minSCTOperators)
+		}
+		listInformational := append([]cmd.LogDescription{}, informational...)
+		listInformational = append(listInformational, ll.Informational(submitFinalCert)...)
+		ctp.setLogs(groups, minSCTOperators, listInformational)
+		ctp.log.Infof("loaded %d CT log groups from log list %s, version %s",
+			len(groups), filename, ll.Version)
+		return nil
+	}, func(err error) {
+		ctp.log.AuditErrf("error reloading CT log list %s: %s", filename, err)
+	})
+	return err
 }
 
 type result struct {
-	sct []byte
-	log string
-	err error
+	sct   []byte
+	log   string
+	group int
+	err   error
 }
 
-// race submits an SCT to each log in a group and waits for the first response back,
-// once it has the first SCT it cancels all of the other submissions and returns.
-// It allows up to len(group)-1 of the submissions to fail as we only care about
-// getting a single SCT.
-func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGroup, expiration time.Time) ([]byte, error) {
-	results := make(chan result, len(group.Logs))
+// race submits an SCT to each log in a group, other than those whose URI is in
+// used, and waits for the first response back, once it has the first SCT it
+// cancels all of the other submissions and returns the SCT and the URI of the
+// log it came from. It allows up to len(group)-1 of the submissions to fail as
+// we only care about getting a single SCT.
+func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGroup, expiration time.Time, used map[string]bool) ([]byte, string, error) {
+	var logs []cmd.LogDescription
+	for _, ld := range group.Logs {
+		uri, _, err := ld.Info(expiration)
+		if err == nil && used[uri] {
+			continue
+		}
+		logs = append(logs, ld)
+	}
+	if len(logs) == 0 {
+		return nil, "", errors.New("no unused logs")
+	}
+	results := make(chan result, len(logs))
 	isPrecert := true
 	// Randomize the order in which we send requests to the logs in a group
 	// so we maximize the distribution of logs we get SCTs from.
-	for i, logNum := range rand.Perm(len(group.Logs)) {
-		ld := group.Logs[logNum]
+	for i, logNum := range rand.Perm(len(logs)) {
+		ld := logs[logNum]
 		go func(i int, ld cmd.LogDescription) {
 			// Each submission waits a bit longer than the previous one, to give the
 			// previous log a chance to reply. If the context is already done by the
@@ -117,17 +211,21 @@ func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGr
 		}(i, ld)
 	}
 
-	for i := 0; i < len(group.Logs); i++ {
+	for i := 0; i < len(logs); i++ {
 		select {
 		case <-ctx.Done():
-			ctp.winnerCounter.With(prometheus.Labels{"log": "timeout", "group": group.Name}).Inc()
-			return nil, ctx.Err()
+			// A group is canceled once enough other groups have returned
+			// SCTs, which isn't a timeout.
+			if ctx.Err() == context.DeadlineExceeded {
+				ctp.winnerCounter.With(prometheus.Labels{"log": "timeout", "group": group.Name}).Inc()
+			}
+			return nil, "", ctx.Err()
 		case res := <-results:
 			if res.sct != nil {
 				ctp.winnerCounter.With(prometheus.Labels{"log": res.log, "group": group.Name}).Inc()
 				// Return the very first SCT we get back. Returning triggers
 				// the defer'd context cancellation method.
-				return res.sct, nil
+				return res.sct, res.log, nil
 			}
 			// We will continue waiting for an SCT until we've seen the same number
 			// of errors as there are logs in the group as we may still get a SCT
@@ -135,27 +233,42 @@ func (ctp *CTPolicy) race(ctx context.Context, cert core.CertDER, group cmd.CTGr
 		}
 	}
 	ctp.winnerCounter.With(prometheus.Labels{"log": "all_failed", "group": group.Name}).Inc()
-	return nil, errors.New("all submissions failed")
+	return nil, "", errors.New("all submissions failed")
 }
 
-// GetSCTs attempts to retrieve a SCT from each configured grouping of logs and returns
-// the set of SCTs to the caller.
-func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration time.Time) (core.SCTDERs, error) {
-	results := make(chan result, len(ctp.groups))
+// GetSCTs attempts to retrieve a SCT from each configured grouping of logs for
+// a certificate valid from notBefore until expiration. It returns the SCTs of
+// the first groups to reply once it has as many as the CT policies require for
+// the certificate's lifetime, and fails as soon as too few groups are left to
+// provide an SCT from each of the required number of groups. If there are fewer
+// groups than SCTs needed, the rest are obtained from other logs of the groups
+// that replied, as far as possible.
+func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, notBefore, expiration time.Time) (core.SCTDERs, error) {
+	groups, required, informational, _ := ctp.logs()
+	if len(groups) < required {
+		return nil, berrors.MissingSCTsError("%d CT log groups configured, SCTs from %d required",
+			len(groups), required)
+	}
+	need := required
+	if required > 0 && expiration.Sub(notBefore) > shortLifetime && need < minLongLivedSCTs {
+		need = minLongLivedSCTs
+	}
+	results := make(chan result, len(groups))
 	subCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
-	for i, g := range ctp.groups {
+	for i, g := range groups {
 		go func(i int, g cmd.CTGroup) {
-			sct, err := ctp.race(subCtx, cert, g, expiration)
+			sct, log, err := ctp.race(subCtx, cert, g, expiration, nil)
 			// Only one of these will be non-nil
 			if err != nil {
 				results <- result{err: berrors.MissingSCTsError("CT log group %q: %s", g.Name, err)}
+				return
 			}
-			results <- result{sct: sct}
+			results <- result{sct: sct, log: log, group: i}
 		}(i, g)
 	}
 	isPrecert := true
-	for _, log := range ctp.informational {
+	for _, log := range informational {
 		go func(l cmd.LogDescription) {
 			// We use a context.Background() here instead of subCtx because these
 			// submissions are running in a goroutine and we don't want them to be
@@ -179,24 +292,140 @@ func (ctp *CTPolicy) GetSCTs(ctx context.Context, cert core.CertDER, expiration
 	}
 
 	var ret core.SCTDERs
-	for i := 0; i < len(ctp.groups); i++ {
+	used := make(map[string]bool)
+	var replied []cmd.CTGroup
+	failures := 0
+	for i := 0; i < len(groups) && len(ret) < need; i++ {
 		res := <-results
-		// If any one group fails to get a SCT then we fail out immediately
-		// cancel any other in progress work as we can't continue
 		if res.err != nil {
-			// Returning triggers the defer'd context cancellation method
-			return nil, res.err
+			failures++
+			// If too few groups are left to get the required SCTs then we fail
+			// out immediately and cancel any other in progress work as we can't
+			// continue
+			if len(groups)-failures < required {
+				// Returning triggers the defer'd context cancellation method
+				return nil, res.err
+			}
+			continue
 		}
 		ret = append(ret, res.sct)
+		used[res.log] = true
+		replied = append(replied, groups[res.group])
+	}
+	// Long-lived certificates need SCTs from more logs than there may be
+	// groups. Any SCTs still missing are best-effort, CheckSCTs rejects a
+	// certificate without enough of them.
+	for _, g := range replied {
+		if len(ret) >= need {
+			break
+		}
+		sct, log, err := ctp.race(subCtx, cert, g, expiration, used)
+		if err != nil {
+			ctp.log.Warningf("getting additional SCT from CT log group %q failed: %s", g.Name, err)
+			continue
+		}
+		ret = append(ret, sct)
+		used[log] = true
 	}
 	return ret, nil
 }
 
+// The requirements of the Chrome and Apple CT policies for SCTs embedded in a
+// certificate.
+const (
+	// shortLifetime is the longest certificate lifetime for which
+	// minShortLivedSCTs SCTs are enough.
+	shortLifetime     = 180 * 24 * time.Hour
+	minShortLivedSCTs = 2
+	minLongLivedSCTs  = 3
+	// minSCTOperators is the number of distinct log operators SCTs are
+	// required from.
+	minSCTOperators = 2
+)
+
+// logOperators returns the operator of each log in groups, keyed by log ID. It
+// returns an error if a log has no operator configured.
+func logOperators(groups []cmd.CTGroup) (map[[sha256.Size]byte]string, error) {
+	operators := make(map[[sha256.Size]byte]string)
+	add := func(b64PK, operator string) error {
+		if operator == "" {
+			return fmt.Errorf("no operator configured for log with key %s", b64PK)
+		}
+		der, err := base64.StdEncoding.DecodeString(b64PK)
+		if err != nil {
+			return fmt.Errorf("decoding log key: %s", err)
+		}
+		operators[sha256.Sum256(der)] = operator
+		return nil
+	}
+	for _, group := range groups {
+		for _, log := range group.Logs {
+			if log.TemporalSet == nil {
+				err := add(log.Key, log.Operator)
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			for _, shard := range log.TemporalSet.Shards {
+				err := add(shard.Key, log.Operator)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return operators, nil
+}
+
+// CheckSCTs returns a MissingSCTsError unless scts, to be embedded in a
+// certificate valid from notBefore to notAfter, satisfy the Chrome and Apple
+// CT policies: every SCT must be from a log in one of the groups, and there
+// must be SCTs from enough distinct logs and operators.
+func (ctp *CTPolicy) CheckSCTs(scts core.SCTDERs, notBefore, notAfter time.Time) error {
+	groups, _, _, _ := ctp.logs()
+	operators, err := logOperators(groups)
+	if err != nil {
+		return err
+	}
+	logIDs := make(map[[sha256.Size]byte]bool)
+	sctOperators := make(map[string]bool)
+	for _, der := range scts {
+		var sct ct.SignedCertificateTimestamp
+		_, err := cttls.Unmarshal(der, &sct)
+		if err != nil {
+			return berrors.MissingSCTsError("failed to parse SCT: %s", err)
+		}
+		operator, ok := operators[sct.LogID.KeyID]
+		if !ok {
+			return berrors.MissingSCTsError("SCT from unknown log %s",
+				base64.StdEncoding.EncodeToString(sct.LogID.KeyID[:]))
+		}
+		logIDs[sct.LogID.KeyID] = true
+		sctOperators[operator] = true
+	}
+
+	required := minShortLivedSCTs
+	if notAfter.Sub(notBefore) > shortLifetime {
+		required = minLongLivedSCTs
+	}
+	if len(logIDs) < required {
+		return berrors.MissingSCTsError("SCTs from %d distinct logs, %d required for a certificate valid for %s",
+			len(logIDs), required, notAfter.Sub(notBefore))
+	}
+	if len(sctOperators) < minSCTOperators {
+		return berrors.MissingSCTsError("SCTs from %d distinct log operators, %d required",
+			len(sctOperators), minSCTOperators)
+	}
+	return nil
+}
+
 // SubmitFinalCert submits finalized certificates created from precertificates
 // to any configured logs
 func (ctp *CTPolicy) SubmitFinalCert(cert []byte, expiration time.Time) {
 	falseVar := false
-	for _, log := range ctp.finalLogs {
+	_, _, _, finalLogs := ctp.logs()
+	for _, log := range finalLogs {
 		go func(l cmd.LogDescription) {
 			uri, key, err := l.Info(expiration)
 			if err != nil {
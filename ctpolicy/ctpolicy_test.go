@@ -2,11 +2,15 @@ package ctpolicy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
 	"regexp"
 	"testing"
 	"time"
 
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
 	"github.com/letsencrypt/boulder/cmd"
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
@@ -117,7 +121,7 @@ func TestGetSCTs(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			ctp := New(tc.mock, tc.groups, nil, blog.NewMock(), metrics.NewNoopScope())
-			ret, err := ctp.GetSCTs(tc.ctx, []byte{0}, time.Time{})
+			ret, err := ctp.GetSCTs(tc.ctx, []byte{0}, time.Time{}, time.Time{})
 			if tc.result != nil {
 				test.AssertDeepEquals(t, ret, tc.result)
 			} else if tc.errRegexp != nil {
@@ -171,12 +175,36 @@ func TestGetSCTsMetrics(t *testing.T) {
 			},
 		},
 	}, nil, blog.NewMock(), metrics.NewNoopScope())
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, time.Time{})
 	test.AssertNotError(t, err, "GetSCTs failed")
 	test.AssertEquals(t, test.CountCounter(ctp.winnerCounter.With(prometheus.Labels{"log": "ghi", "group": "a"})), 1)
 	test.AssertEquals(t, test.CountCounter(ctp.winnerCounter.With(prometheus.Labels{"log": "ghi", "group": "b"})), 1)
 }
 
+func TestGetSCTsAnyRequiredGroups(t *testing.T) {
+	// With SCTs required from two of three groups, a failing group is made up
+	// for by the others.
+	ctp := New(&failOne{badURL: "abc"}, nil, nil, blog.NewMock(), metrics.NewNoopScope())
+	ctp.setLogs([]cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "abc", Key: "def"}}},
+		{Name: "b", Logs: []cmd.LogDescription{{URI: "ghi", Key: "jkl"}}},
+		{Name: "c", Logs: []cmd.LogDescription{{URI: "mno", Key: "pqr"}}},
+	}, 2, nil)
+	scts, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, time.Time{})
+	test.AssertNotError(t, err, "GetSCTs failed")
+	test.AssertEquals(t, len(scts), 2)
+
+	// Two failing groups leave too few SCTs.
+	ctp.setLogs([]cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "abc", Key: "def"}}},
+		{Name: "b", Logs: []cmd.LogDescription{{URI: "abc", Key: "jkl"}}},
+		{Name: "c", Logs: []cmd.LogDescription{{URI: "mno", Key: "pqr"}}},
+	}, 2, nil)
+	_, err = ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, time.Time{})
+	test.AssertError(t, err, "GetSCTs succeeded with two failing groups")
+	test.AssertEquals(t, berrors.Is(err, berrors.MissingSCTs), true)
+}
+
 func TestGetSCTsFailMetrics(t *testing.T) {
 	// When an entire log group fails, we should increment the "winner of SCT
 	// race" stat for that group under the fictional log "all_failed".
@@ -188,7 +216,7 @@ func TestGetSCTsFailMetrics(t *testing.T) {
 			},
 		},
 	}, nil, blog.NewMock(), metrics.NewNoopScope())
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, time.Time{})
 	if err == nil {
 		t.Fatal("GetSCTs should have failed")
 	}
@@ -206,7 +234,7 @@ func TestGetSCTsFailMetrics(t *testing.T) {
 			},
 		},
 	}, nil, blog.NewMock(), metrics.NewNoopScope())
-	_, err = ctp.GetSCTs(ctx, []byte{0}, time.Time{})
+	_, err = ctp.GetSCTs(ctx, []byte{0}, time.Time{}, time.Time{})
 	if err == nil {
 		t.Fatal("GetSCTs should have failed")
 	}
@@ -235,9 +263,157 @@ func TestStagger(t *testing.T) {
 			},
 		},
 	}, nil, blog.NewMock(), metrics.NewNoopScope())
-	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{})
+	_, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Time{}, time.Time{})
 	test.AssertNotError(t, err, "GetSCTs failed")
 	if countingPub.count != 1 {
 		t.Errorf("wrong number of requests to publisher. got %d, expected 1", countingPub.count)
 	}
 }
+
+func TestSetLogListFile(t *testing.T) {
+	ctp := New(&mockPub{}, nil, nil, blog.NewMock(), metrics.NewNoopScope())
+	informational := []cmd.LogDescription{{URI: "informational", Key: "def", SubmitFinalCert: true}}
+	err := ctp.SetLogListFile("../test/ct-log-list.json", time.Second, false, informational)
+	test.AssertNotError(t, err, "SetLogListFile failed")
+
+	groups, _, inf, finalLogs := ctp.logs()
+	test.AssertEquals(t, len(groups), 2)
+	test.AssertEquals(t, groups[0].Name, "a")
+	test.AssertEquals(t, groups[1].Name, "b")
+	test.AssertDeepEquals(t, inf, informational)
+	test.AssertEquals(t, len(finalLogs), 1)
+
+	scts, err := ctp.GetSCTs(context.Background(), []byte{0}, time.Now(), time.Now().Add(90*24*time.Hour))
+	test.AssertNotError(t, err, "GetSCTs failed")
+	test.AssertEquals(t, len(scts), 2)
+
+	// A long-lived certificate needs a third SCT, from another log of one of
+	// the two operators.
+	scts, err = ctp.GetSCTs(context.Background(), []byte{0}, time.Now(), time.Now().Add(365*24*time.Hour))
+	test.AssertNotError(t, err, "GetSCTs failed")
+	test.AssertEquals(t, len(scts), 3)
+
+	err = ctp.SetLogListFile("../test/does-not-exist.json", time.Second, false, nil)
+	test.AssertError(t, err, "SetLogListFile succeeded with a missing file")
+}
+
+func makeSCT(t *testing.T, b64PK string) []byte {
+	der, err := base64.StdEncoding.DecodeString(b64PK)
+	test.AssertNotError(t, err, "decoding log key")
+	sct, err := cttls.Marshal(ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: sha256.Sum256(der)},
+		Signature: ct.DigitallySigned{
+			Algorithm: cttls.SignatureAndHashAlgorithm{
+				Hash:      cttls.SHA256,
+				Signature: cttls.ECDSA,
+			},
+			Signature: []byte{0},
+		},
+	})
+	test.AssertNotError(t, err, "marshaling SCT")
+	return sct
+}
+
+func TestCheckSCTs(t *testing.T) {
+	keyA1 := base64.StdEncoding.EncodeToString([]byte("a1"))
+	keyA2 := base64.StdEncoding.EncodeToString([]byte("a2"))
+	keyB := base64.StdEncoding.EncodeToString([]byte("b"))
+	keyShard := base64.StdEncoding.EncodeToString([]byte("shard"))
+	keyC := base64.StdEncoding.EncodeToString([]byte("c"))
+	keyD := base64.StdEncoding.EncodeToString([]byte("d"))
+	keyUnknown := base64.StdEncoding.EncodeToString([]byte("unknown"))
+	unknownID := sha256.Sum256([]byte("unknown"))
+	ctp := New(&mockPub{}, []cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "a1", Key: keyA1, Operator: "A"}, {URI: "a2", Key: keyA2, Operator: "A"}}},
+		{Name: "b", Logs: []cmd.LogDescription{{URI: "b", Key: keyB, Operator: "B"}, {Operator: "B", TemporalSet: &cmd.TemporalSet{
+			Name:   "shards",
+			Shards: []cmd.LogShard{{URI: "shard", Key: keyShard}},
+		}}}},
+		{Name: "c", Logs: []cmd.LogDescription{{URI: "c", Key: keyC, Operator: "C"}}},
+		// The operator of a log, not the name of its group, counts.
+		{Name: "d", Logs: []cmd.LogDescription{{URI: "d", Key: keyD, Operator: "A"}}},
+	}, nil, blog.NewMock(), metrics.NewNoopScope())
+
+	notBefore := time.Now()
+	shortLived := notBefore.Add(90 * 24 * time.Hour)
+	longLived := notBefore.Add(365 * 24 * time.Hour)
+	testCases := []struct {
+		name     string
+		keys     []string
+		notAfter time.Time
+		errMsg   string
+	}{
+		{
+			name:     "two operators",
+			keys:     []string{keyA1, keyShard},
+			notAfter: shortLived,
+		},
+		{
+			name:     "too few SCTs",
+			keys:     []string{keyA1},
+			notAfter: shortLived,
+			errMsg:   "SCTs from 1 distinct logs, 2 required for a certificate valid for 2160h0m0s",
+		},
+		{
+			name:     "duplicate log",
+			keys:     []string{keyB, keyB},
+			notAfter: shortLived,
+			errMsg:   "SCTs from 1 distinct logs, 2 required for a certificate valid for 2160h0m0s",
+		},
+		{
+			name:     "one operator",
+			keys:     []string{keyA1, keyA2},
+			notAfter: shortLived,
+			errMsg:   "SCTs from 1 distinct log operators, 2 required",
+		},
+		{
+			name:     "one operator in two groups",
+			keys:     []string{keyA1, keyD},
+			notAfter: shortLived,
+			errMsg:   "SCTs from 1 distinct log operators, 2 required",
+		},
+		{
+			name:     "long lived with two SCTs",
+			keys:     []string{keyA1, keyB},
+			notAfter: longLived,
+			errMsg:   "SCTs from 2 distinct logs, 3 required for a certificate valid for 8760h0m0s",
+		},
+		{
+			name:     "long lived with three SCTs",
+			keys:     []string{keyA1, keyB, keyC},
+			notAfter: longLived,
+		},
+		{
+			name:     "unknown log",
+			keys:     []string{keyA1, keyB, keyUnknown},
+			notAfter: shortLived,
+			errMsg:   "SCT from unknown log " + base64.StdEncoding.EncodeToString(unknownID[:]),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var scts core.SCTDERs
+			for _, key := range tc.keys {
+				scts = append(scts, makeSCT(t, key))
+			}
+			err := ctp.CheckSCTs(scts, notBefore, tc.notAfter)
+			if tc.errMsg == "" {
+				test.AssertNotError(t, err, "CheckSCTs failed")
+				return
+			}
+			test.AssertError(t, err, "CheckSCTs succeeded")
+			test.Assert(t, berrors.Is(err, berrors.MissingSCTs), "wrong error type")
+			test.AssertEquals(t, err.Error(), tc.errMsg)
+		})
+	}
+
+	err := ctp.CheckSCTs(core.SCTDERs{[]byte{0}}, notBefore, shortLived)
+	test.Assert(t, berrors.Is(err, berrors.MissingSCTs), "CheckSCTs accepted an unparseable SCT")
+
+	ctp.SetLogs([]cmd.CTGroup{
+		{Name: "a", Logs: []cmd.LogDescription{{URI: "a1", Key: keyA1}}},
+	}, nil)
+	err = ctp.CheckSCTs(core.SCTDERs{makeSCT(t, keyA1)}, notBefore, shortLived)
+	test.AssertError(t, err, "CheckSCTs succeeded with a log without an operator")
+}
@@ -0,0 +1,235 @@
+// Package loglist parses Certificate Transparency log lists in the v3
+// log_list.json schema published by Chrome, and builds the CT log groups
+// used by ctpolicy from them.
+package loglist
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// LogList is a CT log list in the v3 schema.
+type LogList struct {
+	Version          string     `json:"version"`
+	LogListTimestamp time.Time  `json:"log_list_timestamp"`
+	Operators        []Operator `json:"operators"`
+}
+
+// Operator is an organization running one or more CT logs.
+type Operator struct {
+	Name  string   `json:"name"`
+	Email []string `json:"email"`
+	Logs  []Log    `json:"logs"`
+}
+
+// Log is a single CT log, which may be one temporal shard of a set of logs.
+type Log struct {
+	Description string `json:"description"`
+	// LogID is the base64 encoded SHA-256 hash of the log's public key.
+	LogID string `json:"log_id"`
+	// Key is the base64 encoded DER of the log's public key.
+	Key              string            `json:"key"`
+	URL              string            `json:"url"`
+	MMD              int               `json:"mmd"`
+	State            State             `json:"state"`
+	TemporalInterval *TemporalInterval `json:"temporal_interval"`
+}
+
+// TemporalInterval is the range of certificate expiration times a temporally
+// sharded log accepts.
+type TemporalInterval struct {
+	StartInclusive time.Time `json:"start_inclusive"`
+	EndExclusive   time.Time `json:"end_exclusive"`
+}
+
+// StateTimestamp records when a log entered a state.
+type StateTimestamp struct {
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// State is the state of a log in the lifecycle described by Chrome's CT
+// policy. Exactly one of its fields is set.
+type State struct {
+	Pending   *StateTimestamp `json:"pending"`
+	Qualified *StateTimestamp `json:"qualified"`
+	Usable    *StateTimestamp `json:"usable"`
+	ReadOnly  *StateTimestamp `json:"readonly"`
+	Retired   *StateTimestamp `json:"retired"`
+	Rejected  *StateTimestamp `json:"rejected"`
+}
+
+// Possible results of State.Name.
+const (
+	StatePending   = "pending"
+	StateQualified = "qualified"
+	StateUsable    = "usable"
+	StateReadOnly  = "readonly"
+	StateRetired   = "retired"
+	StateRejected  = "rejected"
+)
+
+// Name returns the name of the state, or the empty string if no state or
+// more than one state is set.
+func (s State) Name() string {
+	var names []string
+	for name, ts := range map[string]*StateTimestamp{
+		StatePending:   s.Pending,
+		StateQualified: s.Qualified,
+		StateUsable:    s.Usable,
+		StateReadOnly:  s.ReadOnly,
+		StateRetired:   s.Retired,
+		StateRejected:  s.Rejected,
+	} {
+		if ts != nil {
+			names = append(names, name)
+		}
+	}
+	if len(names) != 1 {
+		return ""
+	}
+	return names[0]
+}
+
+// Parse parses and validates a log list in the v3 schema.
+func Parse(b []byte) (*LogList, error) {
+	var ll LogList
+	err := json.Unmarshal(b, &ll)
+	if err != nil {
+		return nil, err
+	}
+	if len(ll.Operators) == 0 {
+		return nil, errors.New("log list contains no operators")
+	}
+	for _, op := range ll.Operators {
+		if op.Name == "" {
+			return nil, errors.New("log list contains an operator with no name")
+		}
+		for _, l := range op.Logs {
+			if l.URL == "" {
+				return nil, fmt.Errorf("log %q of operator %q has no URL", l.Description, op.Name)
+			}
+			_, err := base64.StdEncoding.DecodeString(l.Key)
+			if l.Key == "" || err != nil {
+				return nil, fmt.Errorf("log %q of operator %q has an invalid key", l.Description, op.Name)
+			}
+			if l.State.Name() == "" {
+				return nil, fmt.Errorf("log %q of operator %q must have exactly one state", l.Description, op.Name)
+			}
+			ti := l.TemporalInterval
+			if ti != nil && !ti.StartInclusive.Before(ti.EndExclusive) {
+				return nil, fmt.Errorf("log %q of operator %q has an empty temporal interval", l.Description, op.Name)
+			}
+		}
+	}
+	return &ll, nil
+}
+
+// descriptions returns the log descriptions for the logs of op in the given
+// state. Temporal shards are combined into temporal sets whose shard is picked
+// by the certificate's expiration. Each shard is added to the first set none of
+// whose shards it overlaps, so an operator running several sharded logs gets a
+// set for each of them.
+func (op Operator) descriptions(state string, submitFinalCert bool) []cmd.LogDescription {
+	var descs []cmd.LogDescription
+	var shards []Log
+	for _, l := range op.Logs {
+		if l.State.Name() != state {
+			continue
+		}
+		if l.TemporalInterval == nil {
+			descs = append(descs, cmd.LogDescription{
+				URI:             l.URL,
+				Key:             l.Key,
+				SubmitFinalCert: submitFinalCert,
+				Operator:        op.Name,
+			})
+			continue
+		}
+		shards = append(shards, l)
+	}
+	sort.SliceStable(shards, func(i, j int) bool {
+		return shards[i].TemporalInterval.StartInclusive.Before(shards[j].TemporalInterval.StartInclusive)
+	})
+	var sets []*cmd.TemporalSet
+	for _, l := range shards {
+		var set *cmd.TemporalSet
+		for _, s := range sets {
+			last := s.Shards[len(s.Shards)-1]
+			if !last.WindowEnd.After(l.TemporalInterval.StartInclusive) {
+				set = s
+				break
+			}
+		}
+		if set == nil {
+			set = &cmd.TemporalSet{Name: fmt.Sprintf("%s %s shards %d", op.Name, state, len(sets)+1)}
+			sets = append(sets, set)
+			descs = append(descs, cmd.LogDescription{
+				SubmitFinalCert: submitFinalCert,
+				Operator:        op.Name,
+				TemporalSet:     set,
+			})
+		}
+		set.Shards = append(set.Shards, cmd.LogShard{
+			URI:         l.URL,
+			Key:         l.Key,
+			WindowStart: l.TemporalInterval.StartInclusive,
+			WindowEnd:   l.TemporalInterval.EndExclusive,
+		})
+	}
+	return descs
+}
+
+// Groups returns a CT log group for each operator with usable logs, sorted by
+// operator name. SCTs are only required from some of the groups, so that the
+// logs of any operator can stand in for those of another that is unavailable.
+func (ll *LogList) Groups(stagger time.Duration, submitFinalCert bool) ([]cmd.CTGroup, error) {
+	var groups []cmd.CTGroup
+	for _, op := range ll.Operators {
+		logs := op.descriptions(StateUsable, submitFinalCert)
+		if len(logs) == 0 {
+			continue
+		}
+		groups = append(groups, cmd.CTGroup{
+			Name:    op.Name,
+			Logs:    logs,
+			Stagger: cmd.ConfigDuration{Duration: stagger},
+		})
+	}
+	if len(groups) == 0 {
+		return nil, errors.New("log list contains no usable logs")
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups, nil
+}
+
+// Informational returns descriptions of the qualified logs in the list, which
+// are submitted to without their SCTs being required.
+func (ll *LogList) Informational(submitFinalCert bool) []cmd.LogDescription {
+	var logs []cmd.LogDescription
+	for _, op := range ll.Operators {
+		logs = append(logs, op.descriptions(StateQualified, submitFinalCert)...)
+	}
+	return logs
+}
+
+// Logs returns the logs in the list in any of the given states.
+func (ll *LogList) Logs(states ...string) []Log {
+	var logs []Log
+	for _, op := range ll.Operators {
+		for _, l := range op.Logs {
+			for _, state := range states {
+				if l.State.Name() == state {
+					logs = append(logs, l)
+					break
+				}
+			}
+		}
+	}
+	return logs
+}
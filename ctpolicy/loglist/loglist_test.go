@@ -0,0 +1,175 @@
+package loglist
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+func TestGroups(t *testing.T) {
+	b, err := ioutil.ReadFile("../../test/ct-log-list.json")
+	test.AssertNotError(t, err, "reading log list")
+	ll, err := Parse(b)
+	test.AssertNotError(t, err, "parsing log list")
+
+	groups, err := ll.Groups(time.Second, true)
+	test.AssertNotError(t, err, "building groups")
+	test.AssertEquals(t, len(groups), 2)
+	test.AssertEquals(t, groups[0].Name, "a")
+	test.AssertEquals(t, groups[0].Stagger.Duration, time.Second)
+	test.AssertEquals(t, len(groups[0].Logs), 2)
+	test.AssertEquals(t, groups[0].Logs[0].URI, "http://boulder:4500")
+	test.Assert(t, groups[0].Logs[0].SubmitFinalCert, "SubmitFinalCert not set")
+
+	// The three shards of B2 are combined into one temporal set.
+	test.AssertEquals(t, groups[1].Name, "b")
+	test.AssertEquals(t, len(groups[1].Logs), 2)
+	set := groups[1].Logs[1].TemporalSet
+	test.Assert(t, set != nil, "temporal shards weren't combined into a set")
+	test.AssertEquals(t, set.Name, "b usable shards 1")
+	test.AssertEquals(t, len(set.Shards), 3)
+	uri, _, err := groups[1].Logs[1].Info(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	test.AssertNotError(t, err, "picking shard")
+	test.AssertEquals(t, uri, "http://boulder:4511")
+
+	test.AssertEquals(t, len(ll.Informational(false)), 0)
+	test.AssertEquals(t, len(ll.Logs(StateUsable)), 6)
+	test.AssertEquals(t, len(ll.Logs(StateRetired, StateRejected)), 0)
+}
+
+func TestShardSelection(t *testing.T) {
+	ll, err := Parse([]byte(`{"operators": [{"name": "Google", "logs": [
+		{"description": "Google 'Argon2021' log", "key": "AAAA", "url": "https://argon2021/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2021-01-01T00:00:00Z", "end_exclusive": "2022-01-01T00:00:00Z"}},
+		{"description": "Google 'Argon2020' log", "key": "AAAA", "url": "https://argon2020/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2020-01-01T00:00:00Z", "end_exclusive": "2021-01-01T00:00:00Z"}},
+		{"description": "Google 'Xenon2020' log", "key": "AAAA", "url": "https://xenon2020/",
+		 "state": {"qualified": {}},
+		 "temporal_interval": {"start_inclusive": "2020-01-01T00:00:00Z", "end_exclusive": "2021-01-01T00:00:00Z"}},
+		{"description": "Google 'Pilot' log", "key": "AAAA", "url": "https://pilot/",
+		 "state": {"retired": {}}}
+	]}]}`))
+	test.AssertNotError(t, err, "parsing log list")
+
+	groups, err := ll.Groups(0, false)
+	test.AssertNotError(t, err, "building groups")
+	test.AssertEquals(t, len(groups), 1)
+	test.AssertEquals(t, len(groups[0].Logs), 1)
+	test.AssertEquals(t, groups[0].Logs[0].TemporalSet.Name, "Google usable shards 1")
+
+	for exp, expected := range map[time.Time]string{
+		time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC): "https://argon2020/",
+		time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC): "https://argon2021/",
+	} {
+		uri, _, err := groups[0].Logs[0].Info(exp)
+		test.AssertNotError(t, err, "picking shard")
+		test.AssertEquals(t, uri, expected)
+	}
+	_, _, err = groups[0].Logs[0].Info(time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC))
+	test.AssertError(t, err, "picked a shard for an expiration no shard covers")
+
+	informational := ll.Informational(false)
+	test.AssertEquals(t, len(informational), 1)
+	test.AssertEquals(t, informational[0].TemporalSet.Name, "Google qualified shards 1")
+
+	retired := ll.Logs(StateRetired)
+	test.AssertEquals(t, len(retired), 1)
+	test.AssertEquals(t, retired[0].URL, "https://pilot/")
+}
+
+func TestOverlappingShards(t *testing.T) {
+	ll, err := Parse([]byte(`{"operators": [{"name": "Google", "logs": [
+		{"description": "Google 'Argon2020' log", "key": "AAAA", "url": "https://argon2020/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2020-01-01T00:00:00Z", "end_exclusive": "2021-01-01T00:00:00Z"}},
+		{"description": "Google 'Xenon2020' log", "key": "AAAA", "url": "https://xenon2020/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2020-01-01T00:00:00Z", "end_exclusive": "2021-01-01T00:00:00Z"}},
+		{"description": "Google 'Argon2021' log", "key": "AAAA", "url": "https://argon2021/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2021-01-01T00:00:00Z", "end_exclusive": "2022-01-01T00:00:00Z"}},
+		{"description": "Google 'Xenon2021' log", "key": "AAAA", "url": "https://xenon2021/",
+		 "state": {"usable": {}},
+		 "temporal_interval": {"start_inclusive": "2021-01-01T00:00:00Z", "end_exclusive": "2022-01-01T00:00:00Z"}}
+	]}]}`))
+	test.AssertNotError(t, err, "parsing log list")
+
+	// Shards covering the same period are put in different sets, regardless
+	// of their descriptions.
+	groups, err := ll.Groups(0, false)
+	test.AssertNotError(t, err, "building groups")
+	test.AssertEquals(t, len(groups), 1)
+	test.AssertEquals(t, len(groups[0].Logs), 2)
+	exp := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	uris := make(map[string]bool)
+	for _, ld := range groups[0].Logs {
+		test.AssertEquals(t, len(ld.TemporalSet.Shards), 2)
+		uri, _, err := ld.Info(exp)
+		test.AssertNotError(t, err, "picking shard")
+		uris[uri] = true
+	}
+	test.AssertDeepEquals(t, uris, map[string]bool{"https://argon2021/": true, "https://xenon2021/": true})
+}
+
+func TestGroupPerOperator(t *testing.T) {
+	ll, err := Parse([]byte(`{"operators": [
+		{"name": "c", "logs": [{"key": "AAAA", "url": "https://c/", "state": {"usable": {}}}]},
+		{"name": "a", "logs": [{"key": "AAAA", "url": "https://a/", "state": {"usable": {}}}]},
+		{"name": "d", "logs": [{"key": "AAAA", "url": "https://d/", "state": {"pending": {}}}]},
+		{"name": "b", "logs": [{"key": "AAAA", "url": "https://b/", "state": {"usable": {}}},
+		                       {"key": "AAAA", "url": "https://b2/", "state": {"qualified": {}}}]}
+	]}`))
+	test.AssertNotError(t, err, "parsing log list")
+
+	// Every operator with usable logs gets a group, and only qualified logs
+	// are informational.
+	groups, err := ll.Groups(0, false)
+	test.AssertNotError(t, err, "building groups")
+	test.AssertEquals(t, len(groups), 3)
+	test.AssertEquals(t, groups[0].Name, "a")
+	test.AssertEquals(t, groups[1].Name, "b")
+	test.AssertEquals(t, groups[2].Name, "c")
+	for _, g := range groups {
+		for _, l := range g.Logs {
+			test.AssertEquals(t, l.Operator, g.Name)
+		}
+	}
+
+	informational := ll.Informational(false)
+	test.AssertEquals(t, len(informational), 1)
+	test.AssertEquals(t, informational[0].URI, "https://b2/")
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		list string
+	}{
+		{"not JSON", `{`},
+		{"no operators", `{"operators": []}`},
+		{"no operator name", `{"operators": [{"logs": []}]}`},
+		{"no URL", `{"operators": [{"name": "a", "logs": [{"key": "AAAA", "state": {"usable": {}}}]}]}`},
+		{"bad key", `{"operators": [{"name": "a", "logs": [{"key": "!", "url": "https://a/", "state": {"usable": {}}}]}]}`},
+		{"no state", `{"operators": [{"name": "a", "logs": [{"key": "AAAA", "url": "https://a/"}]}]}`},
+		{"two states", `{"operators": [{"name": "a", "logs": [{"key": "AAAA", "url": "https://a/",
+			"state": {"usable": {}, "retired": {}}}]}]}`},
+		{"empty interval", `{"operators": [{"name": "a", "logs": [{"key": "AAAA", "url": "https://a/",
+			"state": {"usable": {}},
+			"temporal_interval": {"start_inclusive": "2020-01-01T00:00:00Z", "end_exclusive": "2020-01-01T00:00:00Z"}}]}]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Parse([]byte(tc.list))
+			test.AssertError(t, err, "Parse accepted an invalid log list")
+		})
+	}
+
+	ll, err := Parse([]byte(`{"operators": [{"name": "a", "logs": [{"key": "AAAA", "url": "https://a/",
+		"state": {"pending": {}}}]}]}`))
+	test.AssertNotError(t, err, "parsing log list")
+	_, err = ll.Groups(0, false)
+	test.AssertError(t, err, "Groups succeeded with no usable logs")
+}
@@ -1,6 +1,9 @@
 package errors
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ErrorType provides a coarse category for BoulderErrors
 type ErrorType int
@@ -26,6 +29,14 @@ const (
 type BoulderError struct {
 	Type   ErrorType
 	Detail string
+
+	// LimitName and BucketKey identify the rate limit that was exceeded, and
+	// the key it was exceeded for, for RateLimit errors. ResetAt is when the
+	// limit will next allow the request, or the zero time if that isn't known.
+	// They are only set by RateLimitExceededError.
+	LimitName string
+	BucketKey string
+	ResetAt   time.Time
 }
 
 func (be *BoulderError) Error() string {
@@ -72,6 +83,18 @@ func RateLimitError(msg string, args ...interface{}) error {
 	}
 }
 
+// RateLimitExceededError returns a RateLimit error recording that the named
+// limit was exceeded for key, and will next allow the request at resetAt.
+// resetAt should be left as the zero time if it isn't known, rather than
+// guessed.
+func RateLimitExceededError(limitName, key string, resetAt time.Time, msg string, args ...interface{}) error {
+	err := RateLimitError(msg, args...).(*BoulderError)
+	err.LimitName = limitName
+	err.BucketKey = key
+	err.ResetAt = resetAt
+	return err
+}
+
 func RejectedIdentifierError(msg string, args ...interface{}) error {
 	return New(RejectedIdentifier, msg, args...)
 }
@@ -25,11 +25,22 @@ func _() {
 	_ = x[EnforceMultiVA-14]
 	_ = x[MultiVAFullResults-15]
 	_ = x[RemoveWFE2AccountID-16]
+	_ = x[ServeRenewalInfo-17]
+	_ = x[CertificateProfiles-18]
+	_ = x[BlockedKeyTable-19]
+	_ = x[StoreKeyHashes-20]
+	_ = x[RevokeCertsByKey-21]
+	_ = x[ListAccountOrders-22]
+	_ = x[EdDSAAccountKeys-23]
+	_ = x[EnforceMultiCAA-24]
+	_ = x[MultiCAAFullResults-25]
+	_ = x[EnforceSCTPolicy-26]
+	_ = x[NonCFSSLSigner-27]
 }
 
-const _FeatureFlag_name = "unusedPerformValidationRPCACME13KeyRolloverSimplifiedVAHTTPTLSSNIRevalidationAllowRenewalFirstRLSetIssuedNamesRenewalBitCAAValidationMethodsCAAAccountURIProbeCTLogsHeadNonceStatusOKNewAuthorizationSchemaRevokeAtRAEarlyOrderRateLimitEnforceMultiVAMultiVAFullResultsRemoveWFE2AccountID"
+const _FeatureFlag_name = "unusedPerformValidationRPCACME13KeyRolloverSimplifiedVAHTTPTLSSNIRevalidationAllowRenewalFirstRLSetIssuedNamesRenewalBitCAAValidationMethodsCAAAccountURIProbeCTLogsHeadNonceStatusOKNewAuthorizationSchemaRevokeAtRAEarlyOrderRateLimitEnforceMultiVAMultiVAFullResultsRemoveWFE2AccountIDServeRenewalInfoCertificateProfilesBlockedKeyTableStoreKeyHashesRevokeCertsByKeyListAccountOrdersEdDSAAccountKeysEnforceMultiCAAMultiCAAFullResultsEnforceSCTPolicyNonCFSSLSigner"
 
-var _FeatureFlag_index = [...]uint16{0, 6, 26, 43, 59, 77, 96, 120, 140, 153, 164, 181, 203, 213, 232, 246, 264, 283}
+var _FeatureFlag_index = [...]uint16{0, 6, 26, 43, 59, 77, 96, 120, 140, 153, 164, 181, 203, 213, 232, 246, 264, 283, 299, 318, 333, 347, 363, 380, 396, 411, 430, 446, 460}
 
 func (i FeatureFlag) String() string {
 	if i < 0 || i >= FeatureFlag(len(_FeatureFlag_index)-1) {
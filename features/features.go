@@ -45,6 +45,49 @@ const (
 	// RemoveWFE2AccountID will remove the account ID from account objects returned
 	// from the new-account endpoint if enabled.
 	RemoveWFE2AccountID
+	// ServeRenewalInfo exposes the renewalInfo endpoint in the directory and for
+	// GET requests. WARNING: This feature is a draft and highly unstable.
+	ServeRenewalInfo
+	// CertificateProfiles causes the SA to store and return the certificate
+	// profile requested for an order. It requires the
+	// orders.certificateProfileName column. Without it the RA and WFE refuse
+	// new orders that request a profile.
+	CertificateProfiles
+	// BlockedKeyTable causes the RA to add the key of any certificate revoked
+	// for keyCompromise to the blockedKeys table, and the RA and WFEs to reject
+	// keys found there.
+	BlockedKeyTable
+	// StoreKeyHashes causes the SA to record the hash of each new certificate's
+	// public key in the keyHashToSerial table, so that certificates sharing a
+	// compromised key can be found. It also causes the CA to record the hash
+	// before signing each precertificate. The hashes of certificates issued
+	// before it was enabled are recorded by key-hash-backfill.
+	StoreKeyHashes
+	// RevokeCertsByKey causes the WFE2 to treat a revocation request signed by
+	// the certificate's key as proof of key compromise, and to revoke every
+	// other certificate using that key along with it, a bounded batch of them
+	// straight away and the rest through bad-key-revoker.
+	RevokeCertsByKey
+	// ListAccountOrders causes the WFE2 to include an orders URL in account
+	// objects and to serve the list of an account's orders from it.
+	ListAccountOrders
+	// EdDSAAccountKeys allows Ed25519 keys to be used as ACME account keys
+	// in the WFE2 and RA. Ed448 keys are not supported.
+	EdDSAAccountKeys
+	// EnforceMultiCAA causes the VA to block on remote VA IsCAAValid requests
+	// in order to make a CAA decision with the results, as EnforceMultiVA does
+	// for PerformValidation.
+	EnforceMultiCAA
+	// MultiCAAFullResults will cause the main VA to wait for all of the remote
+	// VA CAA results, not just the threshold required to make a decision.
+	MultiCAAFullResults
+	// EnforceSCTPolicy causes the RA to refuse to issue a certificate for a
+	// precertificate unless its SCTs satisfy the Chrome and Apple CT policies.
+	EnforceSCTPolicy
+	// NonCFSSLSigner causes the CA to issue certificates with the issuance
+	// package, which lints each certificate before signing it, instead of
+	// CFSSL's signer.
+	NonCFSSLSigner
 )
 
 // List of features and their default value, protected by fMu
@@ -66,6 +109,17 @@ var features = map[FeatureFlag]bool{
 	EnforceMultiVA:           false,
 	MultiVAFullResults:       false,
 	RemoveWFE2AccountID:      false,
+	ServeRenewalInfo:         false,
+	CertificateProfiles:      false,
+	BlockedKeyTable:          false,
+	StoreKeyHashes:           false,
+	RevokeCertsByKey:         false,
+	ListAccountOrders:        false,
+	EdDSAAccountKeys:         false,
+	EnforceMultiCAA:          false,
+	MultiCAAFullResults:      false,
+	EnforceSCTPolicy:         false,
+	NonCFSSLSigner:           false,
 }
 
 var fMu = new(sync.RWMutex)
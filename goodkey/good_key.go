@@ -8,8 +8,12 @@ import (
 	"math/big"
 	"sync"
 
-	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/titanous/rocacheck"
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 )
 
 // To generate, run: primes 2 752 | tr '\n' ,
@@ -34,24 +38,34 @@ var (
 	smallPrimes          []*big.Int
 )
 
+// BlockedKeyCheckFunc is used to check whether a key has been blocked, e.g.
+// because it was reported as compromised. keyHash is the SHA-256 hash of the
+// key's DER encoded SubjectPublicKeyInfo, as computed by core.KeySPKIHash.
+type BlockedKeyCheckFunc func(ctx context.Context, keyHash []byte) (bool, error)
+
 // KeyPolicy determines which types of key may be used with various boulder
 // operations.
 type KeyPolicy struct {
 	AllowRSA           bool // Whether RSA keys should be allowed.
 	AllowECDSANISTP256 bool // Whether ECDSA NISTP256 keys should be allowed.
 	AllowECDSANISTP384 bool // Whether ECDSA NISTP384 keys should be allowed.
+	AllowEd25519       bool // Whether Ed25519 keys should be allowed. Only account keys can be Ed25519.
 	weakRSAList        *WeakRSAKeys
+	blockedCheck       BlockedKeyCheckFunc
 }
 
 // NewKeyPolicy returns a KeyPolicy that allows RSA, ECDSA256 and ECDSA384.
 // weakKeyFile contains the path to a JSON file containing truncated modulus
 // hashes of known weak RSA keys. If this argument is empty RSA modulus hash
-// checking will be disabled.
-func NewKeyPolicy(weakKeyFile string) (KeyPolicy, error) {
+// checking will be disabled. If bkc is non-nil it is consulted for every key
+// that otherwise passes the policy, and keys it reports as blocked are
+// rejected.
+func NewKeyPolicy(weakKeyFile string, bkc BlockedKeyCheckFunc) (KeyPolicy, error) {
 	kp := KeyPolicy{
 		AllowRSA:           true,
 		AllowECDSANISTP256: true,
 		AllowECDSANISTP384: true,
+		blockedCheck:       bkc,
 	}
 	if weakKeyFile != "" {
 		keyList, err := LoadWeakRSASuffixes(weakKeyFile)
@@ -65,21 +79,62 @@ func NewKeyPolicy(weakKeyFile string) (KeyPolicy, error) {
 
 // GoodKey returns true if the key is acceptable for both TLS use and account
 // key use (our requirements are the same for either one), according to basic
-// strength and algorithm checking.
+// strength and algorithm checking, and hasn't been blocked. Keys that don't
+// meet the policy are rejected with a berrors.Malformed error; any other error
+// means the blocked key check itself failed.
 // TODO: Support JSONWebKeys once go-jose migration is done.
-func (policy *KeyPolicy) GoodKey(key crypto.PublicKey) error {
+func (policy *KeyPolicy) GoodKey(ctx context.Context, key crypto.PublicKey) error {
+	var err error
 	switch t := key.(type) {
 	case rsa.PublicKey:
-		return policy.goodKeyRSA(t)
+		err = policy.goodKeyRSA(t)
 	case *rsa.PublicKey:
-		return policy.goodKeyRSA(*t)
+		err = policy.goodKeyRSA(*t)
 	case ecdsa.PublicKey:
-		return policy.goodKeyECDSA(t)
+		err = policy.goodKeyECDSA(t)
 	case *ecdsa.PublicKey:
-		return policy.goodKeyECDSA(*t)
+		err = policy.goodKeyECDSA(*t)
+	case ed25519.PublicKey:
+		err = policy.goodKeyEd25519(t)
 	default:
 		return berrors.MalformedError("unknown key type %T", key)
 	}
+	if err != nil {
+		return err
+	}
+	if policy.blockedCheck == nil {
+		return nil
+	}
+	// x509.MarshalPKIXPublicKey only accepts pointers.
+	switch t := key.(type) {
+	case rsa.PublicKey:
+		key = &t
+	case ecdsa.PublicKey:
+		key = &t
+	}
+	keyHash, err := core.KeySPKIHash(key)
+	if err != nil {
+		return err
+	}
+	blocked, err := policy.blockedCheck(ctx, keyHash)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return berrors.MalformedError("public key is forbidden")
+	}
+	return nil
+}
+
+// goodKeyEd25519 determines if an Ed25519 pubkey meets our requirements
+func (policy *KeyPolicy) goodKeyEd25519(key ed25519.PublicKey) error {
+	if !policy.AllowEd25519 {
+		return berrors.MalformedError("Ed25519 keys are not allowed")
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return berrors.MalformedError("Ed25519 key is %d bytes, expected %d", len(key), ed25519.PublicKeySize)
+	}
+	return nil
 }
 
 // GoodKeyECDSA determines if an ECDSA pubkey meets our requirements
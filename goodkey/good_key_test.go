@@ -5,9 +5,15 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"errors"
 	"math/big"
 	"testing"
 
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	berrors "github.com/letsencrypt/boulder/errors"
 	"github.com/letsencrypt/boulder/test"
 )
 
@@ -19,11 +25,11 @@ var testingPolicy = &KeyPolicy{
 
 func TestUnknownKeyType(t *testing.T) {
 	notAKey := struct{}{}
-	test.AssertError(t, testingPolicy.GoodKey(notAKey), "Should have rejected a key of unknown type")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), notAKey), "Should have rejected a key of unknown type")
 }
 
 func TestNilKey(t *testing.T) {
-	e := testingPolicy.GoodKey(nil)
+	e := testingPolicy.GoodKey(context.Background(), nil)
 	test.AssertError(t, e, "Should have rejected a nil key")
 	test.AssertNotContains(t, e.Error(), "%!")
 }
@@ -38,8 +44,8 @@ func TestSmallModulus(t *testing.T) {
 	if !ok {
 		t.Errorf("error parsing pubkey modulus")
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&pubKey), "Should have rejected too-short key.")
-	test.AssertError(t, testingPolicy.GoodKey(pubKey), "Should have rejected too-short key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &pubKey), "Should have rejected too-short key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), pubKey), "Should have rejected too-short key.")
 }
 
 func TestLargeModulus(t *testing.T) {
@@ -52,8 +58,8 @@ func TestLargeModulus(t *testing.T) {
 	if !ok {
 		t.Errorf("error parsing pubkey modulus")
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&pubKey), "Should have rejected too-long key.")
-	test.AssertError(t, testingPolicy.GoodKey(pubKey), "Should have rejected too-long key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &pubKey), "Should have rejected too-long key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), pubKey), "Should have rejected too-long key.")
 }
 
 func TestModulusModulo8(t *testing.T) {
@@ -62,7 +68,7 @@ func TestModulusModulo8(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2049),
 		E: 5,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected modulus with length not divisible by 8.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected modulus with length not divisible by 8.")
 }
 
 func TestSmallExponent(t *testing.T) {
@@ -71,7 +77,7 @@ func TestSmallExponent(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: 5,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected small exponent.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected small exponent.")
 }
 
 func TestEvenExponent(t *testing.T) {
@@ -80,7 +86,7 @@ func TestEvenExponent(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: 1 << 17,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected even exponent.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected even exponent.")
 }
 
 func TestEvenModulus(t *testing.T) {
@@ -89,7 +95,7 @@ func TestEvenModulus(t *testing.T) {
 		N: bigOne.Lsh(bigOne, 2048),
 		E: (1 << 17) + 1,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected even modulus.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected even modulus.")
 }
 
 func TestModulusDivisibleBy752(t *testing.T) {
@@ -101,7 +107,7 @@ func TestModulusDivisibleBy752(t *testing.T) {
 		N: N,
 		E: (1 << 17) + 1,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected modulus divisible by 751.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected modulus divisible by 751.")
 }
 
 func TestROCA(t *testing.T) {
@@ -113,21 +119,21 @@ func TestROCA(t *testing.T) {
 		N: n,
 		E: 65537,
 	}
-	test.AssertError(t, testingPolicy.GoodKey(&key), "Should have rejected ROCA-weak key.")
+	test.AssertError(t, testingPolicy.GoodKey(context.Background(), &key), "Should have rejected ROCA-weak key.")
 }
 
 func TestGoodKey(t *testing.T) {
 	private, err := rsa.GenerateKey(rand.Reader, 2048)
 	test.AssertNotError(t, err, "Error generating key")
-	test.AssertNotError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have accepted good key.")
+	test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have accepted good key.")
 }
 
 func TestECDSABadCurve(t *testing.T) {
 	for _, curve := range invalidCurves {
 		private, err := ecdsa.GenerateKey(curve, rand.Reader)
 		test.AssertNotError(t, err, "Error generating key")
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have rejected key with unsupported curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should have rejected key with unsupported curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have rejected key with unsupported curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have rejected key with unsupported curve.")
 	}
 }
 
@@ -145,8 +151,8 @@ func TestECDSAGoodKey(t *testing.T) {
 	for _, curve := range validCurves {
 		private, err := ecdsa.GenerateKey(curve, rand.Reader)
 		test.AssertNotError(t, err, "Error generating key")
-		test.AssertNotError(t, testingPolicy.GoodKey(&private.PublicKey), "Should have accepted good key.")
-		test.AssertNotError(t, testingPolicy.GoodKey(private.PublicKey), "Should have accepted good key.")
+		test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should have accepted good key.")
+		test.AssertNotError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should have accepted good key.")
 	}
 }
 
@@ -157,8 +163,8 @@ func TestECDSANotOnCurveX(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Add(private.X, big.NewInt(1))
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key not on the curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key not on the curve.")
 	}
 }
 
@@ -170,8 +176,8 @@ func TestECDSANotOnCurveY(t *testing.T) {
 
 		// Change the public key so that it is no longer on the curve.
 		private.Y.Add(private.Y, big.NewInt(1))
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key not on the curve.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key not on the curve.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key not on the curve.")
 	}
 }
 
@@ -182,14 +188,14 @@ func TestECDSANegative(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Neg(private.X)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with negative X.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with negative X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with negative X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with negative X.")
 
 		// Check that negative Y is not accepted.
 		private.X.Neg(private.X)
 		private.Y.Neg(private.Y)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with negative Y.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with negative Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with negative Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with negative Y.")
 	}
 }
 
@@ -200,8 +206,8 @@ func TestECDSANegativeUnmodulatedX(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Mul(private.X, private.Curve.Params().P)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with unmodulated X.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with unmodulated X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with unmodulated X.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with unmodulated X.")
 	}
 }
 
@@ -212,8 +218,8 @@ func TestECDSANegativeUnmodulatedY(t *testing.T) {
 		test.AssertNotError(t, err, "Error generating key")
 
 		private.X.Mul(private.Y, private.Curve.Params().P)
-		test.AssertError(t, testingPolicy.GoodKey(&private.PublicKey), "Should not have accepted key with unmodulated Y.")
-		test.AssertError(t, testingPolicy.GoodKey(private.PublicKey), "Should not have accepted key with unmodulated Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &private.PublicKey), "Should not have accepted key with unmodulated Y.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), private.PublicKey), "Should not have accepted key with unmodulated Y.")
 	}
 }
 
@@ -226,7 +232,56 @@ func TestECDSAIdentity(t *testing.T) {
 			Y:     big.NewInt(0),
 		}
 
-		test.AssertError(t, testingPolicy.GoodKey(&public), "Should not have accepted key with point at infinity.")
-		test.AssertError(t, testingPolicy.GoodKey(public), "Should not have accepted key with point at infinity.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), &public), "Should not have accepted key with point at infinity.")
+		test.AssertError(t, testingPolicy.GoodKey(context.Background(), public), "Should not have accepted key with point at infinity.")
+	}
+}
+
+func TestBlockedKey(t *testing.T) {
+	blockedKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "ecdsa.GenerateKey() failed")
+	goodKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "ecdsa.GenerateKey() failed")
+	blockedHash, err := core.KeySPKIHash(blockedKey.Public())
+	test.AssertNotError(t, err, "core.KeySPKIHash() failed")
+
+	var checkErr error
+	policy, err := NewKeyPolicy("", func(_ context.Context, keyHash []byte) (bool, error) {
+		return string(keyHash) == string(blockedHash), checkErr
+	})
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+
+	test.AssertNotError(t, policy.GoodKey(context.Background(), &goodKey.PublicKey), "Rejected a key that isn't blocked")
+	test.AssertNotError(t, policy.GoodKey(context.Background(), goodKey.PublicKey), "Rejected a key that isn't blocked")
+	err = policy.GoodKey(context.Background(), &blockedKey.PublicKey)
+	test.AssertError(t, err, "Accepted a blocked key")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Blocked key wasn't rejected with a Malformed error")
+	err = policy.GoodKey(context.Background(), blockedKey.PublicKey)
+	test.AssertError(t, err, "Accepted a blocked key")
+
+	checkErr = errors.New("database is on fire")
+	err = policy.GoodKey(context.Background(), &goodKey.PublicKey)
+	test.AssertError(t, err, "Accepted a key when the blocked key check failed")
+	test.Assert(t, !berrors.Is(err, berrors.Malformed), "Blocked key check failure was reported as a Malformed error")
+}
+
+func TestEd25519(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "ed25519.GenerateKey() failed")
+
+	err = testingPolicy.GoodKey(context.Background(), pub)
+	test.AssertError(t, err, "Accepted an Ed25519 key when they aren't allowed")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "Disallowed Ed25519 key wasn't rejected with a Malformed error")
+
+	policy := *testingPolicy
+	policy.AllowEd25519 = true
+	test.AssertNotError(t, policy.GoodKey(context.Background(), pub), "Rejected a good Ed25519 key")
+	test.AssertError(t, policy.GoodKey(context.Background(), pub[1:]), "Accepted a short Ed25519 key")
+
+	blockedHash, err := core.KeySPKIHash(pub)
+	test.AssertNotError(t, err, "core.KeySPKIHash() failed")
+	policy.blockedCheck = func(_ context.Context, keyHash []byte) (bool, error) {
+		return string(keyHash) == string(blockedHash), nil
 	}
+	test.AssertError(t, policy.GoodKey(context.Background(), pub), "Accepted a blocked Ed25519 key")
 }
@@ -0,0 +1,34 @@
+// Package sagoodkey builds goodkey.KeyPolicy objects that consult the SA's
+// blockedKeys table.
+package sagoodkey
+
+import (
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/features"
+	"github.com/letsencrypt/boulder/goodkey"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+)
+
+// blockedKeyChecker is the part of core.StorageGetter used to check whether a
+// key has been blocked.
+type blockedKeyChecker interface {
+	KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error)
+}
+
+// NewKeyPolicy returns a KeyPolicy like goodkey.NewKeyPolicy which, if the
+// BlockedKeyTable feature is enabled, also rejects keys that sa reports as
+// blocked.
+func NewKeyPolicy(weakKeyFile string, sa blockedKeyChecker) (goodkey.KeyPolicy, error) {
+	var bkc goodkey.BlockedKeyCheckFunc
+	if features.Enabled(features.BlockedKeyTable) {
+		bkc = func(ctx context.Context, keyHash []byte) (bool, error) {
+			exists, err := sa.KeyBlocked(ctx, &sapb.KeyBlockedRequest{KeyHash: keyHash})
+			if err != nil {
+				return false, err
+			}
+			return *exists.Exists, nil
+		}
+	}
+	return goodkey.NewKeyPolicy(weakKeyFile, bkc)
+}
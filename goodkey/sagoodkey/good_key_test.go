@@ -0,0 +1,46 @@
+package sagoodkey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/features"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// blockingSA reports a single key hash as blocked.
+type blockingSA struct {
+	blocked []byte
+}
+
+func (sa blockingSA) KeyBlocked(_ context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	exists := bytes.Equal(req.KeyHash, sa.blocked)
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+func TestNewKeyPolicy(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "ecdsa.GenerateKey() failed")
+	keyHash, err := core.KeySPKIHash(key.Public())
+	test.AssertNotError(t, err, "core.KeySPKIHash() failed")
+	sa := blockingSA{blocked: keyHash}
+
+	kp, err := NewKeyPolicy("", sa)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.AssertNotError(t, kp.GoodKey(context.Background(), key.Public()), "Blocked key rejected with BlockedKeyTable disabled")
+
+	err = features.Set(map[string]bool{"BlockedKeyTable": true})
+	test.AssertNotError(t, err, "Failed to set feature flags")
+	defer features.Reset()
+
+	kp, err = NewKeyPolicy("", sa)
+	test.AssertNotError(t, err, "NewKeyPolicy failed")
+	test.AssertError(t, kp.GoodKey(context.Background(), key.Public()), "Blocked key accepted with BlockedKeyTable enabled")
+}
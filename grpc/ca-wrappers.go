@@ -20,18 +20,19 @@ import (
 )
 
 // CertificateAuthorityClientWrapper is the gRPC version of a
-// core.CertificateAuthority client. It composites a CertificateAuthorityClient
-// and OCSPGeneratorClient, either of which may be nil if the calling code
-// doesn't intend to use the relevant functions. Once we've fully moved to gRPC,
-// calling code will do away with this wrapper and directly instantiate exactly
-// the type of client it needs.
+// core.CertificateAuthority client. It composites a CertificateAuthorityClient,
+// OCSPGeneratorClient and CRLGeneratorClient, any of which may be nil if the
+// calling code doesn't intend to use the relevant functions. Once we've fully
+// moved to gRPC, calling code will do away with this wrapper and directly
+// instantiate exactly the type of client it needs.
 type CertificateAuthorityClientWrapper struct {
 	inner     caPB.CertificateAuthorityClient
 	innerOCSP caPB.OCSPGeneratorClient
+	innerCRL  caPB.CRLGeneratorClient
 }
 
-func NewCertificateAuthorityClient(inner caPB.CertificateAuthorityClient, innerOCSP caPB.OCSPGeneratorClient) *CertificateAuthorityClientWrapper {
-	return &CertificateAuthorityClientWrapper{inner, innerOCSP}
+func NewCertificateAuthorityClient(inner caPB.CertificateAuthorityClient, innerOCSP caPB.OCSPGeneratorClient, innerCRL caPB.CRLGeneratorClient) *CertificateAuthorityClientWrapper {
+	return &CertificateAuthorityClientWrapper{inner, innerOCSP, innerCRL}
 }
 
 func (cac CertificateAuthorityClientWrapper) IssueCertificate(ctx context.Context, issueReq *caPB.IssueCertificateRequest) (core.Certificate, error) {
@@ -93,6 +94,22 @@ func (cac CertificateAuthorityClientWrapper) GenerateOCSP(ctx context.Context, o
 	return res.Response, nil
 }
 
+// GenerateCRL is only served by the CRLGenerator service, not by the main
+// CertificateAuthority service, so it requires a CRLGeneratorClient.
+func (cac CertificateAuthorityClientWrapper) GenerateCRL(ctx context.Context, crlReq core.CRLSigningRequest) ([]byte, error) {
+	if cac.innerCRL == nil {
+		return nil, errors.New("this CA client does not support generating CRLs")
+	}
+	res, err := cac.innerCRL.GenerateCRL(ctx, crlSigningRequestToPB(crlReq))
+	if err != nil {
+		return nil, err
+	}
+	if res.Crl == nil {
+		return nil, errIncompleteResponse
+	}
+	return res.Crl, nil
+}
+
 // CertificateAuthorityServerWrapper is the gRPC version of a core.CertificateAuthority server
 type CertificateAuthorityServerWrapper struct {
 	inner core.CertificateAuthority
@@ -147,3 +164,18 @@ func (cas *CertificateAuthorityServerWrapper) GenerateOCSP(ctx context.Context,
 	}
 	return &caPB.OCSPResponse{Response: res}, nil
 }
+
+func (cas *CertificateAuthorityServerWrapper) GenerateCRL(ctx context.Context, request *caPB.GenerateCRLRequest) (*caPB.CRLResponse, error) {
+	if request == nil || request.IssuerCommonName == nil || request.Shard == nil {
+		return nil, errIncompleteRequest
+	}
+	crlReq, err := pbToCRLSigningRequest(request)
+	if err != nil {
+		return nil, err
+	}
+	res, err := cas.inner.GenerateCRL(ctx, crlReq)
+	if err != nil {
+		return nil, err
+	}
+	return &caPB.CRLResponse{Crl: res}, nil
+}
@@ -3,6 +3,7 @@ package grpc
 import (
 	"errors"
 	"strconv"
+	"time"
 
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
@@ -30,7 +31,16 @@ func wrapError(ctx context.Context, err error) error {
 		// Ignoring the error return here is safe because if setting the metadata
 		// fails, we'll still return an error, but it will be interpreted on the
 		// other side as an InternalServerError instead of a more specific one.
-		_ = grpc.SetTrailer(ctx, metadata.Pairs("errortype", strconv.Itoa(int(berr.Type))))
+		pairs := []string{"errortype", strconv.Itoa(int(berr.Type))}
+		if berr.LimitName != "" {
+			pairs = append(pairs,
+				"ratelimit-name", berr.LimitName,
+				"ratelimit-key", berr.BucketKey)
+			if !berr.ResetAt.IsZero() {
+				pairs = append(pairs, "ratelimit-reset", strconv.FormatInt(berr.ResetAt.UnixNano(), 10))
+			}
+		}
+		_ = grpc.SetTrailer(ctx, metadata.Pairs(pairs...))
 		return grpc.Errorf(codes.Unknown, err.Error())
 	}
 	return grpc.Errorf(codes.Unknown, err.Error())
@@ -60,7 +70,31 @@ func unwrapError(err error, md metadata.MD) error {
 				unwrappedErr,
 			)
 		}
-		return berrors.New(berrors.ErrorType(errType), unwrappedErr)
+		berr := berrors.New(berrors.ErrorType(errType), unwrappedErr).(*berrors.BoulderError)
+		unwrapRateLimit(berr, md)
+		return berr
 	}
 	return err
 }
+
+// unwrapRateLimit sets the rate limit fields of berr from the metadata added
+// by wrapError, if there is any. The reset time is omitted when it isn't
+// known. Malformed values are ignored, since the error is still usable
+// without them.
+func unwrapRateLimit(berr *berrors.BoulderError, md metadata.MD) {
+	names, keys, resets := md["ratelimit-name"], md["ratelimit-key"], md["ratelimit-reset"]
+	if len(names) != 1 || len(keys) != 1 || len(resets) > 1 {
+		return
+	}
+	var resetAt time.Time
+	if len(resets) == 1 {
+		reset, err := strconv.ParseInt(resets[0], 10, 64)
+		if err != nil {
+			return
+		}
+		resetAt = time.Unix(0, reset)
+	}
+	berr.LimitName = names[0]
+	berr.BucketKey = keys[0]
+	berr.ResetAt = resetAt
+}
@@ -49,6 +49,17 @@ func TestErrorWrapping(t *testing.T) {
 	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
 	test.AssertDeepEquals(t, err, es.err)
 
+	es.err = berrors.RateLimitExceededError("certificatesPerName", "example.com", time.Unix(0, 1234), "nope")
+	_, err = client.Chill(context.Background(), &testproto.Time{})
+	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
+	test.AssertDeepEquals(t, err, es.err)
+
+	// An unknown reset time stays unknown.
+	es.err = berrors.RateLimitExceededError("certificatesPerName", "example.com", time.Time{}, "nope")
+	_, err = client.Chill(context.Background(), &testproto.Time{})
+	test.Assert(t, err != nil, fmt.Sprintf("nil error returned, expected: %s", err))
+	test.AssertDeepEquals(t, err, es.err)
+
 	test.AssertEquals(t, wrapError(nil, nil), nil)
 	test.AssertEquals(t, unwrapError(nil, nil), nil)
 }
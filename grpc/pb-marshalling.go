@@ -12,9 +12,11 @@ import (
 	"google.golang.org/grpc/codes"
 	"gopkg.in/square/go-jose.v2"
 
+	caPB "github.com/letsencrypt/boulder/ca/proto"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/revocation"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 )
 
@@ -198,7 +200,7 @@ func PBToValidationRecord(in *corepb.ValidationRecord) (record core.ValidationRe
 	}, nil
 }
 
-func ValidationResultToPB(records []core.ValidationRecord, prob *probs.ProblemDetails) (*vapb.ValidationResult, error) {
+func ValidationResultToPB(records []core.ValidationRecord, prob *probs.ProblemDetails, iodef []string) (*vapb.ValidationResult, error) {
 	recordAry := make([]*corepb.ValidationRecord, len(records))
 	var err error
 	for i, v := range records {
@@ -214,26 +216,27 @@ func ValidationResultToPB(records []core.ValidationRecord, prob *probs.ProblemDe
 	return &vapb.ValidationResult{
 		Records:  recordAry,
 		Problems: marshalledProbs,
+		Iodef:    iodef,
 	}, nil
 }
 
-func pbToValidationResult(in *vapb.ValidationResult) ([]core.ValidationRecord, *probs.ProblemDetails, error) {
+func pbToValidationResult(in *vapb.ValidationResult) ([]core.ValidationRecord, *probs.ProblemDetails, []string, error) {
 	if in == nil {
-		return nil, nil, ErrMissingParameters
+		return nil, nil, nil, ErrMissingParameters
 	}
 	recordAry := make([]core.ValidationRecord, len(in.Records))
 	var err error
 	for i, v := range in.Records {
 		recordAry[i], err = PBToValidationRecord(v)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 	prob, err := PBToProblemDetails(in.Problems)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	return recordAry, prob, nil
+	return recordAry, prob, in.Iodef, nil
 }
 
 func performValidationReqToArgs(in *vapb.PerformValidationRequest) (domain string, challenge core.Challenge, authz core.Authorization, err error) {
@@ -295,14 +298,15 @@ func registrationToPB(reg core.Registration) (*corepb.Registration, error) {
 		contacts = *reg.Contact
 	}
 	return &corepb.Registration{
-		Id:              &reg.ID,
-		Key:             keyBytes,
-		Contact:         contacts,
-		ContactsPresent: &contactsPresent,
-		Agreement:       &reg.Agreement,
-		InitialIP:       ipBytes,
-		CreatedAt:       &createdAt,
-		Status:          &status,
+		Id:                   &reg.ID,
+		Key:                  keyBytes,
+		Contact:              contacts,
+		ContactsPresent:      &contactsPresent,
+		Agreement:            &reg.Agreement,
+		InitialIP:            ipBytes,
+		CreatedAt:            &createdAt,
+		Status:               &status,
+		ExternalAccountKeyID: &reg.ExternalAccountKeyID,
 	}, nil
 }
 
@@ -331,14 +335,19 @@ func pbToRegistration(pb *corepb.Registration) (core.Registration, error) {
 			contacts = &empty
 		}
 	}
+	var externalAccountKeyID string
+	if pb.ExternalAccountKeyID != nil {
+		externalAccountKeyID = *pb.ExternalAccountKeyID
+	}
 	return core.Registration{
-		ID:        *pb.Id,
-		Key:       &key,
-		Contact:   contacts,
-		Agreement: *pb.Agreement,
-		InitialIP: initialIP,
-		CreatedAt: time.Unix(0, *pb.CreatedAt),
-		Status:    core.AcmeStatus(*pb.Status),
+		ID:                   *pb.Id,
+		Key:                  &key,
+		Contact:              contacts,
+		Agreement:            *pb.Agreement,
+		InitialIP:            initialIP,
+		CreatedAt:            time.Unix(0, *pb.CreatedAt),
+		Status:               core.AcmeStatus(*pb.Status),
+		ExternalAccountKeyID: externalAccountKeyID,
 	}, nil
 }
 
@@ -382,7 +391,7 @@ func PBToAuthz(pb *corepb.Authorization) (core.Authorization, error) {
 		v2 = *pb.V2
 	}
 	authz := core.Authorization{
-		Identifier:     core.AcmeIdentifier{Type: core.IdentifierDNS, Value: *pb.Identifier},
+		Identifier:     core.IdentifierForName(*pb.Identifier),
 		RegistrationID: *pb.RegistrationID,
 		Status:         core.AcmeStatus(*pb.Status),
 		Expires:        &expires,
@@ -446,3 +455,46 @@ func pbToCert(pb *corepb.Certificate) (core.Certificate, error) {
 		Expires:        time.Unix(0, *pb.Expires),
 	}, nil
 }
+
+func crlSigningRequestToPB(req core.CRLSigningRequest) *caPB.GenerateCRLRequest {
+	entries := make([]*caPB.CRLEntry, len(req.Entries))
+	for i, entry := range req.Entries {
+		serial := entry.Serial
+		reason := int32(entry.Reason)
+		revokedAt := entry.RevokedAt.UnixNano()
+		entries[i] = &caPB.CRLEntry{
+			Serial:    &serial,
+			Reason:    &reason,
+			RevokedAt: &revokedAt,
+		}
+	}
+	return &caPB.GenerateCRLRequest{
+		IssuerCommonName:  &req.IssuerCommonName,
+		Shard:             &req.Shard,
+		DistributionPoint: &req.DistributionPoint,
+		Entries:           entries,
+	}
+}
+
+func pbToCRLSigningRequest(pb *caPB.GenerateCRLRequest) (core.CRLSigningRequest, error) {
+	if pb == nil || pb.IssuerCommonName == nil || pb.Shard == nil {
+		return core.CRLSigningRequest{}, ErrMissingParameters
+	}
+	entries := make([]core.CRLEntry, len(pb.Entries))
+	for i, entry := range pb.Entries {
+		if entry == nil || entry.Serial == nil || entry.Reason == nil || entry.RevokedAt == nil {
+			return core.CRLSigningRequest{}, ErrMissingParameters
+		}
+		entries[i] = core.CRLEntry{
+			Serial:    *entry.Serial,
+			Reason:    revocation.Reason(*entry.Reason),
+			RevokedAt: time.Unix(0, *entry.RevokedAt),
+		}
+	}
+	return core.CRLSigningRequest{
+		IssuerCommonName:  *pb.IssuerCommonName,
+		Shard:             *pb.Shard,
+		DistributionPoint: pb.GetDistributionPoint(),
+		Entries:           entries,
+	}, nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/letsencrypt/boulder/revocation"
 	"github.com/letsencrypt/boulder/test"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 )
@@ -187,14 +188,17 @@ func TestValidationResult(t *testing.T) {
 	result := []core.ValidationRecord{vrA, vrB}
 	prob := &probs.ProblemDetails{Type: probs.TLSProblem, Detail: "asd", HTTPStatus: 200}
 
-	pb, err := ValidationResultToPB(result, prob)
+	iodef := []string{"mailto:security@example.com"}
+
+	pb, err := ValidationResultToPB(result, prob, iodef)
 	test.AssertNotError(t, err, "ValidationResultToPB failed")
 	test.Assert(t, pb != nil, "Returned vapb.ValidationResult is nil")
 
-	reconResult, reconProb, err := pbToValidationResult(pb)
+	reconResult, reconProb, reconIodef, err := pbToValidationResult(pb)
 	test.AssertNotError(t, err, "pbToValidationResult failed")
 	test.AssertDeepEquals(t, reconResult, result)
 	test.AssertDeepEquals(t, reconProb, prob)
+	test.AssertDeepEquals(t, reconIodef, iodef)
 }
 
 func TestPerformValidationReq(t *testing.T) {
@@ -241,6 +245,8 @@ func TestRegistration(t *testing.T) {
 		InitialIP: net.ParseIP("1.1.1.1"),
 		CreatedAt: time.Now().Round(0),
 		Status:    core.StatusValid,
+
+		ExternalAccountKeyID: "some-key",
 	}
 	pbReg, err := registrationToPB(inReg)
 	test.AssertNotError(t, err, "registrationToPB failed")
@@ -315,6 +321,27 @@ func TestCert(t *testing.T) {
 	test.AssertDeepEquals(t, cert, outCert)
 }
 
+func TestCRLSigningRequest(t *testing.T) {
+	now := time.Now().Round(0)
+	req := core.CRLSigningRequest{
+		IssuerCommonName:  "issuer",
+		Shard:             2,
+		DistributionPoint: "http://crl.example.com/2.crl",
+		Entries: []core.CRLEntry{
+			{Serial: "serial", Reason: revocation.KeyCompromise, RevokedAt: now},
+		},
+	}
+
+	outReq, err := pbToCRLSigningRequest(crlSigningRequestToPB(req))
+	test.AssertNotError(t, err, "pbToCRLSigningRequest failed")
+	test.AssertDeepEquals(t, req, outReq)
+
+	reqPB := crlSigningRequestToPB(req)
+	reqPB.Entries[0].Serial = nil
+	_, err = pbToCRLSigningRequest(reqPB)
+	test.AssertEquals(t, err, ErrMissingParameters)
+}
+
 func TestOrderValid(t *testing.T) {
 	testID := int64(1)
 	testExpires := int64(1)
@@ -187,6 +187,28 @@ func (ras *RegistrationAuthorityClientWrapper) FinalizeOrder(ctx context.Context
 	return resp, nil
 }
 
+func (ras *RegistrationAuthorityClientWrapper) RevokeCertByKey(ctx context.Context, request *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error) {
+	resp, err := ras.inner.RevokeCertByKey(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (ras *RegistrationAuthorityClientWrapper) GetRateLimitUsage(ctx context.Context, request *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	resp, err := ras.inner.GetRateLimitUsage(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
 // RegistrationAuthorityServerWrapper is the gRPC version of a core.RegistrationAuthority server
 type RegistrationAuthorityServerWrapper struct {
 	inner core.RegistrationAuthority
@@ -343,3 +365,17 @@ func (ras *RegistrationAuthorityServerWrapper) FinalizeOrder(ctx context.Context
 
 	return ras.inner.FinalizeOrder(ctx, request)
 }
+
+func (ras *RegistrationAuthorityServerWrapper) RevokeCertByKey(ctx context.Context, request *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error) {
+	if request == nil || request.Cert == nil {
+		return nil, errIncompleteRequest
+	}
+	return ras.inner.RevokeCertByKey(ctx, request)
+}
+
+func (ras *RegistrationAuthorityServerWrapper) GetRateLimitUsage(ctx context.Context, request *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	if request == nil || (request.RegistrationID == nil && request.Domain == nil) {
+		return nil, errIncompleteRequest
+	}
+	return ras.inner.GetRateLimitUsage(ctx, request)
+}
@@ -584,6 +584,86 @@ func (sas StorageAuthorityClientWrapper) RevokeCertificate(ctx context.Context,
 	return err
 }
 
+func (sas StorageAuthorityClientWrapper) GetExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) (*sapb.ExternalAccountKey, error) {
+	resp, err := sas.inner.GetExternalAccountKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.KeyID == nil || resp.HmacKey == nil || resp.CreatedAt == nil || resp.RetiredAt == nil || resp.RegistrationID == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sas StorageAuthorityClientWrapper) AddExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKey) error {
+	_, err := sas.inner.AddExternalAccountKey(ctx, req)
+	return err
+}
+
+func (sas StorageAuthorityClientWrapper) RetireExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) error {
+	_, err := sas.inner.RetireExternalAccountKey(ctx, req)
+	return err
+}
+
+func (sas StorageAuthorityClientWrapper) KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	resp, err := sas.inner.KeyBlocked(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil || resp.Exists == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sas StorageAuthorityClientWrapper) GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	resp, err := sas.inner.GetSerialsByKey(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sas StorageAuthorityClientWrapper) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	resp, err := sas.inner.GetOrdersForAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	return resp, nil
+}
+
+func (sas StorageAuthorityClientWrapper) GetCertificatesForAccount(ctx context.Context, req *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error) {
+	resp, err := sas.inner.GetCertificatesForAccount(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		return nil, errIncompleteResponse
+	}
+	for _, ac := range resp.Certificates {
+		if ac == nil || ac.Id == nil || ac.Status == nil || ac.Certificate == nil || ac.Certificate.Serial == nil {
+			return nil, errIncompleteResponse
+		}
+	}
+	return resp, nil
+}
+
+func (sas StorageAuthorityClientWrapper) AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) error {
+	_, err := sas.inner.AddBlockedKey(ctx, req)
+	return err
+}
+
+func (sas StorageAuthorityClientWrapper) AddKeyHash(ctx context.Context, req *sapb.AddKeyHashRequest) error {
+	_, err := sas.inner.AddKeyHash(ctx, req)
+	return err
+}
+
 // StorageAuthorityServerWrapper is the gRPC version of a core.ServerAuthority server
 type StorageAuthorityServerWrapper struct {
 	// TODO(#3119): Don't use core.StorageAuthority
@@ -1110,3 +1190,66 @@ func (sas StorageAuthorityServerWrapper) RevokeCertificate(ctx context.Context,
 	}
 	return &corepb.Empty{}, sas.inner.RevokeCertificate(ctx, req)
 }
+
+func (sas StorageAuthorityServerWrapper) GetExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) (*sapb.ExternalAccountKey, error) {
+	if req == nil || req.KeyID == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetExternalAccountKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKey) (*corepb.Empty, error) {
+	if req == nil || req.KeyID == nil || req.HmacKey == nil {
+		return nil, errIncompleteRequest
+	}
+	return &corepb.Empty{}, sas.inner.AddExternalAccountKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) RetireExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) (*corepb.Empty, error) {
+	if req == nil || req.KeyID == nil {
+		return nil, errIncompleteRequest
+	}
+	return &corepb.Empty{}, sas.inner.RetireExternalAccountKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	if req == nil || req.KeyHash == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.KeyBlocked(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	if req == nil || req.KeyHash == nil || req.ValidAfter == nil || req.Limit == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetSerialsByKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	if req == nil || req.RegistrationID == nil || req.Before == nil || req.Limit == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetOrdersForAccount(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) GetCertificatesForAccount(ctx context.Context, req *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error) {
+	if req == nil || req.RegistrationID == nil || req.Before == nil || req.Limit == nil {
+		return nil, errIncompleteRequest
+	}
+	return sas.inner.GetCertificatesForAccount(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) (*corepb.Empty, error) {
+	if req == nil || req.KeyHash == nil || req.Added == nil || req.Source == nil {
+		return nil, errIncompleteRequest
+	}
+	return &corepb.Empty{}, sas.inner.AddBlockedKey(ctx, req)
+}
+
+func (sas StorageAuthorityServerWrapper) AddKeyHash(ctx context.Context, req *sapb.AddKeyHashRequest) (*corepb.Empty, error) {
+	if req == nil || req.KeyHash == nil || req.Serial == nil || req.CertNotAfter == nil {
+		return nil, errIncompleteRequest
+	}
+	return &corepb.Empty{}, sas.inner.AddKeyHash(ctx, req)
+}
@@ -24,7 +24,7 @@ func (s *ValidationAuthorityGRPCServer) PerformValidation(ctx context.Context, i
 	if err != nil {
 		return nil, err
 	}
-	records, err := s.impl.PerformValidation(ctx, domain, challenge, authz)
+	records, iodef, err := s.impl.PerformValidation(ctx, domain, challenge, authz)
 	// If the type of error was a ProblemDetails, we need to return
 	// both that and the records to the caller (so it can update
 	// the challenge / authz in the SA with the failing records).
@@ -35,7 +35,7 @@ func (s *ValidationAuthorityGRPCServer) PerformValidation(ctx context.Context, i
 	if !ok && err != nil {
 		return nil, err
 	}
-	return ValidationResultToPB(records, prob)
+	return ValidationResultToPB(records, prob, iodef)
 }
 
 func RegisterValidationAuthorityGRPCServer(s *ggrpc.Server, impl core.ValidationAuthority) error {
@@ -45,33 +45,41 @@ func RegisterValidationAuthorityGRPCServer(s *ggrpc.Server, impl core.Validation
 }
 
 type ValidationAuthorityGRPCClient struct {
-	gc vaPB.VAClient
+	gc  vaPB.VAClient
+	caa vaPB.CAAClient
 }
 
-func NewValidationAuthorityGRPCClient(cc *ggrpc.ClientConn) core.ValidationAuthority {
-	return &ValidationAuthorityGRPCClient{vaPB.NewVAClient(cc)}
+// NewValidationAuthorityGRPCClient returns a client for both the VA and CAA
+// services of the VA at the other end of cc.
+func NewValidationAuthorityGRPCClient(cc *ggrpc.ClientConn) *ValidationAuthorityGRPCClient {
+	return &ValidationAuthorityGRPCClient{vaPB.NewVAClient(cc), vaPB.NewCAAClient(cc)}
+}
+
+// IsCAAValid has the VA check CAA for the requested domain.
+func (vac ValidationAuthorityGRPCClient) IsCAAValid(ctx context.Context, req *vaPB.IsCAAValidRequest) (*vaPB.IsCAAValidResponse, error) {
+	return vac.caa.IsCAAValid(ctx, req)
 }
 
 // PerformValidation has the VA revalidate the specified challenge and returns
 // the updated Challenge object.
-func (vac ValidationAuthorityGRPCClient) PerformValidation(ctx context.Context, domain string, challenge core.Challenge, authz core.Authorization) ([]core.ValidationRecord, error) {
+func (vac ValidationAuthorityGRPCClient) PerformValidation(ctx context.Context, domain string, challenge core.Challenge, authz core.Authorization) ([]core.ValidationRecord, []string, error) {
 	req, err := argsToPerformValidationRequest(domain, challenge, authz)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	gRecords, err := vac.gc.PerformValidation(ctx, req)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	records, prob, err := pbToValidationResult(gRecords)
+	records, prob, iodef, err := pbToValidationResult(gRecords)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if prob != nil {
-		return records, prob
+		return records, iodef, prob
 	}
 
 	// We return nil explicitly to avoid "typed nil" problems.
 	// https://golang.org/doc/faq#nil_error
-	return records, nil
+	return records, nil, nil
 }
@@ -0,0 +1,575 @@
+// Package issuance builds and signs end-entity certificates and
+// precertificates with crypto/x509, from a typed profile rather than CFSSL's
+// signing profiles. Every certificate is linted, with a throwaway key, before
+// the issuer's key signs it.
+package issuance
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	ctx509 "github.com/google/certificate-transparency-go/x509"
+	"github.com/jmhodges/clock"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/linter"
+)
+
+var (
+	oidCertificatePolicies = asn1.ObjectIdentifier{2, 5, 29, 32}
+	oidTLSFeature          = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 1, 24}
+	oidCTPoison            = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+	oidSCTList             = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 2}
+
+	// Policy qualifier types, named as they were in CFSSL signing profiles.
+	policyQualifierTypes = map[string]asn1.ObjectIdentifier{
+		"id-qt-cps":     {1, 3, 6, 1, 5, 5, 7, 2, 1},
+		"id-qt-unotice": {1, 3, 6, 1, 5, 5, 7, 2, 2},
+	}
+
+	// The TLS Feature extension with the "must staple" value (RFC 7633).
+	mustStapleExtension = pkix.Extension{
+		Id:    oidTLSFeature,
+		Value: []byte{0x30, 0x03, 0x02, 0x01, 0x05},
+	}
+
+	// The precertificate poison extension (RFC 6962 Section 3.1).
+	ctPoisonExtension = pkix.Extension{
+		Id:       oidCTPoison,
+		Critical: true,
+		Value:    asn1.NullBytes,
+	}
+)
+
+// PolicyQualifier is a qualifier of a certificate policy. Type is either
+// "id-qt-cps", in which case Value is the URL of a CPS, or "id-qt-unotice",
+// in which case Value is the explicit text of a user notice.
+type PolicyQualifier struct {
+	Type  string
+	Value string
+}
+
+// PolicyInformation is a certificate policy, identified by an OID in dotted
+// decimal form, along with its qualifiers.
+type PolicyInformation struct {
+	OID        string
+	Qualifiers []PolicyQualifier
+}
+
+// ProfileConfig describes the certificates issued under a profile. The
+// validity period and Must Staple policy of each certificate are chosen by
+// the caller, within the limits set here.
+type ProfileConfig struct {
+	// IssuerURL is included in the Authority Information Access extension
+	// as the location of the issuer certificate.
+	IssuerURL string
+	// OCSPURL is included in the Authority Information Access extension as
+	// the location of the OCSP responder.
+	OCSPURL string
+	// CRLURL, if set, is included as a CRL distribution point.
+	CRLURL string
+	// Policies are included in the certificate policies extension.
+	Policies []PolicyInformation
+
+	// MaxValidityPeriod is the longest validity period a certificate may
+	// have.
+	MaxValidityPeriod cmd.ConfigDuration
+	// MaxValidityBackdate is the furthest in the past a certificate's
+	// NotBefore may be.
+	MaxValidityBackdate cmd.ConfigDuration
+}
+
+// Profile is a validated ProfileConfig.
+type Profile struct {
+	issuerURL   string
+	ocspURL     string
+	crlURL      string
+	policies    *pkix.Extension
+	maxValidity time.Duration
+	maxBackdate time.Duration
+}
+
+type policyQualifierInfo struct {
+	ID        asn1.ObjectIdentifier
+	Qualifier asn1.RawValue
+}
+
+type policyInformation struct {
+	Policy     asn1.ObjectIdentifier
+	Qualifiers []policyQualifierInfo `asn1:"optional"`
+}
+
+type userNotice struct {
+	ExplicitText string `asn1:"utf8"`
+}
+
+// parseOID parses an OID in dotted decimal form.
+func parseOID(s string) (asn1.ObjectIdentifier, error) {
+	var oid asn1.ObjectIdentifier
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid OID %q", s)
+		}
+		oid = append(oid, n)
+	}
+	if len(oid) < 2 {
+		return nil, fmt.Errorf("invalid OID %q", s)
+	}
+	return oid, nil
+}
+
+// policiesExtension builds the certificate policies extension.
+func policiesExtension(policies []PolicyInformation) (*pkix.Extension, error) {
+	var infos []policyInformation
+	for _, p := range policies {
+		oid, err := parseOID(p.OID)
+		if err != nil {
+			return nil, err
+		}
+		info := policyInformation{Policy: oid}
+		for _, q := range p.Qualifiers {
+			qualifierOID, ok := policyQualifierTypes[q.Type]
+			if !ok {
+				return nil, fmt.Errorf("unsupported policy qualifier type %q", q.Type)
+			}
+			var value []byte
+			if q.Type == "id-qt-cps" {
+				value, err = asn1.MarshalWithParams(q.Value, "ia5")
+			} else {
+				value, err = asn1.Marshal(userNotice{ExplicitText: q.Value})
+			}
+			if err != nil {
+				return nil, err
+			}
+			info.Qualifiers = append(info.Qualifiers, policyQualifierInfo{
+				ID:        qualifierOID,
+				Qualifier: asn1.RawValue{FullBytes: value},
+			})
+		}
+		infos = append(infos, info)
+	}
+	value, err := asn1.Marshal(infos)
+	if err != nil {
+		return nil, err
+	}
+	return &pkix.Extension{Id: oidCertificatePolicies, Value: value}, nil
+}
+
+// NewProfile validates a ProfileConfig and returns the Profile it describes.
+func NewProfile(pc ProfileConfig) (*Profile, error) {
+	if pc.IssuerURL == "" {
+		return nil, errors.New("issuer URL is required")
+	}
+	if pc.OCSPURL == "" {
+		return nil, errors.New("OCSP URL is required")
+	}
+	if len(pc.Policies) == 0 {
+		return nil, errors.New("at least one certificate policy is required")
+	}
+	if pc.MaxValidityPeriod.Duration <= 0 {
+		return nil, errors.New("max validity period must be positive")
+	}
+	if pc.MaxValidityBackdate.Duration < 0 {
+		return nil, errors.New("max validity backdate must not be negative")
+	}
+	policies, err := policiesExtension(pc.Policies)
+	if err != nil {
+		return nil, err
+	}
+	return &Profile{
+		issuerURL:   pc.IssuerURL,
+		ocspURL:     pc.OCSPURL,
+		crlURL:      pc.CRLURL,
+		policies:    policies,
+		maxValidity: pc.MaxValidityPeriod.Duration,
+		maxBackdate: pc.MaxValidityBackdate.Duration,
+	}, nil
+}
+
+// IssuanceRequest describes a certificate or precertificate to be issued.
+type IssuanceRequest struct {
+	PublicKey crypto.PublicKey
+	Serial    *big.Int
+
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	CommonName string
+	// SubjectSerialNumber, if set, is included in the subject's serialNumber
+	// attribute.
+	SubjectSerialNumber string
+	DNSNames            []string
+	IPAddresses         []net.IP
+
+	IncludeMustStaple bool
+	// IncludeCTPoison makes the certificate a precertificate. It can't be
+	// combined with SCTList.
+	IncludeCTPoison bool
+	// SCTList, if not empty, is embedded in the certificate.
+	SCTList []ct.SignedCertificateTimestamp
+
+	// precertTBS, set by RequestFromPrecert, is the to-be-signed certificate
+	// of the precertificate the certificate must correspond to.
+	precertTBS []byte
+}
+
+// Issuer issues certificates signed by an issuer certificate's key, under a
+// single profile.
+type Issuer struct {
+	cert    *x509.Certificate
+	signer  crypto.Signer
+	sigAlg  x509.SignatureAlgorithm
+	profile *Profile
+	linter  *linter.Linter
+	clk     clock.Clock
+}
+
+// NewIssuer returns an Issuer that signs certificates with signer, which may
+// be any crypto.Signer, such as a PKCS#11 key, whose public key is that of
+// cert. Each certificate must pass the lints in registry to be signed.
+func NewIssuer(cert *x509.Certificate, signer crypto.Signer, profile *Profile, registry *linter.Registry, clk clock.Clock) (*Issuer, error) {
+	if cert == nil || signer == nil || profile == nil || registry == nil {
+		return nil, errors.New("issuer certificate, signer, profile and lint registry are required")
+	}
+	var sigAlg x509.SignatureAlgorithm
+	switch k := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		sigAlg = x509.SHA256WithRSA
+	case *ecdsa.PublicKey:
+		switch k.Curve {
+		case elliptic.P256():
+			sigAlg = x509.ECDSAWithSHA256
+		case elliptic.P384():
+			sigAlg = x509.ECDSAWithSHA384
+		default:
+			return nil, fmt.Errorf("unsupported ECDSA curve %s", k.Curve.Params().Name)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported issuer key type %T", cert.PublicKey)
+	}
+	if !samePublicKey(cert.PublicKey, signer.Public()) {
+		return nil, errors.New("signer's public key doesn't match the issuer certificate")
+	}
+	l, err := linter.New(cert, registry)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		cert:    cert,
+		signer:  signer,
+		sigAlg:  sigAlg,
+		profile: profile,
+		linter:  l,
+		clk:     clk,
+	}, nil
+}
+
+// samePublicKey returns true if a and b are the same RSA or ECDSA public key.
+// The keys are compared by their components, since a PKCS#11 signer's ECDSA
+// key may use a curve's CurveParams rather than the curve itself.
+func samePublicKey(a, b crypto.PublicKey) bool {
+	switch ak := a.(type) {
+	case *rsa.PublicKey:
+		bk, ok := b.(*rsa.PublicKey)
+		return ok && ak.N.Cmp(bk.N) == 0 && ak.E == bk.E
+	case *ecdsa.PublicKey:
+		bk, ok := b.(*ecdsa.PublicKey)
+		return ok && ak.Curve.Params().Name == bk.Curve.Params().Name &&
+			ak.X.Cmp(bk.X) == 0 && ak.Y.Cmp(bk.Y) == 0
+	}
+	return false
+}
+
+// Cert returns the issuer certificate.
+func (i *Issuer) Cert() *x509.Certificate {
+	return i.cert
+}
+
+// subjectKeyID returns the key identifier of pub, computed as in RFC 5280
+// Section 4.2.1.2 method (1).
+func subjectKeyID(pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	var spki struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	_, err = asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, err
+	}
+	skid := sha1.Sum(spki.PublicKey.Bytes)
+	return skid[:], nil
+}
+
+// sctListExtension builds the embedded SCT list extension (RFC 6962 Section
+// 3.3).
+func sctListExtension(scts []ct.SignedCertificateTimestamp) (pkix.Extension, error) {
+	var list ctx509.SignedCertificateTimestampList
+	for _, sct := range scts {
+		b, err := cttls.Marshal(sct)
+		if err != nil {
+			return pkix.Extension{}, err
+		}
+		list.SCTList = append(list.SCTList, ctx509.SerializedSCT{Val: b})
+	}
+	b, err := cttls.Marshal(list)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	value, err := asn1.Marshal(b)
+	if err != nil {
+		return pkix.Extension{}, err
+	}
+	return pkix.Extension{Id: oidSCTList, Value: value}, nil
+}
+
+// checkRequest returns an error if req can't be issued under the issuer's
+// profile.
+func (i *Issuer) checkRequest(req *IssuanceRequest) error {
+	switch req.PublicKey.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+	default:
+		return fmt.Errorf("unsupported public key type %T", req.PublicKey)
+	}
+	if req.Serial == nil || req.Serial.Sign() <= 0 {
+		return errors.New("serial must be positive")
+	}
+	if len(req.DNSNames) == 0 && len(req.IPAddresses) == 0 {
+		return errors.New("at least one DNS name or IP address is required")
+	}
+	if !req.NotBefore.Before(req.NotAfter) {
+		return errors.New("NotAfter must be after NotBefore")
+	}
+	if req.NotAfter.Sub(req.NotBefore) > i.profile.maxValidity {
+		return fmt.Errorf("validity period %s is longer than the maximum %s",
+			req.NotAfter.Sub(req.NotBefore), i.profile.maxValidity)
+	}
+	now := i.clk.Now()
+	if req.NotBefore.After(now) {
+		return errors.New("NotBefore is in the future")
+	}
+	if now.Sub(req.NotBefore) > i.profile.maxBackdate {
+		return fmt.Errorf("NotBefore is backdated more than the maximum %s", i.profile.maxBackdate)
+	}
+	if req.NotAfter.After(i.cert.NotAfter) {
+		return errors.New("NotAfter is after the issuer certificate's NotAfter")
+	}
+	if req.IncludeCTPoison && len(req.SCTList) > 0 {
+		return errors.New("a precertificate can't contain SCTs")
+	}
+	return nil
+}
+
+// template builds the certificate template for req.
+func (i *Issuer) template(req *IssuanceRequest) (*x509.Certificate, error) {
+	skid, err := subjectKeyID(req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	keyUsage := x509.KeyUsageDigitalSignature
+	if _, ok := req.PublicKey.(*rsa.PublicKey); ok {
+		keyUsage |= x509.KeyUsageKeyEncipherment
+	}
+	template := &x509.Certificate{
+		SerialNumber:          req.Serial,
+		SignatureAlgorithm:    i.sigAlg,
+		NotBefore:             req.NotBefore,
+		NotAfter:              req.NotAfter,
+		Subject:               pkix.Name{CommonName: req.CommonName, SerialNumber: req.SubjectSerialNumber},
+		DNSNames:              req.DNSNames,
+		IPAddresses:           req.IPAddresses,
+		KeyUsage:              keyUsage,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  false,
+		SubjectKeyId:          skid,
+		OCSPServer:            []string{i.profile.ocspURL},
+		IssuingCertificateURL: []string{i.profile.issuerURL},
+		ExtraExtensions:       []pkix.Extension{*i.profile.policies},
+	}
+	if i.profile.crlURL != "" {
+		template.CRLDistributionPoints = []string{i.profile.crlURL}
+	}
+	if req.IncludeMustStaple {
+		template.ExtraExtensions = append(template.ExtraExtensions, mustStapleExtension)
+	}
+	// The poison and SCT list extensions go last, so that a certificate
+	// issued for a precertificate differs from it only in that extension.
+	if req.IncludeCTPoison {
+		template.ExtraExtensions = append(template.ExtraExtensions, ctPoisonExtension)
+	} else if len(req.SCTList) > 0 {
+		sctList, err := sctListExtension(req.SCTList)
+		if err != nil {
+			return nil, err
+		}
+		template.ExtraExtensions = append(template.ExtraExtensions, sctList)
+	}
+	return template, nil
+}
+
+// Issue lints and then signs the certificate described by req, returning its
+// DER. Nothing is signed with the issuer's key if any lint fails.
+func (i *Issuer) Issue(req *IssuanceRequest) ([]byte, error) {
+	err := i.checkRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	template, err := i.template(req)
+	if err != nil {
+		return nil, err
+	}
+	lintTBS, err := i.linter.Check(template, req.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if req.precertTBS != nil {
+		err = CheckPrecertificateMatch(req.precertTBS, lintTBS)
+		if err != nil {
+			return nil, err
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, i.cert, req.PublicKey, i.signer)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	// The linted certificate must be the one that was signed.
+	if !bytes.Equal(cert.RawTBSCertificate, lintTBS) {
+		return nil, errors.New("signed certificate differs from linted certificate")
+	}
+	return der, nil
+}
+
+// RequestFromPrecert returns the request for the certificate corresponding
+// to precert, a precertificate this issuer issued, with scts embedded. The
+// certificate is built from the issuer's current profile, so Issue refuses to
+// sign it unless it differs from the precertificate only in having the SCT
+// list extension in place of the poison extension.
+func (i *Issuer) RequestFromPrecert(precert *x509.Certificate, scts []ct.SignedCertificateTimestamp) (*IssuanceRequest, error) {
+	err := precert.CheckSignatureFrom(i.cert)
+	if err != nil {
+		return nil, fmt.Errorf("precertificate wasn't issued by this issuer: %s", err)
+	}
+	req := &IssuanceRequest{
+		PublicKey:           precert.PublicKey,
+		Serial:              precert.SerialNumber,
+		NotBefore:           precert.NotBefore,
+		NotAfter:            precert.NotAfter,
+		CommonName:          precert.Subject.CommonName,
+		SubjectSerialNumber: precert.Subject.SerialNumber,
+		DNSNames:            precert.DNSNames,
+		IPAddresses:         precert.IPAddresses,
+		SCTList:             scts,
+		precertTBS:          precert.RawTBSCertificate,
+	}
+	var poisoned bool
+	for _, ext := range precert.Extensions {
+		switch {
+		case ext.Id.Equal(oidTLSFeature):
+			req.IncludeMustStaple = true
+		case ext.Id.Equal(oidCTPoison):
+			poisoned = true
+		}
+	}
+	if !poisoned {
+		return nil, errors.New("certificate isn't a precertificate")
+	}
+	return req, nil
+}
+
+// CheckPrecertificateMatch returns an error unless certTBS, the to-be-signed
+// certificate for a precertificate, is identical to precertTBS, the
+// precertificate's, once the SCT list extension is removed from the former
+// and the poison extension from the latter. Signing a certificate that
+// doesn't match its precertificate is misissuance.
+func CheckPrecertificateMatch(precertTBS, certTBS []byte) error {
+	precertStripped, err := tbsWithoutExtension(precertTBS, oidCTPoison)
+	if err != nil {
+		return fmt.Errorf("parsing precertificate: %s", err)
+	}
+	certStripped, err := tbsWithoutExtension(certTBS, oidSCTList)
+	if err != nil {
+		return fmt.Errorf("parsing certificate: %s", err)
+	}
+	if !bytes.Equal(precertStripped, certStripped) {
+		return errors.New("certificate doesn't match its precertificate")
+	}
+	return nil
+}
+
+// tbsWithoutExtension returns tbs, a DER to-be-signed certificate, with the
+// extension identified by oid removed, if present. The other fields and
+// extensions are kept exactly as they were encoded.
+func tbsWithoutExtension(tbs []byte, oid asn1.ObjectIdentifier) ([]byte, error) {
+	var seq asn1.RawValue
+	rest, err := asn1.Unmarshal(tbs, &seq)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 || seq.Tag != asn1.TagSequence {
+		return nil, errors.New("malformed to-be-signed certificate")
+	}
+	var fields []byte
+	for body := seq.Bytes; len(body) > 0; {
+		var field asn1.RawValue
+		body, err = asn1.Unmarshal(body, &field)
+		if err != nil {
+			return nil, err
+		}
+		// The extensions are explicitly tagged [3].
+		if field.Class != asn1.ClassContextSpecific || field.Tag != 3 {
+			fields = append(fields, field.FullBytes...)
+			continue
+		}
+		var exts []asn1.RawValue
+		_, err = asn1.Unmarshal(field.Bytes, &exts)
+		if err != nil {
+			return nil, err
+		}
+		var kept []byte
+		for _, raw := range exts {
+			var ext pkix.Extension
+			_, err = asn1.Unmarshal(raw.FullBytes, &ext)
+			if err != nil {
+				return nil, err
+			}
+			if !ext.Id.Equal(oid) {
+				kept = append(kept, raw.FullBytes...)
+			}
+		}
+		extSeq, err := asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: kept})
+		if err != nil {
+			return nil, err
+		}
+		extField, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 3, IsCompound: true, Bytes: extSeq})
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, extField...)
+	}
+	return asn1.Marshal(asn1.RawValue{Tag: asn1.TagSequence, IsCompound: true, Bytes: fields})
+}
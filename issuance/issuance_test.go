@@ -0,0 +1,407 @@
+package issuance
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	ct "github.com/google/certificate-transparency-go"
+	cttls "github.com/google/certificate-transparency-go/tls"
+	"github.com/jmhodges/clock"
+	"github.com/miekg/pkcs11"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/linter"
+	"github.com/letsencrypt/boulder/pkcs11helpers"
+	"github.com/letsencrypt/boulder/test"
+)
+
+var defaultProfileConfig = ProfileConfig{
+	IssuerURL: "http://issuer-url",
+	OCSPURL:   "http://ocsp-url",
+	CRLURL:    "http://crl-url",
+	Policies: []PolicyInformation{
+		{OID: "2.23.140.1.2.1"},
+		{OID: "1.2.3.4", Qualifiers: []PolicyQualifier{
+			{Type: "id-qt-cps", Value: "http://example.com/cps"},
+			{Type: "id-qt-unotice", Value: "Do What Thou Wilt"},
+		}},
+	},
+	MaxValidityPeriod:   cmd.ConfigDuration{Duration: 90 * 24 * time.Hour},
+	MaxValidityBackdate: cmd.ConfigDuration{Duration: time.Hour},
+}
+
+// makeIssuer returns an issuer certificate for signer, valid for a year from
+// clk's current time.
+func makeIssuer(t *testing.T, signer crypto.Signer, clk clock.Clock) *x509.Certificate {
+	t.Helper()
+	skid, err := subjectKeyID(signer.Public())
+	test.AssertNotError(t, err, "computing issuer key ID")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer", Organization: []string{"Boulder"}, Country: []string{"US"}},
+		NotBefore:             clk.Now().Add(-time.Hour),
+		NotAfter:              clk.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          skid,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, signer.Public(), signer)
+	test.AssertNotError(t, err, "creating issuer certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issuer certificate")
+	return cert
+}
+
+// newTestIssuer returns an Issuer for signer under the default profile, which
+// ignores the named lints.
+func newTestIssuer(t *testing.T, signer crypto.Signer, clk clock.Clock, ignoredLints ...string) *Issuer {
+	t.Helper()
+	profile, err := NewProfile(defaultProfileConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	registry, err := linter.NewRegistry(ignoredLints)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	issuer, err := NewIssuer(makeIssuer(t, signer, clk), signer, profile, registry, clk)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	return issuer
+}
+
+func newRequest(t *testing.T, clk clock.Clock) *IssuanceRequest {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating subscriber key")
+	return &IssuanceRequest{
+		PublicKey:   k.Public(),
+		Serial:      big.NewInt(0x1234),
+		NotBefore:   clk.Now().Add(-time.Minute),
+		NotAfter:    clk.Now().Add(90*24*time.Hour - time.Minute),
+		CommonName:  "example.com",
+		DNSNames:    []string{"example.com", "www.example.com"},
+		IPAddresses: []net.IP{net.ParseIP("93.184.216.34")},
+	}
+}
+
+func hasExtension(cert *x509.Certificate, oid asn1.ObjectIdentifier) bool {
+	for _, ext := range cert.Extensions {
+		if ext.Id.Equal(oid) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestIssue(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "generating RSA issuer key")
+	p256Key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating P-256 issuer key")
+	p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	test.AssertNotError(t, err, "generating P-384 issuer key")
+
+	for _, tc := range []struct {
+		name   string
+		signer crypto.Signer
+		sigAlg x509.SignatureAlgorithm
+	}{
+		{"RSA", rsaKey, x509.SHA256WithRSA},
+		{"P-256", p256Key, x509.ECDSAWithSHA256},
+		{"P-384", p384Key, x509.ECDSAWithSHA384},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			issuer := newTestIssuer(t, tc.signer, clk)
+			req := newRequest(t, clk)
+			req.IncludeMustStaple = true
+			der, err := issuer.Issue(req)
+			test.AssertNotError(t, err, "Issue failed")
+			cert, err := x509.ParseCertificate(der)
+			test.AssertNotError(t, err, "parsing issued certificate")
+			test.AssertNotError(t, cert.CheckSignatureFrom(issuer.Cert()), "signature doesn't verify")
+
+			test.AssertEquals(t, cert.SignatureAlgorithm, tc.sigAlg)
+			test.AssertEquals(t, cert.SerialNumber.Cmp(req.Serial), 0)
+			test.AssertEquals(t, cert.Subject.CommonName, "example.com")
+			test.AssertDeepEquals(t, cert.DNSNames, req.DNSNames)
+			test.AssertEquals(t, len(cert.IPAddresses), 1)
+			test.AssertEquals(t, cert.KeyUsage, x509.KeyUsageDigitalSignature)
+			test.AssertDeepEquals(t, cert.ExtKeyUsage, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth})
+			test.Assert(t, cert.BasicConstraintsValid && !cert.IsCA, "certificate isn't an end-entity certificate")
+			test.AssertDeepEquals(t, cert.AuthorityKeyId, issuer.Cert().SubjectKeyId)
+			test.AssertDeepEquals(t, cert.OCSPServer, []string{"http://ocsp-url"})
+			test.AssertDeepEquals(t, cert.IssuingCertificateURL, []string{"http://issuer-url"})
+			test.AssertDeepEquals(t, cert.CRLDistributionPoints, []string{"http://crl-url"})
+			test.Assert(t, hasExtension(cert, oidTLSFeature), "Must Staple extension missing")
+			test.Assert(t, !hasExtension(cert, oidCTPoison), "certificate has the poison extension")
+
+			var policies []policyInformation
+			for _, ext := range cert.Extensions {
+				if ext.Id.Equal(oidCertificatePolicies) {
+					_, err = asn1.Unmarshal(ext.Value, &policies)
+					test.AssertNotError(t, err, "parsing certificate policies")
+				}
+			}
+			test.AssertEquals(t, len(policies), 2)
+			test.AssertEquals(t, policies[1].Policy.String(), "1.2.3.4")
+			test.AssertEquals(t, len(policies[1].Qualifiers), 2)
+			var cps string
+			_, err = asn1.Unmarshal(policies[1].Qualifiers[0].Qualifier.FullBytes, &cps)
+			test.AssertNotError(t, err, "parsing CPS qualifier")
+			test.AssertEquals(t, cps, "http://example.com/cps")
+		})
+	}
+}
+
+func TestIssuePrecertAndCertificate(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	issuer := newTestIssuer(t, k, clk)
+
+	req := newRequest(t, clk)
+	req.IncludeCTPoison = true
+	precertDER, err := issuer.Issue(req)
+	test.AssertNotError(t, err, "issuing precertificate")
+	precert, err := x509.ParseCertificate(precertDER)
+	test.AssertNotError(t, err, "parsing precertificate")
+	test.Assert(t, hasExtension(precert, oidCTPoison), "precertificate is missing the poison extension")
+
+	scts := []ct.SignedCertificateTimestamp{{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: [32]byte{1}},
+		Timestamp:  1234,
+		Signature: ct.DigitallySigned{
+			Algorithm: cttls.SignatureAndHashAlgorithm{Hash: cttls.SHA256, Signature: cttls.ECDSA},
+			Signature: []byte{1, 2, 3},
+		},
+	}}
+	certReq, err := issuer.RequestFromPrecert(precert, scts)
+	test.AssertNotError(t, err, "RequestFromPrecert failed")
+	// Some time passes between issuing the precertificate and the certificate.
+	clk.Add(time.Minute)
+	certDER, err := issuer.Issue(certReq)
+	test.AssertNotError(t, err, "issuing certificate")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "parsing certificate")
+	test.Assert(t, !hasExtension(cert, oidCTPoison), "certificate has the poison extension")
+	test.Assert(t, hasExtension(cert, oidSCTList), "certificate is missing the SCT list extension")
+	test.AssertEquals(t, cert.SerialNumber.Cmp(precert.SerialNumber), 0)
+	test.AssertEquals(t, cert.NotBefore, precert.NotBefore)
+	test.AssertDeepEquals(t, cert.DNSNames, precert.DNSNames)
+	test.AssertEquals(t, len(cert.Extensions), len(precert.Extensions))
+
+	_, err = issuer.RequestFromPrecert(cert, scts)
+	test.AssertError(t, err, "RequestFromPrecert accepted a certificate")
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating other issuer key")
+	other := newTestIssuer(t, otherKey, clk)
+	_, err = other.RequestFromPrecert(precert, scts)
+	test.AssertError(t, err, "RequestFromPrecert accepted another issuer's precertificate")
+
+	// A certificate built from a profile changed since the precertificate was
+	// issued doesn't match it, and isn't signed.
+	changedConfig := defaultProfileConfig
+	changedConfig.OCSPURL = "http://other-ocsp-url"
+	changedProfile, err := NewProfile(changedConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	registry, err := linter.NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	changed, err := NewIssuer(issuer.cert, k, changedProfile, registry, clk)
+	test.AssertNotError(t, err, "NewIssuer failed")
+	certReq, err = changed.RequestFromPrecert(precert, scts)
+	test.AssertNotError(t, err, "RequestFromPrecert failed")
+	_, err = changed.Issue(certReq)
+	test.AssertError(t, err, "Issue signed a certificate not matching its precertificate")
+	test.AssertEquals(t, err.Error(), "certificate doesn't match its precertificate")
+}
+
+func TestIssueLintFailure(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	signed := false
+	signer := &recordingSigner{k, &signed}
+	issuer := newTestIssuer(t, signer, clk)
+	signed = false
+
+	req := newRequest(t, clk)
+	// A name longer than the 64 characters allowed in a common name.
+	req.CommonName = strings.Repeat("a", 40) + "." + strings.Repeat("b", 40) + ".com"
+	req.DNSNames = []string{req.CommonName}
+	_, err = issuer.Issue(req)
+	test.AssertError(t, err, "Issue signed a certificate that fails linting")
+	test.Assert(t, strings.Contains(err.Error(), "e_subject_common_name_max_length"), "error doesn't name the failed lint")
+	test.Assert(t, !signed, "issuer key signed a certificate that fails linting")
+
+	issuer = newTestIssuer(t, signer, clk, "e_subject_common_name_max_length")
+	_, err = issuer.Issue(req)
+	test.AssertNotError(t, err, "Issue failed for a certificate failing only ignored lints")
+	test.Assert(t, signed, "issuer key didn't sign the certificate")
+}
+
+// recordingSigner records whether it has been asked to sign anything.
+type recordingSigner struct {
+	crypto.Signer
+	signed *bool
+}
+
+func (s *recordingSigner) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	*s.signed = true
+	return s.Signer.Sign(rand, digest, opts)
+}
+
+func TestIssueBadRequests(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	issuer := newTestIssuer(t, k, clk)
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*IssuanceRequest)
+	}{
+		{"unsupported key", func(r *IssuanceRequest) { r.PublicKey = "key" }},
+		{"no serial", func(r *IssuanceRequest) { r.Serial = nil }},
+		{"no names", func(r *IssuanceRequest) { r.DNSNames, r.IPAddresses = nil, nil }},
+		{"NotAfter before NotBefore", func(r *IssuanceRequest) { r.NotAfter = r.NotBefore.Add(-time.Second) }},
+		{"too long", func(r *IssuanceRequest) { r.NotAfter = r.NotBefore.Add(91 * 24 * time.Hour) }},
+		{"future NotBefore", func(r *IssuanceRequest) { r.NotBefore = clk.Now().Add(time.Second) }},
+		{"backdated too far", func(r *IssuanceRequest) {
+			r.NotBefore = clk.Now().Add(-2 * time.Hour)
+			r.NotAfter = r.NotBefore.Add(time.Hour)
+		}},
+		{"outlives issuer", func(r *IssuanceRequest) {
+			clk.Add(300 * 24 * time.Hour)
+			r.NotBefore = clk.Now()
+			r.NotAfter = clk.Now().Add(89 * 24 * time.Hour)
+		}},
+		{"poison and SCTs", func(r *IssuanceRequest) {
+			r.IncludeCTPoison = true
+			r.SCTList = []ct.SignedCertificateTimestamp{{}}
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+			req := newRequest(t, clk)
+			tc.modify(req)
+			_, err := issuer.Issue(req)
+			test.AssertError(t, err, "Issue accepted a bad request")
+		})
+	}
+}
+
+func TestNewProfile(t *testing.T) {
+	_, err := NewProfile(defaultProfileConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+
+	for _, tc := range []struct {
+		name   string
+		modify func(*ProfileConfig)
+	}{
+		{"no issuer URL", func(pc *ProfileConfig) { pc.IssuerURL = "" }},
+		{"no OCSP URL", func(pc *ProfileConfig) { pc.OCSPURL = "" }},
+		{"no policies", func(pc *ProfileConfig) { pc.Policies = nil }},
+		{"bad OID", func(pc *ProfileConfig) { pc.Policies = []PolicyInformation{{OID: "1.two.3"}} }},
+		{"bad qualifier", func(pc *ProfileConfig) {
+			pc.Policies = []PolicyInformation{{OID: "1.2.3", Qualifiers: []PolicyQualifier{{Type: "id-qt-other"}}}}
+		}},
+		{"no validity", func(pc *ProfileConfig) { pc.MaxValidityPeriod.Duration = 0 }},
+		{"negative backdate", func(pc *ProfileConfig) { pc.MaxValidityBackdate.Duration = -time.Hour }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pc := defaultProfileConfig
+			tc.modify(&pc)
+			_, err := NewProfile(pc)
+			test.AssertError(t, err, "NewProfile accepted a bad config")
+		})
+	}
+}
+
+func TestNewIssuerKeyMismatch(t *testing.T) {
+	clk := clock.NewFake()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating other key")
+	profile, err := NewProfile(defaultProfileConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	registry, err := linter.NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	_, err = NewIssuer(makeIssuer(t, k, clk), other, profile, registry, clk)
+	test.AssertError(t, err, "NewIssuer accepted a signer for another key")
+}
+
+func TestIssuePKCS11(t *testing.T) {
+	clk := clock.NewFake()
+	clk.Set(time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC))
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating issuer key")
+
+	ctx := pkcs11helpers.MockCtx{}
+	ctx.FindObjectsInitFunc = func(pkcs11.SessionHandle, []*pkcs11.Attribute) error { return nil }
+	ctx.FindObjectsFunc = func(pkcs11.SessionHandle, int) ([]pkcs11.ObjectHandle, bool, error) {
+		return []pkcs11.ObjectHandle{1}, false, nil
+	}
+	ctx.FindObjectsFinalFunc = func(pkcs11.SessionHandle) error { return nil }
+	ctx.GetAttributeValueFunc = func(_ pkcs11.SessionHandle, _ pkcs11.ObjectHandle, attrs []*pkcs11.Attribute) ([]*pkcs11.Attribute, error) {
+		var returns []*pkcs11.Attribute
+		for _, attr := range attrs {
+			switch attr.Type {
+			case pkcs11.CKA_KEY_TYPE:
+				returns = append(returns, pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, []byte{3, 0, 0, 0, 0, 0, 0, 0}))
+			case pkcs11.CKA_EC_PARAMS:
+				returns = append(returns, pkcs11.NewAttribute(pkcs11.CKA_EC_PARAMS, []byte{0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07}))
+			case pkcs11.CKA_EC_POINT:
+				returns = append(returns, pkcs11.NewAttribute(pkcs11.CKA_EC_POINT, elliptic.Marshal(elliptic.P256(), k.X, k.Y)))
+			default:
+				return nil, errors.New("GetAttributeValue got unexpected attribute type")
+			}
+		}
+		return returns, nil
+	}
+	ctx.SignInitFunc = func(pkcs11.SessionHandle, []*pkcs11.Mechanism, pkcs11.ObjectHandle) error { return nil }
+	ctx.SignFunc = func(_ pkcs11.SessionHandle, digest []byte) ([]byte, error) {
+		r, s, err := ecdsa.Sign(rand.Reader, k, digest)
+		if err != nil {
+			return nil, err
+		}
+		// PKCS#11 ECDSA signatures are r and s, each padded to the size of
+		// the curve.
+		sig := make([]byte, 64)
+		rBytes, sBytes := r.Bytes(), s.Bytes()
+		copy(sig[32-len(rBytes):32], rBytes)
+		copy(sig[64-len(sBytes):], sBytes)
+		return sig, nil
+	}
+	signer, err := pkcs11helpers.GetSigner(ctx, 0, "label", "ffff")
+	test.AssertNotError(t, err, "GetSigner failed")
+
+	profile, err := NewProfile(defaultProfileConfig)
+	test.AssertNotError(t, err, "NewProfile failed")
+	registry, err := linter.NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	issuer, err := NewIssuer(makeIssuer(t, k, clk), signer, profile, registry, clk)
+	test.AssertNotError(t, err, "NewIssuer failed with a PKCS#11 signer")
+	der, err := issuer.Issue(newRequest(t, clk))
+	test.AssertNotError(t, err, "Issue failed with a PKCS#11 signer")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issued certificate")
+	test.AssertNotError(t, cert.CheckSignatureFrom(issuer.Cert()), "signature doesn't verify")
+}
@@ -0,0 +1,199 @@
+// Package linter runs zlint's certificate lints, along with any other lints
+// registered with zlint, over certificates. It is used by the CA to lint each
+// certificate before signing it, by signing it first with a throwaway key,
+// and by cert-checker to lint certificates that have already been issued.
+package linter
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"sort"
+	"strings"
+
+	zx509 "github.com/zmap/zcrypto/x509"
+	"github.com/zmap/zlint/lints"
+
+	// Importing zlint registers its lints.
+	_ "github.com/zmap/zlint"
+)
+
+// Registry is the set of registered lints, less any that are ignored.
+type Registry struct {
+	lints []*lints.Lint
+}
+
+// NewRegistry returns a Registry of all lints registered with zlint, except
+// those named in ignored. It returns an error if ignored names a lint that
+// isn't registered, so that a misspelled name doesn't go unnoticed.
+func NewRegistry(ignored []string) (*Registry, error) {
+	skip := make(map[string]bool, len(ignored))
+	for _, name := range ignored {
+		if lints.Lints[name] == nil {
+			return nil, fmt.Errorf("unknown lint %q", name)
+		}
+		skip[name] = true
+	}
+	r := &Registry{}
+	for name, l := range lints.Lints {
+		if !skip[name] {
+			r.lints = append(r.lints, l)
+		}
+	}
+	sort.Slice(r.lints, func(i, j int) bool { return r.lints[i].Name < r.lints[j].Name })
+	return r, nil
+}
+
+// Failure describes a lint that found an error in a certificate.
+type Failure struct {
+	Lint    string
+	Status  lints.LintStatus
+	Details string
+}
+
+func (f Failure) String() string {
+	s := fmt.Sprintf("zlint %s: %s", f.Status, f.Lint)
+	if f.Details != "" {
+		s = fmt.Sprintf("%s %s", s, f.Details)
+	}
+	return s
+}
+
+// Check runs the registry's lints over cert and returns a Failure, in lint
+// name order, for each that found an error. Notices and warnings are ignored.
+func (r *Registry) Check(cert *zx509.Certificate) []Failure {
+	var failures []Failure
+	for _, l := range r.lints {
+		res := l.Execute(cert)
+		if res.Status >= lints.Error {
+			failures = append(failures, Failure{
+				Lint:    l.Name,
+				Status:  res.Status,
+				Details: res.Details,
+			})
+		}
+	}
+	return failures
+}
+
+// Linter signs certificates with a throwaway key, on behalf of a copy of an
+// issuer certificate holding that key, so that they can be linted before the
+// issuer's key signs anything.
+type Linter struct {
+	signer   crypto.Signer
+	issuer   *x509.Certificate
+	registry *Registry
+}
+
+// New returns a Linter with a throwaway key of the same type as issuer's key,
+// so that the certificates it signs have the same signature algorithm, issuer
+// name and authority key identifier as those issuer signs.
+func New(issuer *x509.Certificate, registry *Registry) (*Linter, error) {
+	var signer crypto.Signer
+	var err error
+	switch k := issuer.PublicKey.(type) {
+	case *rsa.PublicKey:
+		signer, err = rsa.GenerateKey(rand.Reader, 2048)
+	case *ecdsa.PublicKey:
+		signer, err = ecdsa.GenerateKey(k.Curve, rand.Reader)
+	default:
+		return nil, fmt.Errorf("unsupported issuer key type %T", issuer.PublicKey)
+	}
+	if err != nil {
+		return nil, err
+	}
+	lintIssuer := *issuer
+	lintIssuer.PublicKey = signer.Public()
+	return &Linter{
+		signer:   signer,
+		issuer:   &lintIssuer,
+		registry: registry,
+	}, nil
+}
+
+// Signer returns the throwaway key, for signers that sign certificates
+// themselves rather than through Check.
+func (l *Linter) Signer() crypto.Signer {
+	return l.signer
+}
+
+// Issuer returns the copy of the issuer certificate holding the throwaway
+// key.
+func (l *Linter) Issuer() *x509.Certificate {
+	return l.issuer
+}
+
+// hashes maps the signature algorithms an issuer may use to the hash each
+// signs.
+var hashes = map[x509.SignatureAlgorithm]crypto.Hash{
+	x509.SHA256WithRSA:   crypto.SHA256,
+	x509.ECDSAWithSHA256: crypto.SHA256,
+	x509.ECDSAWithSHA384: crypto.SHA384,
+}
+
+// Resign returns der, a certificate signed by the issuer, signed instead with
+// the throwaway key. It is for signers that only sign certificates derived
+// from ones they signed themselves, such as a certificate for a
+// precertificate.
+func (l *Linter) Resign(der []byte) ([]byte, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	hash, ok := hashes[cert.SignatureAlgorithm]
+	if !ok {
+		return nil, fmt.Errorf("unsupported signature algorithm %s", cert.SignatureAlgorithm)
+	}
+	var raw struct {
+		TBSCertificate     asn1.RawValue
+		SignatureAlgorithm pkix.AlgorithmIdentifier
+		Signature          asn1.BitString
+	}
+	_, err = asn1.Unmarshal(der, &raw)
+	if err != nil {
+		return nil, err
+	}
+	h := hash.New()
+	h.Write(cert.RawTBSCertificate)
+	sig, err := l.signer.Sign(rand.Reader, h.Sum(nil), hash)
+	if err != nil {
+		return nil, err
+	}
+	raw.Signature = asn1.BitString{Bytes: sig, BitLength: 8 * len(sig)}
+	return asn1.Marshal(raw)
+}
+
+// Check signs template with the throwaway key and lints the result. It
+// returns the to-be-signed certificate that was linted, which the caller
+// should compare to the one it signs with the issuer's key.
+func (l *Linter) Check(template *x509.Certificate, pub crypto.PublicKey) ([]byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, template, l.issuer, pub, l.signer)
+	if err != nil {
+		return nil, err
+	}
+	return l.CheckSigned(der)
+}
+
+// CheckSigned lints der, a certificate signed with the throwaway key. It
+// returns an error naming the lints that failed, if any found an error, and
+// otherwise the to-be-signed certificate that was linted.
+func (l *Linter) CheckSigned(der []byte) ([]byte, error) {
+	cert, err := zx509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	failures := l.registry.Check(cert)
+	if len(failures) > 0 {
+		var names []string
+		for _, f := range failures {
+			names = append(names, f.Lint)
+		}
+		return nil, fmt.Errorf("certificate failed lints: %s", strings.Join(names, ", "))
+	}
+	return cert.RawTBSCertificate, nil
+}
@@ -0,0 +1,149 @@
+package linter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	zx509 "github.com/zmap/zcrypto/x509"
+
+	"github.com/letsencrypt/boulder/test"
+)
+
+var notBefore = time.Date(2019, 6, 1, 0, 0, 0, 0, time.UTC)
+
+func makeIssuer(t *testing.T) (*x509.Certificate, *rsa.PrivateKey) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "generating issuer key")
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "issuer", Organization: []string{"Boulder"}, Country: []string{"US"}},
+		NotBefore:             notBefore.Add(-time.Hour),
+		NotAfter:              notBefore.Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          []byte{1, 2, 3},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, k.Public(), k)
+	test.AssertNotError(t, err, "creating issuer certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing issuer certificate")
+	return cert, k
+}
+
+// template returns a subscriber certificate template that passes linting
+// unless ocsp is false, in which case it lacks an OCSP URL and fails
+// e_sub_cert_aia_does_not_contain_ocsp_url.
+func template(ocsp bool) *x509.Certificate {
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1234),
+		SignatureAlgorithm:    x509.SHA256WithRSA,
+		Subject:               pkix.Name{CommonName: "example.com"},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(90 * 24 * time.Hour),
+		DNSNames:              []string{"example.com"},
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		SubjectKeyId:          []byte{4, 5, 6},
+		IssuingCertificateURL: []string{"http://issuer-url"},
+		PolicyIdentifiers:     []asn1.ObjectIdentifier{{2, 23, 140, 1, 2, 1}},
+	}
+	if ocsp {
+		tmpl.OCSPServer = []string{"http://ocsp-url"}
+	}
+	return tmpl
+}
+
+func subscriberKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "generating subscriber key")
+	return k
+}
+
+func TestNewRegistry(t *testing.T) {
+	all, err := NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	some, err := NewRegistry([]string{"e_sub_cert_aia_does_not_contain_ocsp_url"})
+	test.AssertNotError(t, err, "NewRegistry failed with an ignored lint")
+	test.AssertEquals(t, len(some.lints), len(all.lints)-1)
+
+	_, err = NewRegistry([]string{"e_no_such_lint"})
+	test.AssertError(t, err, "NewRegistry accepted an unknown lint")
+}
+
+func TestCheck(t *testing.T) {
+	issuer, issuerKey := makeIssuer(t)
+	registry, err := NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	l, err := New(issuer, registry)
+	test.AssertNotError(t, err, "New failed")
+	pub := subscriberKey(t).Public()
+
+	// The linted certificate is the one the issuer's key would sign.
+	lintTBS, err := l.Check(template(true), pub)
+	test.AssertNotError(t, err, "Check failed for a good certificate")
+	der, err := x509.CreateCertificate(rand.Reader, template(true), issuer, pub, issuerKey)
+	test.AssertNotError(t, err, "signing certificate")
+	cert, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing certificate")
+	test.AssertByteEquals(t, cert.RawTBSCertificate, lintTBS)
+
+	_, err = l.Check(template(false), pub)
+	test.AssertError(t, err, "Check passed a certificate failing a lint")
+	test.Assert(t, strings.Contains(err.Error(), "e_sub_cert_aia_does_not_contain_ocsp_url"), "error doesn't name the failed lint")
+
+	registry, err = NewRegistry([]string{"e_sub_cert_aia_does_not_contain_ocsp_url"})
+	test.AssertNotError(t, err, "NewRegistry failed")
+	l, err = New(issuer, registry)
+	test.AssertNotError(t, err, "New failed")
+	_, err = l.Check(template(false), pub)
+	test.AssertNotError(t, err, "Check failed for a certificate failing only ignored lints")
+}
+
+func TestRegistryCheck(t *testing.T) {
+	issuer, issuerKey := makeIssuer(t)
+	registry, err := NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	der, err := x509.CreateCertificate(rand.Reader, template(false), issuer, subscriberKey(t).Public(), issuerKey)
+	test.AssertNotError(t, err, "signing certificate")
+	cert, err := zx509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing certificate")
+
+	failures := registry.Check(cert)
+	test.AssertEquals(t, len(failures), 1)
+	test.AssertEquals(t, failures[0].Lint, "e_sub_cert_aia_does_not_contain_ocsp_url")
+	test.AssertEquals(t, failures[0].String(), "zlint error: e_sub_cert_aia_does_not_contain_ocsp_url")
+}
+
+func TestResign(t *testing.T) {
+	issuer, issuerKey := makeIssuer(t)
+	registry, err := NewRegistry(nil)
+	test.AssertNotError(t, err, "NewRegistry failed")
+	l, err := New(issuer, registry)
+	test.AssertNotError(t, err, "New failed")
+
+	der, err := x509.CreateCertificate(rand.Reader, template(true), issuer, subscriberKey(t).Public(), issuerKey)
+	test.AssertNotError(t, err, "signing certificate")
+	resigned, err := l.Resign(der)
+	test.AssertNotError(t, err, "Resign failed")
+
+	orig, err := x509.ParseCertificate(der)
+	test.AssertNotError(t, err, "parsing certificate")
+	cert, err := x509.ParseCertificate(resigned)
+	test.AssertNotError(t, err, "parsing re-signed certificate")
+	test.AssertByteEquals(t, cert.RawTBSCertificate, orig.RawTBSCertificate)
+	test.AssertNotError(t, cert.CheckSignatureFrom(l.Issuer()), "re-signed certificate isn't signed by the throwaway key")
+	test.AssertError(t, cert.CheckSignatureFrom(issuer), "re-signed certificate is still signed by the issuer")
+}
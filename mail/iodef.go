@@ -0,0 +1,320 @@
+package mail
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"github.com/weppos/publicsuffix-go/publicsuffix"
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+)
+
+// maxIodefTargets is the most targets each report is sent to. Any further
+// iodef targets are ignored.
+const maxIodefTargets = 3
+
+// maxReportedDomains is the most registered domains an IodefReporter
+// remembers reporting in each interval. Once it is reached no more reports
+// are sent until the oldest expire.
+const maxReportedDomains = 100000
+
+// IodefReporter reports refusals to issue because of CAA to the targets of
+// the domain's iodef records (RFC 8659 Section 4.4). mailto targets are sent
+// an email and http and https targets an HTTP POST, each containing a plain
+// text report rather than an IODEF document. Since anyone can publish iodef
+// records naming any target, and any number of subdomains with them, at most
+// one report is sent per registered domain in each interval, to at most
+// maxIodefTargets targets, and HTTP requests are only made to public IP
+// addresses. Reports are queued and sent by a fixed number of workers, and
+// dropped if the queue is full. It is safe for concurrent use.
+type IodefReporter struct {
+	// mailerMu serializes use of mailer, which isn't safe for concurrent
+	// access.
+	mailerMu sync.Mutex
+	mailer   Mailer
+	client   *http.Client
+	log      blog.Logger
+	clk      clock.Clock
+	interval time.Duration
+
+	// queue holds reports waiting for a worker. closedMu guards against
+	// sending on it once Close has closed it.
+	queue    chan iodefReport
+	workers  sync.WaitGroup
+	closedMu sync.RWMutex
+	closed   bool
+
+	// reported holds when each registered domain was last reported, and
+	// reportOrder the same domains in the order they were reported, so that
+	// expired entries can be dropped without scanning them all.
+	reportedMu  sync.Mutex
+	reported    map[string]time.Time
+	reportOrder []string
+}
+
+// iodefReport is a report waiting to be sent to its targets.
+type iodefReport struct {
+	domain  string
+	subject string
+	body    string
+	targets []string
+}
+
+// NewIodefReporter returns an IodefReporter that sends emails with mailer and
+// HTTP requests bounded by timeout, sending at most one report per registered
+// domain in each interval. Up to queueSize reports wait to be sent by the
+// given number of workers, which are started straight away.
+func NewIodefReporter(
+	mailer Mailer,
+	timeout time.Duration,
+	interval time.Duration,
+	queueSize int,
+	workers int,
+	clk clock.Clock,
+	logger blog.Logger) *IodefReporter {
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: refuseNonPublic,
+	}
+	r := &IodefReporter{
+		mailer: mailer,
+		client: &http.Client{
+			Transport: &http.Transport{DialContext: dialer.DialContext},
+			Timeout:   timeout,
+			// Redirects are refused so that a report can't be bounced to a
+			// different target than the one named in the CAA record.
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		log:      logger,
+		clk:      clk,
+		interval: interval,
+		queue:    make(chan iodefReport, queueSize),
+		reported: make(map[string]time.Time),
+	}
+	for i := 0; i < workers; i++ {
+		r.workers.Add(1)
+		go r.work()
+	}
+	return r
+}
+
+// work sends queued reports until the queue is closed.
+func (r *IodefReporter) work() {
+	defer r.workers.Done()
+	for report := range r.queue {
+		_ = r.deliver(context.Background(), report)
+	}
+}
+
+// Close stops accepting reports and waits for those already queued to be
+// sent.
+func (r *IodefReporter) Close() {
+	r.closedMu.Lock()
+	if !r.closed {
+		r.closed = true
+		close(r.queue)
+	}
+	r.closedMu.Unlock()
+	r.workers.Wait()
+}
+
+// nonPublicNetworks are the networks, other than those recognized by the
+// net.IP methods, that iodef HTTP reports are refused for.
+var nonPublicNetworks = []*net.IPNet{
+	// RFC 1122 Section 3.2.1.3
+	mustParseCIDR("0.0.0.0/8"),
+	// RFC 1918
+	mustParseCIDR("10.0.0.0/8"),
+	mustParseCIDR("172.16.0.0/12"),
+	mustParseCIDR("192.168.0.0/16"),
+	// RFC 6598
+	mustParseCIDR("100.64.0.0/10"),
+	// RFC 2544
+	mustParseCIDR("198.18.0.0/15"),
+	// RFC 1112
+	mustParseCIDR("240.0.0.0/4"),
+	// RFC 4193
+	mustParseCIDR("fc00::/7"),
+	// RFC 6052, NAT64 translation of IPv4 addresses, which may be private.
+	mustParseCIDR("64:ff9b::/96"),
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, ipNet, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return ipNet
+}
+
+// refuseNonPublic is a net.Dialer Control function that refuses to connect to
+// loopback, private and other non-public addresses, so that iodef records
+// can't be used to make requests to our internal network.
+func refuseNonPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() {
+		return fmt.Errorf("refusing to send iodef report to non-public address %s", host)
+	}
+	for _, ipNet := range nonPublicNetworks {
+		if ipNet.Contains(ip) {
+			return fmt.Errorf("refusing to send iodef report to non-public address %s", host)
+		}
+	}
+	return nil
+}
+
+// registeredDomain returns the domain, one label below a public suffix, that
+// domain is part of, or domain itself if it has none.
+func registeredDomain(domain string) string {
+	domain = strings.TrimPrefix(strings.ToLower(domain), "*.")
+	registered, err := publicsuffix.Domain(domain)
+	if err != nil {
+		return domain
+	}
+	return registered
+}
+
+// shouldReport returns true, and records that a report is being sent, if no
+// report has been sent for domain's registered domain in the last interval.
+func (r *IodefReporter) shouldReport(domain string) bool {
+	key := registeredDomain(domain)
+	r.reportedMu.Lock()
+	defer r.reportedMu.Unlock()
+	now := r.clk.Now()
+	for len(r.reportOrder) > 0 {
+		oldest := r.reportOrder[0]
+		if now.Sub(r.reported[oldest]) < r.interval {
+			break
+		}
+		delete(r.reported, oldest)
+		r.reportOrder = r.reportOrder[1:]
+	}
+	if _, ok := r.reported[key]; ok {
+		return false
+	}
+	if len(r.reported) >= maxReportedDomains {
+		r.log.Warningf("not sending iodef report for %s: too many domains reported recently", domain)
+		return false
+	}
+	r.reported[key] = now
+	r.reportOrder = append(r.reportOrder, key)
+	return true
+}
+
+// Report queues a report that issuance for domain was refused, for the given
+// reason, to be sent to each of up to maxIodefTargets targets. It doesn't
+// wait for the report to be sent, so returns an error only if the report
+// couldn't be queued.
+func (r *IodefReporter) Report(_ context.Context, domain, reason string, targets []string) error {
+	if len(targets) == 0 || !r.shouldReport(domain) {
+		return nil
+	}
+	if len(targets) > maxIodefTargets {
+		r.log.Infof("only sending iodef report for %s to the first %d of its %d targets",
+			domain, maxIodefTargets, len(targets))
+		targets = targets[:maxIodefTargets]
+	}
+	subject := fmt.Sprintf("CAA prevented certificate issuance for %s", domain)
+	body := fmt.Sprintf("A request for a certificate including %s was refused at %s "+
+		"because of the domain's CAA records:\n\n%s\n\n"+
+		"This report was sent to the targets of the domain's CAA iodef records.\n",
+		domain, r.clk.Now().UTC().Format(time.RFC3339), reason)
+	report := iodefReport{domain: domain, subject: subject, body: body, targets: targets}
+
+	r.closedMu.RLock()
+	defer r.closedMu.RUnlock()
+	if r.closed {
+		return errors.New("iodef reporter is closed")
+	}
+	select {
+	case r.queue <- report:
+		return nil
+	default:
+		r.log.Warningf("dropping iodef report for %s: queue is full", domain)
+		return errors.New("iodef report queue is full")
+	}
+}
+
+// deliver sends report to each of its targets. A failure to reach one target
+// doesn't stop the others being reported to; the first error encountered is
+// returned.
+func (r *IodefReporter) deliver(ctx context.Context, report iodefReport) error {
+	var firstErr error
+	for _, target := range report.targets {
+		err := r.send(ctx, target, report.subject, report.body)
+		if err != nil {
+			r.log.Warningf("sending iodef report for %s to %q: %s", report.domain, target, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		r.log.Infof("sent iodef report for %s to %q", report.domain, target)
+	}
+	return firstErr
+}
+
+func (r *IodefReporter) send(ctx context.Context, target, subject, body string) error {
+	u, err := url.Parse(target)
+	if err != nil {
+		return err
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "mailto":
+		addr, err := mail.ParseAddress(u.Opaque)
+		if err != nil {
+			return err
+		}
+		return r.sendMail(addr.Address, subject, body)
+	case "http", "https":
+		return r.post(ctx, u.String(), body)
+	default:
+		return fmt.Errorf("unsupported iodef scheme %q", u.Scheme)
+	}
+}
+
+func (r *IodefReporter) sendMail(to, subject, body string) error {
+	r.mailerMu.Lock()
+	defer r.mailerMu.Unlock()
+	err := r.mailer.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = r.mailer.Close() }()
+	return r.mailer.SendMail([]string{to}, subject, body)
+}
+
+func (r *IodefReporter) post(ctx context.Context, target, body string) error {
+	req, err := http.NewRequest("POST", target, bytes.NewBufferString(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	resp, err := r.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package mail
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/test"
+)
+
+// recordingMailer is a Mailer that records the recipients and subjects of the
+// messages it sends.
+type recordingMailer struct {
+	connected bool
+	sent      []string
+}
+
+func (m *recordingMailer) Connect() error {
+	m.connected = true
+	return nil
+}
+
+func (m *recordingMailer) Close() error {
+	m.connected = false
+	return nil
+}
+
+func (m *recordingMailer) SendMail(to []string, subject, _ string) error {
+	if !m.connected {
+		return errors.New("not connected")
+	}
+	m.sent = append(m.sent, strings.Join(to, ",")+": "+subject)
+	return nil
+}
+
+func TestIodefReport(t *testing.T) {
+	var posted []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		posted = append(posted, r.Method+" "+r.URL.Path+": "+string(body))
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+
+	mailer := &recordingMailer{}
+	clk := clock.NewFake()
+	// A single worker sends the reports in the order they were queued.
+	r := NewIodefReporter(mailer, time.Second, time.Hour, 10, 1, clk, blog.NewMock())
+	// The test server listens on a loopback address, which the reporter's own
+	// client refuses to connect to.
+	r.client = srv.Client()
+
+	err := r.Report(context.Background(), "example.com", `no issue record authorizes this request`, []string{
+		"mailto:security@example.com",
+		srv.URL + "/report",
+	})
+	test.AssertNotError(t, err, "Report failed")
+
+	// Only one report is sent per registered domain in each interval, however
+	// many subdomains it is refused for.
+	err = r.Report(context.Background(), "example.com", "reason", []string{"mailto:security@example.com"})
+	test.AssertNotError(t, err, "Report failed")
+	err = r.Report(context.Background(), "www.EXAMPLE.com", "reason", []string{"mailto:security@example.com"})
+	test.AssertNotError(t, err, "Report failed")
+	err = r.Report(context.Background(), "*.a.b.example.com", "reason", []string{"mailto:security@example.com"})
+	test.AssertNotError(t, err, "Report failed")
+	clk.Add(time.Hour)
+	err = r.Report(context.Background(), "example.com", "reason", []string{"mailto:security@example.com"})
+	test.AssertNotError(t, err, "Report failed")
+
+	// Close waits for the queued reports to be sent.
+	r.Close()
+	test.AssertDeepEquals(t, mailer.sent, []string{
+		"security@example.com: CAA prevented certificate issuance for example.com",
+		"security@example.com: CAA prevented certificate issuance for example.com",
+	})
+	test.AssertEquals(t, len(posted), 1)
+	test.Assert(t, strings.HasPrefix(posted[0], "POST /report: A request for a certificate including example.com"), posted[0])
+	test.Assert(t, strings.Contains(posted[0], "no issue record authorizes this request"), posted[0])
+
+	err = r.Report(context.Background(), "example.net", "reason", []string{"mailto:security@example.net"})
+	test.AssertError(t, err, "Report succeeded after Close")
+
+	// A failing target doesn't stop the others being reported to.
+	err = r.deliver(context.Background(), iodefReport{
+		domain:  "example.net",
+		subject: "subject",
+		targets: []string{
+			srv.URL + "/broken",
+			"mailto:not an address",
+			"mailto:security@example.net",
+		},
+	})
+	test.AssertError(t, err, "deliver didn't return an error")
+	test.AssertEquals(t, err.Error(), "500 Internal Server Error")
+	test.AssertEquals(t, len(mailer.sent), 3)
+}
+
+func TestIodefReportLimits(t *testing.T) {
+	clk := clock.NewFake()
+	// With no workers reports stay in the queue.
+	r := NewIodefReporter(&recordingMailer{}, time.Second, time.Hour, 2, 0, clk, blog.NewMock())
+
+	// Only the first maxIodefTargets targets are reported to.
+	var targets []string
+	for i := 0; i < maxIodefTargets+2; i++ {
+		targets = append(targets, fmt.Sprintf("mailto:security%d@example.com", i))
+	}
+	err := r.Report(context.Background(), "example.com", "reason", targets)
+	test.AssertNotError(t, err, "Report failed")
+	report := <-r.queue
+	test.AssertDeepEquals(t, report.targets, targets[:maxIodefTargets])
+
+	// Reported domains are forgotten once the interval has passed.
+	clk.Add(30 * time.Minute)
+	err = r.Report(context.Background(), "example.net", "reason", []string{"mailto:security@example.net"})
+	test.AssertNotError(t, err, "Report failed")
+	test.AssertEquals(t, len(r.reported), 2)
+	clk.Add(30 * time.Minute)
+	test.Assert(t, r.shouldReport("example.org"), "example.org wasn't reported")
+	test.AssertEquals(t, len(r.reported), 2)
+	test.AssertDeepEquals(t, r.reportOrder, []string{"example.net", "example.org"})
+
+	// Reports beyond the queue's size are dropped rather than waiting.
+	err = r.Report(context.Background(), "example.edu", "reason", []string{"mailto:security@example.edu"})
+	test.AssertNotError(t, err, "Report failed")
+	err = r.Report(context.Background(), "example.info", "reason", []string{"mailto:security@example.info"})
+	test.AssertError(t, err, "Report to a full queue succeeded")
+	r.Close()
+}
+
+func TestIodefReportRefusesNonPublic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("report was sent to a loopback address")
+	}))
+	defer srv.Close()
+
+	r := NewIodefReporter(&recordingMailer{}, time.Second, time.Hour, 1, 0, clock.NewFake(), blog.NewMock())
+	err := r.deliver(context.Background(), iodefReport{domain: "example.com", targets: []string{srv.URL}})
+	test.AssertError(t, err, "Report to a loopback address didn't fail")
+
+	for _, addr := range []string{
+		"10.1.2.3:443",
+		"192.168.0.1:80",
+		"[::1]:443",
+		"[fd00::1]:443",
+		"169.254.169.254:80",
+		"0.1.2.3:80",
+		"198.19.0.1:443",
+		"240.0.0.1:80",
+		"255.255.255.255:80",
+		"[64:ff9b::a01:203]:443",
+		"[::ffff:10.1.2.3]:443",
+	} {
+		test.AssertError(t, refuseNonPublic("tcp", addr, nil), addr)
+	}
+	test.AssertNotError(t, refuseNonPublic("tcp", "93.184.216.34:443", nil), "public address refused")
+}
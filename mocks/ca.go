@@ -58,6 +58,11 @@ func (ca *MockCA) GenerateOCSP(ctx context.Context, xferObj core.OCSPSigningRequ
 	return
 }
 
+// GenerateCRL is a mock
+func (ca *MockCA) GenerateCRL(ctx context.Context, crlReq core.CRLSigningRequest) (crl []byte, err error) {
+	return
+}
+
 // RevokeCertificate is a mock
 func (ca *MockCA) RevokeCertificate(ctx context.Context, serial string, reasonCode revocation.Reason) (err error) {
 	return
@@ -610,6 +610,80 @@ func (sa *StorageAuthority) RevokeCertificate(ctx context.Context, req *sapb.Rev
 	return nil
 }
 
+// GetExternalAccountKey is a mock. It knows about three keys: "eab-key", which
+// is usable, "eab-retired", which has been retired, and "eab-bound", which is
+// already bound to registration 1. All of them share the same HMAC key.
+func (sa *StorageAuthority) GetExternalAccountKey(_ context.Context, req *sapb.ExternalAccountKeyID) (*sapb.ExternalAccountKey, error) {
+	createdAt := sa.clk.Now().AddDate(0, 0, -1).UnixNano()
+	var retiredAt, regID int64
+	switch *req.KeyID {
+	case "eab-key":
+	case "eab-retired":
+		retiredAt = sa.clk.Now().Add(-time.Hour).UnixNano()
+	case "eab-bound":
+		regID = 1
+	default:
+		return nil, berrors.NotFoundError("external account key %q not found", *req.KeyID)
+	}
+	return &sapb.ExternalAccountKey{
+		KeyID:          req.KeyID,
+		HmacKey:        []byte("an external account key for testing"),
+		CreatedAt:      &createdAt,
+		RetiredAt:      &retiredAt,
+		RegistrationID: &regID,
+	}, nil
+}
+
+// AddExternalAccountKey is a mock
+func (sa *StorageAuthority) AddExternalAccountKey(_ context.Context, _ *sapb.ExternalAccountKey) error {
+	return nil
+}
+
+// RetireExternalAccountKey is a mock
+func (sa *StorageAuthority) RetireExternalAccountKey(_ context.Context, _ *sapb.ExternalAccountKeyID) error {
+	return nil
+}
+
+// KeyBlocked is a mock. No keys are blocked.
+func (sa *StorageAuthority) KeyBlocked(_ context.Context, _ *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	exists := false
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// GetSerialsByKey is a mock. No other certificates use any key.
+func (sa *StorageAuthority) GetSerialsByKey(_ context.Context, _ *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	return &sapb.Serials{}, nil
+}
+
+// GetOrdersForAccount is a mock. Every account has orders with IDs counting
+// down from 10, returned up to the requested limit.
+func (sa *StorageAuthority) GetOrdersForAccount(_ context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	next := int64(10)
+	if *req.Before > 0 && *req.Before <= next {
+		next = *req.Before - 1
+	}
+	var ids []int64
+	for id := next; id > 0 && int64(len(ids)) < *req.Limit; id-- {
+		ids = append(ids, id)
+	}
+	return &sapb.OrderIDs{Ids: ids}, nil
+}
+
+// GetCertificatesForAccount is a mock. No account has any certificates.
+func (sa *StorageAuthority) GetCertificatesForAccount(_ context.Context, _ *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error) {
+	return &sapb.AccountCertificates{}, nil
+}
+
+// AddKeyHash is a mock
+func (sa *StorageAuthority) AddKeyHash(_ context.Context, _ *sapb.AddKeyHashRequest) error {
+	return nil
+}
+
+// AddBlockedKey is a mock
+func (sa *StorageAuthority) AddBlockedKey(_ context.Context, _ *sapb.AddBlockedKeyRequest) error {
+	return nil
+}
+
 // Publisher is a mock
 type Publisher struct {
 	// empty
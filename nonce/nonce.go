@@ -11,6 +11,11 @@
 // The MaxUsed value determines how long a generated nonce can be used before it
 // is forgotten. To calculate that period, divide the MaxUsed value by average
 // redemption rate (valid POSTs per second).
+//
+// A NonceService can be run on its own behind the nonce-service gRPC daemon
+// and shared by several WFEs. In that case each instance is given a prefix
+// which it adds to every nonce it issues, allowing a WFE to send a nonce back
+// to the instance that issued it to be redeemed.
 package nonce
 
 import (
@@ -20,11 +25,17 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"math/big"
+	"strings"
 	"sync"
 	"time"
 
+	"golang.org/x/net/context"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/metrics"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 )
 
 // MaxUsed defines the maximum number of Nonces we're willing to hold in
@@ -32,7 +43,12 @@ import (
 const MaxUsed = 65536
 const nonceLen = 32
 
+// PrefixLen is the length of the prefix a NonceService adds to the nonces it
+// issues to identify itself.
+const PrefixLen = 4
+
 var errInvalidNonceLength = errors.New("invalid nonce length")
+var errInvalidNoncePrefix = errors.New("nonce has the wrong prefix")
 
 // NonceService generates, cancels, and tracks Nonces.
 type NonceService struct {
@@ -43,6 +59,7 @@ type NonceService struct {
 	usedHeap *int64Heap
 	gcm      cipher.AEAD
 	maxUsed  int
+	prefix   string
 	stats    metrics.Scope
 }
 
@@ -64,9 +81,22 @@ func (h *int64Heap) Pop() interface{} {
 	return x
 }
 
-// NewNonceService constructs a NonceService with defaults
-func NewNonceService(scope metrics.Scope) (*NonceService, error) {
+// NewNonceService constructs a NonceService which remembers up to maxUsed
+// redeemed nonces, or MaxUsed if maxUsed is zero. If prefix is non-empty it
+// must be PrefixLen base64url characters and is added to every nonce issued.
+func NewNonceService(scope metrics.Scope, maxUsed int, prefix string) (*NonceService, error) {
 	scope = scope.NewScope("NonceService")
+	if prefix != "" {
+		if len(prefix) != PrefixLen {
+			return nil, fmt.Errorf("nonce prefix must be %d characters long", PrefixLen)
+		}
+		if _, err := base64.RawURLEncoding.DecodeString(prefix); err != nil {
+			return nil, errors.New("nonce prefix must be valid base64url")
+		}
+	}
+	if maxUsed <= 0 {
+		maxUsed = MaxUsed
+	}
 	key := make([]byte, 16)
 	if _, err := rand.Read(key); err != nil {
 		return nil, err
@@ -84,10 +114,11 @@ func NewNonceService(scope metrics.Scope) (*NonceService, error) {
 	return &NonceService{
 		earliest: 0,
 		latest:   0,
-		used:     make(map[int64]bool, MaxUsed),
+		used:     make(map[int64]bool, maxUsed),
 		usedHeap: &int64Heap{},
 		gcm:      gcm,
-		maxUsed:  MaxUsed,
+		maxUsed:  maxUsed,
+		prefix:   prefix,
 		stats:    scope,
 	}, nil
 }
@@ -113,10 +144,16 @@ func (ns *NonceService) encrypt(counter int64) (string, error) {
 	ct := ns.gcm.Seal(nil, nonce, pt, nil)
 	copy(ret, nonce[4:])
 	copy(ret[8:], ct)
-	return base64.RawURLEncoding.EncodeToString(ret), nil
+	return ns.prefix + base64.RawURLEncoding.EncodeToString(ret), nil
 }
 
 func (ns *NonceService) decrypt(nonce string) (int64, error) {
+	if ns.prefix != "" {
+		if !strings.HasPrefix(nonce, ns.prefix) {
+			return 0, errInvalidNoncePrefix
+		}
+		nonce = nonce[PrefixLen:]
+	}
 	decoded, err := base64.RawURLEncoding.DecodeString(nonce)
 	if err != nil {
 		return 0, err
@@ -189,3 +226,52 @@ func (ns *NonceService) Valid(nonce string) bool {
 	ns.stats.Inc("Valid", 1)
 	return true
 }
+
+// RemoteRedeem redeems a nonce issued by one of a set of remote nonce
+// services, sending it to the service whose prefix it carries. Nonces whose
+// prefix doesn't belong to any of the services are invalid.
+func RemoteRedeem(ctx context.Context, noncePrefixMap map[string]noncepb.NonceServiceClient, nonce string) (bool, error) {
+	if len(nonce) < PrefixLen {
+		return false, nil
+	}
+	nonceService, present := noncePrefixMap[nonce[:PrefixLen]]
+	if !present {
+		return false, nil
+	}
+	resp, err := nonceService.Redeem(ctx, &noncepb.NonceMessage{Nonce: &nonce})
+	if err != nil {
+		return false, err
+	}
+	if resp == nil || resp.Valid == nil {
+		return false, errors.New("incomplete gRPC response message")
+	}
+	return *resp.Valid, nil
+}
+
+// Server wraps a NonceService so that it can be served over gRPC.
+type Server struct {
+	inner *NonceService
+}
+
+// NewServer returns a gRPC server for the given NonceService.
+func NewServer(inner *NonceService) *Server {
+	return &Server{inner: inner}
+}
+
+// Redeem accepts a nonce from a gRPC client and redeems it.
+func (ns *Server) Redeem(ctx context.Context, msg *noncepb.NonceMessage) (*noncepb.ValidMessage, error) {
+	if msg == nil || msg.Nonce == nil {
+		return nil, errors.New("incomplete gRPC request message")
+	}
+	valid := ns.inner.Valid(*msg.Nonce)
+	return &noncepb.ValidMessage{Valid: &valid}, nil
+}
+
+// Nonce generates a nonce and sends it to a gRPC client.
+func (ns *Server) Nonce(ctx context.Context, _ *corepb.Empty) (*noncepb.NonceMessage, error) {
+	nonce, err := ns.inner.Nonce()
+	if err != nil {
+		return nil, err
+	}
+	return &noncepb.NonceMessage{Nonce: &nonce}, nil
+}
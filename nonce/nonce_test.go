@@ -1,15 +1,21 @@
 package nonce
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/metrics"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 	"github.com/letsencrypt/boulder/test"
 )
 
 func TestValidNonce(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 	n, err := ns.Nonce()
 	test.AssertNotError(t, err, "Could not create nonce")
@@ -17,7 +23,7 @@ func TestValidNonce(t *testing.T) {
 }
 
 func TestAlreadyUsed(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 	n, err := ns.Nonce()
 	test.AssertNotError(t, err, "Could not create nonce")
@@ -26,7 +32,7 @@ func TestAlreadyUsed(t *testing.T) {
 }
 
 func TestRejectMalformed(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 	n, err := ns.Nonce()
 	test.AssertNotError(t, err, "Could not create nonce")
@@ -34,15 +40,15 @@ func TestRejectMalformed(t *testing.T) {
 }
 
 func TestRejectShort(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 	test.Assert(t, !ns.Valid("aGkK"), "Accepted an invalid nonce")
 }
 
 func TestRejectUnknown(t *testing.T) {
-	ns1, err := NewNonceService(metrics.NewNoopScope())
+	ns1, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
-	ns2, err := NewNonceService(metrics.NewNoopScope())
+	ns2, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 
 	n, err := ns1.Nonce()
@@ -51,7 +57,7 @@ func TestRejectUnknown(t *testing.T) {
 }
 
 func TestRejectTooLate(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 
 	ns.latest = 2
@@ -62,7 +68,7 @@ func TestRejectTooLate(t *testing.T) {
 }
 
 func TestRejectTooEarly(t *testing.T) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	test.AssertNotError(t, err, "Could not create nonce service")
 
 	n0, err := ns.Nonce()
@@ -89,8 +95,85 @@ func TestRejectTooEarly(t *testing.T) {
 	test.Assert(t, !ns.Valid(n0), "Accepted a nonce that we should have forgotten")
 }
 
+func TestNoncePrefix(t *testing.T) {
+	_, err := NewNonceService(metrics.NewNoopScope(), 0, "abc")
+	test.AssertError(t, err, "Accepted a short prefix")
+	_, err = NewNonceService(metrics.NewNoopScope(), 0, "ab+/")
+	test.AssertError(t, err, "Accepted a prefix that isn't base64url")
+
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "zinc")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	n, err := ns.Nonce()
+	test.AssertNotError(t, err, "Could not create nonce")
+	test.AssertEquals(t, n[:PrefixLen], "zinc")
+	test.Assert(t, !ns.Valid("taro"+n[PrefixLen:]), "Accepted a nonce with the wrong prefix")
+	test.Assert(t, !ns.Valid(n[PrefixLen:]), "Accepted a nonce without its prefix")
+	test.Assert(t, ns.Valid(n), "Did not recognize fresh prefixed nonce")
+}
+
+func TestMaxUsed(t *testing.T) {
+	ns, err := NewNonceService(metrics.NewNoopScope(), 2, "")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	test.AssertEquals(t, ns.maxUsed, 2)
+}
+
+// inMemNonceClient calls a Server directly, standing in for a gRPC
+// connection to the nonce-service.
+type inMemNonceClient struct {
+	server *Server
+	err    error
+}
+
+func (c *inMemNonceClient) Nonce(ctx context.Context, req *corepb.Empty, _ ...grpc.CallOption) (*noncepb.NonceMessage, error) {
+	return c.server.Nonce(ctx, req)
+}
+
+func (c *inMemNonceClient) Redeem(ctx context.Context, req *noncepb.NonceMessage, _ ...grpc.CallOption) (*noncepb.ValidMessage, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.server.Redeem(ctx, req)
+}
+
+func TestRemoteRedeem(t *testing.T) {
+	ctx := context.Background()
+	zinc, err := NewNonceService(metrics.NewNoopScope(), 0, "zinc")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	taro, err := NewNonceService(metrics.NewNoopScope(), 0, "taro")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	zincClient := &inMemNonceClient{server: NewServer(zinc)}
+	prefixMap := map[string]noncepb.NonceServiceClient{
+		"zinc": zincClient,
+		"taro": &inMemNonceClient{server: NewServer(taro)},
+	}
+
+	// A nonce is redeemed by the instance that issued it, whichever client
+	// fetched it.
+	msg, err := prefixMap["taro"].Nonce(ctx, &corepb.Empty{})
+	test.AssertNotError(t, err, "Nonce failed")
+	valid, err := RemoteRedeem(ctx, prefixMap, *msg.Nonce)
+	test.AssertNotError(t, err, "RemoteRedeem failed")
+	test.Assert(t, valid, "Did not redeem a fresh remote nonce")
+	valid, err = RemoteRedeem(ctx, prefixMap, *msg.Nonce)
+	test.AssertNotError(t, err, "RemoteRedeem failed")
+	test.Assert(t, !valid, "Redeemed the same remote nonce twice")
+
+	valid, err = RemoteRedeem(ctx, prefixMap, "abc")
+	test.AssertNotError(t, err, "RemoteRedeem failed for a short nonce")
+	test.Assert(t, !valid, "Redeemed a short nonce")
+	n, err := zinc.Nonce()
+	test.AssertNotError(t, err, "Could not create nonce")
+	valid, err = RemoteRedeem(ctx, prefixMap, "lime"+n[PrefixLen:])
+	test.AssertNotError(t, err, "RemoteRedeem failed for an unknown prefix")
+	test.Assert(t, !valid, "Redeemed a nonce with an unknown prefix")
+
+	zincClient.err = errors.New("unavailable")
+	_, err = RemoteRedeem(ctx, prefixMap, n)
+	test.AssertError(t, err, "RemoteRedeem didn't return the service's error")
+}
+
 func BenchmarkNonces(b *testing.B) {
-	ns, err := NewNonceService(metrics.NewNoopScope())
+	ns, err := NewNonceService(metrics.NewNoopScope(), 0, "")
 	if err != nil {
 		b.Fatal("creating nonce service", err)
 	}
@@ -78,12 +78,13 @@ func GetRSAPublicKey(ctx PKCtx, session pkcs11.SessionHandle, object pkcs11.Obje
 }
 
 // oidDERToCurve maps the hex of the DER encoding of the various curve OIDs to
-// the relevant curve parameters
-var oidDERToCurve = map[string]*elliptic.CurveParams{
-	"06052B81040021":       elliptic.P224().Params(),
-	"06082A8648CE3D030107": elliptic.P256().Params(),
-	"06052B81040022":       elliptic.P384().Params(),
-	"06052B81040023":       elliptic.P521().Params(),
+// the relevant curves. The curves themselves, rather than their parameters,
+// are used so that the public keys built from them can be used with crypto/x509
+var oidDERToCurve = map[string]elliptic.Curve{
+	"06052B81040021":       elliptic.P224(),
+	"06082A8648CE3D030107": elliptic.P256(),
+	"06052B81040022":       elliptic.P384(),
+	"06052B81040023":       elliptic.P521(),
 }
 
 func GetECDSAPublicKey(ctx PKCtx, session pkcs11.SessionHandle, object pkcs11.ObjectHandle) (*ecdsa.PublicKey, error) {
@@ -0,0 +1,147 @@
+package pkcs11helpers
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/asn1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/miekg/pkcs11"
+)
+
+// Signer is a crypto.Signer for a private key object in a PKCS#11 token. It
+// converts ECDSA signatures from the PKCS#11 format to the RFC 5480 one which
+// is required for X.509 certificates
+type Signer struct {
+	ctx PKCtx
+
+	session      pkcs11.SessionHandle
+	objectHandle pkcs11.ObjectHandle
+	keyType      KeyType
+
+	pub crypto.PublicKey
+}
+
+// Sign wraps the package level Sign. If the signing key is ECDSA then the
+// signature is converted from the PKCS#11 format to the RFC 5480 format. For RSA keys a
+// conversion step is not needed.
+func (p *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signature, err := Sign(p.ctx, p.session, p.objectHandle, p.keyType, digest, opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+
+	if p.keyType == ECDSAKey {
+		// Convert from the PKCS#11 format to the RFC 5480 format so that
+		// it can be used in a X.509 certificate
+		r := big.NewInt(0).SetBytes(signature[:len(signature)/2])
+		s := big.NewInt(0).SetBytes(signature[len(signature)/2:])
+		signature, err = asn1.Marshal(struct {
+			R, S *big.Int
+		}{R: r, S: s})
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert signature to RFC 5480 format: %s", err)
+		}
+	}
+	return signature, nil
+}
+
+func (p *Signer) Public() crypto.PublicKey {
+	return p.pub
+}
+
+// FindObject looks up a PKCS#11 object handle based on the provided template.
+// In the case where zero or more than one objects are found to match the
+// template an error is returned.
+func FindObject(ctx PKCtx, session pkcs11.SessionHandle, tmpl []*pkcs11.Attribute) (pkcs11.ObjectHandle, error) {
+	if err := ctx.FindObjectsInit(session, tmpl); err != nil {
+		return 0, err
+	}
+	handles, more, err := ctx.FindObjects(session, 1)
+	if err != nil {
+		return 0, err
+	}
+	if len(handles) == 0 {
+		return 0, errors.New("no objects found matching provided template")
+	}
+	if more {
+		return 0, errors.New("more than one object matches provided template")
+	}
+	if err := ctx.FindObjectsFinal(session); err != nil {
+		return 0, err
+	}
+	return handles[0], nil
+}
+
+// GetSigner constructs a Signer for the private key object associated with the
+// given label and ID. If idStr is empty the key is found by its label alone.
+func GetSigner(ctx PKCtx, session pkcs11.SessionHandle, label string, idStr string) (*Signer, error) {
+	id, err := hex.DecodeString(idStr)
+	if err != nil {
+		return nil, err
+	}
+	keyAttrs := []*pkcs11.Attribute{pkcs11.NewAttribute(pkcs11.CKA_LABEL, label)}
+	if len(id) > 0 {
+		keyAttrs = append(keyAttrs, pkcs11.NewAttribute(pkcs11.CKA_ID, id))
+	}
+
+	// Retrieve the private key handle that will later be used for the certificate
+	// signing operation
+	privateHandle, err := FindObject(ctx, session, append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PRIVATE_KEY),
+	}, keyAttrs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve private key handle: %s", err)
+	}
+	attrs, err := ctx.GetAttributeValue(session, privateHandle, []*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, nil)},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve key type: %s", err)
+	}
+	if len(attrs) == 0 {
+		return nil, errors.New("failed to retrieve key attributes")
+	}
+
+	// Retrieve the public key handle with the same CKA_ID as the private key
+	// and construct a {rsa,ecdsa}.PublicKey for use in x509.CreateCertificate
+	pubHandle, err := FindObject(ctx, session, append([]*pkcs11.Attribute{
+		pkcs11.NewAttribute(pkcs11.CKA_CLASS, pkcs11.CKO_PUBLIC_KEY),
+		pkcs11.NewAttribute(pkcs11.CKA_KEY_TYPE, attrs[0].Value),
+	}, keyAttrs...))
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve public key handle: %s", err)
+	}
+	var pub crypto.PublicKey
+	var keyType KeyType
+	switch {
+	// 0x00000000, CKK_RSA
+	case bytes.Compare(attrs[0].Value, []byte{0, 0, 0, 0, 0, 0, 0, 0}) == 0:
+		keyType = RSAKey
+		pub, err = GetRSAPublicKey(ctx, session, pubHandle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve public key: %s", err)
+		}
+	// 0x00000003, CKK_ECDSA
+	case bytes.Compare(attrs[0].Value, []byte{3, 0, 0, 0, 0, 0, 0, 0}) == 0:
+		keyType = ECDSAKey
+		pub, err = GetECDSAPublicKey(ctx, session, pubHandle)
+		if err != nil {
+			return nil, fmt.Errorf("failed to retrieve public key: %s", err)
+		}
+	default:
+		return nil, errors.New("unsupported key type")
+	}
+
+	return &Signer{
+		ctx:          ctx,
+		session:      session,
+		objectHandle: privateHandle,
+		keyType:      keyType,
+		pub:          pub,
+	}, nil
+}
@@ -1,6 +1,7 @@
 package policy
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
@@ -29,8 +30,15 @@ type AuthorityImpl struct {
 	blacklist              map[string]bool
 	exactBlacklist         map[string]bool
 	wildcardExactBlacklist map[string]bool
+	allowedIPRanges        []*net.IPNet
 	blacklistMu            sync.RWMutex
 
+	// hostnamePolicyEntries are the entries of the hostname policy currently
+	// loaded, and hostnamePolicyMaxRemoved is the largest fraction of them that
+	// a reload may remove, or zero for no limit.
+	hostnamePolicyEntries    map[string]string
+	hostnamePolicyMaxRemoved float64
+
 	enabledChallenges map[string]bool
 	pseudoRNG         *rand.Rand
 	rngMu             sync.Mutex
@@ -52,15 +60,81 @@ func New(challengeTypes map[string]bool) (*AuthorityImpl, error) {
 type blacklistJSON struct {
 	Blacklist      []string
 	ExactBlacklist []string
+	// AllowedIPRanges lists the CIDR blocks containing IP addresses we are
+	// willing to issue for. IP identifiers are rejected when it is empty.
+	AllowedIPRanges []string
 }
 
 // SetHostnamePolicyFile will load the given policy file, returning error if it
-// fails. It will also start a reloader in case the file changes.
-func (pa *AuthorityImpl) SetHostnamePolicyFile(f string) error {
+// fails. It will also start a reloader in case the file changes. A changed
+// file that would remove more than maxRemoved, a fraction between zero and
+// one, of the entries of the loaded policy is refused. Zero means any number
+// of entries may be removed.
+func (pa *AuthorityImpl) SetHostnamePolicyFile(f string, maxRemoved float64) error {
+	pa.blacklistMu.Lock()
+	pa.hostnamePolicyMaxRemoved = maxRemoved
+	pa.blacklistMu.Unlock()
 	_, err := reloader.New(f, pa.loadHostnamePolicy, pa.hostnamePolicyLoadError)
 	return err
 }
 
+// parseHostnamePolicy parses and validates a JSON hostname policy. Unknown
+// fields are rejected, so that a misspelled list isn't silently ignored.
+func parseHostnamePolicy(b []byte) (*blacklistJSON, error) {
+	var bl blacklistJSON
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(&bl)
+	if err != nil {
+		return nil, err
+	}
+	if len(bl.Blacklist) == 0 {
+		return nil, fmt.Errorf("No entries in blacklist.")
+	}
+	for _, v := range bl.ExactBlacklist {
+		// There should at least be a "something." and a TLD like "com"
+		if !strings.Contains(v, ".") {
+			return nil, fmt.Errorf(
+				"Malformed exact blacklist entry, only one label: %q", v)
+		}
+	}
+	for _, v := range bl.AllowedIPRanges {
+		_, _, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, fmt.Errorf("Malformed allowed IP range %q: %s", v, err)
+		}
+	}
+	return &bl, nil
+}
+
+// entries returns the entries of the policy as described for
+// HostnamePolicyEntries.
+func (bl *blacklistJSON) entries() map[string]string {
+	entries := make(map[string]string)
+	for _, v := range bl.Blacklist {
+		entries[fmt.Sprintf("blacklist[%s]", v)] = ""
+	}
+	for _, v := range bl.ExactBlacklist {
+		entries[fmt.Sprintf("exactBlacklist[%s]", v)] = ""
+	}
+	for _, v := range bl.AllowedIPRanges {
+		entries[fmt.Sprintf("allowedIPRanges[%s]", v)] = ""
+	}
+	return entries
+}
+
+// HostnamePolicyEntries parses and validates a JSON hostname policy as
+// SetHostnamePolicyFile does, and returns an entry for each name or IP range
+// in it, e.g. "blacklist[example.com]", so that two versions of the policy can
+// be compared. Entries have no values.
+func HostnamePolicyEntries(b []byte) (map[string]string, error) {
+	bl, err := parseHostnamePolicy(b)
+	if err != nil {
+		return nil, err
+	}
+	return bl.entries(), nil
+}
+
 func (pa *AuthorityImpl) hostnamePolicyLoadError(err error) {
 	pa.log.AuditErrf("error loading hostname policy: %s", err)
 }
@@ -68,14 +142,10 @@ func (pa *AuthorityImpl) hostnamePolicyLoadError(err error) {
 func (pa *AuthorityImpl) loadHostnamePolicy(b []byte) error {
 	hash := sha256.Sum256(b)
 	pa.log.Infof("loading hostname policy, sha256: %s", hex.EncodeToString(hash[:]))
-	var bl blacklistJSON
-	err := json.Unmarshal(b, &bl)
+	bl, err := parseHostnamePolicy(b)
 	if err != nil {
 		return err
 	}
-	if len(bl.Blacklist) == 0 {
-		return fmt.Errorf("No entries in blacklist.")
-	}
 	nameMap := make(map[string]bool)
 	for _, v := range bl.Blacklist {
 		nameMap[v] = true
@@ -90,23 +160,32 @@ func (pa *AuthorityImpl) loadHostnamePolicy(b []byte) error {
 		// the exact blacklist we want "example.com" to be on the
 		// wildcardExactBlacklist so that "*.example.com" cannot be issued.
 		//
-		// First, split the domain into two parts: the first label and the rest of the domain.
+		// First, split the domain into two parts: the first label and the rest of
+		// the domain. parseHostnamePolicy has checked there are two parts.
 		parts := strings.SplitN(v, ".", 2)
-		// if there are less than 2 parts then this entry is malformed! There should
-		// at least be a "something." and a TLD like "com"
-		if len(parts) < 2 {
-			return fmt.Errorf(
-				"Malformed exact blacklist entry, only one label: %q", v)
-		}
 		// Add the second part, the domain minus the first label, to the
 		// wildcardNameMap to block issuance for `*.`+parts[1]
 		wildcardNameMap[parts[1]] = true
 	}
+	var ipRanges []*net.IPNet
+	for _, v := range bl.AllowedIPRanges {
+		_, ipNet, _ := net.ParseCIDR(v)
+		ipRanges = append(ipRanges, ipNet)
+	}
+	entries := bl.entries()
 	pa.blacklistMu.Lock()
+	defer pa.blacklistMu.Unlock()
+	if pa.hostnamePolicyEntries != nil {
+		err = reloader.CheckRemovals(pa.hostnamePolicyEntries, entries, pa.hostnamePolicyMaxRemoved)
+		if err != nil {
+			return err
+		}
+	}
+	pa.hostnamePolicyEntries = entries
 	pa.blacklist = nameMap
 	pa.exactBlacklist = exactNameMap
 	pa.wildcardExactBlacklist = wildcardNameMap
-	pa.blacklistMu.Unlock()
+	pa.allowedIPRanges = ipRanges
 	return nil
 }
 
@@ -157,13 +236,17 @@ var (
 	errMalformedWildcard    = berrors.MalformedError("DNS name had a malformed wildcard label")
 	errICANNTLDWildcard     = berrors.MalformedError("DNS name was a wildcard for an ICANN TLD")
 	errWildcardNotSupported = berrors.MalformedError("Wildcard names not supported")
+	errInvalidIPAddress     = berrors.MalformedError("Invalid IP address")
+	errNonCanonicalIP       = berrors.MalformedError("IP address is not in canonical form")
+	errIPNotAllowed         = berrors.RejectedIdentifierError("Policy forbids issuing for IP address")
 )
 
 // WillingToIssue determines whether the CA is willing to issue for the provided
 // identifier. It expects domains in id to be lowercase to prevent mismatched
 // cases breaking queries.
 //
-// We place several criteria on identifiers we are willing to issue for:
+// IP identifiers are handled by willingToIssueIP. We place several criteria
+// on DNS identifiers we are willing to issue for:
 //
 //  * MUST self-identify as DNS identifiers
 //  * MUST contain only bytes in the DNS hostname character set
@@ -180,6 +263,9 @@ var (
 // If WillingToIssue returns an error, it will be of type MalformedRequestError
 // or RejectedIdentifierError
 func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
+	if id.Type == core.IdentifierIP {
+		return pa.willingToIssueIP(id.Value)
+	}
 	if id.Type != core.IdentifierDNS {
 		return errInvalidIdentifier
 	}
@@ -268,8 +354,38 @@ func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
 	return nil
 }
 
+// willingToIssueIP determines whether the CA is willing to issue for an IP
+// identifier (RFC 8738). The address MUST be written in its canonical textual
+// form, so that it matches the IP address SAN of the certificate byte for
+// byte, and MUST fall inside one of the allowed IP ranges of the hostname
+// policy.
+func (pa *AuthorityImpl) willingToIssueIP(value string) error {
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return errInvalidIPAddress
+	}
+	if ip.String() != value {
+		return errNonCanonicalIP
+	}
+
+	pa.blacklistMu.RLock()
+	defer pa.blacklistMu.RUnlock()
+
+	if pa.blacklist == nil {
+		return fmt.Errorf("Hostname policy not yet loaded.")
+	}
+
+	for _, ipRange := range pa.allowedIPRanges {
+		if ipRange.Contains(ip) {
+			return nil
+		}
+	}
+	return errIPNotAllowed
+}
+
 // WillingToIssueWildcard is an extension of WillingToIssue that accepts DNS
-// identifiers for well formed wildcard domains. It enforces that:
+// identifiers for well formed wildcard domains. IP identifiers are passed
+// straight to WillingToIssue. For DNS identifiers it enforces that:
 // * The identifer is a DNS type identifier
 // * There is at most one `*` wildcard character
 // * That the wildcard character is the leftmost label
@@ -282,7 +398,11 @@ func (pa *AuthorityImpl) WillingToIssue(id core.AcmeIdentifier) error {
 // If all of the above is true then the base domain (e.g. without the *.) is run
 // through WillingToIssue to catch other illegal things (blocked hosts, etc).
 func (pa *AuthorityImpl) WillingToIssueWildcard(ident core.AcmeIdentifier) error {
-	// We're only willing to process DNS identifiers
+	// IP identifiers can't be wildcards
+	if ident.Type == core.IdentifierIP {
+		return pa.WillingToIssue(ident)
+	}
+	// Otherwise we're only willing to process DNS identifiers
 	if ident.Type != core.IdentifierDNS {
 		return errInvalidIdentifier
 	}
@@ -388,18 +508,40 @@ func (pa *AuthorityImpl) ChallengesFor(identifier core.AcmeIdentifier) ([]core.C
 		token = core.NewToken()
 	}
 
-	// If the identifier is for a DNS wildcard name we only
-	// provide a DNS-01 challenge as a matter of CA policy.
-	if strings.HasPrefix(identifier.Value, "*.") {
-		// We must have the DNS-01 challenge type enabled to create challenges for
-		// a wildcard identifier per LE policy.
-		if !pa.ChallengeTypeEnabled(core.ChallengeTypeDNS01) {
+	// RFC 8738 defines no DNS-01 validation for IP identifiers, so they only
+	// get the challenges that connect to the address itself.
+	if identifier.Type == core.IdentifierIP {
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeHTTP01) {
+			challenges = append(challenges, core.HTTPChallenge01(token))
+		}
+
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeTLSALPN01) {
+			challenges = append(challenges, core.TLSALPNChallenge01(token))
+		}
+
+		if len(challenges) == 0 {
+			return nil, fmt.Errorf(
+				"Challenges requested for IP identifier but neither HTTP-01 " +
+					"nor TLS-ALPN-01 challenge type is enabled")
+		}
+	} else if strings.HasPrefix(identifier.Value, "*.") {
+		// If the identifier is for a DNS wildcard name we only
+		// provide DNS-based challenges as a matter of CA policy.
+		// We must have the DNS-01 or DNS-ACCOUNT-01 challenge type enabled to
+		// create challenges for a wildcard identifier per LE policy.
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeDNS01) {
+			challenges = append(challenges, core.DNSChallenge01(token))
+		}
+
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeDNSAccount01) {
+			challenges = append(challenges, core.DNSAccountChallenge01(token))
+		}
+
+		if len(challenges) == 0 {
 			return nil, fmt.Errorf(
-				"Challenges requested for wildcard identifier but DNS-01 " +
-					"challenge type is not enabled")
+				"Challenges requested for wildcard identifier but neither DNS-01 " +
+					"nor DNS-ACCOUNT-01 challenge type is enabled")
 		}
-		// Only provide a DNS-01-Wildcard challenge
-		challenges = []core.Challenge{core.DNSChallenge01(token)}
 	} else {
 		// Otherwise we collect up challenges based on what is enabled.
 		if pa.ChallengeTypeEnabled(core.ChallengeTypeHTTP01) {
@@ -413,6 +555,10 @@ func (pa *AuthorityImpl) ChallengesFor(identifier core.AcmeIdentifier) ([]core.C
 		if pa.ChallengeTypeEnabled(core.ChallengeTypeDNS01) {
 			challenges = append(challenges, core.DNSChallenge01(token))
 		}
+
+		if pa.ChallengeTypeEnabled(core.ChallengeTypeDNSAccount01) {
+			challenges = append(challenges, core.DNSAccountChallenge01(token))
+		}
 	}
 
 	// We shuffle the challenges to prevent ACME clients from relying on the
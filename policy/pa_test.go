@@ -15,8 +15,9 @@ import (
 var log = blog.UseMock()
 
 var enabledChallenges = map[string]bool{
-	core.ChallengeTypeHTTP01: true,
-	core.ChallengeTypeDNS01:  true,
+	core.ChallengeTypeHTTP01:       true,
+	core.ChallengeTypeDNS01:        true,
+	core.ChallengeTypeDNSAccount01: true,
 }
 
 const (
@@ -147,11 +148,11 @@ func TestWillingToIssue(t *testing.T) {
 	defer os.Remove(f.Name())
 	err = ioutil.WriteFile(f.Name(), blacklistBytes, 0640)
 	test.AssertNotError(t, err, "Couldn't write blacklist")
-	err = pa.SetHostnamePolicyFile(f.Name())
+	err = pa.SetHostnamePolicyFile(f.Name(), 0)
 	test.AssertNotError(t, err, "Couldn't load rules")
 
 	// Test for invalid identifier type
-	identifier := core.AcmeIdentifier{Type: "ip", Value: "example.com"}
+	identifier := core.AcmeIdentifier{Type: "email", Value: "example.com"}
 	err = pa.WillingToIssue(identifier)
 	if err != errInvalidIdentifier {
 		t.Error("Identifier was not correctly forbidden: ", identifier)
@@ -222,7 +223,7 @@ func TestWillingToIssueWildcard(t *testing.T) {
 	defer os.Remove(f.Name())
 	err = ioutil.WriteFile(f.Name(), bannedBytes, 0640)
 	test.AssertNotError(t, err, "Couldn't write serialized banned list to file")
-	err = pa.SetHostnamePolicyFile(f.Name())
+	err = pa.SetHostnamePolicyFile(f.Name(), 0)
 	test.AssertNotError(t, err, "Couldn't load policy contents from file")
 
 	makeDNSIdent := func(domain string) core.AcmeIdentifier {
@@ -303,6 +304,53 @@ func TestWillingToIssueWildcard(t *testing.T) {
 	}
 }
 
+func TestWillingToIssueIP(t *testing.T) {
+	pa := paImpl(t)
+
+	policyBytes, err := json.Marshal(blacklistJSON{
+		Blacklist:       []string{"example.com"},
+		AllowedIPRanges: []string{"10.0.0.0/8", "2001:db8::/32"},
+	})
+	test.AssertNotError(t, err, "Couldn't serialize policy")
+	f, _ := ioutil.TempFile("", "test-ip-policy.json")
+	defer os.Remove(f.Name())
+	err = ioutil.WriteFile(f.Name(), policyBytes, 0640)
+	test.AssertNotError(t, err, "Couldn't write serialized policy to file")
+	err = pa.SetHostnamePolicyFile(f.Name(), 0)
+	test.AssertNotError(t, err, "Couldn't load policy contents from file")
+
+	testCases := []struct {
+		ip  string
+		err error
+	}{
+		{"10.1.2.3", nil},
+		{"2001:db8::1", nil},
+		{"192.168.1.1", errIPNotAllowed},
+		{"2001:db9::1", errIPNotAllowed},
+		{"10.1.2", errInvalidIPAddress},
+		{"example.com", errInvalidIPAddress},
+		{"2001:DB8::1", errNonCanonicalIP},
+		{"2001:db8:0::1", errNonCanonicalIP},
+		{"::ffff:10.1.2.3", errNonCanonicalIP},
+	}
+	for _, tc := range testCases {
+		ident := core.AcmeIdentifier{Type: core.IdentifierIP, Value: tc.ip}
+		test.AssertEquals(t, pa.WillingToIssue(ident), tc.err)
+		test.AssertEquals(t, pa.WillingToIssueWildcard(ident), tc.err)
+	}
+
+	// A DNS identifier that looks like an IP address is still rejected, even
+	// when the address is in an allowed range.
+	err = pa.WillingToIssue(core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "10.1.2.3"})
+	test.AssertEquals(t, err, errIPAddress)
+}
+
+func TestMalformedAllowedIPRange(t *testing.T) {
+	pa := paImpl(t)
+	err := pa.loadHostnamePolicy([]byte(`{"Blacklist": ["example.com"], "AllowedIPRanges": ["10.0.0.1"]}`))
+	test.AssertError(t, err, "Loaded a policy with a malformed IP range")
+}
+
 var accountKeyJSON = `{
   "kty":"RSA",
   "n":"yNWVhtYEKJR21y9xsHV-PD_bYwbXSeNuFal46xYxVfRL5mqha7vttvjB_vc7Xg2RvgCxHPCqoxgMPTzHrZT75LjCwIW2K_klBYN8oYvTwwmeSkAz6ut7ZxPv-nZaT5TJhGk0NT2kh_zSpdriEJ_3vW-mqxYbbBmpvHqsa1_zx9fSuHYctAZJWzxzUZXykbWMWQZpEiE0J4ajj51fInEzVn7VxV-mzfMyboQjujPh7aNJxAWSq4oQEJJDgWwSh9leyoJoPpONHxh5nEE5AjE01FkGICSxjpZsF-w8hOTI3XXohUdu29Se26k2B0PolDSuj0GIQU6-W9TdLXSjBb2SpQ",
@@ -352,7 +400,7 @@ func TestChallengesForWildcard(t *testing.T) {
 	test.AssertError(t, err, "ChallengesFor did not error for a wildcard ident "+
 		"when DNS-01 was disabled")
 	test.AssertEquals(t, err.Error(), "Challenges requested for wildcard "+
-		"identifier but DNS-01 challenge type is not enabled")
+		"identifier but neither DNS-01 nor DNS-ACCOUNT-01 challenge type is enabled")
 
 	// Try again with DNS-01 enabled. It should not error and
 	// should return only one DNS-01 type challenge
@@ -363,6 +411,51 @@ func TestChallengesForWildcard(t *testing.T) {
 		"unexpectedly")
 	test.AssertEquals(t, len(challenges), 1)
 	test.AssertEquals(t, challenges[0].Type, core.ChallengeTypeDNS01)
+
+	// With DNS-ACCOUNT-01 enabled as well both DNS-based challenges should be
+	// offered, and still no HTTP-01 challenge
+	enabledChallenges[core.ChallengeTypeDNSAccount01] = true
+	pa = mustConstructPA(t, enabledChallenges)
+	challenges, err = pa.ChallengesFor(wildcardIdent)
+	test.AssertNotError(t, err, "ChallengesFor errored for a wildcard ident "+
+		"unexpectedly")
+	test.AssertEquals(t, len(challenges), 2)
+	for _, chall := range challenges {
+		test.Assert(t, chall.Type != core.ChallengeTypeHTTP01, "HTTP-01 challenge offered for a wildcard ident")
+	}
+
+	// DNS-ACCOUNT-01 alone is enough for a wildcard ident
+	enabledChallenges[core.ChallengeTypeDNS01] = false
+	pa = mustConstructPA(t, enabledChallenges)
+	challenges, err = pa.ChallengesFor(wildcardIdent)
+	test.AssertNotError(t, err, "ChallengesFor errored for a wildcard ident "+
+		"unexpectedly")
+	test.AssertEquals(t, len(challenges), 1)
+	test.AssertEquals(t, challenges[0].Type, core.ChallengeTypeDNSAccount01)
+}
+
+func TestChallengesForIP(t *testing.T) {
+	ipIdent := core.AcmeIdentifier{Type: core.IdentifierIP, Value: "10.1.2.3"}
+
+	pa, err := New(map[string]bool{
+		core.ChallengeTypeHTTP01:       true,
+		core.ChallengeTypeTLSALPN01:    true,
+		core.ChallengeTypeDNS01:        true,
+		core.ChallengeTypeDNSAccount01: true,
+	})
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	challenges, err := pa.ChallengesFor(ipIdent)
+	test.AssertNotError(t, err, "ChallengesFor failed for an IP ident")
+	test.AssertEquals(t, len(challenges), 2)
+	for _, chall := range challenges {
+		test.Assert(t, chall.Type != core.ChallengeTypeDNS01, "DNS-01 challenge offered for an IP ident")
+		test.Assert(t, chall.Type != core.ChallengeTypeDNSAccount01, "DNS-ACCOUNT-01 challenge offered for an IP ident")
+	}
+
+	pa, err = New(map[string]bool{core.ChallengeTypeDNS01: true})
+	test.AssertNotError(t, err, "Couldn't create policy implementation")
+	_, err = pa.ChallengesFor(ipIdent)
+	test.AssertError(t, err, "ChallengesFor didn't fail for an IP ident with only DNS-01 enabled")
 }
 
 // TestMalformedExactBlacklist tests that loading a JSON policy file with an
@@ -394,7 +487,54 @@ func TestMalformedExactBlacklist(t *testing.T) {
 
 	// Try to use the JSON tempfile as the hostname policy. It should produce an
 	// error since the exact blacklist contents are malformed.
-	err = pa.SetHostnamePolicyFile(f.Name())
+	err = pa.SetHostnamePolicyFile(f.Name(), 0)
 	test.AssertError(t, err, "Loaded invalid exact blacklist content without error")
 	test.AssertEquals(t, err.Error(), "Malformed exact blacklist entry, only one label: \"com\"")
 }
+
+func TestUnknownHostnamePolicyField(t *testing.T) {
+	pa := paImpl(t)
+	// A misspelled list is rejected rather than silently ignored.
+	err := pa.loadHostnamePolicy([]byte(`{"Blacklist": ["example.com"], "ExactBlaclist": ["www.example.net"]}`))
+	test.AssertError(t, err, "Loaded a policy with an unknown field")
+}
+
+func TestHostnamePolicyMaxRemoved(t *testing.T) {
+	pa := paImpl(t)
+	pa.hostnamePolicyMaxRemoved = 0.5
+
+	err := pa.loadHostnamePolicy([]byte(`{"Blacklist": ["a.com", "b.com", "c.com", "d.com"]}`))
+	test.AssertNotError(t, err, "Failed to load initial policy")
+
+	// Removing half of the entries is within the limit.
+	err = pa.loadHostnamePolicy([]byte(`{"Blacklist": ["a.com", "b.com"], "ExactBlacklist": ["www.e.com"]}`))
+	test.AssertNotError(t, err, "Failed to load policy removing half the entries")
+
+	// Removing all of them is not, and the loaded policy is kept.
+	err = pa.loadHostnamePolicy([]byte(`{"Blacklist": ["f.com"]}`))
+	test.AssertError(t, err, "Loaded policy removing every entry")
+	err = pa.WillingToIssue(core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "www.a.com"})
+	test.AssertError(t, err, "Refused policy replaced the loaded one")
+}
+
+func TestHostnamePolicyEntries(t *testing.T) {
+	entries, err := HostnamePolicyEntries([]byte(`{
+		"Blacklist": ["example.com"],
+		"ExactBlacklist": ["www.example.net"],
+		"AllowedIPRanges": ["10.0.0.0/8"]
+	}`))
+	test.AssertNotError(t, err, "HostnamePolicyEntries failed")
+	test.AssertDeepEquals(t, entries, map[string]string{
+		"blacklist[example.com]":          "",
+		"exactBlacklist[www.example.net]": "",
+		"allowedIPRanges[10.0.0.0/8]":     "",
+	})
+
+	_, err = HostnamePolicyEntries([]byte(`{"Blacklist": []}`))
+	test.AssertError(t, err, "HostnamePolicyEntries accepted an empty blacklist")
+
+	contents, err := ioutil.ReadFile("../test/hostname-policy.json")
+	test.AssertNotError(t, err, "Failed to read hostname-policy.json")
+	_, err = HostnamePolicyEntries(contents)
+	test.AssertNotError(t, err, "Failed to parse hostname-policy.json")
+}
@@ -3,27 +3,29 @@ package probs
 import (
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // Error types that can be used in ACME payloads
 const (
-	ConnectionProblem            = ProblemType("connection")
-	MalformedProblem             = ProblemType("malformed")
-	ServerInternalProblem        = ProblemType("serverInternal")
-	TLSProblem                   = ProblemType("tls")
-	UnauthorizedProblem          = ProblemType("unauthorized")
-	UnknownHostProblem           = ProblemType("unknownHost")
-	RateLimitedProblem           = ProblemType("rateLimited")
-	BadNonceProblem              = ProblemType("badNonce")
-	InvalidEmailProblem          = ProblemType("invalidEmail")
-	RejectedIdentifierProblem    = ProblemType("rejectedIdentifier")
-	AccountDoesNotExistProblem   = ProblemType("accountDoesNotExist")
-	CAAProblem                   = ProblemType("caa")
-	DNSProblem                   = ProblemType("dns")
-	AlreadyRevokedProblem        = ProblemType("alreadyRevoked")
-	OrderNotReadyProblem         = ProblemType("orderNotReady")
-	BadSignatureAlgorithmProblem = ProblemType("badSignatureAlgorithm")
-	BadPublicKeyProblem          = ProblemType("badPublicKey")
+	ConnectionProblem              = ProblemType("connection")
+	MalformedProblem               = ProblemType("malformed")
+	ServerInternalProblem          = ProblemType("serverInternal")
+	TLSProblem                     = ProblemType("tls")
+	UnauthorizedProblem            = ProblemType("unauthorized")
+	UnknownHostProblem             = ProblemType("unknownHost")
+	RateLimitedProblem             = ProblemType("rateLimited")
+	BadNonceProblem                = ProblemType("badNonce")
+	InvalidEmailProblem            = ProblemType("invalidEmail")
+	RejectedIdentifierProblem      = ProblemType("rejectedIdentifier")
+	AccountDoesNotExistProblem     = ProblemType("accountDoesNotExist")
+	CAAProblem                     = ProblemType("caa")
+	DNSProblem                     = ProblemType("dns")
+	AlreadyRevokedProblem          = ProblemType("alreadyRevoked")
+	OrderNotReadyProblem           = ProblemType("orderNotReady")
+	BadSignatureAlgorithmProblem   = ProblemType("badSignatureAlgorithm")
+	BadPublicKeyProblem            = ProblemType("badPublicKey")
+	ExternalAccountRequiredProblem = ProblemType("externalAccountRequired")
 
 	V1ErrorNS = "urn:acme:error:"
 	V2ErrorNS = "urn:ietf:params:acme:error:"
@@ -40,6 +42,22 @@ type ProblemDetails struct {
 	// HTTPStatus is the HTTP status code the ProblemDetails should probably be sent
 	// as.
 	HTTPStatus int `json:"status,omitempty"`
+	// SubProblems describe the individual parts of a problem (RFC 8555
+	// Section 6.7.1).
+	SubProblems []SubProblemDetails `json:"subproblems,omitempty"`
+}
+
+// SubProblemDetails objects represent one part of a larger problem. Boulder
+// uses them to say which rate limit caused a RateLimitedProblem.
+type SubProblemDetails struct {
+	Type   ProblemType `json:"type,omitempty"`
+	Detail string      `json:"detail,omitempty"`
+	// Limit and Key name the rate limit that was exceeded and the key, such as
+	// a domain name or account ID, it was exceeded for. ResetAt is when the
+	// limit will next allow the request, if that is known.
+	Limit   string     `json:"limit,omitempty"`
+	Key     string     `json:"key,omitempty"`
+	ResetAt *time.Time `json:"resetAt,omitempty"`
 }
 
 func (pd *ProblemDetails) Error() string {
@@ -68,7 +86,8 @@ func ProblemDetailsToStatusCode(prob *ProblemDetails) int {
 		BadNonceProblem,
 		InvalidEmailProblem,
 		RejectedIdentifierProblem,
-		AccountDoesNotExistProblem:
+		AccountDoesNotExistProblem,
+		ExternalAccountRequiredProblem:
 		return http.StatusBadRequest
 	case ServerInternalProblem:
 		return http.StatusInternalServerError
@@ -153,6 +172,16 @@ func BadPublicKey(detail string, a ...interface{}) *ProblemDetails {
 	}
 }
 
+// ExternalAccountRequired returns a ProblemDetails with an
+// ExternalAccountRequiredProblem and a 400 Bad Request status code.
+func ExternalAccountRequired(detail string, a ...interface{}) *ProblemDetails {
+	return &ProblemDetails{
+		Type:       ExternalAccountRequiredProblem,
+		Detail:     fmt.Sprintf(detail, a...),
+		HTTPStatus: http.StatusBadRequest,
+	}
+}
+
 // NotFound returns a ProblemDetails with a MalformedProblem and a 404 Not Found
 // status code.
 func NotFound(detail string, a ...interface{}) *ProblemDetails {
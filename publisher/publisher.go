@@ -26,9 +26,11 @@ import (
 
 	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/core"
+	"github.com/letsencrypt/boulder/ctpolicy/loglist"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	pubpb "github.com/letsencrypt/boulder/publisher/proto"
+	"github.com/letsencrypt/boulder/reloader"
 )
 
 // Log contains the CT client and signature verifier for a particular CT log
@@ -37,6 +39,9 @@ type Log struct {
 	uri      string
 	client   *ctClient.LogClient
 	verifier *ct.SignatureVerifier
+	// keyHash is the SHA-256 hash of the log's public key, which is the
+	// log ID in the SCTs it issues.
+	keyHash [sha256.Size]byte
 }
 
 // logCache contains a cache of *Log's that are constructed as required by
@@ -158,6 +163,7 @@ func NewLog(uri, b64PK string, logger blog.Logger) (*Log, error) {
 		uri:      url.String(),
 		client:   client,
 		verifier: verifier,
+		keyHash:  sha256.Sum256(pkBytes),
 	}, nil
 }
 
@@ -203,6 +209,11 @@ type Impl struct {
 	issuerBundle []ct.ASN1Cert
 	ctLogsCache  logCache
 	metrics      *pubMetrics
+
+	// refusedMu protects refusedLogs, the base64 public keys of logs the
+	// log list marks retired or rejected.
+	refusedMu   sync.RWMutex
+	refusedLogs map[string]bool
 }
 
 // New creates a Publisher that will submit certificates
@@ -222,9 +233,54 @@ func New(
 	}
 }
 
+// SetLogListFile loads the CT log list in the v3 log_list.json schema from
+// filename, and reloads it whenever it changes. Submissions to logs the list
+// marks retired or rejected are refused, and its usable and qualified logs
+// are added to the log cache so that they are probed before the first
+// submission to them.
+func (pub *Impl) SetLogListFile(filename string) error {
+	_, err := reloader.New(filename, func(b []byte) error {
+		ll, err := loglist.Parse(b)
+		if err != nil {
+			return err
+		}
+		refused := make(map[string]bool)
+		for _, l := range ll.Logs(loglist.StateRetired, loglist.StateRejected) {
+			refused[l.Key] = true
+		}
+		for _, l := range ll.Logs(loglist.StateUsable, loglist.StateQualified) {
+			_, err := pub.ctLogsCache.AddLog(l.URL, l.Key, pub.log)
+			if err != nil {
+				return fmt.Errorf("adding log %q: %s", l.Description, err)
+			}
+		}
+		pub.refusedMu.Lock()
+		pub.refusedLogs = refused
+		pub.refusedMu.Unlock()
+		pub.log.Infof("loaded CT log list %s, version %s", filename, ll.Version)
+		return nil
+	}, func(err error) {
+		pub.log.AuditErrf("error reloading CT log list %s: %s", filename, err)
+	})
+	return err
+}
+
+// refused returns true if the log list marks the log with the given base64
+// public key retired or rejected.
+func (pub *Impl) refused(b64PK string) bool {
+	pub.refusedMu.RLock()
+	defer pub.refusedMu.RUnlock()
+	return pub.refusedLogs[b64PK]
+}
+
 // SubmitToSingleCTWithResult will submit the certificate represented by certDER to the CT
 // log specified by log URL and public key (base64) and return the SCT to the caller
 func (pub *Impl) SubmitToSingleCTWithResult(ctx context.Context, req *pubpb.Request) (*pubpb.Result, error) {
+	if pub.refused(*req.LogPublicKey) {
+		pub.log.AuditErrf("Refusing submission to CT log at %s, which is retired or rejected", *req.LogURL)
+		return nil, fmt.Errorf("CT log at %s is retired or rejected", *req.LogURL)
+	}
+
 	cert, err := x509.ParseCertificate(req.Der)
 	if err != nil {
 		pub.log.AuditErrf("Failed to parse certificate: %s", err)
@@ -316,6 +372,15 @@ func (pub *Impl) singleLogSubmit(
 	if err != nil {
 		return nil, err
 	}
+	if sct.SCTVersion != ct.V1 {
+		return nil, fmt.Errorf("SCT has unsupported version %d", sct.SCTVersion)
+	}
+	// The log ID isn't covered by the SCT's signature, so a log could
+	// otherwise return a validly signed SCT naming a different log.
+	if sct.LogID.KeyID != ctLog.keyHash {
+		return nil, fmt.Errorf("SCT log ID %s doesn't match the log's key",
+			base64.StdEncoding.EncodeToString(sct.LogID.KeyID[:]))
+	}
 	err = ctLog.verifier.VerifySCTSignature(*sct, ct.LogEntry{Leaf: *leaf})
 	if err != nil {
 		return nil, err
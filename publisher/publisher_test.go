@@ -11,11 +11,13 @@ import (
 	"encoding/json"
 	"encoding/pem"
 	"fmt"
+	"io/ioutil"
 	"math/big"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
 	"strconv"
 	"strings"
 	"sync/atomic"
@@ -186,6 +188,33 @@ func lyingLogSrv(k *ecdsa.PrivateKey, timestamp time.Time) *testLogSrv {
 	return testLog
 }
 
+// wrongIDLogSrv signs SCTs correctly but names a different log ID in them.
+func wrongIDLogSrv(k *ecdsa.PrivateKey) *testLogSrv {
+	testLog := &testLogSrv{}
+	m := http.NewServeMux()
+	m.HandleFunc("/ct/", func(w http.ResponseWriter, r *http.Request) {
+		decoder := json.NewDecoder(r.Body)
+		var jsonReq ctSubmissionRequest
+		err := decoder.Decode(&jsonReq)
+		if err != nil {
+			return
+		}
+		precert := r.URL.Path == "/ct/v1/add-pre-chain"
+		var sct map[string]interface{}
+		err = json.Unmarshal(CreateTestingSignedSCT(jsonReq.Chain, k, precert, time.Now()), &sct)
+		if err != nil {
+			return
+		}
+		sct["id"] = base64.StdEncoding.EncodeToString(make([]byte, 32))
+		_ = json.NewEncoder(w).Encode(sct)
+		atomic.AddInt64(&testLog.submissions, 1)
+	})
+
+	testLog.Server = httptest.NewUnstartedServer(m)
+	testLog.Server.Start()
+	return testLog
+}
+
 func errorLogSrv() *httptest.Server {
 	m := http.NewServeMux()
 	m.HandleFunc("/ct/", func(w http.ResponseWriter, r *http.Request) {
@@ -339,6 +368,52 @@ func TestTimestampVerificationFuture(t *testing.T) {
 	}
 }
 
+func TestSCTVerification(t *testing.T) {
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	test.AssertNotError(t, err, "Couldn't generate test key")
+
+	for _, tc := range []struct {
+		name      string
+		srv       func(k *ecdsa.PrivateKey) *testLogSrv
+		signer    *ecdsa.PrivateKey
+		errPrefix string
+	}{
+		{
+			name:      "wrong log ID",
+			srv:       wrongIDLogSrv,
+			errPrefix: "SCT log ID AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA= doesn't match the log's key",
+		},
+		{
+			name:      "signed by another key",
+			srv:       logSrv,
+			signer:    otherKey,
+			errPrefix: "failed to verify ECDSA signature",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			pub, _, k := setup(t)
+			signer := k
+			if tc.signer != nil {
+				signer = tc.signer
+			}
+			server := tc.srv(signer)
+			defer server.Close()
+			port, err := getPort(server.URL)
+			test.AssertNotError(t, err, "Failed to get test server port")
+			testLog := addLog(t, pub, port, &k.PublicKey)
+
+			trueBool := true
+			issuerBundle, precert, err := makePrecert(k)
+			test.AssertNotError(t, err, "Failed to create test leaf")
+			pub.issuerBundle = issuerBundle
+
+			_, err = pub.SubmitToSingleCTWithResult(ctx, &pubpb.Request{LogURL: &testLog.uri, LogPublicKey: &testLog.logID, Der: precert, Precert: &trueBool})
+			test.AssertError(t, err, "Accepted an SCT that doesn't verify")
+			test.Assert(t, strings.HasPrefix(err.Error(), tc.errPrefix), fmt.Sprintf("Got wrong error: %s", err))
+		})
+	}
+}
+
 func TestTimestampVerificationPast(t *testing.T) {
 	pub, _, k := setup(t)
 
@@ -475,3 +550,30 @@ func TestProbeLogs(t *testing.T) {
 		"status": "error",
 	})), 1)
 }
+
+func TestLogListRefusesRetiredLogs(t *testing.T) {
+	pub, leaf, k := setup(t)
+	der, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	test.AssertNotError(t, err, "Failed to marshal key")
+	retiredKey := base64.StdEncoding.EncodeToString(der)
+
+	f, err := ioutil.TempFile("", "log-list")
+	test.AssertNotError(t, err, "Failed to create temp file")
+	defer os.Remove(f.Name())
+	_, err = fmt.Fprintf(f, `{"version": "1", "operators": [{"name": "a", "logs": [
+		{"description": "retired", "key": %q, "url": "http://retired.example.com", "state": {"retired": {}}},
+		{"description": "usable", "key": %q, "url": "http://usable.example.com", "state": {"usable": {}}}
+	]}]}`, retiredKey, "MFkwEwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEYggOxPnPkzKBIhTacSYoIfnSL2jPugcbUKx83vFMvk5gKAz/AGe87w20riuPwEGn229hKVbEKHFB61NIqNHC3Q==")
+	test.AssertNotError(t, err, "Failed to write log list")
+	test.AssertNotError(t, f.Close(), "Failed to close log list")
+
+	err = pub.SetLogListFile(f.Name())
+	test.AssertNotError(t, err, "SetLogListFile failed")
+	// The usable log is added to the cache, so that it's probed.
+	test.AssertDeepEquals(t, pub.ctLogsCache.LogURIs(), []string{"http://usable.example.com"})
+
+	uri := "http://retired.example.com"
+	_, err = pub.SubmitToSingleCTWithResult(ctx, &pubpb.Request{LogURL: &uri, LogPublicKey: &retiredKey, Der: leaf.Raw})
+	test.AssertError(t, err, "Submission to a retired log wasn't refused")
+	test.AssertEquals(t, err.Error(), "CT log at http://retired.example.com is retired or rejected")
+}
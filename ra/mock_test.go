@@ -178,3 +178,39 @@ func (sa *mockInvalidAuthorizationsAuthority) RevokeCertificate(_ context.Contex
 func (sa *mockInvalidAuthorizationsAuthority) GetAuthz2(_ context.Context, _ *sapb.AuthorizationID2, opts ...grpc.CallOption) (*corepb.Authorization, error) {
 	return nil, nil
 }
+
+func (sa *mockInvalidAuthorizationsAuthority) GetExternalAccountKey(_ context.Context, _ *sapb.ExternalAccountKeyID, opts ...grpc.CallOption) (*sapb.ExternalAccountKey, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddExternalAccountKey(_ context.Context, _ *sapb.ExternalAccountKey, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) RetireExternalAccountKey(_ context.Context, _ *sapb.ExternalAccountKeyID, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) KeyBlocked(_ context.Context, _ *sapb.KeyBlockedRequest, opts ...grpc.CallOption) (*sapb.Exists, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetSerialsByKey(_ context.Context, _ *sapb.GetSerialsByKeyRequest, opts ...grpc.CallOption) (*sapb.Serials, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetOrdersForAccount(_ context.Context, _ *sapb.GetOrdersForAccountRequest, opts ...grpc.CallOption) (*sapb.OrderIDs, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) GetCertificatesForAccount(_ context.Context, _ *sapb.GetCertificatesForAccountRequest, opts ...grpc.CallOption) (*sapb.AccountCertificates, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddBlockedKey(_ context.Context, _ *sapb.AddBlockedKeyRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
+
+func (sa *mockInvalidAuthorizationsAuthority) AddKeyHash(_ context.Context, _ *sapb.AddKeyHashRequest, opts ...grpc.CallOption) (*core.Empty, error) {
+	return nil, nil
+}
@@ -381,11 +381,12 @@ func (m *AdministrativelyRevokeCertificateRequest) GetAdminName() string {
 }
 
 type NewOrderRequest struct {
-	RegistrationID       *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
-	Names                []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	RegistrationID         *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Names                  []string `protobuf:"bytes,2,rep,name=names" json:"names,omitempty"`
+	CertificateProfileName *string  `protobuf:"bytes,3,opt,name=certificateProfileName" json:"certificateProfileName,omitempty"`
+	XXX_NoUnkeyedLiteral   struct{} `json:"-"`
+	XXX_unrecognized       []byte   `json:"-"`
+	XXX_sizecache          int32    `json:"-"`
 }
 
 func (m *NewOrderRequest) Reset()         { *m = NewOrderRequest{} }
@@ -427,6 +428,13 @@ func (m *NewOrderRequest) GetNames() []string {
 	return nil
 }
 
+func (m *NewOrderRequest) GetCertificateProfileName() string {
+	if m != nil && m.CertificateProfileName != nil {
+		return *m.CertificateProfileName
+	}
+	return ""
+}
+
 type FinalizeOrderRequest struct {
 	Order                *proto1.Order `protobuf:"bytes,1,opt,name=order" json:"order,omitempty"`
 	Csr                  []byte        `protobuf:"bytes,2,opt,name=csr" json:"csr,omitempty"`
@@ -474,6 +482,241 @@ func (m *FinalizeOrderRequest) GetCsr() []byte {
 	return nil
 }
 
+type RevokeCertByKeyRequest struct {
+	Cert                 []byte   `protobuf:"bytes,1,opt,name=cert" json:"cert,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RevokeCertByKeyRequest) Reset()         { *m = RevokeCertByKeyRequest{} }
+func (m *RevokeCertByKeyRequest) String() string { return proto.CompactTextString(m) }
+func (*RevokeCertByKeyRequest) ProtoMessage()    {}
+func (*RevokeCertByKeyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f3baba040132fbcd, []int{9}
+}
+
+func (m *RevokeCertByKeyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokeCertByKeyRequest.Unmarshal(m, b)
+}
+func (m *RevokeCertByKeyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokeCertByKeyRequest.Marshal(b, m, deterministic)
+}
+func (m *RevokeCertByKeyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeCertByKeyRequest.Merge(m, src)
+}
+func (m *RevokeCertByKeyRequest) XXX_Size() int {
+	return xxx_messageInfo_RevokeCertByKeyRequest.Size(m)
+}
+func (m *RevokeCertByKeyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeCertByKeyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeCertByKeyRequest proto.InternalMessageInfo
+
+func (m *RevokeCertByKeyRequest) GetCert() []byte {
+	if m != nil {
+		return m.Cert
+	}
+	return nil
+}
+
+type RevokeCertByKeyResponse struct {
+	Serials              []string `protobuf:"bytes,1,rep,name=serials" json:"serials,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RevokeCertByKeyResponse) Reset()         { *m = RevokeCertByKeyResponse{} }
+func (m *RevokeCertByKeyResponse) String() string { return proto.CompactTextString(m) }
+func (*RevokeCertByKeyResponse) ProtoMessage()    {}
+func (*RevokeCertByKeyResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f3baba040132fbcd, []int{10}
+}
+
+func (m *RevokeCertByKeyResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevokeCertByKeyResponse.Unmarshal(m, b)
+}
+func (m *RevokeCertByKeyResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevokeCertByKeyResponse.Marshal(b, m, deterministic)
+}
+func (m *RevokeCertByKeyResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevokeCertByKeyResponse.Merge(m, src)
+}
+func (m *RevokeCertByKeyResponse) XXX_Size() int {
+	return xxx_messageInfo_RevokeCertByKeyResponse.Size(m)
+}
+func (m *RevokeCertByKeyResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevokeCertByKeyResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RevokeCertByKeyResponse proto.InternalMessageInfo
+
+func (m *RevokeCertByKeyResponse) GetSerials() []string {
+	if m != nil {
+		return m.Serials
+	}
+	return nil
+}
+
+type RateLimitUsageRequest struct {
+	RegistrationID       *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Domain               *string  `protobuf:"bytes,2,opt,name=domain" json:"domain,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RateLimitUsageRequest) Reset()         { *m = RateLimitUsageRequest{} }
+func (m *RateLimitUsageRequest) String() string { return proto.CompactTextString(m) }
+func (*RateLimitUsageRequest) ProtoMessage()    {}
+func (*RateLimitUsageRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f3baba040132fbcd, []int{11}
+}
+
+func (m *RateLimitUsageRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RateLimitUsageRequest.Unmarshal(m, b)
+}
+func (m *RateLimitUsageRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RateLimitUsageRequest.Marshal(b, m, deterministic)
+}
+func (m *RateLimitUsageRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RateLimitUsageRequest.Merge(m, src)
+}
+func (m *RateLimitUsageRequest) XXX_Size() int {
+	return xxx_messageInfo_RateLimitUsageRequest.Size(m)
+}
+func (m *RateLimitUsageRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RateLimitUsageRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RateLimitUsageRequest proto.InternalMessageInfo
+
+func (m *RateLimitUsageRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *RateLimitUsageRequest) GetDomain() string {
+	if m != nil && m.Domain != nil {
+		return *m.Domain
+	}
+	return ""
+}
+
+type RateLimitUsage struct {
+	Name                 *string  `protobuf:"bytes,1,opt,name=name" json:"name,omitempty"`
+	Key                  *string  `protobuf:"bytes,2,opt,name=key" json:"key,omitempty"`
+	Used                 *int64   `protobuf:"varint,3,opt,name=used" json:"used,omitempty"`
+	Threshold            *int64   `protobuf:"varint,4,opt,name=threshold" json:"threshold,omitempty"`
+	Window               *int64   `protobuf:"varint,5,opt,name=window" json:"window,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RateLimitUsage) Reset()         { *m = RateLimitUsage{} }
+func (m *RateLimitUsage) String() string { return proto.CompactTextString(m) }
+func (*RateLimitUsage) ProtoMessage()    {}
+func (*RateLimitUsage) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f3baba040132fbcd, []int{12}
+}
+
+func (m *RateLimitUsage) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RateLimitUsage.Unmarshal(m, b)
+}
+func (m *RateLimitUsage) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RateLimitUsage.Marshal(b, m, deterministic)
+}
+func (m *RateLimitUsage) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RateLimitUsage.Merge(m, src)
+}
+func (m *RateLimitUsage) XXX_Size() int {
+	return xxx_messageInfo_RateLimitUsage.Size(m)
+}
+func (m *RateLimitUsage) XXX_DiscardUnknown() {
+	xxx_messageInfo_RateLimitUsage.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RateLimitUsage proto.InternalMessageInfo
+
+func (m *RateLimitUsage) GetName() string {
+	if m != nil && m.Name != nil {
+		return *m.Name
+	}
+	return ""
+}
+
+func (m *RateLimitUsage) GetKey() string {
+	if m != nil && m.Key != nil {
+		return *m.Key
+	}
+	return ""
+}
+
+func (m *RateLimitUsage) GetUsed() int64 {
+	if m != nil && m.Used != nil {
+		return *m.Used
+	}
+	return 0
+}
+
+func (m *RateLimitUsage) GetThreshold() int64 {
+	if m != nil && m.Threshold != nil {
+		return *m.Threshold
+	}
+	return 0
+}
+
+func (m *RateLimitUsage) GetWindow() int64 {
+	if m != nil && m.Window != nil {
+		return *m.Window
+	}
+	return 0
+}
+
+type RateLimitUsages struct {
+	Usages               []*RateLimitUsage `protobuf:"bytes,1,rep,name=usages" json:"usages,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *RateLimitUsages) Reset()         { *m = RateLimitUsages{} }
+func (m *RateLimitUsages) String() string { return proto.CompactTextString(m) }
+func (*RateLimitUsages) ProtoMessage()    {}
+func (*RateLimitUsages) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f3baba040132fbcd, []int{13}
+}
+
+func (m *RateLimitUsages) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RateLimitUsages.Unmarshal(m, b)
+}
+func (m *RateLimitUsages) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RateLimitUsages.Marshal(b, m, deterministic)
+}
+func (m *RateLimitUsages) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RateLimitUsages.Merge(m, src)
+}
+func (m *RateLimitUsages) XXX_Size() int {
+	return xxx_messageInfo_RateLimitUsages.Size(m)
+}
+func (m *RateLimitUsages) XXX_DiscardUnknown() {
+	xxx_messageInfo_RateLimitUsages.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RateLimitUsages proto.InternalMessageInfo
+
+func (m *RateLimitUsages) GetUsages() []*RateLimitUsage {
+	if m != nil {
+		return m.Usages
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*NewAuthorizationRequest)(nil), "ra.NewAuthorizationRequest")
 	proto.RegisterType((*NewCertificateRequest)(nil), "ra.NewCertificateRequest")
@@ -484,50 +727,68 @@ func init() {
 	proto.RegisterType((*AdministrativelyRevokeCertificateRequest)(nil), "ra.AdministrativelyRevokeCertificateRequest")
 	proto.RegisterType((*NewOrderRequest)(nil), "ra.NewOrderRequest")
 	proto.RegisterType((*FinalizeOrderRequest)(nil), "ra.FinalizeOrderRequest")
+	proto.RegisterType((*RevokeCertByKeyRequest)(nil), "ra.RevokeCertByKeyRequest")
+	proto.RegisterType((*RevokeCertByKeyResponse)(nil), "ra.RevokeCertByKeyResponse")
+	proto.RegisterType((*RateLimitUsageRequest)(nil), "ra.RateLimitUsageRequest")
+	proto.RegisterType((*RateLimitUsage)(nil), "ra.RateLimitUsage")
+	proto.RegisterType((*RateLimitUsages)(nil), "ra.RateLimitUsages")
 }
 
 func init() { proto.RegisterFile("ra/proto/ra.proto", fileDescriptor_f3baba040132fbcd) }
 
 var fileDescriptor_f3baba040132fbcd = []byte{
-	// 605 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xdb, 0x6e, 0xd3, 0x4c,
-	0x10, 0x4e, 0xe2, 0xa6, 0x7f, 0x3b, 0xfd, 0xe9, 0x61, 0xda, 0x52, 0xd7, 0x14, 0xd1, 0x2e, 0x52,
-	0x15, 0x0e, 0x4a, 0xa5, 0x5e, 0x21, 0x55, 0x08, 0x4a, 0x4b, 0x45, 0x84, 0x94, 0x22, 0x4b, 0x80,
-	0xd4, 0x1b, 0x58, 0xec, 0x69, 0x62, 0x11, 0xdb, 0x61, 0xbd, 0x49, 0x48, 0x5e, 0x81, 0x57, 0xe0,
-	0x61, 0x91, 0xd7, 0x9b, 0xc6, 0x76, 0x6c, 0x15, 0x84, 0xb8, 0x9b, 0x9d, 0xc3, 0x37, 0x33, 0xfe,
-	0xe6, 0x93, 0x61, 0x43, 0xf0, 0xa3, 0xbe, 0x08, 0x65, 0x78, 0x24, 0x78, 0x53, 0x19, 0x58, 0x13,
-	0xdc, 0xda, 0x76, 0x42, 0x41, 0x3a, 0x10, 0x9b, 0x49, 0x88, 0x5d, 0xc1, 0x4e, 0x9b, 0x46, 0xa7,
-	0x03, 0xd9, 0x0d, 0x85, 0x37, 0xe1, 0xd2, 0x0b, 0x03, 0x9b, 0xbe, 0x0d, 0x28, 0x92, 0xf8, 0x08,
-	0xea, 0x7c, 0x20, 0xbb, 0x13, 0xb3, 0xba, 0x5f, 0x6d, 0xac, 0x1c, 0x6f, 0x36, 0x55, 0x59, 0x36,
-	0x35, 0xc9, 0xc0, 0x2d, 0xa8, 0x0b, 0xea, 0xb4, 0xce, 0xcd, 0xda, 0x7e, 0xb5, 0x61, 0xd8, 0xc9,
-	0x83, 0xbd, 0x80, 0xed, 0x36, 0x8d, 0xce, 0x48, 0x48, 0xef, 0xda, 0x73, 0xb8, 0xa4, 0x29, 0xf2,
-	0x3a, 0x18, 0x4e, 0x24, 0x14, 0xee, 0xff, 0x76, 0x6c, 0x96, 0x00, 0x84, 0xb0, 0xfb, 0xbe, 0xef,
-	0xaa, 0xc2, 0x8e, 0x17, 0x49, 0x91, 0x19, 0xef, 0x10, 0x16, 0xbe, 0xf0, 0x88, 0xf4, 0x74, 0x98,
-	0x4c, 0x97, 0x49, 0x54, 0x71, 0x7c, 0x0c, 0x8b, 0x03, 0x05, 0xa2, 0xb0, 0x8b, 0x33, 0x75, 0x06,
-	0xfb, 0x59, 0x05, 0x2b, 0xe9, 0xf8, 0xb7, 0x5f, 0xe4, 0x10, 0x56, 0x9d, 0x2e, 0xef, 0xf5, 0x28,
-	0xe8, 0x50, 0x2b, 0x70, 0xe9, 0xbb, 0xde, 0x2c, 0xe7, 0xc5, 0x27, 0xb0, 0x24, 0x28, 0xea, 0x87,
-	0x41, 0x44, 0xa6, 0xa1, 0x50, 0xd7, 0x12, 0xd4, 0xb3, 0x69, 0x9e, 0x7d, 0x93, 0xc0, 0x7c, 0x30,
-	0xdf, 0x91, 0xb8, 0x0e, 0x85, 0xff, 0x81, 0xf7, 0x3c, 0xf7, 0x1f, 0xcf, 0xc6, 0x3e, 0xc1, 0x03,
-	0x9b, 0x86, 0xe1, 0x57, 0x4a, 0x51, 0xf8, 0xd1, 0x93, 0x5d, 0x9b, 0x3a, 0xd3, 0xae, 0x08, 0x0b,
-	0x0e, 0x09, 0xa9, 0xa9, 0x54, 0xb6, 0xf2, 0x85, 0x2e, 0x69, 0x50, 0x65, 0xcf, 0xf8, 0x35, 0xd2,
-	0xfc, 0xf6, 0xa1, 0x71, 0xea, 0xfa, 0x5e, 0xa0, 0x89, 0x18, 0x52, 0x6f, 0x3c, 0xd7, 0xf0, 0x4f,
-	0x3b, 0xed, 0xc1, 0x32, 0x8f, 0x31, 0xdb, 0xdc, 0x4f, 0xbe, 0xe8, 0xb2, 0x3d, 0x73, 0xb0, 0x4b,
-	0x58, 0x6b, 0xd3, 0xe8, 0x52, 0xb8, 0x24, 0x66, 0x77, 0xb4, 0x2a, 0x52, 0xb7, 0xd0, 0x3a, 0x57,
-	0x2d, 0x0c, 0x3b, 0xe7, 0x8d, 0x57, 0x08, 0xb8, 0x4f, 0x91, 0x59, 0xdb, 0x37, 0x1a, 0xcb, 0x76,
-	0xf2, 0x60, 0x6f, 0x61, 0xeb, 0xc2, 0x0b, 0x78, 0xcf, 0x9b, 0x50, 0x06, 0xf5, 0x00, 0xea, 0x61,
-	0xfc, 0xd6, 0x74, 0xac, 0x24, 0x74, 0x24, 0x29, 0x49, 0x64, 0xaa, 0x82, 0xda, 0x8d, 0x0a, 0x8e,
-	0x7f, 0x2c, 0xc2, 0x76, 0xfa, 0x2e, 0x35, 0x7b, 0x72, 0x8c, 0x27, 0x6a, 0xee, 0x74, 0x0c, 0x0b,
-	0xee, 0xd8, 0x2a, 0xf0, 0xb1, 0x0a, 0x5e, 0xc0, 0x7a, 0x5e, 0xe3, 0x78, 0xaf, 0x29, 0x78, 0xb3,
-	0x44, 0xf9, 0x56, 0xd1, 0xf1, 0xb0, 0x0a, 0xbe, 0x84, 0xd5, 0xac, 0x9e, 0x71, 0x57, 0xa3, 0xcc,
-	0xf3, 0x65, 0x6d, 0xe8, 0x33, 0x9e, 0x45, 0x58, 0x05, 0x5b, 0x80, 0xf3, 0x82, 0xc6, 0xfb, 0x31,
-	0x4a, 0xa9, 0xd0, 0x4b, 0x96, 0x7a, 0x03, 0x1b, 0x73, 0x5a, 0xc0, 0xbd, 0x18, 0xa9, 0x4c, 0x22,
-	0x65, 0x6b, 0xb5, 0xc1, 0x2c, 0x3b, 0x73, 0x7c, 0x18, 0x03, 0xde, 0x22, 0x02, 0x4b, 0x93, 0xfb,
-	0xda, 0xef, 0xcb, 0x31, 0xab, 0xe0, 0x09, 0xdc, 0x3d, 0x27, 0xee, 0x48, 0x6f, 0x98, 0x5f, 0xb4,
-	0x88, 0xb2, 0x5c, 0xf1, 0x73, 0xd8, 0x99, 0x15, 0x67, 0x29, 0x2b, 0x1a, 0x3f, 0x5f, 0xfe, 0x19,
-	0x0e, 0x6e, 0x55, 0x14, 0x3e, 0x8d, 0x97, 0xfa, 0x5d, 0xe1, 0xe5, 0x3b, 0x34, 0x61, 0x69, 0xaa,
-	0x20, 0xdc, 0xd4, 0xf4, 0xa7, 0x2f, 0xdf, 0x4a, 0x9f, 0x3a, 0xab, 0xe0, 0x33, 0xb8, 0x93, 0x11,
-	0x08, 0x9a, 0x71, 0x51, 0x91, 0x66, 0x72, 0x95, 0xaf, 0xfe, 0xbb, 0xaa, 0xab, 0x7f, 0xd4, 0xaf,
-	0x00, 0x00, 0x00, 0xff, 0xff, 0x3a, 0x20, 0x77, 0x28, 0xd2, 0x06, 0x00, 0x00,
+	// 804 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x56, 0x5b, 0x6f, 0x1b, 0x45,
+	0x14, 0xf6, 0x25, 0x6e, 0xea, 0x53, 0xb0, 0xeb, 0x69, 0xed, 0x6c, 0xb6, 0x45, 0xa4, 0x83, 0x54,
+	0x19, 0xa8, 0x5c, 0xa9, 0x48, 0x08, 0xa9, 0x8a, 0x20, 0x57, 0xb0, 0x12, 0x99, 0x68, 0xa5, 0x10,
+	0x29, 0x2f, 0x30, 0x78, 0x4f, 0xec, 0x51, 0xf6, 0x62, 0x66, 0xc7, 0x31, 0xce, 0x13, 0x6f, 0xfc,
+	0x09, 0x1e, 0xf8, 0xa9, 0x68, 0x66, 0xc7, 0xd9, 0x8b, 0x77, 0x03, 0x11, 0xea, 0xdb, 0x99, 0x39,
+	0xf7, 0xf3, 0xcd, 0xf9, 0x76, 0xa1, 0x23, 0xd8, 0xdb, 0x99, 0x08, 0x65, 0xf8, 0x56, 0xb0, 0x81,
+	0x16, 0x48, 0x4d, 0x30, 0xbb, 0x3b, 0x0e, 0x05, 0x1a, 0x85, 0x12, 0x63, 0x15, 0xbd, 0x84, 0xad,
+	0x11, 0x2e, 0xf6, 0xe6, 0x72, 0x1a, 0x0a, 0x7e, 0xcb, 0x24, 0x0f, 0x03, 0x07, 0x7f, 0x9b, 0x63,
+	0x24, 0xc9, 0xe7, 0xd0, 0x60, 0x73, 0x39, 0xbd, 0xb5, 0xaa, 0x3b, 0xd5, 0xfe, 0x93, 0x77, 0xcf,
+	0x06, 0xda, 0x2d, 0x6b, 0x1a, 0x5b, 0x90, 0xe7, 0xd0, 0x10, 0x38, 0x19, 0x1e, 0x5a, 0xb5, 0x9d,
+	0x6a, 0xbf, 0xee, 0xc4, 0x07, 0xfa, 0x2d, 0x74, 0x47, 0xb8, 0x38, 0x40, 0x21, 0xf9, 0x15, 0x1f,
+	0x33, 0x89, 0xab, 0xc8, 0x4f, 0xa1, 0x3e, 0x8e, 0x84, 0x8e, 0xfb, 0x91, 0xa3, 0xc4, 0x92, 0x00,
+	0x21, 0x6c, 0x9f, 0xcf, 0x5c, 0xed, 0x38, 0xe1, 0x91, 0x14, 0x99, 0xf2, 0x5e, 0xc3, 0xc6, 0xaf,
+	0x2c, 0x42, 0x53, 0x1d, 0x89, 0xab, 0xcb, 0x18, 0x6a, 0x3d, 0xf9, 0x02, 0x1e, 0xcd, 0x75, 0x10,
+	0xab, 0x56, 0x6a, 0x69, 0x2c, 0xe8, 0x5f, 0x55, 0xb0, 0xe3, 0x8c, 0xff, 0x77, 0x22, 0xaf, 0xa1,
+	0x35, 0x9e, 0x32, 0xcf, 0xc3, 0x60, 0x82, 0xc3, 0xc0, 0xc5, 0xdf, 0x4d, 0x67, 0xb9, 0x5b, 0xf2,
+	0x25, 0x3c, 0x16, 0x18, 0xcd, 0xc2, 0x20, 0x42, 0xab, 0xae, 0xa3, 0xb6, 0xe3, 0xa8, 0x07, 0x2b,
+	0x3b, 0xe7, 0xce, 0x80, 0xfa, 0x60, 0x9d, 0xa1, 0xb8, 0x0a, 0x85, 0xff, 0x13, 0xf3, 0xb8, 0xfb,
+	0x81, 0x6b, 0xa3, 0x3f, 0xc3, 0xa7, 0x0e, 0xde, 0x84, 0xd7, 0x98, 0x82, 0xf0, 0x82, 0xcb, 0xa9,
+	0x83, 0x93, 0x55, 0x56, 0x02, 0x1b, 0x63, 0x14, 0xd2, 0x40, 0xa9, 0x65, 0x7d, 0x17, 0xba, 0x68,
+	0x82, 0x6a, 0x39, 0xc1, 0xb7, 0x9e, 0xc6, 0x77, 0x06, 0xfd, 0x3d, 0xd7, 0xe7, 0x81, 0x01, 0xe2,
+	0x06, 0xbd, 0xe5, 0x5a, 0xc2, 0x87, 0x66, 0x7a, 0x09, 0x4d, 0xa6, 0x62, 0x8e, 0x98, 0x1f, 0x4f,
+	0xb4, 0xe9, 0x24, 0x17, 0xf4, 0xcf, 0x2a, 0xb4, 0x47, 0xb8, 0xf8, 0x51, 0xb8, 0x28, 0x92, 0x87,
+	0xd4, 0x12, 0xa9, 0xc7, 0x30, 0x3c, 0xd4, 0x39, 0xea, 0x4e, 0xee, 0x56, 0xf5, 0x10, 0x30, 0x1f,
+	0x23, 0xab, 0xb6, 0x53, 0xef, 0x37, 0x9d, 0xf8, 0x40, 0xbe, 0x86, 0xde, 0x38, 0xa9, 0xf6, 0x4c,
+	0x84, 0x57, 0xdc, 0xc3, 0x54, 0xf2, 0x12, 0x2d, 0x3d, 0x81, 0xe7, 0xc7, 0x3c, 0x60, 0x1e, 0xbf,
+	0xc5, 0x4c, 0x35, 0xaf, 0xa0, 0x11, 0xaa, 0xb3, 0xc1, 0xf1, 0x49, 0x8c, 0x63, 0x6c, 0x12, 0x6b,
+	0x56, 0xeb, 0x53, 0xbb, 0x5b, 0x1f, 0xfa, 0x06, 0x7a, 0xc9, 0xe0, 0xf6, 0x97, 0x27, 0xb8, 0xbc,
+	0x67, 0x6c, 0xf4, 0x2b, 0xd8, 0x5a, 0xb3, 0x8e, 0x5f, 0x18, 0xb1, 0x60, 0x33, 0x42, 0xc1, 0x99,
+	0x17, 0x59, 0x55, 0xdd, 0xe5, 0xea, 0x48, 0x2f, 0xa0, 0xeb, 0x30, 0x89, 0xa7, 0xdc, 0xe7, 0xf2,
+	0x3c, 0x62, 0x13, 0x7c, 0xe8, 0xf8, 0x7a, 0xf0, 0xc8, 0x0d, 0x7d, 0xc6, 0x03, 0x5d, 0x78, 0xd3,
+	0x31, 0x27, 0xfa, 0x47, 0x15, 0x5a, 0xd9, 0xc8, 0xaa, 0x68, 0x35, 0x5c, 0x1d, 0xa8, 0xe9, 0x68,
+	0x59, 0x35, 0x7d, 0x8d, 0x4b, 0xe3, 0xab, 0x44, 0x65, 0x35, 0x8f, 0xd0, 0x35, 0x4f, 0x4a, 0xcb,
+	0x0a, 0x7d, 0x39, 0x15, 0x18, 0x4d, 0x43, 0xcf, 0xb5, 0x36, 0xb4, 0x22, 0xb9, 0x50, 0x25, 0x2c,
+	0x78, 0xe0, 0x86, 0x0b, 0xab, 0xa1, 0x55, 0xe6, 0x44, 0x77, 0xa1, 0x9d, 0xad, 0x20, 0xd2, 0xac,
+	0xa1, 0x25, 0x3d, 0x07, 0xc5, 0x1a, 0x82, 0x0d, 0x72, 0x03, 0x30, 0x16, 0xef, 0xfe, 0xde, 0x84,
+	0x6e, 0x9a, 0x4e, 0xcc, 0xd2, 0xc9, 0x25, 0x79, 0xaf, 0x5f, 0x5b, 0x5a, 0x47, 0x0a, 0xe8, 0xc7,
+	0x2e, 0xb8, 0xa3, 0x15, 0x72, 0x0c, 0x4f, 0xf3, 0xd4, 0x4c, 0x5e, 0xa8, 0x32, 0x4a, 0x08, 0xdb,
+	0x2e, 0xda, 0x79, 0x5a, 0x21, 0xdf, 0x41, 0x2b, 0x4b, 0xc3, 0x64, 0xdb, 0x44, 0x59, 0x5f, 0x33,
+	0xbb, 0x63, 0xd8, 0x27, 0xd1, 0xd0, 0x0a, 0x19, 0x02, 0x59, 0xe7, 0x61, 0xf2, 0x89, 0x8a, 0x52,
+	0xca, 0xcf, 0x25, 0x4d, 0xfd, 0x00, 0x9d, 0x35, 0x0a, 0x23, 0x2f, 0x55, 0xa4, 0x32, 0x66, 0x2b,
+	0x6b, 0x6b, 0x04, 0x56, 0x19, 0x3b, 0x91, 0xcf, 0x34, 0x5a, 0xf7, 0x73, 0x97, 0x6d, 0x56, 0xeb,
+	0xc8, 0x9f, 0xc9, 0x25, 0xad, 0x90, 0xf7, 0xd0, 0x3b, 0x44, 0x36, 0x96, 0xfc, 0x26, 0xdf, 0x68,
+	0x11, 0x64, 0x39, 0xe7, 0x5d, 0xd8, 0x4a, 0x9c, 0xb3, 0x90, 0x15, 0x95, 0x9f, 0x77, 0xff, 0x05,
+	0x5e, 0xfd, 0x2b, 0x11, 0x92, 0x37, 0xaa, 0xa9, 0xff, 0xca, 0x97, 0xf9, 0x0c, 0x03, 0x78, 0xbc,
+	0xe2, 0x3d, 0xf2, 0xcc, 0xc0, 0x9f, 0xe6, 0x1d, 0x3b, 0x4d, 0x34, 0xb4, 0x42, 0xbe, 0x81, 0x8f,
+	0x33, 0xf4, 0x44, 0x2c, 0xe5, 0x54, 0xc4, 0x58, 0x79, 0xcf, 0x53, 0x68, 0xe7, 0xd8, 0x85, 0xd8,
+	0x59, 0x38, 0xd2, 0x04, 0x65, 0xbf, 0x28, 0xd4, 0x99, 0x0f, 0x5e, 0x85, 0x1c, 0x41, 0xe7, 0x7b,
+	0x94, 0x39, 0x7e, 0xd8, 0x2e, 0x58, 0xc6, 0xbb, 0xc7, 0xb2, 0xa6, 0x8a, 0x68, 0x65, 0x7f, 0xf3,
+	0xb2, 0xa1, 0xff, 0x77, 0xfe, 0x01, 0x00, 0x00, 0xff, 0xff, 0x03, 0x00, 0xc5, 0x42, 0x3b, 0x38,
+	0x1e, 0x09, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -553,6 +814,8 @@ type RegistrationAuthorityClient interface {
 	AdministrativelyRevokeCertificate(ctx context.Context, in *AdministrativelyRevokeCertificateRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 	NewOrder(ctx context.Context, in *NewOrderRequest, opts ...grpc.CallOption) (*proto1.Order, error)
 	FinalizeOrder(ctx context.Context, in *FinalizeOrderRequest, opts ...grpc.CallOption) (*proto1.Order, error)
+	RevokeCertByKey(ctx context.Context, in *RevokeCertByKeyRequest, opts ...grpc.CallOption) (*RevokeCertByKeyResponse, error)
+	GetRateLimitUsage(ctx context.Context, in *RateLimitUsageRequest, opts ...grpc.CallOption) (*RateLimitUsages, error)
 }
 
 type registrationAuthorityClient struct {
@@ -662,6 +925,24 @@ func (c *registrationAuthorityClient) FinalizeOrder(ctx context.Context, in *Fin
 	return out, nil
 }
 
+func (c *registrationAuthorityClient) RevokeCertByKey(ctx context.Context, in *RevokeCertByKeyRequest, opts ...grpc.CallOption) (*RevokeCertByKeyResponse, error) {
+	out := new(RevokeCertByKeyResponse)
+	err := c.cc.Invoke(ctx, "/ra.RegistrationAuthority/RevokeCertByKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *registrationAuthorityClient) GetRateLimitUsage(ctx context.Context, in *RateLimitUsageRequest, opts ...grpc.CallOption) (*RateLimitUsages, error) {
+	out := new(RateLimitUsages)
+	err := c.cc.Invoke(ctx, "/ra.RegistrationAuthority/GetRateLimitUsage", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RegistrationAuthorityServer is the server API for RegistrationAuthority service.
 type RegistrationAuthorityServer interface {
 	NewRegistration(context.Context, *proto1.Registration) (*proto1.Registration, error)
@@ -675,6 +956,8 @@ type RegistrationAuthorityServer interface {
 	AdministrativelyRevokeCertificate(context.Context, *AdministrativelyRevokeCertificateRequest) (*proto1.Empty, error)
 	NewOrder(context.Context, *NewOrderRequest) (*proto1.Order, error)
 	FinalizeOrder(context.Context, *FinalizeOrderRequest) (*proto1.Order, error)
+	RevokeCertByKey(context.Context, *RevokeCertByKeyRequest) (*RevokeCertByKeyResponse, error)
+	GetRateLimitUsage(context.Context, *RateLimitUsageRequest) (*RateLimitUsages, error)
 }
 
 // UnimplementedRegistrationAuthorityServer can be embedded to have forward compatible implementations.
@@ -714,6 +997,12 @@ func (*UnimplementedRegistrationAuthorityServer) NewOrder(ctx context.Context, r
 func (*UnimplementedRegistrationAuthorityServer) FinalizeOrder(ctx context.Context, req *FinalizeOrderRequest) (*proto1.Order, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method FinalizeOrder not implemented")
 }
+func (*UnimplementedRegistrationAuthorityServer) RevokeCertByKey(ctx context.Context, req *RevokeCertByKeyRequest) (*RevokeCertByKeyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeCertByKey not implemented")
+}
+func (*UnimplementedRegistrationAuthorityServer) GetRateLimitUsage(ctx context.Context, req *RateLimitUsageRequest) (*RateLimitUsages, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetRateLimitUsage not implemented")
+}
 
 func RegisterRegistrationAuthorityServer(s *grpc.Server, srv RegistrationAuthorityServer) {
 	s.RegisterService(&_RegistrationAuthority_serviceDesc, srv)
@@ -917,6 +1206,42 @@ func _RegistrationAuthority_FinalizeOrder_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RegistrationAuthority_RevokeCertByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeCertByKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).RevokeCertByKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/RevokeCertByKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).RevokeCertByKey(ctx, req.(*RevokeCertByKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RegistrationAuthority_GetRateLimitUsage_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RateLimitUsageRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RegistrationAuthorityServer).GetRateLimitUsage(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ra.RegistrationAuthority/GetRateLimitUsage",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RegistrationAuthorityServer).GetRateLimitUsage(ctx, req.(*RateLimitUsageRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "ra.RegistrationAuthority",
 	HandlerType: (*RegistrationAuthorityServer)(nil),
@@ -965,6 +1290,14 @@ var _RegistrationAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "FinalizeOrder",
 			Handler:    _RegistrationAuthority_FinalizeOrder_Handler,
 		},
+		{
+			MethodName: "RevokeCertByKey",
+			Handler:    _RegistrationAuthority_RevokeCertByKey_Handler,
+		},
+		{
+			MethodName: "GetRateLimitUsage",
+			Handler:    _RegistrationAuthority_GetRateLimitUsage_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "ra/proto/ra.proto",
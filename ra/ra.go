@@ -1,6 +1,8 @@
 package ra
 
 import (
+	"bytes"
+	"crypto"
 	"crypto/x509"
 	"fmt"
 	"net"
@@ -36,6 +38,7 @@ import (
 	"github.com/letsencrypt/boulder/web"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/weppos/publicsuffix-go/publicsuffix"
+	"golang.org/x/crypto/ocsp"
 	"golang.org/x/net/context"
 	grpc "google.golang.org/grpc"
 )
@@ -48,6 +51,14 @@ type caaChecker interface {
 	) (*vaPB.IsCAAValidResponse, error)
 }
 
+// CAAReporter reports that a domain's CAA records prevented issuance to the
+// targets named in its iodef records. Report must not wait for the report to
+// be sent, so as not to delay the response to the request that was refused.
+// It is implemented by mail.IodefReporter.
+type CAAReporter interface {
+	Report(ctx context.Context, domain, reason string, targets []string) error
+}
+
 // RegistrationAuthorityImpl defines an RA.
 //
 // NOTE: All of the fields in RegistrationAuthorityImpl need to be
@@ -73,9 +84,15 @@ type RegistrationAuthorityImpl struct {
 	forceCNFromSAN               bool
 	reuseValidAuthz              bool
 	orderLifetime                time.Duration
+	// certProfiles maps the names of the certificate profiles that orders may
+	// request to the set of account IDs allowed to request them. A nil set
+	// allows every account.
+	certProfiles map[string]map[int64]bool
 
-	issuer *x509.Certificate
-	purger akamaipb.AkamaiPurgerClient
+	// issuers are used to generate OCSP request URLs to purge, each for the
+	// certificates it issued.
+	issuers []*x509.Certificate
+	purger  akamaipb.AkamaiPurgerClient
 
 	regByIPStats           metrics.Scope
 	regByIPRangeStats      metrics.Scope
@@ -86,6 +103,28 @@ type RegistrationAuthorityImpl struct {
 
 	ctpolicy        *ctpolicy.CTPolicy
 	ctpolicyResults *prometheus.HistogramVec
+
+	// Limiter, if set, enforces the RegistrationsPerIP,
+	// RegistrationsPerIPRange, CertificatesPerName, CertificatesPerFQDNSet and
+	// NewOrdersPerAccount limits with token buckets instead of counting rows
+	// in the database.
+	Limiter *ratelimit.Limiter
+
+	// CAAReporter, if set, is sent a report whenever rechecking CAA finds that
+	// a domain's CAA records prevent issuance and name iodef targets.
+	CAAReporter CAAReporter
+
+	// OCSPStore, if set, is where ocsp-updater also stores OCSP responses for
+	// ocsp-responder to serve. With the RevokeAtRA feature the revoked
+	// response is written to it as soon as a certificate is revoked, replacing
+	// the good response it would otherwise serve until that expired.
+	OCSPStore OCSPResponseStore
+}
+
+// OCSPResponseStore is somewhere other than the database that OCSP responses
+// are served from. It is satisfied by *rocsp.Client.
+type OCSPResponseStore interface {
+	StoreResponse(ctx context.Context, serial string, resp []byte, ttl time.Duration) error
 }
 
 // NewRegistrationAuthorityImpl constructs a new RA object.
@@ -105,7 +144,8 @@ func NewRegistrationAuthorityImpl(
 	orderLifetime time.Duration,
 	ctp *ctpolicy.CTPolicy,
 	purger akamaipb.AkamaiPurgerClient,
-	issuer *x509.Certificate,
+	issuers []*x509.Certificate,
+	certProfiles map[string]CertProfilePolicy,
 ) *RegistrationAuthorityImpl {
 	ctpolicyResults := prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
@@ -117,6 +157,18 @@ func NewRegistrationAuthorityImpl(
 	)
 	stats.MustRegister(ctpolicyResults)
 
+	profileAllowLists := make(map[string]map[int64]bool, len(certProfiles))
+	for name, policy := range certProfiles {
+		var allowed map[int64]bool
+		if len(policy.AllowedAccounts) > 0 {
+			allowed = make(map[int64]bool, len(policy.AllowedAccounts))
+			for _, id := range policy.AllowedAccounts {
+				allowed[id] = true
+			}
+		}
+		profileAllowLists[name] = allowed
+	}
+
 	ra := &RegistrationAuthorityImpl{
 		stats:                        stats,
 		clk:                          clk,
@@ -141,12 +193,48 @@ func NewRegistrationAuthorityImpl(
 		ctpolicy:                     ctp,
 		ctpolicyResults:              ctpolicyResults,
 		purger:                       purger,
-		issuer:                       issuer,
+		issuers:                      issuers,
+		certProfiles:                 profileAllowLists,
 	}
 	return ra
 }
 
-func (ra *RegistrationAuthorityImpl) SetRateLimitPoliciesFile(filename string) error {
+// CertProfilePolicy restricts which accounts may request a certificate profile
+// for their orders.
+type CertProfilePolicy struct {
+	// AllowedAccounts lists the IDs of the accounts that may request the
+	// profile. If it is empty every account may.
+	AllowedAccounts []int64
+}
+
+// checkCertProfile returns an error if the account isn't allowed to request
+// the named certificate profile. The empty name, which selects the CA's default
+// profile, is always allowed. Any other name is refused while the
+// CertificateProfiles feature is disabled, since the SA would not store it and
+// the order would silently be issued under the default profile.
+func (ra *RegistrationAuthorityImpl) checkCertProfile(name string, regID int64) error {
+	if name == "" {
+		return nil
+	}
+	if !features.Enabled(features.CertificateProfiles) {
+		return berrors.MalformedError("certificate profiles are not supported")
+	}
+	allowed, ok := ra.certProfiles[name]
+	if !ok {
+		return berrors.MalformedError("certificate profile %q is not supported", name)
+	}
+	if allowed != nil && !allowed[regID] {
+		return berrors.UnauthorizedError("account is not allowed to request certificate profile %q", name)
+	}
+	return nil
+}
+
+// SetRateLimitPoliciesFile loads the rate limit policies from filename and
+// starts a reloader in case the file changes. A changed file that would remove
+// more than maxRemoved, a fraction between zero and one, of the entries of the
+// loaded policies is refused. Zero means any number of entries may be removed.
+func (ra *RegistrationAuthorityImpl) SetRateLimitPoliciesFile(filename string, maxRemoved float64) error {
+	ra.rlPolicies = ratelimit.NewWithMaxRemoved(maxRemoved)
 	_, err := reloader.New(filename, ra.rlPolicies.LoadPolicies, ra.rateLimitPoliciesLoadError)
 	if err != nil {
 		return err
@@ -177,6 +265,9 @@ type certificateRequestEvent struct {
 	Requester int64 `json:",omitempty"`
 	// OrderID is the associated order ID (may be empty for an ACME v1 issuance)
 	OrderID int64 `json:",omitempty"`
+	// CertProfileName is the certificate profile requested by the order, empty
+	// for the CA's default profile
+	CertProfileName string `json:",omitempty"`
 	// SerialNumber is the string representation of the issued certificate's
 	// serial number
 	SerialNumber string `json:",omitempty"`
@@ -212,10 +303,13 @@ type registrationCounter func(context.Context, net.IP, time.Time, time.Time) (in
 
 // checkRegistrationIPLimit checks a specific registraton limit by using the
 // provided registrationCounter function to determine if the limit has been
-// exceeded for a given IP or IP range
+// exceeded for a given IP or IP range. If the RA has a Limiter the named
+// limit's bucket for key is checked instead.
 func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 	ctx context.Context,
+	name string,
 	limit ratelimit.RateLimitPolicy,
+	key string,
 	ip net.IP,
 	counter registrationCounter) error {
 
@@ -223,6 +317,18 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 		return nil
 	}
 
+	if ra.Limiter != nil {
+		d, err := ra.Limiter.Check(ctx, name, limit, key, noRegistrationID, 1)
+		if err != nil {
+			return err
+		}
+		if !d.Allowed {
+			return berrors.RateLimitExceededError(name, key, ra.clk.Now().Add(d.RetryIn),
+				"too many registrations for this IP")
+		}
+		return nil
+	}
+
 	now := ra.clk.Now()
 	windowBegin := limit.WindowBegin(now)
 	count, err := counter(ctx, ip, windowBegin, now)
@@ -231,7 +337,10 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationIPLimit(
 	}
 
 	if count >= limit.GetThreshold(ip.String(), noRegistrationID) {
-		return berrors.RateLimitError("too many registrations for this IP")
+		// The SA only counts registrations, so when the oldest of them leaves
+		// the window, allowing another, isn't known.
+		return berrors.RateLimitExceededError(name, key, time.Time{},
+			"too many registrations for this IP")
 	}
 
 	return nil
@@ -243,7 +352,8 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context
 	// Check the registrations per IP limit using the CountRegistrationsByIP SA
 	// function that matches IP addresses exactly
 	exactRegLimit := ra.rlPolicies.RegistrationsPerIP()
-	err := ra.checkRegistrationIPLimit(ctx, exactRegLimit, ip, ra.SA.CountRegistrationsByIP)
+	err := ra.checkRegistrationIPLimit(ctx, ratelimit.RegistrationsPerIPLimit, exactRegLimit,
+		ip.String(), ip, ra.SA.CountRegistrationsByIP)
 	if err != nil {
 		ra.regByIPStats.Inc("Exceeded", 1)
 		ra.log.Infof("Rate limit exceeded, RegistrationsByIP, IP: %s", ip)
@@ -262,22 +372,72 @@ func (ra *RegistrationAuthorityImpl) checkRegistrationLimits(ctx context.Context
 	// CountRegistrationsByIPRange SA function that fuzzy-matches IPv6 addresses
 	// within a larger address range
 	fuzzyRegLimit := ra.rlPolicies.RegistrationsPerIPRange()
-	err = ra.checkRegistrationIPLimit(ctx, fuzzyRegLimit, ip, ra.SA.CountRegistrationsByIPRange)
+	err = ra.checkRegistrationIPLimit(ctx, ratelimit.RegistrationsPerIPRangeLimit, fuzzyRegLimit,
+		ipRangeKey(ip), ip, ra.SA.CountRegistrationsByIPRange)
 	if err != nil {
 		ra.regByIPRangeStats.Inc("Exceeded", 1)
 		ra.log.Infof("Rate limit exceeded, RegistrationsByIPRange, IP: %s", ip)
 		// For the fuzzyRegLimit we use a new error message that specifically
 		// mentions that the limit being exceeded is applied to a *range* of IPs
-		return berrors.RateLimitError("too many registrations for this IP range")
+		var resetAt time.Time
+		if berr, ok := err.(*berrors.BoulderError); ok && berr.LimitName != "" {
+			resetAt = berr.ResetAt
+		}
+		return berrors.RateLimitExceededError(ratelimit.RegistrationsPerIPRangeLimit, ipRangeKey(ip), resetAt,
+			"too many registrations for this IP range")
 	}
 	ra.regByIPRangeStats.Inc("Pass", 1)
 
 	return nil
 }
 
+// ipRangeKey returns the IPv6 /48 containing ip, in CIDR notation. This is the
+// same range CountRegistrationsByIPRange counts registrations within, and is
+// used as the Limiter's key for the RegistrationsPerIPRange limit.
+func ipRangeKey(ip net.IP) string {
+	mask := net.CIDRMask(48, 128)
+	ipNet := net.IPNet{IP: ip.To16().Mask(mask), Mask: mask}
+	return ipNet.String()
+}
+
+// spendLimit records one item against the named limit's bucket for each of
+// keys in the RA's Limiter, if it has one. The action being limited has
+// already happened by the time this is called, so failures are only logged.
+func (ra *RegistrationAuthorityImpl) spendLimit(
+	ctx context.Context,
+	name string,
+	limit ratelimit.RateLimitPolicy,
+	keys []string,
+	regID int64) {
+
+	if ra.Limiter == nil {
+		return
+	}
+	for _, key := range keys {
+		_, err := ra.Limiter.Spend(ctx, name, limit, key, regID, 1)
+		if err != nil {
+			ra.log.Warningf("Failed to spend %s rate limit for %q: %s", name, key, err)
+		}
+	}
+}
+
+// spendRegistrationLimits records a new registration from ip against the
+// RegistrationsPerIP and RegistrationsPerIPRange limits.
+func (ra *RegistrationAuthorityImpl) spendRegistrationLimits(ctx context.Context, ip net.IP) {
+	ra.spendLimit(ctx, ratelimit.RegistrationsPerIPLimit, ra.rlPolicies.RegistrationsPerIP(),
+		[]string{ip.String()}, noRegistrationID)
+	if ip.To4() == nil {
+		ra.spendLimit(ctx, ratelimit.RegistrationsPerIPRangeLimit, ra.rlPolicies.RegistrationsPerIPRange(),
+			[]string{ipRangeKey(ip)}, noRegistrationID)
+	}
+}
+
 // NewRegistration constructs a new Registration from a request.
 func (ra *RegistrationAuthorityImpl) NewRegistration(ctx context.Context, init core.Registration) (core.Registration, error) {
-	if err := ra.keyPolicy.GoodKey(init.Key.Key); err != nil {
+	if err := ra.keyPolicy.GoodKey(ctx, init.Key.Key); err != nil {
+		if !berrors.Is(err, berrors.Malformed) {
+			return core.Registration{}, err
+		}
 		return core.Registration{}, berrors.MalformedError("invalid public key: %s", err.Error())
 	}
 	if err := ra.checkRegistrationLimits(ctx, init.InitialIP); err != nil {
@@ -290,9 +450,10 @@ func (ra *RegistrationAuthorityImpl) NewRegistration(ctx context.Context, init c
 	}
 	_ = mergeUpdate(&reg, init)
 
-	// This field isn't updatable by the end user, so it isn't copied by
-	// MergeUpdate. But we need to fill it in for new registrations.
+	// These fields aren't updatable by the end user, so they aren't copied by
+	// MergeUpdate. But we need to fill them in for new registrations.
 	reg.InitialIP = init.InitialIP
+	reg.ExternalAccountKeyID = init.ExternalAccountKeyID
 
 	if err := ra.validateContacts(ctx, reg.Contact); err != nil {
 		return core.Registration{}, err
@@ -303,6 +464,7 @@ func (ra *RegistrationAuthorityImpl) NewRegistration(ctx context.Context, init c
 	if err != nil {
 		return core.Registration{}, err
 	}
+	ra.spendRegistrationLimits(ctx, init.InitialIP)
 
 	ra.stats.Inc("NewRegistrations", 1)
 	return reg, nil
@@ -406,7 +568,11 @@ func (ra *RegistrationAuthorityImpl) checkPendingAuthorizationLimit(ctx context.
 		if count >= limit.GetThreshold(noKey, regID) {
 			ra.pendAuthByRegIDStats.Inc("Exceeded", 1)
 			ra.log.Infof("Rate limit exceeded, PendingAuthorizationsByRegID, regID: %d", regID)
-			return berrors.RateLimitError("too many currently pending authorizations")
+			// Pending authorizations stop counting once they are validated or
+			// expire, neither of which the RA can predict.
+			return berrors.RateLimitExceededError(ratelimit.PendingAuthorizationsPerAccountLimit,
+				ratelimit.RegIDKey(regID), time.Time{},
+				"too many currently pending authorizations")
 		}
 		ra.pendAuthByRegIDStats.Inc("Pass", 1)
 	}
@@ -415,12 +581,42 @@ func (ra *RegistrationAuthorityImpl) checkPendingAuthorizationLimit(ctx context.
 
 func (ra *RegistrationAuthorityImpl) checkInvalidAuthorizationLimit(ctx context.Context, regID int64, hostname string) error {
 	limit := ra.rlPolicies.InvalidAuthorizationsPerAccount()
+	if !limit.Enabled() {
+		return nil
+	}
+	count, ok, err := ra.countInvalidAuthorizations(ctx, limit, regID, hostname)
+	if err != nil || !ok {
+		return err
+	}
+	// Most rate limits have a key for overrides, but there is no meaningful key
+	// here.
+	noKey := ""
+	if count >= int64(limit.GetThreshold(noKey, regID)) {
+		ra.log.Infof("Rate limit exceeded, InvalidAuthorizationsByRegID, regID: %d", regID)
+		// The SA only counts invalid authorizations, so when the oldest of them
+		// leaves the window isn't known.
+		return berrors.RateLimitExceededError(ratelimit.InvalidAuthorizationsPerAccountLimit,
+			ratelimit.RegIDKey(regID)+":"+hostname, time.Time{},
+			"too many failed authorizations recently")
+	}
+	return nil
+}
+
+// countInvalidAuthorizations counts the account's invalid authorizations for
+// hostname within the limit's window. It returns false if the SA can't count
+// them.
+func (ra *RegistrationAuthorityImpl) countInvalidAuthorizations(
+	ctx context.Context,
+	limit ratelimit.RateLimitPolicy,
+	regID int64,
+	hostname string) (int64, bool, error) {
+
 	// The SA.CountInvalidAuthorizations method is not implemented on the wrapper
 	// interface, because we want to move towards using gRPC interfaces more
 	// directly. So we type-assert the wrapper to a gRPC-specific type.
 	saGRPC, ok := ra.SA.(*bgrpc.StorageAuthorityClientWrapper)
-	if !limit.Enabled() || !ok {
-		return nil
+	if !ok {
+		return 0, false, nil
 	}
 	latest := ra.clk.Now().Add(ra.pendingAuthorizationLifetime)
 	earliest := latest.Add(-limit.Window.Duration)
@@ -435,19 +631,12 @@ func (ra *RegistrationAuthorityImpl) checkInvalidAuthorizationLimit(ctx context.
 		},
 	})
 	if err != nil {
-		return err
+		return 0, false, err
 	}
-	if count == nil {
-		return fmt.Errorf("nil count")
-	}
-	// Most rate limits have a key for overrides, but there is no meaningful key
-	// here.
-	noKey := ""
-	if *count.Count >= int64(limit.GetThreshold(noKey, regID)) {
-		ra.log.Infof("Rate limit exceeded, InvalidAuthorizationsByRegID, regID: %d", regID)
-		return berrors.RateLimitError("too many failed authorizations recently")
+	if count == nil || count.Count == nil {
+		return 0, false, fmt.Errorf("nil count")
 	}
-	return nil
+	return *count.Count, true, nil
 }
 
 // checkNewOrdersPerAccountLimit enforces the rlPolicies `NewOrdersPerAccount`
@@ -458,17 +647,32 @@ func (ra *RegistrationAuthorityImpl) checkNewOrdersPerAccountLimit(ctx context.C
 	if !limit.Enabled() {
 		return nil
 	}
-	latest := ra.clk.Now()
-	earliest := latest.Add(-limit.Window.Duration)
-	count, err := ra.SA.CountOrders(ctx, acctID, earliest, latest)
-	if err != nil {
-		return err
+	var exceeded bool
+	// Without the Limiter the SA only counts orders, so the reset time isn't
+	// known.
+	var resetAt time.Time
+	if ra.Limiter != nil {
+		d, err := ra.Limiter.Check(ctx, ratelimit.NewOrdersPerAccountLimit, limit, ratelimit.RegIDKey(acctID), acctID, 1)
+		if err != nil {
+			return err
+		}
+		exceeded = !d.Allowed
+		resetAt = ra.clk.Now().Add(d.RetryIn)
+	} else {
+		latest := ra.clk.Now()
+		earliest := latest.Add(-limit.Window.Duration)
+		count, err := ra.SA.CountOrders(ctx, acctID, earliest, latest)
+		if err != nil {
+			return err
+		}
+		// There is no meaningful override key to use for this rate limit
+		noKey := ""
+		exceeded = count >= limit.GetThreshold(noKey, acctID)
 	}
-	// There is no meaningful override key to use for this rate limit
-	noKey := ""
-	if count >= limit.GetThreshold(noKey, acctID) {
+	if exceeded {
 		ra.newOrderByRegIDStats.Inc("Exceeded", 1)
-		return berrors.RateLimitError("too many new orders recently")
+		return berrors.RateLimitExceededError(ratelimit.NewOrdersPerAccountLimit, ratelimit.RegIDKey(acctID), resetAt,
+			"too many new orders recently")
 	}
 	ra.newOrderByRegIDStats.Inc("Pass", 1)
 	return nil
@@ -601,7 +805,11 @@ func (ra *RegistrationAuthorityImpl) MatchesCSR(parsedCertificate *x509.Certific
 	if !reflect.DeepEqual(parsedNames, hostNames) {
 		return berrors.InternalServerError("generated certificate DNSNames don't match CSR DNSNames")
 	}
-	if !reflect.DeepEqual(parsedCertificate.IPAddresses, csr.IPAddresses) {
+	parsedIPs := core.IdentifierNames(nil, parsedCertificate.IPAddresses)
+	csrIPs := core.IdentifierNames(nil, csr.IPAddresses)
+	sort.Strings(parsedIPs)
+	sort.Strings(csrIPs)
+	if !reflect.DeepEqual(parsedIPs, csrIPs) {
 		return berrors.InternalServerError("generated certificate IPAddresses don't match CSR IPAddresses")
 	}
 	if !reflect.DeepEqual(parsedCertificate.EmailAddresses, csr.EmailAddresses) {
@@ -781,6 +989,7 @@ func (ra *RegistrationAuthorityImpl) recheckCAA(ctx context.Context, authzs []*c
 				)
 			} else if resp.Problem != nil {
 				err = berrors.CAAError(*resp.Problem.Detail)
+				ra.reportCAA(name, *resp.Problem.Detail, resp.Iodef)
 			}
 			ch <- err
 		}(authz)
@@ -799,6 +1008,22 @@ func (ra *RegistrationAuthorityImpl) recheckCAA(ctx context.Context, authzs []*c
 	return nil
 }
 
+// reportCAA sends a report that CAA prevented issuance for domain, for the
+// given reason, to the targets of the domain's iodef records if a CAAReporter
+// is set. It is called both when the VA finds CAA prevents issuance while
+// validating a challenge and when rechecking CAA at finalization.
+func (ra *RegistrationAuthorityImpl) reportCAA(domain, reason string, targets []string) {
+	if ra.CAAReporter == nil || len(targets) == 0 {
+		return
+	}
+	// The request's context may be cancelled once it has been answered, so
+	// the report can't use it.
+	err := ra.CAAReporter.Report(context.Background(), domain, reason, targets)
+	if err != nil {
+		ra.log.Warningf("reporting CAA refusal for %s: %s", domain, err)
+	}
+}
+
 // failOrder marks an order as failed by setting the problem details field of
 // the order & persisting it through the SA. If an error occurs doing this we
 // log it and return the order as-is. There aren't any alternatives if we can't
@@ -846,19 +1071,28 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		return nil, berrors.InternalServerError("Order has no associated names")
 	}
 
+	// The profile was checked when the order was created but the account may
+	// have since been removed from its allow list.
+	if err := ra.checkCertProfile(order.GetCertificateProfileName(), *order.RegistrationID); err != nil {
+		return nil, err
+	}
+
 	// Parse the CSR from the request
 	csrOb, err := x509.ParseCertificateRequest(req.Csr)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := csrlib.VerifyCSR(csrOb, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, *req.Order.RegistrationID); err != nil {
+	if err := csrlib.VerifyCSR(ctx, csrOb, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, *req.Order.RegistrationID); err != nil {
+		if berrors.Is(err, berrors.InternalServer) {
+			return nil, err
+		}
 		return nil, berrors.MalformedError(err.Error())
 	}
 
 	// Dedupe, lowercase and sort both the names from the CSR and the names in the
 	// order.
-	csrNames := core.UniqueLowerNames(csrOb.DNSNames)
+	csrNames := core.UniqueLowerNames(core.IdentifierNames(csrOb.DNSNames, csrOb.IPAddresses))
 	orderNames := core.UniqueLowerNames(order.Names)
 
 	// Immediately reject the request if the number of names differ
@@ -895,7 +1129,7 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 		Bytes: req.Csr,
 		CSR:   csrOb,
 	}
-	cert, err := ra.issueCertificate(ctx, issueReq, accountID(*order.RegistrationID), orderID(*order.Id))
+	cert, err := ra.issueCertificate(ctx, issueReq, accountID(*order.RegistrationID), orderID(*order.Id), order.GetCertificateProfileName())
 	if err != nil {
 		// Fail the order. The problem is computed using
 		// `web.ProblemDetailsForError`, the same function the WFE uses to convert
@@ -936,13 +1170,16 @@ func (ra *RegistrationAuthorityImpl) FinalizeOrder(ctx context.Context, req *rap
 // NewCertificate requests the issuance of a certificate.
 func (ra *RegistrationAuthorityImpl) NewCertificate(ctx context.Context, req core.CertificateRequest, regID int64) (core.Certificate, error) {
 	// Verify the CSR
-	if err := csrlib.VerifyCSR(req.CSR, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, regID); err != nil {
+	if err := csrlib.VerifyCSR(ctx, req.CSR, ra.maxNames, &ra.keyPolicy, ra.PA, ra.forceCNFromSAN, regID); err != nil {
+		if berrors.Is(err, berrors.InternalServer) {
+			return core.Certificate{}, err
+		}
 		return core.Certificate{}, berrors.MalformedError(err.Error())
 	}
 	// NewCertificate provides an order ID of 0, indicating this is a classic ACME
 	// v1 issuance request from the new certificate endpoint that is not
 	// associated with an ACME v2 order.
-	return ra.issueCertificate(ctx, req, accountID(regID), orderID(0))
+	return ra.issueCertificate(ctx, req, accountID(regID), orderID(0), "")
 }
 
 // To help minimize the chance that an accountID would be used as an order ID
@@ -952,21 +1189,25 @@ type accountID int64
 type orderID int64
 
 // issueCertificate sets up a log event structure and captures any errors
-// encountered during issuance, then calls issueCertificateInner.
+// encountered during issuance, then calls issueCertificateInner. The
+// certificate is issued under the named certificate profile, or the CA's
+// default profile if profileName is empty.
 func (ra *RegistrationAuthorityImpl) issueCertificate(
 	ctx context.Context,
 	req core.CertificateRequest,
 	acctID accountID,
-	oID orderID) (core.Certificate, error) {
+	oID orderID,
+	profileName string) (core.Certificate, error) {
 	// Construct the log event
 	logEvent := certificateRequestEvent{
-		ID:          core.NewToken(),
-		OrderID:     int64(oID),
-		Requester:   int64(acctID),
-		RequestTime: ra.clk.Now(),
+		ID:              core.NewToken(),
+		OrderID:         int64(oID),
+		CertProfileName: profileName,
+		Requester:       int64(acctID),
+		RequestTime:     ra.clk.Now(),
 	}
 	var result string
-	cert, err := ra.issueCertificateInner(ctx, req, acctID, oID, &logEvent)
+	cert, err := ra.issueCertificateInner(ctx, req, acctID, oID, profileName, &logEvent)
 	if err != nil {
 		logEvent.Error = err.Error()
 		result = "error"
@@ -986,6 +1227,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	req core.CertificateRequest,
 	acctID accountID,
 	oID orderID,
+	profileName string,
 	logEvent *certificateRequestEvent) (core.Certificate, error) {
 	emptyCert := core.Certificate{}
 	if acctID <= 0 {
@@ -1005,11 +1247,10 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 
 	csr := req.CSR
 	logEvent.CommonName = csr.Subject.CommonName
-	logEvent.Names = csr.DNSNames
-
-	// Validate that authorization key is authorized for all domains in the CSR
-	names := make([]string, len(csr.DNSNames))
-	copy(names, csr.DNSNames)
+	// Validate that authorization key is authorized for all domains and IP
+	// addresses in the CSR
+	names := core.IdentifierNames(csr.DNSNames, csr.IPAddresses)
+	logEvent.Names = names
 
 	if core.KeyDigestEquals(csr.PublicKey, account.Key) {
 		return emptyCert, berrors.MalformedError("certificate public key must be different than account key")
@@ -1065,9 +1306,10 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	acctIDInt := int64(acctID)
 	orderIDInt := int64(oID)
 	issueReq := &caPB.IssueCertificateRequest{
-		Csr:            csr.Raw,
-		RegistrationID: &acctIDInt,
-		OrderID:        &orderIDInt,
+		Csr:                    csr.Raw,
+		RegistrationID:         &acctIDInt,
+		OrderID:                &orderIDInt,
+		CertificateProfileName: &profileName,
 	}
 
 	// wrapError adds a prefix to an error. If the error is a boulder error then
@@ -1089,7 +1331,7 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	if err != nil {
 		return emptyCert, wrapError(err, "parsing precertificate")
 	}
-	scts, err := ra.getSCTs(ctx, precert.DER, parsedPrecert.NotAfter)
+	scts, err := ra.getSCTs(ctx, precert.DER, parsedPrecert.NotBefore, parsedPrecert.NotAfter)
 	if err != nil {
 		return emptyCert, wrapError(err, "getting SCTs")
 	}
@@ -1123,13 +1365,17 @@ func (ra *RegistrationAuthorityImpl) issueCertificateInner(
 	logEvent.NotBefore = parsedCertificate.NotBefore
 	logEvent.NotAfter = parsedCertificate.NotAfter
 
+	ra.spendCertificateLimits(ctx, names, account.ID)
 	ra.stats.Inc("NewCertificates", 1)
 	return cert, nil
 }
 
-func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, expiration time.Time) (core.SCTDERs, error) {
+// getSCTs returns SCTs for the precertificate cert, valid from notBefore until
+// expiration. If the EnforceSCTPolicy feature is enabled, it returns a
+// MissingSCTsError unless the SCTs satisfy the CT policies of the browsers.
+func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, notBefore, expiration time.Time) (core.SCTDERs, error) {
 	started := ra.clk.Now()
-	scts, err := ra.ctpolicy.GetSCTs(ctx, cert, expiration)
+	scts, err := ra.ctpolicy.GetSCTs(ctx, cert, notBefore, expiration)
 	took := ra.clk.Since(started)
 	// The final cert has already been issued so actually return it to the
 	// user even if this fails since we aren't actually doing anything with
@@ -1146,6 +1392,14 @@ func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, e
 		ra.ctpolicyResults.With(prometheus.Labels{"result": state}).Observe(took.Seconds())
 		return nil, err
 	}
+	if features.Enabled(features.EnforceSCTPolicy) {
+		err = ra.ctpolicy.CheckSCTs(scts, notBefore, expiration)
+		if err != nil {
+			ra.log.Warningf("SCTs don't satisfy CT policy: %s", err)
+			ra.ctpolicyResults.With(prometheus.Labels{"result": "policyFailure"}).Observe(took.Seconds())
+			return nil, err
+		}
+	}
 	ra.ctpolicyResults.With(prometheus.Labels{"result": "success"}).Observe(took.Seconds())
 	return scts, nil
 }
@@ -1156,6 +1410,12 @@ func (ra *RegistrationAuthorityImpl) getSCTs(ctx context.Context, cert []byte, e
 func domainsForRateLimiting(names []string) ([]string, error) {
 	var domains []string
 	for _, name := range names {
+		// An IP address is limited on its own, as if it were a registered
+		// domain.
+		if net.ParseIP(name) != nil {
+			domains = append(domains, name)
+			continue
+		}
 		domain, err := publicsuffix.Domain(name)
 		if err != nil {
 			// The only possible errors are:
@@ -1174,6 +1434,9 @@ func domainsForRateLimiting(names []string) ([]string, error) {
 func suffixesForRateLimiting(names []string) ([]string, error) {
 	var suffixMatches []string
 	for _, name := range names {
+		if net.ParseIP(name) != nil {
+			continue
+		}
 		_, err := publicsuffix.Domain(name)
 		if err != nil {
 			// Like `domainsForRateLimiting`, the only possible errors here are:
@@ -1221,6 +1484,33 @@ func (ra *RegistrationAuthorityImpl) enforceNameCounts(
 	return badNames, nil
 }
 
+// limitedNames is the equivalent of enforceNameCounts for an RA with a
+// Limiter, returning those of the names whose bucket for the named limit has
+// no room for another certificate, and when all of them will have room again.
+func (ra *RegistrationAuthorityImpl) limitedNames(
+	ctx context.Context,
+	name string,
+	names []string,
+	limit ratelimit.RateLimitPolicy,
+	regID int64) ([]string, time.Time, error) {
+
+	var badNames []string
+	var resetAt time.Time
+	for _, n := range names {
+		d, err := ra.Limiter.Check(ctx, name, limit, n, regID, 1)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if !d.Allowed {
+			badNames = append(badNames, n)
+			if allowedAt := ra.clk.Now().Add(d.RetryIn); allowedAt.After(resetAt) {
+				resetAt = allowedAt
+			}
+		}
+	}
+	return badNames, resetAt, nil
+}
+
 func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
 	tldNames, err := domainsForRateLimiting(names)
 	if err != nil {
@@ -1232,13 +1522,24 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 	}
 
 	var badNames []string
+	// Without the Limiter the SA only counts certificates, so the reset time
+	// isn't known.
+	var resetAt time.Time
+	var limiterResetAt time.Time
 	// Domains that are exactly equal to a public suffix are treated differently
 	// by enforcing the limit against only exact matches to the names, not
 	// matches to subdomains as well. This allows the owners of such domains to
 	// issue certificates even though issuance from their subdomains may
 	// constantly exceed the rate limit.
 	if len(exactPublicSuffixes) > 0 {
-		psNamesOutOfLimit, err := ra.enforceNameCounts(ctx, exactPublicSuffixes, limit, regID, ra.SA.CountCertificatesByExactNames)
+		var psNamesOutOfLimit []string
+		if ra.Limiter != nil {
+			// The Limiter's buckets are only ever spent for the exact names
+			// below, so the same limit can be used for public suffixes.
+			psNamesOutOfLimit, limiterResetAt, err = ra.limitedNames(ctx, ratelimit.CertificatesPerNameLimit, exactPublicSuffixes, limit, regID)
+		} else {
+			psNamesOutOfLimit, err = ra.enforceNameCounts(ctx, exactPublicSuffixes, limit, regID, ra.SA.CountCertificatesByExactNames)
+		}
 		if err != nil {
 			return fmt.Errorf("checking certificates per name limit (exact) for %q: %s",
 				names, err)
@@ -1249,7 +1550,16 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 	// If there are any tldNames, enforce the certificate count rate limit against
 	// them and any subdomains.
 	if len(tldNames) > 0 {
-		namesOutOfLimit, err := ra.enforceNameCounts(ctx, tldNames, limit, regID, ra.SA.CountCertificatesByNames)
+		var namesOutOfLimit []string
+		if ra.Limiter != nil {
+			var tldResetAt time.Time
+			namesOutOfLimit, tldResetAt, err = ra.limitedNames(ctx, ratelimit.CertificatesPerNameLimit, tldNames, limit, regID)
+			if tldResetAt.After(limiterResetAt) {
+				limiterResetAt = tldResetAt
+			}
+		} else {
+			namesOutOfLimit, err = ra.enforceNameCounts(ctx, tldNames, limit, regID, ra.SA.CountCertificatesByNames)
+		}
 		if err != nil {
 			return fmt.Errorf("checking certificates per name limit for %q: %s",
 				names, err)
@@ -1272,7 +1582,15 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 		domains := strings.Join(badNames, ", ")
 		ra.certsForDomainStats.Inc("Exceeded", 1)
 		ra.log.Infof("Rate limit exceeded, CertificatesForDomain, regID: %d, domains: %s", regID, domains)
-		return berrors.RateLimitError(
+		if ra.Limiter != nil {
+			resetAt = limiterResetAt
+		}
+		// The error can only carry one key, so the first name is reported. The
+		// request will be allowed once all of them have room again.
+		return berrors.RateLimitExceededError(
+			ratelimit.CertificatesPerNameLimit,
+			badNames[0],
+			resetAt,
 			"too many certificates already issued for: %s",
 			domains,
 		)
@@ -1283,13 +1601,30 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerNameLimit(ctx context.C
 }
 
 func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx context.Context, names []string, limit ratelimit.RateLimitPolicy, regID int64) error {
-	count, err := ra.SA.CountFQDNSets(ctx, limit.Window.Duration, names)
-	if err != nil {
-		return fmt.Errorf("checking duplicate certificate limit for %q: %s", names, err)
+	var exceeded bool
+	// Without the Limiter the SA only counts certificates, so the reset time
+	// isn't known.
+	var resetAt time.Time
+	if ra.Limiter != nil {
+		badSets, limiterResetAt, err := ra.limitedNames(ctx, ratelimit.CertificatesPerFQDNSetLimit, []string{fqdnSetKey(names)}, limit, regID)
+		if err != nil {
+			return fmt.Errorf("checking duplicate certificate limit for %q: %s", names, err)
+		}
+		exceeded = len(badSets) > 0
+		resetAt = limiterResetAt
+	} else {
+		count, err := ra.SA.CountFQDNSets(ctx, limit.Window.Duration, names)
+		if err != nil {
+			return fmt.Errorf("checking duplicate certificate limit for %q: %s", names, err)
+		}
+		exceeded = int(count) >= limit.GetThreshold(fqdnSetKey(names), regID)
 	}
 	names = core.UniqueLowerNames(names)
-	if int(count) >= limit.GetThreshold(strings.Join(names, ","), regID) {
-		return berrors.RateLimitError(
+	if exceeded {
+		return berrors.RateLimitExceededError(
+			ratelimit.CertificatesPerFQDNSetLimit,
+			fqdnSetKey(names),
+			resetAt,
 			"too many certificates already issued for exact set of domains: %s",
 			strings.Join(names, ","),
 		)
@@ -1297,6 +1632,34 @@ func (ra *RegistrationAuthorityImpl) checkCertificatesPerFQDNSetLimit(ctx contex
 	return nil
 }
 
+// fqdnSetKey returns the key used for overrides of, and the Limiter's buckets
+// for, the CertificatesPerFQDNSet limit.
+func fqdnSetKey(names []string) string {
+	return strings.Join(core.UniqueLowerNames(names), ",")
+}
+
+// spendCertificateLimits records a certificate issued for names against the
+// CertificatesPerName and CertificatesPerFQDNSet limits.
+func (ra *RegistrationAuthorityImpl) spendCertificateLimits(ctx context.Context, names []string, regID int64) {
+	if ra.Limiter == nil {
+		return
+	}
+	tldNames, err := domainsForRateLimiting(names)
+	if err != nil {
+		ra.log.Warningf("Failed to spend rate limits for %q: %s", names, err)
+		return
+	}
+	exactPublicSuffixes, err := suffixesForRateLimiting(names)
+	if err != nil {
+		ra.log.Warningf("Failed to spend rate limits for %q: %s", names, err)
+		return
+	}
+	ra.spendLimit(ctx, ratelimit.CertificatesPerNameLimit, ra.rlPolicies.CertificatesPerName(),
+		append(tldNames, exactPublicSuffixes...), regID)
+	ra.spendLimit(ctx, ratelimit.CertificatesPerFQDNSetLimit, ra.rlPolicies.CertificatesPerFQDNSet(),
+		[]string{fqdnSetKey(names)}, regID)
+}
+
 func (ra *RegistrationAuthorityImpl) checkLimits(ctx context.Context, names []string, regID int64) error {
 	certNameLimits := ra.rlPolicies.CertificatesPerName()
 	if certNameLimits.Enabled() {
@@ -1316,6 +1679,183 @@ func (ra *RegistrationAuthorityImpl) checkLimits(ctx context.Context, names []st
 	return nil
 }
 
+// GetRateLimitUsage reports how much of each enabled rate limit has been used
+// by the given account and domain, so that operators don't need to work it
+// out from the database. The account limits are reported if a registration ID
+// is given, and the certificate limits if a domain is, for the domain's
+// registered domain (or the domain itself if it is a public suffix or IP
+// address) and for certificates for exactly the domain. The invalid
+// authorizations limit needs both. Usage is taken from the RA's Limiter for
+// the limits it enforces.
+func (ra *RegistrationAuthorityImpl) GetRateLimitUsage(ctx context.Context, req *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	regID := req.GetRegistrationID()
+	domain := strings.ToLower(req.GetDomain())
+	if regID == 0 && domain == "" {
+		return nil, berrors.MalformedError("a registration ID or domain is required")
+	}
+	resp := &rapb.RateLimitUsages{}
+	add := func(name, key string, used, threshold int, limit ratelimit.RateLimitPolicy) {
+		used64, threshold64, window := int64(used), int64(threshold), int64(limit.Window.Duration)
+		resp.Usages = append(resp.Usages, &rapb.RateLimitUsage{
+			Name:      &name,
+			Key:       &key,
+			Used:      &used64,
+			Threshold: &threshold64,
+			Window:    &window,
+		})
+	}
+	// The account limits have no meaningful override key.
+	noKey := ""
+	now := ra.clk.Now()
+
+	if regID != 0 {
+		acctKey := ratelimit.RegIDKey(regID)
+		limit := ra.rlPolicies.PendingAuthorizationsPerAccount()
+		if limit.Enabled() {
+			count, err := ra.SA.CountPendingAuthorizations(ctx, regID)
+			if err != nil {
+				return nil, err
+			}
+			add(ratelimit.PendingAuthorizationsPerAccountLimit, acctKey, count, limit.GetThreshold(noKey, regID), limit)
+		}
+
+		limit = ra.rlPolicies.NewOrdersPerAccount()
+		if limit.Enabled() {
+			var count int
+			var err error
+			if ra.Limiter != nil {
+				count, err = ra.limiterUsage(ctx, ratelimit.NewOrdersPerAccountLimit, limit, acctKey, regID)
+			} else {
+				count, err = ra.SA.CountOrders(ctx, regID, now.Add(-limit.Window.Duration), now)
+			}
+			if err != nil {
+				return nil, err
+			}
+			add(ratelimit.NewOrdersPerAccountLimit, acctKey, count, limit.GetThreshold(noKey, regID), limit)
+		}
+
+		limit = ra.rlPolicies.InvalidAuthorizationsPerAccount()
+		if limit.Enabled() && domain != "" {
+			count, ok, err := ra.countInvalidAuthorizations(ctx, limit, regID, domain)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				add(ratelimit.InvalidAuthorizationsPerAccountLimit, acctKey+":"+domain, int(count),
+					limit.GetThreshold(noKey, regID), limit)
+			}
+		}
+	}
+
+	if domain != "" {
+		limit := ra.rlPolicies.CertificatesPerName()
+		if limit.Enabled() {
+			counts, err := ra.certificatesPerNameUsage(ctx, domain, limit, regID)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range counts {
+				add(ratelimit.CertificatesPerNameLimit, *entry.Name, int(*entry.Count),
+					limit.GetThreshold(*entry.Name, regID), limit)
+			}
+		}
+
+		limit = ra.rlPolicies.CertificatesPerFQDNSet()
+		if limit.Enabled() {
+			key := fqdnSetKey([]string{domain})
+			var count int
+			var err error
+			if ra.Limiter != nil {
+				count, err = ra.limiterUsage(ctx, ratelimit.CertificatesPerFQDNSetLimit, limit, key, regID)
+			} else {
+				var count64 int64
+				count64, err = ra.SA.CountFQDNSets(ctx, limit.Window.Duration, []string{domain})
+				count = int(count64)
+			}
+			if err != nil {
+				return nil, err
+			}
+			add(ratelimit.CertificatesPerFQDNSetLimit, key, count, limit.GetThreshold(key, regID), limit)
+		}
+	}
+	return resp, nil
+}
+
+// certificatesPerNameUsage returns the number of certificates counted against
+// the CertificatesPerName limit for the name domain is limited by.
+func (ra *RegistrationAuthorityImpl) certificatesPerNameUsage(
+	ctx context.Context,
+	domain string,
+	limit ratelimit.RateLimitPolicy,
+	regID int64) ([]*sapb.CountByNames_MapElement, error) {
+
+	tldNames, err := domainsForRateLimiting([]string{domain})
+	if err != nil {
+		return nil, err
+	}
+	exactPublicSuffixes, err := suffixesForRateLimiting([]string{domain})
+	if err != nil {
+		return nil, err
+	}
+	if ra.Limiter != nil {
+		var counts []*sapb.CountByNames_MapElement
+		for _, name := range append(tldNames, exactPublicSuffixes...) {
+			name := name
+			used, err := ra.limiterUsage(ctx, ratelimit.CertificatesPerNameLimit, limit, name, regID)
+			if err != nil {
+				return nil, err
+			}
+			used64 := int64(used)
+			counts = append(counts, &sapb.CountByNames_MapElement{Name: &name, Count: &used64})
+		}
+		return counts, nil
+	}
+	now := ra.clk.Now()
+	windowBegin := limit.WindowBegin(now)
+	var counts []*sapb.CountByNames_MapElement
+	if len(tldNames) > 0 {
+		tldCounts, err := ra.SA.CountCertificatesByNames(ctx, tldNames, windowBegin, now)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, tldCounts...)
+	}
+	if len(exactPublicSuffixes) > 0 {
+		suffixCounts, err := ra.SA.CountCertificatesByExactNames(ctx, exactPublicSuffixes, windowBegin, now)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, suffixCounts...)
+	}
+	for _, entry := range counts {
+		// Should not happen, but be defensive.
+		if entry.Count == nil || entry.Name == nil {
+			return nil, fmt.Errorf("CountByNames_MapElement had nil Count or Name")
+		}
+	}
+	return counts, nil
+}
+
+// limiterUsage returns how much of the named limit's bucket for key in the
+// RA's Limiter has been used, as a number of items.
+func (ra *RegistrationAuthorityImpl) limiterUsage(
+	ctx context.Context,
+	name string,
+	limit ratelimit.RateLimitPolicy,
+	key string,
+	regID int64) (int, error) {
+
+	d, err := ra.Limiter.Check(ctx, name, limit, key, regID, 0)
+	if err != nil {
+		return 0, err
+	}
+	used := limit.GetThreshold(key, regID) - d.Remaining
+	if used < 0 {
+		used = 0
+	}
+	return used, nil
+}
+
 // UpdateRegistration updates an existing Registration with new values. Caller
 // is responsible for making sure that update.Key is only different from base.Key
 // if it is being called from the WFE key change endpoint.
@@ -1488,7 +2028,7 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 		copy(challenges, authz.Challenges)
 		authz.Challenges = challenges
 
-		records, err := ra.VA.PerformValidation(vaCtx, authz.Identifier.Value, authz.Challenges[challIndex], authz)
+		records, iodef, err := ra.VA.PerformValidation(vaCtx, authz.Identifier.Value, authz.Challenges[challIndex], authz)
 		var prob *probs.ProblemDetails
 		if p, ok := err.(*probs.ProblemDetails); ok {
 			prob = p
@@ -1496,6 +2036,9 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 			prob = probs.ServerInternal("Could not communicate with VA")
 			ra.log.AuditErrf("Could not communicate with VA: %s", err)
 		}
+		if prob != nil && prob.Type == probs.CAAProblem {
+			ra.reportCAA(authz.Identifier.Value, prob.Detail, iodef)
+		}
 
 		// Save the updated records
 		challenge := &authz.Challenges[challIndex]
@@ -1523,6 +2066,17 @@ func (ra *RegistrationAuthorityImpl) PerformValidation(
 	return bgrpc.AuthzToPB(authz)
 }
 
+// issuerFor returns the configured issuer of cert.
+func (ra *RegistrationAuthorityImpl) issuerFor(cert *x509.Certificate) (*x509.Certificate, error) {
+	for _, issuer := range ra.issuers {
+		if bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+			return issuer, nil
+		}
+	}
+	return nil, berrors.InternalServerError("issuer %q of certificate %s is not configured",
+		cert.Issuer.CommonName, core.SerialToString(cert.SerialNumber))
+}
+
 func revokeEvent(state, serial, cn string, names []string, revocationCode revocation.Reason) string {
 	return fmt.Sprintf(
 		"Revocation - State: %s, Serial: %s, CN: %s, DNS Names: %s, Reason: %s",
@@ -1535,7 +2089,8 @@ func revokeEvent(state, serial, cn string, names []string, revocationCode revoca
 }
 
 // revokeCertificate generates a revoked OCSP response for the given certificate, stores
-// the revocation information, and purges OCSP request URLs from Akamai.
+// the revocation information and the response, and purges OCSP request URLs
+// from Akamai.
 func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert x509.Certificate, code revocation.Reason) error {
 	now := time.Now()
 	signRequest := core.OCSPSigningRequest{
@@ -1560,7 +2115,17 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert
 	if err != nil {
 		return err
 	}
-	purgeURLs, err := akamai.GeneratePurgeURLs(cert.Raw, ra.issuer)
+	if ra.OCSPStore != nil {
+		err = ra.storeRevokedResponse(ctx, serial, ocspResponse, now)
+		if err != nil {
+			return err
+		}
+	}
+	issuer, err := ra.issuerFor(&cert)
+	if err != nil {
+		return err
+	}
+	purgeURLs, err := akamai.GeneratePurgeURLs(cert.Raw, issuer)
 	if err != nil {
 		return err
 	}
@@ -1572,14 +2137,66 @@ func (ra *RegistrationAuthorityImpl) revokeCertificate(ctx context.Context, cert
 	return nil
 }
 
+// storeRevokedResponse writes a revoked OCSP response to the RA's OCSPStore,
+// expiring at the response's nextUpdate. ocsp-updater doesn't rewrite the
+// responses of certificates revoked by the RA, so until this succeeds the
+// store keeps serving the certificate's last good response.
+func (ra *RegistrationAuthorityImpl) storeRevokedResponse(ctx context.Context, serial string, ocspResponse []byte, now time.Time) error {
+	resp, err := ocsp.ParseResponse(ocspResponse, nil)
+	if err != nil {
+		return fmt.Errorf("parsing revoked OCSP response for serial %s: %s", serial, err)
+	}
+	ttl := resp.NextUpdate.Sub(now)
+	if ttl <= 0 {
+		return fmt.Errorf("revoked OCSP response for serial %s has nextUpdate %s in the past", serial, resp.NextUpdate)
+	}
+	err = ra.OCSPStore.StoreResponse(ctx, serial, ocspResponse, ttl)
+	if err != nil {
+		ra.log.AuditErrf("Failed to store revoked OCSP response for serial %s: %s", serial, err)
+		return err
+	}
+	return nil
+}
+
+// blockKey adds the public key of a certificate being revoked for
+// keyCompromise to the SA's blocked keys, so that it is rejected by the key policy from now on
+// and so that bad-key-revoker revokes any other certificates using it.
+// revokedBy is the ID of the registration that requested the revocation, or
+// zero if it wasn't requested by a registration.
+func (ra *RegistrationAuthorityImpl) blockKey(ctx context.Context, cert x509.Certificate, source, comment string, revokedBy int64) error {
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	added := ra.clk.Now().UnixNano()
+	req := &sapb.AddBlockedKeyRequest{
+		KeyHash:   keyHash,
+		Added:     &added,
+		Source:    &source,
+		RevokedBy: &revokedBy,
+	}
+	if comment != "" {
+		req.Comment = &comment
+	}
+	return ra.SA.AddBlockedKey(ctx, req)
+}
+
 // RevokeCertificateWithReg terminates trust in the certificate provided.
+// Revocations for keyCompromise block the key first, so that a failure to
+// block it fails the revocation and the request can be retried, rather than
+// leaving a revoked certificate whose key can still be used.
 func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, regID int64) error {
 	serialString := core.SerialToString(cert.SerialNumber)
 	var err error
-	if features.Enabled(features.RevokeAtRA) {
-		err = ra.revokeCertificate(ctx, cert, revocationCode)
-	} else {
-		err = ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
+	if revocationCode == revocation.KeyCompromise && features.Enabled(features.BlockedKeyTable) {
+		err = ra.blockKey(ctx, cert, "API", "", regID)
+	}
+	if err == nil {
+		if features.Enabled(features.RevokeAtRA) {
+			err = ra.revokeCertificate(ctx, cert, revocationCode)
+		} else {
+			err = ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
+		}
 	}
 
 	state := "Failure"
@@ -1612,10 +2229,15 @@ func (ra *RegistrationAuthorityImpl) RevokeCertificateWithReg(ctx context.Contex
 func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx context.Context, cert x509.Certificate, revocationCode revocation.Reason, user string) error {
 	serialString := core.SerialToString(cert.SerialNumber)
 	var err error
-	if features.Enabled(features.RevokeAtRA) {
-		err = ra.revokeCertificate(ctx, cert, revocationCode)
-	} else {
-		err = ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
+	if revocationCode == revocation.KeyCompromise && features.Enabled(features.BlockedKeyTable) {
+		err = ra.blockKey(ctx, cert, "admin-revoker", fmt.Sprintf("revoked by %s", user), 0)
+	}
+	if err == nil {
+		if features.Enabled(features.RevokeAtRA) {
+			err = ra.revokeCertificate(ctx, cert, revocationCode)
+		} else {
+			err = ra.SA.MarkCertificateRevoked(ctx, serialString, revocationCode)
+		}
 	}
 
 	state := "Failure"
@@ -1642,6 +2264,107 @@ func (ra *RegistrationAuthorityImpl) AdministrativelyRevokeCertificate(ctx conte
 	return nil
 }
 
+// maxInlineKeyRevocations is the most certificates, besides the requested one,
+// that RevokeCertByKey revokes itself. Each revocation signs an OCSP response
+// and purges the CDN, so a key used by many certificates could otherwise hold
+// up the WFE, RA and CA for as long as it takes to revoke them all. The
+// requested revocation blocks the key, so bad-key-revoker revokes the rest.
+const maxInlineKeyRevocations = 10
+
+// RevokeCertByKey revokes a certificate for keyCompromise on behalf of a
+// requester who has proved possession of its key, along with a bounded batch
+// of other unexpired, unrevoked certificates using the same key. It returns
+// the serials of all the certificates it revoked, starting with the requested
+// one. Other certificates can only be found once the SA has stored key hashes
+// for them, so they are only looked for when the BlockedKeyTable feature is
+// enabled, which also blocks the key so that bad-key-revoker revokes any
+// certificates left over. A failure to revoke one of them is logged rather
+// than returned for the same reason.
+func (ra *RegistrationAuthorityImpl) RevokeCertByKey(ctx context.Context, req *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error) {
+	cert, err := x509.ParseCertificate(req.Cert)
+	if err != nil {
+		return nil, berrors.MalformedError("unable to parse certificate: %s", err)
+	}
+	err = ra.RevokeCertificateWithReg(ctx, *cert, revocation.KeyCompromise, 0)
+	if err != nil {
+		return nil, err
+	}
+	serial := core.SerialToString(cert.SerialNumber)
+	resp := &rapb.RevokeCertByKeyResponse{Serials: []string{serial}}
+	if !features.Enabled(features.BlockedKeyTable) {
+		return resp, nil
+	}
+
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	now := ra.clk.Now().UnixNano()
+	// One more than the batch in case the requested certificate is included.
+	limit := int64(maxInlineKeyRevocations + 1)
+	others, err := ra.SA.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{
+		KeyHash:    keyHash,
+		ValidAfter: &now,
+		Limit:      &limit,
+	})
+	if err != nil {
+		ra.log.AuditErrf("Failed to find other certificates using the key of %s: %s", serial, err)
+		return resp, nil
+	}
+	attempted := 0
+	for _, otherSerial := range others.Serials {
+		if otherSerial == serial {
+			continue
+		}
+		if attempted == maxInlineKeyRevocations {
+			break
+		}
+		attempted++
+		revoked, err := ra.revokeSameKey(ctx, otherSerial, cert.PublicKey)
+		if err != nil {
+			ra.log.AuditErrf("Failed to revoke %s, which uses the same key as %s: %s", otherSerial, serial, err)
+			continue
+		}
+		if revoked {
+			resp.Serials = append(resp.Serials, otherSerial)
+		}
+	}
+	return resp, nil
+}
+
+// revokeSameKey revokes the certificate with the given serial for
+// keyCompromise if it uses key and isn't already revoked. It returns whether
+// the certificate was revoked.
+func (ra *RegistrationAuthorityImpl) revokeSameKey(ctx context.Context, serial string, key crypto.PublicKey) (bool, error) {
+	status, err := ra.SA.GetCertificateStatus(ctx, serial)
+	if err != nil {
+		return false, err
+	}
+	if status.Status == core.OCSPStatusRevoked {
+		return false, nil
+	}
+	stored, err := ra.SA.GetCertificate(ctx, serial)
+	if err != nil {
+		return false, err
+	}
+	cert, err := x509.ParseCertificate(stored.DER)
+	if err != nil {
+		return false, err
+	}
+	sameKey, err := core.PublicKeysEqual(cert.PublicKey, key)
+	if err != nil {
+		return false, err
+	}
+	if !sameKey {
+		return false, berrors.InternalServerError("certificate doesn't use the same key")
+	}
+	err = ra.RevokeCertificateWithReg(ctx, *cert, revocation.KeyCompromise, 0)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // onValidationUpdate saves a validation's new status after receiving an
 // authorization back from the VA.
 func (ra *RegistrationAuthorityImpl) onValidationUpdate(ctx context.Context, authz core.Authorization) error {
@@ -1698,14 +2421,18 @@ func (ra *RegistrationAuthorityImpl) DeactivateAuthorization(ctx context.Context
 // NewOrder creates a new order object
 func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.NewOrderRequest) (*corepb.Order, error) {
 	order := &corepb.Order{
-		RegistrationID: req.RegistrationID,
-		Names:          core.UniqueLowerNames(req.Names),
+		RegistrationID:         req.RegistrationID,
+		Names:                  core.UniqueLowerNames(req.Names),
+		CertificateProfileName: req.CertificateProfileName,
+	}
+
+	if err := ra.checkCertProfile(req.GetCertificateProfileName(), *order.RegistrationID); err != nil {
+		return nil, err
 	}
 
 	// Validate that our policy allows issuing for each of the names in the order
 	for _, name := range order.Names {
-		id := core.AcmeIdentifier{Value: name, Type: core.IdentifierDNS}
-		if err := ra.PA.WillingToIssueWildcard(id); err != nil {
+		if err := ra.PA.WillingToIssueWildcard(core.IdentifierForName(name)); err != nil {
 			return nil, err
 		}
 	}
@@ -1725,8 +2452,8 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	if err != nil && !berrors.Is(err, berrors.NotFound) {
 		return nil, err
 	}
-	// If there was an order, return it
-	if existingOrder != nil {
+	// If there was an order for the same certificate profile, return it
+	if existingOrder != nil && existingOrder.GetCertificateProfileName() == req.GetCertificateProfileName() {
 		return existingOrder, nil
 	}
 	// Otherwise we were unable to find an order to reuse, continue creating a new
@@ -1791,13 +2518,12 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 			continue
 		}
 		authz := nameToExistingAuthz[name]
-		// If the identifier is a wildcard and the existing authz only has one
-		// DNS-01 type challenge we can reuse it. In theory we will
-		// never get back an authorization for a domain with a wildcard prefix
+		// If the identifier is a wildcard and the existing authz only has
+		// DNS-01 or DNS-ACCOUNT-01 type challenges we can reuse it. In theory we
+		// will never get back an authorization for a domain with a wildcard prefix
 		// that doesn't meet this criteria from SA.GetAuthorizations but we verify
 		// again to be safe.
-		if strings.HasPrefix(name, "*.") &&
-			len(authz.Challenges) == 1 && *authz.Challenges[0].Type == core.ChallengeTypeDNS01 {
+		if strings.HasPrefix(name, "*.") && onlyDNSChallenges(authz.Challenges) {
 			order.Authorizations = append(order.Authorizations, *authz.Id)
 			continue
 		} else if !strings.HasPrefix(name, "*.") {
@@ -1829,10 +2555,7 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 		if err := ra.checkInvalidAuthorizationLimit(ctx, *order.RegistrationID, name); err != nil {
 			return nil, err
 		}
-		pb, err := ra.createPendingAuthz(ctx, *order.RegistrationID, core.AcmeIdentifier{
-			Type:  core.IdentifierDNS,
-			Value: name,
-		})
+		pb, err := ra.createPendingAuthz(ctx, *order.RegistrationID, core.IdentifierForName(name))
 		if err != nil {
 			return nil, err
 		}
@@ -1884,6 +2607,8 @@ func (ra *RegistrationAuthorityImpl) NewOrder(ctx context.Context, req *rapb.New
 	if err != nil {
 		return nil, err
 	}
+	ra.spendLimit(ctx, ratelimit.NewOrdersPerAccountLimit, ra.rlPolicies.NewOrdersPerAccount(),
+		[]string{ratelimit.RegIDKey(*order.RegistrationID)}, *order.RegistrationID)
 
 	return storedOrder, nil
 }
@@ -1937,6 +2662,20 @@ func (ra *RegistrationAuthorityImpl) authzValidChallengeEnabled(authz *core.Auth
 	return false
 }
 
+// onlyDNSChallenges returns true if there is at least one challenge and every
+// challenge is of a DNS-based type, as is required for wildcard identifiers.
+func onlyDNSChallenges(challenges []*corepb.Challenge) bool {
+	if len(challenges) == 0 {
+		return false
+	}
+	for _, chall := range challenges {
+		if *chall.Type != core.ChallengeTypeDNS01 && *chall.Type != core.ChallengeTypeDNSAccount01 {
+			return false
+		}
+	}
+	return true
+}
+
 // wildcardOverlap takes a slice of domain names and returns an error if any of
 // them is a non-wildcard FQDN that overlaps with a wildcard domain in the map.
 func wildcardOverlap(dnsNames []string) error {
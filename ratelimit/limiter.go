@@ -0,0 +1,154 @@
+package ratelimit
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+)
+
+// Names of the rate limits, matching their names in the policy file. They are
+// reported in rate limit errors, and those enforced by a Limiter are used as
+// the first part of the key for each bucket, so that the same override key
+// (e.g. an IP address) used by different limits gets a separate bucket for
+// each.
+const (
+	CertificatesPerNameLimit             = "certificatesPerName"
+	RegistrationsPerIPLimit              = "registrationsPerIP"
+	RegistrationsPerIPRangeLimit         = "registrationsPerIPRange"
+	PendingAuthorizationsPerAccountLimit = "pendingAuthorizationsPerAccount"
+	InvalidAuthorizationsPerAccountLimit = "invalidAuthorizationsPerAccount"
+	PendingOrdersPerAccountLimit         = "pendingOrdersPerAccount"
+	NewOrdersPerAccountLimit             = "newOrdersPerAccount"
+	CertificatesPerFQDNSetLimit          = "certificatesPerFQDNSet"
+)
+
+// Decision is the result of checking or spending against a bucket.
+type Decision struct {
+	// Allowed is true if the cost fits within the bucket.
+	Allowed bool
+	// Remaining is the number of items that could still be spent from the
+	// bucket once this decision's cost has been spent, if it was allowed.
+	Remaining int
+	// RetryIn is how long to wait before the cost would be allowed. It is zero
+	// if the cost was allowed.
+	RetryIn time.Duration
+	// ResetIn is how long until the bucket is full again.
+	ResetIn time.Duration
+
+	// newTAT is the bucket's theoretical arrival time once the cost has been
+	// spent.
+	newTAT time.Time
+}
+
+// Limiter enforces RateLimitPolicies with the Generic Cell Rate Algorithm
+// (GCRA), a token bucket that needs only a single timestamp of state for each
+// bucket: its theoretical arrival time (TAT). A policy allowing Threshold items
+// per Window refills its buckets at one item every Window/Threshold, up to a
+// burst of Threshold items. Buckets are keyed by the limit's name and the same
+// key GetThreshold uses for overrides, and their state is kept in a Source
+// shared by every RA, so no counting queries are needed.
+type Limiter struct {
+	source Source
+	clk    clock.Clock
+}
+
+// NewLimiter returns a Limiter keeping its bucket state in source.
+func NewLimiter(clk clock.Clock, source Source) *Limiter {
+	return &Limiter{source: source, clk: clk}
+}
+
+// bucketKey returns the key of the bucket for the named limit and key.
+func bucketKey(name, key string) string {
+	return name + ":" + key
+}
+
+// RegIDKey returns the bucket key to use for limits that are only kept per
+// account, such as NewOrdersPerAccount.
+func RegIDKey(regID int64) string {
+	return strconv.FormatInt(regID, 10)
+}
+
+// Check reports whether cost more items would fit within the named limit's
+// bucket for key, without spending them. The threshold is taken from policy
+// for key and regID as in GetThreshold. A disabled policy allows everything.
+func (l *Limiter) Check(ctx context.Context, name string, policy RateLimitPolicy, key string, regID int64, cost int) (*Decision, error) {
+	if !policy.Enabled() {
+		return &Decision{Allowed: true}, nil
+	}
+	tat, err := l.getTAT(ctx, bucketKey(name, key))
+	if err != nil {
+		return nil, err
+	}
+	return decide(l.clk.Now(), tat, policy, key, regID, cost), nil
+}
+
+// Spend records that cost items were used from the named limit's bucket for
+// key. The items are recorded even if they don't fit within the limit, since
+// Spend is called once the counted action has already happened, e.g. after a
+// certificate was issued, mirroring how the counting queries it replaces
+// count every row. Callers should Check beforehand to refuse actions that
+// would exceed the limit. The returned Decision describes the bucket before
+// the cost was recorded.
+func (l *Limiter) Spend(ctx context.Context, name string, policy RateLimitPolicy, key string, regID int64, cost int) (*Decision, error) {
+	if !policy.Enabled() {
+		return &Decision{Allowed: true}, nil
+	}
+	var d *Decision
+	err := l.source.Update(ctx, bucketKey(name, key), func(tat time.Time) (time.Time, time.Duration) {
+		now := l.clk.Now()
+		d = decide(now, tat, policy, key, regID, cost)
+		// The bucket is full again once its TAT has passed, so there is no
+		// point keeping it any longer than that.
+		return d.newTAT, d.newTAT.Sub(now)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// getTAT returns the TAT of a bucket, or the zero time if the bucket is full.
+func (l *Limiter) getTAT(ctx context.Context, bucket string) (time.Time, error) {
+	tat, err := l.source.Get(ctx, bucket)
+	if err == ErrBucketNotFound {
+		return time.Time{}, nil
+	}
+	return tat, err
+}
+
+// decide applies the GCRA to a bucket with the given TAT at time now.
+func decide(now, tat time.Time, policy RateLimitPolicy, key string, regID int64, cost int) *Decision {
+	threshold := policy.GetThreshold(key, regID)
+	if threshold <= 0 {
+		// An override of zero means nothing is allowed at all.
+		return &Decision{
+			RetryIn: policy.Window.Duration,
+			newTAT:  now.Add(policy.Window.Duration),
+		}
+	}
+	emissionInterval := policy.Window.Duration / time.Duration(threshold)
+	burstOffset := policy.Window.Duration
+
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(emissionInterval * time.Duration(cost))
+	allowAt := newTAT.Add(-burstOffset)
+	if now.Before(allowAt) {
+		return &Decision{
+			Allowed:   false,
+			Remaining: int((burstOffset - tat.Sub(now)) / emissionInterval),
+			RetryIn:   allowAt.Sub(now),
+			ResetIn:   tat.Sub(now),
+			newTAT:    newTAT,
+		}
+	}
+	return &Decision{
+		Allowed:   true,
+		Remaining: int(now.Sub(allowAt) / emissionInterval),
+		ResetIn:   newTAT.Sub(now),
+		newTAT:    newTAT,
+	}
+}
@@ -0,0 +1,209 @@
+package ratelimit
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jmhodges/clock"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+var ctx = context.Background()
+
+func TestLimiterBurstAndRefill(t *testing.T) {
+	clk := clock.NewFake()
+	l := NewLimiter(clk, NewInmemSource())
+	policy := RateLimitPolicy{
+		Window:    cmd.ConfigDuration{Duration: 10 * time.Hour},
+		Threshold: 10,
+	}
+
+	d, err := l.Check(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "empty bucket wasn't allowed")
+	test.AssertEquals(t, d.Remaining, 9)
+
+	// Checking doesn't spend anything, so the whole burst is still available.
+	for i := 0; i < 10; i++ {
+		d, err = l.Spend(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 1)
+		test.AssertNotError(t, err, "Spend failed")
+		test.Assert(t, d.Allowed, "spend within the burst wasn't allowed")
+		test.AssertEquals(t, d.Remaining, 9-i)
+	}
+	test.AssertEquals(t, d.ResetIn, 10*time.Hour)
+
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, !d.Allowed, "spend beyond the burst was allowed")
+	test.AssertEquals(t, d.Remaining, 0)
+	test.AssertEquals(t, d.RetryIn, time.Hour)
+	test.AssertEquals(t, d.ResetIn, 10*time.Hour)
+
+	// Other keys and other limits have their own buckets.
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "example.net", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "a different key shared a bucket")
+	d, err = l.Check(ctx, CertificatesPerFQDNSetLimit, policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "a different limit shared a bucket")
+
+	// One item is refilled every Window/Threshold.
+	clk.Add(time.Hour)
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "refilled bucket wasn't allowed")
+	test.AssertEquals(t, d.Remaining, 0)
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 2)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, !d.Allowed, "cost beyond the refill was allowed")
+	test.AssertEquals(t, d.RetryIn, time.Hour)
+
+	clk.Add(9 * time.Hour)
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "example.com", 1, 10)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "full bucket wasn't allowed")
+}
+
+func TestLimiterOverridesAndDisabled(t *testing.T) {
+	l := NewLimiter(clock.NewFake(), NewInmemSource())
+	policy := RateLimitPolicy{
+		Window:                cmd.ConfigDuration{Duration: time.Hour},
+		Threshold:             1,
+		Overrides:             map[string]int{"big.example.com": 5, "blocked.example.com": 0},
+		RegistrationOverrides: map[int64]int{99: 3},
+	}
+
+	d, err := l.Spend(ctx, CertificatesPerNameLimit, policy, "big.example.com", 1, 5)
+	test.AssertNotError(t, err, "Spend failed")
+	test.Assert(t, d.Allowed, "override wasn't honoured")
+
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "other.example.com", 99, 3)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "registration override wasn't honoured")
+
+	d, err = l.Check(ctx, CertificatesPerNameLimit, policy, "blocked.example.com", 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, !d.Allowed, "zero override allowed a spend")
+
+	d, err = l.Check(ctx, CertificatesPerNameLimit, RateLimitPolicy{}, "example.com", 1, 100)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, d.Allowed, "disabled policy denied a spend")
+}
+
+// TestRedisSource checks that buckets round trip through the Redis server in
+// the test environment.
+func TestRedisSource(t *testing.T) {
+	source, err := NewRedisSource(cmd.RedisConfig{
+		Addr:    vars.RedisAddr,
+		Timeout: cmd.ConfigDuration{Duration: time.Second},
+	})
+	test.AssertNotError(t, err, "NewRedisSource failed")
+	defer func() { _ = source.Close() }()
+
+	// Each test run gets fresh buckets, since Redis keeps them between runs.
+	bucket := fmt.Sprintf("newOrdersPerAccount:%d", time.Now().UnixNano())
+	_, err = source.Get(ctx, bucket)
+	test.AssertEquals(t, err, ErrBucketNotFound)
+
+	tat := time.Unix(0, 1500000000123456789)
+	err = source.Update(ctx, bucket, func(old time.Time) (time.Time, time.Duration) {
+		test.Assert(t, old.IsZero(), "Update passed a TAT for an empty bucket")
+		return tat, time.Minute
+	})
+	test.AssertNotError(t, err, "Update failed")
+	value, err := source.rdb.Get(redisKey(bucket)).Result()
+	test.AssertNotError(t, err, "GET failed")
+	test.AssertEquals(t, value, "1500000000123456789")
+	ttl, err := source.rdb.PTTL(redisKey(bucket)).Result()
+	test.AssertNotError(t, err, "PTTL failed")
+	test.Assert(t, ttl > 59*time.Second && ttl <= time.Minute, fmt.Sprintf("unexpected TTL %s", ttl))
+
+	got, err := source.Get(ctx, bucket)
+	test.AssertNotError(t, err, "Get failed")
+	test.Assert(t, got.Equal(tat), "Get returned a different TAT")
+
+	err = source.Update(ctx, bucket, func(old time.Time) (time.Time, time.Duration) {
+		test.Assert(t, old.Equal(tat), "Update passed a different TAT")
+		return old.Add(time.Second), time.Minute
+	})
+	test.AssertNotError(t, err, "Update failed")
+	got, err = source.Get(ctx, bucket)
+	test.AssertNotError(t, err, "Get failed")
+	test.Assert(t, got.Equal(tat.Add(time.Second)), "Update didn't store the new TAT")
+
+	// TTLs too short for Redis are rounded up, rather than rejected.
+	err = source.Update(ctx, bucket+"-short", func(time.Time) (time.Time, time.Duration) {
+		return tat, time.Microsecond
+	})
+	test.AssertNotError(t, err, "Update failed")
+
+	// A Limiter works the same way backed by Redis.
+	l := NewLimiter(clock.NewFake(), source)
+	policy := RateLimitPolicy{Window: cmd.ConfigDuration{Duration: time.Hour}, Threshold: 2}
+	for i := 0; i < 2; i++ {
+		_, err = l.Spend(ctx, NewOrdersPerAccountLimit, policy, bucket, 3, 1)
+		test.AssertNotError(t, err, "Spend failed")
+	}
+	d, err := l.Check(ctx, NewOrdersPerAccountLimit, policy, bucket, 3, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, !d.Allowed, "spend beyond the burst was allowed")
+
+	_, err = NewRedisSource(cmd.RedisConfig{})
+	test.AssertError(t, err, "NewRedisSource accepted an empty address")
+}
+
+// testConcurrentSpends spends from a single bucket from many goroutines at
+// once and checks that every spend was counted.
+func testConcurrentSpends(t *testing.T, source Source) {
+	l := NewLimiter(clock.NewFake(), source)
+	policy := RateLimitPolicy{Window: cmd.ConfigDuration{Duration: time.Hour}, Threshold: 100}
+	// Each test run gets a fresh bucket, since Redis keeps them for an hour.
+	key := fmt.Sprintf("concurrent-%d", time.Now().UnixNano())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 50)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := l.Spend(ctx, NewOrdersPerAccountLimit, policy, key, 1, 1)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		test.AssertNotError(t, err, "Spend failed")
+	}
+
+	d, err := l.Check(ctx, NewOrdersPerAccountLimit, policy, key, 1, 1)
+	test.AssertNotError(t, err, "Check failed")
+	test.AssertEquals(t, d.Remaining, 49)
+	d, err = l.Check(ctx, NewOrdersPerAccountLimit, policy, key, 1, 51)
+	test.AssertNotError(t, err, "Check failed")
+	test.Assert(t, !d.Allowed, "concurrent spends were undercounted")
+}
+
+func TestConcurrentSpends(t *testing.T) {
+	testConcurrentSpends(t, NewInmemSource())
+}
+
+// TestConcurrentSpendsRedis spends concurrently from a bucket kept in the
+// Redis server in the test environment.
+func TestConcurrentSpendsRedis(t *testing.T) {
+	source, err := NewRedisSource(cmd.RedisConfig{
+		Addr:      vars.RedisAddr,
+		PoolSize:  4,
+		MaxActive: 8,
+		Timeout:   cmd.ConfigDuration{Duration: 5 * time.Second},
+	})
+	test.AssertNotError(t, err, "NewRedisSource failed")
+	defer func() { _ = source.Close() }()
+	testConcurrentSpends(t, source)
+}
@@ -1,12 +1,15 @@
 package ratelimit
 
 import (
+	"fmt"
+	"strconv"
 	"sync"
 	"time"
 
 	"gopkg.in/yaml.v2"
 
 	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/reloader"
 )
 
 // Limits is defined to allow mock implementations be provided during unit
@@ -30,6 +33,9 @@ type Limits interface {
 type limitsImpl struct {
 	sync.RWMutex
 	rlPolicy *rateLimitConfig
+	// maxRemoved is the largest fraction of the current policies' entries
+	// that LoadPolicies may remove, or zero for no limit.
+	maxRemoved float64
 }
 
 func (r *limitsImpl) CertificatesPerName() RateLimitPolicy {
@@ -105,17 +111,23 @@ func (r *limitsImpl) NewOrdersPerAccount() RateLimitPolicy {
 }
 
 // LoadPolicies loads various rate limiting policies from a byte array of
-// YAML configuration (typically read from disk by a reloader)
+// YAML configuration (typically read from disk by a reloader). Policies that
+// fail validation, or that would remove more of the current policies' entries
+// than allowed, are refused and the current policies are kept.
 func (r *limitsImpl) LoadPolicies(contents []byte) error {
-	var newPolicy rateLimitConfig
-	err := yaml.Unmarshal(contents, &newPolicy)
+	newPolicy, err := parsePolicies(contents)
 	if err != nil {
 		return err
 	}
-
 	r.Lock()
-	r.rlPolicy = &newPolicy
-	r.Unlock()
+	defer r.Unlock()
+	if r.rlPolicy != nil {
+		err = reloader.CheckRemovals(r.rlPolicy.entries(), newPolicy.entries(), r.maxRemoved)
+		if err != nil {
+			return err
+		}
+	}
+	r.rlPolicy = newPolicy
 	return nil
 }
 
@@ -123,6 +135,55 @@ func New() Limits {
 	return &limitsImpl{}
 }
 
+// NewWithMaxRemoved returns Limits that refuse to load policies removing more
+// than maxRemoved, a fraction between zero and one, of the entries of the
+// policies already loaded. See PolicyEntries for what counts as an entry.
+func NewWithMaxRemoved(maxRemoved float64) Limits {
+	return &limitsImpl{maxRemoved: maxRemoved}
+}
+
+// parsePolicies parses and validates YAML rate limit policies. Unknown fields
+// are rejected, so that a misspelled limit isn't silently disabled.
+func parsePolicies(contents []byte) (*rateLimitConfig, error) {
+	var config rateLimitConfig
+	err := yaml.UnmarshalStrict(contents, &config)
+	if err != nil {
+		return nil, err
+	}
+	for name, policy := range config.policies() {
+		if policy.Threshold < 0 {
+			return nil, fmt.Errorf("%s: negative threshold %d", name, policy.Threshold)
+		}
+		if policy.Window.Duration < 0 {
+			return nil, fmt.Errorf("%s: negative window %s", name, policy.Window.Duration)
+		}
+		for key, threshold := range policy.Overrides {
+			if threshold < 0 {
+				return nil, fmt.Errorf("%s: negative override %d for %q", name, threshold, key)
+			}
+		}
+		for regID, threshold := range policy.RegistrationOverrides {
+			if threshold < 0 {
+				return nil, fmt.Errorf("%s: negative registration override %d for %d", name, threshold, regID)
+			}
+		}
+	}
+	return &config, nil
+}
+
+// PolicyEntries parses and validates YAML rate limit policies as LoadPolicies
+// does, and returns each of their settings keyed by where it is in the file,
+// e.g. "certificatesPerName.threshold" or
+// "certificatesPerName.overrides[example.com]", so that two versions of the
+// policies can be compared.
+func PolicyEntries(contents []byte) (map[string]string, error) {
+	config, err := parsePolicies(contents)
+	if err != nil {
+		return nil, err
+	}
+	return config.entries(), nil
+}
+
 // rateLimitConfig contains all application layer rate limiting policies. It is
 // unexported and clients are expected to use the exported container struct
 type rateLimitConfig struct {
@@ -159,6 +220,41 @@ type rateLimitConfig struct {
 	CertificatesPerFQDNSet RateLimitPolicy `yaml:"certificatesPerFQDNSet"`
 }
 
+// policies returns each of the policies in c, keyed by its name in the YAML.
+func (c *rateLimitConfig) policies() map[string]RateLimitPolicy {
+	return map[string]RateLimitPolicy{
+		CertificatesPerNameLimit:             c.CertificatesPerName,
+		RegistrationsPerIPLimit:              c.RegistrationsPerIP,
+		RegistrationsPerIPRangeLimit:         c.RegistrationsPerIPRange,
+		PendingAuthorizationsPerAccountLimit: c.PendingAuthorizationsPerAccount,
+		InvalidAuthorizationsPerAccountLimit: c.InvalidAuthorizationsPerAccount,
+		PendingOrdersPerAccountLimit:         c.PendingOrdersPerAccount,
+		NewOrdersPerAccountLimit:             c.NewOrdersPerAccount,
+		CertificatesPerFQDNSetLimit:          c.CertificatesPerFQDNSet,
+	}
+}
+
+// entries returns the settings of c as described for PolicyEntries. Policies
+// that are absent from the YAML have no entries.
+func (c *rateLimitConfig) entries() map[string]string {
+	entries := make(map[string]string)
+	for name, policy := range c.policies() {
+		if policy.Threshold == 0 && policy.Window.Duration == 0 &&
+			len(policy.Overrides) == 0 && len(policy.RegistrationOverrides) == 0 {
+			continue
+		}
+		entries[name+".window"] = policy.Window.Duration.String()
+		entries[name+".threshold"] = strconv.Itoa(policy.Threshold)
+		for key, threshold := range policy.Overrides {
+			entries[fmt.Sprintf("%s.overrides[%s]", name, key)] = strconv.Itoa(threshold)
+		}
+		for regID, threshold := range policy.RegistrationOverrides {
+			entries[fmt.Sprintf("%s.registrationOverrides[%d]", name, regID)] = strconv.Itoa(threshold)
+		}
+	}
+	return entries
+}
+
 // RateLimitPolicy describes a general limiting policy
 type RateLimitPolicy struct {
 	// How long to count items for
@@ -178,3 +178,92 @@ func TestLoadPolicies(t *testing.T) {
 	test.AssertEquals(t, emptyPolicy.PendingAuthorizationsPerAccount().Threshold, 0)
 	test.AssertEquals(t, emptyPolicy.CertificatesPerFQDNSet().Threshold, 0)
 }
+
+func TestLoadPoliciesValidation(t *testing.T) {
+	policy := New()
+
+	// A misspelled limit is rejected rather than silently disabled.
+	err := policy.LoadPolicies([]byte("certificatesPerNames:\n  window: 1h\n  threshold: 2\n"))
+	test.AssertError(t, err, "Loaded policies with an unknown field")
+
+	err = policy.LoadPolicies([]byte("certificatesPerName:\n  window: 1h\n  threshold: -2\n"))
+	test.AssertError(t, err, "Loaded policies with a negative threshold")
+
+	err = policy.LoadPolicies([]byte("certificatesPerName:\n  window: -1h\n  threshold: 2\n"))
+	test.AssertError(t, err, "Loaded policies with a negative window")
+
+	err = policy.LoadPolicies([]byte("certificatesPerName:\n  window: 1h\n  threshold: 2\n  overrides:\n    example.com: -1\n"))
+	test.AssertError(t, err, "Loaded policies with a negative override")
+
+	err = policy.LoadPolicies([]byte("certificatesPerName:\n  window: 1h\n  threshold: 2\n  registrationOverrides:\n    1: -1\n"))
+	test.AssertError(t, err, "Loaded policies with a negative registration override")
+
+	test.AssertEquals(t, policy.CertificatesPerName().Threshold, 0)
+}
+
+func TestLoadPoliciesMaxRemoved(t *testing.T) {
+	policy := NewWithMaxRemoved(0.5)
+
+	// The first policies loaded may have any number of entries.
+	err := policy.LoadPolicies([]byte(`
+certificatesPerName:
+  window: 1h
+  threshold: 2
+  overrides:
+    a.example.com: 10
+    b.example.com: 10
+`))
+	test.AssertNotError(t, err, "Failed to load initial policies")
+
+	// Removing two of the four entries is within the limit.
+	err = policy.LoadPolicies([]byte(`
+certificatesPerName:
+  window: 1h
+  threshold: 2
+`))
+	test.AssertNotError(t, err, "Failed to load policies removing half the entries")
+
+	// Removing every entry is not, and the current policies are kept.
+	err = policy.LoadPolicies([]byte(`
+newOrdersPerAccount:
+  window: 1h
+  threshold: 2
+`))
+	test.AssertError(t, err, "Loaded policies removing every entry")
+	test.AssertEquals(t, policy.CertificatesPerName().Threshold, 2)
+	test.AssertEquals(t, policy.NewOrdersPerAccount().Threshold, 0)
+}
+
+func TestPolicyEntries(t *testing.T) {
+	entries, err := PolicyEntries([]byte(`
+certificatesPerName:
+  window: 2160h
+  threshold: 2
+  overrides:
+    ratelimit.me: 1
+  registrationOverrides:
+    101: 1000
+newOrdersPerAccount:
+  window: 3h
+  threshold: 1500
+`))
+	test.AssertNotError(t, err, "PolicyEntries failed")
+	test.AssertDeepEquals(t, entries, map[string]string{
+		"certificatesPerName.window":                     "2160h0m0s",
+		"certificatesPerName.threshold":                  "2",
+		"certificatesPerName.overrides[ratelimit.me]":    "1",
+		"certificatesPerName.registrationOverrides[101]": "1000",
+		"newOrdersPerAccount.window":                     "3h0m0s",
+		"newOrdersPerAccount.threshold":                  "1500",
+	})
+
+	_, err = PolicyEntries([]byte("err"))
+	test.AssertError(t, err, "PolicyEntries accepted invalid YAML")
+
+	for _, file := range []string{"../test/rate-limit-policies.yml", "../test/rate-limit-policies-b.yml"} {
+		contents, err := ioutil.ReadFile(file)
+		test.AssertNotError(t, err, "Failed to read "+file)
+		_, err = PolicyEntries(contents)
+		test.AssertNotError(t, err, "Failed to parse "+file)
+	}
+}
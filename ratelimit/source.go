@@ -0,0 +1,159 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/redis"
+)
+
+// ErrBucketNotFound is returned by a Source's Get method when it holds no
+// state for a bucket, meaning the bucket is full.
+var ErrBucketNotFound = errors.New("ratelimit: bucket not found")
+
+// Source stores the theoretical arrival time (TAT) of each of a Limiter's
+// buckets.
+type Source interface {
+	// Get returns the TAT of the bucket, or ErrBucketNotFound if there is none.
+	Get(ctx context.Context, bucket string) (time.Time, error)
+	// Update atomically replaces the TAT of the bucket with the one returned
+	// by update, which is passed the bucket's current TAT, or the zero time if
+	// there is none. The source may forget the bucket once the returned ttl
+	// has passed. update may be called more than once if the bucket is changed
+	// concurrently, in which case only the result of the last call is stored.
+	Update(ctx context.Context, bucket string, update UpdateFunc) error
+}
+
+// UpdateFunc computes the new TAT of a bucket from its current one, along with
+// how long the bucket needs to be kept.
+type UpdateFunc func(tat time.Time) (newTAT time.Time, ttl time.Duration)
+
+// inmemSource is a Source kept in memory, for use in tests. It never forgets
+// buckets.
+type inmemSource struct {
+	sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewInmemSource returns a Source that keeps buckets in memory. It isn't
+// shared between processes, so is only suitable for testing.
+func NewInmemSource() Source {
+	return &inmemSource{tats: make(map[string]time.Time)}
+}
+
+func (s *inmemSource) Get(_ context.Context, bucket string) (time.Time, error) {
+	s.Lock()
+	defer s.Unlock()
+	tat, ok := s.tats[bucket]
+	if !ok {
+		return time.Time{}, ErrBucketNotFound
+	}
+	return tat, nil
+}
+
+func (s *inmemSource) Update(_ context.Context, bucket string, update UpdateFunc) error {
+	s.Lock()
+	defer s.Unlock()
+	s.tats[bucket], _ = update(s.tats[bucket])
+	return nil
+}
+
+// RedisSource is a Source kept in Redis, so that every RA shares the same
+// buckets. Each bucket is stored as its TAT in Unix nanoseconds, with a TTL so
+// that Redis drops it once it is full again. Buckets are updated in a
+// WATCH/MULTI/EXEC transaction that is retried if another RA changed the
+// bucket in the meantime, so concurrent spends are never lost.
+type RedisSource struct {
+	rdb *goredis.Client
+}
+
+// NewRedisSource returns a RedisSource using the Redis server configured by c.
+func NewRedisSource(c cmd.RedisConfig) (*RedisSource, error) {
+	rdb, err := redis.NewClientFromConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return &RedisSource{rdb: rdb}, nil
+}
+
+// redisKey returns the Redis key a bucket is stored under.
+func redisKey(bucket string) string {
+	return "rl{" + bucket + "}"
+}
+
+// Get returns the TAT of the bucket, or ErrBucketNotFound if Redis doesn't
+// have it.
+func (s *RedisSource) Get(ctx context.Context, bucket string) (time.Time, error) {
+	return getTAT(s.rdb.WithContext(ctx), bucket)
+}
+
+// getTAT reads the TAT of a bucket with c, which is either the client or a
+// transaction WATCHing the bucket.
+func getTAT(c goredis.Cmdable, bucket string) (time.Time, error) {
+	value, err := c.Get(redisKey(bucket)).Result()
+	if err == goredis.Nil {
+		return time.Time{}, ErrBucketNotFound
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	ns, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ratelimit: malformed TAT for bucket %q: %s", bucket, err)
+	}
+	return time.Unix(0, ns), nil
+}
+
+// Update reads the TAT of the bucket and stores the one update returns,
+// expiring it after the returned ttl. TTLs shorter than a millisecond are
+// rounded up, since Redis can't expire keys any sooner. The bucket is WATCHed
+// while update runs and the new TAT is written with MULTI/EXEC, so if another
+// client changes the bucket first EXEC does nothing and Update starts again.
+// It keeps retrying until it succeeds or ctx is done.
+func (s *RedisSource) Update(ctx context.Context, bucket string, update UpdateFunc) error {
+	for {
+		err := s.tryUpdate(ctx, bucket, update)
+		if err != goredis.TxFailedErr {
+			return err
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+// tryUpdate makes a single attempt at Update, returning goredis.TxFailedErr
+// if the bucket was changed by another client before the new TAT could be
+// written.
+func (s *RedisSource) tryUpdate(ctx context.Context, bucket string, update UpdateFunc) error {
+	key := redisKey(bucket)
+	return s.rdb.WithContext(ctx).Watch(func(tx *goredis.Tx) error {
+		tat, err := getTAT(tx, bucket)
+		if err == ErrBucketNotFound {
+			tat = time.Time{}
+		} else if err != nil {
+			return err
+		}
+		newTAT, ttl := update(tat)
+		if ttl < time.Millisecond {
+			ttl = time.Millisecond
+		}
+		_, err = tx.Pipelined(func(pipe goredis.Pipeliner) error {
+			pipe.Set(key, strconv.FormatInt(newTAT.UnixNano(), 10), ttl)
+			return nil
+		})
+		return err
+	}, key)
+}
+
+// Close closes the RedisSource's connections.
+func (s *RedisSource) Close() error {
+	return s.rdb.Close()
+}
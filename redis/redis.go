@@ -0,0 +1,52 @@
+// Package redis creates clients, using the vendored go-redis library, for the
+// Redis servers that Boulder shares state through.
+package redis
+
+import (
+	"errors"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+
+	"github.com/letsencrypt/boulder/cmd"
+)
+
+// NewClient returns a client for the Redis server at addr. If password is
+// non-empty each new connection is authenticated with it. At least poolSize
+// connections are kept open and at most maxActive, which is raised to
+// poolSize if it is smaller, are open at once. Dialing, each read and write,
+// and waiting for a free connection are each bounded by timeout if it is
+// non-zero. Failed commands aren't retried.
+func NewClient(addr, password string, poolSize, maxActive int, timeout time.Duration) *goredis.Client {
+	if poolSize <= 0 {
+		poolSize = 1
+	}
+	if maxActive < poolSize {
+		maxActive = poolSize
+	}
+	opts := &goredis.Options{
+		Addr:         addr,
+		Password:     password,
+		PoolSize:     maxActive,
+		MinIdleConns: poolSize,
+	}
+	if timeout > 0 {
+		opts.DialTimeout = timeout
+		opts.ReadTimeout = timeout
+		opts.WriteTimeout = timeout
+		opts.PoolTimeout = timeout
+	}
+	return goredis.NewClient(opts)
+}
+
+// NewClientFromConfig returns a client for the Redis server configured by c.
+func NewClientFromConfig(c cmd.RedisConfig) (*goredis.Client, error) {
+	if c.Addr == "" {
+		return nil, errors.New("redis address must not be empty")
+	}
+	password, err := c.Pass()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(c.Addr, password, c.PoolSize, c.MaxActive, c.Timeout.Duration), nil
+}
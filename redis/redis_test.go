@@ -0,0 +1,43 @@
+package redis
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+func TestNewClientFromConfig(t *testing.T) {
+	_, err := NewClientFromConfig(cmd.RedisConfig{})
+	test.AssertError(t, err, "NewClientFromConfig accepted an empty address")
+
+	_, err = NewClientFromConfig(cmd.RedisConfig{
+		Addr:           vars.RedisAddr,
+		PasswordConfig: cmd.PasswordConfig{PasswordFile: "/does/not/exist"},
+	})
+	test.AssertError(t, err, "NewClientFromConfig accepted a missing password file")
+
+	client, err := NewClientFromConfig(cmd.RedisConfig{
+		Addr:      vars.RedisAddr,
+		PoolSize:  2,
+		MaxActive: 1,
+		Timeout:   cmd.ConfigDuration{Duration: time.Second},
+	})
+	test.AssertNotError(t, err, "NewClientFromConfig failed")
+	defer func() { _ = client.Close() }()
+	opts := client.Options()
+	test.AssertEquals(t, opts.PoolSize, 2)
+	test.AssertEquals(t, opts.MinIdleConns, 2)
+	test.AssertEquals(t, opts.ReadTimeout, time.Second)
+
+	// The client talks to the Redis server in the test environment.
+	key := fmt.Sprintf("test-client-%d", time.Now().UnixNano())
+	err = client.Set(key, "value", time.Minute).Err()
+	test.AssertNotError(t, err, "SET failed")
+	value, err := client.Get(key).Result()
+	test.AssertNotError(t, err, "GET failed")
+	test.AssertEquals(t, value, "value")
+}
@@ -2,6 +2,7 @@
 package reloader
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"time"
@@ -82,3 +83,25 @@ func New(filename string, dataCallback func([]byte) error, errorCallback func(er
 	go loop()
 	return &Reloader{stopChan}, nil
 }
+
+// CheckRemovals returns an error if more than maxRemoved, a fraction between
+// zero and one, of the keys of old are missing from new. Loaders use it to
+// refuse to replace a policy with one that has lost much of its contents, for
+// instance because of a typo in a section name or a truncated file. A
+// maxRemoved of zero allows any removals.
+func CheckRemovals(old, new map[string]string, maxRemoved float64) error {
+	if maxRemoved <= 0 || len(old) == 0 {
+		return nil
+	}
+	var removed int
+	for key := range old {
+		if _, ok := new[key]; !ok {
+			removed++
+		}
+	}
+	if fraction := float64(removed) / float64(len(old)); fraction > maxRemoved {
+		return fmt.Errorf("refusing to remove %d of %d policy entries (%.0f%%), more than the allowed %.0f%%",
+			removed, len(old), fraction*100, maxRemoved*100)
+	}
+	return nil
+}
@@ -218,3 +218,29 @@ func TestReloadFailure(t *testing.T) {
 		t.Fatalf("timed out waiting for successful reload")
 	}
 }
+
+func TestCheckRemovals(t *testing.T) {
+	old := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}
+	testCases := []struct {
+		new        map[string]string
+		maxRemoved float64
+		ok         bool
+	}{
+		// Changed values and additions aren't removals.
+		{map[string]string{"a": "5", "b": "2", "c": "3", "d": "4", "e": "6"}, 0.1, true},
+		{map[string]string{"a": "1", "b": "2", "c": "3"}, 0.25, true},
+		{map[string]string{"a": "1", "b": "2"}, 0.25, false},
+		{map[string]string{}, 0.5, false},
+		// Zero allows anything.
+		{map[string]string{}, 0, true},
+	}
+	for i, tc := range testCases {
+		err := CheckRemovals(old, tc.new, tc.maxRemoved)
+		if (err == nil) != tc.ok {
+			t.Errorf("case %d: CheckRemovals returned %v, expected ok=%t", i, err, tc.ok)
+		}
+	}
+	if err := CheckRemovals(nil, nil, 0.1); err != nil {
+		t.Errorf("CheckRemovals failed with nothing to remove: %s", err)
+	}
+}
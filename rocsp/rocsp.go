@@ -0,0 +1,75 @@
+// Package rocsp stores pre-signed OCSP responses in Redis so that they can be
+// served without a round trip to the database.
+package rocsp
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	goredis "github.com/go-redis/redis"
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/redis"
+)
+
+// ErrNotFound is returned by GetResponse when no unexpired response is stored
+// for a serial.
+var ErrNotFound = errors.New("rocsp: response not found")
+
+// Client reads and writes OCSP responses in Redis. Responses are stored under
+// a key derived from the certificate serial, with a TTL so that Redis drops
+// them once they are no longer fresh. It is safe for concurrent use.
+type Client struct {
+	rdb *goredis.Client
+}
+
+// NewClient returns a Client for the Redis server at addr, with a connection
+// pool configured as described for redis.NewClient.
+func NewClient(addr, password string, poolSize, maxActive int, timeout time.Duration) *Client {
+	return &Client{rdb: redis.NewClient(addr, password, poolSize, maxActive, timeout)}
+}
+
+// NewClientFromConfig returns a Client configured by c.
+func NewClientFromConfig(c cmd.RedisConfig) (*Client, error) {
+	rdb, err := redis.NewClientFromConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{rdb: rdb}, nil
+}
+
+// responseKey returns the Redis key an OCSP response for serial is stored
+// under.
+func responseKey(serial string) string {
+	return "r{" + serial + "}"
+}
+
+// StoreResponse stores the DER encoded OCSP response resp for the certificate
+// with the given hex serial, replacing any existing response. Redis expires
+// the response after ttl, which must be at least a millisecond.
+func (c *Client) StoreResponse(ctx context.Context, serial string, resp []byte, ttl time.Duration) error {
+	if ttl < time.Millisecond {
+		return fmt.Errorf("rocsp: TTL %s for serial %s is too short", ttl, serial)
+	}
+	return c.rdb.WithContext(ctx).Set(responseKey(serial), resp, ttl).Err()
+}
+
+// GetResponse returns the DER encoded OCSP response stored for the
+// certificate with the given hex serial, or ErrNotFound if there isn't one.
+func (c *Client) GetResponse(ctx context.Context, serial string) ([]byte, error) {
+	resp, err := c.rdb.WithContext(ctx).Get(responseKey(serial)).Bytes()
+	if err == goredis.Nil {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Close closes the Client's connections.
+func (c *Client) Close() error {
+	return c.rdb.Close()
+}
@@ -0,0 +1,66 @@
+package rocsp
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/letsencrypt/boulder/cmd"
+	"github.com/letsencrypt/boulder/test"
+	"github.com/letsencrypt/boulder/test/vars"
+)
+
+var ctx = context.Background()
+
+// TestStoreAndGetResponse checks that responses round trip through the Redis
+// server in the test environment and that it expires them.
+func TestStoreAndGetResponse(t *testing.T) {
+	client := NewClient(vars.RedisAddr, "", 1, 1, time.Second)
+	defer func() { _ = client.Close() }()
+
+	// Each test run gets a fresh serial, since Redis keeps responses between
+	// runs.
+	serial := fmt.Sprintf("%x", time.Now().UnixNano())
+	_, err := client.GetResponse(ctx, serial)
+	test.AssertEquals(t, err, ErrNotFound)
+
+	resp := []byte("response\r\nwith\x00binary")
+	err = client.StoreResponse(ctx, serial, resp, 72*time.Hour)
+	test.AssertNotError(t, err, "StoreResponse failed")
+	ttl, err := client.rdb.PTTL(responseKey(serial)).Result()
+	test.AssertNotError(t, err, "PTTL failed")
+	test.Assert(t, ttl > 71*time.Hour && ttl <= 72*time.Hour, fmt.Sprintf("unexpected TTL %s", ttl))
+
+	got, err := client.GetResponse(ctx, serial)
+	test.AssertNotError(t, err, "GetResponse failed")
+	test.AssertByteEquals(t, got, resp)
+
+	err = client.StoreResponse(ctx, serial, resp, 10*time.Millisecond)
+	test.AssertNotError(t, err, "StoreResponse failed")
+	time.Sleep(50 * time.Millisecond)
+	_, err = client.GetResponse(ctx, serial)
+	test.AssertEquals(t, err, ErrNotFound)
+
+	err = client.StoreResponse(ctx, serial, resp, time.Microsecond)
+	test.AssertError(t, err, "StoreResponse accepted a TTL under a millisecond")
+}
+
+func TestUnavailable(t *testing.T) {
+	// Nothing listens on this address.
+	client := NewClient("127.0.0.1:1", "", 1, 1, time.Second)
+	defer func() { _ = client.Close() }()
+	_, err := client.GetResponse(ctx, "00aa")
+	test.AssertError(t, err, "GetResponse succeeded with no server")
+	test.Assert(t, err != ErrNotFound, "unavailable server treated as a miss")
+}
+
+func TestNewClientFromConfig(t *testing.T) {
+	_, err := NewClientFromConfig(cmd.RedisConfig{})
+	test.AssertError(t, err, "NewClientFromConfig accepted an empty address")
+
+	client, err := NewClientFromConfig(cmd.RedisConfig{Addr: vars.RedisAddr, PoolSize: 5})
+	test.AssertNotError(t, err, "NewClientFromConfig failed")
+	_ = client.Close()
+}
@@ -0,0 +1,17 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `externalAccountKeys` (
+    `keyID` VARCHAR(255) NOT NULL,
+    `hmacKey` BLOB NOT NULL,
+    `createdAt` DATETIME NOT NULL,
+    `retiredAt` DATETIME DEFAULT NULL,
+    `registrationID` BIGINT(20) DEFAULT NULL,
+    PRIMARY KEY (`keyID`),
+    KEY `registrationID_idx` (`registrationID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `externalAccountKeys`;
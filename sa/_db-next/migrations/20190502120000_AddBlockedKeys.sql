@@ -0,0 +1,33 @@
+-- +goose Up
+-- SQL in section 'Up' is executed when this migration is applied
+
+CREATE TABLE `blockedKeys` (
+    `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+    `keyHash` BINARY(32) NOT NULL,
+    `added` DATETIME NOT NULL,
+    `source` VARCHAR(32) NOT NULL,
+    `comment` VARCHAR(255) DEFAULT NULL,
+    `revokedBy` BIGINT(20) DEFAULT 0,
+    `extantCertificatesChecked` BOOLEAN DEFAULT FALSE,
+    `checkAttempts` INT(11) NOT NULL DEFAULT 0,
+    `nextCheck` DATETIME DEFAULT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `keyHash` (`keyHash`),
+    KEY `extantCertificatesChecked_nextCheck_idx` (`extantCertificatesChecked`, `nextCheck`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+CREATE TABLE `keyHashToSerial` (
+    `id` BIGINT(20) NOT NULL AUTO_INCREMENT,
+    `keyHash` BINARY(32) NOT NULL,
+    `certNotAfter` DATETIME NOT NULL,
+    `certSerial` VARCHAR(255) NOT NULL,
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `unique_keyHash_certserial` (`keyHash`, `certSerial`),
+    KEY `keyHash_certNotAfter` (`keyHash`, `certNotAfter`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8;
+
+-- +goose Down
+-- SQL section 'Down' is executed when this migration is rolled back
+
+DROP TABLE `keyHashToSerial`;
+DROP TABLE `blockedKeys`;
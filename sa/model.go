@@ -18,6 +18,7 @@ import (
 	"github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/revocation"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 )
 
 // By convention, any function that takes a dbOneSelector, dbSelector,
@@ -343,6 +344,13 @@ type orderModel struct {
 	BeganProcessing   bool
 }
 
+// orderModelv2 is an orderModel with the certificateProfileName column. It is
+// used in place of orderModel when the CertificateProfiles feature is enabled.
+type orderModelv2 struct {
+	orderModel
+	CertificateProfileName string
+}
+
 type requestedNameModel struct {
 	ID           int64
 	OrderID      int64
@@ -401,24 +409,37 @@ func modelToOrder(om *orderModel) (*corepb.Order, error) {
 	return order, nil
 }
 
+func modelv2ToOrder(om *orderModelv2) (*corepb.Order, error) {
+	order, err := modelToOrder(&om.orderModel)
+	if err != nil {
+		return nil, err
+	}
+	order.CertificateProfileName = &om.CertificateProfileName
+	return order, nil
+}
+
 var challTypeToUint = map[string]uint{
-	"http-01":     0,
-	"dns-01":      1,
-	"tls-alpn-01": 2,
+	"http-01":        0,
+	"dns-01":         1,
+	"tls-alpn-01":    2,
+	"dns-account-01": 3,
 }
 
 var uintToChallType = map[uint]string{
 	0: "http-01",
 	1: "dns-01",
 	2: "tls-alpn-01",
+	3: "dns-account-01",
 }
 
 var identifierTypeToUint = map[string]uint{
 	"dns": 0,
+	"ip":  1,
 }
 
 var uintToIdentifierType = map[uint]string{
 	0: "dns",
+	1: "ip",
 }
 
 var statusToUint = map[string]uint{
@@ -476,6 +497,7 @@ func authzPBToModel(authz *corepb.Authorization) (*authz2Model, error) {
 	}
 	am := &authz2Model{
 		ID:              int64(id),
+		IdentifierType:  identifierTypeToUint[string(core.IdentifierForName(*authz.Identifier).Type)],
 		IdentifierValue: *authz.Identifier,
 		RegistrationID:  *authz.RegistrationID,
 		Status:          statusToUint[*authz.Status],
@@ -621,3 +643,53 @@ func modelToAuthzPB(am *authz2Model) (*corepb.Authorization, error) {
 	}
 	return pb, nil
 }
+
+// externalAccountKeyModel is the description of a pre-provisioned external
+// account key in the database. RetiredAt and RegistrationID are NULL until the
+// key is retired or bound to a registration, respectively.
+type externalAccountKeyModel struct {
+	KeyID          string     `db:"keyID"`
+	HMACKey        []byte     `db:"hmacKey"`
+	CreatedAt      time.Time  `db:"createdAt"`
+	RetiredAt      *time.Time `db:"retiredAt"`
+	RegistrationID *int64     `db:"registrationID"`
+}
+
+func modelToExternalAccountKeyPB(km *externalAccountKeyModel) *sapb.ExternalAccountKey {
+	createdAt := km.CreatedAt.UnixNano()
+	var retiredAt, regID int64
+	if km.RetiredAt != nil {
+		retiredAt = km.RetiredAt.UnixNano()
+	}
+	if km.RegistrationID != nil {
+		regID = *km.RegistrationID
+	}
+	return &sapb.ExternalAccountKey{
+		KeyID:          &km.KeyID,
+		HmacKey:        km.HMACKey,
+		CreatedAt:      &createdAt,
+		RetiredAt:      &retiredAt,
+		RegistrationID: &regID,
+	}
+}
+
+// blockedKeyModel is the description of a blocked public key in the database.
+// ExtantCertificatesChecked is set once bad-key-revoker has revoked any other
+// certificates using the key.
+type blockedKeyModel struct {
+	ID                        int64     `db:"id"`
+	KeyHash                   []byte    `db:"keyHash"`
+	Added                     time.Time `db:"added"`
+	Source                    string    `db:"source"`
+	Comment                   *string   `db:"comment"`
+	RevokedBy                 int64     `db:"revokedBy"`
+	ExtantCertificatesChecked bool      `db:"extantCertificatesChecked"`
+}
+
+// keyHashModel records the hash of the public key of an issued certificate.
+type keyHashModel struct {
+	ID           int64     `db:"id"`
+	KeyHash      []byte    `db:"keyHash"`
+	CertNotAfter time.Time `db:"certNotAfter"`
+	CertSerial   string    `db:"certSerial"`
+}
@@ -1740,6 +1740,618 @@ func (m *RevokeCertificateRequest) GetResponse() []byte {
 	return nil
 }
 
+type ExternalAccountKeyID struct {
+	KeyID                *string  `protobuf:"bytes,1,opt,name=keyID" json:"keyID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExternalAccountKeyID) Reset()         { *m = ExternalAccountKeyID{} }
+func (m *ExternalAccountKeyID) String() string { return proto.CompactTextString(m) }
+func (*ExternalAccountKeyID) ProtoMessage()    {}
+func (*ExternalAccountKeyID) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{33}
+}
+
+func (m *ExternalAccountKeyID) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalAccountKeyID.Unmarshal(m, b)
+}
+func (m *ExternalAccountKeyID) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalAccountKeyID.Marshal(b, m, deterministic)
+}
+func (m *ExternalAccountKeyID) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalAccountKeyID.Merge(m, src)
+}
+func (m *ExternalAccountKeyID) XXX_Size() int {
+	return xxx_messageInfo_ExternalAccountKeyID.Size(m)
+}
+func (m *ExternalAccountKeyID) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalAccountKeyID.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalAccountKeyID proto.InternalMessageInfo
+
+func (m *ExternalAccountKeyID) GetKeyID() string {
+	if m != nil && m.KeyID != nil {
+		return *m.KeyID
+	}
+	return ""
+}
+
+type ExternalAccountKey struct {
+	KeyID                *string  `protobuf:"bytes,1,opt,name=keyID" json:"keyID,omitempty"`
+	HmacKey              []byte   `protobuf:"bytes,2,opt,name=hmacKey" json:"hmacKey,omitempty"`
+	CreatedAt            *int64   `protobuf:"varint,3,opt,name=createdAt" json:"createdAt,omitempty"`
+	RetiredAt            *int64   `protobuf:"varint,4,opt,name=retiredAt" json:"retiredAt,omitempty"`
+	RegistrationID       *int64   `protobuf:"varint,5,opt,name=registrationID" json:"registrationID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ExternalAccountKey) Reset()         { *m = ExternalAccountKey{} }
+func (m *ExternalAccountKey) String() string { return proto.CompactTextString(m) }
+func (*ExternalAccountKey) ProtoMessage()    {}
+func (*ExternalAccountKey) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{34}
+}
+
+func (m *ExternalAccountKey) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ExternalAccountKey.Unmarshal(m, b)
+}
+func (m *ExternalAccountKey) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ExternalAccountKey.Marshal(b, m, deterministic)
+}
+func (m *ExternalAccountKey) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ExternalAccountKey.Merge(m, src)
+}
+func (m *ExternalAccountKey) XXX_Size() int {
+	return xxx_messageInfo_ExternalAccountKey.Size(m)
+}
+func (m *ExternalAccountKey) XXX_DiscardUnknown() {
+	xxx_messageInfo_ExternalAccountKey.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ExternalAccountKey proto.InternalMessageInfo
+
+func (m *ExternalAccountKey) GetKeyID() string {
+	if m != nil && m.KeyID != nil {
+		return *m.KeyID
+	}
+	return ""
+}
+
+func (m *ExternalAccountKey) GetHmacKey() []byte {
+	if m != nil {
+		return m.HmacKey
+	}
+	return nil
+}
+
+func (m *ExternalAccountKey) GetCreatedAt() int64 {
+	if m != nil && m.CreatedAt != nil {
+		return *m.CreatedAt
+	}
+	return 0
+}
+
+func (m *ExternalAccountKey) GetRetiredAt() int64 {
+	if m != nil && m.RetiredAt != nil {
+		return *m.RetiredAt
+	}
+	return 0
+}
+
+func (m *ExternalAccountKey) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+type AddBlockedKeyRequest struct {
+	KeyHash              []byte   `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	Added                *int64   `protobuf:"varint,2,opt,name=added" json:"added,omitempty"`
+	Source               *string  `protobuf:"bytes,3,opt,name=source" json:"source,omitempty"`
+	Comment              *string  `protobuf:"bytes,4,opt,name=comment" json:"comment,omitempty"`
+	RevokedBy            *int64   `protobuf:"varint,5,opt,name=revokedBy" json:"revokedBy,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddBlockedKeyRequest) Reset()         { *m = AddBlockedKeyRequest{} }
+func (m *AddBlockedKeyRequest) String() string { return proto.CompactTextString(m) }
+func (*AddBlockedKeyRequest) ProtoMessage()    {}
+func (*AddBlockedKeyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{35}
+}
+
+func (m *AddBlockedKeyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddBlockedKeyRequest.Unmarshal(m, b)
+}
+func (m *AddBlockedKeyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddBlockedKeyRequest.Marshal(b, m, deterministic)
+}
+func (m *AddBlockedKeyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddBlockedKeyRequest.Merge(m, src)
+}
+func (m *AddBlockedKeyRequest) XXX_Size() int {
+	return xxx_messageInfo_AddBlockedKeyRequest.Size(m)
+}
+func (m *AddBlockedKeyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddBlockedKeyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddBlockedKeyRequest proto.InternalMessageInfo
+
+func (m *AddBlockedKeyRequest) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
+	}
+	return nil
+}
+
+func (m *AddBlockedKeyRequest) GetAdded() int64 {
+	if m != nil && m.Added != nil {
+		return *m.Added
+	}
+	return 0
+}
+
+func (m *AddBlockedKeyRequest) GetSource() string {
+	if m != nil && m.Source != nil {
+		return *m.Source
+	}
+	return ""
+}
+
+func (m *AddBlockedKeyRequest) GetComment() string {
+	if m != nil && m.Comment != nil {
+		return *m.Comment
+	}
+	return ""
+}
+
+func (m *AddBlockedKeyRequest) GetRevokedBy() int64 {
+	if m != nil && m.RevokedBy != nil {
+		return *m.RevokedBy
+	}
+	return 0
+}
+
+type AddKeyHashRequest struct {
+	KeyHash              []byte   `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	Serial               *string  `protobuf:"bytes,2,opt,name=serial" json:"serial,omitempty"`
+	CertNotAfter         *int64   `protobuf:"varint,3,opt,name=certNotAfter" json:"certNotAfter,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddKeyHashRequest) Reset()         { *m = AddKeyHashRequest{} }
+func (m *AddKeyHashRequest) String() string { return proto.CompactTextString(m) }
+func (*AddKeyHashRequest) ProtoMessage()    {}
+func (*AddKeyHashRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{36}
+}
+
+func (m *AddKeyHashRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddKeyHashRequest.Unmarshal(m, b)
+}
+func (m *AddKeyHashRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddKeyHashRequest.Marshal(b, m, deterministic)
+}
+func (m *AddKeyHashRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddKeyHashRequest.Merge(m, src)
+}
+func (m *AddKeyHashRequest) XXX_Size() int {
+	return xxx_messageInfo_AddKeyHashRequest.Size(m)
+}
+func (m *AddKeyHashRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddKeyHashRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddKeyHashRequest proto.InternalMessageInfo
+
+func (m *AddKeyHashRequest) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
+	}
+	return nil
+}
+
+func (m *AddKeyHashRequest) GetSerial() string {
+	if m != nil && m.Serial != nil {
+		return *m.Serial
+	}
+	return ""
+}
+
+func (m *AddKeyHashRequest) GetCertNotAfter() int64 {
+	if m != nil && m.CertNotAfter != nil {
+		return *m.CertNotAfter
+	}
+	return 0
+}
+
+type KeyBlockedRequest struct {
+	KeyHash              []byte   `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyBlockedRequest) Reset()         { *m = KeyBlockedRequest{} }
+func (m *KeyBlockedRequest) String() string { return proto.CompactTextString(m) }
+func (*KeyBlockedRequest) ProtoMessage()    {}
+func (*KeyBlockedRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{37}
+}
+
+func (m *KeyBlockedRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyBlockedRequest.Unmarshal(m, b)
+}
+func (m *KeyBlockedRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyBlockedRequest.Marshal(b, m, deterministic)
+}
+func (m *KeyBlockedRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyBlockedRequest.Merge(m, src)
+}
+func (m *KeyBlockedRequest) XXX_Size() int {
+	return xxx_messageInfo_KeyBlockedRequest.Size(m)
+}
+func (m *KeyBlockedRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyBlockedRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyBlockedRequest proto.InternalMessageInfo
+
+func (m *KeyBlockedRequest) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
+	}
+	return nil
+}
+
+type GetSerialsByKeyRequest struct {
+	KeyHash              []byte   `protobuf:"bytes,1,opt,name=keyHash" json:"keyHash,omitempty"`
+	ValidAfter           *int64   `protobuf:"varint,2,opt,name=validAfter" json:"validAfter,omitempty"`
+	Limit                *int64   `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetSerialsByKeyRequest) Reset()         { *m = GetSerialsByKeyRequest{} }
+func (m *GetSerialsByKeyRequest) String() string { return proto.CompactTextString(m) }
+func (*GetSerialsByKeyRequest) ProtoMessage()    {}
+func (*GetSerialsByKeyRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{38}
+}
+
+func (m *GetSerialsByKeyRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetSerialsByKeyRequest.Unmarshal(m, b)
+}
+func (m *GetSerialsByKeyRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetSerialsByKeyRequest.Marshal(b, m, deterministic)
+}
+func (m *GetSerialsByKeyRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetSerialsByKeyRequest.Merge(m, src)
+}
+func (m *GetSerialsByKeyRequest) XXX_Size() int {
+	return xxx_messageInfo_GetSerialsByKeyRequest.Size(m)
+}
+func (m *GetSerialsByKeyRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetSerialsByKeyRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetSerialsByKeyRequest proto.InternalMessageInfo
+
+func (m *GetSerialsByKeyRequest) GetKeyHash() []byte {
+	if m != nil {
+		return m.KeyHash
+	}
+	return nil
+}
+
+func (m *GetSerialsByKeyRequest) GetValidAfter() int64 {
+	if m != nil && m.ValidAfter != nil {
+		return *m.ValidAfter
+	}
+	return 0
+}
+
+func (m *GetSerialsByKeyRequest) GetLimit() int64 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+type Serials struct {
+	Serials              []string `protobuf:"bytes,1,rep,name=serials" json:"serials,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Serials) Reset()         { *m = Serials{} }
+func (m *Serials) String() string { return proto.CompactTextString(m) }
+func (*Serials) ProtoMessage()    {}
+func (*Serials) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{39}
+}
+
+func (m *Serials) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Serials.Unmarshal(m, b)
+}
+func (m *Serials) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Serials.Marshal(b, m, deterministic)
+}
+func (m *Serials) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Serials.Merge(m, src)
+}
+func (m *Serials) XXX_Size() int {
+	return xxx_messageInfo_Serials.Size(m)
+}
+func (m *Serials) XXX_DiscardUnknown() {
+	xxx_messageInfo_Serials.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Serials proto.InternalMessageInfo
+
+func (m *Serials) GetSerials() []string {
+	if m != nil {
+		return m.Serials
+	}
+	return nil
+}
+
+type GetOrdersForAccountRequest struct {
+	RegistrationID       *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Before               *int64   `protobuf:"varint,2,opt,name=before" json:"before,omitempty"`
+	Limit                *int64   `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetOrdersForAccountRequest) Reset()         { *m = GetOrdersForAccountRequest{} }
+func (m *GetOrdersForAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*GetOrdersForAccountRequest) ProtoMessage()    {}
+func (*GetOrdersForAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{40}
+}
+
+func (m *GetOrdersForAccountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetOrdersForAccountRequest.Unmarshal(m, b)
+}
+func (m *GetOrdersForAccountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetOrdersForAccountRequest.Marshal(b, m, deterministic)
+}
+func (m *GetOrdersForAccountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetOrdersForAccountRequest.Merge(m, src)
+}
+func (m *GetOrdersForAccountRequest) XXX_Size() int {
+	return xxx_messageInfo_GetOrdersForAccountRequest.Size(m)
+}
+func (m *GetOrdersForAccountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetOrdersForAccountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetOrdersForAccountRequest proto.InternalMessageInfo
+
+func (m *GetOrdersForAccountRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *GetOrdersForAccountRequest) GetBefore() int64 {
+	if m != nil && m.Before != nil {
+		return *m.Before
+	}
+	return 0
+}
+
+func (m *GetOrdersForAccountRequest) GetLimit() int64 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+type OrderIDs struct {
+	Ids                  []int64  `protobuf:"varint,1,rep,name=ids" json:"ids,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *OrderIDs) Reset()         { *m = OrderIDs{} }
+func (m *OrderIDs) String() string { return proto.CompactTextString(m) }
+func (*OrderIDs) ProtoMessage()    {}
+func (*OrderIDs) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{41}
+}
+
+func (m *OrderIDs) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_OrderIDs.Unmarshal(m, b)
+}
+func (m *OrderIDs) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_OrderIDs.Marshal(b, m, deterministic)
+}
+func (m *OrderIDs) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_OrderIDs.Merge(m, src)
+}
+func (m *OrderIDs) XXX_Size() int {
+	return xxx_messageInfo_OrderIDs.Size(m)
+}
+func (m *OrderIDs) XXX_DiscardUnknown() {
+	xxx_messageInfo_OrderIDs.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_OrderIDs proto.InternalMessageInfo
+
+func (m *OrderIDs) GetIds() []int64 {
+	if m != nil {
+		return m.Ids
+	}
+	return nil
+}
+
+type GetCertificatesForAccountRequest struct {
+	RegistrationID       *int64   `protobuf:"varint,1,opt,name=registrationID" json:"registrationID,omitempty"`
+	Before               *int64   `protobuf:"varint,2,opt,name=before" json:"before,omitempty"`
+	Limit                *int64   `protobuf:"varint,3,opt,name=limit" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetCertificatesForAccountRequest) Reset()         { *m = GetCertificatesForAccountRequest{} }
+func (m *GetCertificatesForAccountRequest) String() string { return proto.CompactTextString(m) }
+func (*GetCertificatesForAccountRequest) ProtoMessage()    {}
+func (*GetCertificatesForAccountRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{42}
+}
+
+func (m *GetCertificatesForAccountRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetCertificatesForAccountRequest.Unmarshal(m, b)
+}
+func (m *GetCertificatesForAccountRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetCertificatesForAccountRequest.Marshal(b, m, deterministic)
+}
+func (m *GetCertificatesForAccountRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetCertificatesForAccountRequest.Merge(m, src)
+}
+func (m *GetCertificatesForAccountRequest) XXX_Size() int {
+	return xxx_messageInfo_GetCertificatesForAccountRequest.Size(m)
+}
+func (m *GetCertificatesForAccountRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetCertificatesForAccountRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetCertificatesForAccountRequest proto.InternalMessageInfo
+
+func (m *GetCertificatesForAccountRequest) GetRegistrationID() int64 {
+	if m != nil && m.RegistrationID != nil {
+		return *m.RegistrationID
+	}
+	return 0
+}
+
+func (m *GetCertificatesForAccountRequest) GetBefore() int64 {
+	if m != nil && m.Before != nil {
+		return *m.Before
+	}
+	return 0
+}
+
+func (m *GetCertificatesForAccountRequest) GetLimit() int64 {
+	if m != nil && m.Limit != nil {
+		return *m.Limit
+	}
+	return 0
+}
+
+type AccountCertificate struct {
+	Id                   *int64              `protobuf:"varint,1,opt,name=id" json:"id,omitempty"`
+	Certificate          *proto1.Certificate `protobuf:"bytes,2,opt,name=certificate" json:"certificate,omitempty"`
+	Status               *string             `protobuf:"bytes,3,opt,name=status" json:"status,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *AccountCertificate) Reset()         { *m = AccountCertificate{} }
+func (m *AccountCertificate) String() string { return proto.CompactTextString(m) }
+func (*AccountCertificate) ProtoMessage()    {}
+func (*AccountCertificate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{43}
+}
+
+func (m *AccountCertificate) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountCertificate.Unmarshal(m, b)
+}
+func (m *AccountCertificate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountCertificate.Marshal(b, m, deterministic)
+}
+func (m *AccountCertificate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountCertificate.Merge(m, src)
+}
+func (m *AccountCertificate) XXX_Size() int {
+	return xxx_messageInfo_AccountCertificate.Size(m)
+}
+func (m *AccountCertificate) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountCertificate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountCertificate proto.InternalMessageInfo
+
+func (m *AccountCertificate) GetId() int64 {
+	if m != nil && m.Id != nil {
+		return *m.Id
+	}
+	return 0
+}
+
+func (m *AccountCertificate) GetCertificate() *proto1.Certificate {
+	if m != nil {
+		return m.Certificate
+	}
+	return nil
+}
+
+func (m *AccountCertificate) GetStatus() string {
+	if m != nil && m.Status != nil {
+		return *m.Status
+	}
+	return ""
+}
+
+type AccountCertificates struct {
+	Certificates         []*AccountCertificate `protobuf:"bytes,1,rep,name=certificates" json:"certificates,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *AccountCertificates) Reset()         { *m = AccountCertificates{} }
+func (m *AccountCertificates) String() string { return proto.CompactTextString(m) }
+func (*AccountCertificates) ProtoMessage()    {}
+func (*AccountCertificates) Descriptor() ([]byte, []int) {
+	return fileDescriptor_099fb35e782a48a6, []int{44}
+}
+
+func (m *AccountCertificates) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AccountCertificates.Unmarshal(m, b)
+}
+func (m *AccountCertificates) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AccountCertificates.Marshal(b, m, deterministic)
+}
+func (m *AccountCertificates) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AccountCertificates.Merge(m, src)
+}
+func (m *AccountCertificates) XXX_Size() int {
+	return xxx_messageInfo_AccountCertificates.Size(m)
+}
+func (m *AccountCertificates) XXX_DiscardUnknown() {
+	xxx_messageInfo_AccountCertificates.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AccountCertificates proto.InternalMessageInfo
+
+func (m *AccountCertificates) GetCertificates() []*AccountCertificate {
+	if m != nil {
+		return m.Certificates
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*RegistrationID)(nil), "sa.RegistrationID")
 	proto.RegisterType((*JSONWebKey)(nil), "sa.JSONWebKey")
@@ -1777,117 +2389,157 @@ func init() {
 	proto.RegisterType((*AuthorizationIDs)(nil), "sa.AuthorizationIDs")
 	proto.RegisterType((*AuthorizationID2)(nil), "sa.AuthorizationID2")
 	proto.RegisterType((*RevokeCertificateRequest)(nil), "sa.RevokeCertificateRequest")
+	proto.RegisterType((*ExternalAccountKeyID)(nil), "sa.ExternalAccountKeyID")
+	proto.RegisterType((*ExternalAccountKey)(nil), "sa.ExternalAccountKey")
+	proto.RegisterType((*AddBlockedKeyRequest)(nil), "sa.AddBlockedKeyRequest")
+	proto.RegisterType((*AddKeyHashRequest)(nil), "sa.AddKeyHashRequest")
+	proto.RegisterType((*KeyBlockedRequest)(nil), "sa.KeyBlockedRequest")
+	proto.RegisterType((*GetSerialsByKeyRequest)(nil), "sa.GetSerialsByKeyRequest")
+	proto.RegisterType((*Serials)(nil), "sa.Serials")
+	proto.RegisterType((*GetOrdersForAccountRequest)(nil), "sa.GetOrdersForAccountRequest")
+	proto.RegisterType((*OrderIDs)(nil), "sa.OrderIDs")
+	proto.RegisterType((*GetCertificatesForAccountRequest)(nil), "sa.GetCertificatesForAccountRequest")
+	proto.RegisterType((*AccountCertificate)(nil), "sa.AccountCertificate")
+	proto.RegisterType((*AccountCertificates)(nil), "sa.AccountCertificates")
 }
 
 func init() { proto.RegisterFile("sa/proto/sa.proto", fileDescriptor_099fb35e782a48a6) }
 
 var fileDescriptor_099fb35e782a48a6 = []byte{
-	// 1667 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x58, 0xeb, 0x6e, 0xdb, 0xc6,
-	0x12, 0xd6, 0xc5, 0x72, 0xa4, 0xf1, 0x7d, 0x6d, 0xcb, 0x0c, 0x7d, 0x89, 0xb3, 0xf1, 0xc9, 0x71,
-	0x70, 0x00, 0x27, 0x47, 0x2d, 0x92, 0x00, 0x6e, 0xda, 0xda, 0xb1, 0xa3, 0x38, 0x17, 0xdb, 0xa5,
-	0x12, 0x27, 0x68, 0x81, 0x02, 0x8c, 0xb8, 0x51, 0x58, 0xcb, 0xa4, 0xb2, 0xbb, 0xb2, 0x23, 0xbf,
-	0x40, 0xfb, 0x04, 0x45, 0x7f, 0xf6, 0x39, 0xfa, 0x24, 0x7d, 0x95, 0xfe, 0x2b, 0xf6, 0x42, 0x8a,
-	0xa4, 0x48, 0x29, 0x41, 0x8a, 0xfe, 0xdb, 0x99, 0x9d, 0xf9, 0x66, 0x76, 0x77, 0x66, 0xf8, 0x49,
-	0x30, 0xc7, 0xec, 0xdb, 0x1d, 0xea, 0x73, 0xff, 0x36, 0xb3, 0xb7, 0xe4, 0x02, 0x15, 0x98, 0x6d,
-	0x2e, 0x36, 0x7d, 0x4a, 0xf4, 0x86, 0x58, 0xaa, 0x2d, 0xbc, 0x0e, 0xd3, 0x16, 0x69, 0xb9, 0x8c,
-	0x53, 0x9b, 0xbb, 0xbe, 0x77, 0xb0, 0x87, 0xa6, 0xa1, 0xe0, 0x3a, 0x46, 0x7e, 0x3d, 0xbf, 0x59,
-	0xb4, 0x0a, 0xae, 0x83, 0xd7, 0x00, 0x9e, 0x34, 0x8e, 0x0e, 0x5f, 0x91, 0x37, 0x4f, 0x49, 0x0f,
-	0xcd, 0x42, 0xf1, 0xa7, 0x8b, 0x53, 0xb9, 0x3d, 0x69, 0x89, 0x25, 0xbe, 0x0e, 0x33, 0x3b, 0x5d,
-	0xfe, 0xce, 0xa7, 0xee, 0xe5, 0x20, 0x44, 0x45, 0x42, 0xfc, 0x91, 0x87, 0xb5, 0x3a, 0xe1, 0xc7,
-	0xc4, 0x73, 0x5c, 0xaf, 0x15, 0xb3, 0xb6, 0xc8, 0xfb, 0x2e, 0x61, 0x1c, 0xdd, 0x84, 0x69, 0x1a,
-	0xcb, 0x43, 0x67, 0x90, 0xd0, 0x0a, 0x3b, 0xd7, 0x21, 0x1e, 0x77, 0xdf, 0xba, 0x84, 0xbe, 0xe8,
-	0x75, 0x88, 0x51, 0x90, 0x61, 0x12, 0x5a, 0xb4, 0x09, 0x33, 0x7d, 0xcd, 0x89, 0xdd, 0xee, 0x12,
-	0xa3, 0x28, 0x0d, 0x93, 0x6a, 0xb4, 0x06, 0x70, 0x6e, 0xb7, 0x5d, 0xe7, 0xa5, 0xc7, 0xdd, 0xb6,
-	0x31, 0x26, 0xa3, 0x46, 0x34, 0x98, 0xc1, 0x6a, 0x9d, 0xf0, 0x13, 0xa1, 0x88, 0x65, 0xce, 0x3e,
-	0x35, 0x75, 0x03, 0xae, 0x38, 0xfe, 0x99, 0xed, 0x7a, 0xcc, 0x28, 0xac, 0x17, 0x37, 0x2b, 0x56,
-	0x20, 0x8a, 0x4b, 0xf5, 0xfc, 0x0b, 0x99, 0x60, 0xd1, 0x12, 0x4b, 0xfc, 0x7b, 0x1e, 0xe6, 0x53,
-	0x42, 0xa2, 0xfb, 0x50, 0x92, 0xa9, 0x19, 0xf9, 0xf5, 0xe2, 0xe6, 0x44, 0x0d, 0x6f, 0x31, 0x7b,
-	0x2b, 0xc5, 0x6e, 0xeb, 0xb9, 0xdd, 0xd9, 0x6f, 0x93, 0x33, 0xe2, 0x71, 0x4b, 0x39, 0x98, 0x47,
-	0x00, 0x7d, 0x25, 0xaa, 0xc2, 0xb8, 0x0a, 0xae, 0x5f, 0x49, 0x4b, 0xe8, 0x16, 0x94, 0xec, 0x2e,
-	0x7f, 0x77, 0x29, 0x6f, 0x75, 0xa2, 0x36, 0xbf, 0x25, 0x4b, 0x25, 0xfe, 0x62, 0xca, 0x02, 0xff,
-	0x55, 0x80, 0xb9, 0x87, 0x84, 0x8a, 0xab, 0x6c, 0xda, 0x9c, 0x34, 0xb8, 0xcd, 0xbb, 0x4c, 0x00,
-	0x33, 0x42, 0x5d, 0xbb, 0x1d, 0x00, 0x2b, 0x09, 0x6d, 0x01, 0x62, 0xdd, 0x37, 0xac, 0x49, 0xdd,
-	0x37, 0x84, 0xee, 0x74, 0x3a, 0xd4, 0x3f, 0x27, 0x8e, 0x8c, 0x52, 0xb6, 0x52, 0x76, 0x24, 0x8e,
-	0x44, 0xd4, 0xcf, 0xa6, 0x25, 0xf1, 0xae, 0x7e, 0x93, 0x75, 0x9e, 0xd9, 0x8c, 0xbf, 0xec, 0x38,
-	0x36, 0x27, 0x8e, 0x7e, 0xb2, 0xa4, 0x1a, 0xad, 0xc3, 0x04, 0x25, 0xe7, 0xfe, 0x29, 0x71, 0xf6,
-	0x6c, 0x4e, 0x8c, 0x92, 0xb4, 0x8a, 0xaa, 0xd0, 0x06, 0x4c, 0x69, 0xd1, 0x22, 0x36, 0xf3, 0x3d,
-	0x63, 0x5c, 0xda, 0xc4, 0x95, 0xe8, 0x4b, 0x58, 0x6c, 0xdb, 0x8c, 0xef, 0x7f, 0xe8, 0xb8, 0xea,
-	0x29, 0x0f, 0xed, 0x56, 0x83, 0x78, 0xdc, 0xb8, 0x22, 0xad, 0xd3, 0x37, 0x11, 0x86, 0x49, 0x91,
-	0x90, 0x45, 0x58, 0xc7, 0xf7, 0x18, 0x31, 0xca, 0xb2, 0x61, 0x62, 0x3a, 0x64, 0x42, 0xd9, 0xf3,
-	0xf9, 0xce, 0x5b, 0x4e, 0xa8, 0x51, 0x91, 0x60, 0xa1, 0x8c, 0x56, 0xa0, 0xe2, 0x32, 0x09, 0x4b,
-	0x1c, 0x03, 0xe4, 0x35, 0xf5, 0x15, 0x78, 0x1d, 0xc6, 0x1b, 0xea, 0x5e, 0x33, 0xee, 0x1b, 0x6f,
-	0x43, 0xc9, 0xb2, 0xbd, 0x96, 0x0c, 0x42, 0x6c, 0xda, 0x76, 0x09, 0xe3, 0xba, 0x2e, 0x43, 0x59,
-	0x38, 0xb7, 0x6d, 0x2e, 0x76, 0x0a, 0x72, 0x47, 0x4b, 0x78, 0x15, 0x4a, 0x0f, 0xfd, 0xae, 0xc7,
-	0xd1, 0x02, 0x94, 0x9a, 0x62, 0xa1, 0x3d, 0x95, 0x80, 0x5f, 0xc3, 0x35, 0xb9, 0x1d, 0x79, 0x7d,
-	0xb6, 0xdb, 0x3b, 0xb4, 0xcf, 0x48, 0xd8, 0x13, 0xd7, 0xa0, 0x44, 0x45, 0x78, 0xe9, 0x38, 0x51,
-	0xab, 0x88, 0x3a, 0x95, 0xf9, 0x58, 0x4a, 0x2f, 0x90, 0x3d, 0xe1, 0xa0, 0x5b, 0x41, 0x09, 0xf8,
-	0xe7, 0x3c, 0x4c, 0x4a, 0x68, 0x0d, 0x87, 0xbe, 0x81, 0xc9, 0x66, 0x44, 0xd6, 0x65, 0xbf, 0x2c,
-	0xe0, 0xa2, 0x76, 0xd1, 0x7a, 0x8f, 0x39, 0x98, 0x77, 0x63, 0x65, 0x8f, 0x60, 0x4c, 0x04, 0xd2,
-	0x77, 0x25, 0xd7, 0xfd, 0x33, 0x16, 0xa2, 0x67, 0x3c, 0x86, 0x55, 0x19, 0x20, 0x3a, 0x1c, 0xd9,
-	0x6e, 0xef, 0xe0, 0x38, 0x38, 0xa1, 0x98, 0x71, 0x1d, 0x3d, 0x07, 0x0b, 0x6e, 0xa7, 0x7f, 0xe2,
-	0x42, 0xfa, 0x89, 0xf1, 0x2f, 0x79, 0xb8, 0x2e, 0x21, 0x0f, 0xbc, 0xf3, 0xcf, 0x1f, 0x26, 0x26,
-	0x94, 0xdf, 0xf9, 0x8c, 0xcb, 0xd3, 0xa8, 0x09, 0x18, 0xca, 0xfd, 0x54, 0x8a, 0x19, 0xa9, 0x34,
-	0x00, 0xc9, 0x4c, 0x8e, 0xa8, 0x43, 0x68, 0x18, 0x7a, 0x05, 0x2a, 0x76, 0x53, 0x9e, 0x3e, 0x8c,
-	0xda, 0x57, 0x8c, 0x3e, 0xdf, 0x63, 0x58, 0x90, 0xa0, 0x8f, 0xbe, 0xdb, 0x3b, 0x6c, 0x10, 0x1e,
-	0xc2, 0x56, 0x61, 0xfc, 0xc2, 0xf5, 0x1c, 0xff, 0x42, 0x63, 0x6a, 0x29, 0x7b, 0x1c, 0xe2, 0x3b,
-	0xb0, 0xa0, 0x41, 0xf6, 0x3f, 0xb8, 0xac, 0x8f, 0x14, 0xf1, 0xc8, 0xc7, 0x3d, 0x8e, 0x61, 0xfd,
-	0x98, 0x92, 0x73, 0xd7, 0xef, 0xb2, 0x48, 0x51, 0xc6, 0xbd, 0xb3, 0x46, 0xde, 0x02, 0x94, 0x28,
-	0x69, 0x1d, 0xec, 0x05, 0xef, 0x2f, 0x05, 0xd1, 0x61, 0xca, 0x5d, 0xf8, 0x11, 0xb9, 0x92, 0x7e,
-	0x65, 0x4b, 0x4b, 0xf8, 0x29, 0xac, 0x3e, 0xb7, 0xe9, 0x69, 0x24, 0x9e, 0x15, 0xcc, 0x8d, 0x30,
-	0x60, 0xea, 0x28, 0x44, 0x30, 0xd6, 0xf4, 0x1d, 0xa2, 0xe3, 0xc9, 0x35, 0x3e, 0x85, 0xc5, 0x1d,
-	0xc7, 0x89, 0x61, 0x29, 0x90, 0x59, 0x28, 0x3a, 0x84, 0x06, 0xdf, 0x5b, 0x87, 0xd0, 0xf4, 0x7c,
-	0x05, 0xa8, 0x98, 0x2d, 0xf2, 0xc9, 0x27, 0x2d, 0xb9, 0x16, 0x09, 0xb8, 0x8c, 0x75, 0xc3, 0x11,
-	0xa9, 0x25, 0x7c, 0x07, 0xaa, 0xc9, 0x60, 0x7a, 0x22, 0x89, 0x3b, 0x72, 0x5b, 0xc1, 0xa8, 0x10,
-	0x77, 0x24, 0x25, 0xfc, 0x00, 0x6e, 0xa8, 0xc3, 0xc5, 0x8b, 0x76, 0xb7, 0xb7, 0x27, 0xef, 0x70,
-	0xc4, 0x15, 0xe3, 0x1f, 0x61, 0x63, 0xb8, 0xbb, 0x0e, 0xbf, 0x02, 0x95, 0xb7, 0xae, 0x67, 0xb7,
-	0xdd, 0x4b, 0x12, 0x30, 0x90, 0xbe, 0x42, 0x3c, 0x7f, 0x47, 0x31, 0x08, 0x7d, 0xf4, 0x40, 0xc4,
-	0x6b, 0x30, 0x29, 0x4b, 0x39, 0xda, 0x9b, 0x51, 0x0a, 0xf3, 0x0c, 0x70, 0xf0, 0x09, 0x97, 0x76,
-	0xe9, 0xad, 0x97, 0xf0, 0x12, 0xa7, 0xb1, 0x9b, 0x4d, 0x1e, 0xde, 0xb4, 0x96, 0x70, 0x1d, 0x96,
-	0xea, 0x44, 0xf5, 0xce, 0x23, 0x9f, 0xc6, 0xc6, 0x5e, 0xdf, 0x25, 0x1f, 0x75, 0xc9, 0x98, 0x76,
-	0xbf, 0xe5, 0xc1, 0xa8, 0x13, 0xfe, 0xaf, 0xb1, 0x0a, 0xf1, 0xf1, 0xa4, 0xe4, 0x7d, 0xd7, 0xa5,
-	0xe4, 0xa4, 0x26, 0xa2, 0x5e, 0x32, 0x59, 0x19, 0x65, 0x2b, 0xa9, 0xc6, 0xbf, 0xe6, 0x61, 0x3a,
-	0x41, 0x3d, 0xbe, 0x08, 0xa8, 0x81, 0x9a, 0xc1, 0xab, 0x62, 0x00, 0x0c, 0x61, 0x1d, 0xd2, 0xf6,
-	0x9f, 0x67, 0x1d, 0xcf, 0xe0, 0xda, 0x8e, 0xe3, 0xa4, 0x31, 0xc9, 0xf0, 0xe6, 0x6e, 0xc5, 0x13,
-	0x1d, 0x86, 0xb6, 0x01, 0xb3, 0x09, 0xee, 0x2a, 0xaf, 0xcd, 0x75, 0x82, 0x09, 0x23, 0x96, 0x18,
-	0x0f, 0x58, 0xd5, 0x06, 0x4a, 0xec, 0x12, 0x0c, 0x55, 0xe2, 0x29, 0x3d, 0x9c, 0x35, 0x08, 0xaa,
-	0x30, 0x4e, 0x15, 0xf1, 0xd0, 0x05, 0xa6, 0x24, 0xd1, 0xcb, 0x82, 0xc2, 0xe8, 0x97, 0x93, 0x6b,
-	0x31, 0xef, 0x69, 0xc0, 0x25, 0xc6, 0x64, 0x8f, 0x87, 0x72, 0xed, 0xcf, 0x79, 0x98, 0x6d, 0x70,
-	0x9f, 0xda, 0xad, 0xa0, 0xc1, 0x78, 0x0f, 0x6d, 0xc3, 0x4c, 0x9d, 0xc4, 0x3e, 0x5f, 0x08, 0xc9,
-	0x99, 0x1d, 0x2b, 0x1f, 0x13, 0xa9, 0xdb, 0x89, 0x6a, 0x71, 0x0e, 0x7d, 0x05, 0x0b, 0x09, 0xe7,
-	0xdd, 0x9e, 0x60, 0xff, 0xd3, 0x02, 0xa1, 0xff, 0x6b, 0x20, 0xc3, 0xfb, 0x6b, 0x98, 0x4d, 0x96,
-	0x35, 0x9a, 0x1f, 0x28, 0x97, 0x83, 0x3d, 0x33, 0xed, 0x69, 0x70, 0x0e, 0xbd, 0x90, 0x0d, 0x96,
-	0xf6, 0xc6, 0x48, 0x12, 0xde, 0xe1, 0x3f, 0x25, 0xb2, 0x50, 0x4f, 0xa0, 0x9a, 0xce, 0xe3, 0xd1,
-	0x75, 0x0d, 0x9a, 0xcd, 0xf1, 0xcd, 0xa5, 0x0c, 0xa2, 0x8d, 0x73, 0xe8, 0xff, 0x30, 0x5d, 0x27,
-	0x51, 0x2e, 0x84, 0x40, 0x18, 0x2b, 0x7e, 0x66, 0xce, 0xa9, 0x64, 0x22, 0xdb, 0x38, 0x87, 0xb6,
-	0xe5, 0xf5, 0x0e, 0x92, 0xe7, 0xa8, 0xe3, 0xa2, 0xe4, 0x38, 0x49, 0x13, 0x9c, 0x43, 0x0d, 0x30,
-	0xb2, 0xd8, 0x17, 0xba, 0x11, 0x12, 0xa3, 0x6c, 0x6e, 0x66, 0xce, 0x26, 0xd9, 0x13, 0xce, 0xa1,
-	0xd7, 0x9a, 0xee, 0xc4, 0xdd, 0xf6, 0x3f, 0xd8, 0x4d, 0xfe, 0x99, 0xc8, 0x8f, 0xa1, 0x9a, 0x4e,
-	0xa4, 0xd4, 0xb5, 0x0f, 0x25, 0x59, 0x66, 0x25, 0x34, 0xc1, 0x39, 0xf4, 0x1c, 0x96, 0x33, 0xac,
-	0x25, 0xa3, 0xfc, 0x54, 0xb8, 0x07, 0x60, 0xca, 0x65, 0xea, 0x2c, 0x49, 0xed, 0x95, 0x98, 0x7b,
-	0x0d, 0x26, 0x22, 0x1c, 0x0a, 0x55, 0xc3, 0xbd, 0x18, 0xa9, 0x8a, 0xfb, 0x1c, 0xeb, 0x90, 0xa9,
-	0x0c, 0x10, 0xfd, 0x27, 0x34, 0x1d, 0xc6, 0x10, 0xe3, 0x88, 0x77, 0x61, 0x2a, 0x46, 0xba, 0x90,
-	0x11, 0xee, 0x26, 0x78, 0x58, 0xdc, 0xef, 0x1e, 0x4c, 0xc5, 0x28, 0x96, 0xf2, 0x4b, 0x63, 0x5d,
-	0xa6, 0x2c, 0x4a, 0xa5, 0xc2, 0x39, 0x74, 0x04, 0x57, 0x33, 0x99, 0x16, 0xda, 0x10, 0xa6, 0xa3,
-	0x88, 0x58, 0x02, 0xf0, 0x3e, 0x54, 0xf4, 0xb0, 0xb8, 0xac, 0xa1, 0x85, 0x94, 0x29, 0x51, 0xcb,
-	0x6a, 0xe8, 0x6d, 0x98, 0x39, 0x24, 0x17, 0x89, 0x09, 0x37, 0x30, 0x8f, 0x32, 0x66, 0xd4, 0x3d,
-	0x40, 0xea, 0x87, 0xe2, 0x48, 0xff, 0x09, 0xa5, 0xdb, 0x3f, 0xeb, 0xf0, 0x1e, 0xce, 0xa1, 0x7d,
-	0x58, 0x3a, 0x24, 0x17, 0xa9, 0xc3, 0x29, 0x2d, 0xcf, 0xec, 0xe4, 0x17, 0x1f, 0x69, 0x66, 0xf3,
-	0x11, 0x20, 0x89, 0x1c, 0x9e, 0x40, 0x35, 0x9d, 0x7a, 0xaa, 0x26, 0x18, 0x4a, 0x4b, 0x93, 0x58,
-	0x07, 0x30, 0x1d, 0x27, 0x83, 0xe8, 0xaa, 0x7c, 0x84, 0x34, 0x36, 0x6a, 0x9a, 0x69, 0x5b, 0xea,
-	0x2b, 0x84, 0x73, 0x88, 0xc1, 0xca, 0x30, 0x9a, 0x87, 0xfe, 0xab, 0x7a, 0x6a, 0x24, 0x8f, 0x34,
-	0x37, 0x47, 0x1b, 0x86, 0x41, 0xb7, 0xa1, 0xba, 0x47, 0xec, 0x26, 0x77, 0xcf, 0x07, 0x1f, 0x73,
-	0xb0, 0x85, 0x13, 0x87, 0x7f, 0x00, 0x4b, 0x7d, 0xe7, 0x8f, 0xf8, 0x60, 0x25, 0xdc, 0x6f, 0x42,
-	0xf9, 0x90, 0x5c, 0xc8, 0x86, 0x47, 0x7a, 0x4b, 0x0a, 0x66, 0x54, 0xc0, 0x39, 0x74, 0x07, 0x50,
-	0x43, 0x33, 0xc6, 0x63, 0xea, 0x37, 0x09, 0x63, 0xae, 0xd7, 0x4a, 0xf5, 0x08, 0x90, 0xff, 0x07,
-	0x53, 0x81, 0xc7, 0x3e, 0xa5, 0x3e, 0x1d, 0x65, 0x1c, 0xd4, 0x52, 0x76, 0x2e, 0x7d, 0xe3, 0x72,
-	0xc0, 0x5e, 0x91, 0x9c, 0xd7, 0x51, 0xe6, 0x9c, 0x4c, 0xfc, 0x07, 0x58, 0x1e, 0x42, 0x9c, 0xd1,
-	0xcd, 0xe8, 0x87, 0x33, 0x9b, 0x59, 0x9b, 0x68, 0x90, 0x2b, 0x86, 0x34, 0x21, 0xc6, 0xa3, 0xd1,
-	0xb2, 0x46, 0x4c, 0x63, 0xd7, 0xc9, 0xe4, 0xea, 0x30, 0x37, 0xc0, 0x9e, 0xd1, 0x8a, 0x06, 0xf8,
-	0x94, 0x44, 0x5e, 0x81, 0x91, 0xc5, 0x29, 0xd5, 0x77, 0x6f, 0x04, 0xe3, 0x34, 0xd3, 0xc6, 0x96,
-	0x00, 0xfe, 0x16, 0xe6, 0x06, 0x48, 0xa1, 0xca, 0x30, 0x8b, 0x2b, 0x26, 0x5e, 0x6b, 0xf7, 0xca,
-	0xf7, 0x25, 0xf9, 0x3f, 0xed, 0xdf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x6b, 0x36, 0xc0, 0xfd, 0xd6,
-	0x15, 0x00, 0x00,
+	// 2121 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbc, 0x19, 0xdb, 0x52, 0x1c, 0xc7,
+	0x75, 0x2f, 0x5a, 0xc4, 0x1e, 0x10, 0x82, 0x06, 0x56, 0xa3, 0x11, 0x20, 0xd4, 0x52, 0x14, 0x5c,
+	0x49, 0xb0, 0xb2, 0x4e, 0xd9, 0xaa, 0x60, 0x39, 0x01, 0x81, 0x56, 0x18, 0x09, 0xe1, 0xc1, 0x96,
+	0x5d, 0x49, 0x2a, 0x55, 0xa3, 0x99, 0x16, 0x4c, 0x58, 0x66, 0x56, 0x3d, 0xbd, 0xc0, 0xf2, 0x03,
+	0xc9, 0x17, 0xa4, 0xf2, 0x98, 0xc7, 0x3c, 0xe5, 0x03, 0xf2, 0x67, 0x79, 0x4b, 0xf5, 0xe9, 0x9e,
+	0xfb, 0x0c, 0x2b, 0xca, 0x29, 0xbf, 0xcd, 0x39, 0x7d, 0x6e, 0xdd, 0x7d, 0xae, 0x3d, 0x30, 0x17,
+	0xda, 0x9f, 0x0e, 0x78, 0x20, 0x82, 0x4f, 0x43, 0x7b, 0x1d, 0x3f, 0x48, 0x23, 0xb4, 0xcd, 0x45,
+	0x27, 0xe0, 0x4c, 0x2f, 0xc8, 0x4f, 0xb5, 0x44, 0x57, 0x61, 0xc6, 0x62, 0x47, 0x5e, 0x28, 0xb8,
+	0x2d, 0xbc, 0xc0, 0xdf, 0xdd, 0x26, 0x33, 0xd0, 0xf0, 0x5c, 0xa3, 0xbe, 0x5a, 0x5f, 0x6b, 0x5a,
+	0x0d, 0xcf, 0xa5, 0x2b, 0x00, 0x5f, 0x1f, 0xbe, 0xd9, 0xff, 0x9e, 0xbd, 0xdb, 0x63, 0x23, 0x32,
+	0x0b, 0xcd, 0xbf, 0x9c, 0x9f, 0xe0, 0xf2, 0xb4, 0x25, 0x3f, 0xe9, 0x03, 0xb8, 0xbd, 0x39, 0x14,
+	0xc7, 0x01, 0xf7, 0x2e, 0x8b, 0x22, 0xda, 0x28, 0xe2, 0x3f, 0x75, 0x58, 0xe9, 0x31, 0x71, 0xc0,
+	0x7c, 0xd7, 0xf3, 0x8f, 0x32, 0xd4, 0x16, 0xfb, 0x30, 0x64, 0xa1, 0x20, 0x8f, 0x61, 0x86, 0x67,
+	0xec, 0xd0, 0x16, 0xe4, 0xb0, 0x92, 0xce, 0x73, 0x99, 0x2f, 0xbc, 0xf7, 0x1e, 0xe3, 0xdf, 0x8e,
+	0x06, 0xcc, 0x68, 0xa0, 0x9a, 0x1c, 0x96, 0xac, 0xc1, 0xed, 0x04, 0xf3, 0xd6, 0xee, 0x0f, 0x99,
+	0xd1, 0x44, 0xc2, 0x3c, 0x9a, 0xac, 0x00, 0x9c, 0xd9, 0x7d, 0xcf, 0xfd, 0xce, 0x17, 0x5e, 0xdf,
+	0xb8, 0x81, 0x5a, 0x53, 0x18, 0x1a, 0xc2, 0x72, 0x8f, 0x89, 0xb7, 0x12, 0x91, 0xb1, 0x3c, 0xbc,
+	0xae, 0xe9, 0x06, 0xdc, 0x74, 0x83, 0x53, 0xdb, 0xf3, 0x43, 0xa3, 0xb1, 0xda, 0x5c, 0x6b, 0x5b,
+	0x11, 0x28, 0x0f, 0xd5, 0x0f, 0xce, 0xd1, 0xc0, 0xa6, 0x25, 0x3f, 0xe9, 0x3f, 0xeb, 0x30, 0x5f,
+	0xa2, 0x92, 0x3c, 0x85, 0x16, 0x9a, 0x66, 0xd4, 0x57, 0x9b, 0x6b, 0x53, 0x5d, 0xba, 0x1e, 0xda,
+	0xeb, 0x25, 0x74, 0xeb, 0xaf, 0xed, 0xc1, 0x4e, 0x9f, 0x9d, 0x32, 0x5f, 0x58, 0x8a, 0xc1, 0x7c,
+	0x03, 0x90, 0x20, 0x49, 0x07, 0x26, 0x94, 0x72, 0x7d, 0x4b, 0x1a, 0x22, 0x9f, 0x40, 0xcb, 0x1e,
+	0x8a, 0xe3, 0x4b, 0x3c, 0xd5, 0xa9, 0xee, 0xfc, 0x3a, 0xba, 0x4a, 0xf6, 0xc6, 0x14, 0x05, 0xfd,
+	0x6f, 0x03, 0xe6, 0x9e, 0x33, 0x2e, 0x8f, 0xd2, 0xb1, 0x05, 0x3b, 0x14, 0xb6, 0x18, 0x86, 0x52,
+	0x70, 0xc8, 0xb8, 0x67, 0xf7, 0x23, 0xc1, 0x0a, 0x22, 0xeb, 0x40, 0xc2, 0xe1, 0xbb, 0xd0, 0xe1,
+	0xde, 0x3b, 0xc6, 0x37, 0x07, 0x03, 0x1e, 0x9c, 0x31, 0x17, 0xb5, 0x4c, 0x5a, 0x25, 0x2b, 0x28,
+	0x07, 0x25, 0xea, 0x6b, 0xd3, 0x90, 0xbc, 0xd7, 0xc0, 0x09, 0x07, 0xaf, 0xec, 0x50, 0x7c, 0x37,
+	0x70, 0x6d, 0xc1, 0x5c, 0x7d, 0x65, 0x79, 0x34, 0x59, 0x85, 0x29, 0xce, 0xce, 0x82, 0x13, 0xe6,
+	0x6e, 0xdb, 0x82, 0x19, 0x2d, 0xa4, 0x4a, 0xa3, 0xc8, 0x23, 0xb8, 0xa5, 0x41, 0x8b, 0xd9, 0x61,
+	0xe0, 0x1b, 0x13, 0x48, 0x93, 0x45, 0x92, 0xdf, 0xc0, 0x62, 0xdf, 0x0e, 0xc5, 0xce, 0xc5, 0xc0,
+	0x53, 0x57, 0xb9, 0x6f, 0x1f, 0x1d, 0x32, 0x5f, 0x18, 0x37, 0x91, 0xba, 0x7c, 0x91, 0x50, 0x98,
+	0x96, 0x06, 0x59, 0x2c, 0x1c, 0x04, 0x7e, 0xc8, 0x8c, 0x49, 0x0c, 0x98, 0x0c, 0x8e, 0x98, 0x30,
+	0xe9, 0x07, 0x62, 0xf3, 0xbd, 0x60, 0xdc, 0x68, 0xa3, 0xb0, 0x18, 0x26, 0x4b, 0xd0, 0xf6, 0x42,
+	0x14, 0xcb, 0x5c, 0x03, 0xf0, 0x98, 0x12, 0x04, 0x5d, 0x85, 0x89, 0x43, 0x75, 0xae, 0x15, 0xe7,
+	0x4d, 0x37, 0xa0, 0x65, 0xd9, 0xfe, 0x11, 0x2a, 0x61, 0x36, 0xef, 0x7b, 0x2c, 0x14, 0xda, 0x2f,
+	0x63, 0x58, 0x32, 0xf7, 0x6d, 0x21, 0x57, 0x1a, 0xb8, 0xa2, 0x21, 0xba, 0x0c, 0xad, 0xe7, 0xc1,
+	0xd0, 0x17, 0x64, 0x01, 0x5a, 0x8e, 0xfc, 0xd0, 0x9c, 0x0a, 0xa0, 0x3f, 0xc0, 0x7d, 0x5c, 0x4e,
+	0xdd, 0x7e, 0xb8, 0x35, 0xda, 0xb7, 0x4f, 0x59, 0x1c, 0x13, 0xf7, 0xa1, 0xc5, 0xa5, 0x7a, 0x64,
+	0x9c, 0xea, 0xb6, 0xa5, 0x9f, 0xa2, 0x3d, 0x96, 0xc2, 0x4b, 0xc9, 0xbe, 0x64, 0xd0, 0xa1, 0xa0,
+	0x00, 0xfa, 0xd7, 0x3a, 0x4c, 0xa3, 0x68, 0x2d, 0x8e, 0xfc, 0x0e, 0xa6, 0x9d, 0x14, 0xac, 0xdd,
+	0xfe, 0x9e, 0x14, 0x97, 0xa6, 0x4b, 0xfb, 0x7b, 0x86, 0xc1, 0xfc, 0x3c, 0xe3, 0xf6, 0x04, 0x6e,
+	0x48, 0x45, 0xfa, 0xac, 0xf0, 0x3b, 0xd9, 0x63, 0x23, 0xbd, 0xc7, 0x03, 0x58, 0x46, 0x05, 0xe9,
+	0xe4, 0x18, 0x6e, 0x8d, 0x76, 0x0f, 0xa2, 0x1d, 0xca, 0x1c, 0x37, 0xd0, 0x79, 0xb0, 0xe1, 0x0d,
+	0x92, 0x1d, 0x37, 0xca, 0x77, 0x4c, 0xff, 0x56, 0x87, 0x07, 0x28, 0x72, 0xd7, 0x3f, 0xfb, 0xf1,
+	0xc9, 0xc4, 0x84, 0xc9, 0xe3, 0x20, 0x14, 0xb8, 0x1b, 0x95, 0x01, 0x63, 0x38, 0x31, 0xa5, 0x59,
+	0x61, 0xca, 0x21, 0x10, 0xb4, 0xe4, 0x0d, 0x77, 0x19, 0x8f, 0x55, 0x2f, 0x41, 0xdb, 0x76, 0x70,
+	0xf7, 0xb1, 0xd6, 0x04, 0x31, 0x7e, 0x7f, 0x2f, 0x61, 0x01, 0x85, 0xbe, 0xf8, 0x66, 0x7b, 0xff,
+	0x90, 0x89, 0x58, 0x6c, 0x07, 0x26, 0xce, 0x3d, 0xdf, 0x0d, 0xce, 0xb5, 0x4c, 0x0d, 0x55, 0xa7,
+	0x43, 0xfa, 0x04, 0x16, 0xb4, 0x90, 0x9d, 0x0b, 0x2f, 0x4c, 0x24, 0xa5, 0x38, 0xea, 0x59, 0x8e,
+	0x03, 0x58, 0x3d, 0xe0, 0xec, 0xcc, 0x0b, 0x86, 0x61, 0xca, 0x29, 0xb3, 0xdc, 0x55, 0x29, 0x6f,
+	0x01, 0x5a, 0x9c, 0x1d, 0xed, 0x6e, 0x47, 0xf7, 0x8f, 0x80, 0x8c, 0x30, 0xc5, 0x2e, 0xf9, 0x18,
+	0x7e, 0x21, 0xdf, 0xa4, 0xa5, 0x21, 0xba, 0x07, 0xcb, 0xaf, 0x6d, 0x7e, 0x92, 0xd2, 0x67, 0x45,
+	0x79, 0x23, 0x56, 0x58, 0x9a, 0x0a, 0x09, 0xdc, 0x70, 0x02, 0x97, 0x69, 0x7d, 0xf8, 0x4d, 0x4f,
+	0x60, 0x71, 0xd3, 0x75, 0x33, 0xb2, 0x94, 0x90, 0x59, 0x68, 0xba, 0x8c, 0x47, 0xf5, 0xd6, 0x65,
+	0xbc, 0xdc, 0x5e, 0x29, 0x54, 0xe6, 0x16, 0xbc, 0xf2, 0x69, 0x0b, 0xbf, 0xa5, 0x01, 0x5e, 0x18,
+	0x0e, 0xe3, 0x14, 0xa9, 0x21, 0xfa, 0x04, 0x3a, 0x79, 0x65, 0x3a, 0x23, 0xc9, 0x33, 0xf2, 0x8e,
+	0xa2, 0x54, 0xd1, 0xb6, 0x34, 0x44, 0x9f, 0xc1, 0x43, 0xb5, 0xb9, 0xac, 0xd3, 0x6e, 0x8d, 0xb6,
+	0xf1, 0x0c, 0xc7, 0x1c, 0x31, 0xfd, 0x33, 0x3c, 0xba, 0x9a, 0x5d, 0xab, 0x5f, 0x82, 0xf6, 0x7b,
+	0xcf, 0xb7, 0xfb, 0xde, 0x25, 0x8b, 0x3a, 0x90, 0x04, 0x21, 0xaf, 0x7f, 0xa0, 0x3a, 0x08, 0xbd,
+	0xf5, 0x08, 0xa4, 0x2b, 0x30, 0x8d, 0xae, 0x9c, 0x8e, 0xcd, 0x74, 0x0b, 0xf3, 0x0a, 0x68, 0x54,
+	0xc2, 0x91, 0xae, 0x3c, 0xf4, 0x72, 0x5c, 0x72, 0x37, 0xb6, 0xe3, 0x88, 0xf8, 0xa4, 0x35, 0x44,
+	0x7b, 0x70, 0xa7, 0xc7, 0x54, 0xec, 0xbc, 0x08, 0x78, 0x26, 0xed, 0x25, 0x2c, 0xf5, 0x34, 0x4b,
+	0x45, 0xb6, 0xfb, 0x47, 0x1d, 0x8c, 0x1e, 0x13, 0x3f, 0x59, 0x57, 0x21, 0x8b, 0x27, 0x67, 0x1f,
+	0x86, 0x1e, 0x67, 0x6f, 0xbb, 0x52, 0xeb, 0x65, 0x88, 0x9e, 0x31, 0x69, 0xe5, 0xd1, 0xf4, 0xef,
+	0x75, 0x98, 0xc9, 0xb5, 0x1e, 0x9f, 0x45, 0xad, 0x81, 0xca, 0xc1, 0xcb, 0x32, 0x01, 0x5c, 0xd1,
+	0x75, 0x20, 0xed, 0xff, 0xbf, 0xeb, 0x78, 0x05, 0xf7, 0x37, 0x5d, 0xb7, 0xac, 0x93, 0x8c, 0x4f,
+	0xee, 0x93, 0xac, 0xa1, 0x57, 0x49, 0x7b, 0x04, 0xb3, 0xb9, 0xde, 0x15, 0x8f, 0xcd, 0x73, 0xa3,
+	0x0c, 0x23, 0x3f, 0x29, 0x2d, 0x50, 0x75, 0x0b, 0x2e, 0x76, 0x09, 0x86, 0x72, 0xf1, 0x92, 0x18,
+	0xae, 0x4a, 0x04, 0x1d, 0x98, 0xe0, 0xaa, 0xf1, 0xd0, 0x0e, 0xa6, 0x20, 0x19, 0xcb, 0xae, 0x6c,
+	0x59, 0xd4, 0xcd, 0xe1, 0xb7, 0xcc, 0xf7, 0x3c, 0xea, 0x25, 0x6e, 0x60, 0x8c, 0xc7, 0x30, 0xfd,
+	0x25, 0x2c, 0xec, 0x5c, 0x08, 0xc6, 0x7d, 0xbb, 0xbf, 0xa9, 0xf2, 0xf5, 0x1e, 0x1b, 0x29, 0xaf,
+	0x3b, 0x91, 0x1f, 0x5a, 0xad, 0x02, 0xe8, 0xbf, 0xea, 0x40, 0x8a, 0xe4, 0xe5, 0xc4, 0xd2, 0xbb,
+	0x8e, 0x4f, 0x6d, 0x67, 0x8f, 0x8d, 0xd0, 0xc6, 0x69, 0x2b, 0x02, 0x65, 0xac, 0x3a, 0x9c, 0xd9,
+	0x82, 0xb9, 0x9b, 0x42, 0x5b, 0x9a, 0x20, 0xe4, 0x2a, 0x67, 0xc2, 0xe3, 0xb8, 0xaa, 0xb2, 0x4f,
+	0x82, 0x28, 0xf1, 0xed, 0x56, 0x99, 0x6f, 0x4b, 0x2f, 0x5c, 0xd8, 0x74, 0xdd, 0xad, 0x7e, 0xe0,
+	0x9c, 0x30, 0x77, 0x8f, 0x8d, 0x52, 0x95, 0xe0, 0x84, 0x8d, 0x5e, 0xda, 0xe1, 0xb1, 0xce, 0x8c,
+	0x11, 0x28, 0xb7, 0x61, 0xbb, 0xae, 0x6e, 0x2d, 0x9b, 0x96, 0x02, 0xf0, 0x06, 0x82, 0x21, 0x77,
+	0x58, 0xdc, 0x4d, 0x22, 0x24, 0xe5, 0x38, 0xc1, 0xa9, 0xf4, 0x4d, 0x34, 0xb2, 0x6d, 0x45, 0xa0,
+	0xda, 0x00, 0xa6, 0xf3, 0xad, 0x91, 0xb6, 0x2e, 0x41, 0x50, 0x0f, 0xe6, 0x36, 0x5d, 0x77, 0x4f,
+	0xe9, 0x1c, 0x6f, 0x54, 0xe2, 0x00, 0x8d, 0x8c, 0x03, 0x50, 0x98, 0x76, 0x18, 0x17, 0xfb, 0x51,
+	0x13, 0xa8, 0x8e, 0x31, 0x83, 0xa3, 0xbf, 0x82, 0xb9, 0x3d, 0x36, 0xd2, 0x47, 0x30, 0x56, 0x15,
+	0x3d, 0x86, 0x4e, 0x8f, 0x09, 0xd5, 0x1c, 0x86, 0x5b, 0xa3, 0x8f, 0x3a, 0xb3, 0x68, 0x02, 0x52,
+	0x46, 0x34, 0x52, 0x13, 0x10, 0x62, 0xe4, 0x99, 0xf6, 0xbd, 0x53, 0x2f, 0xba, 0x66, 0x05, 0xd0,
+	0x87, 0x70, 0x53, 0xab, 0x91, 0xa2, 0xd5, 0x8e, 0xe2, 0xc2, 0xac, 0x41, 0xca, 0xc1, 0x8c, 0x72,
+	0x65, 0xf8, 0x22, 0xe0, 0xda, 0xdf, 0xae, 0x9b, 0xe3, 0x3a, 0x30, 0xf1, 0x8e, 0xbd, 0x0f, 0x78,
+	0x54, 0x33, 0x35, 0x54, 0x61, 0xd8, 0x12, 0x4c, 0xa2, 0xc2, 0x5c, 0x30, 0x37, 0x55, 0x30, 0x5f,
+	0xc0, 0x6a, 0x8f, 0x65, 0x5a, 0xd7, 0x9f, 0xca, 0xae, 0x0f, 0x40, 0xb4, 0x9e, 0x94, 0xf6, 0x42,
+	0xd5, 0xf9, 0x0c, 0xa6, 0x9c, 0x64, 0x59, 0x67, 0xc4, 0x39, 0x95, 0xc3, 0x52, 0x7c, 0x56, 0x9a,
+	0xaa, 0x6a, 0x5a, 0xa2, 0xdf, 0xc0, 0x7c, 0x51, 0x65, 0x48, 0x7e, 0xab, 0xfc, 0x2e, 0x82, 0x75,
+	0xa2, 0xec, 0x60, 0x46, 0x2f, 0x90, 0x5b, 0x19, 0xda, 0xee, 0xbf, 0x0d, 0x98, 0x3d, 0x14, 0x01,
+	0xb7, 0x8f, 0xa2, 0x6a, 0x2e, 0x46, 0x64, 0x03, 0x6e, 0xf7, 0x58, 0xa6, 0x57, 0x26, 0x44, 0x4a,
+	0xcb, 0x3e, 0x2d, 0x98, 0x44, 0x6d, 0x23, 0x8d, 0xa5, 0x35, 0xf2, 0x25, 0x2c, 0xe4, 0x98, 0xd1,
+	0x6f, 0xc9, 0x8c, 0x94, 0x90, 0x3c, 0x3d, 0x54, 0x70, 0x7f, 0x05, 0xb3, 0xf9, 0x1a, 0x4a, 0xe6,
+	0x0b, 0xb5, 0x69, 0x77, 0xdb, 0x2c, 0xab, 0x03, 0xb4, 0x46, 0xbe, 0xc5, 0x6a, 0x5e, 0x56, 0x50,
+	0x08, 0x4e, 0xd7, 0x57, 0xbf, 0x5b, 0x54, 0x49, 0x7d, 0x0b, 0x9d, 0xa8, 0xe3, 0xc8, 0x95, 0xd1,
+	0x07, 0x5a, 0x68, 0xf5, 0x83, 0x82, 0x79, 0xa7, 0x62, 0xaa, 0xa7, 0x35, 0xf2, 0x6b, 0x98, 0xc9,
+	0x7a, 0x2f, 0x01, 0x49, 0xac, 0x02, 0xd1, 0x2c, 0xba, 0x09, 0xad, 0x91, 0x0d, 0x3c, 0xde, 0xe2,
+	0xa4, 0x9e, 0x66, 0x5c, 0x94, 0xdf, 0x05, 0x12, 0x5a, 0x23, 0x87, 0x60, 0x54, 0x8d, 0x7a, 0xe4,
+	0x61, 0x3c, 0x85, 0x55, 0x0f, 0x82, 0xe6, 0x6c, 0x7e, 0x54, 0xa3, 0x35, 0xf2, 0x03, 0x2c, 0x97,
+	0xb0, 0xed, 0x5c, 0xd8, 0x8e, 0xf8, 0x91, 0x92, 0x5f, 0x42, 0xa7, 0x7c, 0x6a, 0x53, 0xc7, 0x7e,
+	0xe5, 0x44, 0x67, 0xb6, 0x63, 0x12, 0x5a, 0x23, 0xaf, 0xe1, 0x5e, 0x05, 0x35, 0x8e, 0xaf, 0xd7,
+	0x15, 0xf7, 0x0c, 0x4c, 0xfc, 0x2c, 0x6d, 0x5c, 0x4a, 0x63, 0x25, 0xc3, 0xde, 0x85, 0xa9, 0xd4,
+	0xc0, 0x46, 0x3a, 0xf1, 0x5a, 0x66, 0x82, 0xcb, 0xf2, 0x1c, 0x80, 0x59, 0x3d, 0x6e, 0x92, 0x9f,
+	0xc5, 0xa4, 0x57, 0x8d, 0xa3, 0x59, 0x89, 0x9f, 0xc3, 0xad, 0xcc, 0x84, 0x47, 0x8c, 0x78, 0x35,
+	0x37, 0xf4, 0x65, 0xf9, 0xbe, 0x80, 0x5b, 0x99, 0x79, 0x4e, 0xf1, 0x95, 0x8d, 0x78, 0x26, 0x3a,
+	0xa5, 0x42, 0xd1, 0x1a, 0x79, 0x03, 0x77, 0x2b, 0xc7, 0x3a, 0xf2, 0x48, 0x92, 0x8e, 0x9b, 0xfa,
+	0x72, 0x02, 0x9f, 0x42, 0x5b, 0x27, 0x8b, 0xcb, 0x2e, 0x59, 0x28, 0xc9, 0x12, 0xdd, 0xaa, 0x80,
+	0xde, 0x83, 0xc5, 0x1e, 0x13, 0x25, 0x7d, 0x93, 0xa1, 0x14, 0x14, 0xdb, 0x2f, 0xb3, 0x53, 0xbe,
+	0x82, 0x51, 0x0c, 0x49, 0x4d, 0x27, 0x18, 0x7c, 0x85, 0x1a, 0x9f, 0xb3, 0xfc, 0x4b, 0xcc, 0xb0,
+	0xe9, 0xba, 0x4e, 0x4c, 0x9d, 0x49, 0x4a, 0x8a, 0xbd, 0x39, 0x95, 0x04, 0xb7, 0xe4, 0xde, 0x81,
+	0xf9, 0x92, 0x32, 0x4c, 0x56, 0xb4, 0x84, 0x8a, 0xfa, 0x6c, 0x4e, 0xcb, 0xf5, 0xa8, 0x96, 0xd2,
+	0x1a, 0xf9, 0x13, 0xdc, 0xad, 0xac, 0x9d, 0xea, 0x3e, 0xc6, 0x95, 0x56, 0x95, 0xdb, 0x4a, 0x6a,
+	0x12, 0x26, 0xaa, 0xdb, 0xfb, 0xec, 0x3c, 0x57, 0x44, 0x0a, 0x29, 0xbf, 0xa2, 0x0c, 0x7c, 0x01,
+	0x44, 0x3d, 0xfc, 0x8d, 0xe5, 0x9f, 0x52, 0xb8, 0x9d, 0xd3, 0x81, 0x18, 0xe1, 0xd1, 0xdc, 0xd9,
+	0x67, 0xe7, 0xa5, 0xf9, 0xbf, 0xcc, 0x15, 0xaa, 0xfc, 0x63, 0x03, 0x16, 0x5f, 0xe8, 0x49, 0xf5,
+	0x23, 0x84, 0xe4, 0x6c, 0xf8, 0x1a, 0x3a, 0xe5, 0x4f, 0x09, 0x2a, 0xcf, 0x5c, 0xf9, 0xcc, 0x90,
+	0x97, 0xb5, 0x0b, 0x33, 0xd9, 0xe1, 0x9e, 0xdc, 0xc5, 0x23, 0x2f, 0x7b, 0x5d, 0x30, 0xcd, 0xb2,
+	0x25, 0x3d, 0x55, 0xd4, 0x48, 0x08, 0x4b, 0x57, 0x8d, 0xed, 0xe4, 0xe7, 0x2a, 0x6d, 0x8d, 0x7d,
+	0x17, 0x30, 0xd7, 0xc6, 0x13, 0xc6, 0x4a, 0x37, 0xa0, 0xb3, 0xcd, 0x6c, 0x47, 0x78, 0x67, 0xc5,
+	0xcb, 0x2c, 0x66, 0xc9, 0xdc, 0xe6, 0x9f, 0xc1, 0x9d, 0x84, 0xf9, 0x23, 0x7a, 0x82, 0x1c, 0xfb,
+	0x63, 0x98, 0xdc, 0x67, 0xe7, 0xe8, 0xf0, 0x44, 0x2f, 0x21, 0x60, 0xa6, 0x01, 0x5a, 0x23, 0x4f,
+	0x80, 0x1c, 0xea, 0xa8, 0x39, 0xe0, 0x81, 0xc3, 0xc2, 0xd0, 0xf3, 0x8f, 0x4a, 0x39, 0x22, 0xc9,
+	0xbf, 0x80, 0x5b, 0x11, 0xc7, 0x0e, 0xe7, 0x01, 0x1f, 0x47, 0x1c, 0xf9, 0x52, 0xb5, 0x2d, 0x09,
+	0xf1, 0x64, 0x14, 0xc1, 0x64, 0x36, 0x8e, 0xd7, 0x9c, 0x73, 0x44, 0x86, 0xff, 0x11, 0xee, 0x5d,
+	0xf1, 0x10, 0x42, 0x1e, 0xa7, 0x7b, 0x93, 0xea, 0x97, 0x12, 0x93, 0x14, 0x67, 0xff, 0xb8, 0x13,
+	0xcb, 0xbc, 0x8b, 0x90, 0x7b, 0xe9, 0x0c, 0x93, 0x7b, 0x2d, 0xc9, 0x1b, 0xd7, 0x83, 0xb9, 0xc2,
+	0x6b, 0x08, 0x59, 0xd2, 0x02, 0xae, 0x63, 0xc8, 0xf7, 0x60, 0x54, 0xbd, 0x11, 0xa8, 0xd6, 0x62,
+	0xcc, 0x0b, 0x82, 0x59, 0x56, 0x19, 0xa4, 0xe0, 0xdf, 0xc3, 0x5c, 0x61, 0xc8, 0x57, 0x16, 0x56,
+	0xcd, 0xfe, 0xf9, 0xdb, 0xfa, 0x0a, 0xdf, 0xf9, 0x4a, 0xca, 0x48, 0x45, 0xb1, 0xc8, 0xf3, 0x3f,
+	0x97, 0xcf, 0x0c, 0xc2, 0xe3, 0xec, 0x5a, 0x95, 0x28, 0x27, 0xe4, 0x29, 0xdc, 0xca, 0x4c, 0xd5,
+	0x8a, 0xb3, 0x6c, 0xd0, 0xce, 0x73, 0x76, 0x01, 0x92, 0xb9, 0x57, 0x15, 0xae, 0xc2, 0x1c, 0x9c,
+	0xe3, 0xd9, 0xba, 0xf9, 0x87, 0x16, 0xfe, 0x6a, 0xfc, 0x1f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x03,
+	0x00, 0x54, 0x05, 0x77, 0xd3, 0x99, 0x1c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -1923,6 +2575,11 @@ type StorageAuthorityClient interface {
 	FQDNSetExists(ctx context.Context, in *FQDNSetExistsRequest, opts ...grpc.CallOption) (*Exists, error)
 	PreviousCertificateExists(ctx context.Context, in *PreviousCertificateExistsRequest, opts ...grpc.CallOption) (*Exists, error)
 	GetAuthz2(ctx context.Context, in *AuthorizationID2, opts ...grpc.CallOption) (*proto1.Authorization, error)
+	GetExternalAccountKey(ctx context.Context, in *ExternalAccountKeyID, opts ...grpc.CallOption) (*ExternalAccountKey, error)
+	KeyBlocked(ctx context.Context, in *KeyBlockedRequest, opts ...grpc.CallOption) (*Exists, error)
+	GetSerialsByKey(ctx context.Context, in *GetSerialsByKeyRequest, opts ...grpc.CallOption) (*Serials, error)
+	GetOrdersForAccount(ctx context.Context, in *GetOrdersForAccountRequest, opts ...grpc.CallOption) (*OrderIDs, error)
+	GetCertificatesForAccount(ctx context.Context, in *GetCertificatesForAccountRequest, opts ...grpc.CallOption) (*AccountCertificates, error)
 	// Adders
 	NewRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Registration, error)
 	UpdateRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Empty, error)
@@ -1943,6 +2600,10 @@ type StorageAuthorityClient interface {
 	GetAuthorizations(ctx context.Context, in *GetAuthorizationsRequest, opts ...grpc.CallOption) (*Authorizations, error)
 	AddPendingAuthorizations(ctx context.Context, in *AddPendingAuthorizationsRequest, opts ...grpc.CallOption) (*AuthorizationIDs, error)
 	RevokeCertificate(ctx context.Context, in *RevokeCertificateRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	AddExternalAccountKey(ctx context.Context, in *ExternalAccountKey, opts ...grpc.CallOption) (*proto1.Empty, error)
+	RetireExternalAccountKey(ctx context.Context, in *ExternalAccountKeyID, opts ...grpc.CallOption) (*proto1.Empty, error)
+	AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
+	AddKeyHash(ctx context.Context, in *AddKeyHashRequest, opts ...grpc.CallOption) (*proto1.Empty, error)
 }
 
 type storageAuthorityClient struct {
@@ -2115,6 +2776,51 @@ func (c *storageAuthorityClient) GetAuthz2(ctx context.Context, in *Authorizatio
 	return out, nil
 }
 
+func (c *storageAuthorityClient) GetExternalAccountKey(ctx context.Context, in *ExternalAccountKeyID, opts ...grpc.CallOption) (*ExternalAccountKey, error) {
+	out := new(ExternalAccountKey)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetExternalAccountKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) KeyBlocked(ctx context.Context, in *KeyBlockedRequest, opts ...grpc.CallOption) (*Exists, error) {
+	out := new(Exists)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/KeyBlocked", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetSerialsByKey(ctx context.Context, in *GetSerialsByKeyRequest, opts ...grpc.CallOption) (*Serials, error) {
+	out := new(Serials)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetSerialsByKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetOrdersForAccount(ctx context.Context, in *GetOrdersForAccountRequest, opts ...grpc.CallOption) (*OrderIDs, error) {
+	out := new(OrderIDs)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetOrdersForAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) GetCertificatesForAccount(ctx context.Context, in *GetCertificatesForAccountRequest, opts ...grpc.CallOption) (*AccountCertificates, error) {
+	out := new(AccountCertificates)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/GetCertificatesForAccount", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *storageAuthorityClient) NewRegistration(ctx context.Context, in *proto1.Registration, opts ...grpc.CallOption) (*proto1.Registration, error) {
 	out := new(proto1.Registration)
 	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/NewRegistration", in, out, opts...)
@@ -2286,6 +2992,42 @@ func (c *storageAuthorityClient) RevokeCertificate(ctx context.Context, in *Revo
 	return out, nil
 }
 
+func (c *storageAuthorityClient) AddExternalAccountKey(ctx context.Context, in *ExternalAccountKey, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddExternalAccountKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) RetireExternalAccountKey(ctx context.Context, in *ExternalAccountKeyID, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/RetireExternalAccountKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddBlockedKey(ctx context.Context, in *AddBlockedKeyRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddBlockedKey", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *storageAuthorityClient) AddKeyHash(ctx context.Context, in *AddKeyHashRequest, opts ...grpc.CallOption) (*proto1.Empty, error) {
+	out := new(proto1.Empty)
+	err := c.cc.Invoke(ctx, "/sa.StorageAuthority/AddKeyHash", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // StorageAuthorityServer is the server API for StorageAuthority service.
 type StorageAuthorityServer interface {
 	// Getters
@@ -2309,6 +3051,11 @@ type StorageAuthorityServer interface {
 	FQDNSetExists(context.Context, *FQDNSetExistsRequest) (*Exists, error)
 	PreviousCertificateExists(context.Context, *PreviousCertificateExistsRequest) (*Exists, error)
 	GetAuthz2(context.Context, *AuthorizationID2) (*proto1.Authorization, error)
+	GetExternalAccountKey(context.Context, *ExternalAccountKeyID) (*ExternalAccountKey, error)
+	KeyBlocked(context.Context, *KeyBlockedRequest) (*Exists, error)
+	GetSerialsByKey(context.Context, *GetSerialsByKeyRequest) (*Serials, error)
+	GetOrdersForAccount(context.Context, *GetOrdersForAccountRequest) (*OrderIDs, error)
+	GetCertificatesForAccount(context.Context, *GetCertificatesForAccountRequest) (*AccountCertificates, error)
 	// Adders
 	NewRegistration(context.Context, *proto1.Registration) (*proto1.Registration, error)
 	UpdateRegistration(context.Context, *proto1.Registration) (*proto1.Empty, error)
@@ -2329,6 +3076,10 @@ type StorageAuthorityServer interface {
 	GetAuthorizations(context.Context, *GetAuthorizationsRequest) (*Authorizations, error)
 	AddPendingAuthorizations(context.Context, *AddPendingAuthorizationsRequest) (*AuthorizationIDs, error)
 	RevokeCertificate(context.Context, *RevokeCertificateRequest) (*proto1.Empty, error)
+	AddExternalAccountKey(context.Context, *ExternalAccountKey) (*proto1.Empty, error)
+	RetireExternalAccountKey(context.Context, *ExternalAccountKeyID) (*proto1.Empty, error)
+	AddBlockedKey(context.Context, *AddBlockedKeyRequest) (*proto1.Empty, error)
+	AddKeyHash(context.Context, *AddKeyHashRequest) (*proto1.Empty, error)
 }
 
 // UnimplementedStorageAuthorityServer can be embedded to have forward compatible implementations.
@@ -2389,6 +3140,21 @@ func (*UnimplementedStorageAuthorityServer) PreviousCertificateExists(ctx contex
 func (*UnimplementedStorageAuthorityServer) GetAuthz2(ctx context.Context, req *AuthorizationID2) (*proto1.Authorization, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetAuthz2 not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) GetExternalAccountKey(ctx context.Context, req *ExternalAccountKeyID) (*ExternalAccountKey, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetExternalAccountKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) KeyBlocked(ctx context.Context, req *KeyBlockedRequest) (*Exists, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeyBlocked not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetSerialsByKey(ctx context.Context, req *GetSerialsByKeyRequest) (*Serials, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSerialsByKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetOrdersForAccount(ctx context.Context, req *GetOrdersForAccountRequest) (*OrderIDs, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetOrdersForAccount not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) GetCertificatesForAccount(ctx context.Context, req *GetCertificatesForAccountRequest) (*AccountCertificates, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCertificatesForAccount not implemented")
+}
 func (*UnimplementedStorageAuthorityServer) NewRegistration(ctx context.Context, req *proto1.Registration) (*proto1.Registration, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method NewRegistration not implemented")
 }
@@ -2446,6 +3212,18 @@ func (*UnimplementedStorageAuthorityServer) AddPendingAuthorizations(ctx context
 func (*UnimplementedStorageAuthorityServer) RevokeCertificate(ctx context.Context, req *RevokeCertificateRequest) (*proto1.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method RevokeCertificate not implemented")
 }
+func (*UnimplementedStorageAuthorityServer) AddExternalAccountKey(ctx context.Context, req *ExternalAccountKey) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddExternalAccountKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) RetireExternalAccountKey(ctx context.Context, req *ExternalAccountKeyID) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RetireExternalAccountKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) AddBlockedKey(ctx context.Context, req *AddBlockedKeyRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddBlockedKey not implemented")
+}
+func (*UnimplementedStorageAuthorityServer) AddKeyHash(ctx context.Context, req *AddKeyHashRequest) (*proto1.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddKeyHash not implemented")
+}
 
 func RegisterStorageAuthorityServer(s *grpc.Server, srv StorageAuthorityServer) {
 	s.RegisterService(&_StorageAuthority_serviceDesc, srv)
@@ -2775,6 +3553,96 @@ func _StorageAuthority_GetAuthz2_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_GetExternalAccountKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalAccountKeyID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetExternalAccountKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetExternalAccountKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetExternalAccountKey(ctx, req.(*ExternalAccountKeyID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_KeyBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/KeyBlocked",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).KeyBlocked(ctx, req.(*KeyBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetSerialsByKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSerialsByKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetSerialsByKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetSerialsByKey(ctx, req.(*GetSerialsByKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetOrdersForAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetOrdersForAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetOrdersForAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetOrdersForAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetOrdersForAccount(ctx, req.(*GetOrdersForAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_GetCertificatesForAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCertificatesForAccountRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).GetCertificatesForAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/GetCertificatesForAccount",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).GetCertificatesForAccount(ctx, req.(*GetCertificatesForAccountRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _StorageAuthority_NewRegistration_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(proto1.Registration)
 	if err := dec(in); err != nil {
@@ -3117,6 +3985,78 @@ func _StorageAuthority_RevokeCertificate_Handler(srv interface{}, ctx context.Co
 	return interceptor(ctx, in, info, handler)
 }
 
+func _StorageAuthority_AddExternalAccountKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalAccountKey)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddExternalAccountKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddExternalAccountKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddExternalAccountKey(ctx, req.(*ExternalAccountKey))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_RetireExternalAccountKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExternalAccountKeyID)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).RetireExternalAccountKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/RetireExternalAccountKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).RetireExternalAccountKey(ctx, req.(*ExternalAccountKeyID))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddBlockedKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddBlockedKeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddBlockedKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddBlockedKey",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddBlockedKey(ctx, req.(*AddBlockedKeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _StorageAuthority_AddKeyHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddKeyHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(StorageAuthorityServer).AddKeyHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/sa.StorageAuthority/AddKeyHash",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(StorageAuthorityServer).AddKeyHash(ctx, req.(*AddKeyHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "sa.StorageAuthority",
 	HandlerType: (*StorageAuthorityServer)(nil),
@@ -3193,6 +4133,26 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "GetAuthz2",
 			Handler:    _StorageAuthority_GetAuthz2_Handler,
 		},
+		{
+			MethodName: "GetExternalAccountKey",
+			Handler:    _StorageAuthority_GetExternalAccountKey_Handler,
+		},
+		{
+			MethodName: "KeyBlocked",
+			Handler:    _StorageAuthority_KeyBlocked_Handler,
+		},
+		{
+			MethodName: "GetSerialsByKey",
+			Handler:    _StorageAuthority_GetSerialsByKey_Handler,
+		},
+		{
+			MethodName: "GetOrdersForAccount",
+			Handler:    _StorageAuthority_GetOrdersForAccount_Handler,
+		},
+		{
+			MethodName: "GetCertificatesForAccount",
+			Handler:    _StorageAuthority_GetCertificatesForAccount_Handler,
+		},
 		{
 			MethodName: "NewRegistration",
 			Handler:    _StorageAuthority_NewRegistration_Handler,
@@ -3269,6 +4229,22 @@ var _StorageAuthority_serviceDesc = grpc.ServiceDesc{
 			MethodName: "RevokeCertificate",
 			Handler:    _StorageAuthority_RevokeCertificate_Handler,
 		},
+		{
+			MethodName: "AddExternalAccountKey",
+			Handler:    _StorageAuthority_AddExternalAccountKey_Handler,
+		},
+		{
+			MethodName: "RetireExternalAccountKey",
+			Handler:    _StorageAuthority_RetireExternalAccountKey_Handler,
+		},
+		{
+			MethodName: "AddBlockedKey",
+			Handler:    _StorageAuthority_AddBlockedKey_Handler,
+		},
+		{
+			MethodName: "AddKeyHash",
+			Handler:    _StorageAuthority_AddKeyHash_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "sa/proto/sa.proto",
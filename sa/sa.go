@@ -20,6 +20,7 @@ import (
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/features"
 	bgrpc "github.com/letsencrypt/boulder/grpc"
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
@@ -571,6 +572,9 @@ func (ssa *SQLStorageAuthority) NewRegistration(ctx context.Context, reg core.Re
 	if err != nil {
 		return reg, err
 	}
+	if reg.ExternalAccountKeyID != "" {
+		return ssa.newBoundRegistration(ctx, rm, reg.ExternalAccountKeyID)
+	}
 	err = ssa.dbMap.WithContext(ctx).Insert(rm)
 	if err != nil {
 		return reg, err
@@ -578,6 +582,51 @@ func (ssa *SQLStorageAuthority) NewRegistration(ctx context.Context, reg core.Re
 	return modelToRegistration(rm)
 }
 
+// newBoundRegistration stores a new registration and binds the external
+// account key with the given ID to it in a single transaction, so that each
+// key can only ever be used to create one registration. If the key doesn't
+// exist, is retired, or is already bound a berrors.Unauthorized type error is
+// returned and the registration is not stored.
+func (ssa *SQLStorageAuthority) newBoundRegistration(ctx context.Context, rm *regModel, keyID string) (core.Registration, error) {
+	tx, err := ssa.dbMap.Begin()
+	if err != nil {
+		return core.Registration{}, err
+	}
+	txWithCtx := tx.WithContext(ctx)
+
+	err = txWithCtx.Insert(rm)
+	if err != nil {
+		return core.Registration{}, Rollback(tx, err)
+	}
+
+	result, err := txWithCtx.Exec(`
+		UPDATE externalAccountKeys
+		SET registrationID = ?
+		WHERE keyID = ?
+		AND registrationID IS NULL
+		AND retiredAt IS NULL`,
+		rm.ID,
+		keyID)
+	if err != nil {
+		return core.Registration{}, Rollback(tx, err)
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return core.Registration{}, Rollback(tx, err)
+	}
+	if n == 0 {
+		err = berrors.UnauthorizedError(
+			"external account key %q does not exist, is retired, or has already been used", keyID)
+		return core.Registration{}, Rollback(tx, err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return core.Registration{}, err
+	}
+	return modelToRegistration(rm)
+}
+
 // MarkCertificateRevoked stores the fact that a certificate is revoked, along
 // with a timestamp and a reason.
 // TODO(#4048): This method has been deprecated and replaced by RevokeCertificate.
@@ -909,10 +958,12 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 	// ignore the Subject Common Name (if any). This is a safe assumption because
 	// if a certificate we issued were to have a Subj. CN not present as a SAN it
 	// would be a misissuance and miscalculating whether the cert is a renewal or
-	// not for the purpose of rate limiting is the least of our troubles.
+	// not for the purpose of rate limiting is the least of our troubles. IP
+	// address SANs are included in the same textual form the RA uses for them.
+	names := core.IdentifierNames(parsedCertificate.DNSNames, parsedCertificate.IPAddresses)
 	isRenewal, err := ssa.checkFQDNSetExists(
 		txWithCtx.SelectOne,
-		parsedCertificate.DNSNames)
+		names)
 	if err != nil {
 		return "", Rollback(tx, err)
 	}
@@ -924,7 +975,7 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 
 	err = addFQDNSet(
 		txWithCtx,
-		parsedCertificate.DNSNames,
+		names,
 		serial,
 		parsedCertificate.NotBefore,
 		parsedCertificate.NotAfter,
@@ -933,6 +984,13 @@ func (ssa *SQLStorageAuthority) AddCertificate(
 		return "", Rollback(tx, err)
 	}
 
+	if features.Enabled(features.StoreKeyHashes) {
+		err = addKeyHash(txWithCtx, parsedCertificate)
+		if err != nil {
+			return "", Rollback(tx, err)
+		}
+	}
+
 	return digest, tx.Commit()
 }
 
@@ -977,10 +1035,7 @@ func (ssa *SQLStorageAuthority) CountInvalidAuthorizations(
 	ctx context.Context,
 	req *sapb.CountInvalidAuthorizationsRequest,
 ) (count *sapb.Count, err error) {
-	identifier := core.AcmeIdentifier{
-		Type:  core.IdentifierDNS,
-		Value: *req.Hostname,
-	}
+	identifier := core.IdentifierForName(*req.Hostname)
 
 	idJSON, err := json.Marshal(identifier)
 	if err != nil {
@@ -1071,7 +1126,7 @@ func deleteOrderFQDNSet(
 func addIssuedNames(db dbExecer, cert *x509.Certificate, isRenewal bool) error {
 	var qmarks []string
 	var values []interface{}
-	for _, name := range cert.DNSNames {
+	for _, name := range core.IdentifierNames(cert.DNSNames, cert.IPAddresses) {
 		values = append(values,
 			ReverseName(name),
 			core.SerialToString(cert.SerialNumber),
@@ -1377,8 +1432,19 @@ func (ssa *SQLStorageAuthority) NewOrder(ctx context.Context, req *corepb.Order)
 	}
 	txWithCtx := tx.WithContext(ctx)
 
-	if err := txWithCtx.Insert(order); err != nil {
-		return nil, Rollback(tx, err)
+	if features.Enabled(features.CertificateProfiles) {
+		orderv2 := &orderModelv2{
+			orderModel:             *order,
+			CertificateProfileName: req.GetCertificateProfileName(),
+		}
+		if err := txWithCtx.Insert(orderv2); err != nil {
+			return nil, Rollback(tx, err)
+		}
+		order = &orderv2.orderModel
+	} else {
+		if err := txWithCtx.Insert(order); err != nil {
+			return nil, Rollback(tx, err)
+		}
 	}
 
 	for _, id := range req.Authorizations {
@@ -1561,14 +1627,25 @@ func (ssa *SQLStorageAuthority) namesForOrder(ctx context.Context, orderID int64
 
 // GetOrder is used to retrieve an already existing order object
 func (ssa *SQLStorageAuthority) GetOrder(ctx context.Context, req *sapb.OrderRequest) (*corepb.Order, error) {
-	omObj, err := ssa.dbMap.WithContext(ctx).Get(orderModel{}, *req.Id)
+	var omObj interface{}
+	var err error
+	if features.Enabled(features.CertificateProfiles) {
+		omObj, err = ssa.dbMap.WithContext(ctx).Get(orderModelv2{}, *req.Id)
+	} else {
+		omObj, err = ssa.dbMap.WithContext(ctx).Get(orderModel{}, *req.Id)
+	}
 	if err == sql.ErrNoRows || omObj == nil {
 		return nil, berrors.NotFoundError("no order found for ID %d", *req.Id)
 	}
 	if err != nil {
 		return nil, err
 	}
-	order, err := modelToOrder(omObj.(*orderModel))
+	var order *corepb.Order
+	if om, ok := omObj.(*orderModelv2); ok {
+		order, err = modelv2ToOrder(om)
+	} else {
+		order, err = modelToOrder(omObj.(*orderModel))
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1759,8 +1836,8 @@ func (ssa *SQLStorageAuthority) getAllOrderAuthorizations(
 	// authorization
 	byName := make(map[string]*core.Authorization)
 	for _, auth := range allAuthzs {
-		// We only expect to get back DNS identifiers
-		if auth.Identifier.Type != core.IdentifierDNS {
+		// We only expect to get back DNS and IP identifiers
+		if auth.Identifier.Type != core.IdentifierDNS && auth.Identifier.Type != core.IdentifierIP {
 			return nil, fmt.Errorf("unknown identifier type: %q on authz id %q", auth.Identifier.Type, auth.ID)
 		}
 		// We don't expect there to be multiple authorizations for the same name
@@ -1806,8 +1883,8 @@ func (ssa *SQLStorageAuthority) GetValidOrderAuthorizations(
 	// authorization
 	byName := make(map[string]*core.Authorization)
 	for _, auth := range auths {
-		// We only expect to get back DNS identifiers
-		if auth.Identifier.Type != core.IdentifierDNS {
+		// We only expect to get back DNS and IP identifiers
+		if auth.Identifier.Type != core.IdentifierDNS && auth.Identifier.Type != core.IdentifierIP {
 			return nil, fmt.Errorf("unknown identifier type: %q on authz id %q", auth.Identifier.Type, auth.ID)
 		}
 		existing, present := byName[auth.Identifier.Value]
@@ -1881,8 +1958,7 @@ func (ssa *SQLStorageAuthority) getAuthorizations(
 	params := make([]interface{}, len(names))
 	qmarks := make([]string, len(names))
 	for i, name := range names {
-		id := core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name}
-		idJSON, err := json.Marshal(id)
+		idJSON, err := json.Marshal(core.IdentifierForName(name))
 		if err != nil {
 			return nil, err
 		}
@@ -1922,7 +1998,7 @@ func (ssa *SQLStorageAuthority) getAuthorizations(
 			continue
 		}
 
-		if auth.Identifier.Type != core.IdentifierDNS {
+		if auth.Identifier.Type != core.IdentifierDNS && auth.Identifier.Type != core.IdentifierIP {
 			return nil, fmt.Errorf("unknown identifier type: %q on authz id %q", auth.Identifier.Type, auth.ID)
 		}
 		existing, present := byName[auth.Identifier.Value]
@@ -2014,8 +2090,8 @@ func (ssa *SQLStorageAuthority) GetAuthorizations(
 
 	// Wildcard domain issuance requires that the authorizations returned by this
 	// RPC also include populated challenges such that the caller can know if the
-	// challenges meet the wildcard issuance policy (e.g. only 1 DNS-01
-	// challenge).
+	// challenges meet the wildcard issuance policy (e.g. only DNS-01 or
+	// DNS-ACCOUNT-01 challenges).
 	// Fetch each of the authorizations' associated challenges
 	for _, authz := range authzMap {
 		authz.Challenges, err = ssa.getChallenges(ssa.dbMap.WithContext(ctx), authz.ID)
@@ -2134,3 +2210,254 @@ func (ssa *SQLStorageAuthority) RevokeCertificate(ctx context.Context, req *sapb
 
 	return tx.Commit()
 }
+
+// GetExternalAccountKey returns the external account key with the provided ID
+// or an error. If no key is found matching the ID a berrors.NotFound type
+// error is returned.
+func (ssa *SQLStorageAuthority) GetExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) (*sapb.ExternalAccountKey, error) {
+	obj, err := ssa.dbMap.WithContext(ctx).Get(externalAccountKeyModel{}, *req.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if obj == nil {
+		return nil, berrors.NotFoundError("external account key %q not found", *req.KeyID)
+	}
+	return modelToExternalAccountKeyPB(obj.(*externalAccountKeyModel)), nil
+}
+
+// AddExternalAccountKey stores a new, unbound external account key. If a key
+// with the same ID already exists a berrors.Duplicate type error is returned.
+func (ssa *SQLStorageAuthority) AddExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKey) error {
+	err := ssa.dbMap.WithContext(ctx).Insert(&externalAccountKeyModel{
+		KeyID:     *req.KeyID,
+		HMACKey:   req.HmacKey,
+		CreatedAt: ssa.clk.Now(),
+	})
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
+			return berrors.DuplicateError("external account key %q already exists", *req.KeyID)
+		}
+		return err
+	}
+	return nil
+}
+
+// RetireExternalAccountKey marks the external account key with the provided
+// ID as retired, after which it can no longer be used to create registrations.
+// A registration that is already bound to the key is unaffected. If there is
+// no unretired key matching the ID a berrors.NotFound type error is returned.
+func (ssa *SQLStorageAuthority) RetireExternalAccountKey(ctx context.Context, req *sapb.ExternalAccountKeyID) error {
+	result, err := ssa.dbMap.WithContext(ctx).Exec(`
+		UPDATE externalAccountKeys
+		SET retiredAt = ?
+		WHERE keyID = ?
+		AND retiredAt IS NULL`,
+		ssa.clk.Now(),
+		*req.KeyID)
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return berrors.NotFoundError("no unretired external account key %q", *req.KeyID)
+	}
+	return nil
+}
+
+// addKeyHash records the hash of cert's public key so that it can be found
+// if the key is later blocked.
+func addKeyHash(db dbInserter, cert *x509.Certificate) error {
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	if err != nil {
+		return err
+	}
+	return insertKeyHash(db, keyHash, core.SerialToString(cert.SerialNumber), cert.NotAfter)
+}
+
+// insertKeyHash records that the certificate with the given serial uses the
+// key with the given hash. The CA records the hash before signing a
+// certificate's precertificate, so it is not an error if the hash is already
+// recorded for the serial.
+func insertKeyHash(db dbInserter, keyHash []byte, serial string, notAfter time.Time) error {
+	err := db.Insert(&keyHashModel{
+		KeyHash:      keyHash,
+		CertNotAfter: notAfter,
+		CertSerial:   serial,
+	})
+	if err != nil && strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
+		return nil
+	}
+	return err
+}
+
+// AddKeyHash records the hash of the public key of a certificate that is about
+// to be issued, before its precertificate is signed, so that it is found if the
+// key is later blocked even if the final certificate is never stored.
+func (ssa *SQLStorageAuthority) AddKeyHash(ctx context.Context, req *sapb.AddKeyHashRequest) error {
+	if len(req.KeyHash) != sha256.Size {
+		return berrors.MalformedError("key hash must be %d bytes", sha256.Size)
+	}
+	if req.GetSerial() == "" {
+		return berrors.MalformedError("serial must not be empty")
+	}
+	return insertKeyHash(ssa.dbMap.WithContext(ctx), req.KeyHash, *req.Serial, time.Unix(0, *req.CertNotAfter))
+}
+
+// AddBlockedKey records a public key, by the hash of its SubjectPublicKeyInfo,
+// as blocked. Blocking a key that is already blocked is not an error, and
+// leaves the existing record untouched.
+func (ssa *SQLStorageAuthority) AddBlockedKey(ctx context.Context, req *sapb.AddBlockedKeyRequest) error {
+	if len(req.KeyHash) != sha256.Size {
+		return berrors.MalformedError("key hash must be %d bytes", sha256.Size)
+	}
+	bk := &blockedKeyModel{
+		KeyHash:   req.KeyHash,
+		Added:     time.Unix(0, *req.Added),
+		Source:    *req.Source,
+		Comment:   req.Comment,
+		RevokedBy: req.GetRevokedBy(),
+	}
+	err := ssa.dbMap.WithContext(ctx).Insert(bk)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "Error 1062: Duplicate entry") {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// KeyBlocked checks whether the public key with the given SubjectPublicKeyInfo
+// hash has been blocked.
+func (ssa *SQLStorageAuthority) KeyBlocked(ctx context.Context, req *sapb.KeyBlockedRequest) (*sapb.Exists, error) {
+	var id int64
+	err := ssa.dbMap.WithContext(ctx).SelectOne(
+		&id,
+		"SELECT id FROM blockedKeys WHERE keyHash = ? LIMIT 1",
+		req.KeyHash,
+	)
+	exists := true
+	if err == sql.ErrNoRows {
+		exists = false
+	} else if err != nil {
+		return nil, err
+	}
+	return &sapb.Exists{Exists: &exists}, nil
+}
+
+// GetSerialsByKey returns the serials of up to req.Limit of the certificates
+// using the public key with the given SubjectPublicKeyInfo hash that are
+// unexpired as of req.ValidAfter, in serial order. It only finds certificates
+// that were added while the StoreKeyHashes feature was enabled, or whose key
+// hashes were recorded since by key-hash-backfill.
+func (ssa *SQLStorageAuthority) GetSerialsByKey(ctx context.Context, req *sapb.GetSerialsByKeyRequest) (*sapb.Serials, error) {
+	if req.GetLimit() <= 0 {
+		return nil, berrors.MalformedError("limit must be positive")
+	}
+	var serials []string
+	_, err := ssa.dbMap.WithContext(ctx).Select(
+		&serials,
+		`SELECT certSerial FROM keyHashToSerial
+		WHERE keyHash = ? AND certNotAfter > ?
+		ORDER BY certSerial ASC
+		LIMIT ?`,
+		req.KeyHash,
+		time.Unix(0, *req.ValidAfter),
+		*req.Limit,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &sapb.Serials{Serials: serials}, nil
+}
+
+// GetOrdersForAccount returns the IDs of up to req.Limit of a registration's
+// orders, newest first. If req.Before is non-zero only orders with lower IDs
+// are returned, so that callers can page through the list by passing the last
+// ID of the previous page.
+func (ssa *SQLStorageAuthority) GetOrdersForAccount(ctx context.Context, req *sapb.GetOrdersForAccountRequest) (*sapb.OrderIDs, error) {
+	if *req.Limit <= 0 {
+		return nil, berrors.MalformedError("limit must be positive")
+	}
+	query := "SELECT id FROM orders WHERE registrationID = :regID"
+	params := map[string]interface{}{
+		"regID": *req.RegistrationID,
+		"limit": *req.Limit,
+	}
+	if *req.Before > 0 {
+		query += " AND id < :before"
+		params["before"] = *req.Before
+	}
+	query += " ORDER BY id DESC LIMIT :limit"
+
+	var ids []int64
+	_, err := ssa.dbMap.WithContext(ctx).Select(&ids, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	return &sapb.OrderIDs{Ids: ids}, nil
+}
+
+// accountCertModel is a certificate issued to a registration along with its
+// current OCSP status.
+type accountCertModel struct {
+	ID             int64     `db:"id"`
+	RegistrationID int64     `db:"registrationID"`
+	Serial         string    `db:"serial"`
+	Digest         string    `db:"digest"`
+	DER            []byte    `db:"der"`
+	Issued         time.Time `db:"issued"`
+	Expires        time.Time `db:"expires"`
+	Status         string    `db:"status"`
+}
+
+// GetCertificatesForAccount returns up to req.Limit of the certificates issued
+// to a registration, newest first, with their OCSP status. It pages in the same
+// way as GetOrdersForAccount, using the certificates' row IDs.
+func (ssa *SQLStorageAuthority) GetCertificatesForAccount(ctx context.Context, req *sapb.GetCertificatesForAccountRequest) (*sapb.AccountCertificates, error) {
+	if *req.Limit <= 0 {
+		return nil, berrors.MalformedError("limit must be positive")
+	}
+	query := `SELECT
+		c.id, c.registrationID, c.serial, c.digest, c.der, c.issued, c.expires, cs.status
+		FROM certificates AS c
+		JOIN certificateStatus AS cs ON cs.serial = c.serial
+		WHERE c.registrationID = :regID`
+	params := map[string]interface{}{
+		"regID": *req.RegistrationID,
+		"limit": *req.Limit,
+	}
+	if *req.Before > 0 {
+		query += " AND c.id < :before"
+		params["before"] = *req.Before
+	}
+	query += " ORDER BY c.id DESC LIMIT :limit"
+
+	var models []accountCertModel
+	_, err := ssa.dbMap.WithContext(ctx).Select(&models, query, params)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	resp := &sapb.AccountCertificates{}
+	for _, m := range models {
+		m := m
+		issued := m.Issued.UnixNano()
+		expires := m.Expires.UnixNano()
+		resp.Certificates = append(resp.Certificates, &sapb.AccountCertificate{
+			Id: &m.ID,
+			Certificate: &corepb.Certificate{
+				RegistrationID: &m.RegistrationID,
+				Serial:         &m.Serial,
+				Digest:         &m.Digest,
+				Der:            m.DER,
+				Issued:         &issued,
+				Expires:        &expires,
+			},
+			Status: &m.Status,
+		})
+	}
+	return resp, nil
+}
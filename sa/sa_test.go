@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"crypto/rsa"
+	"crypto/sha256"
 	"crypto/x509"
 	"database/sql"
 	"encoding/json"
@@ -11,7 +12,9 @@ import (
 	"io/ioutil"
 	"math/big"
 	"net"
+	"os"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -2489,3 +2492,342 @@ func TestCountCertificatesRenewalBit(t *testing.T) {
 	// exact match.
 	test.AssertEquals(t, countNameExact(t, "not-example.com"), int64(1))
 }
+
+func TestExternalAccountKeys(t *testing.T) {
+	// The externalAccountKeys table is only created by the _db-next migrations.
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("externalAccountKeys table requires the config-next database")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	keyID := "some-key"
+	hmacKey := []byte("an hmac key")
+
+	_, err := sa.GetExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.AssertError(t, err, "GetExternalAccountKey found a key that hasn't been added")
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "Wrong error type for a missing key")
+
+	err = sa.AddExternalAccountKey(ctx, &sapb.ExternalAccountKey{KeyID: &keyID, HmacKey: hmacKey})
+	test.AssertNotError(t, err, "AddExternalAccountKey failed")
+	err = sa.AddExternalAccountKey(ctx, &sapb.ExternalAccountKey{KeyID: &keyID, HmacKey: hmacKey})
+	test.Assert(t, berrors.Is(err, berrors.Duplicate), "Adding a duplicate key didn't fail with a Duplicate error")
+
+	key, err := sa.GetExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.AssertNotError(t, err, "GetExternalAccountKey failed")
+	test.AssertByteEquals(t, key.HmacKey, hmacKey)
+	test.AssertEquals(t, *key.CreatedAt, fc.Now().UnixNano())
+	test.AssertEquals(t, *key.RetiredAt, int64(0))
+	test.AssertEquals(t, *key.RegistrationID, int64(0))
+
+	// Creating a registration bound to the key records the binding
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:                  satest.GoodJWK(),
+		InitialIP:            net.ParseIP("43.34.43.34"),
+		ExternalAccountKeyID: keyID,
+	})
+	test.AssertNotError(t, err, "NewRegistration with an external account key failed")
+	key, err = sa.GetExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.AssertNotError(t, err, "GetExternalAccountKey failed")
+	test.AssertEquals(t, *key.RegistrationID, reg.ID)
+
+	// The key can't be used for a second registration, and the registration
+	// isn't stored when binding fails
+	var anotherJWK jose.JSONWebKey
+	err = json.Unmarshal([]byte(anotherKey), &anotherJWK)
+	test.AssertNotError(t, err, "Failed to unmarshal JWK")
+	_, err = sa.NewRegistration(ctx, core.Registration{
+		Key:                  &anotherJWK,
+		InitialIP:            net.ParseIP("43.34.43.34"),
+		ExternalAccountKeyID: keyID,
+	})
+	test.Assert(t, berrors.Is(err, berrors.Unauthorized), "Reusing an external account key didn't fail with an Unauthorized error")
+	_, err = sa.GetRegistrationByKey(ctx, &anotherJWK)
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "Registration was stored despite failing to bind")
+
+	// Retiring the key leaves the binding in place
+	fc.Add(time.Hour)
+	err = sa.RetireExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.AssertNotError(t, err, "RetireExternalAccountKey failed")
+	key, err = sa.GetExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.AssertNotError(t, err, "GetExternalAccountKey failed")
+	test.AssertEquals(t, *key.RetiredAt, fc.Now().UnixNano())
+	test.AssertEquals(t, *key.RegistrationID, reg.ID)
+
+	err = sa.RetireExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	test.Assert(t, berrors.Is(err, berrors.NotFound), "Retiring a retired key didn't fail with a NotFound error")
+
+	// A retired key can't be bound
+	retiredID := "retired-key"
+	err = sa.AddExternalAccountKey(ctx, &sapb.ExternalAccountKey{KeyID: &retiredID, HmacKey: hmacKey})
+	test.AssertNotError(t, err, "AddExternalAccountKey failed")
+	err = sa.RetireExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &retiredID})
+	test.AssertNotError(t, err, "RetireExternalAccountKey failed")
+	_, err = sa.NewRegistration(ctx, core.Registration{
+		Key:                  &anotherJWK,
+		InitialIP:            net.ParseIP("43.34.43.34"),
+		ExternalAccountKeyID: retiredID,
+	})
+	test.Assert(t, berrors.Is(err, berrors.Unauthorized), "Binding a retired external account key didn't fail with an Unauthorized error")
+}
+
+func TestOrderCertificateProfileName(t *testing.T) {
+	// The certificateProfileName column is only added by the _db-next
+	// migrations.
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("orders.certificateProfileName requires the config-next database")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	err := features.Set(map[string]bool{"CertificateProfiles": true})
+	test.AssertNotError(t, err, "Failed to enable CertificateProfiles")
+	defer features.Reset()
+
+	reg, err := sa.NewRegistration(ctx, core.Registration{
+		Key:       &jose.JSONWebKey{Key: &rsa.PublicKey{N: big.NewInt(1), E: 1}},
+		InitialIP: net.ParseIP("42.42.42.42"),
+	})
+	test.AssertNotError(t, err, "Couldn't create test registration")
+
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	profile := "shortlived"
+	order, err := sa.NewOrder(ctx, &corepb.Order{
+		RegistrationID:         &reg.ID,
+		Expires:                &expires,
+		Names:                  []string{"example.com"},
+		CertificateProfileName: &profile,
+	})
+	test.AssertNotError(t, err, "sa.NewOrder failed")
+
+	storedOrder, err := sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "sa.GetOrder failed")
+	test.AssertEquals(t, storedOrder.GetCertificateProfileName(), profile)
+
+	// Orders that don't name a profile are stored with the empty name, which
+	// selects the CA's default profile.
+	order, err = sa.NewOrder(ctx, &corepb.Order{
+		RegistrationID: &reg.ID,
+		Expires:        &expires,
+		Names:          []string{"example.org"},
+	})
+	test.AssertNotError(t, err, "sa.NewOrder failed")
+	storedOrder, err = sa.GetOrder(ctx, &sapb.OrderRequest{Id: order.Id})
+	test.AssertNotError(t, err, "sa.GetOrder failed")
+	test.AssertEquals(t, storedOrder.GetCertificateProfileName(), "")
+}
+
+func TestBlockedKeys(t *testing.T) {
+	// The blockedKeys table is only created by the _db-next migrations.
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("blockedKeys table requires the config-next database")
+	}
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	keyHash := sha256.Sum256([]byte("a key"))
+	added := fc.Now().UnixNano()
+	source := "API"
+
+	exists, err := sa.KeyBlocked(ctx, &sapb.KeyBlockedRequest{KeyHash: keyHash[:]})
+	test.AssertNotError(t, err, "KeyBlocked failed")
+	test.Assert(t, !*exists.Exists, "key was blocked before being added")
+
+	err = sa.AddBlockedKey(ctx, &sapb.AddBlockedKeyRequest{
+		KeyHash: keyHash[:],
+		Added:   &added,
+		Source:  &source,
+	})
+	test.AssertNotError(t, err, "AddBlockedKey failed")
+	// Blocking a key twice isn't an error.
+	err = sa.AddBlockedKey(ctx, &sapb.AddBlockedKeyRequest{
+		KeyHash: keyHash[:],
+		Added:   &added,
+		Source:  &source,
+	})
+	test.AssertNotError(t, err, "AddBlockedKey failed for a duplicate key")
+
+	exists, err = sa.KeyBlocked(ctx, &sapb.KeyBlockedRequest{KeyHash: keyHash[:]})
+	test.AssertNotError(t, err, "KeyBlocked failed")
+	test.Assert(t, *exists.Exists, "key wasn't blocked after being added")
+
+	err = sa.AddBlockedKey(ctx, &sapb.AddBlockedKeyRequest{
+		KeyHash: []byte("short"),
+		Added:   &added,
+		Source:  &source,
+	})
+	test.AssertError(t, err, "AddBlockedKey accepted a short key hash")
+}
+
+func TestGetSerialsByKey(t *testing.T) {
+	// The keyHashToSerial table is only created by the _db-next migrations.
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("keyHashToSerial table requires the config-next database")
+	}
+	err := features.Set(map[string]bool{"StoreKeyHashes": true})
+	test.AssertNotError(t, err, "Failed to set feature flags")
+	defer features.Reset()
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	certDER, err := ioutil.ReadFile("test-cert.der")
+	test.AssertNotError(t, err, "Couldn't read test-cert.der")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "Couldn't parse test-cert.der")
+	issued := fc.Now()
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, &issued)
+	test.AssertNotError(t, err, "AddCertificate failed")
+
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	test.AssertNotError(t, err, "KeySPKIHash failed")
+	before := cert.NotAfter.Add(-time.Hour).UnixNano()
+	limit := int64(10)
+	serials, err := sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: keyHash, ValidAfter: &before, Limit: &limit})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertDeepEquals(t, serials.Serials, []string{core.SerialToString(cert.SerialNumber)})
+
+	// Expired certificates aren't returned.
+	after := cert.NotAfter.Add(time.Hour).UnixNano()
+	serials, err = sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: keyHash, ValidAfter: &after, Limit: &limit})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertEquals(t, len(serials.Serials), 0)
+
+	zero := int64(0)
+	_, err = sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: keyHash, ValidAfter: &before, Limit: &zero})
+	test.AssertError(t, err, "GetSerialsByKey accepted a zero limit")
+}
+
+func TestAddKeyHash(t *testing.T) {
+	// The keyHashToSerial table is only created by the _db-next migrations.
+	if !strings.Contains(os.Getenv("BOULDER_CONFIG_DIR"), "test/config-next") {
+		t.Skip("keyHashToSerial table requires the config-next database")
+	}
+	err := features.Set(map[string]bool{"StoreKeyHashes": true})
+	test.AssertNotError(t, err, "Failed to set feature flags")
+	defer features.Reset()
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	certDER, err := ioutil.ReadFile("test-cert.der")
+	test.AssertNotError(t, err, "Couldn't read test-cert.der")
+	cert, err := x509.ParseCertificate(certDER)
+	test.AssertNotError(t, err, "Couldn't parse test-cert.der")
+	keyHash, err := core.KeySPKIHash(cert.PublicKey)
+	test.AssertNotError(t, err, "KeySPKIHash failed")
+	serial := core.SerialToString(cert.SerialNumber)
+	notAfter := cert.NotAfter.UnixNano()
+
+	// The CA stores the hash before signing the precertificate, so it's found
+	// even though no certificate has been stored yet.
+	err = sa.AddKeyHash(ctx, &sapb.AddKeyHashRequest{KeyHash: keyHash, Serial: &serial, CertNotAfter: &notAfter})
+	test.AssertNotError(t, err, "AddKeyHash failed")
+	before := cert.NotAfter.Add(-time.Hour).UnixNano()
+	limit := int64(10)
+	serials, err := sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: keyHash, ValidAfter: &before, Limit: &limit})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertDeepEquals(t, serials.Serials, []string{serial})
+
+	// Storing the final certificate doesn't fail because its hash is already
+	// recorded.
+	issued := fc.Now()
+	_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, &issued)
+	test.AssertNotError(t, err, "AddCertificate failed after AddKeyHash")
+	serials, err = sa.GetSerialsByKey(ctx, &sapb.GetSerialsByKeyRequest{KeyHash: keyHash, ValidAfter: &before, Limit: &limit})
+	test.AssertNotError(t, err, "GetSerialsByKey failed")
+	test.AssertDeepEquals(t, serials.Serials, []string{serial})
+
+	err = sa.AddKeyHash(ctx, &sapb.AddKeyHashRequest{KeyHash: []byte("short"), Serial: &serial, CertNotAfter: &notAfter})
+	test.AssertError(t, err, "AddKeyHash accepted a short key hash")
+}
+
+func TestGetOrdersForAccount(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	other := satest.CreateWorkingRegistration(t, sa)
+
+	expires := fc.Now().Add(time.Hour).UnixNano()
+	status := string(core.StatusPending)
+	newOrder := func(regID int64) int64 {
+		order, err := sa.NewOrder(ctx, &corepb.Order{
+			RegistrationID: &regID,
+			Expires:        &expires,
+			Names:          []string{"example.com"},
+			Authorizations: []string{"a"},
+			Status:         &status,
+		})
+		test.AssertNotError(t, err, "NewOrder failed")
+		return *order.Id
+	}
+	var ids []int64
+	for i := 0; i < 3; i++ {
+		ids = append(ids, newOrder(reg.ID))
+	}
+	newOrder(other.ID)
+
+	zero := int64(0)
+	limit := int64(2)
+	page, err := sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &reg.ID,
+		Before:         &zero,
+		Limit:          &limit,
+	})
+	test.AssertNotError(t, err, "GetOrdersForAccount failed")
+	test.AssertDeepEquals(t, page.Ids, []int64{ids[2], ids[1]})
+
+	page, err = sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &reg.ID,
+		Before:         &ids[1],
+		Limit:          &limit,
+	})
+	test.AssertNotError(t, err, "GetOrdersForAccount failed")
+	test.AssertDeepEquals(t, page.Ids, []int64{ids[0]})
+
+	_, err = sa.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &reg.ID,
+		Before:         &zero,
+		Limit:          &zero,
+	})
+	test.AssertError(t, err, "GetOrdersForAccount accepted a zero limit")
+	test.Assert(t, berrors.Is(err, berrors.Malformed), "wrong error type for zero limit")
+}
+
+func TestGetCertificatesForAccount(t *testing.T) {
+	sa, fc, cleanUp := initSA(t)
+	defer cleanUp()
+
+	reg := satest.CreateWorkingRegistration(t, sa)
+	issued := fc.Now()
+	for _, file := range []string{"www.eff.org.der", "test-cert.der"} {
+		certDER, err := ioutil.ReadFile(file)
+		test.AssertNotError(t, err, "Couldn't read "+file)
+		_, err = sa.AddCertificate(ctx, certDER, reg.ID, nil, &issued)
+		test.AssertNotError(t, err, "AddCertificate failed for "+file)
+	}
+
+	zero := int64(0)
+	limit := int64(1)
+	page, err := sa.GetCertificatesForAccount(ctx, &sapb.GetCertificatesForAccountRequest{
+		RegistrationID: &reg.ID,
+		Before:         &zero,
+		Limit:          &limit,
+	})
+	test.AssertNotError(t, err, "GetCertificatesForAccount failed")
+	test.AssertEquals(t, len(page.Certificates), 1)
+	newest := page.Certificates[0]
+	test.AssertEquals(t, *newest.Certificate.RegistrationID, reg.ID)
+	test.AssertEquals(t, *newest.Status, string(core.OCSPStatusGood))
+
+	page, err = sa.GetCertificatesForAccount(ctx, &sapb.GetCertificatesForAccountRequest{
+		RegistrationID: &reg.ID,
+		Before:         newest.Id,
+		Limit:          &limit,
+	})
+	test.AssertNotError(t, err, "GetCertificatesForAccount failed")
+	test.AssertEquals(t, len(page.Certificates), 1)
+	test.Assert(t, *page.Certificates[0].Id < *newest.Id, "certificates not returned newest first")
+	test.Assert(t, *page.Certificates[0].Certificate.Serial != *newest.Certificate.Serial, "same certificate returned twice")
+}
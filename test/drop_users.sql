@@ -14,6 +14,8 @@ GRANT USAGE ON *.* TO 'ocsp_resp'@'localhost';
 DROP USER 'ocsp_resp'@'localhost';
 GRANT USAGE ON *.* TO 'ocsp_update'@'localhost';
 DROP USER 'ocsp_update'@'localhost';
+GRANT USAGE ON *.* TO 'crl_update'@'localhost';
+DROP USER 'crl_update'@'localhost';
 GRANT USAGE ON *.* TO 'revoker'@'localhost';
 DROP USER 'revoker'@'localhost';
 GRANT USAGE ON *.* TO 'importer'@'localhost';
@@ -10,10 +10,11 @@ command -v minica >/dev/null 2>&1 || {
 }
 
 for HOSTNAME in admin-revoker.boulder expiration-mailer.boulder \
-  ocsp-updater.boulder orphan-finder.boulder wfe.boulder akamai-purger.boulder ; do
+  ocsp-updater.boulder orphan-finder.boulder wfe.boulder akamai-purger.boulder \
+  crl-updater.boulder eab-admin.boulder bad-key-revoker.boulder account-admin.boulder ; do
   minica -domains ${HOSTNAME}
 done
 
-for SERVICE in publisher ra ca sa va ; do
+for SERVICE in publisher ra ca sa va nonce ; do
   minica -domains "${SERVICE}.boulder,${SERVICE}1.boulder,${SERVICE}2.boulder"
 done
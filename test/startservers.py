@@ -101,16 +101,29 @@ def start(race_detection, fakeclock=None, account_uri=None):
         [8005, './bin/ocsp-responder --config %s' % os.path.join(default_config_dir, "ocsp-responder.json")],
         [8004, './bin/boulder-va --config %s --addr va1.boulder:9092 --debug-addr :8004' % os.path.join(default_config_dir, "va.json")],
         [8104, './bin/boulder-va --config %s --addr va2.boulder:9092 --debug-addr :8104' % os.path.join(default_config_dir, "va.json")],
-        [8001, './bin/boulder-ca --config %s --ca-addr ca1.boulder:9093 --ocsp-addr ca1.boulder:9096 --debug-addr :8001' % os.path.join(default_config_dir, "ca-a.json")],
-        [8101, './bin/boulder-ca --config %s --ca-addr ca2.boulder:9093 --ocsp-addr ca2.boulder:9096 --debug-addr :8101' % os.path.join(default_config_dir, "ca-b.json")],
+        [8001, './bin/boulder-ca --config %s --ca-addr ca1.boulder:9093 --ocsp-addr ca1.boulder:9096 --crl-addr ca1.boulder:9106 --debug-addr :8001' % os.path.join(default_config_dir, "ca-a.json")],
+        [8101, './bin/boulder-ca --config %s --ca-addr ca2.boulder:9093 --ocsp-addr ca2.boulder:9096 --crl-addr ca2.boulder:9106 --debug-addr :8101' % os.path.join(default_config_dir, "ca-b.json")],
         [6789, './bin/akamai-test-srv --listen localhost:6789 --secret its-a-secret'],
         [9666, './bin/akamai-purger --config %s' % os.path.join(default_config_dir, "akamai-purger.json")],
         [8006, './bin/ocsp-updater --config %s' % os.path.join(default_config_dir, "ocsp-updater.json")],
         [8002, './bin/boulder-ra --config %s --addr ra1.boulder:9094 --debug-addr :8002' % os.path.join(default_config_dir, "ra.json")],
         [8102, './bin/boulder-ra --config %s --addr ra2.boulder:9094 --debug-addr :8102' % os.path.join(default_config_dir, "ra.json")],
+    ])
+    if default_config_dir.startswith("test/config-next"):
+        # Run the two nonce services that the WFE2 shares nonces through.
+        progs.extend([
+            [8111, './bin/nonce-service --config %s --addr nonce1.boulder:9101 --debug-addr :8111 --prefix zinc' % os.path.join(default_config_dir, "nonce.json")],
+            [8112, './bin/nonce-service --config %s --addr nonce2.boulder:9101 --debug-addr :8112 --prefix taro' % os.path.join(default_config_dir, "nonce.json")],
+        ])
+    progs.extend([
         [4431, './bin/boulder-wfe2 --config %s' % os.path.join(default_config_dir, "wfe2.json")],
         [4000, './bin/boulder-wfe --config %s' % os.path.join(default_config_dir, "wfe.json")],
     ])
+    if default_config_dir.startswith("test/config-next"):
+        progs.extend([
+            [8014, './bin/crl-updater --config %s' % os.path.join(default_config_dir, "crl-updater.json")],
+            [8020, './bin/bad-key-revoker --config %s' % os.path.join(default_config_dir, "bad-key-revoker.json")],
+        ])
     for (port, prog) in progs:
         try:
             global processes
@@ -3,12 +3,16 @@ package va
 import (
 	"encoding/json"
 	"fmt"
+	"math/rand"
+	"net/url"
 	"strings"
 	"sync"
 
+	"github.com/letsencrypt/boulder/canceled"
 	"github.com/letsencrypt/boulder/core"
 	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/features"
+	bgrpc "github.com/letsencrypt/boulder/grpc"
 	"github.com/letsencrypt/boulder/probs"
 	vapb "github.com/letsencrypt/boulder/va/proto"
 	"github.com/miekg/dns"
@@ -20,42 +24,126 @@ type caaParams struct {
 	validationMethod *string
 }
 
+// caaCheckType is passed to processRemoteResults in place of a challenge type
+// when combining the results of remote CAA checks.
+const caaCheckType = "caa"
+
+// IsCAAValid checks CAA for the requested domain. If the EnforceMultiCAA or
+// MultiCAAFullResults feature is enabled and remote VAs are configured, each
+// remote VA checks CAA too, so that a CAA decision isn't made from a single
+// network perspective, and their results are combined with the primary VA's
+// in the same way as for challenge validations.
 func (va *ValidationAuthorityImpl) IsCAAValid(ctx context.Context, req *vapb.IsCAAValidRequest) (*vapb.IsCAAValidResponse, error) {
-	acmeID := core.AcmeIdentifier{
-		Type:  core.IdentifierDNS,
-		Value: *req.Domain,
+	enforce := features.Enabled(features.EnforceMultiCAA)
+	fullResults := features.Enabled(features.MultiCAAFullResults)
+	var remoteProbs chan *probs.ProblemDetails
+	if remoteVACount := len(va.remoteVAs); remoteVACount > 0 && (enforce || fullResults) {
+		remoteProbs = make(chan *probs.ProblemDetails, remoteVACount)
+		go va.performRemoteCAACheck(ctx, req, remoteProbs)
 	}
+
+	acmeID := core.IdentifierForName(*req.Domain)
 	params := &caaParams{
 		accountURIID:     req.AccountURIID,
 		validationMethod: req.ValidationMethod,
 	}
-	if prob := va.checkCAA(ctx, acmeID, params); prob != nil {
+	prob, iodef := va.checkCAA(ctx, acmeID, params)
+	if prob != nil {
+		prob = &probs.ProblemDetails{
+			Type:   prob.Type,
+			Detail: fmt.Sprintf("While processing CAA for %s: %s", *req.Domain, prob.Detail),
+		}
+	} else if remoteProbs != nil {
+		if !enforce {
+			// If we're not going to enforce multi CAA but we are logging the
+			// differentials then collect and log the remote results in a separate
+			// go routine to avoid blocking the primary VA.
+			go func() {
+				_ = va.processRemoteResults(*req.Domain, caaCheckType, nil, remoteProbs, len(va.remoteVAs), true)
+			}()
+		} else {
+			prob = va.processRemoteResults(*req.Domain, caaCheckType, nil, remoteProbs, len(va.remoteVAs), fullResults)
+			if prob != nil {
+				va.log.Infof("CAA check failed due to remote failures: identifier=%v err=%s",
+					*req.Domain, prob)
+				va.metrics.remoteCAAFailures.Inc()
+			}
+		}
+	}
+
+	if prob != nil {
 		typ := string(prob.Type)
-		detail := fmt.Sprintf("While processing CAA for %s: %s", *req.Domain, prob.Detail)
 		return &vapb.IsCAAValidResponse{
 			Problem: &corepb.ProblemDetails{
 				ProblemType: &typ,
-				Detail:      &detail,
+				Detail:      &prob.Detail,
 			},
+			Iodef: iodef,
 		}, nil
 	}
 	return &vapb.IsCAAValidResponse{}, nil
 }
 
+// performRemoteCAACheck calls `IsCAAValid` for each of the configured remoteVAs
+// in a random order, in separate go-routines, writing one result for each to
+// the provided `results` chan as `performRemoteValidation` does. A remote CAA
+// problem is written as-is, a cancelled RPC is treated as a success and any
+// other RPC error is written as a server internal problem.
+func (va *ValidationAuthorityImpl) performRemoteCAACheck(
+	ctx context.Context,
+	req *vapb.IsCAAValidRequest,
+	results chan *probs.ProblemDetails) {
+	for _, i := range rand.Perm(len(va.remoteVAs)) {
+		go func(rva RemoteVA) {
+			resp, err := rva.IsCAAValid(ctx, req)
+			if err != nil {
+				if canceled.Is(err) {
+					// We cancelled the remote VA request before it was finished
+					// because we didn't care about its result.
+					results <- nil
+					return
+				}
+				va.log.Errf("Remote VA %q.IsCAAValid failed: %s", rva.Addresses, err)
+				results <- probs.ServerInternal("Remote IsCAAValid RPC failed")
+				return
+			}
+			prob, err := bgrpc.PBToProblemDetails(resp.Problem)
+			if err != nil {
+				va.log.Errf("Remote VA %q.IsCAAValid returned a malformed problem: %s", rva.Addresses, err)
+				results <- probs.ServerInternal("Remote IsCAAValid RPC failed")
+				return
+			}
+			if prob != nil {
+				// The remote VA will have logged more detail.
+				va.log.Infof("Remote VA %q.IsCAAValid returned problem: %s", rva.Addresses, prob)
+			}
+			results <- prob
+		}(va.remoteVAs[i])
+	}
+}
+
 // checkCAA performs a CAA lookup & validation for the provided identifier. If
-// the CAA lookup & validation fail a problem is returned.
+// the CAA lookup & validation fail a problem is returned. If CAA prevents
+// issuance the problem names the records responsible, and the targets of any
+// iodef records, to which the domain holder asks for such refusals to be
+// reported, are returned too.
 func (va *ValidationAuthorityImpl) checkCAA(
 	ctx context.Context,
 	identifier core.AcmeIdentifier,
-	params *caaParams) *probs.ProblemDetails {
+	params *caaParams) (*probs.ProblemDetails, []string) {
+	// CAA records are only published for domain names, so there is nothing to
+	// check for an IP identifier.
+	if identifier.Type == core.IdentifierIP {
+		return nil, nil
+	}
 	present, valid, records, err := va.checkCAARecords(ctx, identifier, params)
 	if err != nil {
-		return probs.DNS("%v", err)
+		return probs.DNS("%v", err), nil
 	}
 
 	recordsStr, err := json.Marshal(&records)
 	if err != nil {
-		return probs.CAA("CAA records for %s were malformed", identifier.Value)
+		return probs.CAA("CAA records for %s were malformed", identifier.Value), nil
 	}
 
 	accountID, challengeType := "unknown", "unknown"
@@ -69,9 +157,12 @@ func (va *ValidationAuthorityImpl) checkCAA(
 	va.log.AuditInfof("Checked CAA records for %s, [Present: %t, Account ID: %s, Challenge: %s, Valid for issuance: %t] Records=%s",
 		identifier.Value, present, accountID, challengeType, valid, recordsStr)
 	if !valid {
-		return probs.CAA("CAA record for %s prevents issuance", identifier.Value)
+		caaSet := newCAASet(records)
+		wildcard := strings.HasPrefix(identifier.Value, "*.")
+		return probs.CAA("CAA record for %s prevents issuance: %s",
+			identifier.Value, caaSet.refusalReason(wildcard)), caaSet.iodefTargets()
 	}
-	return nil
+	return nil, nil
 }
 
 // CAASet consists of filtered CAA records
@@ -79,26 +170,101 @@ type CAASet struct {
 	Issue     []*dns.CAA
 	Issuewild []*dns.CAA
 	Iodef     []*dns.CAA
+	// Issuemail records (RFC 9495) only restrict the issuance of S/MIME
+	// certificates, so are known but otherwise ignored.
+	Issuemail []*dns.CAA
 	Unknown   []*dns.CAA
 }
 
 // returns true if any CAA records have unknown tag properties and are flagged critical.
 func (caaSet CAASet) criticalUnknown() bool {
-	if len(caaSet.Unknown) > 0 {
-		for _, caaRecord := range caaSet.Unknown {
-			// The critical flag is the bit with significance 128. However, many CAA
-			// record users have misinterpreted the RFC and concluded that the bit
-			// with significance 1 is the critical bit. This is sufficiently
-			// widespread that that bit must reasonably be considered an alias for
-			// the critical bit. The remaining bits are 0/ignore as proscribed by the
-			// RFC.
-			if (caaRecord.Flag & (128 | 1)) != 0 {
-				return true
-			}
+	return caaSet.criticalUnknownRecord() != nil
+}
+
+// criticalUnknownRecord returns the first CAA record with an unknown tag
+// property that is flagged critical, or nil if there is none.
+func (caaSet CAASet) criticalUnknownRecord() *dns.CAA {
+	for _, caaRecord := range caaSet.Unknown {
+		// The critical flag is the bit with significance 128. However, many CAA
+		// record users have misinterpreted the RFC and concluded that the bit
+		// with significance 1 is the critical bit. This is sufficiently
+		// widespread that that bit must reasonably be considered an alias for
+		// the critical bit. The remaining bits are 0/ignore as proscribed by the
+		// RFC.
+		if (caaRecord.Flag & (128 | 1)) != 0 {
+			return caaRecord
 		}
 	}
+	return nil
+}
+
+// issuanceRecords returns the records that decide whether issuance is allowed.
+// Per RFC 6844 Section 5.3 "issueWild properties MUST be ignored when
+// processing a request for a domain that is not a wildcard domain" so these
+// are the issue records unless wildcard is true and there are issuewild
+// records.
+func (caaSet CAASet) issuanceRecords(wildcard bool) []*dns.CAA {
+	if wildcard && len(caaSet.Issuewild) > 0 {
+		return caaSet.Issuewild
+	}
+	return caaSet.Issue
+}
 
-	return false
+// refusalReason describes the records responsible for the CAASet preventing
+// issuance, naming their tags, so that the domain holder can tell which of
+// their records to change.
+func (caaSet CAASet) refusalReason(wildcard bool) string {
+	if caaRecord := caaSet.criticalUnknownRecord(); caaRecord != nil {
+		return fmt.Sprintf("unknown tag %q is flagged critical in record %s",
+			caaRecord.Tag, formatCAA(caaRecord))
+	}
+	records := caaSet.issuanceRecords(wildcard)
+	if len(records) == 0 {
+		return "no issue or issuewild records"
+	}
+	var formatted []string
+	for _, caaRecord := range records {
+		formatted = append(formatted, formatCAA(caaRecord))
+	}
+	return fmt.Sprintf("no %s record authorizes this request, found %s",
+		strings.ToLower(records[0].Tag), strings.Join(formatted, ", "))
+}
+
+// formatCAA formats a CAA record's flag, tag and value as in a zone file,
+// preceded by the name it was found at if that is known.
+func formatCAA(caaRecord *dns.CAA) string {
+	formatted := fmt.Sprintf("%d %s %q", caaRecord.Flag, caaRecord.Tag, caaRecord.Value)
+	if caaRecord.Hdr.Name != "" {
+		return fmt.Sprintf("%s CAA %s", caaRecord.Hdr.Name, formatted)
+	}
+	return formatted
+}
+
+// iodefTargets returns the mailto, http and https URLs of the CAASet's iodef
+// records (RFC 8659 Section 4.4). Malformed values and other schemes are
+// ignored.
+func (caaSet CAASet) iodefTargets() []string {
+	var targets []string
+	for _, caaRecord := range caaSet.Iodef {
+		u, err := url.Parse(strings.TrimSpace(caaRecord.Value))
+		if err != nil {
+			continue
+		}
+		switch strings.ToLower(u.Scheme) {
+		case "mailto":
+			if u.Opaque == "" {
+				continue
+			}
+		case "http", "https":
+			if u.Host == "" {
+				continue
+			}
+		default:
+			continue
+		}
+		targets = append(targets, u.String())
+	}
+	return targets
 }
 
 // Filter CAA records by property
@@ -113,6 +279,8 @@ func newCAASet(CAAs []*dns.CAA) *CAASet {
 			filtered.Issuewild = append(filtered.Issuewild, caaRecord)
 		case "iodef":
 			filtered.Iodef = append(filtered.Iodef, caaRecord)
+		case "issuemail":
+			filtered.Issuemail = append(filtered.Issuemail, caaRecord)
 		default:
 			filtered.Unknown = append(filtered.Unknown, caaRecord)
 		}
@@ -247,15 +415,7 @@ func (va *ValidationAuthorityImpl) validateCAASet(caaSet *CAASet, wildcard bool,
 		return true, true
 	}
 
-	// Per RFC 6844 Section 5.3 "issueWild properties MUST be ignored when
-	// processing a request for a domain that is not a wildcard domain" so we
-	// default to checking the `caaSet.Issue` records and only check
-	// `caaSet.Issuewild` when `wildcard` is true and there is >0 `Issuewild`
-	// records.
-	records := caaSet.Issue
-	if wildcard && len(caaSet.Issuewild) > 0 {
-		records = caaSet.Issuewild
-	}
+	records := caaSet.issuanceRecords(wildcard)
 
 	// There are CAA records pertaining to issuance in our case. Note that this
 	// includes the case of the unsatisfiable CAA record value ";", used to
@@ -9,9 +9,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/miekg/dns"
 
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
 	"github.com/letsencrypt/boulder/features"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/test"
@@ -181,6 +183,28 @@ func (mock caaMockDNS) LookupCAA(_ context.Context, domain string) ([]*dns.CAA,
 		record.Tag = "issuewild"
 		record.Value = "letsencrypt.org"
 		results = append(results, &record)
+	case "reserved-with-iodef.com":
+		record.Hdr.Name = "reserved-with-iodef.com."
+		record.Tag = "issue"
+		record.Value = "ca.com"
+		results = append(results, &record)
+		for _, target := range []string{
+			"mailto:security@reserved-with-iodef.com",
+			"https://reserved-with-iodef.com/caa-report",
+			"ftp://reserved-with-iodef.com/unsupported-scheme",
+			"mailto:",
+		} {
+			results = append(results, &dns.CAA{Tag: "iodef", Value: target})
+		}
+	case "critical-issuemail.com":
+		// Ok issuance - issuemail only restricts S/MIME certificates, so isn't an
+		// unknown tag even when flagged critical.
+		record.Flag = 128
+		record.Tag = "issuemail"
+		record.Value = "ca.com"
+		results = append(results, &record)
+		secondRecord := dns.CAA{Tag: "issue", Value: "letsencrypt.org"}
+		results = append(results, &secondRecord)
 	}
 	return results, nil
 }
@@ -188,7 +212,7 @@ func (mock caaMockDNS) LookupCAA(_ context.Context, domain string) ([]*dns.CAA,
 func TestCAATimeout(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 	va.dnsClient = caaMockDNS{}
-	err := va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "caa-timeout.com"}, nil)
+	err, _ := va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: "caa-timeout.com"}, nil)
 	if err.Type != probs.DNSProblem {
 		t.Errorf("Expected timeout error type %s, got %s", probs.DNSProblem, err.Type)
 	}
@@ -198,6 +222,14 @@ func TestCAATimeout(t *testing.T) {
 	}
 }
 
+func TestCAASkippedForIP(t *testing.T) {
+	va, log := setup(nil, 0, "", nil)
+	va.dnsClient = caaMockDNS{}
+	prob, _ := va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierIP, Value: "10.1.2.3"}, nil)
+	test.Assert(t, prob == nil, "checkCAA failed for an IP identifier")
+	test.AssertEquals(t, len(log.GetAllMatching("Checked CAA records")), 0)
+}
+
 func TestCAAChecking(t *testing.T) {
 	testCases := []struct {
 		Name    string
@@ -545,7 +577,7 @@ func TestCAALogging(t *testing.T) {
 				accountURIID:     tc.AccountURIID,
 				validationMethod: tc.ChallengeType,
 			}
-			_ = va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: tc.Domain}, params)
+			_, _ = va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: tc.Domain}, params)
 
 			caaLogLines := mockLog.GetAllMatching(`Checked CAA records for`)
 			if len(caaLogLines) != 1 {
@@ -589,11 +621,24 @@ func TestCAAFailure(t *testing.T) {
 	va, _ := setup(hs, 0, "", nil)
 	va.dnsClient = caaMockDNS{}
 
-	_, prob := va.validate(ctx, dnsi("reserved.com"), chall, core.Authorization{})
+	_, iodef, prob := va.validate(ctx, dnsi("reserved.com"), chall, core.Authorization{})
 	if prob == nil {
 		t.Fatalf("Expected CAA rejection for reserved.com, got success")
 	}
 	test.AssertEquals(t, prob.Type, probs.CAAProblem)
+	test.AssertEquals(t, len(iodef), 0)
+
+	// The targets of any iodef records are returned with the problem, so that
+	// the RA can report the refusal.
+	_, iodef, prob = va.validate(ctx, dnsi("reserved-with-iodef.com"), chall, core.Authorization{})
+	if prob == nil {
+		t.Fatalf("Expected CAA rejection for reserved-with-iodef.com, got success")
+	}
+	test.AssertEquals(t, prob.Type, probs.CAAProblem)
+	test.AssertDeepEquals(t, iodef, []string{
+		"mailto:security@reserved-with-iodef.com",
+		"https://reserved-with-iodef.com/caa-report",
+	})
 }
 
 func TestParseResults(t *testing.T) {
@@ -828,3 +873,154 @@ func TestExtractIssuerDomainAndParameters(t *testing.T) {
 		})
 	}
 }
+
+func TestMultiCAA(t *testing.T) {
+	// A remote VA whose view of DNS shows a CAA record forbidding issuance for
+	// reserved.com, unlike the primary VA and the other remote VAs, as it might
+	// if its DNS traffic were hijacked.
+	hijackedVA, _ := setup(nil, 0, "", nil)
+	hijackedVA.dnsClient = caaMockDNS{}
+	goodVA, _ := setup(nil, 0, "", nil)
+
+	enforceMultiCAA := map[string]bool{"EnforceMultiCAA": true}
+	enforceMultiCAAFullResults := map[string]bool{"EnforceMultiCAA": true, "MultiCAAFullResults": true}
+
+	caaProb := &corepb.ProblemDetails{
+		ProblemType: proto.String(string(probs.CAAProblem)),
+		Detail:      proto.String(`While processing CAA for reserved.com: CAA record for reserved.com prevents issuance: no issue record authorizes this request, found 0 issue "ca.com"`),
+	}
+	internalProb := &corepb.ProblemDetails{
+		ProblemType: proto.String(string(probs.ServerInternalProblem)),
+		Detail:      proto.String("Remote IsCAAValid RPC failed"),
+	}
+
+	testCases := []struct {
+		Name              string
+		RemoteVAs         []RemoteVA
+		MaxRemoteFailures int
+		Features          map[string]bool
+		ExpectedProb      *corepb.ProblemDetails
+		ExpectedLog       string
+	}{
+		{
+			Name:      "Remote VAs disagree, multi CAA disabled",
+			RemoteVAs: []RemoteVA{{hijackedVA, "hijacked 1"}, {hijackedVA, "hijacked 2"}},
+		},
+		{
+			Name:      "Remote VAs agree, enforce multi CAA",
+			RemoteVAs: []RemoteVA{{goodVA, "good 1"}, {goodVA, "good 2"}},
+			Features:  enforceMultiCAA,
+		},
+		{
+			Name:         "One remote VA disagrees, enforce multi CAA",
+			RemoteVAs:    []RemoteVA{{goodVA, "good"}, {hijackedVA, "hijacked"}},
+			Features:     enforceMultiCAA,
+			ExpectedProb: caaProb,
+			ExpectedLog:  `INFO: Remote VA "hijacked".IsCAAValid returned problem`,
+		},
+		{
+			Name:              "One remote VA disagrees within max remote failures, enforce multi CAA",
+			RemoteVAs:         []RemoteVA{{goodVA, "good"}, {hijackedVA, "hijacked"}},
+			MaxRemoteFailures: 1,
+			Features:          enforceMultiCAA,
+		},
+		{
+			Name:         "One remote VA broken, enforce multi CAA",
+			RemoteVAs:    []RemoteVA{{goodVA, "good"}, {&brokenRemoteVA{}, "broken"}},
+			Features:     enforceMultiCAA,
+			ExpectedProb: internalProb,
+			ExpectedLog:  `ERR: \[AUDIT\] Remote VA "broken".IsCAAValid failed: ` + brokenRemoteVAError.Error(),
+		},
+		{
+			Name:      "One remote VA cancelled, enforce multi CAA",
+			RemoteVAs: []RemoteVA{{goodVA, "good"}, {cancelledVA{}, "cancelled"}},
+			Features:  enforceMultiCAA,
+		},
+		{
+			Name:         "One remote VA disagrees, full results, enforce multi CAA",
+			RemoteVAs:    []RemoteVA{{goodVA, "good"}, {hijackedVA, "hijacked"}},
+			Features:     enforceMultiCAAFullResults,
+			ExpectedProb: caaProb,
+			ExpectedLog:  `INFO: remoteVADifferentials JSON={"Domain":"reserved.com","ChallengeType":"caa",`,
+		},
+	}
+
+	domain := "reserved.com"
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			va, mockLog := setup(nil, tc.MaxRemoteFailures, "", tc.RemoteVAs)
+			if tc.Features != nil {
+				err := features.Set(tc.Features)
+				test.AssertNotError(t, err, "Failed to set feature flags")
+				defer features.Reset()
+			}
+
+			resp, err := va.IsCAAValid(ctx, &vapb.IsCAAValidRequest{Domain: &domain})
+			test.AssertNotError(t, err, "IsCAAValid failed")
+			test.AssertDeepEquals(t, resp.Problem, tc.ExpectedProb)
+
+			if tc.ExpectedLog != "" {
+				test.AssertEquals(t, len(mockLog.GetAllMatching(tc.ExpectedLog)), 1)
+			}
+		})
+	}
+}
+
+func TestCAARefusalDetails(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+	va.dnsClient = caaMockDNS{}
+
+	testCases := []struct {
+		Domain         string
+		ExpectedDetail string
+		ExpectedIodef  []string
+	}{
+		{
+			Domain:         "reserved.com",
+			ExpectedDetail: `CAA record for reserved.com prevents issuance: no issue record authorizes this request, found 0 issue "ca.com"`,
+		},
+		{
+			Domain:         "*.unsatisfiable-wildcard.com",
+			ExpectedDetail: `CAA record for *.unsatisfiable-wildcard.com prevents issuance: no issuewild record authorizes this request, found 0 issuewild ";"`,
+		},
+		{
+			Domain:         "unknown-critical.com",
+			ExpectedDetail: `CAA record for unknown-critical.com prevents issuance: unknown tag "foo" is flagged critical in record 128 foo "bar"`,
+		},
+		{
+			Domain:         "reserved-with-iodef.com",
+			ExpectedDetail: `CAA record for reserved-with-iodef.com prevents issuance: no issue record authorizes this request, found reserved-with-iodef.com. CAA 0 issue "ca.com"`,
+			ExpectedIodef: []string{
+				"mailto:security@reserved-with-iodef.com",
+				"https://reserved-with-iodef.com/caa-report",
+			},
+		},
+		{
+			Domain: "critical-issuemail.com",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Domain, func(t *testing.T) {
+			prob, iodef := va.checkCAA(ctx, core.AcmeIdentifier{Type: core.IdentifierDNS, Value: tc.Domain}, &caaParams{})
+			if tc.ExpectedDetail == "" {
+				test.Assert(t, prob == nil, fmt.Sprintf("unexpected problem: %s", prob))
+				return
+			}
+			test.AssertNotNil(t, prob, "expected a problem")
+			test.AssertEquals(t, prob.Type, probs.CAAProblem)
+			test.AssertEquals(t, prob.Detail, tc.ExpectedDetail)
+			test.AssertDeepEquals(t, iodef, tc.ExpectedIodef)
+		})
+	}
+
+	// The iodef targets are passed back from IsCAAValid for the RA to report
+	// to.
+	domain := "reserved-with-iodef.com"
+	resp, err := va.IsCAAValid(ctx, &vapb.IsCAAValidRequest{Domain: &domain})
+	test.AssertNotError(t, err, "IsCAAValid failed")
+	test.AssertNotNil(t, resp.Problem, "expected a problem")
+	test.AssertDeepEquals(t, resp.Iodef, []string{
+		"mailto:security@reserved-with-iodef.com",
+		"https://reserved-with-iodef.com/caa-report",
+	})
+}
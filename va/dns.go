@@ -15,8 +15,12 @@ import (
 // getAddr will query for all A/AAAA records associated with hostname and return
 // the preferred address, the first net.IP in the addrs slice, and all addresses
 // resolved. This is the same choice made by the Go internal resolution library
-// used by net/http.
+// used by net/http. If hostname is an IP address, as it is for IP identifiers,
+// it is returned as the only address without querying DNS.
 func (va ValidationAuthorityImpl) getAddrs(ctx context.Context, hostname string) ([]net.IP, *probs.ProblemDetails) {
+	if ip := net.ParseIP(hostname); ip != nil {
+		return []net.IP{ip}, nil
+	}
 	addrs, err := va.dnsClient.LookupHost(ctx, hostname)
 	if err != nil {
 		problem := probs.DNS("%v", err)
@@ -49,13 +53,48 @@ func (va *ValidationAuthorityImpl) validateDNS01(ctx context.Context, identifier
 		return nil, probs.Malformed("Identifier type for DNS was not itself DNS")
 	}
 
+	challengeSubdomain := fmt.Sprintf("%s.%s", core.DNSPrefix, identifier.Value)
+	return va.validateTXT(ctx, identifier, challengeSubdomain, challenge.ProvidedKeyAuthorization)
+}
+
+// validateDNSAccount01 validates a dns-account-01 challenge, whose TXT record
+// is published under a label derived from the URL of the account that owns the
+// authorization. The VA doesn't know which ACME API the account was created
+// through, so each of the configured account URI prefixes is tried in turn. If
+// none of them succeed the problem for the first prefix is returned.
+func (va *ValidationAuthorityImpl) validateDNSAccount01(ctx context.Context, identifier core.AcmeIdentifier, challenge core.Challenge, regID int64) ([]core.ValidationRecord, *probs.ProblemDetails) {
+	if identifier.Type != core.IdentifierDNS {
+		va.log.Infof("Identifier type for DNS challenge was not DNS: %s", identifier)
+		return nil, probs.Malformed("Identifier type for DNS was not itself DNS")
+	}
+	if len(va.accountURIPrefixes) == 0 {
+		return nil, probs.ServerInternal("No account URI prefixes configured for %s challenges", core.ChallengeTypeDNSAccount01)
+	}
+
+	var firstProb *probs.ProblemDetails
+	for _, prefix := range va.accountURIPrefixes {
+		accountURL := fmt.Sprintf("%s%d", prefix, regID)
+		challengeSubdomain := fmt.Sprintf("%s.%s.%s", core.DNSAccountLabel(accountURL), core.DNSPrefix, identifier.Value)
+		records, prob := va.validateTXT(ctx, identifier, challengeSubdomain, challenge.ProvidedKeyAuthorization)
+		if prob == nil {
+			return records, nil
+		}
+		if firstProb == nil {
+			firstProb = prob
+		}
+	}
+	return nil, firstProb
+}
+
+// validateTXT checks that one of the TXT records at challengeSubdomain is the
+// digest of the provided key authorization.
+func (va *ValidationAuthorityImpl) validateTXT(ctx context.Context, identifier core.AcmeIdentifier, challengeSubdomain string, keyAuthorization string) ([]core.ValidationRecord, *probs.ProblemDetails) {
 	// Compute the digest of the key authorization file
 	h := sha256.New()
-	h.Write([]byte(challenge.ProvidedKeyAuthorization))
+	h.Write([]byte(keyAuthorization))
 	authorizedKeysDigest := base64.RawURLEncoding.EncodeToString(h.Sum(nil))
 
 	// Look for the required record in the DNS
-	challengeSubdomain := fmt.Sprintf("%s.%s", core.DNSPrefix, identifier.Value)
 	txts, authorities, err := va.dnsClient.LookupTXT(ctx, challengeSubdomain)
 
 	if err != nil {
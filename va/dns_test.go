@@ -21,7 +21,7 @@ func TestDNSValidationEmpty(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
-	_, prob := va.PerformValidation(
+	_, _, prob := va.PerformValidation(
 		context.Background(),
 		"empty-txts.com",
 		chalDNS,
@@ -42,7 +42,7 @@ func TestDNSValidationWrong(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
-	_, prob := va.PerformValidation(
+	_, _, prob := va.PerformValidation(
 		context.Background(),
 		"wrong-dns01.com",
 		chalDNS,
@@ -57,7 +57,7 @@ func TestDNSValidationWrongMany(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
-	_, prob := va.PerformValidation(
+	_, _, prob := va.PerformValidation(
 		context.Background(),
 		"wrong-many-dns01.com",
 		chalDNS,
@@ -72,7 +72,7 @@ func TestDNSValidationWrongLong(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
-	_, prob := va.PerformValidation(
+	_, _, prob := va.PerformValidation(
 		context.Background(),
 		"long-dns01.com",
 		chalDNS,
@@ -88,7 +88,7 @@ func TestDNSValidationFailure(t *testing.T) {
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
 
-	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chalDNS)
+	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chalDNS, 1)
 
 	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
 }
@@ -104,7 +104,7 @@ func TestDNSValidationInvalid(t *testing.T) {
 
 	va, _ := setup(nil, 0, "", nil)
 
-	_, prob := va.validateChallenge(ctx, notDNS, chalDNS)
+	_, prob := va.validateChallenge(ctx, notDNS, chalDNS, 1)
 
 	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
 }
@@ -129,7 +129,7 @@ func TestDNSValidationNotSane(t *testing.T) {
 	}
 
 	for i := 0; i < len(authz.Challenges); i++ {
-		_, prob := va.validateChallenge(ctx, dnsi("localhost"), authz.Challenges[i])
+		_, prob := va.validateChallenge(ctx, dnsi("localhost"), authz.Challenges[i], 1)
 		if prob.Type != probs.MalformedProblem {
 			t.Errorf("Got wrong error type for %d: expected %s, got %s",
 				i, prob.Type, probs.MalformedProblem)
@@ -145,7 +145,7 @@ func TestDNSValidationServFail(t *testing.T) {
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
 
-	_, prob := va.validateChallenge(ctx, dnsi("servfail.com"), chalDNS)
+	_, prob := va.validateChallenge(ctx, dnsi("servfail.com"), chalDNS, 1)
 
 	test.AssertEquals(t, prob.Type, probs.DNSProblem)
 }
@@ -157,11 +157,12 @@ func TestDNSValidationNoServer(t *testing.T) {
 		nil,
 		metrics.NewNoopScope(),
 		clock.Default(),
-		1)
+		1,
+		nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
 
-	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chalDNS)
+	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chalDNS, 1)
 
 	test.AssertEquals(t, prob.Type, probs.DNSProblem)
 }
@@ -174,7 +175,7 @@ func TestDNSValidationOK(t *testing.T) {
 	chalDNS.Token = expectedToken
 	chalDNS.ProvidedKeyAuthorization = expectedKeyAuthorization
 
-	_, prob := va.validateChallenge(ctx, dnsi("good-dns01.com"), chalDNS)
+	_, prob := va.validateChallenge(ctx, dnsi("good-dns01.com"), chalDNS, 1)
 
 	test.Assert(t, prob == nil, "Should be valid.")
 }
@@ -188,11 +189,42 @@ func TestDNSValidationNoAuthorityOK(t *testing.T) {
 
 	chalDNS.ProvidedKeyAuthorization = expectedKeyAuthorization
 
-	_, prob := va.validateChallenge(ctx, dnsi("no-authority-dns01.com"), chalDNS)
+	_, prob := va.validateChallenge(ctx, dnsi("no-authority-dns01.com"), chalDNS, 1)
 
 	test.Assert(t, prob == nil, "Should be valid.")
 }
 
+func TestDNSAccountValidation(t *testing.T) {
+	va, _ := setup(nil, 0, "", nil)
+
+	// create a challenge with well known token
+	chalDNS := core.DNSAccountChallenge01("")
+	chalDNS.Token = expectedToken
+	chalDNS.ProvidedKeyAuthorization = expectedKeyAuthorization
+
+	records, prob := va.validateChallenge(ctx, dnsi("good-dns-account01.com"), chalDNS, 1)
+	test.Assert(t, prob == nil, "Should be valid.")
+	test.AssertEquals(t, records[0].Hostname, "good-dns-account01.com")
+
+	// Another account's label doesn't have the record
+	_, prob = va.validateChallenge(ctx, dnsi("good-dns-account01.com"), chalDNS, 2)
+	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
+	test.AssertContains(t, prob.Detail, "._acme-challenge.good-dns-account01.com")
+
+	// The record is still found when the account URI prefix it was made for
+	// isn't the first one configured
+	va.accountURIPrefixes = []string{"http://boulder:4001/acme/acct/", "http://boulder:4000/acme/reg/"}
+	_, prob = va.validateChallenge(ctx, dnsi("good-dns-account01.com"), chalDNS, 1)
+	test.Assert(t, prob == nil, "Should be valid.")
+
+	va.accountURIPrefixes = nil
+	_, prob = va.validateChallenge(ctx, dnsi("good-dns-account01.com"), chalDNS, 1)
+	test.AssertEquals(t, prob.Type, probs.ServerInternalProblem)
+
+	_, prob = va.validateChallenge(ctx, core.AcmeIdentifier{Type: core.IdentifierIP, Value: "10.1.2.3"}, chalDNS, 1)
+	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
+}
+
 func TestAvailableAddresses(t *testing.T) {
 	v6a := net.ParseIP("::1")
 	v6b := net.ParseIP("2001:db8::2:1") // 2001:DB8 is reserved for docs (RFC 3849)
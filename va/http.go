@@ -425,10 +425,15 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 		return nil, nil, err
 	}
 
-	// Create an initial GET Request
+	// Create an initial GET Request. An IPv6 address identifier has to be
+	// bracketed to be used as a URL host.
+	urlHost := host
+	if ip := net.ParseIP(host); ip != nil && ip.To4() == nil {
+		urlHost = "[" + host + "]"
+	}
 	initialURL := url.URL{
 		Scheme: "http",
-		Host:   host,
+		Host:   urlHost,
 		Path:   path,
 	}
 	initialReq, err := http.NewRequest("GET", initialURL.String(), nil)
@@ -598,9 +603,9 @@ func (va *ValidationAuthorityImpl) processHTTPValidation(
 }
 
 func (va *ValidationAuthorityImpl) validateHTTP01(ctx context.Context, identifier core.AcmeIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
-	if identifier.Type != core.IdentifierDNS {
-		va.log.Infof("Got non-DNS identifier for HTTP validation: %s", identifier)
-		return nil, probs.Malformed("Identifier type for HTTP validation was not DNS")
+	if identifier.Type != core.IdentifierDNS && identifier.Type != core.IdentifierIP {
+		va.log.Infof("Got non-DNS, non-IP identifier for HTTP validation: %s", identifier)
+		return nil, probs.Malformed("Identifier type for HTTP validation was not DNS or IP")
 	}
 
 	// Perform the fetch
@@ -1028,10 +1028,10 @@ func TestHTTP(t *testing.T) {
 	test.AssertEquals(t, len(matchedValidRedirect), 1)
 	test.AssertEquals(t, len(matchedMovedRedirect), 1)
 
-	ipIdentifier := core.AcmeIdentifier{Type: core.IdentifierType("ip"), Value: "127.0.0.1"}
-	_, prob = va.validateHTTP01(ctx, ipIdentifier, chall)
+	emailIdentifier := core.AcmeIdentifier{Type: core.IdentifierType("email"), Value: "root@localhost.com"}
+	_, prob = va.validateHTTP01(ctx, emailIdentifier, chall)
 	if prob == nil {
-		t.Fatalf("IdentifierType IP shouldn't have worked.")
+		t.Fatalf("IdentifierType email shouldn't have worked.")
 	}
 	test.AssertEquals(t, prob.Type, probs.MalformedProblem)
 
@@ -1267,10 +1267,28 @@ func TestValidateHTTP(t *testing.T) {
 
 	va, _ := setup(hs, 0, "", nil)
 
-	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall)
+	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall, 1)
 	test.Assert(t, prob == nil, "validation failed")
 }
 
+func TestValidateHTTPIP(t *testing.T) {
+	chall := core.HTTPChallenge01("")
+	setChallengeToken(&chall, core.NewToken())
+
+	hs := httpSrv(t, chall.Token)
+	defer hs.Close()
+
+	va, _ := setup(hs, 0, "", nil)
+
+	ipIdent := core.AcmeIdentifier{Type: core.IdentifierIP, Value: "127.0.0.1"}
+	records, prob := va.validateChallenge(ctx, ipIdent, chall, 1)
+	test.Assert(t, prob == nil, "validation failed")
+	test.AssertEquals(t, len(records), 1)
+	test.AssertEquals(t, records[0].Hostname, "127.0.0.1")
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+	test.AssertEquals(t, records[0].URL, fmt.Sprintf("http://127.0.0.1/.well-known/acme-challenge/%s", chall.Token))
+}
+
 func TestLimitedReader(t *testing.T) {
 	chall := core.HTTPChallenge01("")
 	setChallengeToken(&chall, core.NewToken())
@@ -1279,7 +1297,7 @@ func TestLimitedReader(t *testing.T) {
 	va, _ := setup(hs, 0, "", nil)
 	defer hs.Close()
 
-	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall)
+	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall, 1)
 
 	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
 	test.Assert(t, strings.HasPrefix(prob.Detail, "Invalid response from "),
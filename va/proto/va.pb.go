@@ -83,10 +83,13 @@ func (m *IsCAAValidRequest) GetAccountURIID() int64 {
 
 // If CAA is valid for the requested domain, the problem will be empty
 type IsCAAValidResponse struct {
-	Problem              *proto1.ProblemDetails `protobuf:"bytes,1,opt,name=problem" json:"problem,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
-	XXX_unrecognized     []byte                 `json:"-"`
-	XXX_sizecache        int32                  `json:"-"`
+	Problem *proto1.ProblemDetails `protobuf:"bytes,1,opt,name=problem" json:"problem,omitempty"`
+	// If CAA prevents issuance, iodef holds the mailto, http and https URLs from
+	// any iodef records, to which the refusal may be reported.
+	Iodef                []string `protobuf:"bytes,2,rep,name=iodef" json:"iodef,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *IsCAAValidResponse) Reset()         { *m = IsCAAValidResponse{} }
@@ -121,6 +124,13 @@ func (m *IsCAAValidResponse) GetProblem() *proto1.ProblemDetails {
 	return nil
 }
 
+func (m *IsCAAValidResponse) GetIodef() []string {
+	if m != nil {
+		return m.Iodef
+	}
+	return nil
+}
+
 type PerformValidationRequest struct {
 	Domain               *string           `protobuf:"bytes,1,opt,name=domain" json:"domain,omitempty"`
 	Challenge            *proto1.Challenge `protobuf:"bytes,2,opt,name=challenge" json:"challenge,omitempty"`
@@ -224,11 +234,14 @@ func (m *AuthzMeta) GetRegID() int64 {
 }
 
 type ValidationResult struct {
-	Records              []*proto1.ValidationRecord `protobuf:"bytes,1,rep,name=records" json:"records,omitempty"`
-	Problems             *proto1.ProblemDetails     `protobuf:"bytes,2,opt,name=problems" json:"problems,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
-	XXX_unrecognized     []byte                     `json:"-"`
-	XXX_sizecache        int32                      `json:"-"`
+	Records  []*proto1.ValidationRecord `protobuf:"bytes,1,rep,name=records" json:"records,omitempty"`
+	Problems *proto1.ProblemDetails     `protobuf:"bytes,2,opt,name=problems" json:"problems,omitempty"`
+	// If CAA prevented issuance, iodef holds the mailto, http and https URLs
+	// from any iodef records, as in IsCAAValidResponse.
+	Iodef                []string `protobuf:"bytes,3,rep,name=iodef" json:"iodef,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *ValidationResult) Reset()         { *m = ValidationResult{} }
@@ -270,6 +283,13 @@ func (m *ValidationResult) GetProblems() *proto1.ProblemDetails {
 	return nil
 }
 
+func (m *ValidationResult) GetIodef() []string {
+	if m != nil {
+		return m.Iodef
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*IsCAAValidRequest)(nil), "va.IsCAAValidRequest")
 	proto.RegisterType((*IsCAAValidResponse)(nil), "va.IsCAAValidResponse")
@@ -281,31 +301,33 @@ func init() {
 func init() { proto.RegisterFile("va/proto/va.proto", fileDescriptor_b39cc52ec1cb3a92) }
 
 var fileDescriptor_b39cc52ec1cb3a92 = []byte{
-	// 378 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0x3b, 0x6f, 0xea, 0x30,
-	0x18, 0x25, 0x89, 0xb8, 0x5c, 0xbe, 0xdc, 0x07, 0x58, 0x80, 0x22, 0xd4, 0x01, 0xb9, 0x0b, 0xaa,
-	0xd4, 0x40, 0xb3, 0x77, 0x48, 0xc9, 0x92, 0x01, 0x15, 0x59, 0x2a, 0x43, 0x37, 0x37, 0x71, 0x21,
-	0x52, 0x88, 0xa9, 0xed, 0x64, 0x68, 0xf7, 0xfe, 0xee, 0x2a, 0x4e, 0x78, 0x14, 0x5a, 0x75, 0xb3,
-	0xcf, 0x39, 0xd2, 0x79, 0xd8, 0xd0, 0x2d, 0xe8, 0x64, 0x2b, 0xb8, 0xe2, 0x93, 0x82, 0xba, 0xfa,
-	0x80, 0xcc, 0x82, 0x0e, 0xfb, 0x11, 0x17, 0xac, 0x26, 0xca, 0x63, 0x45, 0xe1, 0x37, 0xe8, 0x86,
-	0x72, 0xe6, 0xfb, 0x4b, 0x9a, 0x26, 0x31, 0x61, 0x2f, 0x39, 0x93, 0x0a, 0x0d, 0xe0, 0x57, 0xcc,
-	0x37, 0x34, 0xc9, 0x1c, 0x63, 0x64, 0x8c, 0xdb, 0xa4, 0xbe, 0xa1, 0x2b, 0xe8, 0x14, 0xa5, 0x8e,
-	0xaa, 0x84, 0x67, 0x73, 0xa6, 0xd6, 0x3c, 0x76, 0x4c, 0xad, 0x38, 0xc3, 0x11, 0x86, 0x3f, 0x34,
-	0x8a, 0x78, 0x9e, 0xa9, 0x07, 0x12, 0x86, 0x81, 0x63, 0x8d, 0x8c, 0xb1, 0x45, 0x3e, 0x61, 0x38,
-	0x00, 0x74, 0x6c, 0x2e, 0xb7, 0x3c, 0x93, 0x0c, 0xb9, 0xd0, 0xda, 0x0a, 0xfe, 0x94, 0xb2, 0x8d,
-	0xb6, 0xb7, 0xbd, 0x9e, 0xab, 0x03, 0x2f, 0x2a, 0x30, 0x60, 0x8a, 0x26, 0xa9, 0x24, 0x3b, 0x11,
-	0x7e, 0x37, 0xc0, 0x59, 0x30, 0xf1, 0xcc, 0xc5, 0x66, 0xb9, 0x4f, 0xf1, 0x53, 0x95, 0x6b, 0x68,
-	0x47, 0x6b, 0x9a, 0xa6, 0x2c, 0x5b, 0x31, 0xdd, 0xc1, 0xf6, 0xfe, 0x57, 0x36, 0xb3, 0x1d, 0x4c,
-	0x0e, 0x0a, 0x74, 0x09, 0x4d, 0x9a, 0xab, 0xf5, 0xab, 0xae, 0x61, 0x7b, 0x7f, 0xdd, 0x82, 0xba,
-	0x7e, 0x09, 0xcc, 0x99, 0xa2, 0xa4, 0xe2, 0xf0, 0x0d, 0xb4, 0xf7, 0x18, 0xfa, 0x07, 0x66, 0x12,
-	0xd7, 0xa6, 0x66, 0x12, 0xa3, 0x1e, 0x34, 0x05, 0x5b, 0x85, 0x81, 0x36, 0xb3, 0x48, 0x75, 0xc1,
-	0x05, 0x74, 0x8e, 0x33, 0xcb, 0x3c, 0x55, 0x68, 0x0a, 0x2d, 0xc1, 0x22, 0x2e, 0x62, 0xe9, 0x18,
-	0x23, 0x6b, 0x6c, 0x7b, 0x83, 0x2a, 0xd8, 0xb1, 0xb0, 0xa4, 0xc9, 0x4e, 0x86, 0xa6, 0xf0, 0xbb,
-	0x1e, 0x43, 0xd6, 0x5d, 0xbe, 0x9e, 0x6c, 0xaf, 0xf2, 0xee, 0xc1, 0x5c, 0xfa, 0x28, 0x84, 0xee,
-	0xd9, 0x70, 0xe8, 0xa2, 0xec, 0xf6, 0xdd, 0x9e, 0xc3, 0x5e, 0xc9, 0x9e, 0x46, 0xc6, 0x0d, 0x2f,
-	0x00, 0x6b, 0xe6, 0xfb, 0xe8, 0x16, 0xe0, 0xf0, 0xa2, 0xa8, 0x5f, 0x8a, 0xcf, 0xbe, 0xd7, 0x70,
-	0x70, 0x0a, 0x57, 0x0f, 0x8f, 0x1b, 0x77, 0xad, 0xc7, 0xa6, 0xfe, 0x96, 0x1f, 0x01, 0x00, 0x00,
-	0xff, 0xff, 0x63, 0xcf, 0xa9, 0x6d, 0xc5, 0x02, 0x00, 0x00,
+	// 402 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x84, 0x52, 0xbf, 0x8f, 0xd3, 0x30,
+	0x14, 0xbe, 0x24, 0x2a, 0x25, 0x2f, 0xfc, 0xb8, 0x5a, 0xbd, 0x2a, 0xaa, 0x18, 0x22, 0xb3, 0x44,
+	0x48, 0xe4, 0x8e, 0xec, 0x0c, 0xa1, 0x59, 0x32, 0x9c, 0x38, 0x59, 0xa2, 0xc3, 0x6d, 0x26, 0xf1,
+	0xb5, 0x91, 0xd2, 0xb8, 0xd8, 0x4e, 0x06, 0xd8, 0x99, 0xf8, 0xa3, 0x91, 0xed, 0xb4, 0x0d, 0xad,
+	0x80, 0xcd, 0xef, 0x7b, 0x9f, 0xf4, 0xfd, 0xf0, 0x83, 0x59, 0x4f, 0x6f, 0xf7, 0x82, 0x2b, 0x7e,
+	0xdb, 0xd3, 0xc4, 0x3c, 0x90, 0xdb, 0xd3, 0xe5, 0x4d, 0xc9, 0x05, 0x1b, 0x16, 0xfa, 0x69, 0x57,
+	0xf8, 0x07, 0xcc, 0x0a, 0xb9, 0xca, 0xb2, 0x35, 0x6d, 0xea, 0x8a, 0xb0, 0x6f, 0x1d, 0x93, 0x0a,
+	0x2d, 0xe0, 0x59, 0xc5, 0x77, 0xb4, 0x6e, 0x43, 0x27, 0x72, 0x62, 0x9f, 0x0c, 0x13, 0x7a, 0x07,
+	0xd7, 0xbd, 0xe6, 0x51, 0x55, 0xf3, 0xf6, 0x9e, 0xa9, 0x2d, 0xaf, 0x42, 0xd7, 0x30, 0x2e, 0x70,
+	0x84, 0xe1, 0x05, 0x2d, 0x4b, 0xde, 0xb5, 0xea, 0x0b, 0x29, 0x8a, 0x3c, 0xf4, 0x22, 0x27, 0xf6,
+	0xc8, 0x1f, 0x18, 0x7e, 0x04, 0x34, 0x16, 0x97, 0x7b, 0xde, 0x4a, 0x86, 0x12, 0x98, 0xee, 0x05,
+	0xff, 0xda, 0xb0, 0x9d, 0x91, 0x0f, 0xd2, 0x79, 0x62, 0x0c, 0x3f, 0x58, 0x30, 0x67, 0x8a, 0xd6,
+	0x8d, 0x24, 0x07, 0x12, 0x9a, 0xc3, 0xa4, 0xe6, 0x15, 0x7b, 0x0a, 0xdd, 0xc8, 0x8b, 0x7d, 0x62,
+	0x07, 0xfc, 0xd3, 0x81, 0xf0, 0x81, 0x89, 0x27, 0x2e, 0x76, 0xeb, 0xa3, 0xb7, 0xff, 0x05, 0x7c,
+	0x0f, 0x7e, 0xb9, 0xa5, 0x4d, 0xc3, 0xda, 0x0d, 0x33, 0xc9, 0x82, 0xf4, 0xb5, 0x15, 0x5f, 0x1d,
+	0x60, 0x72, 0x62, 0xa0, 0xb7, 0x30, 0xa1, 0x9d, 0xda, 0x7e, 0x37, 0xe1, 0x82, 0xf4, 0x65, 0xd2,
+	0xd3, 0x24, 0xd3, 0xc0, 0x3d, 0x53, 0x94, 0xd8, 0x1d, 0xfe, 0x00, 0xfe, 0x11, 0x43, 0xaf, 0xc0,
+	0xad, 0xab, 0x41, 0xd4, 0xad, 0x2b, 0xed, 0x5d, 0xb0, 0x4d, 0x91, 0x1b, 0x31, 0x8f, 0xd8, 0x01,
+	0xff, 0x72, 0xe0, 0x7a, 0x6c, 0x5a, 0x76, 0x8d, 0x42, 0x77, 0x30, 0x15, 0xac, 0xe4, 0xa2, 0x92,
+	0xa1, 0x13, 0x79, 0x71, 0x90, 0x2e, 0xac, 0xb3, 0x31, 0x51, 0xaf, 0xc9, 0x81, 0x86, 0xee, 0xe0,
+	0xf9, 0xd0, 0x91, 0x0c, 0xdd, 0x7f, 0x34, 0x79, 0x64, 0x9d, 0xaa, 0xf4, 0x46, 0x55, 0xa6, 0x9f,
+	0xc1, 0x5d, 0x67, 0xa8, 0x80, 0xd9, 0x45, 0x9f, 0xe8, 0x8d, 0x8e, 0xfc, 0xb7, 0x9a, 0x97, 0x73,
+	0xbd, 0x3d, 0x0f, 0x82, 0xaf, 0xd2, 0x1c, 0xbc, 0x55, 0x96, 0xa1, 0x8f, 0x00, 0xa7, 0xef, 0x47,
+	0x37, 0x9a, 0x7c, 0x71, 0x8b, 0xcb, 0xc5, 0x39, 0x6c, 0xaf, 0x04, 0x5f, 0x7d, 0x9a, 0x3e, 0x4e,
+	0xcc, 0x0d, 0xff, 0x06, 0x00, 0x00, 0xff, 0xff, 0x03, 0x00, 0x64, 0x40, 0x81, 0x07, 0xf2, 0x02,
+	0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
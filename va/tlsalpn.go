@@ -15,6 +15,7 @@ import (
 
 	"github.com/letsencrypt/boulder/core"
 	"github.com/letsencrypt/boulder/probs"
+	"github.com/miekg/dns"
 )
 
 const (
@@ -50,6 +51,9 @@ func certNames(cert *x509.Certificate) []string {
 		names = append(names, cert.Subject.CommonName)
 	}
 	names = append(names, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		names = append(names, ip.String())
+	}
 	names = core.UniqueLowerNames(names)
 	for i, n := range names {
 		names[i] = replaceInvalidUTF8([]byte(n))
@@ -177,15 +181,45 @@ func (va *ValidationAuthorityImpl) tlsDial(ctx context.Context, hostPort string,
 	return conn, nil
 }
 
+// tlsALPNServerName returns the SNI value to send when validating identifier.
+// For an IP identifier this is the reverse mapping name of the address, e.g.
+// "4.3.2.1.in-addr.arpa" for 1.2.3.4, as specified in RFC 8738 section 6.
+func tlsALPNServerName(identifier core.AcmeIdentifier) (string, error) {
+	if identifier.Type != core.IdentifierIP {
+		return identifier.Value, nil
+	}
+	name, err := dns.ReverseAddr(identifier.Value)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(name, "."), nil
+}
+
+// tlsALPNCertMatches checks that a TLS-ALPN-01 validation certificate was
+// issued only for the identifier being validated: a single dNSName for a DNS
+// identifier, or a single iPAddress and no dNSNames for an IP identifier.
+func tlsALPNCertMatches(cert *x509.Certificate, identifier core.AcmeIdentifier) bool {
+	if identifier.Type == core.IdentifierIP {
+		return len(cert.DNSNames) == 0 && len(cert.IPAddresses) == 1 &&
+			cert.IPAddresses[0].Equal(net.ParseIP(identifier.Value))
+	}
+	return len(cert.DNSNames) == 1 && strings.EqualFold(cert.DNSNames[0], identifier.Value)
+}
+
 func (va *ValidationAuthorityImpl) validateTLSALPN01(ctx context.Context, identifier core.AcmeIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
-	if identifier.Type != "dns" {
-		va.log.Info(fmt.Sprintf("Identifier type for TLS-ALPN-01 was not DNS: %s", identifier))
-		return nil, probs.Malformed("Identifier type for TLS-ALPN-01 was not DNS")
+	if identifier.Type != core.IdentifierDNS && identifier.Type != core.IdentifierIP {
+		va.log.Info(fmt.Sprintf("Identifier type for TLS-ALPN-01 was not DNS or IP: %s", identifier))
+		return nil, probs.Malformed("Identifier type for TLS-ALPN-01 was not DNS or IP")
+	}
+
+	serverName, err := tlsALPNServerName(identifier)
+	if err != nil {
+		return nil, probs.Malformed("Invalid IP address %q for TLS-ALPN-01", identifier.Value)
 	}
 
 	certs, cs, validationRecords, problem := va.tryGetTLSCerts(ctx, identifier, challenge, &tls.Config{
 		NextProtos: []string{ACMETLS1Protocol},
-		ServerName: identifier.Value,
+		ServerName: serverName,
 	})
 	if problem != nil {
 		return validationRecords, problem
@@ -202,8 +236,8 @@ func (va *ValidationAuthorityImpl) validateTLSALPN01(ctx context.Context, identi
 
 	leafCert := certs[0]
 
-	// Verify SNI - certificate returned must be issued only for the domain we are verifying.
-	if len(leafCert.DNSNames) != 1 || !strings.EqualFold(leafCert.DNSNames[0], identifier.Value) {
+	// Verify SNI - certificate returned must be issued only for the identifier we are verifying.
+	if !tlsALPNCertMatches(leafCert, identifier) {
 		hostPort := net.JoinHostPort(validationRecords[0].AddressUsed.String(), validationRecords[0].Port)
 		names := certNames(leafCert)
 		errText := fmt.Sprintf(
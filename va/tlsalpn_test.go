@@ -69,7 +69,12 @@ func tlssniSrvWithNames(t *testing.T, chall core.Challenge, names ...string) *ht
 }
 
 func tlsalpn01Srv(t *testing.T, chall core.Challenge, oid asn1.ObjectIdentifier, names ...string) *httptest.Server {
-	template := tlsCertTemplate(names)
+	return tlsalpn01SrvWithTemplate(t, chall, oid, names[0], tlsCertTemplate(names))
+}
+
+// tlsalpn01SrvWithTemplate serves certificates made from template to clients
+// that send serverName in their SNI.
+func tlsalpn01SrvWithTemplate(t *testing.T, chall core.Challenge, oid asn1.ObjectIdentifier, serverName string, template *x509.Certificate) *httptest.Server {
 	certBytes, _ := x509.CreateCertificate(rand.Reader, template, template, &TheKey.PublicKey, &TheKey)
 	cert := &tls.Certificate{
 		Certificate: [][]byte{certBytes},
@@ -95,7 +100,7 @@ func tlsalpn01Srv(t *testing.T, chall core.Challenge, oid asn1.ObjectIdentifier,
 		Certificates: []tls.Certificate{},
 		ClientAuth:   tls.NoClientCert,
 		GetCertificate: func(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
-			if clientHello.ServerName != names[0] {
+			if clientHello.ServerName != serverName {
 				return nil, nil
 			}
 			if len(clientHello.SupportedProtos) == 1 && clientHello.SupportedProtos[0] == ACMETLS1Protocol {
@@ -332,7 +337,7 @@ func TestTLSALPN01Success(t *testing.T) {
 
 	va, _ := setup(hs, 0, "", nil)
 
-	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall)
+	_, prob := va.validateChallenge(ctx, dnsi("localhost"), chall, 1)
 	if prob != nil {
 		t.Errorf("Validation failed: %v", prob)
 	}
@@ -344,13 +349,70 @@ func TestTLSALPN01Success(t *testing.T) {
 
 	va, _ = setup(hs, 0, "", nil)
 
-	_, prob = va.validateChallenge(ctx, dnsi("localhost"), chall)
+	_, prob = va.validateChallenge(ctx, dnsi("localhost"), chall, 1)
 	if prob != nil {
 		t.Errorf("Validation failed: %v", prob)
 	}
 	test.AssertEquals(t, test.CountCounterVec("oid", IdPeAcmeIdentifierV1Obsolete.String(), va.metrics.tlsALPNOIDCounter), 1)
 }
 
+func TestTLSALPN01SuccessIP(t *testing.T) {
+	chall := createChallenge(core.ChallengeTypeTLSALPN01)
+	template := tlsCertTemplate(nil)
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.1")}
+	hs := tlsalpn01SrvWithTemplate(t, chall, IdPeAcmeIdentifier, "1.0.0.127.in-addr.arpa", template)
+	defer hs.Close()
+
+	va, _ := setup(hs, 0, "", nil)
+
+	ipIdent := core.AcmeIdentifier{Type: core.IdentifierIP, Value: "127.0.0.1"}
+	records, prob := va.validateChallenge(ctx, ipIdent, chall, 1)
+	if prob != nil {
+		t.Fatalf("Validation failed: %v", prob)
+	}
+	test.AssertEquals(t, records[0].AddressUsed.String(), "127.0.0.1")
+}
+
+func TestTLSALPN01WrongIP(t *testing.T) {
+	chall := createChallenge(core.ChallengeTypeTLSALPN01)
+	template := tlsCertTemplate(nil)
+	template.IPAddresses = []net.IP{net.ParseIP("127.0.0.2")}
+	hs := tlsalpn01SrvWithTemplate(t, chall, IdPeAcmeIdentifier, "1.0.0.127.in-addr.arpa", template)
+	defer hs.Close()
+
+	va, _ := setup(hs, 0, "", nil)
+
+	ipIdent := core.AcmeIdentifier{Type: core.IdentifierIP, Value: "127.0.0.1"}
+	_, prob := va.validateChallenge(ctx, ipIdent, chall, 1)
+	if prob == nil {
+		t.Fatalf("Validation succeeded with a certificate for the wrong IP address")
+	}
+	test.AssertEquals(t, prob.Type, probs.UnauthorizedProblem)
+	test.AssertContains(t, prob.Detail, "127.0.0.2")
+}
+
+func TestTLSALPNServerName(t *testing.T) {
+	testCases := []struct {
+		ident    core.AcmeIdentifier
+		expected string
+	}{
+		{dnsi("example.com"), "example.com"},
+		{core.AcmeIdentifier{Type: core.IdentifierIP, Value: "10.1.2.3"}, "3.2.1.10.in-addr.arpa"},
+		{
+			core.AcmeIdentifier{Type: core.IdentifierIP, Value: "2001:db8::1"},
+			"1.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.0.8.b.d.0.1.0.0.2.ip6.arpa",
+		},
+	}
+	for _, tc := range testCases {
+		name, err := tlsALPNServerName(tc.ident)
+		test.AssertNotError(t, err, "tlsALPNServerName failed")
+		test.AssertEquals(t, name, tc.expected)
+	}
+
+	_, err := tlsALPNServerName(core.AcmeIdentifier{Type: core.IdentifierIP, Value: "10.1.2"})
+	test.AssertError(t, err, "tlsALPNServerName accepted a malformed IP address")
+}
+
 func TestValidateTLSALPN01BadChallenge(t *testing.T) {
 	chall := createChallenge(core.ChallengeTypeTLSALPN01)
 	chall2 := chall
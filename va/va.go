@@ -25,15 +25,23 @@ import (
 	blog "github.com/letsencrypt/boulder/log"
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/probs"
+	vapb "github.com/letsencrypt/boulder/va/proto"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 )
 
-// RemoteVA wraps the core.ValidationAuthority interface and adds a field containing the addresses
+// RemoteClients is the interface through which the primary VA reaches a remote
+// VA, both to validate challenges and to check CAA.
+type RemoteClients interface {
+	core.ValidationAuthority
+	IsCAAValid(ctx context.Context, req *vapb.IsCAAValidRequest) (*vapb.IsCAAValidResponse, error)
+}
+
+// RemoteVA wraps the RemoteClients interface and adds a field containing the addresses
 // of the remote gRPC server since the interface (and the underlying gRPC client) doesn't
 // provide a way to extract this metadata which is useful for debugging gRPC connection issues.
 type RemoteVA struct {
-	core.ValidationAuthority
+	RemoteClients
 	Addresses string
 }
 
@@ -42,6 +50,7 @@ type vaMetrics struct {
 	remoteValidationTime                *prometheus.HistogramVec
 	remoteValidationFailures            prometheus.Counter
 	prospectiveRemoteValidationFailures prometheus.Counter
+	remoteCAAFailures                   prometheus.Counter
 	tlsALPNOIDCounter                   *prometheus.CounterVec
 	http01Fallbacks                     prometheus.Counter
 	http01Redirects                     prometheus.Counter
@@ -76,6 +85,12 @@ func initMetrics(stats metrics.Scope) *vaMetrics {
 			Help: "Number of validations that would have failed due to remote VAs returning failure if consesus were enforced",
 		})
 	stats.MustRegister(prospectiveRemoteValidationFailures)
+	remoteCAAFailures := prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "remote_caa_failures",
+			Help: "Number of CAA checks failed due to remote VAs returning failure when consensus is enforced",
+		})
+	stats.MustRegister(remoteCAAFailures)
 	tlsALPNOIDCounter := prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "tls_alpn_oid_usage",
@@ -102,6 +117,7 @@ func initMetrics(stats metrics.Scope) *vaMetrics {
 		remoteValidationTime:                remoteValidationTime,
 		remoteValidationFailures:            remoteValidationFailures,
 		prospectiveRemoteValidationFailures: prospectiveRemoteValidationFailures,
+		remoteCAAFailures:                   remoteCAAFailures,
 		tlsALPNOIDCounter:                   tlsALPNOIDCounter,
 		http01Fallbacks:                     http01Fallbacks,
 		http01Redirects:                     http01Redirects,
@@ -239,13 +255,14 @@ func detailedError(err error) *probs.ProblemDetails {
 // validate performs a challenge validation and, in parallel,
 // checks CAA and GSB for the identifier. If any of those steps fails, it
 // returns a ProblemDetails plus the validation records created during the
-// validation attempt.
+// validation attempt. If CAA prevents issuance the targets of the domain's
+// iodef records are returned too.
 func (va *ValidationAuthorityImpl) validate(
 	ctx context.Context,
 	identifier core.AcmeIdentifier,
 	challenge core.Challenge,
 	authz core.Authorization,
-) ([]core.ValidationRecord, *probs.ProblemDetails) {
+) ([]core.ValidationRecord, []string, *probs.ProblemDetails) {
 
 	// If the identifier is a wildcard domain we need to validate the base
 	// domain by removing the "*." wildcard prefix. We create a separate
@@ -259,30 +276,35 @@ func (va *ValidationAuthorityImpl) validate(
 	// va.checkCAA accepts wildcard identifiers and handles them appropriately so
 	// we can dispatch `checkCAA` with the provided `identifier` instead of
 	// `baseIdentifier`
-	ch := make(chan *probs.ProblemDetails, 1)
+	type caaOutcome struct {
+		prob  *probs.ProblemDetails
+		iodef []string
+	}
+	ch := make(chan caaOutcome, 1)
 	go func() {
 		params := &caaParams{
 			accountURIID:     &authz.RegistrationID,
 			validationMethod: &challenge.Type,
 		}
-		ch <- va.checkCAA(ctx, identifier, params)
+		prob, iodef := va.checkCAA(ctx, identifier, params)
+		ch <- caaOutcome{prob, iodef}
 	}()
 
 	// TODO(#1292): send into another goroutine
-	validationRecords, err := va.validateChallenge(ctx, baseIdentifier, challenge)
+	validationRecords, err := va.validateChallenge(ctx, baseIdentifier, challenge, authz.RegistrationID)
 	if err != nil {
-		return validationRecords, err
+		return validationRecords, nil, err
 	}
 
 	for i := 0; i < cap(ch); i++ {
-		if extraProblem := <-ch; extraProblem != nil {
-			return validationRecords, extraProblem
+		if result := <-ch; result.prob != nil {
+			return validationRecords, result.iodef, result.prob
 		}
 	}
-	return validationRecords, nil
+	return validationRecords, nil, nil
 }
 
-func (va *ValidationAuthorityImpl) validateChallenge(ctx context.Context, identifier core.AcmeIdentifier, challenge core.Challenge) ([]core.ValidationRecord, *probs.ProblemDetails) {
+func (va *ValidationAuthorityImpl) validateChallenge(ctx context.Context, identifier core.AcmeIdentifier, challenge core.Challenge, regID int64) ([]core.ValidationRecord, *probs.ProblemDetails) {
 	if err := challenge.CheckConsistencyForValidation(); err != nil {
 		return nil, probs.Malformed("Challenge failed consistency check: %s", err)
 	}
@@ -293,6 +315,8 @@ func (va *ValidationAuthorityImpl) validateChallenge(ctx context.Context, identi
 		return va.validateDNS01(ctx, identifier, challenge)
 	case core.ChallengeTypeTLSALPN01:
 		return va.validateTLSALPN01(ctx, identifier, challenge)
+	case core.ChallengeTypeDNSAccount01:
+		return va.validateDNSAccount01(ctx, identifier, challenge, regID)
 	}
 	return nil, probs.Malformed("invalid challenge type %s", challenge.Type)
 }
@@ -314,7 +338,7 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 	for _, i := range rand.Perm(len(va.remoteVAs)) {
 		remoteVA := va.remoteVAs[i]
 		go func(rva RemoteVA, index int) {
-			_, err := rva.PerformValidation(ctx, domain, challenge, authz)
+			_, _, err := rva.PerformValidation(ctx, domain, challenge, authz)
 			if err != nil {
 				// returned error can be a nil *probs.ProblemDetails which breaks the
 				// err != nil check so do a slightly more complicated unwrap check to
@@ -356,27 +380,29 @@ func (va *ValidationAuthorityImpl) performRemoteValidation(
 }
 
 // processRemoteResults evaluates a primary VA result, and a channel of remote
-// VA problems to produce a single overall validation result based on configured
-// feature flags. The overall result is calculated based on the VA's configured
-// `maxRemoteFailures` value.
+// VA problems to produce a single overall validation result. The overall
+// result is calculated based on the VA's configured `maxRemoteFailures` value.
+// It is used both for challenge validations, where `challengeType` is the type
+// of the challenge, and for CAA checks, where it is `caaCheckType`.
 //
-// If the `MultiVAFullResults` feature is enabled then `processRemoteResults`
-// will expect to read a result from the `remoteErrors` channel for each VA and
-// will not produce an overall result until all remote VAs have responded. In
-// this case `logRemoteFailureDifferentials` will also be called to describe the
+// If `fullResults` is true (see the `MultiVAFullResults` and
+// `MultiCAAFullResults` feature flags) then `processRemoteResults` will expect
+// to read a result from the `remoteErrors` channel for each VA and will not
+// produce an overall result until all remote VAs have responded. In this case
+// `logRemoteValidationDifferentials` will also be called to describe the
 // differential between the primary and all of the remote VAs.
 //
-// If the `MultiVAFullResults` feature flag is not enabled then
-// `processRemoteResults` will potentially return before all remote VAs have had
-// a chance to respond. This happens if the success or failure threshold is met.
-// This doesn't allow for logging the differential between the primary and
-// remote VAs but is more performant.
+// If `fullResults` is false then `processRemoteResults` will potentially
+// return before all remote VAs have had a chance to respond. This happens if
+// the success or failure threshold is met. This doesn't allow for logging the
+// differential between the primary and remote VAs but is more performant.
 func (va *ValidationAuthorityImpl) processRemoteResults(
 	domain string,
 	challengeType string,
 	primaryResult *probs.ProblemDetails,
 	remoteErrors chan *probs.ProblemDetails,
-	numRemoteVAs int) *probs.ProblemDetails {
+	numRemoteVAs int,
+	fullResults bool) *probs.ProblemDetails {
 
 	state := "failure"
 	start := va.clk.Now()
@@ -406,15 +432,15 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 			bad++
 		}
 
-		// Store the first non-nil problem to return later (if `MultiVAFullResults`
-		// is enabled).
+		// Store the first non-nil problem to return later (if `fullResults` is
+		// true).
 		if firstProb == nil && prob != nil {
 			firstProb = prob
 		}
 
-		// If MultiVAFullResults isn't enabled then return early whenever the
-		// success or failure threshold is met.
-		if !features.Enabled(features.MultiVAFullResults) {
+		// If fullResults isn't true then return early whenever the success or
+		// failure threshold is met.
+		if !fullResults {
 			if good >= required {
 				state = "success"
 				return nil
@@ -423,17 +449,17 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 			}
 		}
 
-		// If we haven't returned early because of MultiVAFullResults being enabled
-		// we need to break the loop once all of the VAs have returned a result.
+		// If we haven't returned early because of fullResults being true we need
+		// to break the loop once all of the VAs have returned a result.
 		if len(remoteProbs) == numRemoteVAs {
 			break
 		}
 	}
 
-	// If we are using `features.MultiVAFullResults` then we haven't returned
-	// early and can now log the differential between what the primary VA saw and
-	// what all of the remote VAs saw.
-	va.logRemoteValidationDifferentials(domain, primaryResult, remoteProbs)
+	// If we are using `fullResults` then we haven't returned early and can now
+	// log the differential between what the primary VA saw and what all of the
+	// remote VAs saw.
+	va.logRemoteValidationDifferentials(domain, challengeType, primaryResult, remoteProbs)
 
 	// Based on the threshold of good/bad return nil or a problem.
 	if good >= required {
@@ -445,14 +471,18 @@ func (va *ValidationAuthorityImpl) processRemoteResults(
 
 	// This condition should not occur - it indicates the good/bad counts didn't
 	// meet either the required threshold or the maxRemoteFailures threshold.
+	if challengeType == caaCheckType {
+		return probs.ServerInternal("Too few remote IsCAAValid RPC results")
+	}
 	return probs.ServerInternal("Too few remote PerformValidation RPC results")
 }
 
-// logRemoteValidationDifferentials is called by `processRemoteResults` when the
-// `MultiVAFullResults` feature flag is enabled. It produces a JSON log line
-// that contains the primary VA result and the results each remote VA returned.
+// logRemoteValidationDifferentials is called by `processRemoteResults` when
+// full results were requested. It produces a JSON log line that contains the
+// primary VA result and the results each remote VA returned.
 func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 	domain string,
+	challengeType string,
 	primaryResult *probs.ProblemDetails,
 	remoteProbs []*probs.ProblemDetails) {
 
@@ -485,11 +515,13 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 
 	logOb := struct {
 		Domain          string
+		ChallengeType   string
 		PrimaryResult   *probs.ProblemDetails
 		RemoteSuccesses int
 		RemoteFailures  []*probs.ProblemDetails
 	}{
 		Domain:          domain,
+		ChallengeType:   challengeType,
 		PrimaryResult:   primaryResult,
 		RemoteSuccesses: len(successes),
 		RemoteFailures:  failures,
@@ -509,8 +541,9 @@ func (va *ValidationAuthorityImpl) logRemoteValidationDifferentials(
 }
 
 // PerformValidation validates the given challenge. It always returns a list of
-// validation records, even when it also returns an error.
-func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain string, challenge core.Challenge, authz core.Authorization) ([]core.ValidationRecord, error) {
+// validation records, even when it also returns an error. If CAA prevents
+// issuance the targets of the domain's iodef records are returned too.
+func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain string, challenge core.Challenge, authz core.Authorization) ([]core.ValidationRecord, []string, error) {
 	logEvent := verificationRequestEvent{
 		ID:        authz.ID,
 		Requester: authz.RegistrationID,
@@ -524,7 +557,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain
 		go va.performRemoteValidation(ctx, domain, challenge, authz, remoteProbs)
 	}
 
-	records, prob := va.validate(ctx, core.AcmeIdentifier{Type: "dns", Value: domain}, challenge, authz)
+	records, iodef, prob := va.validate(ctx, core.IdentifierForName(domain), challenge, authz)
 	challenge.ValidationRecord = records
 
 	// Check for malformed ValidationRecords
@@ -544,10 +577,11 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain
 			// differentials then collect and log the remote results in a separate go
 			// routine to avoid blocking the primary VA.
 			go func() {
-				_ = va.processRemoteResults(domain, string(challenge.Type), prob, remoteProbs, len(va.remoteVAs))
+				_ = va.processRemoteResults(domain, string(challenge.Type), prob, remoteProbs, len(va.remoteVAs), true)
 			}()
 		} else if features.Enabled(features.EnforceMultiVA) {
-			remoteProb := va.processRemoteResults(domain, string(challenge.Type), prob, remoteProbs, len(va.remoteVAs))
+			remoteProb := va.processRemoteResults(domain, string(challenge.Type), prob, remoteProbs, len(va.remoteVAs),
+				features.Enabled(features.MultiVAFullResults))
 			if remoteProb != nil {
 				prob = remoteProb
 				challenge.Status = core.StatusInvalid
@@ -582,7 +616,7 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain
 	// buffers. We log at this layer instead of leaving it up to gRPC because gRPC
 	// doesn't log the actual contents that failed to marshal, making it hard to
 	// figure out what's broken.
-	if _, err := bgrpc.ValidationResultToPB(records, prob); err != nil {
+	if _, err := bgrpc.ValidationResultToPB(records, prob, iodef); err != nil {
 		va.log.Errf(
 			"failed to marshal records %#v and prob %#v to protocol buffer: %v",
 			records, prob, err)
@@ -593,8 +627,8 @@ func (va *ValidationAuthorityImpl) PerformValidation(ctx context.Context, domain
 		// non-nil interface value containing a nil pointer, rather than a nil
 		// interface value. See, e.g.
 		// https://stackoverflow.com/questions/29138591/hiding-nil-values-understanding-why-golang-fails-here
-		return records, nil
+		return records, nil, nil
 	}
 
-	return records, prob
+	return records, iodef, prob
 }
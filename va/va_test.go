@@ -25,6 +25,7 @@ import (
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/test"
+	vapb "github.com/letsencrypt/boulder/va/proto"
 	"github.com/prometheus/client_golang/prometheus"
 	"golang.org/x/net/context"
 	"gopkg.in/square/go-jose.v2"
@@ -92,7 +93,7 @@ func TestPerformValidationInvalid(t *testing.T) {
 	va, _ := setup(nil, 0, "", nil)
 
 	chalDNS := createChallenge(core.ChallengeTypeDNS01)
-	_, prob := va.PerformValidation(context.Background(), "foo.com", chalDNS, core.Authorization{})
+	_, _, prob := va.PerformValidation(context.Background(), "foo.com", chalDNS, core.Authorization{})
 	test.Assert(t, prob != nil, "validation succeeded")
 
 	samples := test.CountHistogramSamples(va.metrics.validationTime.With(prometheus.Labels{
@@ -112,7 +113,7 @@ func TestPerformValidationValid(t *testing.T) {
 	chalDNS := core.DNSChallenge01("")
 	chalDNS.Token = expectedToken
 	chalDNS.ProvidedKeyAuthorization = expectedKeyAuthorization
-	_, prob := va.PerformValidation(context.Background(), "good-dns01.com", chalDNS, core.Authorization{})
+	_, _, prob := va.PerformValidation(context.Background(), "good-dns01.com", chalDNS, core.Authorization{})
 	test.Assert(t, prob == nil, fmt.Sprintf("validation failed: %#v", prob))
 
 	samples := test.CountHistogramSamples(va.metrics.validationTime.With(prometheus.Labels{
@@ -142,7 +143,7 @@ func TestPerformValidationWildcard(t *testing.T) {
 	chalDNS.Token = expectedToken
 	chalDNS.ProvidedKeyAuthorization = expectedKeyAuthorization
 	// perform a validation for a wildcard name
-	_, prob := va.PerformValidation(context.Background(), "*.good-dns01.com", chalDNS, core.Authorization{})
+	_, _, prob := va.PerformValidation(context.Background(), "*.good-dns01.com", chalDNS, core.Authorization{})
 	test.Assert(t, prob == nil, fmt.Sprintf("validation failed: %#v", prob))
 
 	samples := test.CountHistogramSamples(va.metrics.validationTime.With(prometheus.Labels{
@@ -250,19 +251,24 @@ func httpMultiSrv(t *testing.T, token string, allowedUAs map[string]bool) *multi
 }
 
 // cancelledVA is a mock that always returns context.Canceled for
-// PerformValidation calls
+// PerformValidation and IsCAAValid calls
 type cancelledVA struct{}
 
-func (v cancelledVA) PerformValidation(_ context.Context, _ string, _ core.Challenge, _ core.Authorization) ([]core.ValidationRecord, error) {
+func (v cancelledVA) PerformValidation(_ context.Context, _ string, _ core.Challenge, _ core.Authorization) ([]core.ValidationRecord, []string, error) {
+	return nil, nil, context.Canceled
+}
+
+// IsCAAValid returns context.Canceled unconditionally
+func (v cancelledVA) IsCAAValid(_ context.Context, _ *vapb.IsCAAValidRequest) (*vapb.IsCAAValidResponse, error) {
 	return nil, context.Canceled
 }
 
-// brokenRemoteVA is a mock for the core.ValidationAuthority interface mocked to
-// always return errors.
+// brokenRemoteVA is a mock for the RemoteClients interface mocked to always
+// return errors.
 type brokenRemoteVA struct{}
 
 // brokenRemoteVAError is the error returned by a brokenRemoteVA's
-// PerformValidation and IsSafeDomain functions.
+// PerformValidation and IsCAAValid functions.
 var brokenRemoteVAError = errors.New("brokenRemoteVA is broken")
 
 // PerformValidation returns brokenRemoteVAError unconditionally
@@ -270,7 +276,14 @@ func (b *brokenRemoteVA) PerformValidation(
 	_ context.Context,
 	_ string,
 	_ core.Challenge,
-	_ core.Authorization) ([]core.ValidationRecord, error) {
+	_ core.Authorization) ([]core.ValidationRecord, []string, error) {
+	return nil, nil, brokenRemoteVAError
+}
+
+// IsCAAValid returns brokenRemoteVAError unconditionally
+func (b *brokenRemoteVA) IsCAAValid(
+	_ context.Context,
+	_ *vapb.IsCAAValidRequest) (*vapb.IsCAAValidResponse, error) {
 	return nil, brokenRemoteVAError
 }
 
@@ -467,7 +480,7 @@ func TestMultiVA(t *testing.T) {
 			}
 
 			// Perform all validations
-			_, prob := localVA.PerformValidation(ctx, "localhost", chall, core.Authorization{})
+			_, _, prob := localVA.PerformValidation(ctx, "localhost", chall, core.Authorization{})
 			if prob == nil && tc.ExpectedProb != nil {
 				t.Errorf("expected prob %v, got nil", tc.ExpectedProb)
 			} else if prob != nil {
@@ -550,7 +563,7 @@ func TestMultiVAEarlyReturn(t *testing.T) {
 			start := time.Now()
 
 			// Perform all validations
-			_, prob := localVA.PerformValidation(ctx, "localhost", chall, core.Authorization{})
+			_, _, prob := localVA.PerformValidation(ctx, "localhost", chall, core.Authorization{})
 			// It should always fail
 			if prob == nil {
 				t.Error("expected prob from PerformValidation, got nil")
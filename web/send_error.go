@@ -17,7 +17,8 @@ import (
 //  - Adds both the external and the internal error to a RequestEvent.
 //  - If the ProblemDetails provided is a ServerInternalProblem, audit logs the
 //    internal error.
-//  - Prefixes the Type field of the ProblemDetails with a namespace.
+//  - Prefixes the Type field of the ProblemDetails, and of any subproblems,
+//    with a namespace.
 //  - Sends an HTTP response containing the error and an error code to the user.
 func SendError(
 	log blog.Logger,
@@ -50,6 +51,9 @@ func SendError(
 	}
 
 	prob.Type = probs.ProblemType(namespace) + prob.Type
+	for i := range prob.SubProblems {
+		prob.SubProblems[i].Type = probs.ProblemType(namespace) + prob.SubProblems[i].Type
+	}
 	problemDoc, err := json.MarshalIndent(prob, "", "  ")
 	if err != nil {
 		log.AuditErrf("Could not marshal error message: %s - %+v", err, prob)
@@ -111,7 +111,7 @@ func NewWebFrontEndImpl(
 	keyPolicy goodkey.KeyPolicy,
 	logger blog.Logger,
 ) (WebFrontEndImpl, error) {
-	nonceService, err := nonce.NewNonceService(stats)
+	nonceService, err := nonce.NewNonceService(stats, 0, "")
 	if err != nil {
 		return WebFrontEndImpl{}, err
 	}
@@ -489,7 +489,11 @@ func (wfe *WebFrontEndImpl) verifyPOST(ctx context.Context, logEvent *web.Reques
 		// When looking up keys from the registrations DB, we can be confident they
 		// are "good". But when we are verifying against any submitted key, we want
 		// to check its quality before doing the verify.
-		if err = wfe.keyPolicy.GoodKey(submittedKey.Key); err != nil {
+		if err = wfe.keyPolicy.GoodKey(ctx, submittedKey.Key); err != nil {
+			if !berrors.Is(err, berrors.Malformed) {
+				logEvent.AddError("unable to check public key: %s", err)
+				return nil, nil, reg, probs.ServerInternal("Error checking public key")
+			}
 			wfe.stats.Inc("Errors.JWKRejectedByGoodKey", 1)
 			return nil, nil, reg, probs.Malformed(err.Error())
 		}
@@ -882,7 +886,11 @@ func (wfe *WebFrontEndImpl) NewCertificate(ctx context.Context, logEvent *web.Re
 	// bytes on the wire, and (b) the CA logs all rejections as audit events, but
 	// a bad key from the client is just a malformed request and doesn't need to
 	// be audited.
-	if err := wfe.keyPolicy.GoodKey(certificateRequest.CSR.PublicKey); err != nil {
+	if err := wfe.keyPolicy.GoodKey(ctx, certificateRequest.CSR.PublicKey); err != nil {
+		if !berrors.Is(err, berrors.Malformed) {
+			wfe.sendError(response, logEvent, probs.ServerInternal("Error checking public key"), err)
+			return
+		}
 		wfe.sendError(response, logEvent, probs.Malformed("Invalid key in certificate request :: %s", err), err)
 		return
 	}
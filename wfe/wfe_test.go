@@ -267,6 +267,14 @@ func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, _ *rapb.
 	return nil, nil
 }
 
+func (ra *MockRegistrationAuthority) RevokeCertByKey(ctx context.Context, _ *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error) {
+	return nil, nil
+}
+
+func (ra *MockRegistrationAuthority) GetRateLimitUsage(ctx context.Context, _ *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	return nil, nil
+}
+
 type mockPA struct{}
 
 func (pa *mockPA) ChallengesFor(identifier core.AcmeIdentifier) (challenges []core.Challenge, err error) {
@@ -927,6 +935,7 @@ func TestIssueCertificate(t *testing.T) {
 		ctp,
 		nil,
 		nil,
+		nil,
 	)
 	ra.SA = mocks.NewStorageAuthority(fc)
 	ra.CA = &mocks.MockCA{
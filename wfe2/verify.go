@@ -5,6 +5,7 @@ import (
 	"crypto"
 	"crypto/ecdsa"
 	"crypto/rsa"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,11 +16,14 @@ import (
 	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/crypto/ed25519"
 	"gopkg.in/square/go-jose.v2"
 
 	"github.com/letsencrypt/boulder/core"
 	berrors "github.com/letsencrypt/boulder/errors"
+	"github.com/letsencrypt/boulder/nonce"
 	"github.com/letsencrypt/boulder/probs"
+	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/web"
 )
 
@@ -47,6 +51,8 @@ func sigAlgorithmForKey(key crypto.PublicKey) (jose.SignatureAlgorithm, error) {
 		return jose.RS256, nil
 	case *ecdsa.PublicKey:
 		return sigAlgorithmForECDSAKey(k)
+	case ed25519.PublicKey:
+		return jose.EdDSA, nil
 	}
 	return "", sigAlgErr
 }
@@ -64,7 +70,7 @@ func checkAlgorithm(key *jose.JSONWebKey, parsedJWS *jose.JSONWebSignature) erro
 	jwsAlgorithm := parsedJWS.Signatures[0].Header.Algorithm
 	if jwsAlgorithm != string(algorithm) {
 		return fmt.Errorf(
-			"signature type '%s' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512",
+			"signature type '%s' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA",
 			jwsAlgorithm,
 		)
 	}
@@ -174,20 +180,33 @@ func (wfe *WebFrontEndImpl) validPOSTRequest(request *http.Request) *probs.Probl
 }
 
 // validNonce checks a JWS' Nonce header to ensure it is one that the
-// nonceService knows about, otherwise a bad nonce problem is returned.
+// nonceService knows about, otherwise a bad nonce problem is returned. If
+// remote nonce services are configured the nonce is instead redeemed by the
+// instance that issued it.
 // NOTE: this function assumes the JWS has already been verified with the
 // correct public key.
-func (wfe *WebFrontEndImpl) validNonce(jws *jose.JSONWebSignature) *probs.ProblemDetails {
+func (wfe *WebFrontEndImpl) validNonce(jws *jose.JSONWebSignature, ctx context.Context) *probs.ProblemDetails {
 	// validNonce is called after validPOSTRequest() and parseJWS() which
 	// defend against the incorrect number of signatures.
 	header := jws.Signatures[0].Header
-	nonce := header.Nonce
-	if len(nonce) == 0 {
+	if len(header.Nonce) == 0 {
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSMissingNonce"}).Inc()
 		return probs.BadNonce("JWS has no anti-replay nonce")
-	} else if !wfe.nonceService.Valid(nonce) {
+	}
+
+	var valid bool
+	if wfe.remoteNonceService != nil {
+		var err error
+		valid, err = nonce.RemoteRedeem(ctx, wfe.noncePrefixMap, header.Nonce)
+		if err != nil {
+			return probs.ServerInternal("failed to verify nonce validity: %s", err)
+		}
+	} else {
+		valid = wfe.nonceService.Valid(header.Nonce)
+	}
+	if !valid {
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSInvalidNonce"}).Inc()
-		return probs.BadNonce("JWS has an invalid anti-replay nonce: %q", nonce)
+		return probs.BadNonce("JWS has an invalid anti-replay nonce: %q", header.Nonce)
 	}
 	return nil
 }
@@ -276,6 +295,7 @@ func (wfe *WebFrontEndImpl) parseJWS(body []byte) (*jose.JSONWebSignature, *prob
 	var unprotected struct {
 		Header     map[string]string
 		Signatures []interface{}
+		Protected  string
 	}
 	if err := json.Unmarshal(body, &unprotected); err != nil {
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSUnmarshalFailed"}).Inc()
@@ -303,6 +323,10 @@ func (wfe *WebFrontEndImpl) parseJWS(body []byte) (*jose.JSONWebSignature, *prob
 	bodyStr := string(body)
 	parsedJWS, err := jose.ParseSigned(bodyStr)
 	if err != nil {
+		if embedsEd448Key(unprotected.Protected) {
+			wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSEd448Key"}).Inc()
+			return nil, probs.BadPublicKey("Ed448 account keys are not supported")
+		}
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWSParseError"}).Inc()
 		return nil, probs.Malformed("Parse error reading JWS")
 	}
@@ -322,6 +346,28 @@ func (wfe *WebFrontEndImpl) parseJWS(body []byte) (*jose.JSONWebSignature, *prob
 	return parsedJWS, nil
 }
 
+// embedsEd448Key returns true if the given base64url encoded protected JWS
+// header embeds an Ed448 JWK. go-jose fails to parse these with an opaque
+// error, so this lets parseJWS tell the client why their key was refused.
+//
+// TODO: Support Ed448 account keys once go-jose can parse and verify them.
+func embedsEd448Key(protected string) bool {
+	headerBytes, err := base64.RawURLEncoding.DecodeString(protected)
+	if err != nil {
+		return false
+	}
+	var header struct {
+		JWK struct {
+			Kty string `json:"kty"`
+			Crv string `json:"crv"`
+		} `json:"jwk"`
+	}
+	if err := json.Unmarshal(headerBytes, &header); err != nil {
+		return false
+	}
+	return header.JWK.Kty == "OKP" && header.JWK.Crv == "Ed448"
+}
+
 // parseJWSRequest extracts a JSONWebSignature from an HTTP POST request's body using parseJWS.
 func (wfe *WebFrontEndImpl) parseJWSRequest(request *http.Request) (*jose.JSONWebSignature, *probs.ProblemDetails) {
 	// Verify that the POST request has the expected headers
@@ -465,6 +511,7 @@ func (wfe *WebFrontEndImpl) validJWSForKey(
 	jws *jose.JSONWebSignature,
 	jwk *jose.JSONWebKey,
 	request *http.Request,
+	ctx context.Context,
 	logEvent *web.RequestEvent) ([]byte, *probs.ProblemDetails) {
 
 	// Check that the public key and JWS algorithms match expected
@@ -488,7 +535,7 @@ func (wfe *WebFrontEndImpl) validJWSForKey(
 	logEvent.Payload = string(payload)
 
 	// Check that the JWS contains a correct Nonce header
-	if prob := wfe.validNonce(jws); prob != nil {
+	if prob := wfe.validNonce(jws, ctx); prob != nil {
 		return nil, prob
 	}
 
@@ -530,7 +577,7 @@ func (wfe *WebFrontEndImpl) validJWSForAccount(
 	}
 
 	// Verify the JWS with the JWK from the SA
-	payload, prob := wfe.validJWSForKey(jws, pubKey, request, logEvent)
+	payload, prob := wfe.validJWSForKey(jws, pubKey, request, ctx, logEvent)
 	if prob != nil {
 		return nil, nil, nil, prob
 	}
@@ -592,6 +639,7 @@ func (wfe *WebFrontEndImpl) validPOSTAsGETForAccount(
 func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
 	jws *jose.JSONWebSignature,
 	request *http.Request,
+	ctx context.Context,
 	logEvent *web.RequestEvent) ([]byte, *jose.JSONWebKey, *probs.ProblemDetails) {
 	// Extract the embedded JWK from the parsed JWS
 	pubKey, prob := wfe.extractJWK(jws)
@@ -600,13 +648,17 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
 	}
 
 	// If the key doesn't meet the GoodKey policy return a problem immediately
-	if err := wfe.keyPolicy.GoodKey(pubKey.Key); err != nil {
+	if err := wfe.keyPolicy.GoodKey(ctx, pubKey.Key); err != nil {
+		if !berrors.Is(err, berrors.Malformed) {
+			logEvent.AddError("unable to check public key: %s", err)
+			return nil, nil, probs.ServerInternal("Error checking public key")
+		}
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "JWKRejectedByGoodKey"}).Inc()
 		return nil, nil, probs.BadPublicKey(err.Error())
 	}
 
 	// Verify the JWS with the embedded JWK
-	payload, prob := wfe.validJWSForKey(jws, pubKey, request, logEvent)
+	payload, prob := wfe.validJWSForKey(jws, pubKey, request, ctx, logEvent)
 	if prob != nil {
 		return nil, nil, prob
 	}
@@ -618,6 +670,7 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedJWS(
 // using `validSelfAuthenticatedJWS`.
 func (wfe *WebFrontEndImpl) validSelfAuthenticatedPOST(
 	request *http.Request,
+	ctx context.Context,
 	logEvent *web.RequestEvent) ([]byte, *jose.JSONWebKey, *probs.ProblemDetails) {
 	// Parse the JWS from the POST request
 	jws, prob := wfe.parseJWSRequest(request)
@@ -625,7 +678,7 @@ func (wfe *WebFrontEndImpl) validSelfAuthenticatedPOST(
 		return nil, nil, prob
 	}
 	// Extract and validate the embedded JWK from the parsed JWS
-	return wfe.validSelfAuthenticatedJWS(jws, request, logEvent)
+	return wfe.validSelfAuthenticatedJWS(jws, request, ctx, logEvent)
 }
 
 // rolloverRequest is a client request to change the key for the account ID
@@ -665,6 +718,7 @@ func (wfe *WebFrontEndImpl) validKeyRollover(
 	outerJWS *jose.JSONWebSignature,
 	innerJWS *jose.JSONWebSignature,
 	oldKey *jose.JSONWebKey,
+	ctx context.Context,
 	logEvent *web.RequestEvent) (*rolloverOperation, *probs.ProblemDetails) {
 
 	// Extract the embedded JWK from the inner JWS
@@ -674,7 +728,11 @@ func (wfe *WebFrontEndImpl) validKeyRollover(
 	}
 
 	// If the key doesn't meet the GoodKey policy return a problem immediately
-	if err := wfe.keyPolicy.GoodKey(jwk.Key); err != nil {
+	if err := wfe.keyPolicy.GoodKey(ctx, jwk.Key); err != nil {
+		if !berrors.Is(err, berrors.Malformed) {
+			logEvent.AddError("unable to check public key: %s", err)
+			return nil, probs.ServerInternal("Error checking public key")
+		}
 		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "KeyRolloverJWKRejectedByGoodKey"}).Inc()
 		return nil, probs.BadPublicKey(err.Error())
 	}
@@ -735,3 +793,82 @@ func (wfe *WebFrontEndImpl) validKeyRollover(
 		NewKey: *jwk,
 	}, nil
 }
+
+// validExternalAccountBinding checks the external account binding carried in
+// a new-account request, per RFC 8555 Section 7.3.4. It is assumed that the
+// outer JWS has already been validated with `validSelfAuthenticatedPOST` and
+// that acctKey is the JWK embedded in it. This function checks that:
+// 1) the binding is a well formed JWS using a MAC-based algorithm
+// 2) the binding identifies the external account key with a "kid" header and
+//    has the same "url" header as the outer JWS, but no nonce
+// 3) the binding verifies with the HMAC key of that external account key
+// 4) the external account key is neither retired nor already bound to an
+//    account
+// 5) the binding's payload is the account key
+//
+// If the binding is valid the ID of the external account key is returned,
+// otherwise a problem is returned.
+func (wfe *WebFrontEndImpl) validExternalAccountBinding(
+	ctx context.Context,
+	eab []byte,
+	acctKey *jose.JSONWebKey,
+	request *http.Request) (string, *probs.ProblemDetails) {
+	eabJWS, prob := wfe.parseJWS(eab)
+	if prob != nil {
+		return "", probs.Malformed("Invalid external account binding: %s", prob.Detail)
+	}
+
+	// parseJWS defends against the incorrect number of signatures.
+	header := eabJWS.Signatures[0].Header
+	switch jose.SignatureAlgorithm(header.Algorithm) {
+	case jose.HS256, jose.HS384, jose.HS512:
+	default:
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABAlgorithmInvalid"}).Inc()
+		return "", probs.BadSignatureAlgorithm(
+			"external account binding signature type '%s' is not supported, expected one of HS256, HS384 or HS512",
+			header.Algorithm)
+	}
+	if prob := wfe.enforceJWSAuthType(eabJWS, embeddedKeyID); prob != nil {
+		return "", prob
+	}
+	if header.Nonce != "" {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABNoncePresent"}).Inc()
+		return "", probs.Malformed("external account binding JWS must not include a nonce")
+	}
+	if prob := wfe.validPOSTURL(request, eabJWS); prob != nil {
+		return "", prob
+	}
+
+	keyID := header.KeyID
+	eak, err := wfe.SA.GetExternalAccountKey(ctx, &sapb.ExternalAccountKeyID{KeyID: &keyID})
+	if berrors.Is(err, berrors.NotFound) {
+		return "", probs.Unauthorized("external account key %q not found", keyID)
+	} else if err != nil {
+		return "", probs.ServerInternal("unable to retrieve external account key")
+	}
+
+	payload, err := eabJWS.Verify(eak.HmacKey)
+	if err != nil {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABVerifyFailed"}).Inc()
+		return "", probs.Unauthorized(
+			"external account binding does not verify with external account key %q", keyID)
+	}
+	if *eak.RetiredAt != 0 {
+		return "", probs.Unauthorized("external account key %q has been retired", keyID)
+	}
+	if *eak.RegistrationID != 0 {
+		return "", probs.Unauthorized("external account key %q has already been used", keyID)
+	}
+
+	var boundKey jose.JSONWebKey
+	if err := json.Unmarshal(payload, &boundKey); err != nil {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABUnmarshalFailed"}).Inc()
+		return "", probs.Malformed("external account binding payload did not parse as a JWK")
+	}
+	if keysEqual, err := core.PublicKeysEqual(boundKey.Key, acctKey.Key); err != nil || !keysEqual {
+		wfe.stats.joseErrorCount.With(prometheus.Labels{"type": "EABWrongKey"}).Inc()
+		return "", probs.Malformed("external account binding payload does not match the account key")
+	}
+
+	return keyID, nil
+}
@@ -5,14 +5,22 @@ import (
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rsa"
+	"encoding/base64"
 	"fmt"
 	"net/http"
 	"testing"
 
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/net/context"
+	"google.golang.org/grpc"
 
 	"github.com/letsencrypt/boulder/core"
+	corepb "github.com/letsencrypt/boulder/core/proto"
+	blog "github.com/letsencrypt/boulder/log"
+	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/mocks"
+	"github.com/letsencrypt/boulder/nonce"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 	"github.com/letsencrypt/boulder/probs"
 	"github.com/letsencrypt/boulder/test"
 
@@ -41,26 +49,30 @@ func sigAlgForKey(t *testing.T, key interface{}) jose.SignatureAlgorithm {
 }
 
 // keyAlgForKey returns a JWK key algorithm based on the provided private key.
-// Only ECDSA and RSA private keys are supported.
+// Only ECDSA, RSA and Ed25519 private keys are supported.
 func keyAlgForKey(t *testing.T, key interface{}) string {
 	switch key.(type) {
 	case *rsa.PrivateKey, rsa.PrivateKey:
 		return "RSA"
 	case *ecdsa.PrivateKey, ecdsa.PrivateKey:
 		return "ECDSA"
+	case ed25519.PrivateKey:
+		return "Ed25519"
 	}
 	t.Fatalf("Can't figure out keyAlgForKey: %#v", key)
 	return ""
 }
 
-// pubKeyForKey returns the public key of an RSA/ECDSA private key provided as
-// argument.
+// pubKeyForKey returns the public key of an RSA/ECDSA/Ed25519 private key
+// provided as argument.
 func pubKeyForKey(t *testing.T, privKey interface{}) interface{} {
 	switch k := privKey.(type) {
 	case *rsa.PrivateKey:
 		return k.PublicKey
 	case *ecdsa.PrivateKey:
 		return k.PublicKey
+	case ed25519.PrivateKey:
+		return k.Public()
 	}
 	t.Fatalf("Unable to get public key for private key %#v", privKey)
 	return nil
@@ -185,7 +197,7 @@ func TestRejectsNone(t *testing.T) {
 	if err == nil {
 		t.Fatalf("checkAlgorithm did not reject JWS with alg: 'none'")
 	}
-	if err.Error() != "signature type 'none' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512" {
+	if err.Error() != "signature type 'none' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA" {
 		t.Fatalf("checkAlgorithm rejected JWS with alg: 'none', but for wrong reason: %#v", err)
 	}
 }
@@ -216,7 +228,7 @@ func TestRejectsHS256(t *testing.T) {
 	if err == nil {
 		t.Fatalf("checkAlgorithm did not reject JWS with alg: 'HS256'")
 	}
-	expected := "signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512"
+	expected := "signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA"
 	if err.Error() != expected {
 		t.Fatalf("checkAlgorithm rejected JWS with alg: 'none', but for wrong reason: got '%s', wanted %s", err.Error(), expected)
 	}
@@ -248,7 +260,7 @@ func TestCheckAlgorithm(t *testing.T) {
 					},
 				},
 			},
-			"signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512",
+			"signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA",
 		},
 		{
 			jose.JSONWebKey{
@@ -264,7 +276,7 @@ func TestCheckAlgorithm(t *testing.T) {
 					},
 				},
 			},
-			"signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512",
+			"signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA",
 		},
 		{
 			jose.JSONWebKey{
@@ -356,6 +368,21 @@ func TestCheckAlgorithmSuccess(t *testing.T) {
 	if err != nil {
 		t.Errorf("ES256 key: Expected nil error, got '%s'", err)
 	}
+
+	err = checkAlgorithm(&jose.JSONWebKey{
+		Key: ed25519.PublicKey(make([]byte, ed25519.PublicKeySize)),
+	}, &jose.JSONWebSignature{
+		Signatures: []jose.Signature{
+			{
+				Header: jose.Header{
+					Algorithm: "EdDSA",
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Errorf("EdDSA key: Expected nil error, got '%s'", err)
+	}
 }
 
 func TestValidPOSTRequest(t *testing.T) {
@@ -603,7 +630,7 @@ func TestValidNonce(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
 			wfe.stats.joseErrorCount.Reset()
-			prob := wfe.validNonce(tc.JWS)
+			prob := wfe.validNonce(tc.JWS, context.Background())
 			if tc.ExpectedResult == nil && prob != nil {
 				t.Fatalf("Expected nil result, got %#v", prob)
 			} else {
@@ -647,6 +674,75 @@ func signExtraHeaders(
 	return parsedJWS, body
 }
 
+// inMemNonceService calls a nonce.Server directly, standing in for a gRPC
+// connection to a nonce-service instance.
+type inMemNonceService struct {
+	server *nonce.Server
+}
+
+func (ns inMemNonceService) Nonce(ctx context.Context, req *corepb.Empty, _ ...grpc.CallOption) (*noncepb.NonceMessage, error) {
+	return ns.server.Nonce(ctx, req)
+}
+
+func (ns inMemNonceService) Redeem(ctx context.Context, req *noncepb.NonceMessage, _ ...grpc.CallOption) (*noncepb.ValidMessage, error) {
+	return ns.server.Redeem(ctx, req)
+}
+
+// remoteNonceSource is a jose.NonceSource which fetches nonces from a remote
+// nonce service.
+type remoteNonceSource struct {
+	client noncepb.NonceServiceClient
+}
+
+func (s remoteNonceSource) Nonce() (string, error) {
+	msg, err := s.client.Nonce(context.Background(), &corepb.Empty{})
+	if err != nil {
+		return "", err
+	}
+	return *msg.Nonce, nil
+}
+
+func TestValidNonceRemote(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	zinc, err := nonce.NewNonceService(metrics.NewNoopScope(), 0, "zinc")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	taro, err := nonce.NewNonceService(metrics.NewNoopScope(), 0, "taro")
+	test.AssertNotError(t, err, "Could not create nonce service")
+	zincClient := inMemNonceService{server: nonce.NewServer(zinc)}
+	taroClient := inMemNonceService{server: nonce.NewServer(taro)}
+
+	_, err = NewWebFrontEndImpl(metrics.NewNoopScope(), wfe.clk, testKeyPolicy, nil, zincClient, nil, blog.NewMock())
+	test.AssertError(t, err, "NewWebFrontEndImpl accepted a remote nonce service with nothing to redeem nonces")
+
+	wfe.remoteNonceService = zincClient
+	wfe.noncePrefixMap = map[string]noncepb.NonceServiceClient{
+		"zinc": zincClient,
+		"taro": taroClient,
+	}
+
+	// Nonces are issued by the remote service...
+	issued, err := wfe.nonce(context.Background())
+	test.AssertNotError(t, err, "Failed to get a remote nonce")
+	test.AssertEquals(t, issued[:nonce.PrefixLen], "zinc")
+
+	// ...and redeemed by whichever instance issued them.
+	for _, client := range []noncepb.NonceServiceClient{zincClient, taroClient} {
+		jws, _, _ := signRequestEmbed(t, nil, "", "", remoteNonceSource{client})
+		prob := wfe.validNonce(jws, context.Background())
+		test.Assert(t, prob == nil, fmt.Sprintf("Rejected a valid remote nonce: %v", prob))
+		prob = wfe.validNonce(jws, context.Background())
+		test.Assert(t, prob != nil, "Accepted a remote nonce twice")
+		test.AssertEquals(t, prob.Type, probs.BadNonceProblem)
+	}
+
+	// A nonce from the WFE's own nonce service isn't accepted.
+	jws, _, _ := signRequestEmbed(t, nil, "", "", wfe.nonceService)
+	prob := wfe.validNonce(jws, context.Background())
+	test.Assert(t, prob != nil, "Accepted a local nonce when using remote nonce services")
+	test.AssertEquals(t, prob.Type, probs.BadNonceProblem)
+}
+
 func TestValidPOSTURL(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -800,6 +896,13 @@ func TestParseJWSRequest(t *testing.T) {
 }
 `
 
+	// go-jose can't parse Ed448 JWKs, so the key's contents don't matter beyond
+	// being the right size (57 bytes).
+	ed448X := base64.RawURLEncoding.EncodeToString(make([]byte, 57))
+	ed448Protected := base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf(
+		`{"alg":"EdDSA","jwk":{"kty":"OKP","crv":"Ed448","x":"%s"},"nonce":"bm9uY2U","url":"http://localhost/test-path"}`, ed448X)))
+	ed448JWSBody := fmt.Sprintf(`{"protected":"%s","payload":"Zm9v","signature":"c2lnbmF0dXJl"}`, ed448Protected)
+
 	testCases := []struct {
 		Name            string
 		Request         *http.Request
@@ -869,6 +972,16 @@ func TestParseJWSRequest(t *testing.T) {
 			},
 			ErrorStatType: "JWSMultiSig",
 		},
+		{
+			Name:    "Ed448 JWK in JWS",
+			Request: makePostRequestWithPath("test-path", ed448JWSBody),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.BadPublicKeyProblem,
+				Detail:     "Ed448 account keys are not supported",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "JWSEd448Key",
+		},
 		{
 			Name:            "Valid JWS in POST request",
 			Request:         validJWSRequest,
@@ -1162,7 +1275,7 @@ func TestValidJWSForKey(t *testing.T) {
 			JWK:  goodJWK,
 			ExpectedProblem: &probs.ProblemDetails{
 				Type:       probs.BadSignatureAlgorithmProblem,
-				Detail:     "signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384 or ES512",
+				Detail:     "signature type 'HS256' in JWS header is not supported, expected one of RS256, ES256, ES384, ES512 or EdDSA",
 				HTTPStatus: http.StatusBadRequest,
 			},
 			ErrorStatType: "JWSAlgorithmCheckFailed",
@@ -1223,7 +1336,7 @@ func TestValidJWSForKey(t *testing.T) {
 			wfe.stats.joseErrorCount.Reset()
 			inputLogEvent := newRequestEvent()
 			request := makePostRequestWithPath("test", tc.Body)
-			outPayload, prob := wfe.validJWSForKey(tc.JWS, tc.JWK, request, inputLogEvent)
+			outPayload, prob := wfe.validJWSForKey(tc.JWS, tc.JWK, request, context.Background(), inputLogEvent)
 
 			if tc.ExpectedProblem == nil && prob != nil {
 				t.Fatalf("Expected nil problem, got %#v\n", prob)
@@ -1464,7 +1577,7 @@ func TestValidSelfAuthenticatedPOST(t *testing.T) {
 		t.Run(tc.Name, func(t *testing.T) {
 			wfe.stats.joseErrorCount.Reset()
 			inputLogEvent := newRequestEvent()
-			outPayload, jwk, prob := wfe.validSelfAuthenticatedPOST(tc.Request, inputLogEvent)
+			outPayload, jwk, prob := wfe.validSelfAuthenticatedPOST(tc.Request, context.Background(), inputLogEvent)
 			if tc.ExpectedProblem == nil && prob != nil {
 				t.Fatalf("Expected nil problem, got %#v\n", prob)
 			} else if tc.ExpectedProblem == nil {
@@ -1566,3 +1679,190 @@ func TestMatchJWSURLs(t *testing.T) {
 		})
 	}
 }
+
+// testEABKey is the HMAC key that mocks.StorageAuthority returns for all of its
+// external account keys.
+var testEABKey = []byte("an external account key for testing")
+
+// signEAB creates an external account binding JWS over the given payload, MACed
+// with hmacKey and identified by keyID. The URL and nonce headers are set based
+// on the additional arguments. The JWS in serialized string form is returned.
+func signEAB(
+	t *testing.T,
+	alg jose.SignatureAlgorithm,
+	keyID string,
+	hmacKey []byte,
+	url string,
+	payload []byte,
+	nonceService jose.NonceSource) string {
+	signerKey := jose.SigningKey{
+		Key:       hmacKey,
+		Algorithm: alg,
+	}
+	// go-jose only sets the "kid" header for asymmetric keys, so it is added
+	// explicitly.
+	opts := &jose.SignerOptions{
+		NonceSource: nonceService,
+		ExtraHeaders: map[jose.HeaderKey]interface{}{
+			"kid": keyID,
+			"url": url,
+		},
+	}
+
+	signer, err := jose.NewSigner(signerKey, opts)
+	test.AssertNotError(t, err, "Failed to make EAB signer")
+	jws, err := signer.Sign(payload)
+	test.AssertNotError(t, err, "Failed to sign EAB")
+	return jws.FullSerialize()
+}
+
+func TestValidExternalAccountBinding(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	acctKey := loadKey(t, []byte(test2KeyPrivatePEM))
+	acctJWK := &jose.JSONWebKey{Key: acctKey.Public()}
+	acctJWKJSON, err := acctJWK.MarshalJSON()
+	test.AssertNotError(t, err, "Failed to marshal account JWK")
+	otherJWK := &jose.JSONWebKey{Key: loadKey(t, []byte(test1KeyPrivatePEM)).Public()}
+	otherJWKJSON, err := otherJWK.MarshalJSON()
+	test.AssertNotError(t, err, "Failed to marshal other JWK")
+
+	signedURL := "http://localhost" + newAcctPath
+	request := makePostRequestWithPath(newAcctPath, "")
+
+	testCases := []struct {
+		Name            string
+		EAB             string
+		ExpectedKeyID   string
+		ExpectedProblem *probs.ProblemDetails
+		ErrorStatType   string
+	}{
+		{
+			Name:          "Valid binding",
+			EAB:           signEAB(t, jose.HS256, "eab-key", testEABKey, signedURL, acctJWKJSON, nil),
+			ExpectedKeyID: "eab-key",
+		},
+		{
+			Name:          "Valid HS512 binding",
+			EAB:           signEAB(t, jose.HS512, "eab-key", testEABKey, signedURL, acctJWKJSON, nil),
+			ExpectedKeyID: "eab-key",
+		},
+		{
+			Name: "Not a JWS",
+			EAB:  `"eab"`,
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.MalformedProblem,
+				Detail:     "Invalid external account binding: Parse error reading JWS",
+				HTTPStatus: http.StatusBadRequest,
+			},
+		},
+		{
+			Name: "Signed with an account key",
+			EAB: func() string {
+				_, _, body := signRequestKeyID(t, 1, acctKey, signedURL, string(acctJWKJSON), nil)
+				return body
+			}(),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.BadSignatureAlgorithmProblem,
+				Detail:     "external account binding signature type 'RS256' is not supported, expected one of HS256, HS384 or HS512",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "EABAlgorithmInvalid",
+		},
+		{
+			Name: "Includes a nonce",
+			EAB:  signEAB(t, jose.HS256, "eab-key", testEABKey, signedURL, acctJWKJSON, wfe.nonceService),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.MalformedProblem,
+				Detail:     "external account binding JWS must not include a nonce",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "EABNoncePresent",
+		},
+		{
+			Name: "Wrong URL",
+			EAB:  signEAB(t, jose.HS256, "eab-key", testEABKey, "http://localhost/acme/new-order", acctJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.MalformedProblem,
+				Detail:     "JWS header parameter 'url' incorrect. Expected \"http://localhost/acme/new-acct\" got \"http://localhost/acme/new-order\"",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "JWSMismatchedURL",
+		},
+		{
+			Name: "Unknown key ID",
+			EAB:  signEAB(t, jose.HS256, "eab-unknown", testEABKey, signedURL, acctJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.UnauthorizedProblem,
+				Detail:     "external account key \"eab-unknown\" not found",
+				HTTPStatus: http.StatusForbidden,
+			},
+		},
+		{
+			Name: "Wrong HMAC key",
+			EAB:  signEAB(t, jose.HS256, "eab-key", []byte("the wrong key"), signedURL, acctJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.UnauthorizedProblem,
+				Detail:     "external account binding does not verify with external account key \"eab-key\"",
+				HTTPStatus: http.StatusForbidden,
+			},
+			ErrorStatType: "EABVerifyFailed",
+		},
+		{
+			Name: "Retired key",
+			EAB:  signEAB(t, jose.HS256, "eab-retired", testEABKey, signedURL, acctJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.UnauthorizedProblem,
+				Detail:     "external account key \"eab-retired\" has been retired",
+				HTTPStatus: http.StatusForbidden,
+			},
+		},
+		{
+			Name: "Already bound key",
+			EAB:  signEAB(t, jose.HS256, "eab-bound", testEABKey, signedURL, acctJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.UnauthorizedProblem,
+				Detail:     "external account key \"eab-bound\" has already been used",
+				HTTPStatus: http.StatusForbidden,
+			},
+		},
+		{
+			Name: "Payload isn't a JWK",
+			EAB:  signEAB(t, jose.HS256, "eab-key", testEABKey, signedURL, []byte(`{}`), nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.MalformedProblem,
+				Detail:     "external account binding payload did not parse as a JWK",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "EABUnmarshalFailed",
+		},
+		{
+			Name: "Payload is a different key",
+			EAB:  signEAB(t, jose.HS256, "eab-key", testEABKey, signedURL, otherJWKJSON, nil),
+			ExpectedProblem: &probs.ProblemDetails{
+				Type:       probs.MalformedProblem,
+				Detail:     "external account binding payload does not match the account key",
+				HTTPStatus: http.StatusBadRequest,
+			},
+			ErrorStatType: "EABWrongKey",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			wfe.stats.joseErrorCount.Reset()
+			keyID, prob := wfe.validExternalAccountBinding(ctx, []byte(tc.EAB), acctJWK, request)
+			if tc.ExpectedProblem == nil && prob != nil {
+				t.Fatalf("Expected nil problem, got %#v\n", prob)
+			} else if tc.ExpectedProblem == nil {
+				test.AssertEquals(t, keyID, tc.ExpectedKeyID)
+			} else {
+				test.AssertMarshaledEquals(t, prob, tc.ExpectedProblem)
+			}
+			if tc.ErrorStatType != "" {
+				test.AssertEquals(t, test.CountCounterVec(
+					"type", tc.ErrorStatType, wfe.stats.joseErrorCount), 1)
+			}
+		})
+	}
+}
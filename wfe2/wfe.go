@@ -3,16 +3,20 @@ package wfe2
 import (
 	"bytes"
 	"crypto/x509"
+	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
+	"math/big"
 	"net"
 	"net/http"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/jmhodges/clock"
@@ -26,8 +30,10 @@ import (
 	"github.com/letsencrypt/boulder/metrics"
 	"github.com/letsencrypt/boulder/metrics/measured_http"
 	"github.com/letsencrypt/boulder/nonce"
+	noncepb "github.com/letsencrypt/boulder/nonce/proto"
 	"github.com/letsencrypt/boulder/probs"
 	rapb "github.com/letsencrypt/boulder/ra/proto"
+	"github.com/letsencrypt/boulder/reloader"
 	"github.com/letsencrypt/boulder/revocation"
 	sapb "github.com/letsencrypt/boulder/sa/proto"
 	"github.com/letsencrypt/boulder/web"
@@ -55,8 +61,14 @@ const (
 	newOrderPath      = "/acme/new-order"
 	orderPath         = "/acme/order/"
 	finalizeOrderPath = "/acme/finalize/"
+	renewalInfoPath   = "/acme/renewal-info/"
+	ordersPath        = "/acme/orders/"
 )
 
+// defaultOrdersPerPage is the number of order URLs returned per page of an
+// account's orders list if OrdersPerPage isn't set.
+const defaultOrdersPerPage = 100
+
 // WebFrontEndImpl provides all the logic for Boulder's web-facing interface,
 // i.e., ACME.  Its members configure the paths for various ACME functions,
 // plus a few other data items used in ACME.  Its methods are primarily handlers
@@ -76,6 +88,11 @@ type WebFrontEndImpl struct {
 	// newline and one or more PEM encoded certificates separated by a newline,
 	// sorted from leaf to root
 	certificateChains map[string][]byte
+	// chainIssuers maps the same AIA issuer URLs to the first certificate of
+	// their chain, which is the issuer of the certificates it is served with
+	chainIssuers map[string]*x509.Certificate
+	// chainURLs is the sorted keys of certificateChains
+	chainURLs []string
 
 	// URL to the current subscriber agreement (should contain some version identifier)
 	SubscriberAgreementURL string
@@ -89,6 +106,17 @@ type WebFrontEndImpl struct {
 	// "website" field.
 	DirectoryWebsite string
 
+	// ExternalAccountRequired is used for the /directory response's "meta"
+	// element's "externalAccountRequired" field. When true new-account
+	// requests must carry an external account binding (RFC 8555 Section 7.3.4)
+	// made with a pre-provisioned key stored in the SA.
+	ExternalAccountRequired bool
+
+	// CertProfiles maps the names of the certificate profiles that new-order
+	// requests may select to a description of each. It is used for the
+	// /directory response's "meta" element's "profiles" field.
+	CertProfiles map[string]string
+
 	// Allowed prefix for legacy accounts used by verify.go's `lookupJWK`.
 	// See `cmd/boulder-wfe2/main.go`'s comment on the configuration field
 	// `LegacyKeyIDPrefix` for more informaton.
@@ -97,6 +125,12 @@ type WebFrontEndImpl struct {
 	// Register of anti-replay nonces
 	nonceService *nonce.NonceService
 
+	// remoteNonceService, if non-nil, issues nonces in place of nonceService.
+	// noncePrefixMap maps the prefix of each remote nonce service instance to
+	// the client used to redeem the nonces it issued.
+	remoteNonceService noncepb.NonceServiceClient
+	noncePrefixMap     map[string]noncepb.NonceServiceClient
+
 	// Key policy.
 	keyPolicy goodkey.KeyPolicy
 
@@ -108,6 +142,28 @@ type WebFrontEndImpl struct {
 
 	AcceptRevocationReason bool
 	AllowAuthzDeactivation bool
+
+	// OrdersPerPage is the number of order URLs returned per page of an
+	// account's orders list. Zero means defaultOrdersPerPage.
+	OrdersPerPage int
+
+	// massRevocations holds the serials of certificates which have been flagged
+	// for mass revocation. The renewalInfo endpoint tells subscribers to renew
+	// these immediately.
+	massRevocations *massRevocationSet
+}
+
+// massRevocationSet is a set of certificate serials which can be replaced
+// wholesale when the file it was loaded from changes.
+type massRevocationSet struct {
+	sync.RWMutex
+	serials map[string]bool
+}
+
+func (s *massRevocationSet) contains(serial string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.serials[serial]
 }
 
 // NewWebFrontEndImpl constructs a web service for Boulder
@@ -116,24 +172,66 @@ func NewWebFrontEndImpl(
 	clk clock.Clock,
 	keyPolicy goodkey.KeyPolicy,
 	certificateChains map[string][]byte,
+	remoteNonceService noncepb.NonceServiceClient,
+	noncePrefixMap map[string]noncepb.NonceServiceClient,
 	logger blog.Logger,
 ) (WebFrontEndImpl, error) {
-	nonceService, err := nonce.NewNonceService(scope)
+	nonceService, err := nonce.NewNonceService(scope, 0, "")
 	if err != nil {
 		return WebFrontEndImpl{}, err
 	}
+	if remoteNonceService != nil && len(noncePrefixMap) == 0 {
+		return WebFrontEndImpl{}, errors.New("a remote nonce service requires at least one nonce service to redeem nonces with")
+	}
+
+	chainIssuers := make(map[string]*x509.Certificate, len(certificateChains))
+	var chainURLs []string
+	for aiaIssuerURL, chain := range certificateChains {
+		block, _ := pem.Decode(chain)
+		if block == nil {
+			return WebFrontEndImpl{}, fmt.Errorf("certificate chain for AIA issuer URL %q contains no PEM certificate", aiaIssuerURL)
+		}
+		issuer, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return WebFrontEndImpl{}, fmt.Errorf("certificate chain for AIA issuer URL %q has an invalid first certificate: %s", aiaIssuerURL, err)
+		}
+		chainIssuers[aiaIssuerURL] = issuer
+		chainURLs = append(chainURLs, aiaIssuerURL)
+	}
+	sort.Strings(chainURLs)
 
 	return WebFrontEndImpl{
-		log:               logger,
-		clk:               clk,
-		nonceService:      nonceService,
-		keyPolicy:         keyPolicy,
-		certificateChains: certificateChains,
-		stats:             initStats(scope),
-		scope:             scope,
+		log:                logger,
+		clk:                clk,
+		nonceService:       nonceService,
+		remoteNonceService: remoteNonceService,
+		noncePrefixMap:     noncePrefixMap,
+		keyPolicy:          keyPolicy,
+		certificateChains:  certificateChains,
+		chainIssuers:       chainIssuers,
+		chainURLs:          chainURLs,
+		stats:              initStats(scope),
+		scope:              scope,
+		massRevocations:    &massRevocationSet{},
 	}, nil
 }
 
+// nonce returns a fresh anti-replay nonce, from the remote nonce service if
+// one is configured.
+func (wfe *WebFrontEndImpl) nonce(ctx context.Context) (string, error) {
+	if wfe.remoteNonceService == nil {
+		return wfe.nonceService.Nonce()
+	}
+	msg, err := wfe.remoteNonceService.Nonce(ctx, &corepb.Empty{})
+	if err != nil {
+		return "", err
+	}
+	if msg == nil || msg.Nonce == nil {
+		return "", errors.New("incomplete gRPC response message")
+	}
+	return *msg.Nonce, nil
+}
+
 // HandleFunc registers a handler at the given path. It's
 // http.HandleFunc(), but with a wrapper around the handler that
 // provides some generic per-request functionality:
@@ -168,7 +266,7 @@ func (wfe *WebFrontEndImpl) HandleFunc(mux *http.ServeMux, pattern string, h web
 			if request.Method != "GET" || pattern == newNoncePath {
 				// We do not propagate errors here, because (1) they should be
 				// transient, and (2) they fail closed.
-				nonce, err := wfe.nonceService.Nonce()
+				nonce, err := wfe.nonce(ctx)
 				if err == nil {
 					response.Header().Set("Replay-Nonce", nonce)
 				} else {
@@ -309,6 +407,7 @@ func (wfe *WebFrontEndImpl) Handler() http.Handler {
 	// GETable ACME endpoints
 	wfe.HandleFunc(m, directoryPath, wfe.Directory, "GET")
 	wfe.HandleFunc(m, newNoncePath, wfe.Nonce, "GET")
+	wfe.HandleFunc(m, renewalInfoPath, wfe.RenewalInfo, "GET")
 
 	// POSTable ACME endpoints
 	wfe.HandleFunc(m, newAcctPath, wfe.NewAccount, "POST")
@@ -317,6 +416,7 @@ func (wfe *WebFrontEndImpl) Handler() http.Handler {
 	wfe.HandleFunc(m, rolloverPath, wfe.KeyRollover, "POST")
 	wfe.HandleFunc(m, newOrderPath, wfe.NewOrder, "POST")
 	wfe.HandleFunc(m, finalizeOrderPath, wfe.FinalizeOrder, "POST")
+	wfe.HandleFunc(m, ordersPath, wfe.AccountOrders, "POST")
 
 	// POST-as-GETable ACME endpoints
 	// TODO(@cpu): After November 1st, 2019 support for "GET" to the following
@@ -391,6 +491,10 @@ func (wfe *WebFrontEndImpl) Directory(
 		"keyChange":  rolloverPath,
 	}
 
+	if features.Enabled(features.ServeRenewalInfo) {
+		directoryEndpoints["renewalInfo"] = renewalInfoPath
+	}
+
 	// Add a random key to the directory in order to make sure that clients don't hardcode an
 	// expected set of keys. This ensures that we can properly extend the directory when we
 	// need to add a new endpoint or meta element.
@@ -416,6 +520,16 @@ func (wfe *WebFrontEndImpl) Directory(
 	if wfe.DirectoryWebsite != "" {
 		metaMap["website"] = wfe.DirectoryWebsite
 	}
+	// The "meta" directory entry may also include a boolean indicating that new
+	// accounts must be bound to an external account
+	if wfe.ExternalAccountRequired {
+		metaMap["externalAccountRequired"] = true
+	}
+	// The "meta" directory entry may also include an object describing the
+	// certificate profiles that new orders may select
+	if len(wfe.CertProfiles) > 0 && features.Enabled(features.CertificateProfiles) {
+		metaMap["profiles"] = wfe.CertProfiles
+	}
 	directoryEndpoints["meta"] = metaMap
 
 	response.Header().Set("Content-Type", "application/json")
@@ -452,13 +566,63 @@ func (wfe *WebFrontEndImpl) Nonce(
 // sendError wraps web.SendError
 func (wfe *WebFrontEndImpl) sendError(response http.ResponseWriter, logEvent *web.RequestEvent, prob *probs.ProblemDetails, ierr error) {
 	wfe.stats.httpErrorCount.With(prometheus.Labels{"type": string(prob.Type)}).Inc()
+	if berr, ok := ierr.(*berrors.BoulderError); ok && berr.Type == berrors.RateLimit && berr.LimitName != "" {
+		wfe.addRateLimitDetails(response, prob, berr)
+	}
 	web.SendError(wfe.log, probs.V2ErrorNS, response, logEvent, prob, ierr)
 }
 
+// addRateLimitDetails adds a Retry-After header to response, and a subproblem
+// to prob, describing the rate limit berr reports was exceeded, so that
+// clients don't have to parse the problem's detail to know when to retry. If
+// berr doesn't know when the limit resets, both the header and the
+// subproblem's reset time are left out rather than guessed.
+func (wfe *WebFrontEndImpl) addRateLimitDetails(response http.ResponseWriter, prob *probs.ProblemDetails, berr *berrors.BoulderError) {
+	subProb := probs.SubProblemDetails{
+		Type:   probs.RateLimitedProblem,
+		Detail: fmt.Sprintf("%s limit exceeded for %q", berr.LimitName, berr.BucketKey),
+		Limit:  berr.LimitName,
+		Key:    berr.BucketKey,
+	}
+	if berr.ResetAt.IsZero() {
+		prob.SubProblems = append(prob.SubProblems, subProb)
+		return
+	}
+	resetAt := berr.ResetAt.UTC()
+	subProb.ResetAt = &resetAt
+	prob.SubProblems = append(prob.SubProblems, subProb)
+	// Retry-After is a whole number of seconds, so round up to avoid clients
+	// retrying just before the limit resets.
+	retryAfter := berr.ResetAt.Sub(wfe.clk.Now())
+	if retryAfter > 0 {
+		seconds := (retryAfter + time.Second - 1) / time.Second
+		response.Header().Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+	}
+}
+
 func link(url, relation string) string {
 	return fmt.Sprintf("<%s>;rel=\"%s\"", url, relation)
 }
 
+// accountJSON is an account as it is returned to clients. Orders is the URL
+// of the account's orders list (RFC 8555 Section 7.1.2.1), which is only set
+// when the ListAccountOrders feature is enabled.
+type accountJSON struct {
+	core.Registration
+	Orders string `json:"orders,omitempty"`
+}
+
+// acctToJSON returns the object to marshal when returning acct, whose ID is
+// acctID, to a client. The ID is passed separately because acct.ID is zeroed
+// when the RemoveWFE2AccountID feature is enabled.
+func (wfe *WebFrontEndImpl) acctToJSON(request *http.Request, acct core.Registration, acctID int64) accountJSON {
+	resp := accountJSON{Registration: acct}
+	if features.Enabled(features.ListAccountOrders) {
+		resp.Orders = web.RelativeEndpoint(request, fmt.Sprintf("%s%d", ordersPath, acctID))
+	}
+	return resp
+}
+
 // NewAccount is used by clients to submit a new account
 func (wfe *WebFrontEndImpl) NewAccount(
 	ctx context.Context,
@@ -469,7 +633,7 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	// NewAccount uses `validSelfAuthenticatedPOST` instead of
 	// `validPOSTforAccount` because there is no account to authenticate against
 	// until after it is created!
-	body, key, prob := wfe.validSelfAuthenticatedPOST(request, logEvent)
+	body, key, prob := wfe.validSelfAuthenticatedPOST(request, ctx, logEvent)
 	if prob != nil {
 		// validSelfAuthenticatedPOST handles its own setting of logEvent.Errors
 		wfe.sendError(response, logEvent, prob, nil)
@@ -477,9 +641,10 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	}
 
 	var accountCreateRequest struct {
-		Contact              *[]string `json:"contact"`
-		TermsOfServiceAgreed bool      `json:"termsOfServiceAgreed"`
-		OnlyReturnExisting   bool      `json:"onlyReturnExisting"`
+		Contact                *[]string       `json:"contact"`
+		TermsOfServiceAgreed   bool            `json:"termsOfServiceAgreed"`
+		OnlyReturnExisting     bool            `json:"onlyReturnExisting"`
+		ExternalAccountBinding json.RawMessage `json:"externalAccountBinding"`
 	}
 
 	err := json.Unmarshal(body, &accountCreateRequest)
@@ -494,12 +659,13 @@ func (wfe *WebFrontEndImpl) NewAccount(
 			web.RelativeEndpoint(request, fmt.Sprintf("%s%d", acctPath, existingAcct.ID)))
 		logEvent.Requester = existingAcct.ID
 
+		acctID := existingAcct.ID
 		if features.Enabled(features.RemoveWFE2AccountID) {
 			// Zero out the account ID so that it isn't marshalled
 			existingAcct.ID = 0
 		}
 
-		err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, existingAcct)
+		err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.acctToJSON(request, existingAcct, acctID))
 		if err != nil {
 			// ServerInternal because we just created this account, and it
 			// should be OK.
@@ -526,6 +692,24 @@ func (wfe *WebFrontEndImpl) NewAccount(
 		return
 	}
 
+	// If an external account binding is required the request must carry one
+	// that was made with a known, unused external account key. The key ID is
+	// passed along so that the SA can bind the key to the new account.
+	var externalAccountKeyID string
+	if wfe.ExternalAccountRequired {
+		if len(accountCreateRequest.ExternalAccountBinding) == 0 {
+			wfe.sendError(response, logEvent, probs.ExternalAccountRequired(
+				"an external account binding is required to create a new account"), nil)
+			return
+		}
+		externalAccountKeyID, prob = wfe.validExternalAccountBinding(
+			ctx, accountCreateRequest.ExternalAccountBinding, key, request)
+		if prob != nil {
+			wfe.sendError(response, logEvent, prob, nil)
+			return
+		}
+	}
+
 	ip := net.ParseIP(request.Header.Get("X-Real-IP"))
 	if ip == nil {
 		host, _, err := net.SplitHostPort(request.RemoteAddr)
@@ -543,10 +727,11 @@ func (wfe *WebFrontEndImpl) NewAccount(
 	}
 
 	acct, err := wfe.RA.NewRegistration(ctx, core.Registration{
-		Contact:   accountCreateRequest.Contact,
-		Agreement: wfe.SubscriberAgreementURL,
-		Key:       key,
-		InitialIP: ip,
+		Contact:              accountCreateRequest.Contact,
+		Agreement:            wfe.SubscriberAgreementURL,
+		Key:                  key,
+		InitialIP:            ip,
+		ExternalAccountKeyID: externalAccountKeyID,
 	})
 	if err != nil {
 		wfe.sendError(response, logEvent,
@@ -574,12 +759,13 @@ func (wfe *WebFrontEndImpl) NewAccount(
 		response.Header().Add("Link", link(wfe.SubscriberAgreementURL, "terms-of-service"))
 	}
 
+	acctID := acct.ID
 	if features.Enabled(features.RemoveWFE2AccountID) {
 		// Zero out the account ID so that it isn't marshalled
 		acct.ID = 0
 	}
 
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, acct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusCreated, wfe.acctToJSON(request, acct, acctID))
 	if err != nil {
 		// ServerInternal because we just created this account, and it
 		// should be OK.
@@ -618,26 +804,34 @@ type authorizedToRevokeCert func(*x509.Certificate) *probs.ProblemDetails
 // revoke a given certificate. If the request can not  be authenticated or the
 // requester is not authorized to revoke the certificate requested a problem is
 // returned. Otherwise the certificate is marked revoked through the SA.
+//
+// keyProof is true if the request was signed by the certificate's own key. If
+// the RevokeCertsByKey feature is enabled such a request is treated as proof of
+// key compromise: the requested reason is ignored in favour of keyCompromise
+// and other certificates using the key are revoked too, a bounded batch of
+// them straight away and the rest later by bad-key-revoker. The serials of the
+// certificates revoked straight away are returned.
 func (wfe *WebFrontEndImpl) processRevocation(
 	ctx context.Context,
 	jwsBody []byte,
 	acctID int64,
+	keyProof bool,
 	authorizedToRevoke authorizedToRevokeCert,
 	request *http.Request,
-	logEvent *web.RequestEvent) *probs.ProblemDetails {
+	logEvent *web.RequestEvent) ([]string, *probs.ProblemDetails) {
 	// Read the revoke request from the JWS payload
 	var revokeRequest struct {
 		CertificateDER core.JSONBuffer    `json:"certificate"`
 		Reason         *revocation.Reason `json:"reason"`
 	}
 	if err := json.Unmarshal(jwsBody, &revokeRequest); err != nil {
-		return probs.Malformed("Unable to JSON parse revoke request")
+		return nil, probs.Malformed("Unable to JSON parse revoke request")
 	}
 
 	// Parse the provided certificate
 	providedCert, err := x509.ParseCertificate(revokeRequest.CertificateDER)
 	if err != nil {
-		return probs.Malformed("Unable to parse certificate DER")
+		return nil, probs.Malformed("Unable to parse certificate DER")
 	}
 
 	// Compute and record the serial number of the provided certificate
@@ -649,45 +843,55 @@ func (wfe *WebFrontEndImpl) processRevocation(
 	// revocation, return an error
 	cert, err := wfe.SA.GetCertificate(ctx, serial)
 	if err != nil || !bytes.Equal(cert.DER, revokeRequest.CertificateDER) {
-		return probs.NotFound("No such certificate")
+		return nil, probs.NotFound("No such certificate")
 	}
 
 	// Parse the certificate into memory
 	parsedCertificate, err := x509.ParseCertificate(cert.DER)
 	if err != nil {
 		// InternalServerError because cert.DER came from our own DB.
-		return probs.ServerInternal("invalid parse of stored certificate")
+		return nil, probs.ServerInternal("invalid parse of stored certificate")
 	}
 	logEvent.Extra["RetrievedCertificateSerial"] = core.SerialToString(parsedCertificate.SerialNumber)
 	logEvent.Extra["RetrievedCertificateDNSNames"] = parsedCertificate.DNSNames
 
 	if parsedCertificate.NotAfter.Before(wfe.clk.Now()) {
-		return probs.Unauthorized("Certificate is expired")
+		return nil, probs.Unauthorized("Certificate is expired")
 	}
 
 	// Check the certificate status for the provided certificate to see if it is
 	// already revoked
 	certStatus, err := wfe.SA.GetCertificateStatus(ctx, serial)
 	if err != nil {
-		return probs.NotFound("Certificate status not yet available")
+		return nil, probs.NotFound("Certificate status not yet available")
 	}
 	logEvent.Extra["CertificateStatus"] = certStatus.Status
 
 	if certStatus.Status == core.OCSPStatusRevoked {
-		return probs.AlreadyRevoked("Certificate already revoked")
+		return nil, probs.AlreadyRevoked("Certificate already revoked")
 	}
 
 	// Validate that the requester is authenticated to revoke the given certificate
 	prob := authorizedToRevoke(parsedCertificate)
 	if prob != nil {
-		return prob
+		return nil, prob
+	}
+
+	if keyProof && features.Enabled(features.RevokeCertsByKey) {
+		resp, err := wfe.RA.RevokeCertByKey(ctx, &rapb.RevokeCertByKeyRequest{Cert: parsedCertificate.Raw})
+		if err != nil {
+			return nil, web.ProblemDetailsForError(err, "Failed to revoke certificate")
+		}
+		logEvent.Extra["RevokedSerials"] = resp.Serials
+		wfe.log.Debugf("Revoked %v for keyCompromise", resp.Serials)
+		return resp.Serials, nil
 	}
 
 	// Verify the revocation reason supplied is allowed
 	reason := revocation.Reason(0)
 	if revokeRequest.Reason != nil && wfe.AcceptRevocationReason {
 		if _, present := revocation.UserAllowedReasons[*revokeRequest.Reason]; !present {
-			return probs.Malformed("unsupported revocation reason code provided")
+			return nil, probs.Malformed("unsupported revocation reason code provided")
 		}
 		reason = *revokeRequest.Reason
 	}
@@ -695,11 +899,11 @@ func (wfe *WebFrontEndImpl) processRevocation(
 	// Revoke the certificate. AcctID may be 0 if there is no associated account
 	// (e.g. it was a self-authenticated JWS using the certificate public key)
 	if err := wfe.RA.RevokeCertificateWithReg(ctx, *parsedCertificate, reason, acctID); err != nil {
-		return web.ProblemDetailsForError(err, "Failed to revoke certificate")
+		return nil, web.ProblemDetailsForError(err, "Failed to revoke certificate")
 	}
 
 	wfe.log.Debugf("Revoked %v", serial)
-	return nil
+	return nil, nil
 }
 
 // revokeCertByKeyID processes an outer JWS as a revocation request that is
@@ -726,7 +930,8 @@ func (wfe *WebFrontEndImpl) revokeCertByKeyID(
 		if cert.RegistrationID == acct.ID {
 			return nil
 		}
-		valid, err := wfe.acctHoldsAuthorizations(ctx, acct.ID, parsedCertificate.DNSNames)
+		valid, err := wfe.acctHoldsAuthorizations(ctx, acct.ID,
+			core.IdentifierNames(parsedCertificate.DNSNames, parsedCertificate.IPAddresses))
 		if err != nil {
 			return probs.ServerInternal("Failed to retrieve authorizations for names in certificate")
 		}
@@ -736,18 +941,20 @@ func (wfe *WebFrontEndImpl) revokeCertByKeyID(
 		}
 		return nil
 	}
-	return wfe.processRevocation(ctx, jwsBody, acct.ID, authorizedToRevoke, request, logEvent)
+	_, prob = wfe.processRevocation(ctx, jwsBody, acct.ID, false, authorizedToRevoke, request, logEvent)
+	return prob
 }
 
 // revokeCertByJWK processes an outer JWS as a revocation request that is
 // authenticated by an embedded JWK. E.g. in the case where someone is
 // requesting a revocation by using the keypair associated with the certificate
-// to be revoked
+// to be revoked. It returns the serials of any other certificates revoked
+// because they share that key, as described for processRevocation.
 func (wfe *WebFrontEndImpl) revokeCertByJWK(
 	ctx context.Context,
 	outerJWS *jose.JSONWebSignature,
 	request *http.Request,
-	logEvent *web.RequestEvent) *probs.ProblemDetails {
+	logEvent *web.RequestEvent) ([]string, *probs.ProblemDetails) {
 	// We maintain the requestKey as a var that is closed-over by the
 	// `authorizedToRevoke` function to use
 	var requestKey *jose.JSONWebKey
@@ -755,9 +962,9 @@ func (wfe *WebFrontEndImpl) revokeCertByJWK(
 	// `validSelfAuthenticatedJWS` similar to new-reg and key rollover.
 	// We do *not* use `validSelfAuthenticatedPOST` here because we've already
 	// read the HTTP request body in `parseJWSRequest` and it is now empty.
-	jwsBody, jwk, prob := wfe.validSelfAuthenticatedJWS(outerJWS, request, logEvent)
+	jwsBody, jwk, prob := wfe.validSelfAuthenticatedJWS(outerJWS, request, ctx, logEvent)
 	if prob != nil {
-		return prob
+		return nil, prob
 	}
 	requestKey = jwk
 	// For embedded JWK revocations we decide if a requester is able to revoke a specific
@@ -772,7 +979,7 @@ func (wfe *WebFrontEndImpl) revokeCertByJWK(
 	}
 	// We use `0` as the account ID provided to `processRevocation` because this
 	// is a self-authenticated request.
-	return wfe.processRevocation(ctx, jwsBody, 0, authorizedToRevoke, request, logEvent)
+	return wfe.processRevocation(ctx, jwsBody, 0, true, authorizedToRevoke, request, logEvent)
 }
 
 // RevokeCertificate is used by clients to request the revocation of a cert. The
@@ -807,11 +1014,12 @@ func (wfe *WebFrontEndImpl) RevokeCertificate(
 
 	// Handle the revocation request according to how it is authenticated, or if
 	// the authentication type is unknown, error immediately
+	var revokedSerials []string
 	if authType == embeddedKeyID {
 		prob = wfe.revokeCertByKeyID(ctx, jws, request, logEvent)
 		addRequesterHeader(response, logEvent.Requester)
 	} else if authType == embeddedJWK {
-		prob = wfe.revokeCertByJWK(ctx, jws, request, logEvent)
+		revokedSerials, prob = wfe.revokeCertByJWK(ctx, jws, request, logEvent)
 	} else {
 		prob = probs.Malformed("Malformed JWS, no KeyID or embedded JWK")
 	}
@@ -819,6 +1027,21 @@ func (wfe *WebFrontEndImpl) RevokeCertificate(
 		wfe.sendError(response, logEvent, prob, nil)
 		return
 	}
+	if revokedSerials != nil {
+		// Let the requester know which certificates were revoked for
+		// keyCompromise because they share the requested certificate's key.
+		err := wfe.writeJsonResponse(response, logEvent, http.StatusOK, struct {
+			Reason              revocation.Reason `json:"reason"`
+			RevokedCertificates []string          `json:"revokedCertificates"`
+		}{
+			Reason:              revocation.KeyCompromise,
+			RevokedCertificates: revokedSerials,
+		})
+		if err != nil {
+			wfe.sendError(response, logEvent, probs.ServerInternal("Failed to marshal revocation result"), err)
+		}
+		return
+	}
 	response.WriteHeader(http.StatusOK)
 }
 
@@ -929,7 +1152,7 @@ func (wfe *WebFrontEndImpl) Challenge(
 	challenge := authz.Challenges[challengeIndex]
 
 	logEvent.Extra["ChallengeType"] = challenge.Type
-	if authz.Identifier.Type == core.IdentifierDNS {
+	if authz.Identifier.Type == core.IdentifierDNS || authz.Identifier.Type == core.IdentifierIP {
 		logEvent.DNSName = authz.Identifier.Value
 	}
 	logEvent.Status = string(authz.Status)
@@ -1172,7 +1395,7 @@ func (wfe *WebFrontEndImpl) Account(
 	// returning the account.
 	currAcct.Agreement = ""
 
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, currAcct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.acctToJSON(request, *currAcct, currAcct.ID))
 	if err != nil {
 		// ServerInternal because we just generated the account, it should be OK
 		wfe.sendError(response, logEvent,
@@ -1181,6 +1404,86 @@ func (wfe *WebFrontEndImpl) Account(
 	}
 }
 
+// AccountOrders is used to list an account's orders, newest first. It only
+// accepts POST-as-GET requests signed by the account itself. The path is the
+// account ID, optionally followed by the ID of the last order on the previous
+// page, e.g. "1" or "1/42". If there may be more orders a Link header with
+// rel="next" points at the next page.
+func (wfe *WebFrontEndImpl) AccountOrders(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	if !features.Enabled(features.ListAccountOrders) {
+		wfe.sendError(response, logEvent, probs.NotFound("Feature not enabled"), nil)
+		return
+	}
+
+	acct, prob := wfe.validPOSTAsGETForAccount(request, ctx, logEvent)
+	addRequesterHeader(response, logEvent.Requester)
+	if prob != nil {
+		wfe.sendError(response, logEvent, prob, nil)
+		return
+	}
+
+	// Path prefix is stripped, so this should be like "<account ID>" or
+	// "<account ID>/<cursor>"
+	fields := strings.Split(request.URL.Path, "/")
+	if len(fields) > 2 {
+		wfe.sendError(response, logEvent, probs.NotFound("Invalid request path"), nil)
+		return
+	}
+	acctID, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.Malformed("Invalid account ID"), err)
+		return
+	}
+	if acctID != acct.ID {
+		wfe.sendError(response, logEvent,
+			probs.Unauthorized("Request signing key did not match account key"), nil)
+		return
+	}
+	var cursor int64
+	if len(fields) == 2 {
+		cursor, err = strconv.ParseInt(fields[1], 10, 64)
+		if err != nil || cursor <= 0 {
+			wfe.sendError(response, logEvent, probs.Malformed("Invalid page cursor"), err)
+			return
+		}
+	}
+
+	perPage := int64(wfe.OrdersPerPage)
+	if perPage <= 0 {
+		perPage = defaultOrdersPerPage
+	}
+	ids, err := wfe.SA.GetOrdersForAccount(ctx, &sapb.GetOrdersForAccountRequest{
+		RegistrationID: &acctID,
+		Before:         &cursor,
+		Limit:          &perPage,
+	})
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to retrieve orders"), err)
+		return
+	}
+
+	// A full page means there may be more orders. Worst case the next page is
+	// empty.
+	if int64(len(ids.Ids)) == perPage {
+		next := web.RelativeEndpoint(request,
+			fmt.Sprintf("%s%d/%d", ordersPath, acctID, ids.Ids[len(ids.Ids)-1]))
+		response.Header().Add("Link", link(next, "next"))
+	}
+
+	orderURLs := []string{}
+	for _, id := range ids.Ids {
+		orderURLs = append(orderURLs,
+			web.RelativeEndpoint(request, fmt.Sprintf("%s%d/%d", orderPath, acctID, id)))
+	}
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, struct {
+		Orders []string `json:"orders"`
+	}{orderURLs})
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshaling orders"), err)
+		return
+	}
+}
+
 // updateAccount unmarshals an account update request from the provided
 // requestBody to update the given registration. Important: It is assumed the
 // request has already been authenticated by the caller. If the request is
@@ -1332,7 +1635,7 @@ func (wfe *WebFrontEndImpl) Authorization(ctx context.Context, logEvent *web.Req
 			return
 		}
 	}
-	if authz.Identifier.Type == core.IdentifierDNS {
+	if authz.Identifier.Type == core.IdentifierDNS || authz.Identifier.Type == core.IdentifierIP {
 		logEvent.DNSName = authz.Identifier.Value
 	}
 	logEvent.Status = string(authz.Status)
@@ -1445,24 +1748,20 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 			return
 		}
 
-		// NOTE(@cpu): Boulder assumes there will only be **ONE** AIA issuer URL
-		// configured in the CA signing profile. At present this is not enforced by
-		// the CA, but should be. See
-		//  https://github.com/letsencrypt/boulder/issues/3374
-		aiaIssuerURL := parsedCert.IssuingCertificateURL[0]
-		if chain, ok := wfe.certificateChains[aiaIssuerURL]; ok {
+		if chain, ok := wfe.chainFor(parsedCert); ok {
 			// Prepend the chain with the leaf certificate
 			responsePEM = append(leafPEM, chain...)
 		} else {
-			// If there is no wfe.certificateChains entry for the AIA Issuer URL there
-			// is probably a misconfiguration and we should treat it as an internal
-			// server error.
+			// If there is no wfe.certificateChains entry for the certificate's
+			// issuer there is probably a misconfiguration and we should treat it
+			// as an internal server error.
 			wfe.sendError(response, logEvent, probs.ServerInternal(
 				fmt.Sprintf(
-					"Certificate serial %#v has an unknown AIA Issuer URL %q"+
+					"Certificate serial %#v has an unknown issuer %q and AIA Issuer URL %q"+
 						"- no PEM certificate chain associated.",
 					serial,
-					aiaIssuerURL),
+					parsedCert.Issuer.CommonName,
+					strings.Join(parsedCert.IssuingCertificateURL, ", ")),
 			), nil)
 			return
 		}
@@ -1486,6 +1785,168 @@ func (wfe *WebFrontEndImpl) Certificate(ctx context.Context, logEvent *web.Reque
 	return
 }
 
+// issuedBy returns true if issuer's name, and subject key identifier if both
+// are present, match those cert names as its issuer.
+func issuedBy(cert, issuer *x509.Certificate) bool {
+	if !bytes.Equal(cert.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(cert.AuthorityKeyId) > 0 && len(issuer.SubjectKeyId) > 0 {
+		return bytes.Equal(cert.AuthorityKeyId, issuer.SubjectKeyId)
+	}
+	return true
+}
+
+// chainFor returns the configured certificate chain for cert's issuer. With
+// several issuers active, or an issuer reachable at more than one AIA issuer
+// URL, the chain configured for cert's AIA issuer URL is preferred, as long as
+// it starts with cert's issuer. Otherwise the first chain, by URL, that starts
+// with cert's issuer is used. There is no chain for a certificate from an
+// issuer that starts none of the chains, since serving one would present the
+// certificate with the wrong intermediate.
+func (wfe *WebFrontEndImpl) chainFor(cert *x509.Certificate) ([]byte, bool) {
+	// NOTE(@cpu): Boulder assumes there will only be **ONE** AIA issuer URL
+	// configured in the CA signing profile. At present this is not enforced by
+	// the CA, but should be. See
+	//  https://github.com/letsencrypt/boulder/issues/3374
+	var aiaIssuerURL string
+	if len(cert.IssuingCertificateURL) > 0 {
+		aiaIssuerURL = cert.IssuingCertificateURL[0]
+	}
+	chain, ok := wfe.certificateChains[aiaIssuerURL]
+	if ok && issuedBy(cert, wfe.chainIssuers[aiaIssuerURL]) {
+		return chain, true
+	}
+	for _, url := range wfe.chainURLs {
+		if issuedBy(cert, wfe.chainIssuers[url]) {
+			return wfe.certificateChains[url], true
+		}
+	}
+	return nil, false
+}
+
+// renewalInfoRetryAfter is how long clients are asked to wait before polling
+// the renewalInfo endpoint for the same certificate again.
+const renewalInfoRetryAfter = 6 * time.Hour
+
+// parseCertID parses the unique identifier of a certificate from the path of a
+// renewalInfo request. Per draft-ietf-acme-ari the identifier is the
+// base64url-encoded keyIdentifier of the certificate's Authority Key
+// Identifier extension and the base64url-encoded bytes of its serial number,
+// separated by a period. It returns the keyIdentifier and the serial in the
+// form used by the SA.
+func parseCertID(path string) ([]byte, string, error) {
+	parts := strings.Split(path, ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, "", errors.New("certificate identifier must be two base64url-encoded values separated by a period")
+	}
+	akid, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding authority key identifier: %s", err)
+	}
+	serialBytes, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, "", fmt.Errorf("decoding serial number: %s", err)
+	}
+	return akid, core.SerialToString(new(big.Int).SetBytes(serialBytes)), nil
+}
+
+// RenewalInfo is used to get information about the suggested renewal window
+// for the given certificate. It only accepts unauthenticated GET requests.
+// The window is two thirds of the way through the certificate's validity
+// period, unless the certificate has been revoked or flagged for mass
+// revocation, in which case the window is in the past and the client should
+// renew immediately.
+func (wfe *WebFrontEndImpl) RenewalInfo(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
+	if !features.Enabled(features.ServeRenewalInfo) {
+		wfe.sendError(response, logEvent, probs.NotFound("Feature not enabled"), nil)
+		return
+	}
+
+	akid, serial, err := parseCertID(request.URL.Path)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.Malformed("Invalid certificate identifier"), err)
+		return
+	}
+	logEvent.Extra["RequestedSerial"] = serial
+
+	cert, err := wfe.SA.GetCertificate(ctx, serial)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"),
+			fmt.Errorf("unable to get certificate by serial id %#v: %s", serial, err))
+		return
+	}
+	parsedCert, err := x509.ParseCertificate(cert.DER)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal(
+			fmt.Sprintf("unable to parse Boulder issued certificate with serial %#v", serial)), err)
+		return
+	}
+	// The serial alone isn't unique across issuers, so the certificate is only
+	// a match if it was also issued by the key the client named.
+	if !bytes.Equal(parsedCert.AuthorityKeyId, akid) {
+		wfe.sendError(response, logEvent, probs.NotFound("Certificate not found"),
+			fmt.Errorf("certificate %#v has a different authority key identifier", serial))
+		return
+	}
+
+	status, err := wfe.SA.GetCertificateStatus(ctx, serial)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Unable to get certificate status"), err)
+		return
+	}
+
+	var ri core.RenewalInfo
+	if status.Status == core.OCSPStatusRevoked || wfe.massRevocations.contains(serial) {
+		ri = core.RenewalInfoImmediate(wfe.clk.Now())
+	} else {
+		ri = core.RenewalInfoSimple(parsedCert.NotBefore, parsedCert.NotAfter)
+	}
+
+	response.Header().Set("Retry-After", strconv.Itoa(int(renewalInfoRetryAfter/time.Second)))
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, ri)
+	if err != nil {
+		wfe.sendError(response, logEvent, probs.ServerInternal("Error marshalling renewalInfo"), err)
+		return
+	}
+}
+
+type massRevocationJSON struct {
+	Serials []string
+}
+
+// SetMassRevocationFile loads the serials of certificates flagged for mass
+// revocation from the given JSON file, returning an error if it fails. It also
+// starts a reloader in case the file changes.
+func (wfe *WebFrontEndImpl) SetMassRevocationFile(filename string) error {
+	_, err := reloader.New(filename, wfe.loadMassRevocations, wfe.massRevocationsLoadError)
+	return err
+}
+
+func (wfe *WebFrontEndImpl) massRevocationsLoadError(err error) {
+	wfe.log.AuditErrf("error loading mass revocation list: %s", err)
+}
+
+func (wfe *WebFrontEndImpl) loadMassRevocations(b []byte) error {
+	var list massRevocationJSON
+	err := json.Unmarshal(b, &list)
+	if err != nil {
+		return err
+	}
+	serials := make(map[string]bool, len(list.Serials))
+	for _, serial := range list.Serials {
+		if !core.ValidSerial(serial) {
+			return fmt.Errorf("invalid serial %q in mass revocation list", serial)
+		}
+		serials[serial] = true
+	}
+	wfe.massRevocations.Lock()
+	wfe.massRevocations.serials = serials
+	wfe.massRevocations.Unlock()
+	wfe.log.Infof("loaded mass revocation list with %d serials", len(serials))
+	return nil
+}
+
 // Issuer obtains the issuer certificate used by this instance of Boulder.
 func (wfe *WebFrontEndImpl) Issuer(ctx context.Context, logEvent *web.RequestEvent, response http.ResponseWriter, request *http.Request) {
 	// TODO Content negotiation
@@ -1593,7 +2054,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 	}
 
 	// Validate the inner JWS as a key rollover request for the outer JWS
-	rolloverOperation, prob := wfe.validKeyRollover(outerJWS, innerJWS, oldKey, logEvent)
+	rolloverOperation, prob := wfe.validKeyRollover(outerJWS, innerJWS, oldKey, ctx, logEvent)
 	if prob != nil {
 		wfe.sendError(response, logEvent, prob, nil)
 		return
@@ -1649,7 +2110,7 @@ func (wfe *WebFrontEndImpl) KeyRollover(
 		return
 	}
 
-	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, updatedAcct)
+	err = wfe.writeJsonResponse(response, logEvent, http.StatusOK, wfe.acctToJSON(request, updatedAcct, updatedAcct.ID))
 	if err != nil {
 		wfe.sendError(response, logEvent, probs.ServerInternal("Failed to marshal updated account"), err)
 	}
@@ -1663,16 +2124,17 @@ type orderJSON struct {
 	Finalize       string                `json:"finalize"`
 	Certificate    string                `json:"certificate,omitempty"`
 	Error          *probs.ProblemDetails `json:"error,omitempty"`
+	Profile        string                `json:"profile,omitempty"`
 }
 
 // orderToOrderJSON converts a *corepb.Order instance into an orderJSON struct
 // that is returned in HTTP API responses. It will convert the order names to
-// DNS type identifiers and additionally create absolute URLs for the finalize
-// URL and the ceritificate URL as appropriate.
+// DNS or IP type identifiers and additionally create absolute URLs for the
+// finalize URL and the ceritificate URL as appropriate.
 func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corepb.Order) orderJSON {
 	idents := make([]core.AcmeIdentifier, len(order.Names))
 	for i, name := range order.Names {
-		idents[i] = core.AcmeIdentifier{Type: core.IdentifierDNS, Value: name}
+		idents[i] = core.IdentifierForName(name)
 	}
 	finalizeURL := web.RelativeEndpoint(request,
 		fmt.Sprintf("%s%d/%d", finalizeOrderPath, *order.RegistrationID, *order.Id))
@@ -1682,6 +2144,7 @@ func (wfe *WebFrontEndImpl) orderToOrderJSON(request *http.Request, order *corep
 		Identifiers:    idents,
 		Authorizations: make([]string, len(order.Authorizations)),
 		Finalize:       finalizeURL,
+		Profile:        order.GetCertificateProfileName(),
 	}
 	// If there is an order error, prefix its type with the V2 namespace
 	if order.Error != nil {
@@ -1718,12 +2181,14 @@ func (wfe *WebFrontEndImpl) NewOrder(
 		return
 	}
 
-	// We only allow specifying Identifiers in a new order request - if the
-	// `notBefore` and/or `notAfter` fields described in Section 7.4 of acme-08
-	// are sent we return a probs.Malformed as we do not support them
+	// We only allow specifying Identifiers and a certificate profile in a new
+	// order request - if the `notBefore` and/or `notAfter` fields described in
+	// Section 7.4 of acme-08 are sent we return a probs.Malformed as we do not
+	// support them
 	var newOrderRequest struct {
 		Identifiers         []core.AcmeIdentifier `json:"identifiers"`
 		NotBefore, NotAfter string
+		Profile             string `json:"profile"`
 	}
 	err := json.Unmarshal(body, &newOrderRequest)
 	if err != nil {
@@ -1741,14 +2206,32 @@ func (wfe *WebFrontEndImpl) NewOrder(
 		wfe.sendError(response, logEvent, probs.Malformed("NotBefore and NotAfter are not supported"), nil)
 		return
 	}
+	if newOrderRequest.Profile != "" && !features.Enabled(features.CertificateProfiles) {
+		wfe.sendError(response, logEvent, probs.Malformed("NewOrder request specified a profile but profiles are not supported"), nil)
+		return
+	}
+	if _, ok := wfe.CertProfiles[newOrderRequest.Profile]; newOrderRequest.Profile != "" && !ok {
+		wfe.sendError(response, logEvent,
+			probs.Malformed("NewOrder request specified unsupported profile %q", newOrderRequest.Profile), nil)
+		return
+	}
 
-	// Collect up all of the DNS identifier values into a []string for subsequent
-	// layers to process. We reject anything with a non-DNS type identifier here.
+	// Collect up all of the DNS and IP identifier values into a []string for
+	// subsequent layers to process, which tell the two apart by whether the value
+	// is an IP address. We reject any other type of identifier here, as well as
+	// DNS identifiers for IP addresses and IP identifiers for anything else.
 	names := make([]string, len(newOrderRequest.Identifiers))
 	for i, ident := range newOrderRequest.Identifiers {
-		if ident.Type != core.IdentifierDNS {
+		if ident.Type != core.IdentifierDNS && ident.Type != core.IdentifierIP {
+			wfe.sendError(response, logEvent,
+				probs.Malformed("NewOrder request included invalid identifier type: type %q, value %q",
+					ident.Type, ident.Value),
+				nil)
+			return
+		}
+		if core.IdentifierForName(ident.Value).Type != ident.Type {
 			wfe.sendError(response, logEvent,
-				probs.Malformed("NewOrder request included invalid non-DNS type identifier: type %q, value %q",
+				probs.Malformed("NewOrder request included invalid %s identifier value %q",
 					ident.Type, ident.Value),
 				nil)
 			return
@@ -1756,10 +2239,14 @@ func (wfe *WebFrontEndImpl) NewOrder(
 		names[i] = ident.Value
 	}
 
-	order, err := wfe.RA.NewOrder(ctx, &rapb.NewOrderRequest{
+	newOrderReq := &rapb.NewOrderRequest{
 		RegistrationID: &acct.ID,
 		Names:          names,
-	})
+	}
+	if newOrderRequest.Profile != "" {
+		newOrderReq.CertificateProfileName = &newOrderRequest.Profile
+	}
+	order, err := wfe.RA.NewOrder(ctx, newOrderReq)
 	if err != nil {
 		wfe.sendError(response, logEvent, web.ProblemDetailsForError(err, "Error creating new order"), err)
 		return
@@ -13,6 +13,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math/big"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
@@ -23,6 +24,7 @@ import (
 	"time"
 
 	"github.com/jmhodges/clock"
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/net/context"
 	"gopkg.in/square/go-jose.v2"
 
@@ -208,6 +210,7 @@ EeMZ9nWyIM6bktLrE11HnFOnKhAYsM5fZA==
 
 type MockRegistrationAuthority struct {
 	lastRevocationReason revocation.Reason
+	lastRevokedByKey     []byte
 }
 
 func (ra *MockRegistrationAuthority) NewRegistration(ctx context.Context, acct core.Registration) (core.Registration, error) {
@@ -269,6 +272,8 @@ func (ra *MockRegistrationAuthority) NewOrder(ctx context.Context, req *rapb.New
 		Names:          req.Names,
 		Status:         &status,
 		Authorizations: []string{"hello"},
+
+		CertificateProfileName: req.CertificateProfileName,
 	}, nil
 }
 
@@ -278,6 +283,24 @@ func (ra *MockRegistrationAuthority) FinalizeOrder(ctx context.Context, req *rap
 	return req.Order, nil
 }
 
+// RevokeCertByKey reports that a second certificate using the same key was
+// revoked along with the requested one.
+func (ra *MockRegistrationAuthority) RevokeCertByKey(ctx context.Context, req *rapb.RevokeCertByKeyRequest) (*rapb.RevokeCertByKeyResponse, error) {
+	ra.lastRevocationReason = revocation.KeyCompromise
+	ra.lastRevokedByKey = req.Cert
+	cert, err := x509.ParseCertificate(req.Cert)
+	if err != nil {
+		return nil, err
+	}
+	return &rapb.RevokeCertByKeyResponse{
+		Serials: []string{core.SerialToString(cert.SerialNumber), "00000000000000000000000000000000beef"},
+	}, nil
+}
+
+func (ra *MockRegistrationAuthority) GetRateLimitUsage(ctx context.Context, _ *rapb.RateLimitUsageRequest) (*rapb.RateLimitUsages, error) {
+	return nil, nil
+}
+
 type mockPA struct{}
 
 func (pa *mockPA) ChallengesFor(identifier core.AcmeIdentifier) (challenges []core.Challenge, err error) {
@@ -355,7 +378,7 @@ func setupWFE(t *testing.T) (WebFrontEndImpl, clock.FakeClock) {
 		"http://localhost:4000/acme/issuer-cert": append([]byte{'\n'}, chainPEM...),
 	}
 
-	wfe, err := NewWebFrontEndImpl(stats, fc, testKeyPolicy, certChains, blog.NewMock())
+	wfe, err := NewWebFrontEndImpl(stats, fc, testKeyPolicy, certChains, nil, nil, blog.NewMock())
 	test.AssertNotError(t, err, "Unable to create WFE")
 
 	wfe.SubscriberAgreementURL = agreementURL
@@ -771,6 +794,31 @@ func TestDirectory(t *testing.T) {
 	test.AssertEquals(t,
 		randomDirectoryKeyPresent(t, responseWriter.Body.Bytes()),
 		true)
+
+	// Require external account bindings
+	wfe.ExternalAccountRequired = true
+
+	// Expect the meta entry to also have externalAccountRequired
+	metaJSON = `{
+  "AAAAAAAAAAA": "https://community.letsencrypt.org/t/adding-random-entries-to-the-directory/33417",
+  "keyChange": "http://localhost:4300/acme/key-change",
+  "meta": {
+    "caaIdentities": [
+      "Radiant Lock"
+    ],
+    "externalAccountRequired": true,
+    "termsOfService": "http://example.invalid/terms",
+    "website": "zombo.com"
+  },
+  "newAccount": "http://localhost:4300/acme/new-acct",
+  "newNonce": "http://localhost:4300/acme/new-nonce",
+  "newOrder": "http://localhost:4300/acme/new-order",
+  "revokeCert": "http://localhost:4300/acme/revoke-cert"
+}`
+	responseWriter = httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, req)
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), metaJSON)
 }
 
 func TestRelativeDirectory(t *testing.T) {
@@ -1451,6 +1499,60 @@ func TestNewAccountNoID(t *testing.T) {
 	}`)
 }
 
+// mockRANewRegistration records the registration it was asked to create.
+type mockRANewRegistration struct {
+	MockRegistrationAuthority
+	created *core.Registration
+}
+
+func (ra *mockRANewRegistration) NewRegistration(ctx context.Context, acct core.Registration) (core.Registration, error) {
+	ra.created = &acct
+	return ra.MockRegistrationAuthority.NewRegistration(ctx, acct)
+}
+
+func TestNewAccountExternalAccountBinding(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	ra := &mockRANewRegistration{}
+	wfe.RA = ra
+	wfe.ExternalAccountRequired = true
+
+	key := loadKey(t, []byte(test2KeyPrivatePEM))
+	jwkJSON, err := (&jose.JSONWebKey{Key: key.Public()}).MarshalJSON()
+	test.AssertNotError(t, err, "Failed to marshal account JWK")
+	signedURL := fmt.Sprintf("http://localhost%s", newAcctPath)
+
+	newAccount := func(eab string) *httptest.ResponseRecorder {
+		payload := `{"contact":["mailto:person@mail.com"],"termsOfServiceAgreed":true`
+		if eab != "" {
+			payload += `,"externalAccountBinding":` + eab
+		}
+		payload += `}`
+		_, _, body := signRequestEmbed(t, key, signedURL, payload, wfe.nonceService)
+		responseWriter := httptest.NewRecorder()
+		wfe.NewAccount(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(newAcctPath, body))
+		return responseWriter
+	}
+
+	// Without a binding no account is created
+	responseWriter := newAccount("")
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`externalAccountRequired","detail":"an external account binding is required to create a new account","status":400}`)
+	test.Assert(t, ra.created == nil, "Account was created without an external account binding")
+
+	// Nor with a binding made with an unusable key
+	responseWriter = newAccount(signEAB(t, jose.HS256, "eab-bound", testEABKey, signedURL, jwkJSON, nil))
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+	test.Assert(t, ra.created == nil, "Account was created with an already used external account key")
+
+	// With a valid binding the account is created and bound to the key
+	responseWriter = newAccount(signEAB(t, jose.HS256, "eab-key", testEABKey, signedURL, jwkJSON, nil))
+	test.AssertEquals(t, responseWriter.Code, http.StatusCreated)
+	test.Assert(t, ra.created != nil, "Account wasn't created with a valid external account binding")
+	test.AssertEquals(t, ra.created.ExternalAccountKeyID, "eab-key")
+	test.Assert(t, !strings.Contains(responseWriter.Body.String(), "eab-key"),
+		"External account key ID was returned to the client")
+}
+
 func TestGetAuthorization(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -1882,6 +1984,73 @@ func TestGetCertificate(t *testing.T) {
 
 // This uses httptest.NewServer because ServeMux.ServeHTTP won't prevent the
 // body from being sent like the net/http Server's actually do.
+func TestChainForIssuer(t *testing.T) {
+	keyPEM, err := ioutil.ReadFile("../test/test-ca.key")
+	test.AssertNotError(t, err, "Unable to read ../test/test-ca.key")
+	block, _ := pem.Decode(keyPEM)
+	issuerKey, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	test.AssertNotError(t, err, "Unable to parse ../test/test-ca.key")
+	// test-ca.pem and test-ca2.pem share a key but have different names.
+	caPEM, err := ioutil.ReadFile("../test/test-ca.pem")
+	test.AssertNotError(t, err, "Unable to read ../test/test-ca.pem")
+	ca2PEM, err := ioutil.ReadFile("../test/test-ca2.pem")
+	test.AssertNotError(t, err, "Unable to read ../test/test-ca2.pem")
+	ca2, err := core.LoadCert("../test/test-ca2.pem")
+	test.AssertNotError(t, err, "Unable to load ../test/test-ca2.pem")
+
+	caChain := append([]byte{'\n'}, caPEM...)
+	ca2Chain := append([]byte{'\n'}, ca2PEM...)
+	wfe, err := NewWebFrontEndImpl(metrics.NewNoopScope(), clock.NewFake(), testKeyPolicy, map[string][]byte{
+		"http://localhost:4000/acme/issuer-cert":  caChain,
+		"http://localhost:4000/acme/issuer-cert2": ca2Chain,
+	}, nil, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Unable to create WFE")
+
+	leafKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	test.AssertNotError(t, err, "Unable to generate leaf key")
+	leafFor := func(aiaIssuerURL string) *x509.Certificate {
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			NotBefore:             ca2.NotBefore,
+			NotAfter:              ca2.NotAfter,
+			IssuingCertificateURL: []string{aiaIssuerURL},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, ca2, leafKey.Public(), issuerKey)
+		test.AssertNotError(t, err, "Unable to create leaf cert")
+		leaf, err := x509.ParseCertificate(der)
+		test.AssertNotError(t, err, "Unable to parse leaf cert")
+		return leaf
+	}
+
+	// A certificate gets its issuer's chain even when its AIA issuer URL
+	// names another issuer's chain.
+	for _, aiaIssuerURL := range []string{
+		"http://localhost:4000/acme/issuer-cert",
+		"http://localhost:4000/acme/issuer-cert2",
+		"http://localhost:4000/acme/unknown",
+	} {
+		chain, ok := wfe.chainFor(leafFor(aiaIssuerURL))
+		test.Assert(t, ok, "No chain for certificate with AIA issuer URL "+aiaIssuerURL)
+		test.AssertByteEquals(t, chain, ca2Chain)
+	}
+
+	// Without a chain starting with its issuer, a certificate gets no chain,
+	// even if one is configured for its AIA issuer URL.
+	wfe, err = NewWebFrontEndImpl(metrics.NewNoopScope(), clock.NewFake(), testKeyPolicy, map[string][]byte{
+		"http://localhost:4000/acme/issuer-cert": caChain,
+	}, nil, nil, blog.NewMock())
+	test.AssertNotError(t, err, "Unable to create WFE")
+	_, ok := wfe.chainFor(leafFor("http://localhost:4000/acme/issuer-cert"))
+	test.Assert(t, !ok, "Chain for certificate with an unknown issuer and a configured AIA issuer URL")
+	_, ok = wfe.chainFor(leafFor("http://localhost:4000/acme/unknown"))
+	test.Assert(t, !ok, "Chain for certificate with an unknown issuer and AIA issuer URL")
+
+	_, err = NewWebFrontEndImpl(metrics.NewNoopScope(), clock.NewFake(), testKeyPolicy, map[string][]byte{
+		"http://localhost:4000/acme/issuer-cert": []byte("not a chain"),
+	}, nil, nil, blog.NewMock())
+	test.AssertError(t, err, "NewWebFrontEndImpl accepted a chain without certificates")
+}
+
 func TestGetCertificateHEADHasCorrectBodyLength(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -2072,6 +2241,9 @@ func TestDeactivateAccount(t *testing.T) {
 
 func TestNewOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
+	_ = features.Set(map[string]bool{"CertificateProfiles": true})
+	defer features.Reset()
+	wfe.CertProfiles = map[string]string{"shortlived": "Certificates valid for a week"}
 	responseWriter := httptest.NewRecorder()
 
 	targetHost := "localhost"
@@ -2131,13 +2303,62 @@ func TestNewOrder(t *testing.T) {
 		{
 			Name:         "POST, invalid identifier in payload",
 			Request:      signAndPost(t, targetPath, signedURL, nonDNSIdentifierBody, 1, wfe.nonceService),
-			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included invalid non-DNS type identifier: type \"fakeID\", value \"www.i-am-21.com\"","status":400}`,
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included invalid identifier type: type \"fakeID\", value \"www.i-am-21.com\"","status":400}`,
+		},
+		{
+			Name:         "POST, DNS identifier for an IP address in payload",
+			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "10.0.0.1"}]}`, 1, wfe.nonceService),
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included invalid dns identifier value \"10.0.0.1\"","status":400}`,
+		},
+		{
+			Name:         "POST, IP identifier for a domain name in payload",
+			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "ip", "value": "not-example.com"}]}`, 1, wfe.nonceService),
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request included invalid ip identifier value \"not-example.com\"","status":400}`,
+		},
+		{
+			Name:    "POST, good payload with IP identifiers",
+			Request: signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}, {"type": "ip", "value": "10.0.0.1"}]}`, 1, wfe.nonceService),
+			ExpectedBody: `
+					{
+						"status": "pending",
+						"expires": "1970-01-01T00:00:00Z",
+						"identifiers": [
+							{ "type": "dns", "value": "not-example.com"},
+							{ "type": "ip", "value": "10.0.0.1"}
+						],
+						"authorizations": [
+							"http://localhost/acme/authz/hello"
+						],
+						"finalize": "http://localhost/acme/finalize/1/1"
+					}`,
 		},
 		{
 			Name:         "POST, notAfter and notBefore in payload",
 			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "notBefore":"now", "notAfter": "later"}`, 1, wfe.nonceService),
 			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NotBefore and NotAfter are not supported","status":400}`,
 		},
+		{
+			Name:         "POST, unsupported profile in payload",
+			Request:      signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "profile": "forever"}`, 1, wfe.nonceService),
+			ExpectedBody: `{"type":"` + probs.V2ErrorNS + `malformed","detail":"NewOrder request specified unsupported profile \"forever\"","status":400}`,
+		},
+		{
+			Name:    "POST, good payload with profile",
+			Request: signAndPost(t, targetPath, signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "profile": "shortlived"}`, 1, wfe.nonceService),
+			ExpectedBody: `
+					{
+						"status": "pending",
+						"expires": "1970-01-01T00:00:00Z",
+						"identifiers": [
+							{ "type": "dns", "value": "not-example.com"}
+						],
+						"authorizations": [
+							"http://localhost/acme/authz/hello"
+						],
+						"finalize": "http://localhost/acme/finalize/1/1",
+						"profile": "shortlived"
+					}`,
+		},
 		{
 			Name:    "POST, good payload",
 			Request: signAndPost(t, targetPath, signedURL, validOrderBody, 1, wfe.nonceService),
@@ -2420,6 +2641,34 @@ func TestKeyRollover(t *testing.T) {
 	}
 }
 
+func TestKeyRolloverEd25519(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	wfe.SA = &mockSAGetRegByKeyNotFound{mocks.NewStorageAuthority(fc)}
+	wfe.keyPolicy.AllowEd25519 = true
+
+	_, newKey, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate Ed25519 key")
+	newJWKJSON, err := jose.JSONWebKey{Key: newKey.Public()}.MarshalJSON()
+	test.AssertNotError(t, err, "Failed to marshal JWK JSON")
+
+	payload := `{"oldKey":` + test1KeyPublicJSON + `,"account":"http://localhost/acme/acct/1"}`
+	_, _, inner := signRequestEmbed(t, newKey, "http://localhost/key-change", payload, wfe.nonceService)
+	_, _, outer := signRequestKeyID(t, 1, nil, "http://localhost/key-change", inner, wfe.nonceService)
+	responseWriter := httptest.NewRecorder()
+	wfe.KeyRollover(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("key-change", outer))
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"id": 1,
+		"key": `+string(newJWKJSON)+`,
+		"contact": [
+			"mailto:person@mail.com"
+		],
+		"agreement": "http://example.invalid/terms",
+		"initialIp": "",
+		"createdAt": "0001-01-01T00:00:00Z",
+		"status": "valid"
+	}`)
+}
+
 func TestGetOrder(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -2627,6 +2876,49 @@ func TestRevokeCertificateReasons(t *testing.T) {
 	}
 }
 
+// With RevokeCertsByKey enabled, a revocation request signed with the cert key
+// is treated as keyCompromise regardless of the requested reason, and the
+// response lists every certificate revoked because it uses the key.
+func TestRevokeCertificateByKeyCompromise(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	err := features.Set(map[string]bool{"RevokeCertsByKey": true})
+	test.AssertNotError(t, err, "Failed to set feature flags")
+	defer features.Reset()
+
+	wfe.AcceptRevocationReason = true
+	ra := wfe.RA.(*MockRegistrationAuthority)
+	wfe.SA = &mockSANoSuchRegistration{mocks.NewStorageAuthority(fc)}
+
+	keyPemBytes, err := ioutil.ReadFile("test/238.key")
+	test.AssertNotError(t, err, "Failed to load key")
+	key := loadKey(t, keyPemBytes)
+
+	reason := revocation.Reason(1)
+	revokeRequestJSON, err := makeRevokeRequestJSON(&reason)
+	test.AssertNotError(t, err, "Failed to make revokeRequestJSON")
+	_, _, jwsBody := signRequestEmbed(t, key, "http://localhost/revoke-cert", string(revokeRequestJSON), wfe.nonceService)
+
+	responseWriter := httptest.NewRecorder()
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
+		makePostRequestWithPath("revoke-cert", jwsBody))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"reason":1,"revokedCertificates":["0000000000000000000000000000000000ee","00000000000000000000000000000000beef"]}`)
+	test.Assert(t, ra.lastRevokedByKey != nil, "RevokeCertByKey wasn't called")
+	test.AssertEquals(t, ra.lastRevocationReason, revocation.Reason(revocation.KeyCompromise))
+
+	// Requests signed by an account are unaffected.
+	ra.lastRevokedByKey = nil
+	_, _, jwsBody = signRequestKeyID(t, 1, nil, "http://localhost/revoke-cert", string(revokeRequestJSON), wfe.nonceService)
+	responseWriter = httptest.NewRecorder()
+	wfe.RevokeCertificate(ctx, newRequestEvent(), responseWriter,
+		makePostRequestWithPath("revoke-cert", jwsBody))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Body.String(), "")
+	test.Assert(t, ra.lastRevokedByKey == nil, "RevokeCertByKey was called for an account-signed request")
+	test.AssertEquals(t, ra.lastRevocationReason, reason)
+}
+
 // Valid revocation request for existing, non-revoked cert, signed with account
 // that issued the cert.
 func TestRevokeCertificateIssuingAccount(t *testing.T) {
@@ -2837,6 +3129,40 @@ func TestNewAccountWhenGetRegByKeyNotFound(t *testing.T) {
 	}`)
 }
 
+func TestNewAccountEd25519(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	wfe.SA = &mockSAGetRegByKeyNotFound{mocks.NewStorageAuthority(fc)}
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	test.AssertNotError(t, err, "Failed to generate Ed25519 key")
+
+	payload := `{"contact":["mailto:person@mail.com"],"termsOfServiceAgreed":true}`
+	signedURL := "http://localhost/new-account"
+	newAccount := func() *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		_, _, body := signRequestEmbed(t, key, signedURL, payload, wfe.nonceService)
+		wfe.NewAccount(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("/new-account", body))
+		return responseWriter
+	}
+
+	// Ed25519 account keys are refused unless the key policy allows them
+	responseWriter := newAccount()
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"type": "`+probs.V2ErrorNS+`badPublicKey",
+		"detail": "Ed25519 keys are not allowed",
+		"status": 400
+	}`)
+
+	wfe.keyPolicy.AllowEd25519 = true
+	responseWriter = newAccount()
+	if responseWriter.Code != http.StatusCreated {
+		t.Errorf("Bad response to NewAccount: %d, %s", responseWriter.Code, responseWriter.Body)
+	}
+	var acct core.Registration
+	err = json.Unmarshal(responseWriter.Body.Bytes(), &acct)
+	test.AssertNotError(t, err, "Couldn't unmarshal returned account object")
+	test.AssertDeepEquals(t, acct.Key.Key, key.Public())
+}
+
 func TestPrepAuthzForDisplay(t *testing.T) {
 	wfe, _ := setupWFE(t)
 
@@ -2887,6 +3213,33 @@ func TestPrepAuthzForDisplay(t *testing.T) {
 	test.AssertEquals(t, chal.URI, "")
 }
 
+func TestPrepAuthzForDisplayDNSAccount01(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	chall := core.DNSAccountChallenge01("")
+	chall.ProvidedKeyAuthorization = chall.Token + ".thumbprint"
+	authz := &core.Authorization{
+		ID:             "12345",
+		Status:         core.StatusPending,
+		RegistrationID: 1,
+		Identifier:     core.AcmeIdentifier{Type: "dns", Value: "*.example.com"},
+		Challenges:     []core.Challenge{chall},
+		V2:             true,
+	}
+	wfe.prepAuthorizationForDisplay(&http.Request{Host: "localhost"}, authz)
+
+	// The challenge is offered like any other, without the key authorization.
+	// The client derives the TXT record label from its own account URL.
+	challJSON, err := json.Marshal(authz.Challenges[0])
+	test.AssertNotError(t, err, "Failed to marshal challenge")
+	test.AssertUnmarshaledEquals(t, string(challJSON), `{
+		"type": "dns-account-01",
+		"status": "pending",
+		"url": "http://localhost/acme/challenge/v2/12345/`+chall.StringID()+`",
+		"token": "`+chall.Token+`"
+	}`)
+}
+
 // noSCTMockRA is a mock RA that always returns a `berrors.MissingSCTsError` from `FinalizeOrder`
 type noSCTMockRA struct {
 	MockRegistrationAuthority
@@ -2994,3 +3347,257 @@ func TestChallengeNewIDScheme(t *testing.T) {
 			tc.expected)
 	}
 }
+
+func TestDirectoryRenewalInfo(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	mux := wfe.Handler()
+
+	_ = features.Set(map[string]bool{"ServeRenewalInfo": true})
+	defer features.Reset()
+
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL(directoryPath),
+		Host:   "localhost:4300",
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	var directory map[string]interface{}
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &directory)
+	test.AssertNotError(t, err, "Failed to unmarshal directory")
+	test.AssertEquals(t, directory["renewalInfo"], "http://localhost:4300/acme/renewal-info/")
+}
+
+func TestNewOrderProfileDisabled(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.CertProfiles = map[string]string{"shortlived": "Certificates valid for a week"}
+	responseWriter := httptest.NewRecorder()
+
+	signedURL := "http://localhost/new-order"
+	request := signAndPost(t, "new-order", signedURL, `{"identifiers":[{"type": "dns", "value": "not-example.com"}], "profile": "shortlived"}`, 1, wfe.nonceService)
+	wfe.NewOrder(ctx, newRequestEvent(), responseWriter, request)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(),
+		`{"type":"`+probs.V2ErrorNS+`malformed","detail":"NewOrder request specified a profile but profiles are not supported","status":400}`)
+}
+
+func TestDirectoryCertProfiles(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	_ = features.Set(map[string]bool{"CertificateProfiles": true})
+	defer features.Reset()
+	wfe.CertProfiles = map[string]string{
+		"classic":    "Certificates valid for 90 days",
+		"shortlived": "Certificates valid for a week",
+	}
+	mux := wfe.Handler()
+
+	responseWriter := httptest.NewRecorder()
+	mux.ServeHTTP(responseWriter, &http.Request{
+		Method: "GET",
+		URL:    mustParseURL(directoryPath),
+		Host:   "localhost:4300",
+	})
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	var directory struct {
+		Meta struct {
+			Profiles map[string]string `json:"profiles"`
+		} `json:"meta"`
+	}
+	err := json.Unmarshal(responseWriter.Body.Bytes(), &directory)
+	test.AssertNotError(t, err, "Failed to unmarshal directory")
+	test.AssertDeepEquals(t, directory.Meta.Profiles, wfe.CertProfiles)
+}
+
+// mockSARevokedCertificate is a mock SA that reports every certificate as
+// revoked.
+type mockSARevokedCertificate struct {
+	core.StorageGetter
+}
+
+func (msa mockSARevokedCertificate) GetCertificateStatus(_ context.Context, _ string) (core.CertificateStatus, error) {
+	return core.CertificateStatus{Status: core.OCSPStatusRevoked}, nil
+}
+
+func TestRenewalInfo(t *testing.T) {
+	wfe, fc := setupWFE(t)
+	mux := wfe.Handler()
+
+	_ = features.Set(map[string]bool{"ServeRenewalInfo": true})
+	defer features.Reset()
+
+	// test/238.crt has serial 0xee and this authority key identifier.
+	akid := "jog7s8eJhAvSKMvu6xHZxPnnjsg"
+	certID := akid + ".7g"
+
+	getRenewalInfo := func(path string) *httptest.ResponseRecorder {
+		responseWriter := httptest.NewRecorder()
+		mux.ServeHTTP(responseWriter, &http.Request{
+			Method: "GET",
+			URL:    mustParseURL(renewalInfoPath + path),
+		})
+		return responseWriter
+	}
+	parseRenewalInfo := func(responseWriter *httptest.ResponseRecorder) core.RenewalInfo {
+		test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+		test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "21600")
+		var ri core.RenewalInfo
+		err := json.Unmarshal(responseWriter.Body.Bytes(), &ri)
+		test.AssertNotError(t, err, "Failed to unmarshal renewalInfo")
+		return ri
+	}
+
+	// A good certificate gets a window two thirds of the way through its
+	// validity period.
+	ri := parseRenewalInfo(getRenewalInfo(certID))
+	notBefore := time.Date(2015, 6, 13, 0, 15, 55, 0, time.UTC)
+	notAfter := time.Date(2016, 6, 12, 0, 15, 55, 0, time.UTC)
+	test.AssertDeepEquals(t, ri, core.RenewalInfoSimple(notBefore, notAfter))
+
+	// Malformed identifiers are rejected.
+	for _, path := range []string{"", "7g", akid + ".", akid + ".7g.7g", "!!!.7g", akid + ".!!!"} {
+		responseWriter := getRenewalInfo(path)
+		test.AssertEquals(t, responseWriter.Code, http.StatusBadRequest)
+	}
+
+	// Unknown certificates, and known serials with a different authority key
+	// identifier, are not found.
+	test.AssertEquals(t, getRenewalInfo(akid+".AQ").Code, http.StatusNotFound)
+	test.AssertEquals(t, getRenewalInfo("AAAA.7g").Code, http.StatusNotFound)
+
+	// A certificate flagged for mass revocation should be renewed immediately.
+	err := wfe.loadMassRevocations([]byte(`{"serials": ["0000000000000000000000000000000000ee"]}`))
+	test.AssertNotError(t, err, "Failed to load mass revocation list")
+	ri = parseRenewalInfo(getRenewalInfo(certID))
+	test.AssertDeepEquals(t, ri, core.RenewalInfoImmediate(fc.Now()))
+	err = wfe.loadMassRevocations([]byte(`{"serials": []}`))
+	test.AssertNotError(t, err, "Failed to load mass revocation list")
+
+	// So should a revoked certificate.
+	wfe.SA = mockSARevokedCertificate{mocks.NewStorageAuthority(fc)}
+	mux = wfe.Handler()
+	ri = parseRenewalInfo(getRenewalInfo(certID))
+	test.AssertDeepEquals(t, ri, core.RenewalInfoImmediate(fc.Now()))
+
+	// With the feature disabled the endpoint doesn't exist.
+	features.Reset()
+	test.AssertEquals(t, getRenewalInfo(certID).Code, http.StatusNotFound)
+}
+
+func TestLoadMassRevocations(t *testing.T) {
+	wfe, _ := setupWFE(t)
+
+	err := wfe.loadMassRevocations([]byte(`{"serials": ["0000000000000000000000000000000000ee"]}`))
+	test.AssertNotError(t, err, "Failed to load mass revocation list")
+	test.Assert(t, wfe.massRevocations.contains("0000000000000000000000000000000000ee"), "serial should be flagged")
+	test.Assert(t, !wfe.massRevocations.contains("0000000000000000000000000000000000b2"), "serial shouldn't be flagged")
+
+	err = wfe.loadMassRevocations([]byte(`{"serials": ["nope"]}`))
+	test.AssertError(t, err, "Loaded mass revocation list with an invalid serial")
+	err = wfe.loadMassRevocations([]byte(`not json`))
+	test.AssertError(t, err, "Loaded mass revocation list that isn't JSON")
+	// A failed load leaves the previous list in place.
+	test.Assert(t, wfe.massRevocations.contains("0000000000000000000000000000000000ee"), "serial should still be flagged")
+}
+
+func TestAccountOrders(t *testing.T) {
+	wfe, _ := setupWFE(t)
+	wfe.OrdersPerPage = 4
+
+	postAsGet := func(acctID int64, key interface{}, path string) *httptest.ResponseRecorder {
+		_, _, body := signRequestKeyID(t, acctID, key, "http://localhost/"+path, "", wfe.nonceService)
+		responseWriter := httptest.NewRecorder()
+		wfe.AccountOrders(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath(path, body))
+		return responseWriter
+	}
+
+	// The endpoint doesn't exist without the feature.
+	responseWriter := postAsGet(1, nil, "1")
+	test.AssertEquals(t, responseWriter.Code, http.StatusNotFound)
+
+	_ = features.Set(map[string]bool{"ListAccountOrders": true})
+	defer features.Reset()
+
+	// The mock SA has orders 10 down to 1 for every account, so with four
+	// orders per page there are three pages.
+	responseWriter = postAsGet(1, nil, "1")
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"orders":[
+		"http://localhost/acme/order/1/10",
+		"http://localhost/acme/order/1/9",
+		"http://localhost/acme/order/1/8",
+		"http://localhost/acme/order/1/7"]}`)
+	test.AssertEquals(t, responseWriter.Header().Get("Link"),
+		`<http://localhost/acme/orders/1/7>;rel="next"`)
+
+	responseWriter = postAsGet(1, nil, "1/7")
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertEquals(t, responseWriter.Header().Get("Link"),
+		`<http://localhost/acme/orders/1/3>;rel="next"`)
+
+	responseWriter = postAsGet(1, nil, "1/3")
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{"orders":[
+		"http://localhost/acme/order/1/2",
+		"http://localhost/acme/order/1/1"]}`)
+	test.AssertEquals(t, responseWriter.Header().Get("Link"), "")
+
+	// Accounts can only list their own orders.
+	altKey := loadKey(t, []byte(test2KeyPrivatePEM))
+	responseWriter = postAsGet(2, altKey, "1")
+	test.AssertEquals(t, responseWriter.Code, http.StatusForbidden)
+
+	for _, path := range []string{"1/0", "1/x", "x", "1/2/3"} {
+		responseWriter = postAsGet(1, nil, path)
+		test.Assert(t, responseWriter.Code >= 400, fmt.Sprintf("request for %q didn't fail", path))
+	}
+
+	// Accounts include the URL of their orders list.
+	_, _, body := signRequestKeyID(t, 1, nil, "http://localhost/1", "", wfe.nonceService)
+	responseWriter = httptest.NewRecorder()
+	wfe.Account(ctx, newRequestEvent(), responseWriter, makePostRequestWithPath("1", body))
+	test.AssertEquals(t, responseWriter.Code, http.StatusOK)
+	test.AssertContains(t, responseWriter.Body.String(), `"orders": "http://localhost/acme/orders/1"`)
+}
+
+func TestSendErrorRateLimit(t *testing.T) {
+	wfe, fc := setupWFE(t)
+
+	resetAt := fc.Now().Add(90*time.Minute + 500*time.Millisecond)
+	err := berrors.RateLimitExceededError("certificatesPerName", "example.com", resetAt,
+		"too many certificates already issued for: example.com")
+	responseWriter := httptest.NewRecorder()
+	wfe.sendError(responseWriter, newRequestEvent(), web.ProblemDetailsForError(err, "Error creating new order"), err)
+
+	test.AssertEquals(t, responseWriter.Code, 429)
+	// Retry-After is rounded up to a whole second.
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "5401")
+	resetJSON, _ := json.Marshal(resetAt.UTC())
+	test.AssertUnmarshaledEquals(t, responseWriter.Body.String(), `{
+		"type": "urn:ietf:params:acme:error:rateLimited",
+		"detail": "Error creating new order :: too many certificates already issued for: example.com: see https://letsencrypt.org/docs/rate-limits/",
+		"status": 429,
+		"subproblems": [{
+			"type": "urn:ietf:params:acme:error:rateLimited",
+			"detail": "certificatesPerName limit exceeded for \"example.com\"",
+			"limit": "certificatesPerName",
+			"key": "example.com",
+			"resetAt": `+string(resetJSON)+`
+		}]
+	}`)
+
+	// Without a known reset time neither Retry-After nor resetAt is sent.
+	responseWriter = httptest.NewRecorder()
+	err = berrors.RateLimitExceededError("pendingAuthorizationsPerAccount", "1", time.Time{},
+		"too many currently pending authorizations")
+	wfe.sendError(responseWriter, newRequestEvent(), web.ProblemDetailsForError(err, "Error creating new authz"), err)
+	test.AssertEquals(t, responseWriter.Code, 429)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "")
+	test.AssertContains(t, responseWriter.Body.String(), `"limit": "pendingAuthorizationsPerAccount"`)
+	test.AssertNotContains(t, responseWriter.Body.String(), "resetAt")
+
+	// Rate limit errors without details are sent as before.
+	responseWriter = httptest.NewRecorder()
+	err = berrors.RateLimitError("too many things")
+	wfe.sendError(responseWriter, newRequestEvent(), web.ProblemDetailsForError(err, "Error"), err)
+	test.AssertEquals(t, responseWriter.Header().Get("Retry-After"), "")
+	test.AssertNotContains(t, responseWriter.Body.String(), "subproblems")
+}